@@ -0,0 +1,230 @@
+// ! package accesslog --> one line per request: method, path, status, bytes, latency, user, client IP + request ID
+// ? busy routes are sampled (ACCESS_LOG_SAMPLE), server errors are always logged;
+// ? at LOG_LEVEL=debug a DEBUG: line with the request headers + body follows, credentials redacted
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fem/internal/logging"
+	"fem/internal/middleware"
+	"fem/internal/utils"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! MaxBody --> request bodies are dumped up to this many bytes, the handler still reads all of it
+const MaxBody = 4 << 10
+
+// ! Redacted --> replaces header values and body fields that carry credentials
+const Redacted = "[REDACTED]"
+
+// * redactedHeaders --> canonical names, never dumped as sent
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+	"X-Scim-Token":        true,
+}
+
+// ! Rule --> requests whose path starts with Prefix are logged Percent (0-100) of the time
+type Rule struct {
+	Prefix  string
+	Percent int
+}
+
+// ! ParseSampling --> "/health=0,/workouts=10"; the longest matching prefix wins, unmatched paths are always logged
+func ParseSampling(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		prefix, raw, ok := strings.Cut(part, "=")
+		percent, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || !strings.HasPrefix(prefix, "/") || err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("ACCESS_LOG_SAMPLE: %q, want /path=percent with percent between 0 and 100", part)
+		}
+		rules = append(rules, Rule{Prefix: strings.TrimSpace(prefix), Percent: percent})
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].Prefix) > len(rules[j].Prefix) })
+	return rules, nil
+}
+
+// ! Logger --> root pipeline middleware, see Middleware
+type Logger struct {
+	Logger *log.Logger
+	Levels *logging.LevelWriter //* nil --> bodies are never dumped
+	Rules  []Rule               //* longest prefix first, as ParseSampling returns them
+}
+
+// ! New --> sampling is ParseSampling's spec, levels decides whether the debug dump is worth building
+func New(sampling string, logger *log.Logger, levels *logging.LevelWriter) (*Logger, error) {
+	rules, err := ParseSampling(sampling)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{Logger: logger, Levels: levels, Rules: rules}, nil
+}
+
+func (l *Logger) percent(path string) int {
+	for _, rule := range l.Rules {
+		if strings.HasPrefix(path, rule.Prefix) {
+			return rule.Percent
+		}
+	}
+	return 100
+}
+
+type userKey struct{}
+
+// ! User --> user pipeline middleware, hands the authenticated user's ID back to the access log line
+// ? the root middleware runs before authentication, so it leaves a slot on the context for this to fill
+func User(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slot, ok := r.Context().Value(userKey{}).(*int); ok {
+			if user := middleware.GetUser(r); !user.IsAnonymousUser() {
+				*slot = user.ID
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ! Middleware --> logs the request once the handler returned
+// ? sampling is decided up front so unsampled requests don't pay for the body dump
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		percent := l.percent(r.URL.Path)
+		sampled := percent >= 100 || rand.IntN(100) < percent
+		var dump string
+		if sampled && l.Levels != nil && l.Levels.Enabled(logging.LevelDebug) {
+			dump = dumpRequest(r)
+		}
+
+		userID := 0
+		r = r.WithContext(context.WithValue(r.Context(), userKey{}, &userID))
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(sw, r)
+		elapsed := time.Since(start)
+
+		if !sampled && sw.status < http.StatusInternalServerError {
+			return
+		}
+		user := "-"
+		if userID != 0 {
+			user = strconv.Itoa(userID)
+		}
+		requestID := utils.RequestIDFrom(r.Context())
+		if requestID == "" {
+			requestID = "-"
+		}
+		l.Logger.Printf("access: %s %s status=%d bytes=%d latency=%s user=%s ip=%s req=%s",
+			r.Method, r.URL.Path, sw.status, sw.bytes, elapsed.Round(time.Microsecond), user, middleware.ClientIP(r), requestID)
+		if dump != "" {
+			l.Logger.Printf("DEBUG: access %s %s %s", r.Method, r.URL.Path, dump)
+		}
+	})
+}
+
+// * dumpRequest --> headers + the first MaxBody bytes of the body, r.Body is put back for the handler
+func dumpRequest(r *http.Request) string {
+	header := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		header[name] = strings.Join(values, ", ")
+		if redactedHeaders[name] {
+			header[name] = Redacted
+		}
+	}
+	out := map[string]any{"headers": header}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		head, err := io.ReadAll(io.LimitReader(r.Body, MaxBody))
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), r.Body), Closer: r.Body}
+		if err == nil && len(head) > 0 {
+			out["body"] = redactBody(head)
+		}
+	}
+
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ! redactBody --> JSON bodies with credential fields replaced, anything else (forms, uploads, cut off JSON) only by size
+func redactBody(body []byte) any {
+	var parsed any
+	if json.Unmarshal(body, &parsed) != nil {
+		return fmt.Sprintf("%d bytes", len(body))
+	}
+	return redactValue(parsed)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if sensitive(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// * sensitive --> password, new_password, client_secret, refresh_token, totp code ...
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret") || strings.Contains(key, "token") || key == "code"
+}
+
+// * statusWriter --> remembers the status + counts the body bytes for the log line
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status, w.wrote = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// * Unwrap --> http.ResponseController reaches Flush + Hijack on the real writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package accesslog
+
+import (
+	"bytes"
+	"fem/internal/logging"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSampling(t *testing.T) {
+	rules, err := ParseSampling(" /v1=50, /v1/workouts=10,/health=0")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{"/v1/workouts", 10}, {"/health", 0}, {"/v1", 50}}, rules)
+
+	for _, spec := range []string{"/health", "health=0", "/health=101", "/health=x"} {
+		_, err := ParseSampling(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	levels := logging.NewLevelWriter(&out, logging.LevelInfo)
+	l, err := New("/health=0", log.New(levels, "", 0), levels)
+	require.NoError(t, err)
+
+	var body string
+	inner := User(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	//* stands in for authenticate, which runs between the two in the real pipelines
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(w, middleware.SetUser(r, &store.User{ID: 7}))
+	}))
+	serve := func(path, payload string) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer secret-token")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve("/v1/tokens/authentication", `{"username":"sam","password":"hunter2"}`)
+	assert.Contains(t, out.String(), "access: POST /v1/tokens/authentication status=200 bytes=5")
+	assert.Contains(t, out.String(), "user=7")
+	assert.NotContains(t, out.String(), "DEBUG:")
+
+	out.Reset()
+	serve("/health", "")
+	assert.Empty(t, out.String(), "sampled out")
+	serve("/health/broken", "")
+	assert.Contains(t, out.String(), "access: POST /health/broken status=500", "server errors skip sampling")
+	out.Reset()
+
+	levels.SetLevel(logging.LevelDebug)
+	serve("/v1/tokens/authentication", `{"username":"sam","password":"hunter2","nested":[{"refresh_token":"abc"}]}`)
+	assert.Contains(t, body, "hunter2", "the handler still reads the whole body")
+	assert.Contains(t, out.String(), `"username":"sam"`)
+	assert.Contains(t, out.String(), `"Authorization":"[REDACTED]"`)
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "secret-token")
+	assert.NotContains(t, out.String(), "abc")
+}
@@ -0,0 +1,27 @@
+package achievements
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+// ! badgeTemplate --> shareable SVG badge, html/template escapes names for us
+var badgeTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="240" height="280" viewBox="0 0 240 280">
+  <circle cx="120" cy="110" r="96" fill="{{.Color}}"/>
+  <circle cx="120" cy="110" r="80" fill="none" stroke="#ffffff" stroke-width="4"/>
+  <text x="120" y="118" font-family="Helvetica, Arial, sans-serif" font-size="22" font-weight="bold" fill="#ffffff" text-anchor="middle">{{.Name}}</text>
+  <text x="120" y="236" font-family="Helvetica, Arial, sans-serif" font-size="16" fill="#333333" text-anchor="middle">{{.Username}}</text>
+  <text x="120" y="260" font-family="Helvetica, Arial, sans-serif" font-size="12" fill="#777777" text-anchor="middle">{{.EarnedAt}}</text>
+</svg>
+`))
+
+// ! WriteBadge --> renders the badge for a user who earned the rule
+func WriteBadge(w io.Writer, rule *Rule, username string, earnedAt time.Time) error {
+	return badgeTemplate.Execute(w, map[string]string{
+		"Color":    rule.Color,
+		"Name":     rule.Name,
+		"Username": username,
+		"EarnedAt": "Earned " + earnedAt.Format("Jan 2, 2006"),
+	})
+}
@@ -0,0 +1,78 @@
+package achievements
+
+import (
+	"fem/internal/events"
+	"fem/internal/store"
+	"log"
+	"slices"
+)
+
+// ! Engine --> evaluates achievement rules when domain events arrive
+type Engine struct {
+	Rules  []*Rule
+	Store  store.AchievementStore
+	Logger *log.Logger
+	Bus    *events.Bus //* set by Subscribe, new awards are published back as AchievementEarned
+}
+
+// ! NewEngine --> constructor with the default rule set
+func NewEngine(achievementStore store.AchievementStore, logger *log.Logger) *Engine {
+	return &Engine{Rules: DefaultRules(), Store: achievementStore, Logger: logger}
+}
+
+// ! Subscribe --> hooks the engine up to every event type a rule listens for
+func (e *Engine) Subscribe(bus *events.Bus) {
+	e.Bus = bus
+	types := []string{}
+	for _, rule := range e.Rules {
+		for _, t := range rule.Events {
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+	}
+	bus.Subscribe(e.HandleEvent, types...)
+}
+
+// ! Rule --> looks up a rule by key, nil when unknown
+func (e *Engine) Rule(key string) *Rule {
+	for _, rule := range e.Rules {
+		if rule.Key == key {
+			return rule
+		}
+	}
+	return nil
+}
+
+// ! HandleEvent --> events.Handler that evaluates the user's rules
+func (e *Engine) HandleEvent(event events.Event) error {
+	_, err := e.Evaluate(event.UserID, event.Type)
+	return err
+}
+
+// ! Evaluate --> awards every rule listening to eventType that the user now satisfies, returns the new ones
+func (e *Engine) Evaluate(userID int, eventType string) ([]*Rule, error) {
+	stats, err := e.Store.GetAchievementStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	awarded := []*Rule{}
+	for _, rule := range e.Rules {
+		if !slices.Contains(rule.Events, eventType) || !rule.Earned(stats) {
+			continue
+		}
+		isNew, err := e.Store.AwardAchievement(userID, rule.Key)
+		if err != nil {
+			return awarded, err
+		}
+		if isNew {
+			e.Logger.Printf("achievement %s awarded to user %d", rule.Key, userID)
+			awarded = append(awarded, rule)
+			if e.Bus != nil {
+				e.Bus.Publish(events.Event{Type: events.AchievementEarned, UserID: userID, Ref: rule.Key})
+			}
+		}
+	}
+	return awarded, nil
+}
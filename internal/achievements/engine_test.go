@@ -0,0 +1,74 @@
+package achievements
+
+import (
+	"bytes"
+	"fem/internal/events"
+	"fem/internal/store"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * memoryStore --> in-memory AchievementStore for engine tests
+type memoryStore struct {
+	stats   *store.AchievementStats
+	awarded map[string]bool
+}
+
+func (m *memoryStore) GetAchievementStats(userID int) (*store.AchievementStats, error) {
+	return m.stats, nil
+}
+
+func (m *memoryStore) AwardAchievement(userID int, key string) (bool, error) {
+	if m.awarded[key] {
+		return false, nil
+	}
+	m.awarded[key] = true
+	return true, nil
+}
+
+func (m *memoryStore) ListAchievements(userID int) ([]*store.UserAchievement, error) {
+	return nil, nil
+}
+
+func (m *memoryStore) GetAchievement(userID int, key string) (*store.UserAchievement, error) {
+	return nil, nil
+}
+
+// ! TestEvaluate --> rules unlock once and only when their threshold is met
+func TestEvaluate(t *testing.T) {
+	memory := &memoryStore{
+		stats:   &store.AchievementStats{TotalWorkouts: 3, LongestStreakDays: 7, MaxWeightKG: 95, TotalVolumeKG: 10000},
+		awarded: map[string]bool{},
+	}
+	engine := NewEngine(memory, log.New(&bytes.Buffer{}, "", 0))
+
+	awarded, err := engine.Evaluate(1, events.WorkoutCreated)
+	require.NoError(t, err)
+	keys := []string{}
+	for _, rule := range awarded {
+		keys = append(keys, rule.Key)
+	}
+	assert.Equal(t, []string{"first_workout", "7_day_streak", "10000kg_lifted"}, keys)
+
+	awarded, err = engine.Evaluate(1, events.WorkoutCreated)
+	require.NoError(t, err)
+	assert.Empty(t, awarded) // * already earned
+
+	awarded, err = engine.Evaluate(1, events.WorkoutDeleted)
+	require.NoError(t, err)
+	assert.Empty(t, awarded) // * no rule listens to deletes
+}
+
+// ! TestWriteBadge --> user supplied names are escaped in the SVG
+func TestWriteBadge(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteBadge(&buf, DefaultRules()[0], "<script>", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "&lt;script&gt;")
+	assert.Contains(t, buf.String(), `fill="#4caf50"`)
+	assert.Contains(t, buf.String(), "Earned Jan 2, 2024")
+}
@@ -0,0 +1,64 @@
+package achievements
+
+import (
+	"fem/internal/events"
+	"fem/internal/store"
+)
+
+// ! Rule --> one achievement, Earned decides from the user's stats whether it's unlocked
+type Rule struct {
+	Key         string                                   `json:"key"`
+	Name        string                                   `json:"name"`
+	Description string                                   `json:"description"`
+	Color       string                                   `json:"-"` //* badge background
+	Events      []string                                 `json:"-"` //* event types that can unlock it
+	Earned      func(stats *store.AchievementStats) bool `json:"-"`
+}
+
+// ! DefaultRules --> the built-in achievement catalogue
+func DefaultRules() []*Rule {
+	workoutEvents := []string{events.WorkoutCreated, events.WorkoutUpdated}
+
+	return []*Rule{
+		{
+			Key:         "first_workout",
+			Name:        "First Workout",
+			Description: "Logged your first workout",
+			Color:       "#4caf50",
+			Events:      workoutEvents,
+			Earned:      func(s *store.AchievementStats) bool { return s.TotalWorkouts >= 1 },
+		},
+		{
+			Key:         "100_workouts",
+			Name:        "Centurion",
+			Description: "Logged 100 workouts",
+			Color:       "#3f51b5",
+			Events:      workoutEvents,
+			Earned:      func(s *store.AchievementStats) bool { return s.TotalWorkouts >= 100 },
+		},
+		{
+			Key:         "7_day_streak",
+			Name:        "7-Day Streak",
+			Description: "Trained 7 days in a row",
+			Color:       "#ff9800",
+			Events:      workoutEvents,
+			Earned:      func(s *store.AchievementStats) bool { return s.LongestStreakDays >= 7 },
+		},
+		{
+			Key:         "100kg_club",
+			Name:        "100kg Club",
+			Description: "Lifted 100 kg or more in a single set",
+			Color:       "#f44336",
+			Events:      workoutEvents,
+			Earned:      func(s *store.AchievementStats) bool { return s.MaxWeightKG >= 100 },
+		},
+		{
+			Key:         "10000kg_lifted",
+			Name:        "Ten Tonnes",
+			Description: "Lifted 10,000 kg in total (sets x reps x weight)",
+			Color:       "#795548",
+			Events:      workoutEvents,
+			Earned:      func(s *store.AchievementStats) bool { return s.TotalVolumeKG >= 10000 },
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed static
+var static embed.FS
+
+// ! Handler --> serves the admin UI, mount at /admin and /admin/assets/*
+// ? the page itself is public, every call it makes goes through the admin APIs with the signed-in admin's token
+func Handler() http.Handler {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) //* only fails if the embed directive is broken
+	}
+	files := http.FileServer(http.FS(assets))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		path := req.URL.Path
+		if asset, ok := strings.CutPrefix(path, "/admin/assets/"); ok && asset != "" {
+			path = "/" + asset
+		} else if path == "/admin" || path == "/admin/" {
+			path = "/"
+		} else {
+			http.NotFound(w, req)
+			return
+		}
+
+		req = req.Clone(req.Context())
+		req.URL.Path = path
+		files.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,151 @@
+"use strict";
+
+// token lives in sessionStorage only, closing the tab signs the admin out
+const TOKEN_KEY = "fittrack_admin_token";
+const $ = (id) => document.getElementById(id);
+
+function show(message, isError) {
+  const el = $("message");
+  el.textContent = message;
+  el.className = isError ? "error" : "";
+  el.hidden = !message;
+}
+
+async function api(method, path, body) {
+  const headers = { "Authorization": "Bearer " + sessionStorage.getItem(TOKEN_KEY) };
+  if (body) headers["Content-Type"] = "application/json";
+  const res = await fetch(path, { method, headers, body: body ? JSON.stringify(body) : undefined });
+  if (res.status === 401) {
+    signOut();
+    throw new Error("session expired, sign in again");
+  }
+  if (res.status === 204) return null;
+  const data = await res.json();
+  if (!res.ok) throw new Error(data.error || res.statusText);
+  return data;
+}
+
+// row builds a <tr> from text or nodes, never innerHTML so user data can't inject markup
+function row(cells) {
+  const tr = document.createElement("tr");
+  for (const cell of cells) {
+    const td = document.createElement("td");
+    if (cell instanceof Node) td.appendChild(cell);
+    else td.textContent = cell === null || cell === undefined ? "" : String(cell);
+    tr.appendChild(td);
+  }
+  return tr;
+}
+
+function fmt(ts) {
+  return ts ? new Date(ts).toLocaleString() : "";
+}
+
+function signedIn(yes) {
+  $("login").hidden = yes;
+  $("nav").hidden = !yes;
+  if (yes) openTab("users");
+  else document.querySelectorAll(".tab").forEach((t) => (t.hidden = true));
+}
+
+function signOut() {
+  sessionStorage.removeItem(TOKEN_KEY);
+  signedIn(false);
+}
+
+function openTab(name) {
+  document.querySelectorAll(".tab").forEach((t) => (t.hidden = t.id !== name));
+  document.querySelectorAll("nav button[data-tab]").forEach((b) => b.classList.toggle("active", b.dataset.tab === name));
+  show("");
+  if (name === "flags") loadFlags().catch((e) => show(e.message, true));
+  if (name === "jobs") loadJobs().catch((e) => show(e.message, true));
+}
+
+async function searchUsers(q) {
+  const data = await api("GET", "/v1/admin/users?q=" + encodeURIComponent(q));
+  const rows = $("user-rows");
+  rows.replaceChildren();
+  for (const u of data.users) {
+    const revoke = document.createElement("button");
+    revoke.textContent = "Revoke sessions";
+    revoke.disabled = u.active_tokens === 0;
+    revoke.addEventListener("click", async () => {
+      if (!confirm("Sign " + u.username + " out of every device?")) return;
+      try {
+        await api("DELETE", "/v1/admin/users/" + u.id + "/tokens");
+        show("Revoked sessions of " + u.username);
+        await searchUsers(q);
+      } catch (e) {
+        show(e.message, true);
+      }
+    });
+    rows.appendChild(row([u.id, u.username, u.email, u.is_admin ? "yes" : "", u.workouts, u.active_tokens, fmt(u.created_at), fmt(u.deleted_at), revoke]));
+  }
+  if (data.users.length === 0) show("No users found");
+}
+
+async function loadFlags() {
+  const data = await api("GET", "/v1/admin/feature-flags");
+  $("flags-version").textContent = data.version;
+  $("flags-min-version").textContent = data.min_version || "none";
+  const rows = $("flag-rows");
+  rows.replaceChildren();
+  for (const name of Object.keys(data.features).sort()) {
+    rows.appendChild(row([name, data.features[name] ? "on" : "off"]));
+  }
+}
+
+async function loadJobs() {
+  const form = new FormData($("job-filter"));
+  const params = new URLSearchParams({ status: form.get("status"), type: form.get("type") });
+  const data = await api("GET", "/v1/admin/jobs?" + params);
+
+  const counts = $("job-count-rows");
+  counts.replaceChildren();
+  for (const c of data.counts) counts.appendChild(row([c.type, c.status, c.count]));
+
+  const rows = $("job-rows");
+  rows.replaceChildren();
+  for (const j of data.jobs) {
+    const err = document.createElement("span");
+    err.className = "error-text";
+    err.textContent = j.last_error || "";
+    rows.appendChild(row([j.id, j.type, j.status, j.attempts + "/" + j.max_attempts, fmt(j.run_at), err]));
+  }
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  $("login-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const form = new FormData(e.target);
+    const res = await fetch("/v1/tokens/authentication", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ username: form.get("username"), password: form.get("password") }),
+    });
+    const data = await res.json();
+    if (!res.ok) return show(data.error || "sign in failed", true);
+    sessionStorage.setItem(TOKEN_KEY, data.auth_token.token);
+    try {
+      await api("GET", "/v1/admin/feature-flags"); // 403 for non-admins
+      show("");
+      signedIn(true);
+    } catch (err) {
+      sessionStorage.removeItem(TOKEN_KEY);
+      show(err.message, true);
+    }
+  });
+
+  $("user-search").addEventListener("submit", (e) => {
+    e.preventDefault();
+    searchUsers(new FormData(e.target).get("q")).catch((err) => show(err.message, true));
+  });
+  $("job-filter").addEventListener("submit", (e) => {
+    e.preventDefault();
+    loadJobs().catch((err) => show(err.message, true));
+  });
+  document.querySelectorAll("nav button[data-tab]").forEach((b) => b.addEventListener("click", () => openTab(b.dataset.tab)));
+  $("logout").addEventListener("click", signOut);
+
+  signedIn(Boolean(sessionStorage.getItem(TOKEN_KEY)));
+});
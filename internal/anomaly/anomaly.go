@@ -0,0 +1,132 @@
+package anomaly
+
+import (
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fmt"
+	"math"
+)
+
+// ! what happens when a workout trips a threshold
+const (
+	ModeWarn    = "warn"    //* save it anyway, return the warnings alongside the workout
+	ModeConfirm = "confirm" //* reject until the client resends with ?confirm=true
+)
+
+// ! Thresholds --> upper bounds past which a logged value is treated as implausible
+type Thresholds struct {
+	MaxWorkoutMinutes    int
+	MaxCalories          int
+	MaxEntrySeconds      int
+	MaxSets              int
+	MaxReps              int
+	MaxWeightKG          int
+	MaxEntriesPerWorkout int
+}
+
+// ! Warning --> one suspicious field, Path points into the request body (e.g. entries[2].weight)
+type Warning struct {
+	Path    string `json:"path"`
+	Value   any    `json:"value"`
+	Limit   int    `json:"limit"`
+	Message string `json:"message"`
+}
+
+// ! Detector --> checks workouts before they are written
+type Detector struct {
+	Mode       string
+	Thresholds Thresholds
+}
+
+// ! DefaultThresholds --> generous limits, only values no human produces get flagged
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MaxWorkoutMinutes:    12 * 60,
+		MaxCalories:          10000,
+		MaxEntrySeconds:      6 * 60 * 60,
+		MaxSets:              50,
+		MaxReps:              1000,
+		MaxWeightKG:          500,
+		MaxEntriesPerWorkout: 100,
+	}
+}
+
+// ! NewDetectorFromEnv --> thresholds and mode can be tuned per deployment via ANOMALY_* env vars
+func NewDetectorFromEnv() *Detector {
+	defaults := DefaultThresholds()
+	mode := utils.GetEnv("ANOMALY_MODE", ModeConfirm)
+	if mode != ModeWarn {
+		mode = ModeConfirm
+	}
+
+	return &Detector{
+		Mode: mode,
+		Thresholds: Thresholds{
+			MaxWorkoutMinutes:    utils.GetEnvInt("ANOMALY_MAX_WORKOUT_MINUTES", defaults.MaxWorkoutMinutes),
+			MaxCalories:          utils.GetEnvInt("ANOMALY_MAX_CALORIES", defaults.MaxCalories),
+			MaxEntrySeconds:      utils.GetEnvInt("ANOMALY_MAX_ENTRY_SECONDS", defaults.MaxEntrySeconds),
+			MaxSets:              utils.GetEnvInt("ANOMALY_MAX_SETS", defaults.MaxSets),
+			MaxReps:              utils.GetEnvInt("ANOMALY_MAX_REPS", defaults.MaxReps),
+			MaxWeightKG:          utils.GetEnvInt("ANOMALY_MAX_WEIGHT_KG", defaults.MaxWeightKG),
+			MaxEntriesPerWorkout: utils.GetEnvInt("ANOMALY_MAX_ENTRIES", defaults.MaxEntriesPerWorkout),
+		},
+	}
+}
+
+// ! RequiresConfirmation --> true when the caller must resend with confirm before anything is saved
+func (d *Detector) RequiresConfirmation(warnings []Warning, confirmed bool) bool {
+	return d.Mode == ModeConfirm && len(warnings) > 0 && !confirmed
+}
+
+// ! visit --> calls fn for every thresholded value in the workout, shared by Check and Score
+// ? estimated calories are ours, not the client's, so they are never checked
+func (d *Detector) visit(workout *store.Workout, fn func(path string, value float64, limit int, unit string)) {
+	t := d.Thresholds
+
+	fn("duration_minutes", float64(workout.DurationMinutes), t.MaxWorkoutMinutes, " min")
+	if !workout.CaloriesEstimated {
+		fn("calories_burned", float64(workout.CaloriesBurned), t.MaxCalories, " kcal")
+	}
+	fn("entries", float64(len(workout.Entries)), t.MaxEntriesPerWorkout, "")
+
+	for i, entry := range workout.Entries {
+		prefix := fmt.Sprintf("entries[%d].", i)
+		fn(prefix+"sets", float64(entry.Sets), t.MaxSets, "")
+		if entry.Reps != nil {
+			fn(prefix+"reps", float64(*entry.Reps), t.MaxReps, "")
+		}
+		if entry.DurationSeconds != nil {
+			fn(prefix+"duration_seconds", float64(*entry.DurationSeconds), t.MaxEntrySeconds, " s")
+		}
+		if entry.Weight != nil {
+			fn(prefix+"weight", *entry.Weight, t.MaxWeightKG, " kg")
+		}
+	}
+}
+
+// ! Check --> every value over its threshold, empty slice means the workout looks plausible
+func (d *Detector) Check(workout *store.Workout) []Warning {
+	warnings := []Warning{}
+	d.visit(workout, func(path string, value float64, limit int, unit string) {
+		if limit > 0 && value > float64(limit) {
+			warnings = append(warnings, Warning{
+				Path:    path,
+				Value:   value,
+				Limit:   limit,
+				Message: fmt.Sprintf("%s of %g%s exceeds the limit of %d%s", path, value, unit, limit, unit),
+			})
+		}
+	})
+	return warnings
+}
+
+// ! Score --> how close the most extreme value gets to its threshold, 1 or more means it would be flagged
+func (d *Detector) Score(workout *store.Workout) float64 {
+	score := 0.0
+	d.visit(workout, func(path string, value float64, limit int, unit string) {
+		if limit > 0 {
+			score = max(score, value/float64(limit))
+		}
+	})
+	return math.Round(score*1000) / 1000
+}
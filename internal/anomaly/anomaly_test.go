@@ -0,0 +1,47 @@
+package anomaly
+
+import (
+	"fem/internal/store"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ! TestCheck --> plausible workouts pass, implausible values point at the offending field
+func TestCheck(t *testing.T) {
+	detector := &Detector{Mode: ModeConfirm, Thresholds: DefaultThresholds()}
+	reps := 5
+	squat := 5000.0
+
+	plausible := &store.Workout{DurationMinutes: 60, CaloriesBurned: 500, Entries: []store.WorkoutEntry{{Sets: 3, Reps: &reps}}}
+	assert.Empty(t, detector.Check(plausible))
+
+	implausible := &store.Workout{DurationMinutes: 30 * 60, Entries: []store.WorkoutEntry{{Sets: 3, Reps: &reps, Weight: &squat}}}
+	warnings := detector.Check(implausible)
+	if assert.Len(t, warnings, 2) {
+		assert.Equal(t, "duration_minutes", warnings[0].Path)
+		assert.Equal(t, "entries[0].weight", warnings[1].Path)
+	}
+}
+
+// ! TestRequiresConfirmation --> only confirm mode blocks, and only until the client confirms
+func TestRequiresConfirmation(t *testing.T) {
+	warnings := []Warning{{Path: "duration_minutes"}}
+
+	confirm := &Detector{Mode: ModeConfirm}
+	assert.True(t, confirm.RequiresConfirmation(warnings, false))
+	assert.False(t, confirm.RequiresConfirmation(warnings, true))
+	assert.False(t, confirm.RequiresConfirmation(nil, false))
+
+	warn := &Detector{Mode: ModeWarn}
+	assert.False(t, warn.RequiresConfirmation(warnings, false))
+}
+
+// ! TestScore --> ratio of the most extreme value to its limit
+func TestScore(t *testing.T) {
+	detector := &Detector{Mode: ModeConfirm, Thresholds: DefaultThresholds()}
+	squat := 250.0
+
+	workout := &store.Workout{DurationMinutes: 60, Entries: []store.WorkoutEntry{{Sets: 5, Weight: &squat}}}
+	assert.Equal(t, 0.5, detector.Score(workout)) // * 250kg of a 500kg limit
+}
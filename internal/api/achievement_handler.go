@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"fem/internal/achievements"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AchievementHandler struct {
+	userStore        store.UserStore        //* badge owner's username
+	achievementStore store.AchievementStore //* earned achievements
+	engine           *achievements.Engine   //* rule catalogue
+	logger           *log.Logger
+}
+
+//! achievementResponse --> catalogue entry plus the caller's progress on it
+type achievementResponse struct {
+	*achievements.Rule
+	Earned   bool       `json:"earned"`
+	EarnedAt *time.Time `json:"earned_at"`
+	BadgeURL string     `json:"badge_url,omitempty"` // * public, only for earned badges
+}
+
+//! NewAchievementHandler --> constructor for achievement handler
+func NewAchievementHandler(userStore store.UserStore, achievementStore store.AchievementStore, engine *achievements.Engine, logger *log.Logger) *AchievementHandler {
+	return &AchievementHandler{
+		userStore:        userStore,
+		achievementStore: achievementStore,
+		engine:           engine,
+		logger:           logger,
+	}
+}
+
+//! listAchievements --> the rule catalogue with userID's progress, earnedOnly keeps just the badges (profiles)
+func listAchievements(engine *achievements.Engine, achievementStore store.AchievementStore, userID int, earnedOnly bool) ([]achievementResponse, error) {
+	earned, err := achievementStore.ListAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	earnedAt := map[string]time.Time{}
+	for _, a := range earned {
+		earnedAt[a.Key] = a.EarnedAt
+	}
+
+	list := make([]achievementResponse, 0, len(engine.Rules))
+	for _, rule := range engine.Rules {
+		item := achievementResponse{Rule: rule}
+		if at, ok := earnedAt[rule.Key]; ok {
+			item.Earned = true
+			item.EarnedAt = &at
+			item.BadgeURL = fmt.Sprintf("/v1/users/%s/badges/%s.svg", utils.FormatID(int64(userID)), rule.Key)
+		} else if earnedOnly {
+			continue
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+//! HandleListMyAchievements --> GET /users/me/achievements, full catalogue with earned flags
+func (h *AchievementHandler) HandleListMyAchievements(w http.ResponseWriter, req *http.Request) {
+	list, err := listAchievements(h.engine, h.achievementStore, middleware.GetUser(req).ID, false)
+	if err != nil {
+		h.logger.Printf("ERROR: listAchievements: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"achievements": list})
+}
+
+//! HandleGetBadge --> GET /users/{id}/badges/{key}.svg public, shareable badge image
+//? unearned badges 404 so the URL itself proves the achievement
+func (h *AchievementHandler) HandleGetBadge(w http.ResponseWriter, req *http.Request) {
+	userID, err := utils.ReadIDParam(req)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	rule := h.engine.Rule(chi.URLParam(req, "key"))
+	if rule == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	earned, err := h.achievementStore.GetAchievement(int(userID), rule.Key)
+	if err != nil {
+		h.logger.Printf("ERROR: getAchievement: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if earned == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	user, err := h.userStore.GetUserByID(userID)
+	if err != nil || user == nil {
+		h.logger.Printf("ERROR: getUserByID: %v", err)
+		http.NotFound(w, req)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = achievements.WriteBadge(&buf, rule, user.Username, earned.EarnedAt)
+	if err != nil {
+		h.logger.Printf("ERROR: writeBadge: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"errors"
+	"fem/internal/audit"
+	"fem/internal/clientconfig"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"fem/internal/utils"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHandler struct {
+	adminStore   store.AdminStore        //* user + job queue lookups
+	tokenStore   store.TokenStore        //* session revocation
+	lockoutStore store.LoginLockoutStore //* failed login lockouts, admins can lift them early
+	shadowStore  store.ShadowStore       //* traffic mirror mismatches
+	clientConfig *clientconfig.Config    //* feature flags, read-only (edited in CLIENT_CONFIG_FILE)
+	audit        *audit.Recorder         //* session revocations + lockout overrides
+	logger       *log.Logger
+}
+
+// ! NewAdminHandler --> constructor for admin handler
+func NewAdminHandler(adminStore store.AdminStore, tokenStore store.TokenStore, lockoutStore store.LoginLockoutStore, shadowStore store.ShadowStore, clientConfig *clientconfig.Config, auditRecorder *audit.Recorder, logger *log.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminStore:   adminStore,
+		tokenStore:   tokenStore,
+		lockoutStore: lockoutStore,
+		shadowStore:  shadowStore,
+		clientConfig: clientConfig,
+		audit:        auditRecorder,
+		logger:       logger,
+	}
+}
+
+// ! HandleSearchUsers --> GET /admin/users?q=&limit= username / email prefix or exact id
+func (h *AdminHandler) HandleSearchUsers(w http.ResponseWriter, req *http.Request) {
+	query := strings.TrimSpace(req.URL.Query().Get("q"))
+	if query == "" {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "q is required"})
+		return
+	}
+
+	users, err := h.adminStore.SearchUsers(query, readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: searchUsers: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"users": users})
+}
+
+// ! HandleGetUser --> GET /admin/users/{id}
+func (h *AdminHandler) HandleGetUser(w http.ResponseWriter, req *http.Request) {
+	user, ok := h.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"user": user})
+}
+
+// ! HandleRevokeTokens --> DELETE /admin/users/{id}/tokens, signs the user out everywhere
+func (h *AdminHandler) HandleRevokeTokens(w http.ResponseWriter, req *http.Request) {
+	user, ok := h.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.tokenStore.DeleteAllTokensForUser(user.ID, tokens.ScopeAuth)
+	if err != nil {
+		h.logger.Printf("ERROR: deleteAllTokensForUser: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	h.logger.Printf("admin: revoked auth tokens of user %d", user.ID)
+	h.audit.Record(req.Context(), store.AuditEntry{
+		Action:   audit.TokensRevoked,
+		ActorID:  audit.UserID(middleware.GetUser(req).ID),
+		TargetID: audit.UserID(user.ID),
+		Metadata: map[string]any{"scope": tokens.ScopeAuth},
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! requireUser --> loads {id}, writes the error itself
+func (h *AdminHandler) requireUser(w http.ResponseWriter, req *http.Request) (*store.AdminUser, bool) {
+	userID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid user id"})
+		return nil, false
+	}
+
+	user, err := h.adminStore.GetAdminUser(userID)
+	if err != nil {
+		h.logger.Printf("ERROR: getAdminUser: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if user == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "user not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+// ! HandleGetFeatureFlags --> GET /admin/feature-flags, what /client-config currently serves
+func (h *AdminHandler) HandleGetFeatureFlags(w http.ResponseWriter, req *http.Request) {
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{
+		"version":     h.clientConfig.Version,
+		"min_version": h.clientConfig.MinVersion,
+		"features":    h.clientConfig.Features,
+		"platforms":   h.clientConfig.Platforms,
+	})
+}
+
+// ! HandleListJobs --> GET /admin/jobs?status=&type=&limit= queue counts + matching jobs, newest first
+func (h *AdminHandler) HandleListJobs(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	status := query.Get("status")
+	if status != "" && status != store.JobQueued && status != store.JobRunning && status != store.JobDone && status != store.JobFailed {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "status must be queued, running, done or failed"})
+		return
+	}
+
+	counts, err := h.adminStore.CountJobs()
+	if err != nil {
+		h.logger.Printf("ERROR: countJobs: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	jobs, err := h.adminStore.ListJobs(store.JobFilter{Status: status, Type: query.Get("type"), Limit: readLeaderboardLimit(req)})
+	if err != nil {
+		h.logger.Printf("ERROR: listJobs: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"counts": counts, "jobs": jobs})
+}
+
+// ! HandleListShadowDiffs --> GET /admin/shadow-diffs?limit= mirrored requests that answered differently, newest first
+func (h *AdminHandler) HandleListShadowDiffs(w http.ResponseWriter, req *http.Request) {
+	diffs, err := h.shadowStore.ListShadowDiffs(readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: listShadowDiffs: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"diffs": diffs})
+}
+
+// ! HandleListAuditLog --> GET /admin/audit-log?action=&actor_id=&target_id=&since=&until=&before_id=&limit= newest first
+// ? pass next_before_id back as before_id for the next page
+func (h *AdminHandler) HandleListAuditLog(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	filter := store.AuditFilter{Action: query.Get("action"), Limit: readLeaderboardLimit(req)}
+	for param, dst := range map[string]*int{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		if raw := query.Get(param); raw != "" {
+			id, err := utils.ParseID(raw)
+			if err != nil {
+				utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid " + param})
+				return
+			}
+			*dst = int(id)
+		}
+	}
+	if raw := query.Get("before_id"); raw != "" {
+		id, err := utils.ParseID(raw) //* next_before_id goes out encoded like every other *_id
+		if err != nil || id < 1 {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid before_id"})
+			return
+		}
+		filter.BeforeID = id
+	}
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if raw := query.Get(param); raw != "" {
+			t, err := utils.ParseTimeParam(raw)
+			if err != nil {
+				utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": param + " must be a date (2006-01-02) or RFC3339 timestamp"})
+				return
+			}
+			*dst = t
+		}
+	}
+
+	entries, err := h.audit.Store.ListAudit(filter)
+	if err != nil {
+		h.logger.Printf("ERROR: listAudit: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	var next *int64
+	cursor := ""
+	if len(entries) == filter.Limit {
+		next = &entries[len(entries)-1].ID
+		cursor = utils.FormatID(*next)
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"entries": entries, "next_before_id": next}.PaginateCursor(req, "before_id", cursor, filter.Limit))
+}
+
+// ! HandleListLockouts --> GET /admin/lockouts usernames + IPs currently locked out of login, soonest unlock first
+func (h *AdminHandler) HandleListLockouts(w http.ResponseWriter, req *http.Request) {
+	lockouts, err := h.lockoutStore.ListLoginLockouts(time.Now())
+	if err != nil {
+		h.logger.Printf("ERROR: listLoginLockouts: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"lockouts": lockouts})
+}
+
+// ! HandleUnlockUser --> DELETE /admin/users/{id}/lockout lifts the user's login lockout and forgets its failures
+func (h *AdminHandler) HandleUnlockUser(w http.ResponseWriter, req *http.Request) {
+	user, ok := h.requireUser(w, req)
+	if !ok {
+		return
+	}
+	h.clearLockout(w, req, store.LockoutUsername, service.UsernameLockoutKey(user.Username), audit.UserID(user.ID))
+}
+
+// ! HandleUnlockIP --> DELETE /admin/lockouts/ip/{ip} same for a client IP
+func (h *AdminHandler) HandleUnlockIP(w http.ResponseWriter, req *http.Request) {
+	ip := net.ParseIP(chi.URLParam(req, "ip"))
+	if ip == nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid ip"})
+		return
+	}
+	h.clearLockout(w, req, store.LockoutIP, ip.String(), nil)
+}
+
+// * clearLockout --> 404 when there was no lockout row, writes the response itself
+func (h *AdminHandler) clearLockout(w http.ResponseWriter, req *http.Request, kind, key string, targetID *int) {
+	err := h.lockoutStore.ClearLoginLockout(kind, key)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "no failed logins recorded"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: clearLoginLockout: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	h.logger.Printf("admin: cleared login lockout %s %s", kind, key)
+	h.audit.Record(req.Context(), store.AuditEntry{
+		Action:   audit.LoginUnlocked,
+		ActorID:  audit.UserID(middleware.GetUser(req).ID),
+		TargetID: targetID,
+		Metadata: map[string]any{"kind": kind, "key": key},
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/automation"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+)
+
+type AutomationHandler struct {
+	automationStore store.AutomationStore //* per-user rules
+	logger          *log.Logger
+}
+
+// ! automationRuleRequest --> POST + PUT /automation-rules payload, enabled defaults to true
+type automationRuleRequest struct {
+	Name      string                     `json:"name"`
+	Trigger   string                     `json:"trigger"`
+	Condition *store.AutomationCondition `json:"condition"`
+	Action    store.AutomationAction     `json:"action"`
+	Enabled   *bool                      `json:"enabled"`
+}
+
+// ! NewAutomationHandler --> constructor for automation handler
+func NewAutomationHandler(automationStore store.AutomationStore, logger *log.Logger) *AutomationHandler {
+	return &AutomationHandler{
+		automationStore: automationStore,
+		logger:          logger,
+	}
+}
+
+// ! readRule --> decodes + validates the body into rule, writes the error itself
+func (h *AutomationHandler) readRule(w http.ResponseWriter, req *http.Request, rule *store.AutomationRule) bool {
+	var r automationRuleRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return false
+	}
+
+	rule.Name, rule.Trigger, rule.Condition, rule.Action = r.Name, r.Trigger, r.Condition, r.Action
+	rule.Enabled = r.Enabled == nil || *r.Enabled
+	err = automation.Validate(rule)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// ! requireRuleOwner --> loads {id} and makes sure it belongs to the current user, writes the error itself
+func (h *AutomationHandler) requireRuleOwner(w http.ResponseWriter, req *http.Request) (*store.AutomationRule, bool) {
+	ruleID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid rule id"})
+		return nil, false
+	}
+
+	rule, err := h.automationStore.GetRule(ruleID)
+	if err != nil {
+		h.logger.Printf("ERROR: getRule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if rule == nil || rule.UserID != middleware.GetUser(req).ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "rule not found"})
+		return nil, false
+	}
+	return rule, true
+}
+
+// ! HandleCreateRule --> POST /automation-rules
+func (h *AutomationHandler) HandleCreateRule(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+	rule := &store.AutomationRule{UserID: currentUser.ID}
+	if !h.readRule(w, req, rule) {
+		return
+	}
+
+	existing, err := h.automationStore.ListRules(currentUser.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listRules: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if len(existing) >= automation.MaxRulesPerUser {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "rule limit reached, delete one first"})
+		return
+	}
+
+	err = h.automationStore.CreateRule(rule)
+	if err != nil {
+		h.logger.Printf("ERROR: createRule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"rule": rule})
+}
+
+// ! HandleListRules --> GET /automation-rules
+func (h *AutomationHandler) HandleListRules(w http.ResponseWriter, req *http.Request) {
+	list, err := h.automationStore.ListRules(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listRules: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"rules": list})
+}
+
+// ! HandleGetRule --> GET /automation-rules/{id}
+func (h *AutomationHandler) HandleGetRule(w http.ResponseWriter, req *http.Request) {
+	rule, ok := h.requireRuleOwner(w, req)
+	if !ok {
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"rule": rule})
+}
+
+// ! HandleUpdateRule --> PUT /automation-rules/{id}, replaces the whole rule
+func (h *AutomationHandler) HandleUpdateRule(w http.ResponseWriter, req *http.Request) {
+	rule, ok := h.requireRuleOwner(w, req)
+	if !ok || !h.readRule(w, req, rule) {
+		return
+	}
+
+	err := h.automationStore.UpdateRule(rule)
+	if err != nil {
+		h.logger.Printf("ERROR: updateRule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"rule": rule})
+}
+
+// ! HandleDeleteRule --> DELETE /automation-rules/{id}
+func (h *AutomationHandler) HandleDeleteRule(w http.ResponseWriter, req *http.Request) {
+	rule, ok := h.requireRuleOwner(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.automationStore.DeleteRule(rule.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		h.logger.Printf("ERROR: deleteRule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/utils"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type AvatarHandler struct {
+	avatars *service.AvatarService //* nil when avatars aren't available on this server
+	logger  *log.Logger
+}
+
+// ! NewAvatarHandler --> constructor for avatar handler
+func NewAvatarHandler(avatarService *service.AvatarService, logger *log.Logger) *AvatarHandler {
+	return &AvatarHandler{avatars: avatarService, logger: logger}
+}
+
+func avatarURL(userID int) string {
+	return "/v1/users/" + utils.FormatID(int64(userID)) + "/avatar"
+}
+
+// ! HandleSetAvatar --> PUT /users/me/avatar, the image as the raw body or as a multipart "avatar" field
+func (h *AvatarHandler) HandleSetAvatar(w http.ResponseWriter, req *http.Request) {
+	if h.avatars == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "avatars are not available on this server"})
+		return
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, h.avatars.MaxBytes+multipartOverhead)
+	data, err := readAvatar(req)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.WriteJson(w, http.StatusRequestEntityTooLarge, utils.Envelope{"error": fmt.Sprintf("avatar must be at most %dMB", h.avatars.MaxBytes>>20)})
+			return
+		}
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	currentUser := middleware.GetUser(req)
+	avatar, err := h.avatars.Set(req.Context(), currentUser.ID, data)
+	var invalid *service.ValidationError
+	if errors.As(err, &invalid) {
+		utils.WriteJson(w, http.StatusUnprocessableEntity, utils.Envelope{"error": invalid.Message})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: setAvatar: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	w.Header().Set("ETag", avatar.ETag)
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"avatar_url": avatarURL(currentUser.ID), "etag": avatar.ETag})
+}
+
+// * readAvatar --> multipart uploads (browsers) and plain image bodies (curl --data-binary) both work
+func readAvatar(req *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		data, err := io.ReadAll(req.Body)
+		if err == nil && len(data) == 0 {
+			return nil, errors.New("avatar is required")
+		}
+		return data, err
+	}
+	err := req.ParseMultipartForm(multipartOverhead)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, errors.New("invalid multipart upload")
+	}
+	defer req.MultipartForm.RemoveAll()
+	file, _, err := req.FormFile("avatar")
+	if err != nil {
+		return nil, errors.New("avatar is required")
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// ! HandleDeleteAvatar --> DELETE /users/me/avatar
+func (h *AvatarHandler) HandleDeleteAvatar(w http.ResponseWriter, req *http.Request) {
+	if h.avatars == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "avatars are not available on this server"})
+		return
+	}
+	err := h.avatars.Remove(req.Context(), middleware.GetUser(req).ID)
+	if errors.Is(err, service.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "no avatar set"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: removeAvatar: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! HandleGetAvatar --> GET /users/{id}/avatar public like badge images, answers 304 to a matching If-None-Match
+// ? the ETag changes with every upload, so caches revalidate cheaply without touching the blob store
+func (h *AvatarHandler) HandleGetAvatar(w http.ResponseWriter, req *http.Request) {
+	if h.avatars == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "avatars are not available on this server"})
+		return
+	}
+	userID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid user id"})
+		return
+	}
+
+	avatar, err := h.avatars.Get(int(userID))
+	if err != nil {
+		h.logger.Printf("ERROR: getAvatar: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if avatar == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "avatar not found"})
+		return
+	}
+
+	w.Header().Set("ETag", avatar.ETag)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if req.Header.Get("If-None-Match") == avatar.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := h.avatars.Open(req.Context(), avatar)
+	if errors.Is(err, service.ErrNotFound) {
+		w.Header().Del("ETag")
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "avatar not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: openAvatar: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = io.Copy(w, body)
+	if err != nil {
+		h.logger.Printf("ERROR: serveAvatar: %v", err)
+	}
+}
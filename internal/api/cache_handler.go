@@ -0,0 +1,25 @@
+package api
+
+import (
+	"fem/internal/cache"
+	"fem/internal/utils"
+	"net/http"
+)
+
+type CacheHandler struct {
+	tokens *cache.UserStore //* nil when TOKEN_CACHE_TTL=0
+}
+
+// ! NewCacheHandler --> constructor for the cache stats endpoint, tokens may be nil
+func NewCacheHandler(tokens *cache.UserStore) *CacheHandler {
+	return &CacheHandler{tokens: tokens}
+}
+
+// ! HandleGetStats --> GET /admin/cache token lookup hits / misses since start
+func (h *CacheHandler) HandleGetStats(w http.ResponseWriter, req *http.Request) {
+	if h.tokens == nil {
+		utils.WriteJson(w, http.StatusOK, utils.Envelope{"token_cache": nil})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"token_cache": h.tokens.Stats()})
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/challenges"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//! maxChallengeLength --> longest window a challenge may span
+const maxChallengeLength = 366 * 24 * time.Hour
+
+type ChallengeHandler struct {
+	challengeStore store.ChallengeStore //* challenges, participants + results, nil answers 501
+	logger         *log.Logger
+}
+
+//! createChallengeRequest --> POST /challenges payload
+type createChallengeRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Metric      string    `json:"metric"`
+	StartsAt    time.Time `json:"starts_at"` //* optional, defaults to now
+	EndsAt      time.Time `json:"ends_at"`
+}
+
+//! NewChallengeHandler --> constructor for challenge handler
+func NewChallengeHandler(challengeStore store.ChallengeStore, logger *log.Logger) *ChallengeHandler {
+	return &ChallengeHandler{
+		challengeStore: challengeStore,
+		logger:         logger,
+	}
+}
+
+func (r *createChallengeRequest) validate(now time.Time) error {
+	r.Title = strings.TrimSpace(r.Title)
+	if r.Title == "" || len(r.Title) > 100 {
+		return errors.New("title is required and must be at most 100 characters")
+	}
+	if !store.ValidEventMetric(r.Metric) {
+		return errors.New("metric must be one of workouts, duration_minutes, calories, volume_kg")
+	}
+	if r.StartsAt.IsZero() {
+		r.StartsAt = now
+	}
+	if r.EndsAt.IsZero() {
+		return errors.New("ends_at is required")
+	}
+	if !r.EndsAt.After(r.StartsAt) || !r.EndsAt.After(now) {
+		return errors.New("ends_at must be in the future and after starts_at")
+	}
+	if r.EndsAt.Sub(r.StartsAt) > maxChallengeLength {
+		return errors.New("a challenge can last at most a year")
+	}
+	return nil
+}
+
+//! available --> writes the 501 on servers without challenge tables (sqlite)
+func (h *ChallengeHandler) available(w http.ResponseWriter) bool {
+	if h.challengeStore == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "challenges are not available on this server"})
+		return false
+	}
+	return true
+}
+
+//! loadChallenge --> {id} from the URL, writes the 404 itself
+func (h *ChallengeHandler) loadChallenge(w http.ResponseWriter, req *http.Request) (*store.Challenge, bool) {
+	if !h.available(w) {
+		return nil, false
+	}
+	challengeID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid challenge id"})
+		return nil, false
+	}
+
+	challenge, err := h.challengeStore.GetChallenge(challengeID)
+	if err != nil {
+		h.logger.Printf("ERROR: getChallenge: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if challenge == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "challenge not found"})
+		return nil, false
+	}
+	return challenge, true
+}
+
+//! challengeOver --> past ends_at, nobody joins or leaves anymore
+func challengeOver(challenge *store.Challenge) bool {
+	return challenge.Status == store.EventStatusEnded || challenge.Status == store.EventStatusClosed
+}
+
+//! HandleCreateChallenge --> POST /challenges, the creator takes part right away
+func (h *ChallengeHandler) HandleCreateChallenge(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	var r createChallengeRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	err = r.validate(time.Now())
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	createdBy := middleware.GetUser(req).ID
+	challenge := &store.Challenge{
+		Title:       r.Title,
+		Description: r.Description,
+		Metric:      r.Metric,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+		CreatedBy:   &createdBy,
+	}
+	err = h.challengeStore.CreateChallenge(challenge)
+	if err != nil {
+		writeStoreError(w, h.logger, "createChallenge", err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"challenge": challenge})
+}
+
+//! HandleListChallenges --> GET /challenges, the ones the caller takes part in
+func (h *ChallengeHandler) HandleListChallenges(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	list, err := h.challengeStore.ListChallenges(middleware.GetUser(req).ID, readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: listChallenges: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"challenges": list})
+}
+
+//! HandleGetChallenge --> GET /challenges/{id} with every participant's progress, live until the challenge closes
+func (h *ChallengeHandler) HandleGetChallenge(w http.ResponseWriter, req *http.Request) {
+	challenge, ok := h.loadChallenge(w, req)
+	if !ok {
+		return
+	}
+
+	standings, err := h.challengeStore.GetChallengeStandings(challenge)
+	if err != nil {
+		h.logger.Printf("ERROR: getChallengeStandings: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"challenge": challenge, "standings": standings})
+}
+
+//! HandleJoinChallenge --> POST /challenges/{id}/join, joining twice is fine, an ended challenge answers 409
+func (h *ChallengeHandler) HandleJoinChallenge(w http.ResponseWriter, req *http.Request) {
+	challenge, ok := h.loadChallenge(w, req)
+	if !ok {
+		return
+	}
+	if challengeOver(challenge) {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "challenge has ended"})
+		return
+	}
+
+	err := h.challengeStore.JoinChallenge(int64(challenge.ID), middleware.GetUser(req).ID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "challenge has ended"}) //* it ended since loadChallenge
+		return
+	}
+	if err != nil {
+		writeStoreError(w, h.logger, "joinChallenge", err)
+		return
+	}
+
+	challenge, ok = h.loadChallenge(w, req)
+	if !ok {
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"challenge": challenge})
+}
+
+//! HandleLeaveChallenge --> POST /challenges/{id}/leave, only before the challenge ends
+func (h *ChallengeHandler) HandleLeaveChallenge(w http.ResponseWriter, req *http.Request) {
+	challenge, ok := h.loadChallenge(w, req)
+	if !ok {
+		return
+	}
+	if challengeOver(challenge) {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "challenge has ended"})
+		return
+	}
+
+	err := h.challengeStore.LeaveChallenge(int64(challenge.ID), middleware.GetUser(req).ID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "you are not taking part in this challenge"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: leaveChallenge: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//! HandleGetResults --> GET /challenges/{id}/results final standings + winners, 409 until the close job has run
+func (h *ChallengeHandler) HandleGetResults(w http.ResponseWriter, req *http.Request) {
+	challenge, ok := h.loadChallenge(w, req)
+	if !ok {
+		return
+	}
+	if challenge.ClosedAt == nil {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "results are announced once the challenge has ended", "ends_at": challenge.EndsAt})
+		return
+	}
+
+	results, err := h.challengeStore.GetChallengeStandings(challenge)
+	if err != nil {
+		h.logger.Printf("ERROR: getChallengeStandings: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"challenge": challenge, "winners": challenges.Winners(results), "results": results})
+}
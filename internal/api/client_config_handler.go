@@ -0,0 +1,60 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fem/internal/clientconfig"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type ClientConfigHandler struct {
+	config *clientconfig.Config //* flags, version policy + remote settings, loaded once at startup
+	logger *log.Logger
+}
+
+//! NewClientConfigHandler --> constructor for client config handler
+func NewClientConfigHandler(config *clientconfig.Config, logger *log.Logger) *ClientConfigHandler {
+	return &ClientConfigHandler{
+		config: config,
+		logger: logger,
+	}
+}
+
+//! clientParam --> query param wins over the header so cached URLs stay self-describing
+func clientParam(req *http.Request, query, header string) string {
+	value := req.URL.Query().Get(query)
+	if value == "" {
+		value = req.Header.Get(header)
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+//! HandleGetClientConfig --> GET /client-config?platform=ios&version=2.1.0 public, apps fetch it before login
+//? platform/version can also come from X-Client-Platform / X-Client-Version, upgrade is null when either is missing
+func (h *ClientConfigHandler) HandleGetClientConfig(w http.ResponseWriter, req *http.Request) {
+	platform := clientParam(req, "platform", "X-Client-Platform")
+	version := clientParam(req, "version", "X-Client-Version")
+
+	//* the upgrade block depends on the caller, so the etag covers config version + platform + app version
+	sum := sha256.Sum256([]byte(h.config.Version + "|" + platform + "|" + version))
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:])[:16])
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Vary", "X-Client-Platform, X-Client-Version")
+	if req.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{
+		"version":  h.config.Version,
+		"features": h.config.Features,
+		"settings": h.config.Settings,
+		"upgrade":  h.config.UpgradeFor(platform, version),
+	})
+}
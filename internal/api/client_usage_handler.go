@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultUsageDays = 30
+	maxUsageDays     = 365
+)
+
+type ClientUsageHandler struct {
+	usageStore store.ClientUsageStore //* per-day request counters
+	logger     *log.Logger
+}
+
+//! NewClientUsageHandler --> constructor for client usage handler
+func NewClientUsageHandler(usageStore store.ClientUsageStore, logger *log.Logger) *ClientUsageHandler {
+	return &ClientUsageHandler{
+		usageStore: usageStore,
+		logger:     logger,
+	}
+}
+
+//! HandleGetUsage --> GET /admin/clients/usage?days=30&route=/workouts/{id}&client_id=ios&limit=
+//? filter on a deprecated route to see which client versions still call it
+func (h *ClientUsageHandler) HandleGetUsage(w http.ResponseWriter, req *http.Request) {
+	days := defaultUsageDays
+	if raw := req.URL.Query().Get("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxUsageDays {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = n
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+	usage, err := h.usageStore.ListClientUsage(store.ClientUsageFilter{
+		Since:    since,
+		Route:    req.URL.Query().Get("route"),
+		ClientID: req.URL.Query().Get("client_id"),
+		Limit:    readLeaderboardLimit(req),
+	})
+	if err != nil {
+		h.logger.Printf("ERROR: listClientUsage: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"since": since.Format("2006-01-02"), "usage": usage})
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/events"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//! comment limits
+const (
+	maxCommentLength    = 2000
+	defaultCommentLimit = 50
+	maxCommentLimit     = 200
+)
+
+//! allowedReactions --> small fixed palette keeps counts meaningful
+var allowedReactions = map[string]bool{
+	"👍":  true,
+	"❤️": true,
+	"🔥":  true,
+	"💪":  true,
+	"👏":  true,
+	"🎉":  true,
+}
+
+type CommentHandler struct {
+	workstore    store.WorkoutStore //* loads the workout for visibility checks
+	followStore  store.FollowStore  //* followers-only workouts
+	commentStore store.CommentStore //* comments + reactions
+	bus          *events.Bus        //* CommentAdded, pushed to the workout's owner
+	logger       *log.Logger
+}
+
+//! commentRequest --> POST /workouts/{id}/comments payload
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+//! reactionRequest --> POST /workouts/{id}/reactions payload
+type reactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+//! NewCommentHandler --> constructor for comment handler
+func NewCommentHandler(workoutStore store.WorkoutStore, followStore store.FollowStore, commentStore store.CommentStore, bus *events.Bus, logger *log.Logger) *CommentHandler {
+	return &CommentHandler{
+		workstore:    workoutStore,
+		followStore:  followStore,
+		commentStore: commentStore,
+		bus:          bus,
+		logger:       logger,
+	}
+}
+
+//! HandleCreateComment --> POST /workouts/{id}/comments, anyone who can see the workout may comment
+func (h *CommentHandler) HandleCreateComment(w http.ResponseWriter, req *http.Request) {
+	workout, ok := requireWorkoutViewer(h.workstore, h.followStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	var r commentRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	r.Body = strings.TrimSpace(r.Body)
+	if r.Body == "" || utf8.RuneCountInString(r.Body) > maxCommentLength {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "body must be between 1 and 2000 characters"})
+		return
+	}
+
+	comment := &store.Comment{
+		WorkoutID: workout.ID,
+		UserID:    middleware.GetUser(req).ID,
+		Body:      r.Body,
+	}
+	err = h.commentStore.CreateComment(comment)
+	if err != nil {
+		h.logger.Printf("ERROR: createComment: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if workout.UserID != comment.UserID {
+		h.bus.Publish(events.Event{Type: events.CommentAdded, UserID: workout.UserID, WorkoutID: workout.ID, Ref: strconv.Itoa(comment.UserID)})
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"comment": comment})
+}
+
+//! HandleListComments --> GET /workouts/{id}/comments?offset=&limit=
+func (h *CommentHandler) HandleListComments(w http.ResponseWriter, req *http.Request) {
+	workout, ok := requireWorkoutViewer(h.workstore, h.followStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	query := req.URL.Query()
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultCommentLimit
+	}
+	limit = min(limit, maxCommentLimit)
+
+	comments, total, err := h.commentStore.ListComments(int64(workout.ID), offset, limit)
+	if err != nil {
+		h.logger.Printf("ERROR: listComments: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"comments": comments, "total": total, "offset": offset, "limit": limit}.Paginate(req, offset, limit, len(comments), &total))
+}
+
+//! HandleDeleteComment --> DELETE /workouts/{id}/comments/{commentID}
+//? the comment's author and the workout's owner may both remove it
+func (h *CommentHandler) HandleDeleteComment(w http.ResponseWriter, req *http.Request) {
+	workout, ok := requireWorkoutViewer(h.workstore, h.followStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	commentID, err := utils.ReadInt64Param(req, "commentID")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid comment id"})
+		return
+	}
+
+	comment, err := h.commentStore.GetComment(commentID)
+	if err != nil {
+		h.logger.Printf("ERROR: getComment: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if comment == nil || comment.WorkoutID != workout.ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "comment not found"})
+		return
+	}
+
+	currentUser := middleware.GetUser(req)
+	if comment.UserID != currentUser.ID && workout.UserID != currentUser.ID {
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "you are not authorized to delete this comment"})
+		return
+	}
+
+	err = h.commentStore.DeleteComment(commentID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) { //* already gone is as good as deleted
+		h.logger.Printf("ERROR: deleteComment: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//! HandleAddReaction --> POST /workouts/{id}/reactions {"emoji": "🔥"}
+func (h *CommentHandler) HandleAddReaction(w http.ResponseWriter, req *http.Request) {
+	workout, ok := requireWorkoutViewer(h.workstore, h.followStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	var r reactionRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	if !allowedReactions[r.Emoji] {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "unsupported reaction"})
+		return
+	}
+
+	err = h.commentStore.AddReaction(int64(workout.ID), int64(middleware.GetUser(req).ID), r.Emoji)
+	if err != nil {
+		h.logger.Printf("ERROR: addReaction: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	h.writeCounts(w, workout)
+}
+
+//! HandleRemoveReaction --> DELETE /workouts/{id}/reactions/{emoji}
+func (h *CommentHandler) HandleRemoveReaction(w http.ResponseWriter, req *http.Request) {
+	workout, ok := requireWorkoutViewer(h.workstore, h.followStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	emoji, err := url.PathUnescape(chi.URLParam(req, "emoji")) //* emoji arrive percent-encoded in the path
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid reaction"})
+		return
+	}
+
+	err = h.commentStore.RemoveReaction(int64(workout.ID), int64(middleware.GetUser(req).ID), emoji)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "reaction not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: removeReaction: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	h.writeCounts(w, workout)
+}
+
+//! writeCounts --> reaction endpoints answer with fresh totals so clients can redraw without a refetch
+func (h *CommentHandler) writeCounts(w http.ResponseWriter, workout *store.Workout) {
+	counts, err := h.commentStore.GetCounts(int64(workout.ID))
+	if err != nil {
+		h.logger.Printf("ERROR: getCounts: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"counts": counts})
+}
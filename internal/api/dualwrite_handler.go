@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"fem/internal/dualwrite"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+)
+
+type DualWriteHandler struct {
+	flags   *dualwrite.Flags   //* nil when DUALWRITE_DATABASE_URL isn't set
+	metrics *dualwrite.Metrics //* secondary write + read-compare counters
+	logger  *log.Logger
+}
+
+// ! NewDualWriteHandler --> constructor for the dual-write admin endpoints, flags may be nil
+func NewDualWriteHandler(flags *dualwrite.Flags, metrics *dualwrite.Metrics, logger *log.Logger) *DualWriteHandler {
+	return &DualWriteHandler{
+		flags:   flags,
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+
+// ! HandleGetDualWrite --> GET /admin/dual-write current flags + divergence metrics
+func (h *DualWriteHandler) HandleGetDualWrite(w http.ResponseWriter, req *http.Request) {
+	if !h.configured(w) {
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"mode": h.flags.Get(), "metrics": h.metrics.Snapshot()})
+}
+
+// ! HandleSetDualWrite --> PUT /admin/dual-write replaces the flags, takes effect on the next store call
+// ? not persisted --> a restart goes back to DUALWRITE_MODE
+func (h *DualWriteHandler) HandleSetDualWrite(w http.ResponseWriter, req *http.Request) {
+	if !h.configured(w) {
+		return
+	}
+
+	var mode dualwrite.Mode
+	err := json.NewDecoder(req.Body).Decode(&mode)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	h.flags.Set(mode)
+	h.logger.Printf("dualwrite: mode set to %+v", mode)
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"mode": mode, "metrics": h.metrics.Snapshot()})
+}
+
+func (h *DualWriteHandler) configured(w http.ResponseWriter) bool {
+	if h.flags == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "dual-write is not configured"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"fem/internal/events"
+	"fem/internal/middleware"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ! streamRetry --> reconnect delay suggested to EventSource clients
+const streamRetry = 3 * time.Second
+
+type EventStreamHandler struct {
+	hub       *events.Hub
+	heartbeat time.Duration //* comment line sent when idle so proxies don't drop the connection
+	logger    *log.Logger
+}
+
+// ! NewEventStreamHandler --> constructor for the SSE handler
+func NewEventStreamHandler(hub *events.Hub, heartbeat time.Duration, logger *log.Logger) *EventStreamHandler {
+	return &EventStreamHandler{
+		hub:       hub,
+		heartbeat: heartbeat,
+		logger:    logger,
+	}
+}
+
+// ! HandleStream --> GET /events, text/event-stream of the user's workout + feed updates
+// ? Last-Event-ID (header, or ?last_event_id= for clients that can't set it) replays what was missed,
+// ? a "resync" event means the gap is too old to replay and the client should refetch
+func (h *EventStreamHandler) HandleStream(w http.ResponseWriter, req *http.Request) {
+	rc := http.NewResponseController(w)
+	err := rc.SetWriteDeadline(time.Time{}) //* the server WriteTimeout would cut the stream off
+	if err != nil {
+		h.logger.Printf("ERROR: event stream write deadline: %v", err)
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := req.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = req.URL.Query().Get("last_event_id")
+	}
+	lastID, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	messages, missed, complete, cancel := h.hub.Listen(middleware.GetUser(req).ID, lastID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") //* nginx would otherwise buffer the stream
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if !complete {
+		fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+	}
+	for _, msg := range missed {
+		writeEvent(w, msg)
+	}
+	err = rc.Flush()
+	if err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return //* fell behind or the server is shutting down, the client reconnects
+			}
+			writeEvent(w, msg)
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		err = rc.Flush()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ! writeEvent --> one SSE frame, data is always a single JSON line
+func writeEvent(w http.ResponseWriter, msg events.Message) {
+	data, _ := json.Marshal(msg.Data)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fem/internal/experiments"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+)
+
+type ExperimentHandler struct {
+	assigner        *experiments.Assigner //* deterministic variant assignment
+	experimentStore store.ExperimentStore //* exposure log (synced to the warehouse)
+	logger          *log.Logger
+}
+
+//! NewExperimentHandler --> constructor for experiment handler
+func NewExperimentHandler(assigner *experiments.Assigner, experimentStore store.ExperimentStore, logger *log.Logger) *ExperimentHandler {
+	return &ExperimentHandler{
+		assigner:        assigner,
+		experimentStore: experimentStore,
+		logger:          logger,
+	}
+}
+
+//! HandleGetMyExperiments --> GET /users/me/experiments, experiment key -> variant
+//? handing out the assignment is the exposure, so it's logged here
+func (h *ExperimentHandler) HandleGetMyExperiments(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+	assignments := h.assigner.Assign(currentUser.ID)
+
+	variants := make(map[string]string, len(assignments))
+	exposures := make([]store.ExperimentExposure, 0, len(assignments))
+	for _, a := range assignments {
+		variants[a.Experiment] = a.Variant
+		exposures = append(exposures, store.ExperimentExposure{Experiment: a.Experiment, Variant: a.Variant})
+	}
+
+	//* a lost exposure skews analysis slightly, failing the request would block the client
+	err := h.experimentStore.LogExposures(currentUser.ID, exposures)
+	if err != nil {
+		h.logger.Printf("ERROR: logExposures: %v", err)
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"experiments": variants})
+}
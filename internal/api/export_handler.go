@@ -0,0 +1,289 @@
+package api
+
+import (
+	"encoding/json"
+	"fem/internal/export"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"fem/internal/utils"
+	"fem/internal/worker"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+//! export limits
+const (
+	exportLinkTTL     = 24 * time.Hour       //* signed download links stay valid for a day
+	maxExportWindow   = 366 * 24 * time.Hour //* one export covers at most a year
+	exportQuotaWindow = 24 * time.Hour       //* ACCOUNT_EXPORTS_PER_DAY counts over a rolling day
+)
+
+type ExportHandler struct {
+	exportStore  store.ExportStore
+	orgStore     store.OrgStore
+	jobs         worker.Enqueuer //* export.run jobs build the bundle off the request path
+	dailyExports int             //* account exports per user per 24h, 0 = unlimited
+	logger       *log.Logger
+}
+
+//! createOrgExportRequest --> POST /orgs/{id}/exports payload
+type createOrgExportRequest struct {
+	Format    string `json:"format"`    // * defaults to csv
+	Anonymize bool   `json:"anonymize"` // * replace every member name with a pseudonym
+	Locale    string `json:"locale"`    // * CSV number + date format (e.g. de-DE), defaults to Accept-Language
+	From      string `json:"from"`      // * date or RFC3339, defaults to 30 days ago
+	To        string `json:"to"`        // * date or RFC3339, defaults to now
+}
+
+//! createAccountExportRequest --> POST /users/me/export payload (body is optional)
+type createAccountExportRequest struct {
+	Format string `json:"format"` // * defaults to json
+	Locale string `json:"locale"` // * CSV number + date format (e.g. de-DE), defaults to Accept-Language
+}
+
+//! NewExportHandler --> constructor for export handler
+func NewExportHandler(exportStore store.ExportStore, orgStore store.OrgStore, jobs worker.Enqueuer, dailyExports int, logger *log.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportStore:  exportStore,
+		orgStore:     orgStore,
+		jobs:         jobs,
+		dailyExports: dailyExports,
+		logger:       logger,
+	}
+}
+
+//! exportResponse --> job plus a signed download link once it's ready
+func exportResponse(job *store.ExportJob) utils.Envelope {
+	envelope := utils.Envelope{"export": job}
+	if job.Status == store.ExportStatusDone {
+		envelope["download_url"] = tokens.SignURL("/v1/exports/"+utils.FormatID(int64(job.ID))+"/download", exportLinkTTL)
+	}
+	return envelope
+}
+
+//! exportLocale --> the locale the body asked for, otherwise the best match for Accept-Language
+//? false when the body named a locale we can't format for
+func exportLocale(req *http.Request, requested string) (string, bool) {
+	if requested == "" {
+		return export.NegotiateLocale(req.Header.Get("Accept-Language")), true
+	}
+	locale, ok := export.LookupLocale(requested)
+	return locale.Tag, ok
+}
+
+//! HandleCreateOrgExport --> POST /orgs/{id}/exports (owners only)
+//! returns 202 right away, the bundle is produced in the background
+func (h *ExportHandler) HandleCreateOrgExport(w http.ResponseWriter, req *http.Request) {
+	orgID, ok := requireOrgOwner(h.orgStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	var r createOrgExportRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		h.logger.Printf("ERROR: decoding createOrgExport request: %v", err)
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	if r.Format == "" {
+		r.Format = export.FormatCSV
+	}
+	if !export.ValidFormat(r.Format) {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "unsupported export format"})
+		return
+	}
+	locale, ok := exportLocale(req, r.Locale)
+	if !ok {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "unsupported locale"})
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if r.From != "" {
+		from, err = utils.ParseTimeParam(r.From)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid from"})
+			return
+		}
+	}
+	if r.To != "" {
+		to, err = utils.ParseTimeParam(r.To)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid to"})
+			return
+		}
+	}
+	if !from.Before(to) || to.Sub(from) > maxExportWindow {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "from must be before to and cover at most one year"})
+		return
+	}
+
+	id := int(orgID)
+	job := &store.ExportJob{
+		Kind:        store.ExportKindOrg,
+		OrgID:       &id,
+		RequestedBy: middleware.GetUser(req).ID,
+		Format:      r.Format,
+		Anonymize:   r.Anonymize,
+		Locale:      locale,
+		From:        from,
+		To:          to,
+	}
+	h.startExport(w, job)
+}
+
+//! startExport --> creates the exports row and queues the build, answers 202 with the pending job
+func (h *ExportHandler) startExport(w http.ResponseWriter, job *store.ExportJob) {
+	err := h.exportStore.CreateExport(job)
+	if err != nil {
+		h.logger.Printf("ERROR: createExport: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	err = h.jobs.Enqueue(export.JobRun, export.RunPayload{ExportID: int64(job.ID)})
+	if err != nil {
+		h.logger.Printf("ERROR: enqueue export %d: %v", job.ID, err)
+		job.Status = store.ExportStatusFailed
+		job.Error = "export could not be started"
+		h.exportStore.UpdateExport(job)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusAccepted, exportResponse(job))
+}
+
+//! HandleGetOrgExport --> GET /orgs/{id}/exports/{exportID} status polling
+func (h *ExportHandler) HandleGetOrgExport(w http.ResponseWriter, req *http.Request) {
+	orgID, ok := requireOrgOwner(h.orgStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	exportID, err := utils.ReadInt64Param(req, "exportID")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid export id"})
+		return
+	}
+
+	job, err := h.exportStore.GetExport(exportID)
+	if err != nil {
+		h.logger.Printf("ERROR: getExport: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	//? exports of other orgs look exactly like missing ones
+	if job == nil || job.OrgID == nil || int64(*job.OrgID) != orgID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "export not found"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, exportResponse(job))
+}
+
+//! HandleCreateAccountExport --> POST /users/me/export, zip of everything stored about the caller
+func (h *ExportHandler) HandleCreateAccountExport(w http.ResponseWriter, req *http.Request) {
+	var r createAccountExportRequest
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+			return
+		}
+	}
+	if r.Format == "" {
+		r.Format = export.FormatJSON
+	}
+	if !export.ValidFormat(r.Format) {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "unsupported export format"})
+		return
+	}
+	locale, ok := exportLocale(req, r.Locale)
+	if !ok {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "unsupported locale"})
+		return
+	}
+
+	currentUser := middleware.GetUser(req)
+	if h.dailyExports > 0 {
+		recent, err := h.exportStore.ListUserExports(currentUser.ID, time.Now().Add(-exportQuotaWindow))
+		if err != nil {
+			h.logger.Printf("ERROR: listUserExports: %v", err)
+			utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+			return
+		}
+		if len(recent) >= h.dailyExports {
+			utils.WriteJson(w, http.StatusTooManyRequests, utils.Envelope{"error": "export quota reached, see GET /users/me/usage"})
+			return
+		}
+	}
+	h.startExport(w, &store.ExportJob{
+		Kind:        store.ExportKindAccount,
+		RequestedBy: currentUser.ID,
+		Format:      r.Format,
+		Locale:      locale,
+		From:        currentUser.CreatedAt,
+		To:          time.Now(),
+	})
+}
+
+//! HandleGetAccountExport --> GET /users/me/export/{jobID} status polling
+func (h *ExportHandler) HandleGetAccountExport(w http.ResponseWriter, req *http.Request) {
+	exportID, err := utils.ReadInt64Param(req, "jobID")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid export id"})
+		return
+	}
+
+	job, err := h.exportStore.GetExport(exportID)
+	if err != nil {
+		h.logger.Printf("ERROR: getExport: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	//? someone else's export looks exactly like a missing one
+	if job == nil || job.Kind != store.ExportKindAccount || job.RequestedBy != middleware.GetUser(req).ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "export not found"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, exportResponse(job))
+}
+
+//! HandleDownloadExport --> GET /exports/{id}/download?expires=...&signature=...
+//! public route, the signed URL itself is the credential
+func (h *ExportHandler) HandleDownloadExport(w http.ResponseWriter, req *http.Request) {
+	if !tokens.VerifySignedURL(req.URL.Path, req.URL.Query()) {
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "invalid or expired download link"})
+		return
+	}
+
+	exportID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid export id"})
+		return
+	}
+
+	job, err := h.exportStore.GetExport(exportID)
+	if err != nil {
+		h.logger.Printf("ERROR: getExport: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if job == nil || job.Status != store.ExportStatusDone || job.FilePath == "" {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "export not found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filepath.Base(job.FilePath)))
+	http.ServeFile(w, req, job.FilePath)
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fem/internal/events"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fem/internal/worker"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//! feed page sizes
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+type FollowHandler struct {
+	userStore   store.UserStore   //* makes sure the followee exists
+	followStore store.FollowStore //* social graph + feed queries
+	jobs        worker.Enqueuer   //* feed backfill runs in the background
+	bus         *events.Bus       //* FollowerAdded, pushed to the followee
+	logger      *log.Logger
+}
+
+//! NewFollowHandler --> constructor for follow handler
+func NewFollowHandler(userStore store.UserStore, followStore store.FollowStore, jobs worker.Enqueuer, bus *events.Bus, logger *log.Logger) *FollowHandler {
+	return &FollowHandler{
+		userStore:   userStore,
+		followStore: followStore,
+		jobs:        jobs,
+		bus:         bus,
+		logger:      logger,
+	}
+}
+
+//! encodeFeedCursor --> opaque cursor so clients don't depend on its shape
+func encodeFeedCursor(item *store.FeedItem) string {
+	raw := fmt.Sprintf("%d:%d", item.CreatedAt.UnixNano(), item.WorkoutID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFeedCursor(cursor string) (*store.FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var nanos, id int64
+	_, err = fmt.Sscanf(string(raw), "%d:%d", &nanos, &id)
+	if err != nil {
+		return nil, err
+	}
+	return &store.FeedCursor{At: time.Unix(0, nanos), ID: id}, nil
+}
+
+//! readFolloweeID --> {id} from the URL, rejects following yourself and unknown users
+func (h *FollowHandler) readFolloweeID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	followeeID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid user id"})
+		return 0, false
+	}
+	if followeeID == int64(middleware.GetUser(req).ID) {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "you cannot follow yourself"})
+		return 0, false
+	}
+
+	user, err := h.userStore.GetUserByID(followeeID)
+	if err != nil {
+		h.logger.Printf("ERROR: getUserByID: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return 0, false
+	}
+	if user == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "user not found"})
+		return 0, false
+	}
+	return followeeID, true
+}
+
+//! HandleFollow --> POST /users/{id}/follow, idempotent
+func (h *FollowHandler) HandleFollow(w http.ResponseWriter, req *http.Request) {
+	followeeID, ok := h.readFolloweeID(w, req)
+	if !ok {
+		return
+	}
+
+	followerID := int64(middleware.GetUser(req).ID)
+	already, err := h.followStore.IsFollowing(followerID, followeeID)
+	if err != nil {
+		h.logger.Printf("ERROR: isFollowing: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	err = h.followStore.Follow(followerID, followeeID)
+	if err != nil {
+		h.logger.Printf("ERROR: follow: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if !already {
+		h.bus.Publish(events.Event{Type: events.FollowerAdded, UserID: int(followeeID), Ref: strconv.FormatInt(followerID, 10)}) //* following again doesn't notify twice
+	}
+
+	//* the follow is saved either way, a failed enqueue only delays older workouts showing up in the feed
+	err = h.jobs.Enqueue(worker.JobFeedBackfill, worker.FeedBackfillPayload{FollowerID: followerID, FolloweeID: followeeID})
+	if err != nil {
+		h.logger.Printf("ERROR: enqueue feed backfill: %v", err)
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"following": true})
+}
+
+//! HandleUnfollow --> DELETE /users/{id}/follow
+func (h *FollowHandler) HandleUnfollow(w http.ResponseWriter, req *http.Request) {
+	followeeID, ok := h.readFolloweeID(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.followStore.Unfollow(int64(middleware.GetUser(req).ID), followeeID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "you are not following this user"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: unfollow: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//! HandleGetFeed --> GET /feed?limit=&cursor= recent followers/public workouts from people you follow
+func (h *FollowHandler) HandleGetFeed(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	limit := defaultFeedLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(parsed, maxFeedLimit)
+	}
+
+	var before *store.FeedCursor
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := decodeFeedCursor(raw)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid cursor"})
+			return
+		}
+		before = cursor
+	}
+
+	items, err := h.followStore.GetFeed(int64(middleware.GetUser(req).ID), before, limit)
+	if err != nil {
+		h.logger.Printf("ERROR: getFeed: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	//* a full page means there may be more, hand back where it ended
+	var nextCursor *string
+	cursor := ""
+	if len(items) == limit {
+		cursor = encodeFeedCursor(items[len(items)-1])
+		nextCursor = &cursor
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"items": items, "next_cursor": nextCursor}.PaginateCursor(req, "cursor", cursor, limit))
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/goals"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+type GoalHandler struct {
+	goalStore    store.GoalStore    //* goals + computed progress
+	profileStore store.ProfileStore //* starting weight for weight goals
+	logger       *log.Logger
+}
+
+//! createGoalRequest --> POST /goals payload
+type createGoalRequest struct {
+	Type        string  `json:"type"`
+	TargetValue float64 `json:"target_value"`
+	Deadline    *string `json:"deadline"` // * YYYY-MM-DD, optional
+}
+
+//! updateGoalRequest --> PUT /goals/{id} payload, pointers allow partial updates
+type updateGoalRequest struct {
+	TargetValue *float64 `json:"target_value"`
+	Deadline    *string  `json:"deadline"` // * "" clears the deadline
+}
+
+//! NewGoalHandler --> constructor for goal handler
+func NewGoalHandler(goalStore store.GoalStore, profileStore store.ProfileStore, logger *log.Logger) *GoalHandler {
+	return &GoalHandler{
+		goalStore:    goalStore,
+		profileStore: profileStore,
+		logger:       logger,
+	}
+}
+
+//! validateDeadline --> deadlines are plain dates and can't already be over
+func validateDeadline(deadline string) error {
+	parsed, err := time.Parse(time.DateOnly, deadline)
+	if err != nil {
+		return errors.New("deadline must be formatted as YYYY-MM-DD")
+	}
+	if parsed.Before(time.Now().Truncate(24 * time.Hour)) {
+		return errors.New("deadline cannot be in the past")
+	}
+	return nil
+}
+
+//! requireGoalOwner --> loads {id} and makes sure it belongs to the current user, writes the error itself
+func (h *GoalHandler) requireGoalOwner(w http.ResponseWriter, req *http.Request) (*store.Goal, bool) {
+	goalID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid goal id"})
+		return nil, false
+	}
+
+	goal, err := h.goalStore.GetGoal(goalID)
+	if err != nil {
+		h.logger.Printf("ERROR: getGoal: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if goal == nil || goal.UserID != middleware.GetUser(req).ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "goal not found"})
+		return nil, false
+	}
+	return goal, true
+}
+
+//! HandleCreateGoal --> POST /goals
+func (h *GoalHandler) HandleCreateGoal(w http.ResponseWriter, req *http.Request) {
+	var r createGoalRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	if r.Type != store.GoalWeeklyWorkouts && r.Type != store.GoalTotalMinutes && r.Type != store.GoalWeightTarget {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "type must be weekly_workouts, total_minutes or weight_target"})
+		return
+	}
+	if r.TargetValue <= 0 {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "target_value must be greater than 0"})
+		return
+	}
+	if r.Deadline != nil {
+		if err := validateDeadline(*r.Deadline); err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+			return
+		}
+	}
+
+	currentUser := middleware.GetUser(req)
+	goal := &store.Goal{
+		UserID:      currentUser.ID,
+		Type:        r.Type,
+		TargetValue: r.TargetValue,
+		Deadline:    r.Deadline,
+	}
+
+	//* weight goals measure progress from the weight at the time the goal was set
+	if goal.Type == store.GoalWeightTarget {
+		if err := validateWeight(r.TargetValue); err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "target_value: " + err.Error()})
+			return
+		}
+		profile, err := h.profileStore.GetProfile(currentUser.ID)
+		if err != nil {
+			h.logger.Printf("ERROR: getProfile: %v", err)
+			utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+			return
+		}
+		if profile.WeightKG == nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "log a body weight before setting a weight goal"})
+			return
+		}
+		goal.StartValue = profile.WeightKG
+	}
+
+	err = h.goalStore.CreateGoal(goal)
+	if err != nil {
+		h.logger.Printf("ERROR: createGoal: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	//* reload so the response carries current progress
+	created, err := h.goalStore.GetGoal(int64(goal.ID))
+	if err != nil || created == nil {
+		h.logger.Printf("ERROR: getGoal after create: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	goals.Fill(created)
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"goal": created})
+}
+
+//! HandleListGoals --> GET /goals with percent_complete for each goal
+func (h *GoalHandler) HandleListGoals(w http.ResponseWriter, req *http.Request) {
+	list, err := h.goalStore.ListGoals(int64(middleware.GetUser(req).ID))
+	if err != nil {
+		h.logger.Printf("ERROR: listGoals: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	goals.Fill(list...)
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"goals": list})
+}
+
+//! HandleGetGoal --> GET /goals/{id}
+func (h *GoalHandler) HandleGetGoal(w http.ResponseWriter, req *http.Request) {
+	goal, ok := h.requireGoalOwner(w, req)
+	if !ok {
+		return
+	}
+	goals.Fill(goal)
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"goal": goal})
+}
+
+//! HandleUpdateGoal --> PUT /goals/{id}
+func (h *GoalHandler) HandleUpdateGoal(w http.ResponseWriter, req *http.Request) {
+	goal, ok := h.requireGoalOwner(w, req)
+	if !ok {
+		return
+	}
+
+	var r updateGoalRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	if r.TargetValue != nil {
+		if *r.TargetValue <= 0 {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "target_value must be greater than 0"})
+			return
+		}
+		goal.TargetValue = *r.TargetValue
+	}
+	if r.Deadline != nil {
+		if *r.Deadline == "" {
+			goal.Deadline = nil
+		} else if err := validateDeadline(*r.Deadline); err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+			return
+		} else {
+			goal.Deadline = r.Deadline
+		}
+	}
+
+	err = h.goalStore.UpdateGoal(goal)
+	if err != nil {
+		h.logger.Printf("ERROR: updateGoal: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	goals.Fill(goal)
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"goal": goal})
+}
+
+//! HandleDeleteGoal --> DELETE /goals/{id}
+func (h *GoalHandler) HandleDeleteGoal(w http.ResponseWriter, req *http.Request) {
+	goal, ok := h.requireGoalOwner(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.goalStore.DeleteGoal(int64(goal.ID))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		h.logger.Printf("ERROR: deleteGoal: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
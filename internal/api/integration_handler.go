@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fem/internal/integrations"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fem/internal/worker"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ! connectStateTTL --> how long the user has to approve access on Strava
+const connectStateTTL = 15 * time.Minute
+
+type IntegrationHandler struct {
+	integrationStore store.IntegrationStore     //* connected accounts + sync state
+	strava           *integrations.StravaClient //* nil when STRAVA_CLIENT_ID isn't configured
+	jobs             worker.Enqueuer            //* syncs run in the background
+	logger           *log.Logger
+}
+
+// ! integrationStatus --> GET /integrations item, Connection is null until the user connects
+type integrationStatus struct {
+	Provider   string                       `json:"provider"`
+	Available  bool                         `json:"available"` //* configured on this server
+	Connected  bool                         `json:"connected"`
+	Connection *store.IntegrationConnection `json:"connection"`
+}
+
+// ! NewIntegrationHandler --> constructor for integration handler
+func NewIntegrationHandler(integrationStore store.IntegrationStore, strava *integrations.StravaClient, jobs worker.Enqueuer, logger *log.Logger) *IntegrationHandler {
+	return &IntegrationHandler{
+		integrationStore: integrationStore,
+		strava:           strava,
+		jobs:             jobs,
+		logger:           logger,
+	}
+}
+
+// ! requireStrava --> 404s every Strava endpoint when the integration isn't configured
+func (h *IntegrationHandler) requireStrava(w http.ResponseWriter) bool {
+	if h.strava == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "strava integration is not enabled"})
+		return false
+	}
+	return true
+}
+
+// ! enqueueSync --> best effort, the periodic sync picks the connection up anyway
+func (h *IntegrationHandler) enqueueSync(connectionID int64) error {
+	err := h.jobs.Enqueue(integrations.JobSync, integrations.SyncPayload{ConnectionID: connectionID})
+	if err != nil {
+		h.logger.Printf("ERROR: enqueue integration sync: %v", err)
+	}
+	return err
+}
+
+// ! HandleListIntegrations --> GET /integrations
+func (h *IntegrationHandler) HandleListIntegrations(w http.ResponseWriter, req *http.Request) {
+	connections, err := h.integrationStore.ListConnections(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listConnections: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	strava := integrationStatus{Provider: store.ProviderStrava, Available: h.strava != nil}
+	for _, c := range connections {
+		if c.Provider == store.ProviderStrava {
+			strava.Connected = true
+			strava.Connection = c
+		}
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"integrations": []integrationStatus{strava}})
+}
+
+// ! HandleConnectStrava --> POST /integrations/strava/connect, the client opens authorize_url in a browser
+func (h *IntegrationHandler) HandleConnectStrava(w http.ResponseWriter, req *http.Request) {
+	if !h.requireStrava(w) {
+		return
+	}
+
+	state := integrations.SignState(store.ProviderStrava, middleware.GetUser(req).ID, connectStateTTL)
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"authorize_url": h.strava.AuthorizeURL(state)})
+}
+
+// ! HandleStravaCallback --> GET /integrations/strava/callback?code=&state=&scope= public, Strava redirects the browser here
+// ? the signed state stands in for the bearer token the browser doesn't have
+func (h *IntegrationHandler) HandleStravaCallback(w http.ResponseWriter, req *http.Request) {
+	if !h.requireStrava(w) {
+		return
+	}
+
+	query := req.URL.Query()
+	userID, ok := integrations.VerifyState(store.ProviderStrava, query.Get("state"))
+	if !ok {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid or expired state, start the connection again"})
+		return
+	}
+	if query.Get("error") != "" || query.Get("code") == "" {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "strava access was not granted"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 30*time.Second)
+	defer cancel()
+	token, err := h.strava.Exchange(ctx, query.Get("code"))
+	if err != nil {
+		h.logger.Printf("ERROR: strava exchange: %v", err)
+		utils.WriteJson(w, http.StatusBadGateway, utils.Envelope{"error": "could not reach strava"})
+		return
+	}
+	if token.Athlete == nil {
+		utils.WriteJson(w, http.StatusBadGateway, utils.Envelope{"error": "strava did not return an athlete"})
+		return
+	}
+
+	connection := &store.IntegrationConnection{
+		UserID:         userID,
+		Provider:       store.ProviderStrava,
+		ExternalUserID: strconv.FormatInt(token.Athlete.ID, 10),
+		AccessToken:    token.AccessToken,
+		RefreshToken:   token.RefreshToken,
+		TokenExpiresAt: time.Unix(token.ExpiresAt, 0),
+		Scope:          query.Get("scope"),
+	}
+	err = h.integrationStore.UpsertConnection(connection)
+	if err != nil {
+		h.logger.Printf("ERROR: upsertConnection: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	h.enqueueSync(connection.ID)
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"integration": integrationStatus{
+		Provider:   store.ProviderStrava,
+		Available:  true,
+		Connected:  true,
+		Connection: connection,
+	}})
+}
+
+// ! HandleSyncStrava --> POST /integrations/strava/sync, pulls new activities now instead of waiting for the schedule
+func (h *IntegrationHandler) HandleSyncStrava(w http.ResponseWriter, req *http.Request) {
+	if !h.requireStrava(w) {
+		return
+	}
+
+	connection, err := h.integrationStore.GetConnection(middleware.GetUser(req).ID, store.ProviderStrava)
+	if err != nil {
+		h.logger.Printf("ERROR: getConnection: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if connection == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "strava is not connected"})
+		return
+	}
+
+	err = h.enqueueSync(connection.ID)
+	if err != nil {
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, http.StatusAccepted, utils.Envelope{"status": "queued"})
+}
+
+// ! HandleDisconnectStrava --> DELETE /integrations/strava, already imported workouts stay
+func (h *IntegrationHandler) HandleDisconnectStrava(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+	connection, err := h.integrationStore.GetConnection(currentUser.ID, store.ProviderStrava)
+	if err != nil {
+		h.logger.Printf("ERROR: getConnection: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if connection == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "strava is not connected"})
+		return
+	}
+
+	//* revoking at Strava is a courtesy, our side forgets the tokens either way
+	if h.strava != nil {
+		ctx, cancel := context.WithTimeout(req.Context(), 10*time.Second)
+		err = h.strava.Deauthorize(ctx, connection.AccessToken)
+		cancel()
+		if err != nil {
+			h.logger.Printf("ERROR: strava deauthorize: %v", err)
+		}
+	}
+
+	err = h.integrationStore.DeleteConnection(currentUser.ID, store.ProviderStrava)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "strava is not connected"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: deleteConnection: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fem/internal/gamification"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//! leaderboard page sizes
+const (
+	defaultLeaderboardLimit = 25
+	maxLeaderboardLimit     = 100
+)
+
+type LeaderboardHandler struct {
+	xp           *gamification.Service  //* XP standings
+	leaderboards store.LeaderboardStore //* cached weekly + streak numbers, nil answers 501
+	logger       *log.Logger
+}
+
+//! NewLeaderboardHandler --> constructor for leaderboard handler
+func NewLeaderboardHandler(xp *gamification.Service, leaderboardStore store.LeaderboardStore, logger *log.Logger) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		xp:           xp,
+		leaderboards: leaderboardStore,
+		logger:       logger,
+	}
+}
+
+//! readLeaderboardLimit --> ?limit= with a default and a cap
+func readLeaderboardLimit(req *http.Request) int {
+	limit, err := strconv.Atoi(req.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		return defaultLeaderboardLimit
+	}
+	return min(limit, maxLeaderboardLimit)
+}
+
+//! HandleXPLeaderboard --> GET /leaderboards/xp top users by (decayed) XP with their level
+func (h *LeaderboardHandler) HandleXPLeaderboard(w http.ResponseWriter, req *http.Request) {
+	standings, err := h.xp.Leaderboard(readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: xp leaderboard: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"leaderboard": standings})
+}
+
+//! HandleMetricLeaderboard --> GET /leaderboards/{metric}?scope=global|friends, metric is weekly_minutes, weekly_workouts or streak
+//? numbers come from the leaderboard_stats cache (LEADERBOARD_REFRESH_INTERVAL), friends = you + the users you follow
+func (h *LeaderboardHandler) HandleMetricLeaderboard(w http.ResponseWriter, req *http.Request) {
+	if h.leaderboards == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "leaderboards are not available on this server"})
+		return
+	}
+	metric := chi.URLParam(req, "metric")
+	if !store.ValidLeaderboardMetric(metric) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "unknown leaderboard, use weekly_minutes, weekly_workouts or streak"})
+		return
+	}
+
+	var scope store.LeaderboardScope
+	switch req.URL.Query().Get("scope") {
+	case "", "global":
+	case "friends":
+		user := middleware.GetUser(req)
+		if user.IsAnonymousUser() {
+			utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "you must be logged in to see your friends' leaderboard"})
+			return
+		}
+		scope.FriendsOf = user.ID
+	default:
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "scope must be global or friends"})
+		return
+	}
+
+	entries, err := h.leaderboards.TopLeaderboard(metric, scope, readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: %s leaderboard: %v", metric, err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	standings := make([]metricStanding, 0, len(entries))
+	for i, entry := range entries {
+		standings = append(standings, metricStanding{Rank: i + 1, LeaderboardEntry: entry})
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"metric": metric, "leaderboard": standings})
+}
+
+//* metricStanding --> an entry with its position, equal values still get consecutive ranks like the XP board
+type metricStanding struct {
+	Rank int `json:"rank"`
+	*store.LeaderboardEntry
+}
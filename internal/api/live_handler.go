@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fem/internal/live"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// ! liveSubprotocol --> negotiated on /ws, browsers also offer "bearer.<token>" since they can't set Authorization
+const (
+	liveSubprotocol = "fittrack.live.v1"
+	bearerProtocol  = "bearer."
+)
+
+type LiveHandler struct {
+	hub            *live.Hub
+	userStore      store.UserStore   //* token lookup for browser clients
+	followStore    store.FollowStore //* only followers may watch a session
+	allowedOrigins []string          //* extra browser origins besides the API host itself
+	upgrader       websocket.Upgrader
+	logger         *log.Logger
+}
+
+// ! NewLiveHandler --> constructor for live session handler
+func NewLiveHandler(hub *live.Hub, userStore store.UserStore, followStore store.FollowStore, allowedOrigins []string, logger *log.Logger) *LiveHandler {
+	h := &LiveHandler{
+		hub:            hub,
+		userStore:      userStore,
+		followStore:    followStore,
+		allowedOrigins: allowedOrigins,
+		logger:         logger,
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    []string{liveSubprotocol},
+		CheckOrigin:     h.checkOrigin,
+	}
+	return h
+}
+
+// ! checkOrigin --> native apps send no Origin, browsers must come from the API host or WS_ALLOWED_ORIGINS
+// ? without this any website could open a socket with the visitor's token
+func (h *LiveHandler) checkOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, req.Host) || slices.Contains(h.allowedOrigins, origin)
+}
+
+// ! authenticate --> the Authorization header (already checked by the middleware) or a bearer.<token> subprotocol
+func (h *LiveHandler) authenticate(req *http.Request) (*store.User, error) {
+	user := middleware.GetUser(req)
+	if !user.IsAnonymousUser() {
+		return user, nil
+	}
+	for _, protocol := range websocket.Subprotocols(req) {
+		if token, ok := strings.CutPrefix(protocol, bearerProtocol); ok {
+			return h.userStore.GetUserToken(tokens.ScopeAuth, token)
+		}
+	}
+	return nil, nil
+}
+
+// ! HandleWebSocket --> GET /ws, upgrades and runs the live session protocol (see live.Incoming / live.Outgoing)
+// ? auth happens before the upgrade so a bad token is a plain 401, not a socket that closes right away
+func (h *LiveHandler) HandleWebSocket(w http.ResponseWriter, req *http.Request) {
+	user, err := h.authenticate(req)
+	if err != nil {
+		h.logger.Printf("ERROR: live authenticate: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if user == nil {
+		utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "you must be logged in to access this route"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return //* Upgrade already wrote the error response
+	}
+
+	client := live.NewClient(conn, user.ID)
+	client.Run(h.hub, func(hostID int) (bool, error) {
+		if hostID == user.ID {
+			return true, nil
+		}
+		return h.followStore.IsFollowing(int64(user.ID), int64(hostID))
+	})
+}
+
+// ! HandleListLiveSessions --> GET /live-sessions, sessions the current user can join right now
+func (h *LiveHandler) HandleListLiveSessions(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+	var lookupErr error
+	sessions := h.hub.Sessions(func(hostID int) bool {
+		if hostID == currentUser.ID {
+			return true
+		}
+		following, err := h.followStore.IsFollowing(int64(currentUser.ID), int64(hostID))
+		if err != nil {
+			lookupErr = err
+		}
+		return following
+	})
+	if lookupErr != nil {
+		h.logger.Printf("ERROR: isFollowing: %v", lookupErr)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"sessions": sessions})
+}
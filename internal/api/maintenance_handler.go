@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"fem/internal/maintenance"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+)
+
+type MaintenanceHandler struct {
+	mode   *maintenance.Mode
+	logger *log.Logger
+}
+
+// ! NewMaintenanceHandler --> constructor for the maintenance admin endpoints
+func NewMaintenanceHandler(mode *maintenance.Mode, logger *log.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{mode: mode, logger: logger}
+}
+
+// ! setMaintenanceRequest --> PUT /admin/maintenance payload
+type setMaintenanceRequest struct {
+	Enabled    *bool  `json:"enabled"`
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retry_after_seconds"` // * optional, keeps the current value
+}
+
+// ! HandleGetMaintenance --> GET /admin/maintenance
+func (h *MaintenanceHandler) HandleGetMaintenance(w http.ResponseWriter, req *http.Request) {
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"maintenance": h.mode.Status()})
+}
+
+// ! HandleSetMaintenance --> PUT /admin/maintenance switches this instance, takes effect on the next request
+// ? not persisted --> a restart goes back to MAINTENANCE_MODE
+func (h *MaintenanceHandler) HandleSetMaintenance(w http.ResponseWriter, req *http.Request) {
+	var r setMaintenanceRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil || r.Enabled == nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "enabled is required"})
+		return
+	}
+	if r.RetryAfter < 0 {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "retry_after_seconds can't be negative"})
+		return
+	}
+
+	var status maintenance.Status
+	if *r.Enabled {
+		status = h.mode.Enable(r.Message, r.RetryAfter)
+		h.logger.Printf("maintenance: on (retry after %ds): %s", status.RetryAfter, status.Message)
+	} else {
+		status = h.mode.Disable()
+		h.logger.Printf("maintenance: off")
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"maintenance": status})
+}
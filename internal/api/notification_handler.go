@@ -0,0 +1,283 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ! maxDeviceTokenLength --> FCM tokens run to ~160 characters and APNs ones to 64 bytes hex, anything this long is junk
+const maxDeviceTokenLength = 4096
+
+// ! inbox page sizes
+const (
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+)
+
+type NotificationHandler struct {
+	notificationStore store.NotificationStore //* devices, preferences + the inbox, nil answers 501
+	logger            *log.Logger
+}
+
+// ! registerDeviceRequest --> POST /devices payload
+type registerDeviceRequest struct {
+	Platform string `json:"platform"` // * fcm or apns
+	Token    string `json:"token"`
+	Name     string `json:"name"` // * optional label, e.g. "Pixel 8"
+}
+
+// ! notificationPreferencesRequest --> PUT /users/me/notification-preferences payload, pointers allow partial updates
+type notificationPreferencesRequest struct {
+	Comments     *bool `json:"comments"`
+	Followers    *bool `json:"followers"`
+	Challenges   *bool `json:"challenges"`
+	Reminders    *bool `json:"reminders"`
+	Achievements *bool `json:"achievements"`
+	Email        *bool `json:"email"`
+	Push         *bool `json:"push"`
+	InApp        *bool `json:"in_app"`
+	WeeklyDigest *bool `json:"weekly_digest"`
+}
+
+// ! NewNotificationHandler --> constructor for notification handler
+func NewNotificationHandler(notificationStore store.NotificationStore, logger *log.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationStore: notificationStore,
+		logger:            logger,
+	}
+}
+
+// ! available --> writes the 501 on servers without the notification tables (sqlite)
+func (h *NotificationHandler) available(w http.ResponseWriter) bool {
+	if h.notificationStore == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "notifications are not available on this server"})
+		return false
+	}
+	return true
+}
+
+// ! HandleRegisterDevice --> POST /devices, registering a known token again just refreshes it
+func (h *NotificationHandler) HandleRegisterDevice(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	var r registerDeviceRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	if r.Platform != store.DevicePlatformFCM && r.Platform != store.DevicePlatformAPNs {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "platform must be fcm or apns"})
+		return
+	}
+	r.Token = strings.TrimSpace(r.Token)
+	if r.Token == "" || len(r.Token) > maxDeviceTokenLength {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "token is required"})
+		return
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) > 100 {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "name must be at most 100 characters"})
+		return
+	}
+
+	device := &store.Device{UserID: middleware.GetUser(req).ID, Platform: r.Platform, Token: r.Token, Name: r.Name}
+	err = h.notificationStore.RegisterDevice(device)
+	if err != nil {
+		writeStoreError(w, h.logger, "registerDevice", err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"device": device})
+}
+
+// ! HandleListDevices --> GET /devices, tokens are left out
+func (h *NotificationHandler) HandleListDevices(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	devices, err := h.notificationStore.ListDevices(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listDevices: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"devices": devices})
+}
+
+// ! HandleDeleteDevice --> DELETE /devices/{id}, e.g. on logout
+func (h *NotificationHandler) HandleDeleteDevice(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	deviceID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid device id"})
+		return
+	}
+
+	err = h.notificationStore.DeleteDevice(deviceID, middleware.GetUser(req).ID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "device not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: deleteDevice: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! HandleGetPreferences --> GET /users/me/notification-preferences
+func (h *NotificationHandler) HandleGetPreferences(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	prefs, err := h.notificationStore.GetNotificationPreferences(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: getNotificationPreferences: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"preferences": prefs})
+}
+
+// ! HandleUpdatePreferences --> PUT /users/me/notification-preferences, categories + channels left out keep their setting
+func (h *NotificationHandler) HandleUpdatePreferences(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	var r notificationPreferencesRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	userID := middleware.GetUser(req).ID
+	prefs, err := h.notificationStore.GetNotificationPreferences(userID)
+	if err != nil {
+		h.logger.Printf("ERROR: getNotificationPreferences: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	fields := map[*bool]*bool{
+		&prefs.Comments: r.Comments, &prefs.Followers: r.Followers, &prefs.Challenges: r.Challenges, &prefs.Reminders: r.Reminders,
+		&prefs.Achievements: r.Achievements, &prefs.Email: r.Email, &prefs.Push: r.Push, &prefs.InApp: r.InApp,
+		&prefs.WeeklyDigest: r.WeeklyDigest,
+	}
+	for field, value := range fields {
+		if value != nil {
+			*field = *value
+		}
+	}
+
+	err = h.notificationStore.UpdateNotificationPreferences(userID, prefs)
+	if err != nil {
+		writeStoreError(w, h.logger, "updateNotificationPreferences", err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"preferences": prefs})
+}
+
+// ! HandleListNotifications --> GET /notifications?offset=&limit=&unread=true, newest first with the unread count
+func (h *NotificationHandler) HandleListNotifications(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	query := req.URL.Query()
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultNotificationLimit
+	}
+	limit = min(limit, maxNotificationLimit)
+	unreadOnly := query.Get("unread") == "true"
+
+	userID := middleware.GetUser(req).ID
+	notifications, total, err := h.notificationStore.ListNotifications(userID, unreadOnly, offset, limit)
+	if err != nil {
+		h.logger.Printf("ERROR: listNotifications: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	unread, err := h.notificationStore.CountUnreadNotifications(userID)
+	if err != nil {
+		h.logger.Printf("ERROR: countUnreadNotifications: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"notifications": notifications, "unread": unread, "total": total, "offset": offset, "limit": limit}.Paginate(req, offset, limit, len(notifications), &total))
+}
+
+// ! HandleGetUnreadCount --> GET /notifications/unread-count, cheap enough to poll for a badge
+func (h *NotificationHandler) HandleGetUnreadCount(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	unread, err := h.notificationStore.CountUnreadNotifications(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: countUnreadNotifications: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"unread": unread})
+}
+
+// ! HandleMarkRead --> POST /notifications/{id}/read, marking it again is fine
+func (h *NotificationHandler) HandleMarkRead(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	notificationID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid notification id"})
+		return
+	}
+
+	err = h.notificationStore.MarkNotificationRead(notificationID, middleware.GetUser(req).ID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "notification not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: markNotificationRead: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! HandleMarkAllRead --> POST /notifications/read-all, answers how many were unread
+func (h *NotificationHandler) HandleMarkAllRead(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	count, err := h.notificationStore.MarkAllNotificationsRead(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: markAllNotificationsRead: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"marked_read": count})
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fem/internal/integrations"
+	"fem/internal/middleware"
+	"fem/internal/oauth"
+	"fem/internal/service"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ! loginStateTTL --> how long the user has to finish signing in at the provider
+const loginStateTTL = 10 * time.Minute
+
+// * state cookies --> the state also goes into a cookie, so a callback only works in the browser that started it
+const (
+	loginStateCookie = "oauth_state"
+	linkStateCookie  = "oauth_link_state" //* its own name, a sign-in started meanwhile doesn't break a link
+)
+
+type OAuthHandler struct {
+	auth      *service.AuthService       //* resolves identities to users + issues tokens
+	providers map[string]*oauth.Provider //* only the configured ones
+	logger    *log.Logger
+}
+
+// ! NewOAuthHandler --> constructor for oauth handler
+func NewOAuthHandler(authService *service.AuthService, providers map[string]*oauth.Provider, logger *log.Logger) *OAuthHandler {
+	return &OAuthHandler{auth: authService, providers: providers, logger: logger}
+}
+
+// ! provider --> {provider} from the path, writes the 404 itself when it isn't configured
+func (h *OAuthHandler) provider(w http.ResponseWriter, req *http.Request) (*oauth.Provider, bool) {
+	provider, ok := h.providers[chi.URLParam(req, "provider")]
+	if !ok {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "sign in with this provider is not enabled"})
+		return nil, false
+	}
+	return provider, true
+}
+
+// ! HandleLogin --> GET /auth/{provider}/login, redirects the browser to the provider's sign-in page
+func (h *OAuthHandler) HandleLogin(w http.ResponseWriter, req *http.Request) {
+	provider, ok := h.provider(w, req)
+	if !ok {
+		return
+	}
+
+	state := integrations.SignState("login:"+provider.Name, 0, loginStateTTL)
+	setStateCookie(w, req, loginStateCookie, state)
+	http.Redirect(w, req, provider.AuthorizeURL(state), http.StatusFound)
+}
+
+// * setStateCookie --> remembers state in the browser the flow started in
+func setStateCookie(w http.ResponseWriter, req *http.Request, name, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   int(loginStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode, //* Lax still sends it on the provider's top-level redirect back
+	})
+}
+
+// * takeStateCookie --> true when the callback's state is the one this browser started with, the cookie is used up then
+func takeStateCookie(w http.ResponseWriter, req *http.Request, name, state string) bool {
+	cookie, err := req.Cookie(name)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return false
+	}
+	http.SetCookie(w, &http.Cookie{Name: name, Path: "/", MaxAge: -1})
+	return true
+}
+
+// ! HandleStartLink --> POST /users/me/linked-accounts/{provider}, the client opens authorize_url in the same browser
+// ? the only way to attach a provider to an existing account, the signed state carries who asked for it;
+// ? the state cookie set here keeps an authorize_url sent to someone else from linking their identity to this account
+func (h *OAuthHandler) HandleStartLink(w http.ResponseWriter, req *http.Request) {
+	provider, ok := h.provider(w, req)
+	if !ok {
+		return
+	}
+
+	state := integrations.SignState("link:"+provider.Name, middleware.GetUser(req).ID, loginStateTTL)
+	setStateCookie(w, req, linkStateCookie, state)
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"authorize_url": provider.AuthorizeURL(state)})
+}
+
+// ! identity --> trades the callback's code for the provider identity, writes the error itself
+func (h *OAuthHandler) identity(w http.ResponseWriter, req *http.Request, provider *oauth.Provider) (*oauth.Identity, bool) {
+	query := req.URL.Query()
+	if query.Get("error") != "" || query.Get("code") == "" {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "sign in was not granted"})
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 30*time.Second)
+	defer cancel()
+	accessToken, err := provider.Exchange(ctx, query.Get("code"))
+	if err != nil {
+		h.logger.Printf("ERROR: oauth exchange: %v", err)
+		utils.WriteJson(w, http.StatusBadGateway, utils.Envelope{"error": "could not reach " + provider.Name})
+		return nil, false
+	}
+	identity, err := provider.Identity(ctx, accessToken)
+	if err != nil {
+		h.logger.Printf("ERROR: oauth identity: %v", err)
+		utils.WriteJson(w, http.StatusBadGateway, utils.Envelope{"error": "could not reach " + provider.Name})
+		return nil, false
+	}
+	return identity, true
+}
+
+// ! HandleCallback --> GET /auth/{provider}/callback?code=&state= public, the provider redirects the browser here
+// ? answers like POST /tokens/authentication: 201 + auth_token, or 202 + challenge_token when 2FA is on;
+// ? a state from HandleStartLink links the identity instead and answers 201 + linked_account
+func (h *OAuthHandler) HandleCallback(w http.ResponseWriter, req *http.Request) {
+	provider, ok := h.provider(w, req)
+	if !ok {
+		return
+	}
+
+	state := req.URL.Query().Get("state")
+	if userID, ok := integrations.VerifyState("link:"+provider.Name, state); ok {
+		if !takeStateCookie(w, req, linkStateCookie, state) {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid or expired state, start linking again"})
+			return
+		}
+		h.link(w, req, provider, userID)
+		return
+	}
+
+	_, valid := integrations.VerifyState("login:"+provider.Name, state)
+	if !valid || !takeStateCookie(w, req, loginStateCookie, state) {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid or expired state, start signing in again"})
+		return
+	}
+	identity, ok := h.identity(w, req, provider)
+	if !ok {
+		return
+	}
+
+	token, created, err := h.auth.LoginWithProvider(req.Context(), identity)
+	var twoFactor *service.TwoFactorRequiredError
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &twoFactor):
+		utils.WriteJson(w, http.StatusAccepted, utils.Envelope{"two_factor_required": true, "challenge_token": twoFactor.Challenge})
+		return
+	case errors.Is(err, oauth.ErrNoEmail):
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": provider.Name + " did not share a verified email address"})
+		return
+	case errors.Is(err, service.ErrInvalidCredentials):
+		utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "account is no longer active"})
+		return
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": invalid.Message})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: loginWithProvider: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"auth_token": token, "created": created})
+}
+
+// * link --> the callback of a HandleStartLink flow, userID comes from the verified state
+func (h *OAuthHandler) link(w http.ResponseWriter, req *http.Request, provider *oauth.Provider, userID int) {
+	identity, ok := h.identity(w, req, provider)
+	if !ok {
+		return
+	}
+
+	account, err := h.auth.LinkProvider(req.Context(), userID, identity)
+	var invalid *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials):
+		utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "account is no longer active"})
+		return
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": invalid.Message})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: linkProvider: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"linked_account": account})
+}
+
+// ! HandleListLinkedAccounts --> GET /users/me/linked-accounts
+func (h *OAuthHandler) HandleListLinkedAccounts(w http.ResponseWriter, req *http.Request) {
+	accounts, err := h.auth.LinkedProviders(req.Context(), middleware.GetUser(req))
+	if err != nil {
+		h.logger.Printf("ERROR: listLinkedAccounts: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"linked_accounts": accounts})
+}
+
+// ! HandleUnlink --> DELETE /users/me/linked-accounts/{provider}
+func (h *OAuthHandler) HandleUnlink(w http.ResponseWriter, req *http.Request) {
+	err := h.auth.UnlinkProvider(req.Context(), middleware.GetUser(req), chi.URLParam(req, "provider"))
+	var invalid *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "provider is not linked"})
+		return
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": invalid.Message})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: unlinkProvider: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/middleware"
+	"fem/internal/oauth"
+	"fem/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * withProvider --> {provider} on req, the way the router sets it
+func withProvider(req *http.Request, name string) *http.Request {
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("provider", name)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+}
+
+// ! TestOAuthLinkStateBoundToBrowser --> a link callback only works with the cookie HandleStartLink set, a forwarded authorize_url does nothing
+func TestOAuthLinkStateBoundToBrowser(t *testing.T) {
+	provider := &oauth.Provider{Name: "github", ClientID: "client", AuthURL: "https://github.example/authorize"}
+	h := NewOAuthHandler(nil, map[string]*oauth.Provider{"github": provider}, log.New(io.Discard, "", 0))
+
+	req := withProvider(httptest.NewRequest(http.MethodPost, "/users/me/linked-accounts/github", nil), "github")
+	req = middleware.SetUser(req, &store.User{ID: 7, Username: "ana"})
+	rec := httptest.NewRecorder()
+	h.HandleStartLink(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		AuthorizeURL string `json:"authorize_url"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	authorize, err := url.Parse(body.AuthorizeURL)
+	require.NoError(t, err)
+	state := authorize.Query().Get("state")
+	require.NotEmpty(t, state)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, linkStateCookie, cookies[0].Name)
+	assert.Equal(t, state, cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+
+	callback := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := withProvider(httptest.NewRequest(http.MethodGet, "/auth/github/callback?code=abc&state="+url.QueryEscape(state), nil), "github")
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		h.HandleCallback(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, callback(nil).Code, "another browser has no cookie")
+	assert.Equal(t, http.StatusBadRequest, callback(&http.Cookie{Name: linkStateCookie, Value: "someone-elses"}).Code)
+	assert.Equal(t, http.StatusBadRequest, callback(&http.Cookie{Name: loginStateCookie, Value: state}).Code, "a sign-in cookie doesn't stand in for the link one")
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type OrgHandler struct {
+	orgStore store.OrgStore //* org + membership queries
+	logger   *log.Logger    //* for error logging
+}
+
+//! createOrgRequest --> payload for creating a new org
+type createOrgRequest struct {
+	Name string `json:"name"`
+}
+
+//! updatePrivacyRequest --> member's own org privacy settings
+type updatePrivacyRequest struct {
+	ShareStats *bool `json:"share_stats"`
+}
+
+//! updateRoleRequest --> owner changing a member's role
+type updateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+//! NewOrgHandler --> constructor for org handler
+func NewOrgHandler(orgStore store.OrgStore, logger *log.Logger) *OrgHandler {
+	return &OrgHandler{
+		orgStore: orgStore,
+		logger:   logger,
+	}
+}
+
+//! requireOrgOwner --> loads the org from the {id} param and checks the current user owns it
+//? an owner deactivated through SCIM loses owner rights along with the membership
+//? writes the error response itself, callers just return when ok is false
+func requireOrgOwner(orgStore store.OrgStore, logger *log.Logger, w http.ResponseWriter, req *http.Request) (orgID int64, ok bool) {
+	orgID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid org id"})
+		return 0, false
+	}
+
+	currentUser := middleware.GetUser(req)
+	member, err := orgStore.GetMember(orgID, currentUser.ID)
+	if err != nil {
+		logger.Printf("ERROR: getMember: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return 0, false
+	}
+	if member == nil || !member.Active || member.Role != store.OrgRoleOwner {
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "you must be an owner of this org"})
+		return 0, false
+	}
+	return orgID, true
+}
+
+//! HandleCreateOrg --> POST /orgs (creator becomes owner)
+func (h *OrgHandler) HandleCreateOrg(w http.ResponseWriter, req *http.Request) {
+	var r createOrgRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		h.logger.Printf("ERROR: decoding createOrg request: %v", err)
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 255 {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "name is required and must be at most 255 characters"})
+		return
+	}
+
+	org := &store.Org{Name: r.Name}
+	err = h.orgStore.CreateOrg(org, middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: createOrg: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"org": org})
+}
+
+//! HandleRotateSCIMToken --> POST /orgs/{id}/scim-token
+//! issues a fresh SCIM bearer token for the org's identity provider (shown only once)
+func (h *OrgHandler) HandleRotateSCIMToken(w http.ResponseWriter, req *http.Request) {
+	orgID, ok := requireOrgOwner(h.orgStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	//* SCIM tokens don't expire, they live until the owner rotates them again
+	token, err := tokens.GenerateToken(0, 0, tokens.ScopeSCIM)
+	if err != nil {
+		h.logger.Printf("ERROR: generating scim token: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	err = h.orgStore.SetSCIMTokenHash(orgID, token.Hash)
+	if err != nil {
+		h.logger.Printf("ERROR: setSCIMTokenHash: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"scim_token": token.Plaintext})
+}
+
+//! HandleUpdateMyPrivacy --> PUT /orgs/{id}/members/me/privacy
+//! lets a member keep their name out of org exports (they show up anonymized instead)
+func (h *OrgHandler) HandleUpdateMyPrivacy(w http.ResponseWriter, req *http.Request) {
+	orgID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid org id"})
+		return
+	}
+
+	var r updatePrivacyRequest
+	err = json.NewDecoder(req.Body).Decode(&r)
+	if err != nil || r.ShareStats == nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "share_stats is required"})
+		return
+	}
+
+	err = h.orgStore.SetShareStats(orgID, middleware.GetUser(req).ID, *r.ShareStats)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "you are not a member of this org"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: setShareStats: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"share_stats": *r.ShareStats})
+}
+
+//! HandleUpdateMemberRole --> PUT /orgs/{id}/members/{userID}/role (owners only)
+func (h *OrgHandler) HandleUpdateMemberRole(w http.ResponseWriter, req *http.Request) {
+	orgID, ok := requireOrgOwner(h.orgStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	userID, err := utils.ReadInt64Param(req, "userID")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid user id"})
+		return
+	}
+
+	var r updateRoleRequest
+	err = json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	if r.Role != store.OrgRoleOwner && r.Role != store.OrgRoleCoach && r.Role != store.OrgRoleMember {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "role must be owner, coach or member"})
+		return
+	}
+	//? owners can't demote themselves, otherwise an org could end up with nobody in charge
+	if int(userID) == middleware.GetUser(req).ID && r.Role != store.OrgRoleOwner {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "you cannot change your own role"})
+		return
+	}
+
+	err = h.orgStore.SetMemberRole(orgID, int(userID), r.Role)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "user is not a member of this org"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: setMemberRole: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"role": r.Role})
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"errors"
+	"fem/internal/patch"
+	"fem/internal/utils"
+	"net/http"
+)
+
+// ! patchApplied --> false once the error response for a failed patch.Changes is written
+// ? 415 names the patch formats in Accept-Patch, a failed JSON Patch test is 409, anything else the client's 400
+func patchApplied(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, patch.ErrUnsupportedMediaType):
+		w.Header().Set("Accept-Patch", patch.AcceptPatch)
+		utils.WriteJson(w, http.StatusUnsupportedMediaType, utils.Envelope{"error": err.Error()})
+	case errors.Is(err, patch.ErrTestFailed):
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": err.Error()})
+	default:
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+	}
+	return false
+}
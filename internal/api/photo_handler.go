@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"fem/internal/blob"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/tokens"
+	"fem/internal/utils"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ! multipartOverhead --> room for boundaries + headers on top of the photo size cap
+const multipartOverhead = 1 << 20
+
+type PhotoHandler struct {
+	photos *service.PhotoService //* nil when photos aren't available on this server
+	blobs  blob.Store            //* serves BLOB_STORE=disk objects behind signed URLs
+	logger *log.Logger
+}
+
+// ! NewPhotoHandler --> constructor for photo handler
+func NewPhotoHandler(photoService *service.PhotoService, blobs blob.Store, logger *log.Logger) *PhotoHandler {
+	return &PhotoHandler{photos: photoService, blobs: blobs, logger: logger}
+}
+
+// * writePhotoError --> shared mapping for the photo routes
+func (h *PhotoHandler) writePhotoError(w http.ResponseWriter, action string, err error) {
+	var invalid *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "workout or photo not found"})
+	case errors.Is(err, service.ErrForbidden):
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "you can only change photos of your own workouts"})
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusUnprocessableEntity, utils.Envelope{"error": invalid.Message})
+	default:
+		h.logger.Printf("ERROR: %s: %v", action, err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+	}
+}
+
+// ! HandleUploadPhoto --> POST /workouts/{id}/photos, multipart "photo" field holding a JPEG, PNG or GIF
+func (h *PhotoHandler) HandleUploadPhoto(w http.ResponseWriter, req *http.Request) {
+	if h.photos == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "workout photos are not available on this server"})
+		return
+	}
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid workout id"})
+		return
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, h.photos.MaxBytes+multipartOverhead)
+	err = req.ParseMultipartForm(multipartOverhead)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.WriteJson(w, http.StatusRequestEntityTooLarge, utils.Envelope{"error": fmt.Sprintf("photo must be at most %dMB", h.photos.MaxBytes>>20)})
+			return
+		}
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "expected a multipart/form-data upload"})
+		return
+	}
+	defer req.MultipartForm.RemoveAll()
+
+	file, _, err := req.FormFile("photo")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "photo is required"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		h.writePhotoError(w, "readPhoto", err)
+		return
+	}
+
+	photo, err := h.photos.Upload(req.Context(), middleware.GetUser(req).ID, workoutID, data)
+	if err != nil {
+		h.writePhotoError(w, "uploadPhoto", err)
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"photo": photo})
+}
+
+// ! HandleDeletePhoto --> DELETE /workouts/{id}/photos/{photoID}
+func (h *PhotoHandler) HandleDeletePhoto(w http.ResponseWriter, req *http.Request) {
+	if h.photos == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "workout photos are not available on this server"})
+		return
+	}
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid workout id"})
+		return
+	}
+	photoID, err := utils.ReadInt64Param(req, "photoID")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid photo id"})
+		return
+	}
+
+	err = h.photos.Delete(req.Context(), middleware.GetUser(req).ID, workoutID, photoID)
+	if err != nil {
+		h.writePhotoError(w, "deletePhoto", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! HandleDownloadBlob --> GET /blobs/{key...}?expires=...&signature=...
+// ! public route, the signed URL itself is the credential (S3 URLs point at the bucket instead)
+func (h *PhotoHandler) HandleDownloadBlob(w http.ResponseWriter, req *http.Request) {
+	if !tokens.VerifySignedURL(req.URL.Path, req.URL.Query()) {
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "invalid or expired link"})
+		return
+	}
+
+	key := chi.URLParam(req, "*")
+	body, err := h.blobs.Open(req.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "file not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: openBlob: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(key)))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = io.Copy(w, body)
+	if err != nil {
+		h.logger.Printf("ERROR: serveBlob: %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/achievements"
+	"fem/internal/gamification"
+	"fem/internal/middleware"
+	"fem/internal/patch"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+//! defaultWeightWindow --> how far back GET /users/me/weights looks when no range is given
+const defaultWeightWindow = 90 * 24 * time.Hour
+
+type ProfileHandler struct {
+	userStore        store.UserStore        //* bio lives on the user record
+	profileStore     store.ProfileStore     //* body metrics + weight history
+	xp               *gamification.Service  //* XP + level shown on the profile
+	achievementStore store.AchievementStore //* earned badges shown on the profile
+	engine           *achievements.Engine   //* rule catalogue the badges come from
+	logger           *log.Logger
+}
+
+//! updateProfileRequest --> PUT /users/me payload, pointers allow partial updates
+type updateProfileRequest struct {
+	Bio               *string  `json:"bio"`
+	HeightCM          *float64 `json:"height_cm"`
+	WeightKG          *float64 `json:"weight_kg"`
+	Birthdate         *string  `json:"birthdate"`
+	Units             *string  `json:"units"`
+	Timezone          *string  `json:"timezone"` // * IANA name, e.g. America/New_York
+	EventsOptIn       *bool    `json:"events_opt_in"`
+	LeaderboardOptOut *bool    `json:"leaderboard_opt_out"`
+}
+
+//! addWeightRequest --> POST /users/me/weights payload
+type addWeightRequest struct {
+	WeightKG   float64    `json:"weight_kg"`
+	MeasuredAt *time.Time `json:"measured_at"` // * optional, defaults to now
+}
+
+//! NewProfileHandler --> constructor for profile handler
+func NewProfileHandler(userStore store.UserStore, profileStore store.ProfileStore, xp *gamification.Service, achievementStore store.AchievementStore, engine *achievements.Engine, logger *log.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		userStore:        userStore,
+		profileStore:     profileStore,
+		xp:               xp,
+		achievementStore: achievementStore,
+		engine:           engine,
+		logger:           logger,
+	}
+}
+
+//! validateWeight --> keeps obviously broken values out of the history
+func validateWeight(kg float64) error {
+	if kg < 20 || kg > 500 {
+		return errors.New("weight_kg must be between 20 and 500")
+	}
+	return nil
+}
+
+//! validateProfileRequest --> server-side validation before anything is saved
+func (h *ProfileHandler) validateProfileRequest(r *updateProfileRequest) error {
+	if r.HeightCM != nil && (*r.HeightCM < 50 || *r.HeightCM > 300) {
+		return errors.New("height_cm must be between 50 and 300")
+	}
+	if r.WeightKG != nil {
+		if err := validateWeight(*r.WeightKG); err != nil {
+			return err
+		}
+	}
+	if r.Birthdate != nil {
+		birthdate, err := time.Parse(time.DateOnly, *r.Birthdate)
+		if err != nil {
+			return errors.New("birthdate must be formatted as YYYY-MM-DD")
+		}
+		if birthdate.After(time.Now()) {
+			return errors.New("birthdate cannot be in the future")
+		}
+	}
+	if r.Units != nil && *r.Units != store.UnitsMetric && *r.Units != store.UnitsImperial {
+		return errors.New("units must be either metric or imperial")
+	}
+	if r.Timezone != nil {
+		//* "" and "Local" load fine but would mean the server's zone
+		if _, err := time.LoadLocation(*r.Timezone); err != nil || *r.Timezone == "" || *r.Timezone == "Local" {
+			return errors.New("timezone must be an IANA time zone name like Europe/Berlin")
+		}
+	}
+	return nil
+}
+
+//! HandleGetMe --> GET /users/me returns the user together with their profile, level and earned badges
+func (h *ProfileHandler) HandleGetMe(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+
+	profile, err := h.profileStore.GetProfile(currentUser.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: getProfile: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	level, err := h.xp.LevelFor(currentUser.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: xp levelFor: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	badges, err := listAchievements(h.engine, h.achievementStore, currentUser.ID, true)
+	if err != nil {
+		h.logger.Printf("ERROR: listAchievements: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"user": currentUser, "profile": profile, "level": level, "achievements": badges})
+}
+
+//! publicProfile --> what anyone may see about a user, no email, body metrics or admin flag
+type publicProfile struct {
+	ID           int                   `json:"id"`
+	Username     string                `json:"username"`
+	Bio          string                `json:"bio"`
+	Level        *gamification.Level   `json:"level"`
+	Achievements []achievementResponse `json:"achievements"` // * earned badges only, their URLs are public anyway
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+//! HandleGetPublicProfile --> GET /users/{id}/profile, can be opened to anonymous callers via PUBLIC_API_FILE
+func (h *ProfileHandler) HandleGetPublicProfile(w http.ResponseWriter, req *http.Request) {
+	userID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid user id"})
+		return
+	}
+
+	user, err := h.userStore.GetUserByID(userID)
+	if err != nil {
+		h.logger.Printf("ERROR: getUserByID: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if user == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "user not found"})
+		return
+	}
+
+	level, err := h.xp.LevelFor(user.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: xp levelFor: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	badges, err := listAchievements(h.engine, h.achievementStore, user.ID, true)
+	if err != nil {
+		h.logger.Printf("ERROR: listAchievements: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"profile": publicProfile{ID: user.ID, Username: user.Username, Bio: user.Bio, Level: level, Achievements: badges, CreatedAt: user.CreatedAt}})
+}
+
+//! HandleUpdateMe --> PUT /users/me partial update of bio + profile fields
+func (h *ProfileHandler) HandleUpdateMe(w http.ResponseWriter, req *http.Request) {
+	var r updateProfileRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		h.logger.Printf("ERROR: decoding updateProfile request: %v", err)
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	h.updateProfile(w, req, r)
+}
+
+//! HandlePatchMe --> PATCH /users/me merge patch or JSON Patch against the editable profile fields
+func (h *ProfileHandler) HandlePatchMe(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+	profile, err := h.profileStore.GetProfile(currentUser.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: getProfile: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	var r updateProfileRequest
+	err = patch.Changes(req.Header.Get("Content-Type"), req.Body, profileDocument(currentUser, profile), &r)
+	if !patchApplied(w, err) {
+		return
+	}
+	h.updateProfile(w, req, r)
+}
+
+//! profileDocument --> the user's current values in updateProfileRequest's shape, what PATCH /users/me edits
+func profileDocument(user *store.User, profile *store.Profile) updateProfileRequest {
+	return updateProfileRequest{
+		Bio:               &user.Bio,
+		HeightCM:          profile.HeightCM,
+		WeightKG:          profile.WeightKG,
+		Birthdate:         profile.Birthdate,
+		Units:             &profile.Units,
+		Timezone:          &profile.Timezone,
+		EventsOptIn:       &profile.EventsOptIn,
+		LeaderboardOptOut: &profile.LeaderboardOptOut,
+	}
+}
+
+//* updateProfile --> validates + saves what PUT or PATCH changes, writes the response
+func (h *ProfileHandler) updateProfile(w http.ResponseWriter, req *http.Request, r updateProfileRequest) {
+	err := h.validateProfileRequest(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	currentUser := middleware.GetUser(req)
+	if r.Bio != nil {
+		currentUser.Bio = *r.Bio
+		err = h.userStore.UpdateUser(currentUser)
+		if err != nil {
+			writeStoreError(w, h.logger, "updateUser", err)
+			return
+		}
+	}
+
+	profile, err := h.profileStore.GetProfile(currentUser.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: getProfile: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	//* only overwrite what the client sent
+	if r.HeightCM != nil {
+		profile.HeightCM = r.HeightCM
+	}
+	if r.WeightKG != nil {
+		profile.WeightKG = r.WeightKG
+	}
+	if r.Birthdate != nil {
+		profile.Birthdate = r.Birthdate
+	}
+	if r.Units != nil {
+		profile.Units = *r.Units
+	}
+	if r.Timezone != nil {
+		profile.Timezone = *r.Timezone
+	}
+	if r.EventsOptIn != nil {
+		profile.EventsOptIn = *r.EventsOptIn
+	}
+	if r.LeaderboardOptOut != nil {
+		profile.LeaderboardOptOut = *r.LeaderboardOptOut
+	}
+
+	err = h.profileStore.UpsertProfile(profile)
+	if err != nil {
+		h.logger.Printf("ERROR: upsertProfile: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	//? a weight typed into the profile form is a measurement too, keep the history complete
+	if r.WeightKG != nil {
+		err = h.profileStore.AddWeight(&store.WeightEntry{UserID: currentUser.ID, WeightKG: *r.WeightKG, MeasuredAt: time.Now()})
+		if err != nil {
+			h.logger.Printf("ERROR: addWeight: %v", err)
+		}
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"user": currentUser, "profile": profile})
+}
+
+//! HandleAddWeight --> POST /users/me/weights
+func (h *ProfileHandler) HandleAddWeight(w http.ResponseWriter, req *http.Request) {
+	var r addWeightRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		h.logger.Printf("ERROR: decoding addWeight request: %v", err)
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	err = validateWeight(r.WeightKG)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	entry := &store.WeightEntry{
+		UserID:     middleware.GetUser(req).ID,
+		WeightKG:   r.WeightKG,
+		MeasuredAt: time.Now(),
+	}
+	if r.MeasuredAt != nil {
+		if r.MeasuredAt.After(time.Now()) {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "measured_at cannot be in the future"})
+			return
+		}
+		entry.MeasuredAt = *r.MeasuredAt
+	}
+
+	err = h.profileStore.AddWeight(entry)
+	if err != nil {
+		h.logger.Printf("ERROR: addWeight: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"weight": entry})
+}
+
+//! HandleListWeights --> GET /users/me/weights?from=2024-01-01&to=2024-03-31
+//? from/to accept either a date or a full RFC3339 timestamp, default is the last 90 days
+func (h *ProfileHandler) HandleListWeights(w http.ResponseWriter, req *http.Request) {
+	to := time.Now()
+	from := to.Add(-defaultWeightWindow)
+
+	var err error
+	if raw := req.URL.Query().Get("from"); raw != "" {
+		from, err = utils.ParseTimeParam(raw)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid from parameter"})
+			return
+		}
+	}
+	if raw := req.URL.Query().Get("to"); raw != "" {
+		to, err = utils.ParseTimeParam(raw)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid to parameter"})
+			return
+		}
+		//* a bare date means "up to the end of that day"
+		if len(raw) == len(time.DateOnly) {
+			to = to.Add(24*time.Hour - time.Nanosecond)
+		}
+	}
+	if from.After(to) {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "from must be before to"})
+		return
+	}
+
+	weights, err := h.profileStore.ListWeights(middleware.GetUser(req).ID, from, to)
+	if err != nil {
+		h.logger.Printf("ERROR: listWeights: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"weights": weights, "from": from, "to": to})
+}
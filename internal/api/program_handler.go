@@ -0,0 +1,328 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/programs"
+	"fem/internal/reminders"
+	"fem/internal/schedule"
+	"fem/internal/service"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+//! maxEnrollmentLead --> furthest ahead an enrollment may start
+const maxEnrollmentLead = 366 * 24 * time.Hour
+
+type ProgramHandler struct {
+	programStore store.ProgramStore     //* programs + enrollments, nil answers 501
+	workoutStore store.WorkoutStore     //* template workouts
+	followStore  store.FollowStore      //* followers-only templates
+	profileStore store.ProfileStore     //* the enrolling user's timezone
+	materializer *schedule.Materializer //* fills the planned sessions right away after enrolling
+	logger       *log.Logger
+}
+
+//! programRequest --> POST /programs and PUT /programs/{id} payload, the whole program with its weeks
+type programRequest struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Visibility  string              `json:"visibility"` // * private (default) | public
+	Weeks       []store.ProgramWeek `json:"weeks"`      // * in order, week numbers come from the position
+}
+
+//! enrollRequest --> POST /programs/{id}/enroll payload, both optional
+type enrollRequest struct {
+	StartsOn  string `json:"starts_on"`   // * YYYY-MM-DD in the user's timezone, defaults to today
+	TimeOfDay string `json:"time_of_day"` // * HH:MM, defaults to programs.DefaultTimeOfDay
+}
+
+//! NewProgramHandler --> constructor for program handler
+func NewProgramHandler(programStore store.ProgramStore, workoutStore store.WorkoutStore, followStore store.FollowStore, profileStore store.ProfileStore, materializer *schedule.Materializer, logger *log.Logger) *ProgramHandler {
+	return &ProgramHandler{
+		programStore: programStore,
+		workoutStore: workoutStore,
+		followStore:  followStore,
+		profileStore: profileStore,
+		materializer: materializer,
+		logger:       logger,
+	}
+}
+
+//! available --> writes the 501 on servers without program tables (sqlite)
+func (h *ProgramHandler) available(w http.ResponseWriter) bool {
+	if h.programStore == nil {
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "programs are not available on this server"})
+		return false
+	}
+	return true
+}
+
+//! loadProgram --> {id} for the current user, 404 for private programs of others they aren't enrolled in
+func (h *ProgramHandler) loadProgram(w http.ResponseWriter, req *http.Request) (*store.Program, *store.ProgramEnrollment, bool) {
+	if !h.available(w) {
+		return nil, nil, false
+	}
+	programID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid program id"})
+		return nil, nil, false
+	}
+
+	program, err := h.programStore.GetProgram(programID)
+	if err != nil {
+		h.logger.Printf("ERROR: getProgram: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, nil, false
+	}
+	userID := middleware.GetUser(req).ID
+	var enrollment *store.ProgramEnrollment
+	if program != nil {
+		enrollment, err = h.programStore.GetEnrollment(programID, userID)
+		if err != nil {
+			h.logger.Printf("ERROR: getEnrollment: %v", err)
+			utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+			return nil, nil, false
+		}
+	}
+	if program == nil || (program.UserID != userID && program.Visibility != store.ProgramPublic && enrollment == nil) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "program not found"})
+		return nil, nil, false
+	}
+	return program, enrollment, true
+}
+
+//! requireAuthor --> loadProgram plus a 403 for anyone but the author
+func (h *ProgramHandler) requireAuthor(w http.ResponseWriter, req *http.Request) (*store.Program, bool) {
+	program, _, ok := h.loadProgram(w, req)
+	if !ok {
+		return nil, false
+	}
+	if program.UserID != middleware.GetUser(req).ID {
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "only the author can change a program"})
+		return nil, false
+	}
+	return program, true
+}
+
+//! readProgram --> decodes + validates the payload, the templates must be workouts the author can see (public ones for a public program)
+func (h *ProgramHandler) readProgram(w http.ResponseWriter, req *http.Request, program *store.Program) bool {
+	var r programRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return false
+	}
+	program.Title, program.Description, program.Visibility, program.Weeks = r.Title, r.Description, r.Visibility, r.Weeks
+	err = programs.Validate(program)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return false
+	}
+
+	for _, workoutID := range programs.WorkoutIDs(program) {
+		workout, err := service.VisibleWorkout(h.workoutStore, h.followStore, int64(workoutID), program.UserID)
+		if errors.Is(err, service.ErrNotFound) {
+			utils.WriteJson(w, http.StatusUnprocessableEntity, utils.Envelope{"error": fmt.Sprintf("template workout %d not found", workoutID)})
+			return false
+		}
+		if err != nil {
+			h.logger.Printf("ERROR: getTemplateWorkout: %v", err)
+			utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+			return false
+		}
+		//* everyone can read a public program, its templates must not reveal anything they couldn't see anyway
+		if program.Visibility == store.ProgramPublic && workout.Visibility != store.VisibilityPublic {
+			utils.WriteJson(w, http.StatusUnprocessableEntity, utils.Envelope{"error": fmt.Sprintf("template workout %d must be public in a public program", workoutID)})
+			return false
+		}
+	}
+	return true
+}
+
+//! HandleCreateProgram --> POST /programs
+func (h *ProgramHandler) HandleCreateProgram(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	program := &store.Program{UserID: middleware.GetUser(req).ID}
+	if !h.readProgram(w, req, program) {
+		return
+	}
+
+	err := h.programStore.CreateProgram(program)
+	if err != nil {
+		writeStoreError(w, h.logger, "createProgram", err)
+		return
+	}
+	h.writeProgram(w, http.StatusCreated, int64(program.ID))
+}
+
+//! writeProgram --> reloads the program so the templates come with their titles
+func (h *ProgramHandler) writeProgram(w http.ResponseWriter, status int, id int64) {
+	program, err := h.programStore.GetProgram(id)
+	if err != nil || program == nil {
+		h.logger.Printf("ERROR: getProgram: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, status, utils.Envelope{"program": program})
+}
+
+//! HandleListPrograms --> GET /programs, the caller's own programs and the ones they are enrolled in
+func (h *ProgramHandler) HandleListPrograms(w http.ResponseWriter, req *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	list, err := h.programStore.ListPrograms(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listPrograms: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"programs": list})
+}
+
+//! HandleGetProgram --> GET /programs/{id} with its weeks and the caller's enrollment (null when not enrolled)
+func (h *ProgramHandler) HandleGetProgram(w http.ResponseWriter, req *http.Request) {
+	program, enrollment, ok := h.loadProgram(w, req)
+	if !ok {
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"program": program, "enrollment": enrollment})
+}
+
+//! HandleUpdateProgram --> PUT /programs/{id} replaces the whole program, existing enrollments keep their plan
+func (h *ProgramHandler) HandleUpdateProgram(w http.ResponseWriter, req *http.Request) {
+	program, ok := h.requireAuthor(w, req)
+	if !ok {
+		return
+	}
+	if !h.readProgram(w, req, program) {
+		return
+	}
+
+	err := h.programStore.UpdateProgram(program)
+	if err != nil {
+		writeStoreError(w, h.logger, "updateProgram", err)
+		return
+	}
+	h.writeProgram(w, http.StatusOK, int64(program.ID))
+}
+
+//! HandleDeleteProgram --> DELETE /programs/{id}, sessions already planned for enrolled users stay on their calendar
+func (h *ProgramHandler) HandleDeleteProgram(w http.ResponseWriter, req *http.Request) {
+	program, ok := h.requireAuthor(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.programStore.DeleteProgram(int64(program.ID))
+	if err != nil {
+		writeStoreError(w, h.logger, "deleteProgram", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//! HandleEnroll --> POST /programs/{id}/enroll plans every program day as a session on the caller's schedule
+func (h *ProgramHandler) HandleEnroll(w http.ResponseWriter, req *http.Request) {
+	program, enrollment, ok := h.loadProgram(w, req)
+	if !ok {
+		return
+	}
+	if enrollment != nil {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "already enrolled in this program"})
+		return
+	}
+	var r enrollRequest
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+			return
+		}
+	}
+
+	userID := middleware.GetUser(req).ID
+	profile, err := h.profileStore.GetProfile(userID)
+	if err != nil {
+		h.logger.Printf("ERROR: getProfile: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	loc := profile.Location()
+	today := time.Now().In(loc).Format(time.DateOnly)
+
+	enrollment = &store.ProgramEnrollment{ProgramID: program.ID, UserID: userID, StartsOn: r.StartsOn, TimeOfDay: r.TimeOfDay, Timezone: loc.String()}
+	if enrollment.StartsOn == "" {
+		enrollment.StartsOn = today
+	}
+	startsOn, err := time.Parse(time.DateOnly, enrollment.StartsOn)
+	if err != nil || enrollment.StartsOn < today || startsOn.Sub(time.Now()) > maxEnrollmentLead {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "starts_on must be a date (YYYY-MM-DD) from today up to a year ahead"})
+		return
+	}
+	if enrollment.TimeOfDay == "" {
+		enrollment.TimeOfDay = programs.DefaultTimeOfDay
+	}
+	_, err = reminders.ParseClock(enrollment.TimeOfDay)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "time_of_day must be HH:MM"})
+		return
+	}
+
+	planned, err := programs.Plan(program, enrollment)
+	if err != nil {
+		h.logger.Printf("ERROR: planProgram: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	err = h.programStore.Enroll(enrollment, planned)
+	if errors.Is(err, store.ErrConflict) {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "already enrolled in this program"}) //* a concurrent enroll won
+		return
+	}
+	if err != nil {
+		writeStoreError(w, h.logger, "enroll", err)
+		return
+	}
+
+	//* best effort, the materializer job picks up anything left
+	for _, s := range planned {
+		err = h.materializer.Materialize(s, time.Now())
+		if err != nil {
+			h.logger.Printf("ERROR: materialize schedule %d: %v", s.ID, err)
+		}
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"enrollment": enrollment, "schedules": planned})
+}
+
+//! HandleUnenroll --> DELETE /programs/{id}/enroll removes the enrollment and every session it planned
+func (h *ProgramHandler) HandleUnenroll(w http.ResponseWriter, req *http.Request) {
+	program, _, ok := h.loadProgram(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.programStore.Unenroll(int64(program.ID), middleware.GetUser(req).ID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "not enrolled in this program"})
+		return
+	}
+	if err != nil {
+		writeStoreError(w, h.logger, "unenroll", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
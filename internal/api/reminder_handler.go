@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/reminders"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+type ReminderHandler struct {
+	reminderStore store.ReminderStore //* per-user reminder rules
+	logger        *log.Logger
+}
+
+// ! reminderRequest --> POST + PUT /reminders payload, enabled defaults to true
+type reminderRequest struct {
+	Name     string `json:"name"`
+	RemindAt string `json:"remind_at"`
+	Timezone string `json:"timezone"`
+	Days     string `json:"days"`
+	Email    bool   `json:"email"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+// ! snoozeRequest --> POST /reminders/{id}/snooze payload, minutes defaults to 10
+type snoozeRequest struct {
+	Minutes int `json:"minutes"`
+}
+
+// ! NewReminderHandler --> constructor for reminder handler
+func NewReminderHandler(reminderStore store.ReminderStore, logger *log.Logger) *ReminderHandler {
+	return &ReminderHandler{
+		reminderStore: reminderStore,
+		logger:        logger,
+	}
+}
+
+// ! readReminder --> decodes + validates the body into r, writes the error itself
+func (h *ReminderHandler) readReminder(w http.ResponseWriter, req *http.Request, r *store.Reminder) bool {
+	var body reminderRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return false
+	}
+
+	r.Name, r.RemindAt, r.Timezone, r.Days, r.Email = body.Name, body.RemindAt, body.Timezone, body.Days, body.Email
+	r.Enabled = body.Enabled == nil || *body.Enabled
+	err = reminders.Validate(r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// ! requireReminderOwner --> loads {id} and makes sure it belongs to the current user, writes the error itself
+func (h *ReminderHandler) requireReminderOwner(w http.ResponseWriter, req *http.Request) (*store.Reminder, bool) {
+	reminderID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid reminder id"})
+		return nil, false
+	}
+
+	reminder, err := h.reminderStore.GetReminder(reminderID)
+	if err != nil {
+		h.logger.Printf("ERROR: getReminder: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if reminder == nil || reminder.UserID != middleware.GetUser(req).ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "reminder not found"})
+		return nil, false
+	}
+	return reminder, true
+}
+
+// ! HandleCreateReminder --> POST /reminders
+func (h *ReminderHandler) HandleCreateReminder(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+	reminder := &store.Reminder{UserID: currentUser.ID}
+	if !h.readReminder(w, req, reminder) {
+		return
+	}
+
+	existing, err := h.reminderStore.ListReminders(currentUser.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listReminders: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if len(existing) >= reminders.MaxRemindersPerUser {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "reminder limit reached, delete one first"})
+		return
+	}
+
+	err = h.reminderStore.CreateReminder(reminder)
+	if err != nil {
+		h.logger.Printf("ERROR: createReminder: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"reminder": reminder})
+}
+
+// ! HandleListReminders --> GET /reminders
+func (h *ReminderHandler) HandleListReminders(w http.ResponseWriter, req *http.Request) {
+	list, err := h.reminderStore.ListReminders(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listReminders: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"reminders": list})
+}
+
+// ! HandleGetReminder --> GET /reminders/{id}
+func (h *ReminderHandler) HandleGetReminder(w http.ResponseWriter, req *http.Request) {
+	reminder, ok := h.requireReminderOwner(w, req)
+	if !ok {
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"reminder": reminder})
+}
+
+// ! HandleUpdateReminder --> PUT /reminders/{id}, replaces the whole rule, a pending snooze stays
+func (h *ReminderHandler) HandleUpdateReminder(w http.ResponseWriter, req *http.Request) {
+	reminder, ok := h.requireReminderOwner(w, req)
+	if !ok || !h.readReminder(w, req, reminder) {
+		return
+	}
+
+	err := h.reminderStore.UpdateReminder(reminder)
+	if err != nil {
+		h.logger.Printf("ERROR: updateReminder: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"reminder": reminder})
+}
+
+// ! HandleDeleteReminder --> DELETE /reminders/{id}
+func (h *ReminderHandler) HandleDeleteReminder(w http.ResponseWriter, req *http.Request) {
+	reminder, ok := h.requireReminderOwner(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.reminderStore.DeleteReminder(reminder.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		h.logger.Printf("ERROR: deleteReminder: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! HandleSnoozeReminder --> POST /reminders/{id}/snooze, mutes the reminder and sends it again once the snooze ends
+// ? snoozing before the reminder time only pushes it back, it never fires earlier than remind_at
+func (h *ReminderHandler) HandleSnoozeReminder(w http.ResponseWriter, req *http.Request) {
+	reminder, ok := h.requireReminderOwner(w, req)
+	if !ok {
+		return
+	}
+
+	var body snoozeRequest
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+			return
+		}
+	}
+	snooze := time.Duration(body.Minutes) * time.Minute
+	if body.Minutes == 0 {
+		snooze = reminders.DefaultSnooze
+	}
+	if snooze < time.Minute || snooze > reminders.MaxSnooze {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": fmt.Sprintf("minutes must be between 1 and %d", int(reminders.MaxSnooze.Minutes()))})
+		return
+	}
+
+	until := time.Now().UTC().Add(snooze).Truncate(time.Second)
+	err := h.reminderStore.SnoozeReminder(reminder.ID, until)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		h.logger.Printf("ERROR: snoozeReminder: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	reminder.SnoozedUntil = &until
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"reminder": reminder})
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"fem/internal/service"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+)
+
+type SandboxHandler struct {
+	sandboxes *service.SandboxService //* provisions throwaway accounts
+	logger    *log.Logger
+}
+
+//! NewSandboxHandler --> constructor for sandbox handler
+func NewSandboxHandler(sandboxService *service.SandboxService, logger *log.Logger) *SandboxHandler {
+	return &SandboxHandler{
+		sandboxes: sandboxService,
+		logger:    logger,
+	}
+}
+
+//! HandleCreateSandbox --> POST /sandbox, public, only mounted with SANDBOX_ENABLED=true
+//! returns credentials + an auth token for an account pre-filled with sample workouts, gone after SANDBOX_TTL
+func (h *SandboxHandler) HandleCreateSandbox(w http.ResponseWriter, req *http.Request) {
+	sandbox, err := h.sandboxes.Provision(req.Context())
+	if err != nil {
+		h.logger.Printf("ERROR: provisioning sandbox %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"sandbox": sandbox})
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/schedule"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//! defaultOccurrenceWindow --> GET /schedules/occurrences looks this far ahead by default
+const defaultOccurrenceWindow = 14 * 24 * time.Hour
+
+type ScheduleHandler struct {
+	scheduleStore store.ScheduleStore    //* series + occurrences
+	materializer  *schedule.Materializer //* fills occurrences right away after a write
+	logger        *log.Logger
+}
+
+//! scheduleRequest --> POST /schedules and PUT /schedules/{id} payload (whole series)
+type scheduleRequest struct {
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	DurationMinutes int       `json:"duration_minutes"`
+	StartsAt        time.Time `json:"starts_at"`
+	RRule           string    `json:"rrule"` // * e.g. FREQ=WEEKLY;BYDAY=MO,WE,FR
+}
+
+//! occurrenceRequest --> PUT /schedules/{id}/occurrences/{occurrenceID}, edits just that one session
+type occurrenceRequest struct {
+	OccursAt        *time.Time `json:"occurs_at"`
+	Title           *string    `json:"title"`
+	DurationMinutes *int       `json:"duration_minutes"`
+	Status          *string    `json:"status"` // * scheduled | skipped
+}
+
+//! NewScheduleHandler --> constructor for schedule handler
+func NewScheduleHandler(scheduleStore store.ScheduleStore, materializer *schedule.Materializer, logger *log.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleStore: scheduleStore,
+		materializer:  materializer,
+		logger:        logger,
+	}
+}
+
+//! validate --> shared checks for create + whole-series update
+func (r *scheduleRequest) validate() error {
+	r.Title = strings.TrimSpace(r.Title)
+	if r.Title == "" || len(r.Title) > 255 {
+		return errors.New("title is required and must be at most 255 characters")
+	}
+	if r.DurationMinutes <= 0 {
+		return errors.New("duration_minutes must be greater than 0")
+	}
+	if r.StartsAt.IsZero() {
+		return errors.New("starts_at is required")
+	}
+	_, err := schedule.Parse(r.RRule)
+	if err != nil {
+		return errors.New("rrule: " + err.Error())
+	}
+	return nil
+}
+
+//! requireScheduleOwner --> loads {id} for the current user, 404 for anyone else's schedule
+func (h *ScheduleHandler) requireScheduleOwner(w http.ResponseWriter, req *http.Request) (*store.Schedule, bool) {
+	scheduleID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid schedule id"})
+		return nil, false
+	}
+
+	s, err := h.scheduleStore.GetSchedule(scheduleID)
+	if err != nil {
+		h.logger.Printf("ERROR: getSchedule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if s == nil || s.UserID != middleware.GetUser(req).ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "schedule not found"})
+		return nil, false
+	}
+	return s, true
+}
+
+//! materialize --> best effort, the background job catches up if this fails
+func (h *ScheduleHandler) materialize(s *store.Schedule) {
+	err := h.materializer.Materialize(s, time.Now())
+	if err != nil {
+		h.logger.Printf("ERROR: materialize schedule %d: %v", s.ID, err)
+	}
+}
+
+//! HandleCreateSchedule --> POST /schedules
+func (h *ScheduleHandler) HandleCreateSchedule(w http.ResponseWriter, req *http.Request) {
+	var r scheduleRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	err = r.validate()
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	s := &store.Schedule{
+		UserID:          middleware.GetUser(req).ID,
+		Title:           r.Title,
+		Description:     r.Description,
+		DurationMinutes: r.DurationMinutes,
+		StartsAt:        r.StartsAt,
+		RRule:           r.RRule,
+	}
+	err = h.scheduleStore.CreateSchedule(s)
+	if err != nil {
+		h.logger.Printf("ERROR: createSchedule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	h.materialize(s)
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"schedule": s})
+}
+
+//! HandleListSchedules --> GET /schedules
+func (h *ScheduleHandler) HandleListSchedules(w http.ResponseWriter, req *http.Request) {
+	schedules, err := h.scheduleStore.ListSchedules(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listSchedules: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"schedules": schedules})
+}
+
+//! HandleGetSchedule --> GET /schedules/{id}
+func (h *ScheduleHandler) HandleGetSchedule(w http.ResponseWriter, req *http.Request) {
+	s, ok := h.requireScheduleOwner(w, req)
+	if !ok {
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"schedule": s})
+}
+
+//! HandleUpdateSchedule --> PUT /schedules/{id} edits the whole series, future occurrences are regenerated
+func (h *ScheduleHandler) HandleUpdateSchedule(w http.ResponseWriter, req *http.Request) {
+	s, ok := h.requireScheduleOwner(w, req)
+	if !ok {
+		return
+	}
+
+	var r scheduleRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	err = r.validate()
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	s.Title = r.Title
+	s.Description = r.Description
+	s.DurationMinutes = r.DurationMinutes
+	s.StartsAt = r.StartsAt
+	s.RRule = r.RRule
+	err = h.scheduleStore.UpdateSchedule(s)
+	if err != nil {
+		h.logger.Printf("ERROR: updateSchedule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	h.materialize(s)
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"schedule": s})
+}
+
+//! HandleDeleteSchedule --> DELETE /schedules/{id} removes the series and all its occurrences
+func (h *ScheduleHandler) HandleDeleteSchedule(w http.ResponseWriter, req *http.Request) {
+	s, ok := h.requireScheduleOwner(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.scheduleStore.DeleteSchedule(int64(s.ID))
+	if err != nil {
+		h.logger.Printf("ERROR: deleteSchedule: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//! HandleListOccurrences --> GET /schedules/occurrences?from=&to= upcoming sessions across all schedules
+func (h *ScheduleHandler) HandleListOccurrences(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	from := time.Now()
+	to := from.Add(defaultOccurrenceWindow)
+
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := utils.ParseTimeParam(raw)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "from must be a date (YYYY-MM-DD) or RFC3339 timestamp"})
+			return
+		}
+		from = parsed
+	}
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := utils.ParseTimeParam(raw)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "to must be a date (YYYY-MM-DD) or RFC3339 timestamp"})
+			return
+		}
+		to = parsed
+	}
+	if !to.After(from) {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "to must be after from"})
+		return
+	}
+
+	occurrences, err := h.scheduleStore.ListOccurrences(middleware.GetUser(req).ID, from, to)
+	if err != nil {
+		h.logger.Printf("ERROR: listOccurrences: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"occurrences": occurrences})
+}
+
+//! loadOccurrence --> {occurrenceID} that belongs to the schedule in {id}
+func (h *ScheduleHandler) loadOccurrence(w http.ResponseWriter, req *http.Request) (*store.Occurrence, bool) {
+	s, ok := h.requireScheduleOwner(w, req)
+	if !ok {
+		return nil, false
+	}
+
+	occurrenceID, err := utils.ReadInt64Param(req, "occurrenceID")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid occurrence id"})
+		return nil, false
+	}
+
+	o, err := h.scheduleStore.GetOccurrence(occurrenceID)
+	if err != nil {
+		h.logger.Printf("ERROR: getOccurrence: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if o == nil || o.ScheduleID != s.ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "occurrence not found"})
+		return nil, false
+	}
+	return o, true
+}
+
+//! HandleUpdateOccurrence --> PUT /schedules/{id}/occurrences/{occurrenceID} edits only this occurrence
+func (h *ScheduleHandler) HandleUpdateOccurrence(w http.ResponseWriter, req *http.Request) {
+	o, ok := h.loadOccurrence(w, req)
+	if !ok {
+		return
+	}
+
+	var r occurrenceRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	if r.OccursAt != nil {
+		o.OccursAt = *r.OccursAt
+	}
+	if r.Title != nil {
+		title := strings.TrimSpace(*r.Title)
+		if title == "" || len(title) > 255 {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "title must be between 1 and 255 characters"})
+			return
+		}
+		o.Title = title
+	}
+	if r.DurationMinutes != nil {
+		if *r.DurationMinutes <= 0 {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "duration_minutes must be greater than 0"})
+			return
+		}
+		o.DurationMinutes = *r.DurationMinutes
+	}
+	if r.Status != nil {
+		if *r.Status != store.OccurrenceScheduled && *r.Status != store.OccurrenceSkipped {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "status must be scheduled or skipped"})
+			return
+		}
+		o.Status = *r.Status
+	}
+
+	h.saveOccurrence(w, o)
+}
+
+//! HandleSkipOccurrence --> POST /schedules/{id}/occurrences/{occurrenceID}/skip
+func (h *ScheduleHandler) HandleSkipOccurrence(w http.ResponseWriter, req *http.Request) {
+	o, ok := h.loadOccurrence(w, req)
+	if !ok {
+		return
+	}
+
+	o.Status = store.OccurrenceSkipped
+	h.saveOccurrence(w, o)
+}
+
+func (h *ScheduleHandler) saveOccurrence(w http.ResponseWriter, o *store.Occurrence) {
+	err := h.scheduleStore.UpdateOccurrence(o)
+	if err != nil {
+		h.logger.Printf("ERROR: updateOccurrence: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"occurrence": o})
+}
@@ -0,0 +1,440 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//! SCIM 2.0 schema URNs (RFC 7643 / RFC 7644)
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimMaxCount    = 100 //* upper bound on page size for list requests
+)
+
+//! scimFilterPattern --> the only filter identity providers actually send: userName eq "alice"
+var scimFilterPattern = regexp.MustCompile(`^userName eq "([^"]*)"$`)
+
+type SCIMHandler struct {
+	userStore store.UserStore //* userName lookups
+	orgStore  store.OrgStore  //* membership is what SCIM really provisions
+	logger    *log.Logger
+}
+
+//! scimEmail --> SCIM multi-valued email attribute
+type scimEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary"`
+}
+
+//! scimUserRequest --> incoming SCIM User resource (POST / PUT)
+type scimUserRequest struct {
+	ExternalID string      `json:"externalId"`
+	UserName   string      `json:"userName"`
+	Active     *bool       `json:"active"` // * pointer --> missing means active
+	Password   string      `json:"password"`
+	Emails     []scimEmail `json:"emails"`
+}
+
+//! scimPatchRequest --> PatchOp message, only "active" is patchable for now
+type scimPatchRequest struct {
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+//! NewSCIMHandler --> constructor for SCIM provisioning handler
+func NewSCIMHandler(userStore store.UserStore, orgStore store.OrgStore, logger *log.Logger) *SCIMHandler {
+	return &SCIMHandler{
+		userStore: userStore,
+		orgStore:  orgStore,
+		logger:    logger,
+	}
+}
+
+//! writeSCIM --> SCIM clients expect application/scim+json instead of our usual envelope
+func writeSCIM(w http.ResponseWriter, status int, data any) {
+	body, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
+
+//! writeSCIMError --> error body in the SCIM error schema
+func writeSCIMError(w http.ResponseWriter, status int, detail string) {
+	writeSCIM(w, status, utils.Envelope{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	})
+}
+
+//! toSCIMUser --> maps our org membership onto a SCIM User resource
+func toSCIMUser(member *store.OrgMember) utils.Envelope {
+	resource := utils.Envelope{
+		"schemas":  []string{scimUserSchema},
+		"id":       strconv.Itoa(member.User.ID),
+		"userName": member.User.Username,
+		"active":   member.Active,
+		"emails":   []scimEmail{{Value: member.User.Email, Primary: true}},
+		"meta": utils.Envelope{
+			"resourceType": "User",
+			"created":      member.CreatedAt.Format(time.RFC3339),
+			"lastModified": member.UpdatedAt.Format(time.RFC3339),
+			"location":     fmt.Sprintf("/scim/v2/Users/%d", member.User.ID),
+		},
+	}
+	if member.ExternalID != "" {
+		resource["externalId"] = member.ExternalID
+	}
+	return resource
+}
+
+//! primaryEmail --> picks the primary email, falling back to the first one
+func (r *scimUserRequest) primaryEmail() string {
+	for _, email := range r.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(r.Emails) > 0 {
+		return r.Emails[0].Value
+	}
+	return ""
+}
+
+//! loadMember --> reads {id} and fetches the membership inside the authenticated org
+func (h *SCIMHandler) loadMember(w http.ResponseWriter, req *http.Request) (*store.OrgMember, bool) {
+	userID, err := utils.ReadIDParam(req)
+	if err != nil {
+		writeSCIMError(w, http.StatusNotFound, "user not found")
+		return nil, false
+	}
+
+	org := middleware.GetOrg(req)
+	member, err := h.orgStore.GetMember(int64(org.ID), int(userID))
+	if err != nil {
+		h.logger.Printf("ERROR: scim getMember: %v", err)
+		writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+	if member == nil {
+		writeSCIMError(w, http.StatusNotFound, "user not found")
+		return nil, false
+	}
+	return member, true
+}
+
+//! setActive --> flips membership state, nothing outside this org's membership changes
+//? the account may be someone's personal one too, so its sessions are left alone
+func (h *SCIMHandler) setActive(member *store.OrgMember, active bool) error {
+	member.Active = active
+	return h.orgStore.UpsertMember(member)
+}
+
+//! isLastOwner --> member is the org's only active owner, SCIM must not deactivate or remove it
+//? otherwise the org is left with nobody who can rotate the token or manage members
+func (h *SCIMHandler) isLastOwner(member *store.OrgMember) (bool, error) {
+	if member.Role != store.OrgRoleOwner || !member.Active {
+		return false, nil
+	}
+	owners, err := h.orgStore.CountActiveOwners(int64(member.OrgID))
+	return owners <= 1, err
+}
+
+//! setActiveChecked --> setActive that refuses to deactivate the last active owner, writes the error response itself
+func (h *SCIMHandler) setActiveChecked(w http.ResponseWriter, member *store.OrgMember, active bool) bool {
+	if !active {
+		last, err := h.isLastOwner(member)
+		if err != nil {
+			h.logger.Printf("ERROR: scim countActiveOwners: %v", err)
+			writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+			return false
+		}
+		if last {
+			writeSCIMError(w, http.StatusConflict, "the org's last active owner can't be deactivated")
+			return false
+		}
+	}
+
+	err := h.setActive(member, active)
+	if err != nil {
+		h.logger.Printf("ERROR: scim setActive: %v", err)
+		writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+		return false
+	}
+	return true
+}
+
+//! HandleListUsers --> GET /scim/v2/Users?filter=userName eq "x"&startIndex=1&count=50
+func (h *SCIMHandler) HandleListUsers(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	username := ""
+	if filter := query.Get("filter"); filter != "" {
+		match := scimFilterPattern.FindStringSubmatch(filter)
+		if match == nil {
+			writeSCIMError(w, http.StatusBadRequest, "unsupported filter")
+			return
+		}
+		username = match[1]
+	}
+
+	//* SCIM pagination is 1-based
+	startIndex, err := strconv.Atoi(query.Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil || count < 0 || count > scimMaxCount {
+		count = scimMaxCount
+	}
+
+	org := middleware.GetOrg(req)
+	members, total, err := h.orgStore.ListMembers(int64(org.ID), username, startIndex-1, count)
+	if err != nil {
+		h.logger.Printf("ERROR: scim listMembers: %v", err)
+		writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	resources := make([]utils.Envelope, 0, len(members))
+	for _, member := range members {
+		resources = append(resources, toSCIMUser(member))
+	}
+
+	writeSCIM(w, http.StatusOK, utils.Envelope{
+		"schemas":      []string{scimListSchema},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	})
+}
+
+//! HandleGetUser --> GET /scim/v2/Users/{id}
+func (h *SCIMHandler) HandleGetUser(w http.ResponseWriter, req *http.Request) {
+	member, ok := h.loadMember(w, req)
+	if !ok {
+		return
+	}
+	writeSCIM(w, http.StatusOK, toSCIMUser(member))
+}
+
+//! HandleCreateUser --> POST /scim/v2/Users
+//! creates the local user and adds it to the org
+//? an existing userName is only linked again when this org's SCIM created that account,
+//? anyone else's account can't be claimed by naming it
+func (h *SCIMHandler) HandleCreateUser(w http.ResponseWriter, req *http.Request) {
+	var r scimUserRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+	if r.UserName == "" || len(r.UserName) > 50 {
+		writeSCIMError(w, http.StatusBadRequest, "userName is required and must be at most 50 characters")
+		return
+	}
+
+	org := middleware.GetOrg(req)
+	member := &store.OrgMember{
+		OrgID:      org.ID,
+		Role:       store.OrgRoleMember,
+		Active:     r.Active == nil || *r.Active,
+		ExternalID: r.ExternalID,
+	}
+
+	user, err := h.userStore.GetUserByUsername(r.UserName)
+	if err != nil {
+		h.logger.Printf("ERROR: scim getUserByUsername: %v", err)
+		writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if user == nil {
+		email := r.primaryEmail()
+		if email == "" {
+			writeSCIMError(w, http.StatusBadRequest, "a primary email is required")
+			return
+		}
+
+		//? provisioned users normally sign in through the IdP, so without a password we set an unguessable one
+		password := r.Password
+		if password == "" {
+			password = rand.Text()
+		}
+
+		member.User = &store.User{Username: r.UserName, Email: email}
+		err = member.User.PasswordHash.Set(password)
+		if err != nil {
+			h.logger.Printf("ERROR: scim hashing password: %v", err)
+			writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		err = h.orgStore.ProvisionUser(member)
+		if errors.Is(err, store.ErrConflict) {
+			writeSCIMError(w, http.StatusConflict, "user could not be created, email may already be in use")
+			return
+		}
+		if err != nil {
+			h.logger.Printf("ERROR: scim provisionUser: %v", err)
+			writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+	} else {
+		provisioned, err := h.orgStore.IsProvisioned(int64(org.ID), user.ID)
+		if err != nil {
+			h.logger.Printf("ERROR: scim isProvisioned: %v", err)
+			writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if !provisioned {
+			writeSCIMError(w, http.StatusConflict, "userName is taken by an account this org did not provision")
+			return
+		}
+
+		existing, err := h.orgStore.GetMember(int64(org.ID), user.ID)
+		if err != nil {
+			h.logger.Printf("ERROR: scim getMember: %v", err)
+			writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if existing != nil {
+			writeSCIMError(w, http.StatusConflict, "user is already a member of this org")
+			return
+		}
+
+		member.User = user
+		err = h.orgStore.UpsertMember(member)
+		if err != nil {
+			h.logger.Printf("ERROR: scim upsertMember: %v", err)
+			writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/scim/v2/Users/%d", member.User.ID))
+	writeSCIM(w, http.StatusCreated, toSCIMUser(member))
+}
+
+//! HandleReplaceUser --> PUT /scim/v2/Users/{id}
+//? only active/externalId are applied, they live on this org's membership;
+//? userName and emails belong to the account itself and are never rewritten through SCIM
+func (h *SCIMHandler) HandleReplaceUser(w http.ResponseWriter, req *http.Request) {
+	member, ok := h.loadMember(w, req)
+	if !ok {
+		return
+	}
+
+	var r scimUserRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	member.ExternalID = r.ExternalID
+	if !h.setActiveChecked(w, member, r.Active == nil || *r.Active) {
+		return
+	}
+
+	writeSCIM(w, http.StatusOK, toSCIMUser(member))
+}
+
+//! HandlePatchUser --> PATCH /scim/v2/Users/{id}
+//! IdPs deprovision with {"op":"replace","path":"active","value":false} (or value {"active":false})
+func (h *SCIMHandler) HandlePatchUser(w http.ResponseWriter, req *http.Request) {
+	member, ok := h.loadMember(w, req)
+	if !ok {
+		return
+	}
+
+	var r scimPatchRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	active := member.Active
+	for _, op := range r.Operations {
+		if !strings.EqualFold(op.Op, "replace") && !strings.EqualFold(op.Op, "add") {
+			writeSCIMError(w, http.StatusBadRequest, "unsupported patch operation")
+			return
+		}
+
+		switch op.Path {
+		case "active":
+			err = json.Unmarshal(op.Value, &active)
+		case "":
+			var value struct {
+				Active *bool `json:"active"`
+			}
+			err = json.Unmarshal(op.Value, &value)
+			if value.Active != nil {
+				active = *value.Active
+			}
+		default:
+			writeSCIMError(w, http.StatusBadRequest, "unsupported patch path")
+			return
+		}
+		if err != nil {
+			writeSCIMError(w, http.StatusBadRequest, "invalid patch value")
+			return
+		}
+	}
+
+	if !h.setActiveChecked(w, member, active) {
+		return
+	}
+
+	writeSCIM(w, http.StatusOK, toSCIMUser(member))
+}
+
+//! HandleDeleteUser --> DELETE /scim/v2/Users/{id}
+//? removes the org membership only, the account and its sessions survive (it may belong elsewhere)
+func (h *SCIMHandler) HandleDeleteUser(w http.ResponseWriter, req *http.Request) {
+	member, ok := h.loadMember(w, req)
+	if !ok {
+		return
+	}
+
+	last, err := h.isLastOwner(member)
+	if err != nil {
+		h.logger.Printf("ERROR: scim countActiveOwners: %v", err)
+		writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if last {
+		writeSCIMError(w, http.StatusConflict, "the org's last active owner can't be removed")
+		return
+	}
+
+	err = h.orgStore.RemoveMember(int64(member.OrgID), member.User.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: scim removeMember: %v", err)
+		writeSCIMError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
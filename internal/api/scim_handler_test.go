@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/memstore"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * scimRouter --> the /scim/v2 routes from routes.go behind the token middleware
+func scimRouter(users store.UserStore, orgs store.OrgStore) http.Handler {
+	h := NewSCIMHandler(users, orgs, log.New(io.Discard, "", 0))
+	sm := &middleware.SCIMMiddleware{OrgStore: orgs}
+	r := chi.NewRouter()
+	r.Route("/scim/v2", func(r chi.Router) {
+		r.Use(sm.Authenticate)
+		r.Get("/Users", h.HandleListUsers)
+		r.Post("/Users", h.HandleCreateUser)
+		r.Get("/Users/{id}", h.HandleGetUser)
+		r.Put("/Users/{id}", h.HandleReplaceUser)
+		r.Patch("/Users/{id}", h.HandlePatchUser)
+		r.Delete("/Users/{id}", h.HandleDeleteUser)
+	})
+	return r
+}
+
+// * scimCall --> one request as the identity provider holding token
+func scimCall(router http.Handler, token, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// * rotateSCIMToken --> POST /orgs/{id}/scim-token as user
+func rotateSCIMToken(h *OrgHandler, user *store.User, orgID int) *httptest.ResponseRecorder {
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", strconv.Itoa(orgID))
+	req := httptest.NewRequest(http.MethodPost, "/orgs/"+strconv.Itoa(orgID)+"/scim-token", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+	req = middleware.SetUser(req, user)
+	rec := httptest.NewRecorder()
+	h.HandleRotateSCIMToken(rec, req)
+	return rec
+}
+
+// * issueSCIMToken --> rotates the org's token as its owner and returns the plaintext
+func issueSCIMToken(t *testing.T, h *OrgHandler, owner *store.User, orgID int) string {
+	rec := rotateSCIMToken(h, owner, orgID)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var body struct {
+		Token string `json:"scim_token"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.NotEmpty(t, body.Token)
+	return body.Token
+}
+
+// * scimUserID --> the SCIM id of the user resource in rec
+func scimUserID(t *testing.T, rec *httptest.ResponseRecorder) string {
+	var body struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.NotEmpty(t, body.ID)
+	return body.ID
+}
+
+// ! TestSCIMTokenIssuance --> only an org owner gets a token, rotating it retires the old one
+func TestSCIMTokenIssuance(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	orgs := memstore.NewOrgStore(db)
+	h := NewOrgHandler(orgs, log.New(io.Discard, "", 0))
+	router := scimRouter(users, orgs)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	acme := &store.Org{Name: "acme"}
+	require.NoError(t, orgs.CreateOrg(acme, ana.ID))
+	require.NoError(t, orgs.UpsertMember(&store.OrgMember{OrgID: acme.ID, User: ben, Role: store.OrgRoleMember, Active: true}))
+
+	assert.Equal(t, http.StatusForbidden, rotateSCIMToken(h, ben, acme.ID).Code, "members can't issue tokens")
+
+	first := issueSCIMToken(t, h, ana, acme.ID)
+	assert.Equal(t, http.StatusOK, scimCall(router, first, http.MethodGet, "/scim/v2/Users", "").Code)
+
+	second := issueSCIMToken(t, h, ana, acme.ID)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, http.StatusUnauthorized, scimCall(router, first, http.MethodGet, "/scim/v2/Users", "").Code, "the rotated token stops working")
+	assert.Equal(t, http.StatusOK, scimCall(router, second, http.MethodGet, "/scim/v2/Users", "").Code)
+
+	assert.Equal(t, http.StatusUnauthorized, scimCall(router, "", http.MethodGet, "/scim/v2/Users", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, scimCall(router, "not-a-token", http.MethodGet, "/scim/v2/Users", "").Code)
+}
+
+// ! TestSCIMOrgScoping --> an org's token sees and changes its own members only, and can't claim accounts it didn't provision
+func TestSCIMOrgScoping(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	orgs := memstore.NewOrgStore(db)
+	h := NewOrgHandler(orgs, log.New(io.Discard, "", 0))
+	router := scimRouter(users, orgs)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	acme := &store.Org{Name: "acme"}
+	globex := &store.Org{Name: "globex"}
+	require.NoError(t, orgs.CreateOrg(acme, ana.ID))
+	require.NoError(t, orgs.CreateOrg(globex, ben.ID))
+	acmeToken := issueSCIMToken(t, h, ana, acme.ID)
+	globexToken := issueSCIMToken(t, h, ben, globex.ID)
+
+	rec := scimCall(router, acmeToken, http.MethodPost, "/scim/v2/Users", `{"userName":"cleo","externalId":"idp-1","emails":[{"value":"cleo@example.com","primary":true}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	cleo := "/scim/v2/Users/" + scimUserID(t, rec)
+
+	// * the other org's token can't read or touch the membership
+	assert.Equal(t, http.StatusNotFound, scimCall(router, globexToken, http.MethodGet, cleo, "").Code)
+	assert.Equal(t, http.StatusNotFound, scimCall(router, globexToken, http.MethodPut, cleo, `{"active":false}`).Code)
+	assert.Equal(t, http.StatusNotFound, scimCall(router, globexToken, http.MethodPatch, cleo, `{"Operations":[{"op":"replace","path":"active","value":false}]}`).Code)
+	assert.Equal(t, http.StatusNotFound, scimCall(router, globexToken, http.MethodDelete, cleo, "").Code)
+
+	var list struct {
+		TotalResults int `json:"totalResults"`
+	}
+	rec = scimCall(router, globexToken, http.MethodGet, `/scim/v2/Users?filter=userName%20eq%20%22cleo%22`, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Zero(t, list.TotalResults)
+
+	rec = scimCall(router, acmeToken, http.MethodGet, cleo, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var user struct {
+		Active     bool   `json:"active"`
+		ExternalID string `json:"externalId"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	assert.True(t, user.Active, "globex's deactivation never landed")
+	assert.Equal(t, "idp-1", user.ExternalID)
+
+	// * naming an existing account doesn't link it
+	assert.Equal(t, http.StatusConflict, scimCall(router, globexToken, http.MethodPost, "/scim/v2/Users", `{"userName":"cleo"}`).Code, "provisioned by acme, not globex")
+	assert.Equal(t, http.StatusConflict, scimCall(router, acmeToken, http.MethodPost, "/scim/v2/Users", `{"userName":"ben"}`).Code, "signed up on its own")
+	member, err := orgs.GetMember(int64(acme.ID), ben.ID)
+	require.NoError(t, err)
+	assert.Nil(t, member)
+
+	// * acme can link the account it provisioned again after removing it, but not twice
+	assert.Equal(t, http.StatusConflict, scimCall(router, acmeToken, http.MethodPost, "/scim/v2/Users", `{"userName":"cleo"}`).Code, "already a member")
+	assert.Equal(t, http.StatusNoContent, scimCall(router, acmeToken, http.MethodDelete, cleo, "").Code)
+	assert.Equal(t, http.StatusNotFound, scimCall(router, acmeToken, http.MethodGet, cleo, "").Code)
+	assert.Equal(t, http.StatusCreated, scimCall(router, acmeToken, http.MethodPost, "/scim/v2/Users", `{"userName":"cleo"}`).Code)
+	assert.Equal(t, http.StatusOK, scimCall(router, acmeToken, http.MethodGet, cleo, "").Code)
+}
+
+// ! TestSCIMLastOwner --> a deactivated owner loses owner rights, SCIM can't deactivate or remove the org's last active owner
+func TestSCIMLastOwner(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	orgs := memstore.NewOrgStore(db)
+	h := NewOrgHandler(orgs, log.New(io.Discard, "", 0))
+	router := scimRouter(users, orgs)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	acme := &store.Org{Name: "acme"}
+	require.NoError(t, orgs.CreateOrg(acme, ana.ID))
+	token := issueSCIMToken(t, h, ana, acme.ID)
+	anaPath := "/scim/v2/Users/" + strconv.Itoa(ana.ID)
+	benPath := "/scim/v2/Users/" + strconv.Itoa(ben.ID)
+
+	// * ana is the only owner, every way of taking her out is refused
+	assert.Equal(t, http.StatusConflict, scimCall(router, token, http.MethodPut, anaPath, `{"active":false}`).Code)
+	assert.Equal(t, http.StatusConflict, scimCall(router, token, http.MethodPatch, anaPath, `{"Operations":[{"op":"replace","path":"active","value":false}]}`).Code)
+	assert.Equal(t, http.StatusConflict, scimCall(router, token, http.MethodDelete, anaPath, "").Code)
+	assert.Equal(t, http.StatusOK, scimCall(router, token, http.MethodPut, anaPath, `{"active":true,"externalId":"idp-ana"}`).Code, "keeping her active is fine")
+
+	// * with a second active owner ana can go, and her owner rights go with the membership
+	require.NoError(t, orgs.UpsertMember(&store.OrgMember{OrgID: acme.ID, User: ben, Role: store.OrgRoleOwner, Active: true}))
+	assert.Equal(t, http.StatusOK, scimCall(router, token, http.MethodPatch, anaPath, `{"Operations":[{"op":"replace","path":"active","value":false}]}`).Code)
+	assert.Equal(t, http.StatusForbidden, rotateSCIMToken(h, ana, acme.ID).Code, "a deactivated owner is no owner")
+	assert.Equal(t, http.StatusConflict, scimCall(router, token, http.MethodDelete, benPath, "").Code, "ben is the last active one now")
+	assert.Equal(t, http.StatusNoContent, scimCall(router, token, http.MethodDelete, anaPath, "").Code, "an inactive owner can be removed")
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fem/internal/achievements"
+	"fem/internal/middleware"
+	"fem/internal/seasons"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fem/internal/worker"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//! defaultBadgeColor --> used when an admin doesn't pick one
+const defaultBadgeColor = "#9c27b0"
+
+var badgeColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type SeasonalEventHandler struct {
+	eventStore store.SeasonalEventStore //* events, participants + standings
+	jobs       worker.Enqueuer          //* enrolls opted-in users right after an event is created
+	logger     *log.Logger
+}
+
+//! seasonalEventRequest --> POST/PUT /admin/seasonal-events payload
+type seasonalEventRequest struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Metric      string    `json:"metric"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	BadgeColor  string    `json:"badge_color"`
+}
+
+//! NewSeasonalEventHandler --> constructor for seasonal event handler
+func NewSeasonalEventHandler(eventStore store.SeasonalEventStore, jobs worker.Enqueuer, logger *log.Logger) *SeasonalEventHandler {
+	return &SeasonalEventHandler{
+		eventStore: eventStore,
+		jobs:       jobs,
+		logger:     logger,
+	}
+}
+
+func (r *seasonalEventRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 100 {
+		return errors.New("name is required and must be at most 100 characters")
+	}
+	if !store.ValidEventMetric(r.Metric) {
+		return errors.New("metric must be one of workouts, duration_minutes, calories, volume_kg")
+	}
+	if r.StartsAt.IsZero() || r.EndsAt.IsZero() {
+		return errors.New("starts_at and ends_at are required")
+	}
+	if !r.EndsAt.After(r.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if r.BadgeColor == "" {
+		r.BadgeColor = defaultBadgeColor
+	}
+	if !badgeColorPattern.MatchString(r.BadgeColor) {
+		return errors.New("badge_color must be a hex color like #9c27b0")
+	}
+	return nil
+}
+
+//! loadEvent --> {id} from the URL, writes the 404 itself
+func (h *SeasonalEventHandler) loadEvent(w http.ResponseWriter, req *http.Request) (*store.SeasonalEvent, bool) {
+	eventID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid event id"})
+		return nil, false
+	}
+
+	event, err := h.eventStore.GetSeasonalEvent(eventID)
+	if err != nil {
+		h.logger.Printf("ERROR: getSeasonalEvent: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if event == nil {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "event not found"})
+		return nil, false
+	}
+	return event, true
+}
+
+//! enroll --> best effort, the periodic enroll job catches up if this fails
+func (h *SeasonalEventHandler) enroll() {
+	err := h.jobs.Enqueue(seasons.JobEnroll, struct{}{})
+	if err != nil {
+		h.logger.Printf("ERROR: enqueue seasonal event enroll: %v", err)
+	}
+}
+
+//! HandleCreateEvent --> POST /admin/seasonal-events
+func (h *SeasonalEventHandler) HandleCreateEvent(w http.ResponseWriter, req *http.Request) {
+	var r seasonalEventRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	err = r.validate()
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	createdBy := middleware.GetUser(req).ID
+	event := &store.SeasonalEvent{
+		Name:        r.Name,
+		Description: r.Description,
+		Metric:      r.Metric,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+		BadgeColor:  r.BadgeColor,
+		CreatedBy:   &createdBy,
+	}
+	err = h.eventStore.CreateSeasonalEvent(event)
+	if err != nil {
+		h.logger.Printf("ERROR: createSeasonalEvent: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	h.enroll()
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"event": event})
+}
+
+//! HandleUpdateEvent --> PUT /admin/seasonal-events/{id}, closed events can't change anymore
+func (h *SeasonalEventHandler) HandleUpdateEvent(w http.ResponseWriter, req *http.Request) {
+	event, ok := h.loadEvent(w, req)
+	if !ok {
+		return
+	}
+	if event.ClosedAt != nil {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "event is closed"})
+		return
+	}
+
+	var r seasonalEventRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	err = r.validate()
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	event.Name = r.Name
+	event.Description = r.Description
+	event.Metric = r.Metric
+	event.StartsAt = r.StartsAt
+	event.EndsAt = r.EndsAt
+	event.BadgeColor = r.BadgeColor
+	err = h.eventStore.UpdateSeasonalEvent(event)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "event is closed"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: updateSeasonalEvent: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	h.enroll()
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"event": event})
+}
+
+//! HandleDeleteEvent --> DELETE /admin/seasonal-events/{id}, takes participants and results with it
+func (h *SeasonalEventHandler) HandleDeleteEvent(w http.ResponseWriter, req *http.Request) {
+	eventID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid event id"})
+		return
+	}
+
+	err = h.eventStore.DeleteSeasonalEvent(eventID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "event not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: deleteSeasonalEvent: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//! HandleListEvents --> GET /seasonal-events
+func (h *SeasonalEventHandler) HandleListEvents(w http.ResponseWriter, req *http.Request) {
+	list, err := h.eventStore.ListSeasonalEvents(readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: listSeasonalEvents: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"events": list})
+}
+
+//! HandleGetEvent --> GET /seasonal-events/{id} with the caller's own standing (null when not taking part)
+func (h *SeasonalEventHandler) HandleGetEvent(w http.ResponseWriter, req *http.Request) {
+	event, ok := h.loadEvent(w, req)
+	if !ok {
+		return
+	}
+
+	me, err := h.eventStore.GetStanding(event, middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: getStanding: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"event": event, "me": me})
+}
+
+//! standingResponse --> standings row plus the badge link once the event is closed
+type standingResponse struct {
+	*store.EventStanding
+	BadgeURL string `json:"badge_url,omitempty"`
+}
+
+//! HandleGetStandings --> GET /seasonal-events/{id}/standings?limit=
+func (h *SeasonalEventHandler) HandleGetStandings(w http.ResponseWriter, req *http.Request) {
+	event, ok := h.loadEvent(w, req)
+	if !ok {
+		return
+	}
+
+	standings, err := h.eventStore.GetStandings(event, readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: getStandings: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	list := make([]standingResponse, 0, len(standings))
+	for _, standing := range standings {
+		item := standingResponse{EventStanding: standing}
+		if event.ClosedAt != nil {
+			item.BadgeURL = fmt.Sprintf("/v1/seasonal-events/%s/badges/%s.svg", utils.FormatID(int64(event.ID)), utils.FormatID(int64(standing.UserID)))
+		}
+		list = append(list, item)
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"event": event, "standings": list})
+}
+
+//! HandleGetBadge --> GET /seasonal-events/{id}/badges/{userID}.svg public, only exists once the event closed with a score
+func (h *SeasonalEventHandler) HandleGetBadge(w http.ResponseWriter, req *http.Request) {
+	eventID, err := utils.ReadIDParam(req)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	userID, err := utils.ReadInt64Param(req, "userID")
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	event, err := h.eventStore.GetSeasonalEvent(eventID)
+	if err != nil {
+		h.logger.Printf("ERROR: getSeasonalEvent: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if event == nil || event.ClosedAt == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	result, err := h.eventStore.GetStanding(event, int(userID))
+	if err != nil {
+		h.logger.Printf("ERROR: getStanding: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	rule := &achievements.Rule{Name: fmt.Sprintf("#%d %s", result.Rank, event.Name), Color: event.BadgeColor}
+	var buf bytes.Buffer
+	err = achievements.WriteBadge(&buf, rule, result.Username, *event.ClosedAt)
+	if err != nil {
+		h.logger.Printf("ERROR: writeBadge: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type SessionHandler struct {
+	auth   *service.AuthService //* sessions are auth tokens, revoking them is logout
+	logger *log.Logger
+}
+
+// ! NewSessionHandler --> constructor for session handler
+func NewSessionHandler(authService *service.AuthService, logger *log.Logger) *SessionHandler {
+	return &SessionHandler{auth: authService, logger: logger}
+}
+
+// ! HandleListSessions --> GET /users/me/sessions, active tokens with where they were last used
+func (h *SessionHandler) HandleListSessions(w http.ResponseWriter, req *http.Request) {
+	//* RequireUser already validated the header format
+	current := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	sessions, err := h.auth.ListSessions(req.Context(), middleware.GetUser(req), current)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": invalid.Message})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: listSessions: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"sessions": sessions})
+}
+
+// ! HandleRevokeSession --> DELETE /users/me/sessions/{id}, revoking the current one is a plain logout
+func (h *SessionHandler) HandleRevokeSession(w http.ResponseWriter, req *http.Request) {
+	id, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid session id"})
+		return
+	}
+
+	err = h.auth.RevokeSession(req.Context(), middleware.GetUser(req), id)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "session not found"})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: revokeSession: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! HandleRevokeAllSessions --> DELETE /users/me/sessions, logout everywhere (this token included)
+func (h *SessionHandler) HandleRevokeAllSessions(w http.ResponseWriter, req *http.Request) {
+	err := h.auth.LogoutEverywhere(req.Context(), middleware.GetUser(req))
+	if err != nil {
+		h.logger.Printf("ERROR: logoutEverywhere: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"fem/internal/units"
+	"fem/internal/utils"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//! share link lifetimes
+const (
+	defaultShareTTL = 7 * 24 * time.Hour
+	maxShareTTL     = 90 * 24 * time.Hour
+)
+
+type ShareHandler struct {
+	workstore  store.WorkoutStore //* ownership checks + loading the shared workout
+	shareStore store.ShareStore   //* share link bookkeeping
+	logger     *log.Logger
+}
+
+//! createShareRequest --> optional body for POST /workouts/{id}/share
+type createShareRequest struct {
+	ExpiresInHours int `json:"expires_in_hours"` // * defaults to 7 days, capped at 90
+}
+
+//! NewShareHandler --> constructor for share handler
+func NewShareHandler(workoutStore store.WorkoutStore, shareStore store.ShareStore, logger *log.Logger) *ShareHandler {
+	return &ShareHandler{
+		workstore:  workoutStore,
+		shareStore: shareStore,
+		logger:     logger,
+	}
+}
+
+//! HandleCreateShare --> POST /workouts/{id}/share (owner only)
+//! returns the public link once, only its hash is stored
+func (h *ShareHandler) HandleCreateShare(w http.ResponseWriter, req *http.Request) {
+	workoutID, ok := requireWorkoutOwner(h.workstore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	var r createShareRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil && err != io.EOF { //* empty body is fine, defaults apply
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	ttl := defaultShareTTL
+	if r.ExpiresInHours > 0 {
+		ttl = min(time.Duration(r.ExpiresInHours)*time.Hour, maxShareTTL)
+	}
+
+	token, err := tokens.GenerateToken(middleware.GetUser(req).ID, ttl, tokens.ScopeShare)
+	if err != nil {
+		h.logger.Printf("ERROR: generating share token: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	share := &store.WorkoutShare{
+		WorkoutID: int(workoutID),
+		TokenHash: token.Hash,
+		ExpiresAt: token.Expiry,
+	}
+	err = h.shareStore.CreateShare(share)
+	if err != nil {
+		h.logger.Printf("ERROR: createShare: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"share": share, "url": "/v1/shared/" + token.Plaintext})
+}
+
+//! HandleRevokeShares --> DELETE /workouts/{id}/share revokes every active link
+func (h *ShareHandler) HandleRevokeShares(w http.ResponseWriter, req *http.Request) {
+	workoutID, ok := requireWorkoutOwner(h.workstore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.shareStore.RevokeShares(workoutID)
+	if err != nil {
+		h.logger.Printf("ERROR: revokeShares: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"revoked": revoked})
+}
+
+//! HandleGetShared --> GET /shared/{token} public, read-only view of the workout
+func (h *ShareHandler) HandleGetShared(w http.ResponseWriter, req *http.Request) {
+	token := chi.URLParam(req, "token")
+
+	workoutID, err := h.shareStore.GetSharedWorkoutID(token)
+	if err != nil {
+		h.logger.Printf("ERROR: getSharedWorkoutID: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	//? unknown, expired and revoked links all look the same to the caller
+	if workoutID == 0 {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "share link is invalid or has expired"})
+		return
+	}
+
+	workout, err := h.workstore.GetWorkoutByID(workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "share link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: getWorkoutByID: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	//* public viewers are anonymous, don't tell them who owns it and don't let caches keep it after revocation
+	workout.UserID = 0
+	w.Header().Set("Cache-Control", "no-store")
+	system := units.FromRequest(req) //* anonymous, so only ?units= picks imperial
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"workout": units.Workout(workout, system), "read_only": true, "units": system})
+}
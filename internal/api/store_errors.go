@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+)
+
+// ! storeErrorStatus --> the HTTP status every handler uses for the store errors, 0 for anything else
+// ? service.ErrNotFound is store.ErrNotFound, so service misses land here too
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, store.ErrForeignKey):
+		return http.StatusUnprocessableEntity
+	}
+	return 0
+}
+
+var storeErrorMessages = map[int]string{
+	http.StatusNotFound:            "not found",
+	http.StatusConflict:            "already exists",
+	http.StatusUnprocessableEntity: "refers to something that doesn't exist",
+}
+
+// ! writeStoreError --> store errors get their status + a generic message, anything else is logged as op and answered 500
+func writeStoreError(w http.ResponseWriter, logger *log.Logger, op string, err error) {
+	status := storeErrorStatus(err)
+	if status == 0 {
+		logger.Printf("ERROR: %s: %v", op, err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	utils.WriteJson(w, status, utils.Envelope{"error": storeErrorMessages[status]})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/base64"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/units"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSyncLimit = 100
+	maxSyncLimit     = 500
+)
+
+// ! sync ops --> what a client does with a change: put the workout in its local copy, or drop it
+const (
+	syncOpUpsert = "upsert"
+	syncOpDelete = "delete"
+)
+
+type SyncHandler struct {
+	syncStore store.SyncStore
+	logger    *log.Logger
+}
+
+// ! NewSyncHandler --> constructor for the offline sync handler
+func NewSyncHandler(syncStore store.SyncStore, logger *log.Logger) *SyncHandler {
+	return &SyncHandler{syncStore: syncStore, logger: logger}
+}
+
+// ! syncChange --> one change as clients get it, workout only for upserts
+type syncChange struct {
+	Op        string         `json:"op"`
+	WorkoutID int64          `json:"workout_id"`
+	ChangedAt time.Time      `json:"changed_at"`
+	Workout   *store.Workout `json:"workout,omitempty"`
+}
+
+// ! encodeSyncCursor --> opaque like the feed cursor, clients only hand it back
+func encodeSyncCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(seq, 10)))
+}
+
+// * decodeSyncCursor --> "" is a full sync from the start of the log
+func decodeSyncCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || seq < 0 {
+		return 0, fmt.Errorf("invalid sync cursor %q", cursor)
+	}
+	return seq, nil
+}
+
+// ! HandleSync --> GET /sync?since=&limit=, the caller's workout changes after since, oldest first
+// ? every workout shows up once with its latest state (entries + tags) or as a tombstone; keep cursor for the next call,
+// ? has_more says to call again right away. Entries are converted to the caller's units like the workout routes
+func (h *SyncHandler) HandleSync(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	limit := defaultSyncLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(parsed, maxSyncLimit)
+	}
+
+	since, err := decodeSyncCursor(query.Get("since"))
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid since cursor"})
+		return
+	}
+
+	//* one extra row tells whether there's more without a count
+	changes, err := h.syncStore.ListChanges(middleware.GetUser(req).ID, since, limit+1)
+	if err != nil {
+		h.logger.Printf("ERROR: listChanges: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	hasMore := len(changes) > limit
+	if hasMore {
+		changes = changes[:limit]
+	}
+
+	system := units.FromRequest(req)
+	out := make([]syncChange, len(changes))
+	for i, change := range changes {
+		out[i] = syncChange{Op: syncOpUpsert, WorkoutID: change.WorkoutID, ChangedAt: change.ChangedAt}
+		if change.Deleted {
+			out[i].Op = syncOpDelete
+			continue
+		}
+		out[i].Workout = units.Workout(change.Workout, system)
+	}
+
+	//* an empty page hands back the cursor it got, clients store it either way
+	if len(changes) > 0 {
+		since = changes[len(changes)-1].Seq
+	}
+	cursor := encodeSyncCursor(since)
+	next := ""
+	if hasMore {
+		next = cursor
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{
+		"changes":  out,
+		"cursor":   cursor,
+		"has_more": hasMore,
+		"units":    system,
+	}.PaginateCursor(req, "since", next, limit))
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ! ?limit= default + cap for tag autocomplete
+const (
+	defaultTagLimit = 20
+	maxTagLimit     = 100
+)
+
+type TagHandler struct {
+	workouts *service.WorkoutService //* tag rules + ownership checks
+	logger   *log.Logger
+}
+
+// ! NewTagHandler --> constructor for tag handler
+func NewTagHandler(workoutService *service.WorkoutService, logger *log.Logger) *TagHandler {
+	return &TagHandler{workouts: workoutService, logger: logger}
+}
+
+// * writeTagError --> shared mapping for the tag routes, action names the failing call in the log
+func (h *TagHandler) writeTagError(w http.ResponseWriter, action string, err error) {
+	var invalid *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "workout not found"})
+	case errors.Is(err, service.ErrForbidden):
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "you can only tag your own workouts"})
+	case errors.Is(err, service.ErrUnavailable):
+		utils.WriteJson(w, http.StatusNotImplemented, utils.Envelope{"error": "workout tags are not available on this server"})
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": invalid.Message})
+	default:
+		h.logger.Printf("ERROR: %s: %v", action, err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+	}
+}
+
+// ! HandleListTags --> GET /tags?prefix=le&limit=20, the caller's tags with usage counts, most used first
+func (h *TagHandler) HandleListTags(w http.ResponseWriter, req *http.Request) {
+	limit, err := strconv.Atoi(req.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultTagLimit
+	}
+	limit = min(limit, maxTagLimit)
+
+	tags, err := h.workouts.ListTags(req.Context(), middleware.GetUser(req).ID, req.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		h.writeTagError(w, "listTags", err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"tags": tags})
+}
+
+// ! HandleSetTags --> PUT /workouts/{id}/tags {"tags": [...]}, replaces every tag, [] clears them
+func (h *TagHandler) HandleSetTags(w http.ResponseWriter, req *http.Request) {
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid workout id"})
+		return
+	}
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	err = json.NewDecoder(req.Body).Decode(&body)
+	if err != nil || body.Tags == nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "request body must be {\"tags\": [...]}"})
+		return
+	}
+
+	tags, err := h.workouts.SetTags(req.Context(), middleware.GetUser(req).ID, workoutID, body.Tags)
+	if err != nil {
+		h.writeTagError(w, "setTags", err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"tags": tags})
+}
+
+// ! HandleAddTag --> POST /workouts/{id}/tags {"tag": "legs"}
+func (h *TagHandler) HandleAddTag(w http.ResponseWriter, req *http.Request) {
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid workout id"})
+		return
+	}
+	var body struct {
+		Tag string `json:"tag"`
+	}
+	err = json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	tags, err := h.workouts.AddTag(req.Context(), middleware.GetUser(req).ID, workoutID, body.Tag)
+	if err != nil {
+		h.writeTagError(w, "addTag", err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"tags": tags})
+}
+
+// ! HandleRemoveTag --> DELETE /workouts/{id}/tags/{tag}
+func (h *TagHandler) HandleRemoveTag(w http.ResponseWriter, req *http.Request) {
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid workout id"})
+		return
+	}
+
+	err = h.workouts.RemoveTag(req.Context(), middleware.GetUser(req).ID, workoutID, chi.URLParam(req, "tag"))
+	if errors.Is(err, service.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "workout or tag not found"})
+		return
+	}
+	if err != nil {
+		h.writeTagError(w, "removeTag", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
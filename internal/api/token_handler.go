@@ -2,17 +2,19 @@ package api
 
 import (
 	"encoding/json"
-	"fem/internal/store"
-	"fem/internal/tokens"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/service"
 	"fem/internal/utils"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type TokenHandler struct {
-	tokenStore store.TokenStore //* for creating/storing tokens
-	userStore store.UserStore //* for validating user credentials
+	auth *service.AuthService //* credential checks + token creation, shared with the gRPC server
 	logger *log.Logger //* for error logging
 }
 
@@ -22,11 +24,16 @@ type createTokenRequest struct {
 	Password string `json:"password"` //* plaintext password to verify
 }
 
+//! verifyTwoFactorRequest --> second login step, code is a TOTP code or a backup code
+type verifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code string `json:"code"`
+}
+
 //! NewTokenHandler --> constructor for token handler
-func NewTokenHandler(tokenStore store.TokenStore,userStore store.UserStore,logger *log.Logger) *TokenHandler {
+func NewTokenHandler(authService *service.AuthService,logger *log.Logger) *TokenHandler {
 	return &TokenHandler{
-		tokenStore: tokenStore,
-		userStore: userStore,
+		auth: authService,
 		logger: logger,
 	}
 }
@@ -50,38 +57,83 @@ func (h *TokenHandler) HandleCreateToken(w http.ResponseWriter,req *http.Request
 	if err!= nil {
 		h.logger.Printf("ERROR : createTokenRequest %v", err)
 		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error":"invalid payload request"})
+		return
 	}
-	//* lookup user by username in database
-	user, err := h.userStore.GetUserByUsername(tokenRequestingUser.Username)
-	if err != nil || user == nil {
-		h.logger.Printf("ERROR: GetUserByUsername: %v", err)
-		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+	//* credentials valid --> new authentication token (expires in 24 hours)
+	token, err := h.auth.Login(req.Context(), tokenRequestingUser.Username, tokenRequestingUser.Password)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		//? unknown user or wrong password, the client can't tell which
+		utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "invalid credentials"})
+		return
+	}
+	var twoFactor *service.TwoFactorRequiredError
+	if errors.As(err, &twoFactor) {
+		//? right password, 2FA on --> trade the challenge + a code at POST /tokens/authentication/2fa
+		utils.WriteJson(w, http.StatusAccepted, utils.Envelope{"two_factor_required": true, "challenge_token": twoFactor.Challenge})
+		return
+	}
+	if h.writeLockedOut(w,err) {
 		return
 	}
-
-	//! comparing plaintext password with hashed password using bcrypt
-	passwordsDoMatch, err := user.PasswordHash.Matches(tokenRequestingUser.Password)
 	if err != nil {
-		h.logger.Printf("ERORR: PasswordHash.Mathes %v", err)
+		h.logger.Printf("ERORR: Creating Token %v", err)
 		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
 		return
 	}
 
-	if !passwordsDoMatch {
-		//? wrong password
-		utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "invalid credentials"})
+	//* return token to client (they'll use this in Authorization header for protected routes)
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"auth_token": token})
+}
+//! HandleVerifyTwoFactor --> POST /tokens/authentication/2fa, challenge from the password step + code --> auth token
+func (h *TokenHandler) HandleVerifyTwoFactor(w http.ResponseWriter,req *http.Request) {
+	var r verifyTwoFactorRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error":"invalid payload request"})
 		return
 	}
 
-	//* credentials valid! generate new authentication token (expire in 24 hours)
-	token, err := h.tokenStore.CreateNewToken(user.ID, 24*time.Hour, tokens.ScopeAuth)
+	token, err := h.auth.VerifyTwoFactor(req.Context(),r.ChallengeToken,r.Code)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		//? expired challenge or wrong code
+		utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "invalid challenge or code"})
+		return
+	}
+	if h.writeLockedOut(w,err) {
+		return
+	}
 	if err != nil {
-		h.logger.Printf("ERORR: Creating Token %v", err)
+		h.logger.Printf("ERROR: verifyTwoFactor: %v", err)
 		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
 		return
-
 	}
 
-	//* return token to client (they'll use this in Authorization header for protected routes)
 	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"auth_token": token})
-}
\ No newline at end of file
+}
+
+//! writeLockedOut --> 429 + Retry-After for a LockedOutError, false for anything else
+func (h *TokenHandler) writeLockedOut(w http.ResponseWriter,err error) bool {
+	var locked *service.LockedOutError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	//? too many failures for this username or IP, nothing was checked
+	retryAfter := locked.RetryAfter(time.Now())
+	w.Header().Set("Retry-After",strconv.Itoa(int(retryAfter.Seconds())))
+	utils.WriteJson(w, http.StatusTooManyRequests, utils.Envelope{"error": "too many failed login attempts", "locked_until": locked.Until, "retry_after_seconds": int(retryAfter.Seconds())})
+	return true
+}
+
+//! HandleDeleteToken --> DELETE /tokens/authentication (logout endpoint)
+//! Revokes the bearer token this request was authenticated with
+func (h *TokenHandler) HandleDeleteToken(w http.ResponseWriter,req *http.Request)  {
+	//* Authenticate + RequireUser already validated the header format
+	token := strings.TrimPrefix(req.Header.Get("Authorization"),"Bearer ")
+	err := h.auth.Logout(req.Context(),middleware.GetUser(req).ID,token)
+	if err != nil {
+		h.logger.Printf("ERROR: deleting token %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/trainingload"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//! ?weeks= default + cap for the weekly history
+const (
+	defaultTrainingLoadWeeks = 8
+	maxTrainingLoadWeeks     = 52
+)
+
+type TrainingLoadHandler struct {
+	loadStore  store.TrainingLoadStore //* daily session load
+	profiles   store.ProfileStore      //* timezone for the user's "today"
+	metric     string                  //* default ?metric=
+	thresholds trainingload.Thresholds
+	logger     *log.Logger
+}
+
+//! NewTrainingLoadHandler --> constructor for training load handler
+func NewTrainingLoadHandler(loadStore store.TrainingLoadStore, profiles store.ProfileStore, metric string, thresholds trainingload.Thresholds, logger *log.Logger) *TrainingLoadHandler {
+	return &TrainingLoadHandler{
+		loadStore:  loadStore,
+		profiles:   profiles,
+		metric:     metric,
+		thresholds: thresholds,
+		logger:     logger,
+	}
+}
+
+//! HandleGetTrainingLoad --> GET /stats/training-load?metric=duration|volume&weeks=8, acute:chronic ratio with risk bands
+func (h *TrainingLoadHandler) HandleGetTrainingLoad(w http.ResponseWriter, req *http.Request) {
+	metric := req.URL.Query().Get("metric")
+	if metric == "" {
+		metric = h.metric
+	}
+	if metric != trainingload.MetricDuration && metric != trainingload.MetricVolume {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "metric must be duration or volume"})
+		return
+	}
+	weeks, err := strconv.Atoi(req.URL.Query().Get("weeks"))
+	if err != nil || weeks < 1 {
+		weeks = defaultTrainingLoadWeeks
+	}
+	weeks = min(weeks, maxTrainingLoadWeeks)
+
+	user := middleware.GetUser(req)
+	profile, err := h.profiles.GetProfile(user.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: getProfile: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	today := trainingload.LocalDay(time.Now(), profile.Location())
+	daily, err := h.loadStore.ListDailyLoad(user.ID, trainingload.Since(today, weeks))
+	if err != nil {
+		h.logger.Printf("ERROR: listDailyLoad: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"training_load": trainingload.Compute(daily, metric, today, weeks, h.thresholds)})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+)
+
+type TwoFactorHandler struct {
+	auth   *service.AuthService //* TOTP setup + checks live with the rest of login
+	logger *log.Logger
+}
+
+// ! twoFactorCodeRequest --> confirm / disable payload, code is a TOTP code or (disable only) a backup code
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// ! NewTwoFactorHandler --> constructor for two-factor handler
+func NewTwoFactorHandler(authService *service.AuthService, logger *log.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{auth: authService, logger: logger}
+}
+
+// ! HandleGetTwoFactor --> GET /users/me/2fa, enabled flag + backup codes left
+func (h *TwoFactorHandler) HandleGetTwoFactor(w http.ResponseWriter, req *http.Request) {
+	tf, err := h.auth.TwoFactorStatus(req.Context(), middleware.GetUser(req))
+	if err != nil {
+		h.logger.Printf("ERROR: twoFactorStatus: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if !tf.Enabled() {
+		utils.WriteJson(w, http.StatusOK, utils.Envelope{"enabled": false})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"enabled": true, "confirmed_at": tf.ConfirmedAt, "backup_codes_left": tf.BackupCodesLeft})
+}
+
+// ! HandleSetup --> POST /users/me/2fa/setup, new secret + otpauth URI, nothing changes until it is confirmed
+// ? render otpauth_uri as a QR code for authenticator apps, secret is for typing in by hand
+func (h *TwoFactorHandler) HandleSetup(w http.ResponseWriter, req *http.Request) {
+	setup, err := h.auth.SetupTwoFactor(req.Context(), middleware.GetUser(req))
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": invalid.Message})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: setupTwoFactor: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"two_factor": setup})
+}
+
+// ! HandleConfirm --> POST /users/me/2fa/confirm {"code"}, turns 2FA on and returns the backup codes (shown once)
+func (h *TwoFactorHandler) HandleConfirm(w http.ResponseWriter, req *http.Request) {
+	var r twoFactorCodeRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	codes, err := h.auth.ConfirmTwoFactor(req.Context(), middleware.GetUser(req), r.Code)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": invalid.Message})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: confirmTwoFactor: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"enabled": true, "backup_codes": codes})
+}
+
+// ! HandleDisable --> DELETE /users/me/2fa {"code"}, a TOTP or backup code is required
+func (h *TwoFactorHandler) HandleDisable(w http.ResponseWriter, req *http.Request) {
+	var r twoFactorCodeRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	err = h.auth.DisableTwoFactor(req.Context(), middleware.GetUser(req), r.Code)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "two-factor authentication is not enabled"})
+		return
+	case errors.Is(err, service.ErrInvalidCredentials):
+		utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "invalid code"})
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: disableTwoFactor: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
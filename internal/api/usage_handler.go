@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+//! usageWindow --> how far back the request history goes
+const usageWindow = 30 * 24 * time.Hour
+
+type UsageHandler struct {
+	userUsage    store.UserUsageStore //* per-user daily request counters
+	exportStore  store.ExportStore    //* export quota + stored bundles
+	dailyExports int                  //* same limit the export handler enforces, 0 = unlimited
+	logger       *log.Logger
+}
+
+//! requestUsage --> authenticated requests, counts lag by CLIENT_USAGE_FLUSH_INTERVAL
+type requestUsage struct {
+	Today      int64                 `json:"today"`
+	Last30Days int64                 `json:"last_30_days"`
+	Daily      []*store.UserRequests `json:"daily"`
+}
+
+//! storageUsage --> bytes the server keeps on disk for the caller
+//? export bundles are the only per-user files today, workout imports are parsed and discarded
+type storageUsage struct {
+	UsedBytes int64 `json:"used_bytes"`
+	Files     int   `json:"files"`
+}
+
+//! exportQuota --> account exports in the last 24h against ACCOUNT_EXPORTS_PER_DAY
+type exportQuota struct {
+	Used      int        `json:"used"`
+	Limit     *int       `json:"limit"`     // * nil when unlimited
+	Remaining *int       `json:"remaining"` // * nil when unlimited
+	ResetsAt  *time.Time `json:"resets_at"` // * when the oldest counted export drops out of the window
+}
+
+//! NewUsageHandler --> constructor for usage handler
+func NewUsageHandler(userUsage store.UserUsageStore, exportStore store.ExportStore, dailyExports int, logger *log.Logger) *UsageHandler {
+	return &UsageHandler{
+		userUsage:    userUsage,
+		exportStore:  exportStore,
+		dailyExports: dailyExports,
+		logger:       logger,
+	}
+}
+
+//! HandleGetMyUsage --> GET /users/me/usage, what the caller used against their limits
+//? rate_limit stays null, requests aren't rate limited yet
+func (h *UsageHandler) HandleGetMyUsage(w http.ResponseWriter, req *http.Request) {
+	currentUser := middleware.GetUser(req)
+	now := time.Now().UTC()
+
+	daily, err := h.userUsage.ListUserRequests(currentUser.ID, now.Add(-usageWindow))
+	if err != nil {
+		h.logger.Printf("ERROR: listUserRequests: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	requests := requestUsage{Daily: daily}
+	today := now.Truncate(24 * time.Hour)
+	for _, d := range daily {
+		requests.Last30Days += d.Requests
+		if d.Day.Equal(today) {
+			requests.Today = d.Requests
+		}
+	}
+
+	//* every export ever requested can still have a bundle on disk, the quota only looks at the last day
+	exports, err := h.exportStore.ListUserExports(currentUser.ID, time.Time{})
+	if err != nil {
+		h.logger.Printf("ERROR: listUserExports: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	storage := storageUsage{}
+	quota := exportQuota{}
+	windowStart := now.Add(-exportQuotaWindow)
+	for _, job := range exports {
+		if job.FilePath != "" {
+			if info, err := os.Stat(job.FilePath); err == nil {
+				storage.UsedBytes += info.Size()
+				storage.Files++
+			}
+		}
+		if job.CreatedAt.After(windowStart) {
+			quota.Used++
+			resetsAt := job.CreatedAt.Add(exportQuotaWindow).UTC() //* newest first, ends on the oldest
+			quota.ResetsAt = &resetsAt
+		}
+	}
+	if h.dailyExports > 0 {
+		limit, remaining := h.dailyExports, max(h.dailyExports-quota.Used, 0)
+		quota.Limit, quota.Remaining = &limit, &remaining
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"usage": utils.Envelope{
+		"requests":   requests,
+		"rate_limit": nil,
+		"storage":    storage,
+		"exports":    quota,
+	}})
+}
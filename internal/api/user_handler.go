@@ -3,70 +3,49 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fem/internal/middleware"
+	"fem/internal/service"
 	"fem/internal/store"
 	"fem/internal/utils"
 	"log"
 	"net/http"
-	"regexp"
+	"time"
 )
 
-//! types declaration
-//! registerUserRequest --> incoming JSON payload for user registration
-type registerUserRequest struct {
-	Username string `json:"username"` //* unique username for login
-	Password string `json:"password"` //* plaintext password (will be hashed)
-	Email    string `json:"email"` //* user email address
-	Bio      string `json:"bio"` //* optional user bio
+//! deleteAccountRequest --> DELETE /users/me payload, the password is asked again before anything is removed
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+//! changePasswordRequest --> PUT /users/me/password payload
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword string `json:"new_password"`
 }
 
 type UserHandler struct {
 	userStore store.UserStore //* database operations for users
+	users *service.UserService //* registration rules shared with the gRPC server
+	auth *service.AuthService //* password changes (audited)
+	deletionGrace time.Duration //* how long a deleted account waits before the purge job removes it
 	logger *log.Logger //* for error logging
 }
 
 //! NewUserHandler --> constructor that creates user handler instance
-func NewUserHandler(userStore store.UserStore, logger *log.Logger) *UserHandler {
+func NewUserHandler(userStore store.UserStore, userService *service.UserService, authService *service.AuthService, deletionGrace time.Duration, logger *log.Logger) *UserHandler {
 	//* return instance of struct --> methods can now access userStore and logger
 	return &UserHandler{
 		userStore: userStore,
+		users: userService,
+		auth: authService,
+		deletionGrace: deletionGrace,
 		logger: logger,
 	}
 }
 
-//! validateUserRegisterRequest --> server-side validation before saving to database
-//? prevents invalid data from entering the system
-func (h *UserHandler) validateUserRegisterRequest (regUser *registerUserRequest) error {
-	//* checking if username is provided
-	if regUser.Username == "" {
-	return	errors.New("Username is required")
-	}
-
-	if len(regUser.Username) > 50 {
-	return	errors.New("Username cannot be greater than 50 characters")
-	}
-
-	if regUser.Email == "" {
-	return	errors.New("Email is required")
-	}
-	
-	if regUser.Password == "" {
-		return errors.New("password is required")
-	}
-
-	//! regex validation for email format
-	emailRegexPattern := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegexPattern.MatchString(regUser.Email)  {
-		//* test if email matches standard email pattern
-		return errors.New("Invalid email format")
-	} 
-
-	return nil
-
-}
-
 //! HandleRegisterUser --> POST /users endpoint for creating new user accounts
 func (h *UserHandler) HandleRegisterUser(w http.ResponseWriter, req *http.Request) {
-	var r registerUserRequest //* holds incoming JSON data
+	var r service.Registration //* holds incoming JSON data
 
 	//* decode JSON body into struct
 	err:= json.NewDecoder(req.Body).Decode(&r)
@@ -76,39 +55,96 @@ func (h *UserHandler) HandleRegisterUser(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	err = h.validateUserRegisterRequest(&r)
-	if err!= nil {
-		// h.logger.Printf("Error : decoding Register request : %V ",err)
-		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error":err.Error()})
+	//* validation, bcrypt hashing (NEVER store plaintext passwords) and hooks live in the service
+	user,err := h.users.Register(req.Context(),r)
+	var invalid *service.ValidationError
+	if errors.As(err,&invalid) {
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error":invalid.Message})
+		return
+	}
+	var weak *service.PasswordError
+	if errors.As(err,&weak) {
+		writeWeakPassword(w,weak)
+		return
+	}
+	if err != nil {
+		writeStoreError(w,h.logger,"registering user",err) //* 409 --> username or email already taken
+		return
+	}
+
+	//* 201 Created response with user data (password hash is excluded via json:"-" tag)
+		utils.WriteJson(w,http.StatusCreated,utils.Envelope{"user":user })
+
+}
+
+//! HandleDeleteMe --> DELETE /users/me, soft deletes the account, the purge job hard deletes it after the grace period
+func (h *UserHandler) HandleDeleteMe(w http.ResponseWriter, req *http.Request) {
+	var r deleteAccountRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil || r.Password == "" {
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error":"password is required"})
 		return
 	}
 
-	//* create User struct with validated data
-	user := &store.User{
-		Username: r.Username,
-		Email: r.Email,
+	//* a stolen token alone shouldn't be enough to wipe an account
+	currentUser := middleware.GetUser(req)
+	ok,err := currentUser.PasswordHash.Matches(r.Password)
+	if err != nil {
+		h.logger.Printf("ERROR: matching password: %v",err)
+		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error":"internal server error"})
+		return
 	}
-	if r.Bio == "" {
-		user.Bio = r.Bio
+	if !ok {
+		utils.WriteJson(w,http.StatusUnauthorized,utils.Envelope{"error":"invalid password"})
+		return
 	}
 
-	//! hash the password using bcrypt (cost factor 12) - NEVER store plaintext passwords
-	err = user.PasswordHash.Set(r.Password)
+	deletedAt,err := h.userStore.DeleteAccount(int64(currentUser.ID))
+	if errors.Is(err,store.ErrNotFound) {
+		utils.WriteJson(w,http.StatusNotFound,utils.Envelope{"error":"user not found"})
+		return
+	}
 	if err != nil {
-		h.logger.Printf("ERROR : hashing password %v ",err)
+		h.logger.Printf("ERROR: deleteAccount: %v",err)
 		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error":"internal server error"})
 		return
 	}
 
-	//* save user to database
-	err = h.userStore.CreateUser(user)
+	utils.WriteJson(w,http.StatusAccepted,utils.Envelope{"deleted_at":deletedAt,"purge_after":deletedAt.Add(h.deletionGrace)})
+}
+
+//! HandleChangePassword --> PUT /users/me/password, current password required, other sessions stay signed in
+func (h *UserHandler) HandleChangePassword(w http.ResponseWriter, req *http.Request) {
+	var r changePasswordRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
 	if err != nil {
-		h.logger.Printf("ERROR : registering user %v ",err)
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error":"invalid request payload"})
+		return
+	}
+
+	err = h.auth.ChangePassword(req.Context(),middleware.GetUser(req),r.CurrentPassword,r.NewPassword)
+	var invalid *service.ValidationError
+	var weak *service.PasswordError
+	switch {
+	case errors.As(err,&invalid):
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error":invalid.Message})
+		return
+	case errors.Is(err,service.ErrInvalidCredentials):
+		utils.WriteJson(w,http.StatusUnauthorized,utils.Envelope{"error":"invalid password"})
+		return
+	case errors.As(err,&weak):
+		writeWeakPassword(w,weak)
+		return
+	case err != nil:
+		h.logger.Printf("ERROR: changePassword: %v",err)
 		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error":"internal server error"})
 		return
 	}
 
-	//* 201 Created response with user data (password hash is excluded via json:"-" tag)
-		utils.WriteJson(w,http.StatusCreated,utils.Envelope{"user":user })
+	w.WriteHeader(http.StatusNoContent)
+}
 
-}
\ No newline at end of file
+//! writeWeakPassword --> 422 listing every rule the password failed, so the client can show them all at once
+func writeWeakPassword(w http.ResponseWriter,weak *service.PasswordError) {
+	utils.WriteJson(w,http.StatusUnprocessableEntity,utils.Envelope{"error":"password is too weak","password_errors":weak.Problems})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/anomaly"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fem/internal/verification"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type VerificationHandler struct {
+	workstore         store.WorkoutStore      //* loads the workout being verified
+	followStore       store.FollowStore       //* who may look at the verification
+	orgStore          store.OrgStore          //* coach <-> athlete relationship
+	verificationStore store.VerificationStore //* evidence + status
+	detector          *anomaly.Detector       //* anomaly score
+	requirements      verification.Requirements
+	logger            *log.Logger
+}
+
+//! attachVideoRequest --> POST /workouts/{id}/verification payload
+type attachVideoRequest struct {
+	VideoURL string `json:"video_url"`
+}
+
+//! attestRequest --> POST /workouts/{id}/verification/attest payload
+type attestRequest struct {
+	Approved *bool  `json:"approved"`
+	Note     string `json:"note"`
+}
+
+//! NewVerificationHandler --> constructor for verification handler
+func NewVerificationHandler(workoutStore store.WorkoutStore, followStore store.FollowStore, orgStore store.OrgStore, verificationStore store.VerificationStore, detector *anomaly.Detector, logger *log.Logger) *VerificationHandler {
+	return &VerificationHandler{
+		workstore:         workoutStore,
+		followStore:       followStore,
+		orgStore:          orgStore,
+		verificationStore: verificationStore,
+		detector:          detector,
+		requirements:      verification.DefaultRequirements(),
+		logger:            logger,
+	}
+}
+
+//! load --> existing evidence for the workout, or a fresh pending record
+func (h *VerificationHandler) load(workout *store.Workout) (*store.WorkoutVerification, error) {
+	v, err := h.verificationStore.GetVerification(int64(workout.ID))
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		v = &store.WorkoutVerification{WorkoutID: workout.ID, Status: store.VerificationPending}
+	}
+	return v, nil
+}
+
+//! evaluateAndSave --> rescoring on every change keeps the score in line with the current workout
+func (h *VerificationHandler) evaluateAndSave(w http.ResponseWriter, workout *store.Workout, v *store.WorkoutVerification) {
+	v.AnomalyScore = h.detector.Score(workout)
+	status, missing := verification.Evaluate(v, workout.Flagged, h.requirements)
+	v.Status = status
+
+	err := h.verificationStore.SaveVerification(v)
+	if err != nil {
+		h.logger.Printf("ERROR: saveVerification: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"verification": v, "missing": missing, "verified": status == store.VerificationVerified})
+}
+
+//! HandleGetVerification --> GET /workouts/{id}/verification, visible to anyone who can see the workout
+func (h *VerificationHandler) HandleGetVerification(w http.ResponseWriter, req *http.Request) {
+	workout, ok := requireWorkoutViewer(h.workstore, h.followStore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	v, err := h.load(workout)
+	if err != nil {
+		h.logger.Printf("ERROR: getVerification: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	_, missing := verification.Evaluate(v, workout.Flagged, h.requirements)
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"verification": v, "missing": missing, "verified": workout.Verified})
+}
+
+//! HandleAttachVideo --> POST /workouts/{id}/verification (owner attaches video proof)
+func (h *VerificationHandler) HandleAttachVideo(w http.ResponseWriter, req *http.Request) {
+	workoutID, ok := requireWorkoutOwner(h.workstore, h.logger, w, req)
+	if !ok {
+		return
+	}
+
+	var r attachVideoRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+	parsed, err := url.Parse(r.VideoURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "video_url must be an http(s) URL"})
+		return
+	}
+
+	workout, err := h.workstore.GetWorkoutByID(workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "workout does not exists"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: getWorkoutByID: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	v, err := h.load(workout)
+	if err != nil {
+		h.logger.Printf("ERROR: getVerification: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	v.VideoURL = &r.VideoURL
+
+	h.evaluateAndSave(w, workout, v)
+}
+
+//! HandleAttest --> POST /workouts/{id}/verification/attest (coach of the athlete, never the athlete)
+//? coaches review private workouts too, so visibility isn't checked here, the coach relationship is
+func (h *VerificationHandler) HandleAttest(w http.ResponseWriter, req *http.Request) {
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "Invalid workout id"})
+		return
+	}
+	workout, err := h.workstore.GetWorkoutByID(workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "workout does not exists"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: getWorkoutByID: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	var r attestRequest
+	err = json.NewDecoder(req.Body).Decode(&r)
+	if err != nil || r.Approved == nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "approved is required"})
+		return
+	}
+
+	currentUser := middleware.GetUser(req)
+	if workout.UserID == currentUser.ID {
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "you cannot attest your own workout"})
+		return
+	}
+	coach, err := h.orgStore.IsCoachFor(currentUser.ID, workout.UserID)
+	if err != nil {
+		h.logger.Printf("ERROR: isCoachFor: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if !coach {
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "only a coach in the athlete's org can attest this workout"})
+		return
+	}
+
+	v, err := h.load(workout)
+	if err != nil {
+		h.logger.Printf("ERROR: getVerification: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	now := time.Now()
+	v.AttestedBy = &currentUser.ID
+	v.Approved = r.Approved
+	v.Note = r.Note
+	v.AttestedAt = &now
+
+	h.evaluateAndSave(w, workout, v)
+}
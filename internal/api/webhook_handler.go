@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fem/internal/webhooks"
+	"log"
+	"net/http"
+)
+
+type WebhookHandler struct {
+	webhookStore store.WebhookStore //* subscriptions + delivery log
+	allowHTTP    bool               //* WEBHOOKS_ALLOW_PRIVATE, local development against http://localhost
+	logger       *log.Logger
+}
+
+// ! createWebhookRequest --> POST /webhooks payload
+type createWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// ! NewWebhookHandler --> constructor for webhook handler
+func NewWebhookHandler(webhookStore store.WebhookStore, allowHTTP bool, logger *log.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookStore: webhookStore,
+		allowHTTP:    allowHTTP,
+		logger:       logger,
+	}
+}
+
+// ! requireWebhookOwner --> loads {id} and makes sure it belongs to the current user, writes the error itself
+func (h *WebhookHandler) requireWebhookOwner(w http.ResponseWriter, req *http.Request) (*store.Webhook, bool) {
+	webhookID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid webhook id"})
+		return nil, false
+	}
+
+	webhook, err := h.webhookStore.GetWebhook(webhookID)
+	if err != nil {
+		h.logger.Printf("ERROR: getWebhook: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	if webhook == nil || webhook.UserID != middleware.GetUser(req).ID {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "webhook not found"})
+		return nil, false
+	}
+	webhook.Secret = ""
+	return webhook, true
+}
+
+// ! HandleCreateWebhook --> POST /webhooks, the response is the only time the signing secret is shown
+func (h *WebhookHandler) HandleCreateWebhook(w http.ResponseWriter, req *http.Request) {
+	var r createWebhookRequest
+	err := json.NewDecoder(req.Body).Decode(&r)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "invalid request payload"})
+		return
+	}
+
+	err = webhooks.ValidateURL(r.URL, h.allowHTTP)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+	eventList, err := webhooks.ValidateEvents(r.Events)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	currentUser := middleware.GetUser(req)
+	existing, err := h.webhookStore.ListWebhooks(currentUser.ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listWebhooks: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	if len(existing) >= webhooks.MaxPerUser {
+		utils.WriteJson(w, http.StatusConflict, utils.Envelope{"error": "webhook limit reached, delete one first"})
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		h.logger.Printf("ERROR: newSecret: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	webhook := &store.Webhook{UserID: currentUser.ID, URL: r.URL, Secret: secret, Events: eventList}
+	err = h.webhookStore.CreateWebhook(webhook)
+	if err != nil {
+		h.logger.Printf("ERROR: createWebhook: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, utils.Envelope{"webhook": webhook})
+}
+
+// ! HandleListWebhooks --> GET /webhooks, secrets left out
+func (h *WebhookHandler) HandleListWebhooks(w http.ResponseWriter, req *http.Request) {
+	list, err := h.webhookStore.ListWebhooks(middleware.GetUser(req).ID)
+	if err != nil {
+		h.logger.Printf("ERROR: listWebhooks: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+	for _, webhook := range list {
+		webhook.Secret = ""
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"webhooks": list})
+}
+
+// ! HandleDeleteWebhook --> DELETE /webhooks/{id}, pending deliveries go with it
+func (h *WebhookHandler) HandleDeleteWebhook(w http.ResponseWriter, req *http.Request) {
+	webhook, ok := h.requireWebhookOwner(w, req)
+	if !ok {
+		return
+	}
+
+	err := h.webhookStore.DeleteWebhook(webhook.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "webhook not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Printf("ERROR: deleteWebhook: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ! HandleListDeliveries --> GET /webhooks/{id}/deliveries?limit= newest first
+func (h *WebhookHandler) HandleListDeliveries(w http.ResponseWriter, req *http.Request) {
+	webhook, ok := h.requireWebhookOwner(w, req)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhookStore.ListDeliveries(webhook.ID, readLeaderboardLimit(req))
+	if err != nil {
+		h.logger.Printf("ERROR: listDeliveries: %v", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"webhook": webhook, "deliveries": deliveries})
+}
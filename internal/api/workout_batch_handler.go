@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fem/internal/anomaly"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/store"
+	"fem/internal/units"
+	"fem/internal/utils"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ! maxBatchBytes --> request size cap for POST /workouts/batch, room for service.MaxBatchOps full workouts
+const maxBatchBytes = 2 << 20
+
+// ! batchRequest --> POST /workouts/batch payload, operations run in order
+type batchRequest struct {
+	Atomic     bool               `json:"atomic"` //* all or nothing, default is every op on its own
+	Operations []batchRequestItem `json:"operations"`
+}
+
+// ! batchRequestItem --> workout is the POST /workouts body for create, the PUT /workouts/{id} body for update
+type batchRequestItem struct {
+	Op        string          `json:"op"`
+	ID        json.RawMessage `json:"id"`         //* update + delete, a number or an encoded id string
+	Workout   json.RawMessage `json:"workout"`    //* create + update
+	ClientRef string          `json:"client_ref"` //* echoed back, lets an offline queue match results to its entries
+}
+
+// ! batchResult --> one op's outcome; status is what the single-workout route would have answered
+type batchResult struct {
+	ClientRef string            `json:"client_ref,omitempty"`
+	Op        string            `json:"op"`
+	Status    int               `json:"status"`
+	Workout   *store.Workout    `json:"workout,omitempty"`
+	Warnings  []anomaly.Warning `json:"warnings,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// ! HandleBatchWorkouts --> POST /workouts/batch?confirm=, creates/updates/deletes in one transaction, a result per op
+// ? 200 whenever the batch itself was processed, failed ops are reported in their result; see service.Batch
+func (wh *WorkoutHandler) HandleBatchWorkouts(w http.ResponseWriter, req *http.Request) {
+	req.Body = http.MaxBytesReader(w, req.Body, maxBatchBytes)
+	var body batchRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.WriteJson(w, http.StatusRequestEntityTooLarge, utils.Envelope{"error": "batch must be at most 2MB"})
+			return
+		}
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "Invalid request payload"})
+		return
+	}
+	if len(body.Operations) == 0 {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "operations must not be empty"})
+		return
+	}
+	if len(body.Operations) > service.MaxBatchOps {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": fmt.Sprintf("a batch takes at most %d operations", service.MaxBatchOps)})
+		return
+	}
+
+	system := units.FromRequest(req)
+	ops := make([]service.BatchOp, len(body.Operations))
+	for i, item := range body.Operations {
+		op, err := batchOp(item, system)
+		if err != nil {
+			utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": fmt.Sprintf("operations[%d]: %v", i, err)})
+			return
+		}
+		ops[i] = op
+	}
+
+	currentUser := middleware.GetUser(req)
+	results, err := wh.workouts.Batch(req.Context(), currentUser.ID, ops, body.Atomic, req.URL.Query().Get("confirm") == "true")
+	if err != nil {
+		wh.writeServiceError(w, err)
+		return
+	}
+
+	out := make([]batchResult, len(results))
+	succeeded := 0
+	for i, result := range results {
+		out[i] = wh.batchResult(body.Operations[i], result, system)
+		if result.Err == nil {
+			succeeded++
+		}
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{
+		"results":   out,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"units":     system,
+	})
+}
+
+// * batchOp --> one item decoded the way its single-workout route decodes it, entries converted from the caller's units
+func batchOp(item batchRequestItem, system string) (service.BatchOp, error) {
+	op := service.BatchOp{Op: item.Op}
+	switch item.Op {
+	case store.WorkoutOpCreate:
+		op.Workout = &store.Workout{}
+		err := json.Unmarshal(item.Workout, op.Workout)
+		if err != nil || len(item.Workout) == 0 {
+			return op, errors.New("create needs a workout object")
+		}
+		op.Workout.Entries = units.Canonical(op.Workout.Entries, system)
+		return op, nil
+	case store.WorkoutOpUpdate, store.WorkoutOpDelete:
+		id, err := utils.ParseID(strings.Trim(string(item.ID), `"`))
+		if err != nil {
+			return op, fmt.Errorf("%s needs the workout id", item.Op)
+		}
+		op.ID = id
+		if item.Op == store.WorkoutOpDelete {
+			return op, nil
+		}
+		err = json.Unmarshal(item.Workout, &op.Patch)
+		if err != nil || len(item.Workout) == 0 {
+			return op, errors.New("update needs a workout object")
+		}
+		op.Patch.Entries = units.Canonical(op.Patch.Entries, system)
+		return op, nil
+	}
+	return op, fmt.Errorf("op must be %s, %s or %s", store.WorkoutOpCreate, store.WorkoutOpUpdate, store.WorkoutOpDelete)
+}
+
+// * batchResult --> status + message the way writeServiceError and the single-workout handlers word them
+func (wh *WorkoutHandler) batchResult(item batchRequestItem, result service.BatchResult, system string) batchResult {
+	out := batchResult{ClientRef: item.ClientRef, Op: item.Op, Warnings: result.Warnings}
+
+	var invalid *service.ValidationError
+	var anomalous *service.AnomalyError
+	switch err := result.Err; {
+	case err == nil:
+		out.Status = map[string]int{store.WorkoutOpCreate: http.StatusCreated, store.WorkoutOpUpdate: http.StatusOK, store.WorkoutOpDelete: http.StatusNoContent}[item.Op]
+		if result.Workout != nil {
+			out.Workout = units.Workout(result.Workout, system)
+		}
+	case errors.Is(err, service.ErrBatchAborted):
+		out.Status, out.Error = http.StatusFailedDependency, "not saved, another operation of the atomic batch failed"
+	case errors.As(err, &invalid):
+		out.Status, out.Error = http.StatusBadRequest, invalid.Message
+	case errors.As(err, &anomalous):
+		out.Status, out.Error = http.StatusUnprocessableEntity, "workout contains implausible values, resend with ?confirm=true to save it anyway"
+	case errors.Is(err, service.ErrNotFound):
+		out.Status, out.Error = http.StatusNotFound, "workout does not exists"
+	case errors.Is(err, service.ErrForbidden):
+		out.Status, out.Error = http.StatusForbidden, "you are not authorized to change this workout"
+	case errors.Is(err, service.ErrUnavailable):
+		out.Status, out.Error = http.StatusNotImplemented, "workout tags are not available on this server"
+	case storeErrorStatus(err) != 0:
+		out.Status = storeErrorStatus(err)
+		out.Error = storeErrorMessages[out.Status]
+	default:
+		wh.logger.Printf("Error : batch %s : %v ", item.Op, err)
+		out.Status, out.Error = http.StatusInternalServerError, "Internal server error"
+	}
+	return out
+}
@@ -1,11 +1,13 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fem/internal/middleware"
+	"fem/internal/patch"
+	"fem/internal/service"
 	"fem/internal/store"
+	"fem/internal/units"
 	"fem/internal/utils"
 	"log"
 	"net/http"
@@ -14,49 +16,214 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+//! ?limit= default + cap for GET /workouts
+const (
+	defaultWorkoutListLimit = 20
+	maxWorkoutListLimit = 100
+)
+
 // types declaration
 type WorkoutHandler struct {
-	workstore store.WorkoutStore //* interface --> allows swapping db implementations without changing handler logic
+	commentStore store.CommentStore //* comment + reaction counts shown with a workout
+	workouts service.Workouts //* interface --> visibility, ownership, validation + patch rules shared with the gRPC server
+	photos *service.PhotoService //* nil when photos aren't available, responses just skip them
 	logger *log.Logger //* for logging errors and important events
 
 }
 
 // ? - constructor function that returns instance of WorkoutHandler with initialized fields
-func NewWorkoutHandler(workoutStore store.WorkoutStore,logger *log.Logger) *WorkoutHandler {
+func NewWorkoutHandler(commentStore store.CommentStore,workoutService service.Workouts,photoService *service.PhotoService,logger *log.Logger) *WorkoutHandler {
 return &WorkoutHandler{
-	workstore: workoutStore,
+	commentStore: commentStore,
+	workouts: workoutService,
+	photos: photoService,
 	logger: logger,
 }
 }
 
+//! writeServiceError --> validation + anomaly errors from the workout service, anything else is a 500
+//? not found / forbidden are mapped by each handler, their messages differ per route
+func (wh *WorkoutHandler) writeServiceError(w http.ResponseWriter, err error) {
+	var invalid *service.ValidationError
+	var anomalous *service.AnomalyError
+	switch {
+	case errors.As(err,&invalid):
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : invalid.Message})
+	case errors.Is(err,service.ErrUnavailable):
+		utils.WriteJson(w,http.StatusNotImplemented,utils.Envelope{"error" : "workout tags are not available on this server"})
+	case errors.As(err,&anomalous):
+		//? clients confirm by resending the same request with ?confirm=true
+		utils.WriteJson(w,http.StatusUnprocessableEntity,utils.Envelope{
+			"error" : "workout contains implausible values, resend with ?confirm=true to save it anyway",
+			"warnings" : anomalous.Warnings,
+		})
+	case storeErrorStatus(err) != 0:
+		writeStoreError(w,wh.logger,"workout service",err) //* e.g. deleted while the update was in flight
+	default:
+		wh.logger.Printf("Error : workout service : %v ",err)
+		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "Internal server error"})
+	}
+}
+
+//! readFields --> ?fields=title,duration_minutes (sparse fieldset) for workout responses, false when the 400 is written
+//? read before any work is done, a typo in the list must not create or update anything
+func readFields(w http.ResponseWriter, req *http.Request) (utils.Fields,bool) {
+	fields,err := utils.ParseFields(req,store.Workout{})
+	if err != nil {
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : err.Error()})
+		return nil,false
+	}
+	return fields,true
+}
+
+//! selectFields --> a workout or a list of them cut down to fields, false when the 500 is written
+func (wh *WorkoutHandler) selectFields(w http.ResponseWriter, fields utils.Fields, v any) (any,bool) {
+	shaped,err := fields.Select(v)
+	if err != nil {
+		wh.logger.Printf("Error : selectFields : %v ",err)
+		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "Internal server error"})
+		return nil,false
+	}
+	return shaped,true
+}
+
+//! requireWorkoutOwner --> reads {id}, checks the workout exists and belongs to the current user
+//? shared by every /workouts/{id}/... sub-resource, writes the error response itself
+func requireWorkoutOwner(workstore store.WorkoutStore, logger *log.Logger, w http.ResponseWriter, req *http.Request) (int64, bool) {
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "Invalid workout id"})
+		return 0, false
+	}
+
+	err = service.CheckWorkoutOwner(workstore, workoutID, middleware.GetUser(req).ID)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "workout does not exists"})
+		return 0, false
+	case errors.Is(err, service.ErrForbidden):
+		utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "you are not authorized to access this workout"})
+		return 0, false
+	case err != nil:
+		logger.Printf("Error : getWorkoutOwner : %v ", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return 0, false
+	}
+	return workoutID, true
+}
+
+//! requireWorkoutViewer --> reads {id} and loads the workout if the current user may see it
+//? hidden workouts answer 404 like missing ones, so private workouts can't be probed
+func requireWorkoutViewer(workstore store.WorkoutStore, followStore store.FollowStore, logger *log.Logger, w http.ResponseWriter, req *http.Request) (*store.Workout, bool) {
+	return viewWorkout(logger, w, req, func(workoutID int64, viewerID int) (*store.Workout, error) {
+		return service.VisibleWorkout(workstore, followStore, workoutID, viewerID)
+	})
+}
+
+//! viewWorkout --> requireWorkoutViewer with the loader passed in, load must answer ErrNotFound for hidden workouts
+func viewWorkout(logger *log.Logger, w http.ResponseWriter, req *http.Request, load func(workoutID int64, viewerID int) (*store.Workout, error)) (*store.Workout, bool) {
+	workoutID, err := utils.ReadIDParam(req)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "Invalid workout id"})
+		return nil, false
+	}
+
+	workout, err := load(workoutID, middleware.GetUser(req).ID)
+	if errors.Is(err, service.ErrNotFound) {
+		utils.WriteJson(w, http.StatusNotFound, utils.Envelope{"error": "workout does not exists"})
+		return nil, false
+	}
+	if err != nil {
+		logger.Printf("Error : getWorkoutByID : %v ", err)
+		utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+		return nil, false
+	}
+	return workout, true
+}
+
 //! methods --> have base method WorkoutHandler ( points to type which persists changes across app) --> other called via base this one	
 //! GET /workouts/{id} --> fetches single workout by its ID
 func (wh *WorkoutHandler) HandleWorkoutByID(w http.ResponseWriter, req *http.Request) {
 // extracting id from url via chi
 
-//* reading workout ID from URL path parameter
-workoutID,err := utils.ReadIDParam(req)
-if err != nil {
-	wh.logger.Printf("Error : readIdParam : %v ",err)
-	utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : "Invalid workout id"})
+fields,ok := readFields(w,req)
+if !ok {
 	return
 }
 
-workout,err := wh.workstore.GetWorkoutByID(workoutID)
-if err != nil {
-	// ? - db error fetching workout
-	wh.logger.Printf("Error : getWorkoutByID : %v ",err)
-	utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "Internal Server Error"})
+//! same viewer check as the comment + reaction routes, hidden workouts look exactly like missing ones
+//? the service loads it so tags come along, counts + photos below are only read for a workout the caller may see
+workout,ok := viewWorkout(wh.logger,w,req,func(workoutID int64,viewerID int) (*store.Workout,error) {
+	return wh.workouts.Get(req.Context(),viewerID,workoutID)
+})
+if !ok {
+	return
+}
+workoutID := int64(workout.ID)
+//* entries come back in the caller's unit system (?units= or their profile), stored as kg + meters
+system := units.FromRequest(req)
+//* social counts + photos are extras --> a failure here shouldn't hide the workout itself
+shaped,ok := wh.selectFields(w,fields,units.Workout(workout,system))
+if !ok {
 	return
 }
+envelope := utils.Envelope{"workout":shaped,"units":system}
+counts,err := wh.commentStore.GetCounts(workoutID)
+if err != nil {
+	wh.logger.Printf("Error : getCounts : %v ",err)
+} else {
+	envelope["counts"] = counts
+}
+if wh.photos != nil {
+	photos,err := wh.photos.List(req.Context(),workoutID)
+	if err != nil {
+		wh.logger.Printf("Error : listPhotos : %v ",err)
+	} else {
+		envelope["photos"] = photos //* URLs are signed, they expire after LinkTTL
+	}
+}
+
 // * sending json response with helper function
-utils.WriteJson(w,http.StatusOK,utils.Envelope{"workout":workout})
+utils.WriteJson(w,http.StatusOK,envelope)
 }
 
 
+//! GET /workouts?tag=legs&offset=&limit= --> the caller's own workouts, newest performed first, entries left out
+func (wh *WorkoutHandler) HandleListWorkouts(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	offset,err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit,err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultWorkoutListLimit
+	}
+	limit = min(limit,maxWorkoutListLimit)
+	fields,ok := readFields(w,req)
+	if !ok {
+		return
+	}
+
+	workouts,err := wh.workouts.List(req.Context(),middleware.GetUser(req).ID,query.Get("tag"),limit,offset)
+	if err != nil {
+		wh.writeServiceError(w,err)
+		return
+	}
+	shaped,ok := wh.selectFields(w,fields,workouts)
+	if !ok {
+		return
+	}
+	utils.WriteJson(w,http.StatusOK,utils.Envelope{"workouts" : shaped,"offset" : offset,"limit" : limit}.Paginate(req,offset,limit,len(workouts),nil))
+}
+
 // ! CreateWorkout Method
 //! POST /workouts --> creates new workout for authenticated user
 func (wh *WorkoutHandler) HandleCreateWorkout (w http.ResponseWriter, req *http.Request) {
+fields,ok := readFields(w,req)
+if !ok {
+	return
+}
 var workout  store.Workout //* follows type def of this struct
 //* decode incoming JSON body into workout struct
 err := json.NewDecoder(req.Body).Decode(&workout)
@@ -66,6 +233,9 @@ wh.logger.Printf("Error : decodingCreateWorkout : %v ",err)
 utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : "Invalid request sent"})
 	return
 }
+//* weights + distances arrive in the caller's units, everything below works in kg + meters
+system := units.FromRequest(req)
+workout.Entries = units.Canonical(workout.Entries,system)
 
 //! Current live user with get user which is fetched from context using getUser method
 currentUser := middleware.GetUser(req)
@@ -74,17 +244,17 @@ if currentUser == nil || currentUser == store.AnonymousUser {
 	return
 }
 
-//* assigning authenticated user's ID to workout --> links workout ownership
-workout.UserID = currentUser.ID
-
-createWorkout,err := wh.workstore.CreateWorkout(&workout)
+createWorkout,warnings,err := wh.workouts.Create(req.Context(),currentUser.ID,&workout,req.URL.Query().Get("confirm") == "true")
 if err !=nil {
-	wh.logger.Printf("Error : createWorkout : %v ",err)
-	utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "failed to create workout"})
+	wh.writeServiceError(w,err)
 	return
 }
 
-utils.WriteJson(w,http.StatusCreated,utils.Envelope{"workout" : createWorkout})
+shaped,ok := wh.selectFields(w,fields,units.Workout(createWorkout,system))
+if !ok {
+	return
+}
+utils.WriteJson(w,http.StatusCreated,utils.Envelope{"workout" : shaped,"warnings" : warnings,"units" : system})
 }
 
 // ! UpdateWorkout Method
@@ -97,29 +267,15 @@ workoutID,err := utils.ReadIDParam(req)
 if err!= nil {
 	wh.logger.Printf("Error : readIdParam : %v ",err)
 	utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : "Invalid workout update id"})
-}
-existingWorkout,err := wh.workstore.GetWorkoutByID(workoutID)
-if err != nil {
-	// ? - db error while fetching workout
-	wh.logger.Printf("Error : getWorkoutByID : %v ",err)
-	utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "Internal server error"})
 	return
 }
-if existingWorkout == nil {
-	http.NotFound(w,req)
+fields,ok := readFields(w,req)
+if !ok {
 	return
 }
 
-// found existing workout
-
-//* using pointers (*string, *int) --> allows partial updates (nil = no change, value = update)
-var updateWorkoutRequest struct {
-		Title           *string              `json:"title"`
-		Description     *string              `json:"description"`
-		DurationMinutes *int                 `json:"duration_minutes"`
-		CaloriesBurned  *int                 `json:"calories_burned"`
-		Entries         []store.WorkoutEntry `json:"entries"`
-	}
+	//* using pointers (*string, *int) --> allows partial updates (nil = no change, value = update)
+	var updateWorkoutRequest service.WorkoutPatch
 	err = json.NewDecoder(req.Body).Decode(&updateWorkoutRequest) // this body refrences to instance of the struct which persists changes
 
 	if err != nil {
@@ -128,24 +284,8 @@ var updateWorkoutRequest struct {
 	    utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : "Invalid request payload"})
 		return
 	}
-
-	//* only update fields that were provided in request --> allows partial updates
-	if updateWorkoutRequest.Title != nil {
-		existingWorkout.Title = *updateWorkoutRequest.Title
-	}
-
-	if updateWorkoutRequest.Description != nil {
-		existingWorkout.Description = *updateWorkoutRequest.Description
-	}
-	if updateWorkoutRequest.DurationMinutes != nil {
-		existingWorkout.DurationMinutes = *updateWorkoutRequest.DurationMinutes
-	}
-	if updateWorkoutRequest.CaloriesBurned != nil {
-		existingWorkout.CaloriesBurned = *updateWorkoutRequest.CaloriesBurned
-	}
-	if updateWorkoutRequest.Entries != nil {
-		existingWorkout.Entries = updateWorkoutRequest.Entries
-	}
+	system := units.FromRequest(req)
+	updateWorkoutRequest.Entries = units.Canonical(updateWorkoutRequest.Entries,system)
 
 	//  Current live user with get user which is fetched from context using getUser method
 	currentUser := middleware.GetUser(req)
@@ -154,36 +294,71 @@ var updateWorkoutRequest struct {
 	return
 	}
 
-	//* fetch who owns this workout from db
-	workoutOwner,err := wh.workstore.GetWorkoutOwner(workoutID)
+	wh.saveUpdate(w,req,currentUser.ID,workoutID,fields,updateWorkoutRequest,system)
+}
+
+//! PATCH /workouts/{id} --> RFC 7386 merge patch or RFC 6902 JSON Patch against the workout as GET returns it
+//? the patch runs on the editable fields in the caller's units, what it changed goes through the same update as PUT
+func (wh *WorkoutHandler) HandlePatchWorkoutByID(w http.ResponseWriter,req *http.Request) {
+	workoutID,err := utils.ReadIDParam(req)
 	if err != nil {
-		if errors.Is(err,sql.ErrNoRows) {
-			utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : "workout does not exists"})
-			return	
-		}
-		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "internal server error"})
+		utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : "Invalid workout update id"})
+		return
+	}
+	fields,ok := readFields(w,req)
+	if !ok {
 		return
 	}
+	currentUser := middleware.GetUser(req)
 
-	//! Authorization check: current user who is live on but isn't this workout owner ... trying to alternate someone's workout
-	if workoutOwner != currentUser.ID {
+	current,err := wh.workouts.Get(req.Context(),currentUser.ID,workoutID)
+	if errors.Is(err,service.ErrNotFound) {
+		utils.WriteJson(w,http.StatusNotFound,utils.Envelope{"error" : "workout does not exists"})
+		return
+	}
+	if err != nil {
+		wh.writeServiceError(w,err)
+		return
+	}
+	if current.UserID != currentUser.ID {
 		utils.WriteJson(w,http.StatusForbidden,utils.Envelope{"error" : "you are not authorized to update this workout"})
 		return
 	}
 
-	// ! make sure ID is set for the update
-	existingWorkout.ID = int(workoutID)
-	
-	err = wh.workstore.UpdateWorkout(existingWorkout)
-	if err !=nil {
-		// ? - db error while updating
-		wh.logger.Printf("Error : updateWorkout : %v ",err)
-		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "Internal server error"})
+	system := units.FromRequest(req)
+	var changes service.WorkoutPatch
+	err = patch.Changes(req.Header.Get("Content-Type"),req.Body,service.PatchOf(units.Workout(current,system)),&changes)
+	if !patchApplied(w,err) {
+		return
+	}
+	changes.Entries = units.Canonical(changes.Entries,system)
+
+	wh.saveUpdate(w,req,currentUser.ID,workoutID,fields,changes,system)
+}
+
+//* saveUpdate --> the part PUT + PATCH share once they know what changes, writes the response
+func (wh *WorkoutHandler) saveUpdate(w http.ResponseWriter,req *http.Request,userID int,workoutID int64,fields utils.Fields,changes service.WorkoutPatch,system string) {
+	//! the service checks ownership --> someone else's workout can't be altered
+	existingWorkout,warnings,err := wh.workouts.Update(req.Context(),userID,workoutID,changes,req.URL.Query().Get("confirm") == "true")
+	if errors.Is(err,service.ErrNotFound) {
+		utils.WriteJson(w,http.StatusNotFound,utils.Envelope{"error" : "workout does not exists"})
+		return
+	}
+	if errors.Is(err,service.ErrForbidden) {
+		utils.WriteJson(w,http.StatusForbidden,utils.Envelope{"error" : "you are not authorized to update this workout"})
+		return
+	}
+	if err != nil {
+		wh.writeServiceError(w,err)
 		return
 	}
 
 	// * sending response
-	utils.WriteJson(w,http.StatusOK,utils.Envelope{"workout":existingWorkout})
+	shaped,ok := wh.selectFields(w,fields,units.Workout(existingWorkout,system))
+	if !ok {
+		return
+	}
+	utils.WriteJson(w,http.StatusOK,utils.Envelope{"workout":shaped,"warnings":warnings,"units":system})
 }
 
 //! DELETE /workouts/{id} --> deletes workout (only if user owns it)
@@ -197,8 +372,8 @@ func (wh *WorkoutHandler) HandleDeleteWorkoutByID(w http.ResponseWriter, req *ht
 		return
 	}
 
-	//* convert string ID to int64 for db query
-	workoutID,err := strconv.ParseInt(paramsWorkoutID,10,64)
+	//* convert string ID to int64 for db query (decodes obfuscated ids too)
+	workoutID,err := utils.ParseID(paramsWorkoutID)
 	if err != nil {
 		http.NotFound(w,req)
 		return
@@ -211,35 +386,21 @@ func (wh *WorkoutHandler) HandleDeleteWorkoutByID(w http.ResponseWriter, req *ht
 	return
 	}
 
-	//* verify workout exists and get its owner
-	workoutOwner,err := wh.workstore.GetWorkoutOwner(workoutID)
-	if err != nil {
-		if errors.Is(err,sql.ErrNoRows) {
-			utils.WriteJson(w,http.StatusBadRequest,utils.Envelope{"error" : "workout does not exists"})
-			return	
-		}
-		utils.WriteJson(w,http.StatusInternalServerError,utils.Envelope{"error" : "internal server error"})
+	//! the service checks the workout exists and belongs to the current user
+	err = wh.workouts.Delete(req.Context(),currentUser.ID,workoutID)
+	if errors.Is(err,service.ErrNotFound) {
+		utils.WriteJson(w,http.StatusNotFound,utils.Envelope{"error" : "workout does not exists"})
 		return
 	}
-
-	//! Authorization check: if current user is not owner of that workout the client is trying to modify it
-	if workoutOwner != currentUser.ID {
+	if errors.Is(err,service.ErrForbidden) {
 		utils.WriteJson(w,http.StatusForbidden,utils.Envelope{"error" : "you are not authorized to delete this workout"})
 		return
 	}
-
-
-
-//* perform delete operation in database
-err = wh.workstore.DeleteWorkout(workoutID)
-if err == sql.ErrNoRows {
-http.Error(w,"Workout not found",http.StatusNotFound)
-return
-}  
-if err != nil {
-http.Error(w,"error deleting the workout", http.StatusInternalServerError)
-return
-}  
+	if err != nil {
+		wh.logger.Printf("Error : deleteWorkout : %v ",err)
+		http.Error(w,"error deleting the workout", http.StatusInternalServerError)
+		return
+	}
 
 //* 204 No Content --> successful deletion, no response body needed
 w.WriteHeader(http.StatusNoContent)
@@ -248,7 +409,7 @@ w.WriteHeader(http.StatusNoContent)
 // ! WORKFLOW BREAKDOWN:
 // 1. WorkoutStore interface --> defines what methods our store needs (contract/blueprint)
 // 2. PostgresWorkoutStore --> actual implementation with real SQL queries
-// 3. WorkoutHandler holds workstore (the interface) --> can swap db types easily
-// 4. Handler methods (like HandleCreateWorkout) --> call wh.workstore.CreateWorkout() 
+// 3. WorkoutService --> business rules (visibility, ownership, validation, patches) on top of the store
+// 4. WorkoutHandler holds service.Workouts (the interface) --> HandleCreateWorkout calls wh.workouts.Create()
 // 5. Routes hook these handlers to URLs --> /workouts maps to HandleCreateWorkout
-// ? - interface lets us swap PostgreSQL for MySQL/MongoDB without touching handlers! 
\ No newline at end of file
+// ? - interfaces let us swap PostgreSQL for MySQL/MongoDB (or the rules for a fake) without touching handlers! 
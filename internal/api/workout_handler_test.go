@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/anomaly"
+	"fem/internal/hooks"
+	"fem/internal/memstore"
+	"fem/internal/middleware"
+	"fem/internal/service"
+	"fem/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * getWorkout --> GET /workouts/{id} as viewer, straight into the handler
+func getWorkout(h *WorkoutHandler, viewer *store.User, id int) *httptest.ResponseRecorder {
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", strconv.Itoa(id))
+	req := httptest.NewRequest(http.MethodGet, "/workouts/"+strconv.Itoa(id), nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+	req = middleware.SetUser(req, viewer)
+	rec := httptest.NewRecorder()
+	h.HandleWorkoutByID(rec, req)
+	return rec
+}
+
+// ! TestHandleWorkoutByIDVisibility --> private and followers-only workouts look missing to anyone who may not see them
+func TestHandleWorkoutByIDVisibility(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	follows := memstore.NewFollowStore(db)
+	workouts := service.NewWorkoutService(memstore.NewWorkoutStore(db), memstore.NewProfileStore(db), follows,
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+	h := NewWorkoutHandler(memstore.NewCommentStore(db), workouts, nil, logger)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	ids := map[string]int{}
+	for _, visibility := range []string{store.VisibilityPrivate, store.VisibilityFollowers, store.VisibilityPublic} {
+		workout, _, err := workouts.Create(context.Background(), ana.ID, &store.Workout{Title: visibility, DurationMinutes: 30, Visibility: visibility}, true)
+		require.NoError(t, err)
+		ids[visibility] = workout.ID
+	}
+
+	for visibility, id := range ids {
+		assert.Equal(t, http.StatusOK, getWorkout(h, ana, id).Code, "owner sees %s", visibility)
+	}
+	assert.Equal(t, http.StatusNotFound, getWorkout(h, ben, ids[store.VisibilityPrivate]).Code)
+	assert.Equal(t, http.StatusNotFound, getWorkout(h, ben, ids[store.VisibilityFollowers]).Code)
+	assert.Equal(t, http.StatusOK, getWorkout(h, ben, ids[store.VisibilityPublic]).Code)
+
+	require.NoError(t, follows.Follow(int64(ben.ID), int64(ana.ID)))
+	assert.Equal(t, http.StatusOK, getWorkout(h, ben, ids[store.VisibilityFollowers]).Code, "followers see it once they follow")
+	assert.Equal(t, http.StatusNotFound, getWorkout(h, ben, ids[store.VisibilityPrivate]).Code, "private stays private")
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(getWorkout(h, ben, ids[store.VisibilityPrivate]).Body.Bytes(), &body))
+	assert.NotContains(t, body, "workout")
+	assert.NotContains(t, body, "counts", "no comment or reaction counts for a hidden workout")
+
+	require.NoError(t, json.Unmarshal(getWorkout(h, ben, ids[store.VisibilityPublic]).Body.Bytes(), &body))
+	assert.Contains(t, body, "counts")
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fem/internal/importer"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	maxImportBytes  = 10 << 20 //* upload size cap, multipart overhead included
+	importBatchSize = 100      //* workouts per transaction
+)
+
+//! importResult --> one line of the import report
+type importResult struct {
+	Row       int    `json:"row"` //* JSON array position or CSV line number
+	Status    string `json:"status"`
+	WorkoutID int    `json:"workout_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+//! importFormat --> explicit ?format= / form field first, then the file extension
+func importFormat(req *http.Request, filename string) string {
+	format := strings.ToLower(req.FormValue("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+	return format
+}
+
+//! HandleImportWorkouts --> POST /workouts/import, multipart "file" field holding CSV or JSON
+//? every row is validated and reported on its own, one bad line doesn't sink the rest
+//? imported history doesn't publish workout events (see WorkoutService.Import)
+func (wh *WorkoutHandler) HandleImportWorkouts(w http.ResponseWriter, req *http.Request) {
+	req.Body = http.MaxBytesReader(w, req.Body, maxImportBytes)
+	err := req.ParseMultipartForm(maxImportBytes)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.WriteJson(w, http.StatusRequestEntityTooLarge, utils.Envelope{"error": "file must be at most 10MB"})
+			return
+		}
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "expected a multipart/form-data upload"})
+		return
+	}
+	defer req.MultipartForm.RemoveAll()
+
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	format := importFormat(req, header.Filename)
+	if format != importer.FormatCSV && format != importer.FormatJSON {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": "format must be csv or json"})
+		return
+	}
+	rows, err := importer.Parse(format, file)
+	if err != nil {
+		utils.WriteJson(w, http.StatusBadRequest, utils.Envelope{"error": err.Error()})
+		return
+	}
+
+	currentUser := middleware.GetUser(req)
+
+	results := make([]importResult, len(rows))
+	pending := []int{} //* indexes of rows that passed validation
+	for i, row := range rows {
+		results[i] = importResult{Row: row.Line, Status: "failed"}
+		if row.Err != nil {
+			results[i].Error = row.Err.Error()
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	imported := 0
+	for start := 0; start < len(pending); start += importBatchSize {
+		batch := pending[start:min(start+importBatchSize, len(pending))]
+		workouts := make([]*store.Workout, len(batch))
+		for j, i := range batch {
+			workouts[j] = rows[i].Workout
+		}
+
+		rowErrs, err := wh.workouts.Import(req.Context(), currentUser.ID, workouts)
+		if err != nil {
+			wh.logger.Printf("ERROR: importWorkouts: %v", err)
+		}
+		for j, i := range batch {
+			switch {
+			case err != nil || rowErrs[j] != nil:
+				if rowErrs != nil {
+					wh.logger.Printf("ERROR: importWorkouts row %d: %v", rows[i].Line, rowErrs[j])
+				}
+				results[i].Error = "workout could not be saved"
+			default:
+				results[i].Status = "imported"
+				results[i].WorkoutID = workouts[j].ID
+				imported++
+			}
+		}
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{
+		"imported": imported,
+		"failed":   len(rows) - imported,
+		"results":  results,
+	})
+}
@@ -3,72 +3,201 @@ package app
 import (
 	"database/sql"
 	"fem/internal/api"
+	"fem/internal/audit"
+	"fem/internal/events"
+	"fem/internal/cache"
+	"fem/internal/cors"
+	"fem/internal/clientusage"
+	"fem/internal/hooks"
+	"fem/internal/lifecycle"
+	"fem/internal/memstore"
+	"fem/internal/metrics"
+	"fem/internal/logging"
 	"fem/internal/middleware"
+	"fem/internal/pipeline"
+	"fem/internal/publicapi"
+	"fem/internal/schedule"
+	"fem/internal/spa"
 	"fem/internal/store"
-	"fem/migrations"
+	"fem/internal/warehouse"
+	"fem/internal/worker"
+	"fem/web"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+//! pipeline stage names --> extension points for Before/After hooks
+const (
+	StageClientIP = "client_ip" //* root: real client IP, forwarded headers only from TRUSTED_PROXIES
+	StageRequestID = "request_id" //* root: X-Request-ID in + out, links + meta on JSON responses
+	StageAccessLog = "access_log" //* root: one line per request, sampled by ACCESS_LOG_SAMPLE
+	StagePrettyJSON = "pretty_json" //* root: ?pretty=1 indents the JSON response
+	StageNegotiate = "negotiate" //* root: Accept: application/xml or application/msgpack re-encodes WriteJson responses
+	StageAudit = "audit" //* root: caller IP + user agent for audit log entries
+	StageCORS = "cors" //* root: CORS headers + preflight answers for CORS_ALLOWED_ORIGINS
+	StageMaintenance = "maintenance" //* root: 503 + Retry-After while maintenance mode is on
+	StageClientUsage = "client_usage" //* root: per-client request counts
+	StageHooks = "hooks" //* root: BeforeResponse plugin hooks
+	StageClientVersion = "client_version" //* root: 426 for outdated app builds
+	StageShadow = "shadow" //* root: mirrors sampled GETs to SHADOW_BASE_URL, only present when configured
+	StageAuthenticate = "authenticate" //* user routes: bearer token --> user in context
+	StageUserUsage = "user_usage" //* user routes: per-user request counts for GET /users/me/usage
+	StageAccessLogUser = "access_log_user" //* user routes: the authenticated user's ID for the access log line
+	StageUnits = "units" //* user routes: metric/imperial preference for workout entries, read lazily
 )
 
+//! legacyDeprecatedAt --> when /v1 shipped and the unprefixed routes became deprecated
+var legacyDeprecatedAt = time.Date(2026,10,15,0,0,0,0,time.UTC)
+
 //! types declarement
 //! Application struct --> holds all dependencies needed across the app
 type Application struct {
 	Logger *log.Logger //* centralized logger for error tracking
+	LogLevel *logging.LevelWriter //* the level Logger filters at, switched by Reload
+	CORS *cors.Policy //* allowed browser origins, switched by Reload
 	WorkoutHandler *api.WorkoutHandler //* handles workout CRUD operations
 	UserHandler *api.UserHandler //* handles user registration
+	SandboxHandler *api.SandboxHandler //* throwaway demo accounts, nil unless SANDBOX_ENABLED=true
+	UsageHandler *api.UsageHandler //* handles the caller's own usage report
+	ProfileHandler *api.ProfileHandler //* handles /users/me profile + weight history
 	TokenHandler *api.TokenHandler //* handles authentication token creation
+	OrgHandler *api.OrgHandler //* handles org creation and SCIM token rotation
+	SCIMHandler *api.SCIMHandler //* handles SCIM user provisioning for orgs
+	ExportHandler *api.ExportHandler //* handles org exports + signed downloads
+	ShareHandler *api.ShareHandler //* handles public read-only workout links
+	FollowHandler *api.FollowHandler //* handles follows + activity feed
+	CommentHandler *api.CommentHandler //* handles workout comments + reactions
+	GoalHandler *api.GoalHandler //* handles goals + progress tracking
+	VerificationHandler *api.VerificationHandler //* handles leaderboard verification evidence
+	AchievementHandler *api.AchievementHandler //* handles achievements + badge images
+	LeaderboardHandler *api.LeaderboardHandler //* handles leaderboards
+	ScheduleHandler *api.ScheduleHandler //* handles recurring workout schedules
+	SeasonalEventHandler *api.SeasonalEventHandler //* handles seasonal events + standings
+	ChallengeHandler *api.ChallengeHandler //* handles user challenges, progress + results
+	ProgramHandler *api.ProgramHandler //* handles multi-week programs + enrollments
+	ExperimentHandler *api.ExperimentHandler //* handles A/B test assignments
+	ClientConfigHandler *api.ClientConfigHandler //* handles mobile client config
+	ClientUsageHandler *api.ClientUsageHandler //* handles per-client API usage reports
+	IntegrationHandler *api.IntegrationHandler //* handles third-party connections (Strava)
+	WebhookHandler *api.WebhookHandler //* handles user webhooks + delivery log
+	AutomationHandler *api.AutomationHandler //* handles per-user automation rules
+	ReminderHandler *api.ReminderHandler //* handles workout reminder rules + snooze
+	NotificationHandler *api.NotificationHandler //* handles push devices, notification preferences + the inbox
+	TwoFactorHandler *api.TwoFactorHandler //* handles TOTP setup, confirmation + disabling
+	OAuthHandler *api.OAuthHandler //* handles Google/GitHub sign-in + linked accounts
+	SessionHandler *api.SessionHandler //* handles session listing + remote logout
+	TagHandler *api.TagHandler //* handles workout tags + tag autocomplete
+	SyncHandler *api.SyncHandler //* handles incremental sync for offline clients
+	PhotoHandler *api.PhotoHandler //* handles workout photos + blob downloads
+	AvatarHandler *api.AvatarHandler //* handles profile avatars
+	TrainingLoadHandler *api.TrainingLoadHandler //* handles the acute:chronic workload report
+	Audit *audit.Recorder //* security audit log, AdminActions wraps the admin API
+	PublicAPI *publicapi.Gate //* anonymous reads on routes listed in PUBLIC_API_FILE, rate limited per IP
+	EventStreamHandler *api.EventStreamHandler //* handles the real-time SSE stream
+	CacheHandler *api.CacheHandler //* token cache statistics
+	DualWriteHandler *api.DualWriteHandler //* flips dual-write flags, reports secondary divergences
+	MaintenanceHandler *api.MaintenanceHandler //* switches maintenance mode
+	AdminHandler *api.AdminHandler //* handles admin user lookup, session revocation, flags + job status
+	LiveHandler *api.LiveHandler //* handles live workout sessions over websockets
+	GraphQLHandler http.Handler //* POST /graphql, batched reads over the same stores
+	SPA *spa.Handler //* web frontend for unmatched routes, nil unless WEB_UI is set
+	GRPCServer *grpc.Server //* typed API for internal services, served on its own port (see main.go)
 	Middleware middleware.UserMiddleware //* authentication middleware for protected routes
+	SCIMMiddleware middleware.SCIMMiddleware //* authenticates identity providers on SCIM routes
+	ClientVersionMiddleware middleware.ClientVersionMiddleware //* rejects app builds below min_version
+	ClientUsageMiddleware middleware.ClientUsageMiddleware //* counts requests per client build + route
+	VersionMiddleware middleware.VersionMiddleware //* /v1 negotiation + deprecation headers on unprefixed routes
+	Pipeline *pipeline.Pipeline //* middleware for every route, in order
+	UserPipeline *pipeline.Pipeline //* middleware for the user-authenticated route group, in order
+	WarehouseSyncer *warehouse.Syncer //* optional ClickHouse sync, nil when not configured
+	ScheduleMaterializer *schedule.Materializer //* generates upcoming schedule occurrences in the background
+	ClientUsageRecorder *clientusage.Recorder //* flushes per-client request counts in the background
+	Worker *worker.Pool //* background job runner (token cleanup, email, feed fan-out)
+	Events *events.Bus //* in-process domain events
+	EventHub *events.Hub //* per-user live connections (SSE), fed from Events
+	Hooks *hooks.Registry //* synchronous plugin hooks, register before SetupRoutes
+	DevMode bool //* APP_ENV=development, opens up contributor tooling like /debug/routes
 	DB *sql.DB //* database connection pool
+	Lifecycle *lifecycle.Lifecycle //* readiness checks + drain state for rolling deploys
+	DBMonitor *store.DBMonitor //* background ping, /health answers 503 while the database is unreachable
+	ReadRouter *store.ReadRouter //* replica read routing, nil without READ_REPLICA_DATABASE_URL
+	DBPool store.PoolConfig //* limits applied to DB, shown on /debug/db
+	DBDriver string //* postgres | sqlite | memory, sqlite runs without the postgres-only background loops
+	MemDB *memstore.DB //* backs every store when DBDriver is memory, nil otherwise
+	Metrics *metrics.Registry //* GET /metrics, db pool + token cache gauges
+
+	components []Component //* started + stopped by Start / Stop
 }
 
-//! NewApplication --> constructor that initializes entire app with all dependencies
+//! NewApplication --> the default wiring, everything from env vars (see Builder for overrides)
 func NewApplication() (*Application,error) {
+	return NewBuilder().Build()
+}
 
-	//* establishing database connection
-	pgDb,err := store.Open()
-	if err != nil {
-		return nil,err
+//! registerMetrics --> what GET /metrics exports, all read at scrape time
+func registerMetrics(reg *metrics.Registry,db *sql.DB,dbMonitor *store.DBMonitor,readRouter *store.ReadRouter,tokenCache *cache.UserStore) {
+	pool := func(read func(store.PoolStats) float64) func() float64 {
+		return func() float64 { return read(store.GetPoolStats(db)) }
 	}
+	reg.Gauge("db_up","1 when the latest database ping succeeded.",func() float64 {
+		if dbMonitor.Up() {
+			return 1
+		}
+		return 0
+	})
+	reg.Gauge("db_max_open_connections","Configured maximum of open connections (0 = unlimited).",pool(func(s store.PoolStats) float64 { return float64(s.MaxOpenConnections) }))
+	reg.Gauge("db_open_connections","Open connections, in use + idle.",pool(func(s store.PoolStats) float64 { return float64(s.OpenConnections) }))
+	reg.Gauge("db_in_use_connections","Connections currently running a query.",pool(func(s store.PoolStats) float64 { return float64(s.InUse) }))
+	reg.Gauge("db_idle_connections","Idle connections kept in the pool.",pool(func(s store.PoolStats) float64 { return float64(s.Idle) }))
+	reg.Counter("db_wait_count_total","Queries that had to wait for a free connection.",pool(func(s store.PoolStats) float64 { return float64(s.WaitCount) }))
+	reg.Counter("db_wait_seconds_total","Time spent waiting for a free connection.",pool(func(s store.PoolStats) float64 { return s.WaitDuration.Seconds() }))
+	reg.Counter("db_max_idle_closed_total","Connections closed because the idle pool was full.",pool(func(s store.PoolStats) float64 { return float64(s.MaxIdleClosed) }))
+	reg.Counter("db_max_lifetime_closed_total","Connections closed for reaching DB_CONN_MAX_LIFETIME.",pool(func(s store.PoolStats) float64 { return float64(s.MaxLifetimeClosed) }))
 
-	//* running database migrations --> ensures tables are up to date
-	err = store.Migratefs(pgDb,migrations.FS,".")
-	if err != nil {
-		panic(err)
+	if readRouter != nil {
+		reg.Gauge("db_replica_up","1 when the latest read replica ping succeeded, reads go to the primary otherwise.",func() float64 {
+			if readRouter.ReplicaUp() {
+				return 1
+			}
+			return 0
+		})
+		reg.Counter("db_replica_fallbacks_total","Replica reads that failed and were retried on the primary.",func() float64 { return float64(readRouter.Fallbacks()) })
 	}
 
-
-	//* creating logger instance with date and time stamps
-	logger := log.New(os.Stdout,"",log.Ldate | log.Ltime) 
-
-	//! Initializing all store instances --> database layer that talks to postgres
-	workoutStore := store.NewPostgresWorkoutStore(pgDb) //* workout operations
-	userStore := store.NewPostUserStore(pgDb) //* user operations
-	tokenStore := store.NewPostgresTokenStore(pgDb) //* token operations
-
-	//! Initializing all handler instances --> HTTP request handlers
-	workoutHandler := api.NewWorkoutHandler(workoutStore,logger) //* workout endpoints
-	userHandler := api.NewUserHandler(userStore,logger) //* user registration endpoint
-	tokenHandler := api.NewTokenHandler(tokenStore,userStore,logger) //* authentication endpoint
-	mwHandler := middleware.UserMiddleware{UserStore: userStore} //* middleware for auth checks
-
-	//* creating Application instance with all dependencies wired up
-	app := &Application{
-		Logger : logger,
-		WorkoutHandler: workoutHandler,
-		UserHandler: userHandler,
-		TokenHandler: tokenHandler,
-		Middleware : mwHandler,
-		DB: pgDb,
+	if tokenCache != nil {
+		reg.Counter("token_cache_hits_total","Token lookups answered from the cache.",func() float64 { return float64(tokenCache.Stats().Hits) })
+		reg.Counter("token_cache_misses_total","Token lookups that went to the database.",func() float64 { return float64(tokenCache.Stats().Misses) })
+		reg.Gauge("token_cache_hit_rate","Share of token lookups answered from the cache since start.",func() float64 { return tokenCache.Stats().HitRate })
 	}
-	
-	return app,nil //* return initialized app ready to handle requests
-
 }
 
 //! HealthCheck --> simple endpoint to verify server is running
 //! GET /health --> returns status message
 func (a *Application) HealthCheck(w http.ResponseWriter,req *http.Request) {
+	if a.DBMonitor != nil && !a.DBMonitor.Up() {
+		http.Error(w,"database unavailable",http.StatusServiceUnavailable) //* load balancers stop routing here until it's back
+		return
+	}
 	fmt.Fprintf(w," 🦖FitTrack API is healthy 🪐 and running with Docker + Air! 🔥\n") //* simple text response
-}
\ No newline at end of file
+}
+//! newSPAHandler --> "" disables the frontend, "embedded" uses the build compiled into the binary
+func newSPAHandler(source string) (*spa.Handler,error) {
+	switch source {
+	case "":
+		return nil,nil
+	case "embedded":
+		dist,err := fs.Sub(web.Dist,"dist")
+		if err != nil {
+			return nil,err
+		}
+		return spa.New(dist)
+	default:
+		return spa.New(os.DirFS(source))
+	}
+}
@@ -0,0 +1,740 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fem/internal/accesslog"
+	"fem/internal/achievements"
+	"fem/internal/anomaly"
+	"fem/internal/api"
+	"fem/internal/blob"
+	"fem/internal/audit"
+	"fem/internal/automation"
+	"fem/internal/reminders"
+	"fem/internal/trainingload"
+	"fem/internal/units"
+	"fem/internal/dualwrite"
+	"fem/internal/events"
+	"fem/internal/hashid"
+	"fem/internal/cache"
+	"fem/internal/challenges"
+	"fem/internal/clientconfig"
+	"fem/internal/config"
+	"fem/internal/cors"
+	"fem/internal/clientusage"
+	"fem/internal/experiments"
+	"fem/internal/hooks"
+	"fem/internal/integrations"
+	"fem/internal/lifecycle"
+	"fem/internal/live"
+	"fem/internal/export"
+	"fem/internal/gamification"
+	"fem/internal/graph"
+	"fem/internal/grpcapi"
+	"fem/internal/mailer"
+	"fem/internal/oauth"
+	"fem/internal/password"
+	"fem/internal/metrics"
+	"fem/internal/logging"
+	"fem/internal/maintenance"
+	"fem/internal/middleware"
+	"fem/internal/notify"
+	"fem/internal/digest"
+	"fem/internal/outbox"
+	"fem/internal/pipeline"
+	"fem/internal/publicapi"
+	"fem/internal/schedule"
+	"fem/internal/seasons"
+	"fem/internal/service"
+	"fem/internal/shadow"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fem/internal/warehouse"
+	"fem/internal/webhooks"
+	"fem/internal/worker"
+	"fem/migrations"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+)
+
+//! Builder --> wires config, logger, stores, cache, mailer + background workers into an Application
+//? every With* is optional, anything left unset comes from the env vars like before; tests swap single pieces
+type Builder struct {
+	logOutput io.Writer
+	stores func(stores *Stores)
+	cache cache.Cache
+	mailer mailer.Mailer
+	config *config.Config
+}
+
+func NewBuilder() *Builder {
+	return &Builder{logOutput: os.Stdout}
+}
+
+//* WithLogOutput --> where Logger writes, LOG_LEVEL still filters it
+func (b *Builder) WithLogOutput(out io.Writer) *Builder {
+	b.logOutput = out
+	return b
+}
+
+//* WithStores --> replaces stores after DB_DRIVER picked them, the replica, dual-write + cache wrappers still go on top
+func (b *Builder) WithStores(override func(stores *Stores)) *Builder {
+	b.stores = override
+	return b
+}
+
+//* WithCache --> read + token cache backend instead of CACHE_BACKEND
+func (b *Builder) WithCache(c cache.Cache) *Builder {
+	b.cache = c
+	return b
+}
+
+//* WithMailer --> instead of SMTP_ADDR / the log mailer
+func (b *Builder) WithMailer(m mailer.Mailer) *Builder {
+	b.mailer = m
+	return b
+}
+
+//* WithConfig --> the config file's reloadable settings are applied once built, nil means no file
+func (b *Builder) WithConfig(c *config.Config) *Builder {
+	b.config = c
+	return b
+}
+
+//! Build --> opens the database, builds stores, services + handlers and registers the background loops as components
+func (b *Builder) Build() (*Application,error) {
+
+
+	//* creating logger instance with date and time stamps, LOG_LEVEL filters it (switchable on SIGHUP)
+	logLevel,err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		return nil,err
+	}
+	logWriter := logging.NewLevelWriter(b.logOutput,logLevel)
+	logger := log.New(logWriter,"",log.Ldate | log.Ltime) 
+
+	//! DB_DRIVER=sqlite --> single-file database (SQLITE_PATH), users + tokens + workouts only, for demos and local development
+	dbDriver := utils.GetEnv("DB_DRIVER","postgres")
+	var pgDb *sql.DB
+	switch dbDriver {
+	case "postgres":
+		//* establishing database connection
+		pgDb,err = store.Open()
+		if err != nil {
+			return nil,err
+		}
+
+		//* postgres may still be starting (docker-compose / k8s don't order containers) --> retry with backoff up to DB_WAIT_TIMEOUT
+		err = store.WaitForDB(context.Background(),pgDb,utils.GetEnvDuration("DB_WAIT_TIMEOUT",time.Minute),logger)
+		if err != nil {
+			return nil,err
+		}
+
+		//* running database migrations --> ensures tables are up to date
+		err = store.Migratefs(pgDb,migrations.FS,".")
+		if err != nil {
+			panic(err)
+		}
+	case "memory":
+		//? every store is in-memory below, the sqlite handle only feeds the db ping, /metrics and /debug/db
+		pgDb,err = store.OpenSQLite(":memory:")
+		if err != nil {
+			return nil,err
+		}
+		logger.Printf("DB_DRIVER=memory : nothing is persisted, data is gone on restart\n")
+	case "sqlite":
+		//? the remaining stores still get this handle, their features answer errors until postgres is configured
+		sqlitePath := utils.GetEnv("SQLITE_PATH","fittrack.db")
+		pgDb,err = store.OpenSQLite(sqlitePath)
+		if err != nil {
+			return nil,err
+		}
+		logger.Printf("DB_DRIVER=sqlite : opened %s, users, tokens + workouts only, background jobs are off\n",sqlitePath)
+	default:
+		return nil,fmt.Errorf("DB_DRIVER must be postgres, sqlite or memory, got %q",dbDriver)
+	}
+
+	//! id obfuscation --> ID_OBFUSCATION=compat encodes ids in responses but still accepts numeric ones, strict only takes encoded
+	switch mode := utils.GetEnv("ID_OBFUSCATION","off"); mode {
+	case "off":
+	case "compat","strict":
+		codec,err := hashid.New(os.Getenv("ID_SALT"))
+		if err != nil {
+			return nil,fmt.Errorf("ID_OBFUSCATION=%s needs ID_SALT: %w",mode,err)
+		}
+		utils.SetIDCodec(codec,mode == "compat")
+	default:
+		return nil,fmt.Errorf("ID_OBFUSCATION must be off, compat or strict, got %q",mode)
+	}
+	//* compact JSON responses, indented everywhere in development and per request with ?pretty=1
+	utils.SetPrettyJSON(os.Getenv("APP_ENV") == "development")
+
+	//! stores --> picked by DB_DRIVER, WithStores swaps single ones before the replica, dual-write + cache wrappers below
+	stores,memDB,err := newStores(dbDriver,pgDb)
+	if err != nil {
+		return nil,err
+	}
+	if b.stores != nil {
+		b.stores(stores)
+	}
+
+	primaryWorkouts := stores.Workouts //* before the replica + cache wrappers, the workout service's read-modify-writes read here
+
+	//! read replica --> READ_REPLICA_DATABASE_URL takes workout reads, feeds, XP + GraphQL lists, the primary answers while it is down
+	var readRouter *store.ReadRouter
+	if dsn := os.Getenv("READ_REPLICA_DATABASE_URL"); dsn != "" {
+		replicaDb,err := store.OpenURL(dsn)
+		if err != nil {
+			return nil,err
+		}
+		readRouter = store.NewReadRouter(store.NewDBMonitor(replicaDb,utils.GetEnvDuration("DB_PING_INTERVAL",10*time.Second),logger),logger)
+		stores.Workouts = store.NewRoutedWorkoutStore(stores.Workouts,store.NewPostgresWorkoutStore(replicaDb),readRouter)
+		stores.Follows = store.NewRoutedFollowStore(stores.Follows,store.NewPostgresFollowStore(replicaDb),readRouter)
+		stores.XP = store.NewRoutedXPStore(stores.XP,store.NewPostgresXPStore(replicaDb),readRouter)
+		stores.Graph = store.NewRoutedGraphStore(stores.Graph,store.NewPostgresGraphStore(replicaDb),readRouter)
+	}
+
+	//! dual-write --> DUALWRITE_DATABASE_URL is the backend being migrated to, DUALWRITE_MODE picks write,compare,read_secondary
+	var dualWriteFlags *dualwrite.Flags
+	dualWriteMetrics := dualwrite.NewMetrics()
+	if dsn := os.Getenv("DUALWRITE_DATABASE_URL"); dsn != "" {
+		mode,err := dualwrite.ParseMode(os.Getenv("DUALWRITE_MODE"))
+		if err != nil {
+			return nil,err
+		}
+		secondaryDb,err := store.OpenURL(dsn)
+		if err != nil {
+			return nil,err
+		}
+		dualWriteFlags = dualwrite.NewFlags(mode)
+		stores.Workouts = dualwrite.NewWorkoutStore(stores.Workouts,store.NewPostgresWorkoutStore(secondaryDb),dualWriteFlags,dualWriteMetrics,logger)
+	}
+
+	//! read cache --> CACHE_BACKEND=memory (single instance) or redis (REDIS_URL), wraps the hot workout lookups
+	readCache := b.cache
+	if readCache == nil {
+		switch backend := utils.GetEnv("CACHE_BACKEND","off"); backend {
+		case "off":
+		case "memory":
+			readCache = cache.NewMemory(utils.GetEnvInt("CACHE_SIZE",10000))
+		case "redis":
+			readCache,err = cache.NewRedis(utils.GetEnv("REDIS_URL","redis://localhost:6379/0"),"fem:")
+			if err != nil {
+				return nil,err
+			}
+		default:
+			return nil,fmt.Errorf("CACHE_BACKEND must be off, memory or redis, got %q",backend)
+		}
+	}
+	if readCache != nil {
+		cachedWorkouts := cache.NewWorkoutStore(stores.Workouts,readCache,utils.GetEnvDuration("CACHE_TTL",time.Minute),logger)
+		stores.Workouts = cachedWorkouts
+		stores.Verifications = cache.NewVerificationStore(stores.Verifications,cachedWorkouts)
+	}
+
+	//! token cache --> on by default, the auth middleware otherwise hits postgres on every request
+	//? shares CACHE_BACKEND when one is set, other instances only see a revocation through redis; TOKEN_CACHE_TTL=0 turns it off
+	var tokenCache *cache.UserStore
+	if tokenCacheTTL := utils.GetEnvDuration("TOKEN_CACHE_TTL",30*time.Second); tokenCacheTTL > 0 {
+		backend := readCache
+		if backend == nil {
+			backend = cache.NewMemory(utils.GetEnvInt("CACHE_SIZE",10000))
+		}
+		tokenCache = cache.NewUserStore(stores.Users,backend,tokenCacheTTL,logger)
+		stores.Users = tokenCache
+		stores.Tokens = cache.NewTokenStore(stores.Tokens,tokenCache)
+		if stores.Sessions != nil {
+			stores.Sessions = cache.NewSessionStore(stores.Sessions,tokenCache) //* DELETE /users/me/sessions/{id} revokes through here
+		}
+	}
+
+	//* exporter writes finished bundles to EXPORT_DIR
+	exporter,err := export.NewExporter(utils.GetEnv("EXPORT_DIR",filepath.Join(os.TempDir(),"fittrack-exports")),stores.Exports,stores.Orgs,stores.Users,stores.Profiles,stores.Accounts,logger)
+	if err != nil {
+		return nil,err
+	}
+
+	//* photo bytes go to BLOB_STORE (disk by default, s3 for anything with more than one instance)
+	blobStore,err := blob.FromEnv("/v1/blobs")
+	if err != nil {
+		return nil,err
+	}
+
+	//* warehouse sync is opt-in, only runs when a ClickHouse URL is configured
+	var warehouseSyncer *warehouse.Syncer
+	if clickhouseURL := os.Getenv("WAREHOUSE_CLICKHOUSE_URL"); clickhouseURL != "" {
+		sink := warehouse.NewClickHouseSink(
+			clickhouseURL,
+			utils.GetEnv("WAREHOUSE_CLICKHOUSE_DATABASE","default"),
+			os.Getenv("WAREHOUSE_CLICKHOUSE_USER"),
+			os.Getenv("WAREHOUSE_CLICKHOUSE_PASSWORD"),
+		)
+		warehouseSyncer = warehouse.NewSyncer(
+			sink,
+			stores.Warehouse,
+			utils.GetEnvInt("WAREHOUSE_BATCH_SIZE",500),
+			utils.GetEnvDuration("WAREHOUSE_SYNC_INTERVAL",time.Minute),
+			logger,
+		)
+	}
+
+	//* outgoing email --> SMTP_ADDR, or only logged
+	mail := b.mailer
+	if mail == nil {
+		mail = mailer.NewFromEnv(logger)
+	}
+
+	//* background job runner --> slow work (email, feed fan-out, cleanups) runs off the request path
+	pool := worker.NewPool(stores.Jobs,utils.GetEnvInt("WORKER_CONCURRENCY",4),utils.GetEnvDuration("WORKER_POLL_INTERVAL",time.Second),logger)
+	pool.Register(worker.JobTokenCleanup,worker.TokenCleanup(stores.Tokens,logger))
+	pool.Register(worker.JobSendEmail,worker.SendEmail(mail))
+	pool.Register(worker.JobFeedBackfill,worker.FeedBackfill(stores.Follows))
+	pool.Register(export.JobRun,export.RunJob(exporter))
+	pool.Every(worker.JobTokenCleanup,utils.GetEnvDuration("TOKEN_CLEANUP_INTERVAL",time.Hour))
+
+	//* deleted accounts are kept for ACCOUNT_DELETION_GRACE, then the purge job hard deletes them
+	deletionGrace := utils.GetEnvDuration("ACCOUNT_DELETION_GRACE",30*24*time.Hour)
+	pool.Register(worker.JobAccountPurge,worker.AccountPurge(stores.Users,deletionGrace,logger))
+	pool.Every(worker.JobAccountPurge,utils.GetEnvDuration("ACCOUNT_PURGE_INTERVAL",time.Hour))
+
+	//* metric leaderboards read a cache, the refresh job recomputes it; one now so a fresh memory store has numbers
+	if stores.Leaderboards != nil {
+		pool.Register(worker.JobLeaderboardRefresh,worker.LeaderboardRefresh(stores.Leaderboards))
+		pool.Every(worker.JobLeaderboardRefresh,utils.GetEnvDuration("LEADERBOARD_REFRESH_INTERVAL",5*time.Minute))
+		err = pool.Enqueue(worker.JobLeaderboardRefresh,nil)
+		if err != nil {
+			logger.Printf("ERROR: enqueue leaderboard refresh: %v",err)
+		}
+	}
+
+	//! sandbox accounts --> SANDBOX_ENABLED=true opens POST /sandbox, accounts live SANDBOX_TTL before the purge job deletes them
+	var sandboxHandler *api.SandboxHandler
+	if os.Getenv("SANDBOX_ENABLED") == "true" {
+		sandboxService := service.NewSandboxService(stores.Sandbox,stores.Workouts,stores.Tokens,utils.GetEnvDuration("SANDBOX_TTL",24*time.Hour))
+		sandboxHandler = api.NewSandboxHandler(sandboxService,logger)
+		pool.Register(worker.JobSandboxPurge,worker.SandboxPurge(stores.Sandbox,logger))
+		pool.Every(worker.JobSandboxPurge,utils.GetEnvDuration("SANDBOX_PURGE_INTERVAL",15*time.Minute))
+	}
+
+	//* domain event bus --> handlers publish, background subscribers (achievements, ...) react
+	bus := events.NewBus(logger)
+	hookRegistry := hooks.NewRegistry(logger) //* plugin extension points, empty by default
+	pool.Register(worker.JobFeedFanout,worker.FeedFanout(stores.Follows,bus))
+	bus.Subscribe(func(e events.Event) error {
+		return pool.Enqueue(worker.JobFeedFanout,worker.FeedFanoutPayload{WorkoutID: int64(e.WorkoutID)})
+	},events.WorkoutCreated,events.WorkoutUpdated) //* updates can make a workout visible to followers
+
+	//* user webhooks --> workout events are POSTed signed, WEBHOOKS_ALLOW_PRIVATE=true lifts the https + public address rules for local testing
+	webhooksAllowPrivate := os.Getenv("WEBHOOKS_ALLOW_PRIVATE") == "true"
+	webhookDispatcher := &webhooks.Dispatcher{
+		Store: stores.Webhooks,
+		Workouts: stores.Workouts,
+		Jobs: pool,
+		Client: webhooks.NewClient(utils.GetEnvDuration("WEBHOOKS_TIMEOUT",10*time.Second),webhooksAllowPrivate),
+		Logger: logger,
+	}
+	pool.Register(webhooks.JobDispatch,webhooks.DispatchJob(webhookDispatcher))
+	pool.Register(webhooks.JobDeliver,webhooks.DeliverJob(webhookDispatcher))
+	webhookDispatcher.Subscribe(bus)
+
+	//* seasonal events --> opted-in users are enrolled and finished events closed on a schedule
+	pool.Register(seasons.JobEnroll,seasons.EnrollJob(stores.SeasonalEvents,logger))
+	pool.Register(seasons.JobClose,seasons.CloseJob(stores.SeasonalEvents,bus,logger))
+	pool.Every(seasons.JobEnroll,utils.GetEnvDuration("SEASONAL_EVENTS_ENROLL_INTERVAL",10*time.Minute))
+	pool.Every(seasons.JobClose,utils.GetEnvDuration("SEASONAL_EVENTS_CLOSE_INTERVAL",5*time.Minute))
+	//* challenges --> finished ones are closed on a schedule, their results frozen and the participants told
+	if stores.Challenges != nil {
+		pool.Register(challenges.JobClose,challenges.CloseJob(stores.Challenges,bus,logger))
+		pool.Every(challenges.JobClose,utils.GetEnvDuration("CHALLENGES_CLOSE_INTERVAL",time.Minute))
+	}
+	achievementEngine := achievements.NewEngine(stores.Achievements,logger)
+	achievementEngine.Subscribe(bus)
+	xpService := gamification.NewService(stores.XP,gamification.ConfigFromEnv())
+	xpService.Subscribe(bus)
+	automation.NewEngine(stores.Automations,stores.Users,pool,logger).Subscribe(bus) //* user rules, actions go through the worker
+
+	//* training load --> acute:chronic ratio past the TRAINING_LOAD_THRESHOLDS caution/high marks is pushed on the live stream
+	trainingLoadMetric := utils.GetEnv("TRAINING_LOAD_METRIC",trainingload.MetricDuration)
+	trainingLoadThresholds := trainingload.ParseThresholds(utils.GetEnv("TRAINING_LOAD_THRESHOLDS",""))
+	trainingload.NewMonitor(stores.TrainingLoad,stores.Profiles,trainingLoadMetric,trainingLoadThresholds,logger).Subscribe(bus)
+
+	//* live updates --> the hub keeps SSE_REPLAY_SIZE recent events per user for Last-Event-ID reconnects
+	eventHub := events.NewHub(utils.GetEnvInt("SSE_REPLAY_SIZE",100),utils.GetEnvDuration("SSE_REPLAY_WINDOW",5*time.Minute))
+	eventHub.Forward(bus,events.WorkoutCreated,events.WorkoutUpdated,events.WorkoutDeleted,events.AchievementEarned,events.FeedEntryAdded,events.ReminderDue,events.TrainingLoadHigh,events.ChallengeEnded)
+
+	//* events outbox --> workout stores write their events in the change's transaction, the relay puts them on the bus
+	//* every OUTBOX_POLL_INTERVAL (right away after a local write) and drops published rows after OUTBOX_RETENTION
+	outboxRelay := outbox.NewRelay(stores.Outbox,bus,utils.GetEnvDuration("OUTBOX_POLL_INTERVAL",time.Second),utils.GetEnvDuration("OUTBOX_RETENTION",24*time.Hour),logger)
+
+	//* event streaming --> EVENTS_PUBLISHER=nats|kafka sends workout + sign-up events to analytics pipelines, schema in internal/events/schema
+	eventPublisher,err := events.NewPublisherFromEnv()
+	if err != nil {
+		return nil,err
+	}
+	if eventPublisher != nil {
+		streamer := &events.Streamer{Publisher: eventPublisher,Timeout: utils.GetEnvDuration("EVENTS_PUBLISH_TIMEOUT",5*time.Second),Logger: logger}
+		streamer.Forward(bus,events.StreamEvents...)
+	}
+
+	//* workout reminders --> checked every REMINDERS_INTERVAL in each rule's timezone, delivered on the live stream (+ email)
+	reminderScheduler := reminders.NewScheduler(stores.Reminders,stores.Schedules,stores.Users,bus,pool,logger)
+	pool.Register(reminders.JobEvaluate,reminders.EvaluateJob(reminderScheduler))
+	pool.Every(reminders.JobEvaluate,utils.GetEnvDuration("REMINDERS_INTERVAL",time.Minute))
+
+	//* notifications --> comments, new followers, challenge results, reminders + badges go to the in-app inbox, the user's
+	//* phones through FCM (FCM_CREDENTIALS_FILE) and APNs (APNS_KEY_FILE ...) and email, as their preferences say
+	if stores.Notifications != nil {
+		pushSenders,err := notify.SendersFromEnv(logger)
+		if err != nil {
+			return nil,err
+		}
+		notifier := &notify.Notifier{
+			Store: stores.Notifications,
+			Users: stores.Users,
+			Workouts: stores.Workouts,
+			Challenges: stores.Challenges,
+			Reminders: stores.Reminders,
+			Achievements: achievementEngine,
+			Senders: pushSenders,
+			Jobs: pool,
+			Logger: logger,
+		}
+		pool.Register(notify.JobDispatch,notify.DispatchJob(notifier))
+		pool.Register(notify.JobDeliver,notify.DeliverJob(notifier))
+		notifier.Subscribe(bus)
+	}
+
+	//* weekly digest --> last week's workouts, records + goals emailed on Monday at DIGEST_SEND_TIME in each user's timezone
+	if stores.Digests != nil {
+		digestSender := digest.NewSender(stores.Digests,stores.Goals,pool,utils.GetEnv("DIGEST_SEND_TIME","08:00"),logger)
+		_,err = reminders.ParseClock(digestSender.SendAt)
+		if err != nil {
+			return nil,fmt.Errorf("DIGEST_SEND_TIME must be HH:MM, got %q",digestSender.SendAt)
+		}
+		pool.Register(digest.JobSend,digest.SendJob(digestSender))
+		pool.Every(digest.JobSend,utils.GetEnvDuration("DIGEST_INTERVAL",5*time.Minute))
+	}
+
+	//* schedule occurrences are generated SCHEDULE_HORIZON ahead of time
+	//* request counts are buffered per instance and flushed every CLIENT_USAGE_FLUSH_INTERVAL
+	clientUsageRecorder := clientusage.NewRecorder(stores.ClientUsage,utils.GetEnvDuration("CLIENT_USAGE_FLUSH_INTERVAL",time.Minute),logger)
+	clientUsageRecorder.Users = stores.UserUsage //* per-user counts for GET /users/me/usage
+
+	scheduleMaterializer := schedule.NewMaterializer(
+		stores.Schedules,
+		utils.GetEnvDuration("SCHEDULE_HORIZON",8*7*24*time.Hour),
+		utils.GetEnvDuration("SCHEDULE_MATERIALIZE_INTERVAL",time.Hour),
+		logger,
+	)
+
+	//* experiment definitions come from EXPERIMENTS_FILE, a broken file stops startup
+	assigner,err := experiments.LoadFromEnv()
+	if err != nil {
+		return nil,err
+	}
+
+	//* mobile client config comes from CLIENT_CONFIG_FILE, same deal
+	clientConfig,err := clientconfig.LoadFromEnv()
+	if err != nil {
+		return nil,err
+	}
+
+	//* public read-only routes come from PUBLIC_API_FILE, nothing is exposed without it
+	publicAPIConfig,err := publicapi.LoadFromEnv()
+	if err != nil {
+		return nil,err
+	}
+	//* X-Forwarded-For / X-Real-IP only count when the peer is listed here, nobody by default
+	trustedProxies,err := middleware.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil,err
+	}
+
+	//* shared anomaly detector --> write-time checks + verification scores use the same thresholds
+	detector := anomaly.NewDetectorFromEnv()
+
+	//* Strava sync is opt-in, jobs are only registered when STRAVA_CLIENT_ID/SECRET are set
+	strava := integrations.NewStravaClientFromEnv()
+	if strava != nil {
+		syncer := &integrations.Syncer{
+			Store: stores.Integrations,
+			Workouts: stores.Workouts,
+			Profiles: stores.Profiles,
+			Detector: detector,
+			Strava: strava,
+			Relay: outboxRelay,
+			InitialWindow: utils.GetEnvDuration("STRAVA_INITIAL_SYNC_WINDOW",30*24*time.Hour),
+			Logger: logger,
+		}
+		pool.Register(integrations.JobSync,integrations.SyncJob(syncer))
+		pool.Register(integrations.JobSyncAll,integrations.SyncAllJob(stores.Integrations,pool))
+		pool.Every(integrations.JobSyncAll,utils.GetEnvDuration("STRAVA_SYNC_INTERVAL",time.Hour))
+	}
+
+	//* web frontend --> WEB_UI=embedded serves web/dist from the binary, any other value is a directory on disk
+	spaHandler,err := newSPAHandler(os.Getenv("WEB_UI"))
+	if err != nil {
+		return nil,err
+	}
+
+	//! service layer --> business rules shared by the HTTP handlers and the gRPC server
+	workoutService := service.NewWorkoutService(stores.Workouts,stores.Profiles,stores.Follows,detector,outboxRelay,hookRegistry,logger)
+	workoutService.Tags = stores.Tags
+	workoutService.Primary = primaryWorkouts
+	var photoService *service.PhotoService
+	if stores.Photos != nil {
+		photoService = service.NewPhotoService(stores.Photos,stores.Workouts,blobStore,logger)
+		photoService.MaxBytes = int64(utils.GetEnvInt("PHOTO_MAX_BYTES",10<<20))
+		photoService.Subscribe(bus) //* deleting a workout removes its photo blobs
+	}
+	var avatarService *service.AvatarService
+	if stores.Avatars != nil {
+		avatarService = service.NewAvatarService(stores.Avatars,blobStore,logger)
+		avatarService.MaxBytes = int64(utils.GetEnvInt("AVATAR_MAX_BYTES",5<<20))
+	}
+	passwordValidator := password.NewValidator(password.PolicyFromEnv(),password.CheckerFromEnv(),logger) //* registration + password change rules
+	userService := service.NewUserService(stores.Users,hookRegistry,logger)
+	userService.Passwords = passwordValidator
+	userService.Bus = bus //* user.registered, only the event publisher listens
+	auditRecorder := audit.NewRecorder(stores.Audit,logger)
+	authService := service.NewAuthService(stores.Tokens,stores.Users)
+	authService.Audit = auditRecorder //* logins, logouts, password changes (HTTP + gRPC)
+	authService.Lockouts = stores.LoginLockouts
+	authService.Policy = service.LockoutPolicyFromEnv() //* LOGIN_MAX_FAILURES=0 + LOGIN_MAX_FAILURES_PER_IP=0 turn lockouts off
+	authService.TwoFactor = stores.TwoFactor
+	authService.Issuer = utils.GetEnv("TOTP_ISSUER","FitTrack") //* label in authenticator apps
+	authService.LinkedAccounts = stores.LinkedAccounts
+	authService.Registrations = userService //* first social sign-in creates the account through the normal hooks
+	authService.Sessions = stores.Sessions
+	authService.Passwords = passwordValidator
+
+	//! Initializing all handler instances --> HTTP request handlers
+	workoutHandler := api.NewWorkoutHandler(stores.Comments,workoutService,photoService,logger) //* workout endpoints
+	userHandler := api.NewUserHandler(stores.Users,userService,authService,deletionGrace,logger) //* user registration endpoint
+	profileHandler := api.NewProfileHandler(stores.Users,stores.Profiles,xpService,stores.Achievements,achievementEngine,logger) //* profile endpoints
+	tokenHandler := api.NewTokenHandler(authService,logger) //* authentication endpoint
+	twoFactorHandler := api.NewTwoFactorHandler(authService,logger) //* TOTP setup + backup codes
+	oauthProviders := oauth.ProvidersFromEnv() //* only providers with a client id + secret
+	if stores.LinkedAccounts == nil {
+		oauthProviders = nil
+	}
+	oauthHandler := api.NewOAuthHandler(authService,oauthProviders,logger) //* social sign-in endpoints
+	sessionHandler := api.NewSessionHandler(authService,logger) //* session endpoints
+	tagHandler := api.NewTagHandler(workoutService,logger) //* workout tag endpoints
+	syncHandler := api.NewSyncHandler(stores.Sync,logger) //* workout change feed for offline clients
+	photoHandler := api.NewPhotoHandler(photoService,blobStore,logger) //* workout photo uploads + signed blob downloads
+	avatarHandler := api.NewAvatarHandler(avatarService,logger) //* avatar upload + serving
+	orgHandler := api.NewOrgHandler(stores.Orgs,logger) //* org endpoints
+	scimHandler := api.NewSCIMHandler(stores.Users,stores.Orgs,logger) //* SCIM provisioning endpoints
+	accountExportsPerDay := utils.GetEnvInt("ACCOUNT_EXPORTS_PER_DAY",5) //* 0 = unlimited
+	exportHandler := api.NewExportHandler(stores.Exports,stores.Orgs,pool,accountExportsPerDay,logger) //* export endpoints
+	usageHandler := api.NewUsageHandler(stores.UserUsage,stores.Exports,accountExportsPerDay,logger) //* per-user usage endpoint
+	shareHandler := api.NewShareHandler(stores.Workouts,stores.Shares,logger) //* share link endpoints
+	followHandler := api.NewFollowHandler(stores.Users,stores.Follows,pool,bus,logger) //* social endpoints
+	commentHandler := api.NewCommentHandler(stores.Workouts,stores.Follows,stores.Comments,bus,logger) //* comment + reaction endpoints
+	goalHandler := api.NewGoalHandler(stores.Goals,stores.Profiles,logger) //* goal endpoints
+	verificationHandler := api.NewVerificationHandler(stores.Workouts,stores.Follows,stores.Orgs,stores.Verifications,detector,logger) //* verification endpoints
+	achievementHandler := api.NewAchievementHandler(stores.Users,stores.Achievements,achievementEngine,logger) //* achievement endpoints
+	leaderboardHandler := api.NewLeaderboardHandler(xpService,stores.Leaderboards,logger) //* leaderboard endpoints
+	scheduleHandler := api.NewScheduleHandler(stores.Schedules,scheduleMaterializer,logger) //* schedule endpoints
+	seasonalEventHandler := api.NewSeasonalEventHandler(stores.SeasonalEvents,pool,logger) //* seasonal event endpoints
+	challengeHandler := api.NewChallengeHandler(stores.Challenges,logger) //* challenge endpoints
+	programHandler := api.NewProgramHandler(stores.Programs,stores.Workouts,stores.Follows,stores.Profiles,scheduleMaterializer,logger) //* program + enrollment endpoints
+	experimentHandler := api.NewExperimentHandler(assigner,stores.Experiments,logger) //* experiment endpoints
+	clientConfigHandler := api.NewClientConfigHandler(clientConfig,logger) //* client config endpoint
+	clientUsageHandler := api.NewClientUsageHandler(stores.ClientUsage,logger) //* client usage report endpoint
+	integrationHandler := api.NewIntegrationHandler(stores.Integrations,strava,pool,logger) //* integration endpoints
+	webhookHandler := api.NewWebhookHandler(stores.Webhooks,webhooksAllowPrivate,logger) //* webhook endpoints
+	automationHandler := api.NewAutomationHandler(stores.Automations,logger) //* automation rule endpoints
+	reminderHandler := api.NewReminderHandler(stores.Reminders,logger) //* reminder endpoints
+	notificationHandler := api.NewNotificationHandler(stores.Notifications,logger) //* device + notification preference endpoints
+	trainingLoadHandler := api.NewTrainingLoadHandler(stores.TrainingLoad,stores.Profiles,trainingLoadMetric,trainingLoadThresholds,logger) //* ACWR endpoint
+	liveHub := live.NewHub(utils.GetEnvInt("LIVE_MAX_VIEWERS",500)) //* live workout rooms, in-process
+	liveHandler := api.NewLiveHandler(liveHub,stores.Users,stores.Follows,strings.Fields(os.Getenv("WS_ALLOWED_ORIGINS")),logger) //* websocket endpoint
+	metricsRegistry := metrics.NewRegistry()
+	dbMonitor := store.NewDBMonitor(pgDb,utils.GetEnvDuration("DB_PING_INTERVAL",10*time.Second),logger)
+	registerMetrics(metricsRegistry,pgDb,dbMonitor,readRouter,tokenCache)
+
+	//! lifecycle --> /ready gates on the database + job workers, SIGTERM drains before anything stops
+	lifecycleState := lifecycle.New(lifecycle.InstanceFromEnv(),
+		utils.GetEnvDuration("SHUTDOWN_DRAIN_DELAY",5*time.Second),
+		utils.GetEnvDuration("SHUTDOWN_TIMEOUT",20*time.Second))
+	lifecycleState.AddCheck("database",dbMonitor.Up)
+	if dbDriver != "sqlite" {
+		lifecycleState.AddCheck("jobs",pool.Running) //* no job queue in sqlite mode
+	}
+
+	cacheHandler := api.NewCacheHandler(tokenCache) //* token cache hit rate
+	maintenanceMode := maintenance.FromEnv() //* MAINTENANCE_MODE, switched at runtime through PUT /admin/maintenance
+	maintenanceHandler := api.NewMaintenanceHandler(maintenanceMode,logger) //* maintenance mode switch
+	dualWriteHandler := api.NewDualWriteHandler(dualWriteFlags,dualWriteMetrics,logger) //* dual-write flags + divergence metrics
+	adminHandler := api.NewAdminHandler(stores.Admin,stores.Tokens,stores.LoginLockouts,stores.Shadow,clientConfig,auditRecorder,logger) //* admin endpoints
+	eventStreamHandler := api.NewEventStreamHandler(eventHub,utils.GetEnvDuration("SSE_HEARTBEAT_INTERVAL",15*time.Second),logger) //* SSE endpoint
+	mwHandler := middleware.UserMiddleware{UserStore: stores.Users,Sessions: stores.Sessions,Touches: middleware.NewSessionTouches()} //* middleware for auth checks
+	scimMwHandler := middleware.SCIMMiddleware{OrgStore: stores.Orgs} //* middleware for SCIM token checks
+	clientUsageMwHandler := middleware.ClientUsageMiddleware{Recorder: clientUsageRecorder} //* middleware for usage counting
+	clientVersionMwHandler := middleware.ClientVersionMiddleware{Config: clientConfig,Exempt: []string{"/health","/client-config","/v1/client-config"}} //* middleware for outdated app builds
+
+	//* unprefixed routes are deprecated as of the /v1 release, API_LEGACY_SUNSET (YYYY-MM-DD) moves the removal date
+	legacySunset := legacyDeprecatedAt.AddDate(0,6,0)
+	if raw := os.Getenv("API_LEGACY_SUNSET"); raw != "" {
+		legacySunset,err = time.Parse(time.DateOnly,raw)
+		if err != nil {
+			return nil,fmt.Errorf("API_LEGACY_SUNSET: %w",err)
+		}
+	}
+	versionMwHandler := middleware.VersionMiddleware{DeprecatedAt: legacyDeprecatedAt,Sunset: legacySunset} //* middleware for API versioning
+
+	//* creating Application instance with all dependencies wired up
+	app := &Application{
+		Logger : logger,
+		LogLevel: logWriter,
+		CORS: cors.NewPolicy(cors.ParseOrigins(os.Getenv("CORS_ALLOWED_ORIGINS"))),
+		WorkoutHandler: workoutHandler,
+		UserHandler: userHandler,
+		SandboxHandler: sandboxHandler,
+		UsageHandler: usageHandler,
+		ProfileHandler: profileHandler,
+		TokenHandler: tokenHandler,
+		OrgHandler: orgHandler,
+		SCIMHandler: scimHandler,
+		ExportHandler: exportHandler,
+		ShareHandler: shareHandler,
+		FollowHandler: followHandler,
+		CommentHandler: commentHandler,
+		GoalHandler: goalHandler,
+		VerificationHandler: verificationHandler,
+		AchievementHandler: achievementHandler,
+		LeaderboardHandler: leaderboardHandler,
+		ScheduleHandler: scheduleHandler,
+		SeasonalEventHandler: seasonalEventHandler,
+		ChallengeHandler: challengeHandler,
+		ProgramHandler: programHandler,
+		ExperimentHandler: experimentHandler,
+		ClientConfigHandler: clientConfigHandler,
+		ClientUsageHandler: clientUsageHandler,
+		IntegrationHandler: integrationHandler,
+		WebhookHandler: webhookHandler,
+		AutomationHandler: automationHandler,
+		ReminderHandler: reminderHandler,
+		NotificationHandler: notificationHandler,
+		TwoFactorHandler: twoFactorHandler,
+		OAuthHandler: oauthHandler,
+		SessionHandler: sessionHandler,
+		TagHandler: tagHandler,
+		SyncHandler: syncHandler,
+		PhotoHandler: photoHandler,
+		AvatarHandler: avatarHandler,
+		TrainingLoadHandler: trainingLoadHandler,
+		Audit: auditRecorder,
+		PublicAPI: publicapi.NewGate(publicAPIConfig),
+		EventStreamHandler: eventStreamHandler,
+		CacheHandler: cacheHandler,
+		DualWriteHandler: dualWriteHandler,
+		MaintenanceHandler: maintenanceHandler,
+		AdminHandler: adminHandler,
+		LiveHandler: liveHandler,
+		GraphQLHandler: graph.NewHandler(&graph.Resolver{Workouts: workoutService,Users: stores.Users,Profiles: stores.Profiles,Graph: stores.Graph,Logger: logger}),
+		SPA: spaHandler,
+		GRPCServer: grpcapi.NewServer(workoutService,userService,authService,logger),
+		Middleware : mwHandler,
+		SCIMMiddleware: scimMwHandler,
+		ClientVersionMiddleware: clientVersionMwHandler,
+		ClientUsageMiddleware: clientUsageMwHandler,
+		VersionMiddleware: versionMwHandler,
+		WarehouseSyncer: warehouseSyncer,
+		ScheduleMaterializer: scheduleMaterializer,
+		ClientUsageRecorder: clientUsageRecorder,
+		Worker: pool,
+		Events: bus,
+		EventHub: eventHub,
+		Hooks: hookRegistry,
+		DevMode: os.Getenv("APP_ENV") == "development",
+		DBDriver: dbDriver,
+		MemDB: memDB,
+		DB: pgDb,
+		Lifecycle: lifecycleState,
+		DBMonitor: dbMonitor,
+		ReadRouter: readRouter,
+		DBPool: store.PoolConfigFromEnv(),
+		Metrics: metricsRegistry,
+	}
+	
+	//* access log --> ACCESS_LOG_SAMPLE="/health=0,/v1/workouts=10" keeps busy routes quiet, 5xx are always logged
+	accessLog,err := accesslog.New(os.Getenv("ACCESS_LOG_SAMPLE"),logger,logWriter)
+	if err != nil {
+		return nil,err
+	}
+
+	//! default middleware order --> deployments add their own stages Before/After these names, see main.go
+	app.Pipeline = pipeline.New(
+		pipeline.Stage{Name: StageClientIP,Middleware: trustedProxies.Middleware}, //* first, rate limits + audit entries + sessions all read its IP
+		pipeline.Stage{Name: StageRequestID,Middleware: utils.RequestID}, //* before the access log, which prints the ID
+		pipeline.Stage{Name: StageAccessLog,Middleware: accessLog.Middleware}, //* outside everything else, so maintenance 503s + 426s are logged with their latency
+		pipeline.Stage{Name: StagePrettyJSON,Middleware: utils.PrettyJSON}, //* cheap, only reads the query string
+		pipeline.Stage{Name: StageNegotiate,Middleware: utils.Negotiate},
+		pipeline.Stage{Name: StageAudit,Middleware: audit.Capture}, //* cheap, every later stage + handler can record
+		pipeline.Stage{Name: StageCORS,Middleware: app.CORS.Middleware}, //* before maintenance, so browsers can read its 503
+		pipeline.Stage{Name: StageMaintenance,Middleware: maintenanceMode.Middleware}, //* before usage counting + hooks, nothing else runs during maintenance
+		pipeline.Stage{Name: StageClientUsage,Middleware: app.ClientUsageMiddleware.Track}, //* outermost so rejected old builds are counted too
+		pipeline.Stage{Name: StageHooks,Middleware: app.Hooks.Middleware}, //* BeforeResponse sees 426s as well
+		pipeline.Stage{Name: StageClientVersion,Middleware: app.ClientVersionMiddleware.RequireMinVersion}, //* before any auth work
+	)
+	//* traffic mirroring --> SHADOW_BASE_URL points at the deployment under test, SHADOW_PERCENT of GETs are replayed there
+	if target := os.Getenv("SHADOW_BASE_URL"); target != "" {
+		mirror,err := shadow.New(target,
+			utils.GetEnvInt("SHADOW_PERCENT",10),
+			utils.GetEnvInt("SHADOW_CONCURRENCY",10),
+			utils.GetEnvDuration("SHADOW_TIMEOUT",5*time.Second),
+			stores.Shadow,logger)
+		if err != nil {
+			return nil,err
+		}
+		err = app.Pipeline.Use(StageShadow,mirror.Middleware) //* innermost, compares what the handlers answered
+		if err != nil {
+			return nil,err
+		}
+	}
+	app.UserPipeline = pipeline.New(
+		pipeline.Stage{Name: StageAuthenticate,Middleware: app.Middleware.Authenticate},
+		pipeline.Stage{Name: StageAccessLogUser,Middleware: accesslog.User}, //* needs the user authenticate put in context
+		pipeline.Stage{Name: StageUserUsage,Middleware: app.ClientUsageMiddleware.TrackUser}, //* needs the user authenticate put in context
+		pipeline.Stage{Name: StageUnits,Middleware: units.NewResolver(stores.Profiles,logger).Middleware}, //* the profile is only read by handlers that convert
+	)
+
+	//! components --> Start launches them in this order, Stop shuts them down in reverse, so the database closes last
+	app.AddComponent(Component{Name: "database",Stop: func(context.Context) error { return pgDb.Close() }})
+	if eventPublisher != nil {
+		app.AddComponent(Component{Name: "event_publisher",Stop: func(context.Context) error { return eventPublisher.Close() }}) //* after the outbox relay stops
+	}
+	app.AddComponent(Loop("db_monitor",dbMonitor.Run)) //* notices database outages, /health reports them
+	app.AddComponent(Loop("outbox",outboxRelay.Run)) //* every driver has the outbox, workout events only reach the bus through it
+	if readRouter != nil {
+		app.AddComponent(Loop("read_replica",readRouter.Run)) //* reads fall back to the primary while the replica is down
+	}
+	//? postgres-only loops --> DB_DRIVER=sqlite has no job queue, schedules or usage tables (memory has them all)
+	if dbDriver != "sqlite" {
+		if warehouseSyncer != nil {
+			app.AddComponent(Loop("warehouse",warehouseSyncer.Run))
+		}
+		app.AddComponent(Loop("schedules",scheduleMaterializer.Run))
+		app.AddComponent(Loop("client_usage",clientUsageRecorder.Run))
+		app.AddComponent(Loop("worker",pool.Run)) //* stopped first, finishes the job it holds
+	}
+
+	//* config file --> log level, CORS origins + public API limits from the file win over their env vars
+	if b.config != nil {
+		err = app.Reload(b.config)
+		if err != nil {
+			return nil,err
+		}
+	}
+
+	return app,nil //* return initialized app ready to handle requests
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fem/internal/mailer"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingWorkouts struct {
+	store.WorkoutStore
+	gets int
+}
+
+func (c *countingWorkouts) GetWorkoutByID(id int64) (*store.Workout, error) {
+	c.gets++
+	return c.WorkoutStore.GetWorkoutByID(id)
+}
+
+type discardMailer struct{}
+
+func (discardMailer) Send(context.Context, mailer.Message) error { return nil }
+
+func TestBuilderOverrides(t *testing.T) {
+	t.Setenv("DB_DRIVER", "memory")
+	t.Setenv("BLOB_DIR", t.TempDir())
+	t.Setenv("EXPORT_DIR", t.TempDir())
+	logs := &bytes.Buffer{}
+	workouts := &countingWorkouts{}
+
+	app, err := NewBuilder().
+		WithLogOutput(logs).
+		WithMailer(discardMailer{}).
+		WithStores(func(stores *Stores) {
+			workouts.WorkoutStore = stores.Workouts
+			stores.Workouts = workouts
+		}).
+		Build()
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "DB_DRIVER=memory")
+
+	require.NoError(t, app.Start(context.Background()))
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", "1")
+	req := httptest.NewRequest(http.MethodGet, "/workouts/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+	req = middleware.SetUser(req, &store.User{ID: 1})
+	rec := httptest.NewRecorder()
+	app.WorkoutHandler.HandleWorkoutByID(rec, req)
+	assert.Equal(t, 1, workouts.gets, "handlers go through the replaced store")
+	assert.Equal(t, http.StatusNotFound, rec.Code, "the memory store is empty")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, app.Stop(ctx))
+	assert.Error(t, app.DB.Ping(), "the database closes last")
+}
+
+func TestStartStopsStartedComponentsOnFailure(t *testing.T) {
+	var stopped []string
+	component := func(name string, startErr error) Component {
+		return Component{
+			Name:  name,
+			Start: func(context.Context) error { return startErr },
+			Stop: func(context.Context) error {
+				stopped = append(stopped, name)
+				return nil
+			},
+		}
+	}
+	app := &Application{}
+	app.AddComponent(component("first", nil))
+	app.AddComponent(component("second", nil))
+	app.AddComponent(component("broken", errors.New("no port")))
+	app.AddComponent(component("never", nil))
+
+	err := app.Start(context.Background())
+	assert.ErrorContains(t, err, "start broken: no port")
+	assert.Equal(t, []string{"second", "first"}, stopped)
+}
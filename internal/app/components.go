@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+//! Component --> a part of the app with a lifetime, Start runs before the servers take traffic, Stop after they stopped
+type Component struct {
+	Name string
+	Start func(ctx context.Context) error //* nil --> nothing to start
+	Stop func(ctx context.Context) error //* nil --> nothing to stop
+}
+
+//! Loop --> a background Run(ctx) loop as a Component, Stop cancels it and waits until it returned
+func Loop(name string,run func(context.Context)) Component {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx,cancel = context.WithCancel(context.Background()) //* outlives Start's ctx, only Stop ends the loop
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if cancel == nil {
+				return nil //* never started
+			}
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("still running: %w",ctx.Err())
+			}
+		},
+	}
+}
+
+//! AddComponent --> register before Start, e.g. a transport built outside of the Builder
+func (a *Application) AddComponent(c Component) {
+	a.components = append(a.components,c)
+}
+
+//! Start --> starts the components in order, a failure stops the ones already started
+func (a *Application) Start(ctx context.Context) error {
+	for i,c := range a.components {
+		if c.Start == nil {
+			continue
+		}
+		err := c.Start(ctx)
+		if err != nil {
+			stopErr := stopAll(ctx,a.components[:i])
+			return errors.Join(fmt.Errorf("start %s: %w",c.Name,err),stopErr)
+		}
+	}
+	return nil
+}
+
+//! Stop --> stops every component in reverse order, keeps going past errors and returns them all
+func (a *Application) Stop(ctx context.Context) error {
+	return stopAll(ctx,a.components)
+}
+
+func stopAll(ctx context.Context,components []Component) error {
+	var errs []error
+	for i := len(components)-1; i >= 0; i-- {
+		c := components[i]
+		if c.Stop == nil {
+			continue
+		}
+		err := c.Stop(ctx)
+		if err != nil {
+			errs = append(errs,fmt.Errorf("stop %s: %w",c.Name,err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fem/internal/config"
+	"fem/internal/logging"
+	"fem/internal/publicapi"
+)
+
+// ! Reload --> applies the reloadable settings of the config file (log level, CORS origins, public API limits) to the running app
+// ? settings missing from the file keep their current value, the public API falls back to re-reading PUBLIC_API_FILE
+func (a *Application) Reload(c *config.Config) error {
+	//* parse everything first so a bad file changes nothing
+	var level logging.Level
+	if c.LogLevel != "" {
+		var err error
+		level,err = logging.ParseLevel(c.LogLevel)
+		if err != nil {
+			return err
+		}
+	}
+	publicAPI := c.PublicAPIConfig()
+	if publicAPI == nil {
+		var err error
+		publicAPI,err = publicapi.LoadFromEnv()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.LogLevel != "" {
+		a.LogLevel.SetLevel(level)
+	}
+	if c.CORS != nil {
+		a.CORS.SetOrigins(c.CORS.AllowedOrigins)
+	}
+	a.PublicAPI.SetConfig(publicAPI)
+
+	a.Logger.Printf("config reloaded : log_level=%s cors=%v public routes=%d\n",a.LogLevel.Level(),a.CORS.Origins(),len(publicAPI.Routes))
+	return nil
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fmt"
+)
+
+//! Stores --> every store the app is wired with, nil means the feature answers 501 (see the sqlite notes in newStores)
+type Stores struct {
+	Workouts store.WorkoutStore //* workout operations
+	Users store.UserStore //* user operations
+	Tokens store.TokenStore //* token operations
+	Orgs store.OrgStore //* org + membership operations
+	Profiles store.ProfileStore //* profile + body metrics operations
+	Exports store.ExportStore //* export job bookkeeping
+	Shares store.ShareStore //* workout share links
+	Follows store.FollowStore //* follows + activity feed
+	Comments store.CommentStore //* comments + reactions
+	Goals store.GoalStore //* goals + progress
+	Verifications store.VerificationStore //* anti-cheat evidence
+	Achievements store.AchievementStore //* earned achievements
+	XP store.XPStore //* XP ledger
+	Schedules store.ScheduleStore //* recurring schedules + occurrences
+	Jobs store.JobStore //* background job queue
+	SeasonalEvents store.SeasonalEventStore //* seasonal events + standings
+	Experiments store.ExperimentStore //* experiment exposure log
+	ClientUsage store.ClientUsageStore //* per-client request counters
+	Integrations store.IntegrationStore //* connected third-party accounts
+	Accounts store.AccountStore //* whole-account reads for data exports
+	Webhooks store.WebhookStore //* user webhooks + delivery log
+	Automations store.AutomationStore //* per-user automation rules
+	Reminders store.ReminderStore //* workout reminder rules
+	TrainingLoad store.TrainingLoadStore //* daily load + ACWR alerts
+	Audit store.AuditStore //* security audit log
+	LoginLockouts store.LoginLockoutStore //* failed login counts + lockouts
+	TwoFactor store.TwoFactorStore //* TOTP secrets + backup codes
+	LinkedAccounts store.LinkedAccountStore //* Google/GitHub identities
+	Sessions store.SessionStore //* auth token metadata (device, IP, last used)
+	Tags store.TagStore //* workout tags + per-user counts
+	Photos store.PhotoStore //* workout photo metadata, the bytes live in the blob store
+	Avatars store.AvatarStore //* avatar blob key per user
+	Admin store.AdminStore //* admin UI lookups
+	Warehouse store.WarehouseStore //* change feeds for the warehouse sync
+	Shadow store.ShadowStore //* traffic mirror mismatches
+	Graph store.GraphStore //* GraphQL list queries
+	Sandbox store.SandboxStore //* throwaway demo accounts
+	UserUsage store.UserUsageStore //* per-user request counters
+	Sync store.SyncStore //* workout change log for offline clients
+	Outbox store.OutboxStore //* workout events written with the change, drained by the outbox relay
+	Leaderboards store.LeaderboardStore //* cached weekly + streak aggregates
+	Challenges store.ChallengeStore //* user challenges, participants + frozen results
+	Notifications store.NotificationStore //* push devices + notification preferences
+	Digests store.DigestStore //* weekly digest recipients, summaries + sent weeks
+	Programs store.ProgramStore //* multi-week programs + enrollments
+}
+
+//! newStores --> postgres stores on db, DB_DRIVER=sqlite swaps in its own users/tokens/workouts, memory backs every store with one memstore.DB
+func newStores(dbDriver string,pgDb *sql.DB) (*Stores,*memstore.DB,error) {
+	stores := &Stores{}
+	stores.Workouts = store.NewPostgresWorkoutStore(pgDb)
+	//* WORKOUT_STORE_DRIVER=pgxpool --> native pgx pool with batched entry writes for the workout hot path
+	switch driver := utils.GetEnv("WORKOUT_STORE_DRIVER","sql"); driver {
+	case "sql":
+	case "pgxpool":
+		if dbDriver != "postgres" {
+			return nil,nil,fmt.Errorf("WORKOUT_STORE_DRIVER=pgxpool needs DB_DRIVER=postgres")
+		}
+		pgxPool,err := store.OpenPool(context.Background())
+		if err != nil {
+			return nil,nil,err
+		}
+		stores.Workouts = store.NewPgxWorkoutStore(pgxPool)
+	default:
+		return nil,nil,fmt.Errorf("WORKOUT_STORE_DRIVER must be sql or pgxpool, got %q",driver)
+	}
+	stores.Users = store.NewPostUserStore(pgDb)
+	stores.Tokens = store.NewPostgresTokenStore(pgDb)
+	if dbDriver == "sqlite" {
+		stores.Workouts = store.NewSQLiteWorkoutStore(pgDb)
+		stores.Users = store.NewSQLiteUserStore(pgDb)
+		stores.Tokens = store.NewSQLiteTokenStore(pgDb)
+	}
+	stores.Orgs = store.NewPostgresOrgStore(pgDb)
+	stores.Profiles = store.NewPostgresProfileStore(pgDb)
+	stores.Exports = store.NewPostgresExportStore(pgDb)
+	stores.Shares = store.NewPostgresShareStore(pgDb)
+	stores.Follows = store.NewPostgresFollowStore(pgDb)
+	stores.Comments = store.NewPostgresCommentStore(pgDb)
+	stores.Goals = store.NewPostgresGoalStore(pgDb)
+	stores.Verifications = store.NewPostgresVerificationStore(pgDb)
+	stores.Achievements = store.NewPostgresAchievementStore(pgDb)
+	stores.XP = store.NewPostgresXPStore(pgDb)
+	stores.Schedules = store.NewPostgresScheduleStore(pgDb)
+	stores.Jobs = store.NewPostgresJobStore(pgDb)
+	stores.SeasonalEvents = store.NewPostgresSeasonalEventStore(pgDb)
+	stores.Experiments = store.NewPostgresExperimentStore(pgDb)
+	stores.ClientUsage = store.NewPostgresClientUsageStore(pgDb)
+	stores.Integrations = store.NewPostgresIntegrationStore(pgDb)
+	stores.Accounts = store.NewPostgresAccountStore(pgDb)
+	stores.Webhooks = store.NewPostgresWebhookStore(pgDb)
+	stores.Automations = store.NewPostgresAutomationStore(pgDb)
+	stores.Reminders = store.NewPostgresReminderStore(pgDb)
+	stores.TrainingLoad = store.NewPostgresTrainingLoadStore(pgDb)
+	stores.Audit = store.NewPostgresAuditStore(pgDb)
+	stores.LoginLockouts = store.NewPostgresLoginLockoutStore(pgDb)
+	stores.TwoFactor = store.NewPostgresTwoFactorStore(pgDb)
+	stores.LinkedAccounts = store.NewPostgresLinkedAccountStore(pgDb)
+	stores.Sessions = store.NewPostgresSessionStore(pgDb)
+	stores.Tags = store.NewPostgresTagStore(pgDb)
+	stores.Photos = store.NewPostgresPhotoStore(pgDb)
+	stores.Avatars = store.NewPostgresAvatarStore(pgDb)
+	stores.Leaderboards = store.NewPostgresLeaderboardStore(pgDb)
+	stores.Challenges = store.NewPostgresChallengeStore(pgDb)
+	stores.Notifications = store.NewPostgresNotificationStore(pgDb)
+	stores.Digests = store.NewPostgresDigestStore(pgDb)
+	stores.Programs = store.NewPostgresProgramStore(pgDb)
+	if dbDriver == "sqlite" {
+		stores.LoginLockouts = memstore.NewLoginLockoutStore(memstore.New()) //* login works on sqlite, its lockouts just don't survive restarts
+		stores.TwoFactor = nil //* 2FA needs postgres (or memory), logins on sqlite stay single-factor
+		stores.LinkedAccounts = nil //* social login needs postgres (or memory)
+		stores.Sessions = nil //* the sqlite tokens table has no session columns, GET /users/me/sessions answers 501
+		stores.Tags = nil //* no workout_tags table on sqlite, the tag routes answer 501
+		stores.Photos = nil //* no workout_photos table on sqlite, the photo routes answer 501
+		stores.Avatars = nil //* no user_profiles table on sqlite, the avatar routes answer 501
+		stores.Leaderboards = nil //* no leaderboard_stats view on sqlite, GET /leaderboards/{metric} answers 501
+		stores.Challenges = nil //* no challenge tables on sqlite, the challenge routes answer 501
+		stores.Notifications = nil //* no devices table on sqlite, the device routes answer 501 and nothing is pushed
+		stores.Digests = nil //* no notification preferences on sqlite, no weekly digest either
+		stores.Programs = nil //* no program or schedule tables on sqlite, the program routes answer 501
+	}
+	stores.Admin = store.NewPostgresAdminStore(pgDb)
+	stores.Warehouse = store.NewPostgresWarehouseStore(pgDb)
+	stores.Shadow = store.NewPostgresShadowStore(pgDb)
+	stores.Graph = store.NewPostgresGraphStore(pgDb)
+	stores.Sandbox = store.NewPostgresSandboxStore(pgDb)
+	stores.UserUsage = store.NewPostgresUserUsageStore(pgDb)
+	stores.Sync = store.NewPostgresSyncStore(pgDb)
+	stores.Outbox = store.NewPostgresOutboxStore(pgDb)
+	if dbDriver == "sqlite" {
+		stores.Sync = store.NewSQLiteSyncStore(pgDb) //* kept by the triggers in the sqlite schema
+		stores.Outbox = store.NewSQLiteOutboxStore(pgDb)
+	}
+
+	//! DB_DRIVER=memory --> every store on one in-memory DB, -demo seeds it, zero external dependencies
+	var memDB *memstore.DB
+	if dbDriver == "memory" {
+		memDB = memstore.New()
+		stores.Workouts = memstore.NewWorkoutStore(memDB)
+		stores.Users = memstore.NewUserStore(memDB)
+		stores.Tokens = memstore.NewTokenStore(memDB)
+		stores.Orgs = memstore.NewOrgStore(memDB)
+		stores.Profiles = memstore.NewProfileStore(memDB)
+		stores.Exports = memstore.NewExportStore(memDB)
+		stores.Shares = memstore.NewShareStore(memDB)
+		stores.Follows = memstore.NewFollowStore(memDB)
+		stores.Comments = memstore.NewCommentStore(memDB)
+		stores.Goals = memstore.NewGoalStore(memDB)
+		stores.Verifications = memstore.NewVerificationStore(memDB)
+		stores.Achievements = memstore.NewAchievementStore(memDB)
+		stores.XP = memstore.NewXPStore(memDB)
+		stores.Schedules = memstore.NewScheduleStore(memDB)
+		stores.Jobs = memstore.NewJobStore(memDB)
+		stores.SeasonalEvents = memstore.NewSeasonalEventStore(memDB)
+		stores.Experiments = memstore.NewExperimentStore(memDB)
+		stores.ClientUsage = memstore.NewClientUsageStore(memDB)
+		stores.Integrations = memstore.NewIntegrationStore(memDB)
+		stores.Accounts = memstore.NewAccountStore(memDB)
+		stores.Webhooks = memstore.NewWebhookStore(memDB)
+		stores.Automations = memstore.NewAutomationStore(memDB)
+		stores.Reminders = memstore.NewReminderStore(memDB)
+		stores.TrainingLoad = memstore.NewTrainingLoadStore(memDB)
+		stores.Audit = memstore.NewAuditStore(memDB)
+		stores.LoginLockouts = memstore.NewLoginLockoutStore(memDB)
+		stores.TwoFactor = memstore.NewTwoFactorStore(memDB)
+		stores.LinkedAccounts = memstore.NewLinkedAccountStore(memDB)
+		stores.Sessions = memstore.NewSessionStore(memDB)
+		stores.Tags = memstore.NewTagStore(memDB)
+		stores.Photos = memstore.NewPhotoStore(memDB)
+		stores.Avatars = memstore.NewAvatarStore(memDB)
+		stores.Admin = memstore.NewAdminStore(memDB)
+		stores.Warehouse = memstore.NewWarehouseStore(memDB)
+		stores.Shadow = memstore.NewShadowStore(memDB)
+		stores.Graph = memstore.NewGraphStore(memDB)
+		stores.Sandbox = memstore.NewSandboxStore(memDB)
+		stores.UserUsage = memstore.NewUserUsageStore(memDB)
+		stores.Sync = memstore.NewSyncStore(memDB)
+		stores.Outbox = memstore.NewOutboxStore(memDB)
+		stores.Leaderboards = memstore.NewLeaderboardStore(memDB)
+		stores.Challenges = memstore.NewChallengeStore(memDB)
+		stores.Notifications = memstore.NewNotificationStore(memDB)
+		stores.Digests = memstore.NewDigestStore(memDB)
+		stores.Programs = memstore.NewProgramStore(memDB)
+	}
+	return stores,memDB,nil
+}
@@ -0,0 +1,159 @@
+// ! package audit --> records security-relevant events with who, from where and when
+// ? transports put the caller's IP + user agent on the context (Capture for HTTP, the gRPC interceptor),
+// ? so services can record without knowing which transport they're serving
+package audit
+
+import (
+	"context"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"log"
+	"net"
+	"net/http"
+)
+
+// ! recorded actions
+const (
+	Login             = "auth.login"
+	LoginFailed       = "auth.login_failed"
+	LoginLocked       = "auth.login_locked"        //* a username or IP hit its failure limit, metadata has kind, key + until
+	LoginUnlocked     = "auth.login_unlocked"      //* an admin lifted a lockout early
+	Logout            = "auth.logout"              //* a token was revoked by its owner, metadata has session_id when it wasn't the current one
+	TokensRevoked     = "auth.tokens_revoked"      //* a user or an admin signed the user out everywhere
+	PasswordChanged   = "user.password_changed"    //* target is the user, actor is whoever changed it
+	TwoFactorEnabled  = "user.two_factor_enabled"  //* TOTP confirmed, backup codes issued
+	TwoFactorDisabled = "user.two_factor_disabled" //* the user turned it off with a current code
+	AdminAction       = "admin.action"             //* any other admin write, metadata has method, route + status
+)
+
+// * maxUserAgent --> client controlled, capped so one request can't bloat the log
+const maxUserAgent = 512
+
+// ! Client --> where a request came from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+type recordedKey struct{}
+
+// ! WithClient --> ctx carrying the caller's address for every Record below it
+func WithClient(ctx context.Context, client Client) context.Context {
+	if len(client.UserAgent) > maxUserAgent {
+		client.UserAgent = client.UserAgent[:maxUserAgent]
+	}
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ! ClientFrom --> zero Client when nothing was captured (jobs, tests)
+func ClientFrom(ctx context.Context) Client {
+	client, _ := ctx.Value(clientKey{}).(Client)
+	return client
+}
+
+// ! RemoteIP --> host part of RemoteAddr
+func RemoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// ! Capture --> root middleware, puts the HTTP caller on the request context
+// ? runs after the client IP stage, so entries behind a trusted proxy get the forwarded address
+func Capture(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithClient(r.Context(), Client{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ! Recorder --> writes entries to the audit log, a nil Recorder records nothing
+type Recorder struct {
+	Store  store.AuditStore
+	Logger *log.Logger
+}
+
+// ! NewRecorder --> constructor for the audit recorder
+func NewRecorder(auditStore store.AuditStore, logger *log.Logger) *Recorder {
+	return &Recorder{Store: auditStore, Logger: logger}
+}
+
+// ! Record --> fills in the client from ctx and saves the entry
+// ? a failed write is logged, never returned: the audited action already happened
+func (r *Recorder) Record(ctx context.Context, entry store.AuditEntry) {
+	if r == nil {
+		return
+	}
+	client := ClientFrom(ctx)
+	entry.IP, entry.UserAgent = client.IP, client.UserAgent
+	if recorded, ok := ctx.Value(recordedKey{}).(*bool); ok {
+		*recorded = true
+	}
+
+	err := r.Store.RecordAudit(&entry)
+	if err != nil {
+		r.Logger.Printf("ERROR: audit %s: %v", entry.Action, err)
+	}
+}
+
+// ! UserID --> pointer for AuditEntry.ActorID / TargetID
+func UserID(id int) *int {
+	return &id
+}
+
+// ! AdminActions --> admin group middleware, records every write that didn't record something more specific
+// ? runs inside the user pipeline so the actor is known, rejected (403) attempts are recorded too
+func (r *Recorder) AdminActions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r == nil || req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		recorded := false
+		req = req.WithContext(context.WithValue(req.Context(), recordedKey{}, &recorded))
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, req)
+		if recorded {
+			return
+		}
+
+		entry := store.AuditEntry{Action: AdminAction, Metadata: map[string]any{
+			"method": req.Method,
+			"path":   req.URL.Path,
+			"status": sw.status,
+		}}
+		if user := middleware.GetUser(req); !user.IsAnonymousUser() {
+			entry.ActorID = UserID(user.ID)
+		}
+		r.Record(req.Context(), entry)
+	})
+}
+
+// * statusWriter --> remembers the status for the admin action entry
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status, w.wrote = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+// * Unwrap --> http.ResponseController reaches the real writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
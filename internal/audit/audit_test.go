@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"fem/internal/memstore"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestAdminActions --> writes get a generic entry unless the handler recorded its own, reads get none
+func TestAdminActions(t *testing.T) {
+	auditStore := memstore.NewAuditStore(memstore.New())
+	recorder := NewRecorder(auditStore, log.New(io.Discard, "", 0))
+	admin := &store.User{ID: 7, IsAdmin: true}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /admin/flags", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("DELETE /admin/users/3/tokens", func(w http.ResponseWriter, r *http.Request) {
+		recorder.Record(r.Context(), store.AuditEntry{Action: TokensRevoked, ActorID: UserID(7), TargetID: UserID(3)})
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /admin/flags", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Capture(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder.AdminActions(mux).ServeHTTP(w, middleware.SetUser(r, admin))
+	}))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/admin/flags", nil),
+		httptest.NewRequest(http.MethodDelete, "/admin/users/3/tokens", nil),
+		httptest.NewRequest(http.MethodGet, "/admin/flags", nil),
+	} {
+		req.Header.Set("User-Agent", "admin-ui")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries, err := auditStore.ListAudit(store.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, TokensRevoked, entries[0].Action)
+	assert.Equal(t, AdminAction, entries[1].Action)
+	assert.Equal(t, 7, *entries[1].ActorID)
+	assert.Equal(t, http.StatusAccepted, entries[1].Metadata["status"])
+	assert.Equal(t, "192.0.2.1", entries[1].IP) //* httptest's RemoteAddr
+	assert.Equal(t, "admin-ui", entries[1].UserAgent)
+}
@@ -0,0 +1,109 @@
+package automation
+
+import (
+	"fem/internal/events"
+	"fem/internal/mailer"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"log"
+	"time"
+)
+
+// ! Engine --> evaluates users' automation rules when domain events arrive
+type Engine struct {
+	Store  store.AutomationStore
+	Users  store.UserStore //* email address for email actions
+	Jobs   worker.Enqueuer //* actions run as background jobs (email.send)
+	Logger *log.Logger
+}
+
+// ! NewEngine --> constructor for the automation engine
+func NewEngine(automationStore store.AutomationStore, userStore store.UserStore, jobs worker.Enqueuer, logger *log.Logger) *Engine {
+	return &Engine{Store: automationStore, Users: userStore, Jobs: jobs, Logger: logger}
+}
+
+// ! Subscribe --> every trigger a rule can use
+func (e *Engine) Subscribe(bus *events.Bus) {
+	bus.Subscribe(e.HandleEvent, Triggers...)
+}
+
+// ! HandleEvent --> events.Handler that evaluates the user's rules for the event type
+func (e *Engine) HandleEvent(event events.Event) error {
+	_, err := e.Evaluate(event.UserID, event.Type, event.At)
+	return err
+}
+
+// ! Evaluate --> fires every enabled rule whose condition holds, returns the rules that fired
+// ? a rule with a condition fires at most once per window, one without fires on every event
+func (e *Engine) Evaluate(userID int, trigger string, at time.Time) ([]*store.AutomationRule, error) {
+	rules, err := e.Store.ListEnabledRules(userID, trigger)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	fired := []*store.AutomationRule{}
+	statsBySince := map[time.Time]*store.AutomationStats{}
+	for _, rule := range rules {
+		window := "week"
+		if rule.Condition != nil {
+			window = rule.Condition.Window
+		}
+		since := WindowStart(window, at)
+
+		stats, ok := statsBySince[since]
+		if !ok {
+			stats, err = e.Store.GetAutomationStats(userID, since)
+			if err != nil {
+				return fired, err
+			}
+			statsBySince[since] = stats
+		}
+		if rule.Condition != nil && !Met(rule.Condition, stats) {
+			continue
+		}
+
+		notSince := since
+		if rule.Condition == nil {
+			notSince = at //* no window to dedupe on, only a replay of the same event is skipped
+		}
+		claimed, err := e.Store.MarkRuleFired(rule.ID, at, notSince)
+		if err != nil {
+			return fired, err
+		}
+		if !claimed {
+			continue
+		}
+
+		err = e.run(userID, rule, trigger, stats)
+		if err != nil {
+			return fired, err
+		}
+		e.Logger.Printf("automation rule %d fired for user %d on %s", rule.ID, userID, trigger)
+		fired = append(fired, rule)
+	}
+	return fired, nil
+}
+
+// ! run --> performs the rule's action
+func (e *Engine) run(userID int, rule *store.AutomationRule, trigger string, stats *store.AutomationStats) error {
+	switch rule.Action.Type {
+	case ActionEmail:
+		user, err := e.Users.GetUserByID(int64(userID))
+		if err != nil || user == nil {
+			return err
+		}
+		subject, message := rule.Action.Subject, rule.Action.Message
+		if subject == "" {
+			subject = DefaultSubject
+		}
+		if message == "" {
+			message = DefaultMessage
+		}
+		return e.Jobs.Enqueue(worker.JobSendEmail, mailer.Message{
+			To:      user.Email,
+			Subject: Render(subject, rule, trigger, stats),
+			Body:    Render(message, rule, trigger, stats),
+		})
+	}
+	return nil
+}
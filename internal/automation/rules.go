@@ -0,0 +1,131 @@
+package automation
+
+import (
+	"errors"
+	"fem/internal/events"
+	"fem/internal/store"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! rule vocabulary
+var (
+	Triggers = []string{events.WorkoutCreated, events.WorkoutUpdated, events.WorkoutDeleted, events.AchievementEarned}
+	Metrics  = []string{"workouts", "minutes", "calories"}
+	Windows  = []string{"day", "week", "month"}
+	Ops      = []string{">=", ">", "==", "<=", "<"}
+	Actions  = []string{ActionEmail}
+)
+
+// ! ActionEmail --> email the rule owner, Subject/Message are templates
+const ActionEmail = "email"
+
+// ! limits on user input
+const (
+	MaxRulesPerUser = 20
+	maxNameLength   = 100
+	maxSubject      = 200
+	maxMessage      = 2000
+)
+
+// ! default email, used when the rule leaves subject/message empty
+const (
+	DefaultSubject = "{{rule}}"
+	DefaultMessage = "Your rule \"{{rule}}\" fired.\n\nThis {{window}}: {{workouts}} workouts, {{minutes}} minutes, {{calories}} calories."
+)
+
+// ! Validate --> checks a rule from the API, error messages are safe to show
+func Validate(rule *store.AutomationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" || len(rule.Name) > maxNameLength {
+		return fmt.Errorf("name is required and must be at most %d characters", maxNameLength)
+	}
+	if !slices.Contains(Triggers, rule.Trigger) {
+		return fmt.Errorf("trigger must be one of %s", strings.Join(Triggers, ", "))
+	}
+
+	if c := rule.Condition; c != nil {
+		if !slices.Contains(Metrics, c.Metric) {
+			return fmt.Errorf("condition.metric must be one of %s", strings.Join(Metrics, ", "))
+		}
+		if !slices.Contains(Windows, c.Window) {
+			return fmt.Errorf("condition.window must be one of %s", strings.Join(Windows, ", "))
+		}
+		if !slices.Contains(Ops, c.Op) {
+			return fmt.Errorf("condition.op must be one of %s", strings.Join(Ops, " "))
+		}
+		if c.Value < 0 {
+			return errors.New("condition.value cannot be negative")
+		}
+	}
+
+	a := &rule.Action
+	if !slices.Contains(Actions, a.Type) {
+		return fmt.Errorf("action.type must be one of %s", strings.Join(Actions, ", "))
+	}
+	if len(a.Subject) > maxSubject || len(a.Message) > maxMessage {
+		return fmt.Errorf("action.subject and action.message are limited to %d and %d characters", maxSubject, maxMessage)
+	}
+	return nil
+}
+
+// ! WindowStart --> beginning of the window containing at, weeks start on Monday (UTC)
+func WindowStart(window string, at time.Time) time.Time {
+	at = at.UTC()
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	switch window {
+	case "week":
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// ! Met --> compares the condition's metric from stats against its value
+func Met(c *store.AutomationCondition, stats *store.AutomationStats) bool {
+	var actual float64
+	switch c.Metric {
+	case "workouts":
+		actual = float64(stats.Workouts)
+	case "minutes":
+		actual = float64(stats.Minutes)
+	case "calories":
+		actual = float64(stats.Calories)
+	}
+
+	switch c.Op {
+	case ">=":
+		return actual >= c.Value
+	case ">":
+		return actual > c.Value
+	case "==":
+		return actual == c.Value
+	case "<=":
+		return actual <= c.Value
+	case "<":
+		return actual < c.Value
+	}
+	return false
+}
+
+// ! Render --> fills {{rule}} {{event}} {{window}} {{workouts}} {{minutes}} {{calories}}, unknown placeholders stay as written
+func Render(template string, rule *store.AutomationRule, event string, stats *store.AutomationStats) string {
+	window := "week"
+	if rule.Condition != nil {
+		window = rule.Condition.Window
+	}
+	return strings.NewReplacer(
+		"{{rule}}", rule.Name,
+		"{{event}}", event,
+		"{{window}}", window,
+		"{{workouts}}", strconv.Itoa(stats.Workouts),
+		"{{minutes}}", strconv.Itoa(stats.Minutes),
+		"{{calories}}", strconv.Itoa(stats.Calories),
+	).Replace(template)
+}
@@ -0,0 +1,47 @@
+package automation
+
+import (
+	"fem/internal/store"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	rule := &store.AutomationRule{
+		Name:      " weekly summary ",
+		Trigger:   "workout.created",
+		Condition: &store.AutomationCondition{Metric: "workouts", Window: "week", Op: ">=", Value: 3},
+		Action:    store.AutomationAction{Type: ActionEmail},
+	}
+	assert.NoError(t, Validate(rule))
+	assert.Equal(t, "weekly summary", rule.Name)
+
+	rule.Condition.Op = "!="
+	assert.Error(t, Validate(rule))
+	rule.Condition = nil
+	rule.Trigger = "user.registered"
+	assert.Error(t, Validate(rule))
+}
+
+// ! TestWindowStart --> weeks start on Monday, a Monday is its own week start
+func TestWindowStart(t *testing.T) {
+	sunday := time.Date(2024, 3, 17, 22, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), WindowStart("week", sunday))
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), WindowStart("week", time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), WindowStart("month", sunday))
+	assert.Equal(t, time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC), WindowStart("day", sunday))
+}
+
+func TestMetAndRender(t *testing.T) {
+	stats := &store.AutomationStats{Workouts: 3, Minutes: 150, Calories: 900}
+	condition := &store.AutomationCondition{Metric: "workouts", Window: "week", Op: ">=", Value: 3}
+	assert.True(t, Met(condition, stats))
+	condition.Op = ">"
+	assert.False(t, Met(condition, stats))
+
+	rule := &store.AutomationRule{Name: "Summary", Condition: condition}
+	assert.Equal(t, "Summary: 3 workouts, 150 min this week {{nope}}",
+		Render("{{rule}}: {{workouts}} workouts, {{minutes}} min this {{window}} {{nope}}", rule, "workout.created", stats))
+}
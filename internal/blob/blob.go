@@ -0,0 +1,66 @@
+// Package blob keeps binary objects (workout photos) on local disk or in an S3 compatible bucket.
+// Keys are slash separated paths like workouts/12/ab34.jpg, readers get time limited signed URLs.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fem/internal/utils"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ! ErrNotFound --> Open on a key that was never written (or already deleted)
+var ErrNotFound = errors.New("blob: not found")
+
+// ! Store --> where uploaded objects live, implementations must be safe for concurrent use
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	//* deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	//* every object whose key starts with prefix, e.g. workouts/12/
+	DeletePrefix(ctx context.Context, prefix string) error
+	//* a GET URL for key that stops working after ttl
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// ! ValidKey --> relative, no empty / dot segments, no backslashes, so a key can't escape the disk root
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// ! FromEnv --> BLOB_STORE=disk (default, under BLOB_DIR) or s3 (S3_BUCKET + credentials)
+// ? urlPrefix is the route disk URLs are signed for, S3 hands out presigned bucket URLs instead
+func FromEnv(urlPrefix string) (Store, error) {
+	switch kind := utils.GetEnv("BLOB_STORE", "disk"); kind {
+	case "disk":
+		return NewDisk(utils.GetEnv("BLOB_DIR", filepath.Join(os.TempDir(), "fittrack-blobs")), urlPrefix)
+	case "s3":
+		bucket := utils.GetEnv("S3_BUCKET", "")
+		if bucket == "" {
+			return nil, errors.New("blob: BLOB_STORE=s3 needs S3_BUCKET")
+		}
+		return NewS3(S3Config{
+			Bucket:          bucket,
+			Region:          utils.GetEnv("S3_REGION", "us-east-1"),
+			Endpoint:        utils.GetEnv("S3_ENDPOINT", ""),
+			AccessKeyID:     utils.GetEnv("S3_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+			SecretAccessKey: utils.GetEnv("S3_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		}), nil
+	default:
+		return nil, fmt.Errorf("blob: unknown BLOB_STORE %q (disk or s3)", kind)
+	}
+}
@@ -0,0 +1,101 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fem/internal/tokens"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ! Disk --> objects as files under Dir, served back through a signed route of this server
+type Disk struct {
+	Dir       string
+	URLPrefix string //* e.g. /v1/blobs, the key is appended and the whole path signed
+}
+
+// ! NewDisk --> creates dir if needed
+func NewDisk(dir, urlPrefix string) (*Disk, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, err
+	}
+	return &Disk{Dir: dir, URLPrefix: strings.TrimSuffix(urlPrefix, "/")}, nil
+}
+
+func (d *Disk) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("blob: invalid key %q", key)
+	}
+	return filepath.Join(d.Dir, filepath.FromSlash(key)), nil
+}
+
+// ! Put --> written to a temp file and renamed, readers never see half an object
+func (d *Disk) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //* no-op once renamed
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (d *Disk) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (d *Disk) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ! DeletePrefix --> prefix has to name a directory (end in /), partial file names aren't matched
+func (d *Disk) DeletePrefix(ctx context.Context, prefix string) error {
+	path, err := d.path(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+func (d *Disk) SignedURL(key string, ttl time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("blob: invalid key %q", key)
+	}
+	return tokens.SignURL(d.URLPrefix+"/"+key, ttl), nil
+}
@@ -0,0 +1,295 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! maxPresignTTL --> SigV4 query signatures can't outlive a week
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// ! S3Config --> bucket + credentials, Endpoint is only for S3 compatible stores (MinIO, R2, ...)
+type S3Config struct {
+	Bucket          string
+	Region          string
+	Endpoint        string //* e.g. http://localhost:9000, switches to path-style URLs
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// ! S3 --> Store on the S3 REST API, requests signed with AWS Signature Version 4
+type S3 struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// ! NewS3 --> store for one bucket
+func NewS3(config S3Config) *S3 {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	return &S3{config: config, client: &http.Client{Timeout: 30 * time.Second}, now: time.Now}
+}
+
+// * bucketURL --> path-style on a custom endpoint, virtual-hosted on AWS; path is the key with a leading /
+func (s *S3) bucketURL(path string) (*url.URL, error) {
+	if s.config.Endpoint == "" {
+		return &url.URL{Scheme: "https", Host: s.config.Bucket + ".s3." + s.config.Region + ".amazonaws.com", Path: path}, nil
+	}
+	u, err := url.Parse(s.config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("blob: S3_ENDPOINT: %w", err)
+	}
+	u.Path = u.Path + "/" + s.config.Bucket + path
+	return u, nil
+}
+
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("blob: invalid key %q", key)
+	}
+	u, err := s.bucketURL("/" + key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawPath = uriEncode(u.Path, false) //* sent exactly as it was signed
+	return u, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPut, u, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return expectStatus(resp, http.StatusOK)
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	resp, err := s.do(ctx, http.MethodGet, u, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	err = expectStatus(resp, http.StatusOK)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, u, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return expectStatus(resp, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// * listResult --> the parts of a ListObjectsV2 response DeletePrefix needs
+type listResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// ! DeletePrefix --> lists the prefix page by page and deletes object by object
+func (s *S3) DeletePrefix(ctx context.Context, prefix string) error {
+	token := ""
+	for {
+		u, err := s.bucketURL("/")
+		if err != nil {
+			return err
+		}
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u.RawQuery = canonicalQuery(query)
+
+		resp, err := s.do(ctx, http.MethodGet, u, nil, "")
+		if err != nil {
+			return err
+		}
+		var page listResult
+		err = expectStatus(resp, http.StatusOK)
+		if err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(&page)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, object := range page.Contents {
+			err = s.Delete(ctx, object.Key)
+			if err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// ! SignedURL --> presigned GET, the bucket itself checks the signature
+func (s *S3) SignedURL(key string, ttl time.Duration) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	amzDate, scope := s.scope(s.now())
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.config.AccessKeyID + "/" + scope},
+		"X-Amz-Date":          {amzDate},
+		"X-Amz-Expires":       {strconv.Itoa(int(min(ttl, maxPresignTTL).Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	u.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + s.signature(amzDate, scope, canonical)
+	return u.String(), nil
+}
+
+// * do --> one signed request, the payload is hashed in full (objects here are photo sized)
+func (s *S3) do(ctx context.Context, method string, u *url.URL, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	amzDate, scope := s.scope(s.now())
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	//* canonical headers: lowercase names, sorted, host always included
+	signed := map[string]string{"host": u.Host, "x-amz-date": amzDate, "x-amz-content-sha256": payloadHash}
+	if contentType != "" {
+		signed["content-type"] = contentType
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + strings.TrimSpace(signed[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{method, u.EscapedPath(), u.RawQuery, headers.String(), signedHeaders, payloadHash}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, s.signature(amzDate, scope, canonical)))
+	return s.client.Do(req)
+}
+
+// * scope --> X-Amz-Date and the credential scope for t
+func (s *S3) scope(t time.Time) (string, string) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	return amzDate, amzDate[:8] + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// * signature --> the SigV4 signature of a canonical request, key derived per day + region
+func (s *S3) signature(amzDate, scope, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := []byte("AWS4" + s.config.SecretAccessKey)
+	for _, part := range []string{amzDate[:8], s.config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// * uriEncode --> RFC 3986 encoding SigV4 expects, slashes kept in paths
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// * canonicalQuery --> sorted by name, names and values uriEncoded
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{}
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// * expectStatus --> error with the start of S3's XML error body for any other status
+func expectStatus(resp *http.Response, statuses ...int) error {
+	for _, status := range statuses {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("blob: s3 %s %s: status %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, bytes.TrimSpace(body))
+}
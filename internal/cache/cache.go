@@ -0,0 +1,41 @@
+// ! package cache --> cache-aside for hot reads, in process (Memory) or shared between instances (Redis)
+// ? values are opaque bytes, callers pick the encoding; a cache failure is a miss, never a failed request
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// ! Cache --> Get reports found=false for missing and expired keys
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// ! getJSON --> false on a miss or an entry that no longer decodes (e.g. written by an older build)
+func getJSON(ctx context.Context, c Cache, key string, v any) (bool, error) {
+	raw, found, err := c.Get(ctx, key)
+	if err != nil || !found {
+		return false, err
+	}
+	return json.Unmarshal(raw, v) == nil, nil
+}
+
+func setJSON(ctx context.Context, c Cache, key string, v any, ttl time.Duration) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, raw, ttl)
+}
+
+func newGeneration() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"fem/internal/store"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+	m.Set(ctx, "a", []byte("1"), time.Minute)
+	m.Set(ctx, "b", []byte("2"), time.Minute)
+	m.Get(ctx, "a") //* b is now the oldest
+	m.Set(ctx, "c", []byte("3"), time.Minute)
+
+	_, found, _ := m.Get(ctx, "b")
+	assert.False(t, found)
+	value, found, _ := m.Get(ctx, "a")
+	assert.True(t, found)
+	assert.Equal(t, "1", string(value))
+	assert.Equal(t, 2, m.Len())
+}
+
+func TestMemoryExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	m := NewMemory(10)
+	m.now = func() time.Time { return now }
+	m.Set(ctx, "a", []byte("1"), time.Second)
+
+	now = now.Add(2 * time.Second)
+	_, found, _ := m.Get(ctx, "a")
+	assert.False(t, found)
+}
+
+type countingUserStore struct {
+	store.UserStore
+	user    *store.User
+	lookups atomic.Int64
+	release chan struct{} //* when set, lookups block until it's closed
+}
+
+func (s *countingUserStore) GetUserToken(scope string, plaintext string) (*store.User, error) {
+	s.lookups.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	user := *s.user
+	return &user, nil
+}
+
+func TestUserStoreInvalidate(t *testing.T) {
+	next := &countingUserStore{user: &store.User{ID: 7, Username: "sam"}}
+	next.user.PasswordHash.SetHash([]byte("bcrypt"))
+	users := NewUserStore(next, NewMemory(10), time.Minute, log.New(io.Discard, "", 0))
+
+	for range 3 {
+		user, err := users.GetUserToken("authentication", "secret")
+		require.NoError(t, err)
+		assert.Equal(t, "sam", user.Username)
+		assert.Equal(t, []byte("bcrypt"), user.PasswordHash.Hash())
+	}
+	assert.Equal(t, int64(1), next.lookups.Load())
+
+	users.Invalidate(7)
+	users.GetUserToken("authentication", "secret")
+	assert.Equal(t, int64(2), next.lookups.Load())
+	assert.Equal(t, TokenStats{Hits: 2, Misses: 2, HitRate: 0.5}, users.Stats())
+}
+
+type stubSessionStore struct {
+	store.SessionStore
+}
+
+func (s *stubSessionStore) DeleteSession(userID int, id int64) error {
+	return nil
+}
+
+// ! TestSessionStoreRevokeInvalidates --> a revoked session stops authenticating before the TTL is up
+func TestSessionStoreRevokeInvalidates(t *testing.T) {
+	next := &countingUserStore{user: &store.User{ID: 7, Username: "sam"}}
+	users := NewUserStore(next, NewMemory(10), time.Minute, log.New(io.Discard, "", 0))
+	sessions := NewSessionStore(&stubSessionStore{}, users)
+
+	users.GetUserToken("authentication", "secret")
+	users.GetUserToken("authentication", "secret")
+	assert.Equal(t, int64(1), next.lookups.Load())
+
+	require.NoError(t, sessions.DeleteSession(7, 3))
+	users.GetUserToken("authentication", "secret")
+	assert.Equal(t, int64(2), next.lookups.Load(), "looked up again after the revoke")
+}
+
+func TestUserStoreSharesConcurrentMisses(t *testing.T) {
+	next := &countingUserStore{user: &store.User{ID: 7}, release: make(chan struct{})}
+	users := NewUserStore(next, NewMemory(10), time.Minute, log.New(io.Discard, "", 0))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := users.GetUserToken("authentication", "secret")
+			assert.NoError(t, err)
+			assert.Equal(t, 7, user.ID)
+		}()
+	}
+	//* give every goroutine time to join the in-flight lookup
+	require.Eventually(t, func() bool { return next.lookups.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), next.lookups.Load())
+}
@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fem/internal/store"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+func workoutKey(id int64) string {
+	return "workout:" + strconv.FormatInt(id, 10)
+}
+
+// ! WorkoutStore --> caches GetWorkoutByID, drops the entry on every write that goes through it
+// ? other writers to the workouts table (verification) need their own invalidation, see VerificationStore
+type WorkoutStore struct {
+	store.WorkoutStore
+	cache  Cache
+	ttl    time.Duration
+	logger *log.Logger
+}
+
+func NewWorkoutStore(next store.WorkoutStore, cache Cache, ttl time.Duration, logger *log.Logger) *WorkoutStore {
+	return &WorkoutStore{WorkoutStore: next, cache: cache, ttl: ttl, logger: logger}
+}
+
+func (s *WorkoutStore) GetWorkoutByID(id int64) (*store.Workout, error) {
+	ctx := context.Background()
+	workout := &store.Workout{}
+	hit, err := getJSON(ctx, s.cache, workoutKey(id), workout)
+	if err != nil {
+		s.logger.Printf("ERROR: cache get workout %d: %v", id, err)
+	}
+	if hit {
+		return workout, nil
+	}
+
+	workout, err = s.WorkoutStore.GetWorkoutByID(id)
+	if err != nil {
+		return nil, err //* misses aren't cached, the workout may be created any moment
+	}
+	err = setJSON(ctx, s.cache, workoutKey(id), workout, s.ttl)
+	if err != nil {
+		s.logger.Printf("ERROR: cache set workout %d: %v", id, err)
+	}
+	return workout, nil
+}
+
+func (s *WorkoutStore) UpdateWorkout(workout *store.Workout) error {
+	defer s.invalidate(int64(workout.ID))
+	return s.WorkoutStore.UpdateWorkout(workout)
+}
+
+func (s *WorkoutStore) DeleteWorkout(id int64) error {
+	defer s.invalidate(id)
+	return s.WorkoutStore.DeleteWorkout(id)
+}
+
+func (s *WorkoutStore) BatchWorkouts(ops []store.WorkoutOp, atomic bool) ([]error, error) {
+	defer func() {
+		for _, op := range ops {
+			if op.Op != store.WorkoutOpCreate {
+				s.invalidate(int64(op.Workout.ID))
+			}
+		}
+	}()
+	return s.WorkoutStore.BatchWorkouts(ops, atomic)
+}
+
+// ! invalidate --> runs after the write, failed writes included (a half applied transaction may still have changed nothing, dropping is always safe)
+func (s *WorkoutStore) invalidate(id int64) {
+	err := s.cache.Delete(context.Background(), workoutKey(id))
+	if err != nil {
+		s.logger.Printf("ERROR: cache invalidate workout %d: %v", id, err)
+	}
+}
+
+// ! VerificationStore --> SaveVerification flips workouts.verified, so the cached workout has to go
+type VerificationStore struct {
+	store.VerificationStore
+	workouts *WorkoutStore
+}
+
+func NewVerificationStore(next store.VerificationStore, workouts *WorkoutStore) *VerificationStore {
+	return &VerificationStore{VerificationStore: next, workouts: workouts}
+}
+
+func (s *VerificationStore) SaveVerification(v *store.WorkoutVerification) error {
+	defer s.workouts.invalidate(int64(v.WorkoutID))
+	return s.VerificationStore.SaveVerification(v)
+}
+
+// ! cachedUser --> store.User hides the password hash from JSON, it travels next to it instead
+type cachedUser struct {
+	User         *store.User `json:"user"`
+	PasswordHash []byte      `json:"password_hash"`
+	Generation   string      `json:"generation"`
+}
+
+func userGenerationKey(userID int) string {
+	return "user-gen:" + strconv.Itoa(userID)
+}
+
+// ! tokenKey --> keyed by the token's hash, plaintext tokens never reach the cache
+func tokenKey(scope, plaintext string) string {
+	hash := sha256.Sum256([]byte(plaintext))
+	return "token:" + scope + ":" + hex.EncodeToString(hash[:])
+}
+
+// ! UserStore --> caches the user-by-token lookup the auth middleware does on every request
+// ? entries carry the user's generation, bumping it (profile edit, account deletion, token revocation) orphans all of them at once
+// ? expiry isn't re-checked on a hit, so a token can outlive its expiry by up to ttl
+type UserStore struct {
+	store.UserStore
+	cache  Cache
+	ttl    time.Duration
+	logger *log.Logger
+	group  singleflight.Group //* concurrent misses for one token share a single db lookup
+	hits   atomic.Int64
+	misses atomic.Int64
+	shared atomic.Int64
+}
+
+func NewUserStore(next store.UserStore, cache Cache, ttl time.Duration, logger *log.Logger) *UserStore {
+	return &UserStore{UserStore: next, cache: cache, ttl: ttl, logger: logger}
+}
+
+// ! TokenStats --> token lookups since start, shared counts misses that piggybacked on another request's lookup
+type TokenStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	Shared  int64   `json:"shared"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+func (s *UserStore) Stats() TokenStats {
+	stats := TokenStats{Hits: s.hits.Load(), Misses: s.misses.Load(), Shared: s.shared.Load()}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// ! GetUserToken --> every caller gets its own copy, handlers mutate the user they're given
+func (s *UserStore) GetUserToken(scope string, plaintext string) (*store.User, error) {
+	ctx := context.Background()
+	key := tokenKey(scope, plaintext)
+
+	var entry cachedUser
+	hit, err := getJSON(ctx, s.cache, key, &entry)
+	if err == nil && hit && entry.User != nil {
+		generation, found, genErr := s.cache.Get(ctx, userGenerationKey(entry.User.ID))
+		err = genErr
+		if found && string(generation) == entry.Generation {
+			s.hits.Add(1)
+			entry.User.PasswordHash.SetHash(entry.PasswordHash)
+			return entry.User, nil
+		}
+	}
+	if err != nil {
+		s.logger.Printf("ERROR: cache get token: %v", err)
+	}
+
+	s.misses.Add(1)
+	result, err, shared := s.group.Do(key, func() (any, error) {
+		return s.lookup(ctx, key, scope, plaintext)
+	})
+	if shared {
+		s.shared.Add(1)
+	}
+	if err != nil || result.(*store.User) == nil {
+		return nil, err
+	}
+	user := *result.(*store.User)
+	return &user, nil
+}
+
+// ! lookup --> the db read on a miss, stored for the next request
+func (s *UserStore) lookup(ctx context.Context, key, scope, plaintext string) (*store.User, error) {
+	user, err := s.UserStore.GetUserToken(scope, plaintext)
+	if err != nil || user == nil {
+		return user, err
+	}
+
+	generation, err := s.generation(ctx, user.ID)
+	if err == nil {
+		err = setJSON(ctx, s.cache, key, cachedUser{User: user, PasswordHash: user.PasswordHash.Hash(), Generation: generation}, s.ttl)
+	}
+	if err != nil {
+		s.logger.Printf("ERROR: cache set token: %v", err)
+	}
+	return user, nil
+}
+
+// ! generation --> the user's current generation, a fresh one when it was invalidated or evicted
+func (s *UserStore) generation(ctx context.Context, userID int) (string, error) {
+	raw, found, err := s.cache.Get(ctx, userGenerationKey(userID))
+	if err != nil {
+		return "", err
+	}
+	if found {
+		return string(raw), nil
+	}
+	generation := newGeneration()
+	//* outlives every token entry pointing at it, evicting it early only costs a miss
+	return generation, s.cache.Set(ctx, userGenerationKey(userID), []byte(generation), 2*s.ttl)
+}
+
+func (s *UserStore) UpdateUser(user *store.User) error {
+	defer s.Invalidate(user.ID)
+	return s.UserStore.UpdateUser(user)
+}
+
+func (s *UserStore) UpdatePassword(user *store.User) error {
+	defer s.Invalidate(user.ID)
+	return s.UserStore.UpdatePassword(user)
+}
+
+func (s *UserStore) DeleteAccount(userID int64) (time.Time, error) {
+	defer s.Invalidate(int(userID))
+	return s.UserStore.DeleteAccount(userID)
+}
+
+// ! Invalidate --> every cached token lookup for userID becomes a miss
+func (s *UserStore) Invalidate(userID int) {
+	err := s.cache.Delete(context.Background(), userGenerationKey(userID))
+	if err != nil {
+		s.logger.Printf("ERROR: cache invalidate user %d: %v", userID, err)
+	}
+}
+
+func (s *UserStore) forget(scope, plaintext string) {
+	err := s.cache.Delete(context.Background(), tokenKey(scope, plaintext))
+	if err != nil {
+		s.logger.Printf("ERROR: cache invalidate token: %v", err)
+	}
+}
+
+// ! TokenStore --> revoking tokens has to reach the cached token lookups too
+type TokenStore struct {
+	store.TokenStore
+	users *UserStore
+}
+
+func NewTokenStore(next store.TokenStore, users *UserStore) *TokenStore {
+	return &TokenStore{TokenStore: next, users: users}
+}
+
+// ! DeleteToken --> logout, only this token's entry goes
+func (s *TokenStore) DeleteToken(scope string, plaintext string) error {
+	defer s.users.forget(scope, plaintext)
+	return s.TokenStore.DeleteToken(scope, plaintext)
+}
+
+func (s *TokenStore) DeleteAllTokensForUser(userID int, scope string) error {
+	defer s.users.Invalidate(userID)
+	return s.TokenStore.DeleteAllTokensForUser(userID, scope)
+}
+
+// ! SessionStore --> revoking one session from the list must reach the cached token lookups too
+type SessionStore struct {
+	store.SessionStore
+	users *UserStore
+}
+
+func NewSessionStore(next store.SessionStore, users *UserStore) *SessionStore {
+	return &SessionStore{SessionStore: next, users: users}
+}
+
+// ! DeleteSession --> only the session id is known here, not its plaintext, so all of userID's entries go
+func (s *SessionStore) DeleteSession(userID int, id int64) error {
+	defer s.users.Invalidate(userID)
+	return s.SessionStore.DeleteSession(userID, id)
+}
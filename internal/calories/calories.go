@@ -0,0 +1,109 @@
+package calories
+
+import (
+	"fem/internal/store"
+	"math"
+	"strings"
+)
+
+// ! fallbacks used when we know nothing about the exercise or the user
+const (
+	DefaultMET      = 5.0  //* moderate general exercise
+	DefaultWeightKG = 70.0 //* reference adult body weight used by most MET tables
+	secondsPerRep   = 4    //* rough time under tension per rep, used when only reps are logged
+	restPerSetSecs  = 60   //* rest between sets counts toward elapsed time
+)
+
+// ! metValues --> MET (metabolic equivalent) per exercise, from the Compendium of Physical Activities
+// ? keys are normalized with normalize() so "Bench Press" and "bench-press" hit the same row
+var metValues = map[string]float64{
+	"running":           9.8,
+	"jogging":           7.0,
+	"walking":           3.5,
+	"hiking":            6.0,
+	"cycling":           7.5,
+	"swimming":          8.0,
+	"rowing":            7.0,
+	"jump rope":         12.3,
+	"elliptical":        5.0,
+	"stair climber":     9.0,
+	"yoga":              2.5,
+	"pilates":           3.0,
+	"stretching":        2.3,
+	"plank":             3.8,
+	"push ups":          8.0,
+	"pull ups":          8.0,
+	"burpees":           8.0,
+	"lunges":            4.0,
+	"squats":            5.0,
+	"bench press":       6.0,
+	"deadlift":          6.0,
+	"overhead press":    6.0,
+	"kettlebell swings": 9.8,
+	"hiit":              8.0,
+}
+
+// ! normalize --> lowercases, turns dashes/underscores into spaces and collapses whitespace
+func normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// ! METFor --> MET value for an exercise name, DefaultMET when we don't know it
+func METFor(exercise string) float64 {
+	name := normalize(exercise)
+	if met, ok := metValues[name]; ok {
+		return met
+	}
+	//* "squat" / "squats", "push up" / "push ups"
+	if met, ok := metValues[name+"s"]; ok {
+		return met
+	}
+	return DefaultMET
+}
+
+// ! kcal --> standard formula: kcal = MET * body weight (kg) * hours
+func kcal(met, weightKG, seconds float64) float64 {
+	return met * weightKG * seconds / 3600
+}
+
+// ! entrySeconds --> how long an entry took, estimated from sets/reps when no duration is logged
+func entrySeconds(entry store.WorkoutEntry) float64 {
+	sets := max(entry.Sets, 1)
+	if entry.DurationSeconds != nil {
+		return float64(*entry.DurationSeconds * sets)
+	}
+	if entry.Reps != nil {
+		return float64(sets*(*entry.Reps)*secondsPerRep + (sets-1)*restPerSetSecs)
+	}
+	return 0
+}
+
+// ! EstimateWorkout --> calories for a whole workout
+// ! each entry contributes with its own MET, leftover workout time counts as light activity
+func EstimateWorkout(workout *store.Workout, weightKG float64) int {
+	if weightKG <= 0 {
+		weightKG = DefaultWeightKG
+	}
+
+	total := 0.0
+	covered := 0.0
+	for _, entry := range workout.Entries {
+		seconds := entrySeconds(entry)
+		total += kcal(METFor(entry.ExerciseName), weightKG, seconds)
+		covered += seconds
+	}
+
+	//? warm-ups, transitions, anything the entries don't account for
+	remaining := float64(workout.DurationMinutes*60) - covered
+	if remaining > 0 {
+		met := DefaultMET
+		if len(workout.Entries) > 0 {
+			met = 3.0 //* light activity between sets
+		}
+		total += kcal(met, weightKG, remaining)
+	}
+
+	return int(math.Round(total))
+}
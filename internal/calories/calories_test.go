@@ -0,0 +1,75 @@
+package calories
+
+import (
+	"fem/internal/store"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ! TestMETFor --> name normalization and fallback
+func TestMETFor(t *testing.T) {
+	assert.Equal(t, 6.0, METFor("Bench Press"))
+	assert.Equal(t, 6.0, METFor("bench-press"))
+	assert.Equal(t, 5.0, METFor("squat")) // * singular still matches "squats"
+	assert.Equal(t, DefaultMET, METFor("underwater basket weaving"))
+}
+
+// ! TestEstimateWorkout --> table-driven checks of the MET formula
+func TestEstimateWorkout(t *testing.T) {
+	test := []struct {
+		name     string
+		workout  *store.Workout
+		weightKG float64
+		want     int
+	}{
+		{
+			// * 60 min of unknown activity --> 5.0 MET * 70kg * 1h
+			name:     "no entries uses default MET",
+			workout:  &store.Workout{DurationMinutes: 60},
+			weightKG: 70,
+			want:     350,
+		},
+		{
+			// ? - missing weight falls back to DefaultWeightKG
+			name:     "zero weight uses default",
+			workout:  &store.Workout{DurationMinutes: 60},
+			weightKG: 0,
+			want:     350,
+		},
+		{
+			// * 30 min running at 80kg --> 9.8 * 80 * 0.5
+			name: "timed entry covers whole workout",
+			workout: &store.Workout{
+				DurationMinutes: 30,
+				Entries: []store.WorkoutEntry{
+					{ExerciseName: "Running", Sets: 1, DurationSeconds: intPointer(1800)},
+				},
+			},
+			weightKG: 80,
+			want:     392,
+		},
+		{
+			// * 3x10 squats = 3*10*4s + 2*60s rest = 240s at 5.0 MET, remaining 360s at 3.0 MET
+			name: "rep based entry plus light activity",
+			workout: &store.Workout{
+				DurationMinutes: 10,
+				Entries: []store.WorkoutEntry{
+					{ExerciseName: "squats", Sets: 3, Reps: intPointer(10)},
+				},
+			},
+			weightKG: 60,
+			want:     38,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EstimateWorkout(tt.workout, tt.weightKG))
+		})
+	}
+}
+
+func intPointer(i int) *int {
+	return &i
+}
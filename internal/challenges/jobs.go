@@ -0,0 +1,51 @@
+package challenges
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/events"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"log"
+	"strconv"
+	"time"
+)
+
+// ! JobClose --> background job type that closes finished challenges
+const JobClose = "challenges.close"
+
+// ! Winners --> the rank 1 rows of final standings, nobody wins a challenge nobody scored in
+func Winners(standings []*store.EventStanding) []*store.EventStanding {
+	winners := []*store.EventStanding{}
+	for _, standing := range standings {
+		if standing.Rank == 1 && standing.Score > 0 {
+			winners = append(winners, standing)
+		}
+	}
+	return winners
+}
+
+// ! CloseJob --> freezes the results of every finished challenge and tells each participant it's over
+// ? closing is transactional per challenge, a retry only picks up challenges that are still open
+func CloseJob(challengeStore store.ChallengeStore, bus *events.Bus, logger *log.Logger) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		due, err := challengeStore.ListChallengesToClose(time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, challenge := range due {
+			results, err := challengeStore.CloseChallenge(int64(challenge.ID))
+			if err != nil {
+				return err
+			}
+			logger.Printf("challenge %d (%s) closed, %d participants, %d winners", challenge.ID, challenge.Title, len(results), len(Winners(results)))
+
+			ref := strconv.Itoa(challenge.ID)
+			for _, result := range results {
+				bus.Publish(events.Event{Type: events.ChallengeEnded, UserID: result.UserID, Ref: ref})
+			}
+		}
+		return nil
+	}
+}
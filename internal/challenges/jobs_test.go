@@ -0,0 +1,69 @@
+package challenges
+
+import (
+	"context"
+	"fem/internal/events"
+	"fem/internal/store"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryChallengeStore --> only what the close job touches
+type memoryChallengeStore struct {
+	store.ChallengeStore
+	due     []*store.Challenge
+	results map[int64][]*store.EventStanding
+	closed  []int64
+}
+
+func (s *memoryChallengeStore) ListChallengesToClose(now time.Time) ([]*store.Challenge, error) {
+	return s.due, nil
+}
+
+func (s *memoryChallengeStore) CloseChallenge(id int64) ([]*store.EventStanding, error) {
+	s.closed = append(s.closed, id)
+	return s.results[id], nil
+}
+
+func TestCloseJobNotifiesParticipants(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	challengeStore := &memoryChallengeStore{
+		due: []*store.Challenge{{ID: 5, Title: "Plank February"}, {ID: 6, Title: "Nobody joined"}},
+		results: map[int64][]*store.EventStanding{
+			5: {{Rank: 1, UserID: 10, Score: 90}, {Rank: 1, UserID: 11, Score: 90}, {Rank: 3, UserID: 12, Score: 0}},
+		},
+	}
+
+	bus := events.NewBus(logger)
+	var mu sync.Mutex
+	ended := []events.Event{}
+	bus.Subscribe(func(e events.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ended = append(ended, e)
+		return nil
+	}, events.ChallengeEnded)
+
+	err := CloseJob(challengeStore, bus, logger)(context.Background(), nil)
+	require.NoError(t, err)
+	bus.Wait()
+
+	assert.Equal(t, []int64{5, 6}, challengeStore.closed)
+	require.Len(t, ended, 3)
+	for _, e := range ended {
+		assert.Equal(t, "5", e.Ref)
+		assert.Contains(t, []int{10, 11, 12}, e.UserID)
+	}
+}
+
+func TestWinners(t *testing.T) {
+	tied := []*store.EventStanding{{Rank: 1, UserID: 1, Score: 5}, {Rank: 1, UserID: 2, Score: 5}, {Rank: 3, UserID: 3, Score: 1}}
+	assert.Len(t, Winners(tied), 2)
+	assert.Empty(t, Winners([]*store.EventStanding{{Rank: 1, UserID: 1, Score: 0}, {Rank: 1, UserID: 2, Score: 0}}))
+}
@@ -0,0 +1,153 @@
+package clientconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ! Platform --> release policy for one client (ios, android, ...)
+type Platform struct {
+	MinVersion    string `json:"min_version"`    //* older clients must upgrade before using the app
+	LatestVersion string `json:"latest_version"` //* older clients are nudged to upgrade
+	StoreURL      string `json:"store_url"`
+}
+
+// ! Config --> server-managed settings for mobile clients, loaded from CLIENT_CONFIG_FILE
+type Config struct {
+	Version    string              `json:"version"`     //* content hash, changes whenever anything in the file does
+	MinVersion string              `json:"min_version"` //* floor for platforms without their own policy
+	Features   map[string]bool     `json:"features"`
+	Platforms  map[string]Platform `json:"platforms"`
+	Settings   map[string]any      `json:"settings"`
+}
+
+// ! Upgrade --> what a given client build should do
+type Upgrade struct {
+	Required      bool   `json:"required"`    // * below min_version --> block until updated
+	Recommended   bool   `json:"recommended"` // * below latest_version --> show a nudge
+	MinVersion    string `json:"min_version,omitempty"`
+	LatestVersion string `json:"latest_version,omitempty"`
+	StoreURL      string `json:"store_url,omitempty"`
+}
+
+// ! LoadFromEnv --> no CLIENT_CONFIG_FILE means no flags, no settings and no version policy
+func LoadFromEnv() (*Config, error) {
+	path := os.Getenv("CLIENT_CONFIG_FILE")
+	if path == "" {
+		return Parse([]byte(`{}`))
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// ! Parse --> validates version strings up front so a typo can't force-upgrade everyone
+func Parse(raw []byte) (*Config, error) {
+	config := &Config{}
+	err := json.Unmarshal(raw, config)
+	if err != nil {
+		return nil, fmt.Errorf("client config: %w", err)
+	}
+	if config.Features == nil {
+		config.Features = map[string]bool{}
+	}
+	if config.Platforms == nil {
+		config.Platforms = map[string]Platform{}
+	}
+	if config.Settings == nil {
+		config.Settings = map[string]any{}
+	}
+
+	if config.MinVersion != "" {
+		_, err = ParseVersion(config.MinVersion)
+		if err != nil {
+			return nil, fmt.Errorf("client config: min_version: %w", err)
+		}
+	}
+	for name, p := range config.Platforms {
+		for _, v := range []string{p.MinVersion, p.LatestVersion} {
+			if v == "" {
+				continue
+			}
+			_, err = ParseVersion(v)
+			if err != nil {
+				return nil, fmt.Errorf("client config: %s: %w", name, err)
+			}
+		}
+	}
+
+	//* hash of the canonical encoding (map keys are sorted), ignores whatever version the file claimed
+	config.Version = ""
+	canonical, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(canonical)
+	config.Version = hex.EncodeToString(sum[:])[:16]
+	return config, nil
+}
+
+// ! ParseVersion --> "1.4.2" style, missing parts count as 0 and pre-release suffixes are ignored
+func ParseVersion(raw string) ([3]int, error) {
+	var parts [3]int
+	core, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(raw), "v"), "-")
+	fields := strings.Split(core, ".")
+	if core == "" || len(fields) > 3 {
+		return parts, fmt.Errorf("invalid version %q", raw)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q", raw)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// ! CompareVersions --> -1, 0 or 1 like strings.Compare
+func CompareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// ! UpgradeFor --> upgrade signal for a client, nil when the version is unknown or no policy applies
+func (c *Config) UpgradeFor(platform, version string) *Upgrade {
+	policy, ok := c.Platforms[platform]
+	if !ok {
+		if c.MinVersion == "" {
+			return nil
+		}
+		policy = Platform{MinVersion: c.MinVersion}
+	}
+	current, err := ParseVersion(version)
+	if err != nil {
+		return nil
+	}
+
+	upgrade := &Upgrade{MinVersion: policy.MinVersion, LatestVersion: policy.LatestVersion, StoreURL: policy.StoreURL}
+	if policy.MinVersion != "" {
+		min, _ := ParseVersion(policy.MinVersion)
+		upgrade.Required = CompareVersions(current, min) < 0
+	}
+	if policy.LatestVersion != "" {
+		latest, _ := ParseVersion(policy.LatestVersion)
+		upgrade.Recommended = CompareVersions(current, latest) < 0
+	}
+	return upgrade
+}
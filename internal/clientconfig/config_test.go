@@ -0,0 +1,54 @@
+package clientconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("v2.10-beta")
+	require.NoError(t, err)
+	assert.Equal(t, [3]int{2, 10, 0}, v)
+
+	for _, bad := range []string{"", "1.2.3.4", "1.x", "-1"} {
+		_, err = ParseVersion(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestUpgradeFor(t *testing.T) {
+	config, err := Parse([]byte(`{
+		"features": {"new_feed": true},
+		"platforms": {"ios": {"min_version": "2.0.0", "latest_version": "2.3.1", "store_url": "https://apps.example/ios"}}
+	}`))
+	require.NoError(t, err)
+
+	assert.True(t, config.UpgradeFor("ios", "1.9.9").Required)
+	assert.False(t, config.UpgradeFor("ios", "2.0").Required)
+	assert.True(t, config.UpgradeFor("ios", "2.0").Recommended)
+	assert.False(t, config.UpgradeFor("ios", "2.3.1").Recommended)
+	assert.Nil(t, config.UpgradeFor("android", "1.0.0"))
+	assert.Nil(t, config.UpgradeFor("ios", "garbage"))
+
+	config.MinVersion = "1.5.0"
+	assert.True(t, config.UpgradeFor("android", "1.0.0").Required)
+	assert.False(t, config.UpgradeFor("", "1.5.0").Required)
+	assert.False(t, config.UpgradeFor("ios", "2.0.0").Required) //* platform policy wins over the floor
+}
+
+func TestVersionTracksContent(t *testing.T) {
+	a, err := Parse([]byte(`{"features": {"a": true, "b": false}}`))
+	require.NoError(t, err)
+	b, err := Parse([]byte(`{"features": {"b": false, "a": true}, "version": "ignored"}`))
+	require.NoError(t, err)
+	c, err := Parse([]byte(`{"features": {"a": false, "b": false}}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, a.Version, b.Version)
+	assert.NotEqual(t, a.Version, c.Version)
+
+	_, err = Parse([]byte(`{"platforms": {"ios": {"min_version": "two"}}}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package clientusage
+
+import (
+	"context"
+	"errors"
+	"fem/internal/store"
+	"log"
+	"sync"
+	"time"
+)
+
+// ! Key --> what a request is counted under, Day is truncated to UTC midnight
+type Key struct {
+	Day           time.Time
+	ClientID      string
+	ClientVersion string
+	Method        string
+	Route         string
+}
+
+// ! userDay --> per-user counts, only kept when Recorder.Users is set
+type userDay struct {
+	userID int
+	day    time.Time
+}
+
+type counter struct {
+	requests int64
+	lastSeen time.Time
+}
+
+// ! Recorder --> counts requests in memory and flushes the totals every Interval
+// ? one row per key per flush instead of one write per request
+type Recorder struct {
+	Store    store.ClientUsageStore
+	Users    store.UserUsageStore //* optional, RecordUser is a no-op without it
+	Interval time.Duration
+	Logger   *log.Logger
+
+	mu     sync.Mutex
+	counts map[Key]*counter
+	users  map[userDay]int64
+}
+
+// ! NewRecorder --> constructor, call Run to start flushing
+func NewRecorder(usageStore store.ClientUsageStore, interval time.Duration, logger *log.Logger) *Recorder {
+	return &Recorder{
+		Store:    usageStore,
+		Interval: interval,
+		Logger:   logger,
+		counts:   map[Key]*counter{},
+		users:    map[userDay]int64{},
+	}
+}
+
+// ! Record --> counts one request seen at `at`
+func (r *Recorder) Record(key Key, at time.Time) {
+	at = at.UTC()
+	key.Day = at.Truncate(24 * time.Hour)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.counts[key]
+	if !ok {
+		c = &counter{}
+		r.counts[key] = c
+	}
+	c.requests++
+	if at.After(c.lastSeen) {
+		c.lastSeen = at
+	}
+}
+
+// ! RecordUser --> counts one authenticated request for userID seen at `at`
+func (r *Recorder) RecordUser(userID int, at time.Time) {
+	if r.Users == nil {
+		return
+	}
+	key := userDay{userID: userID, day: at.UTC().Truncate(24 * time.Hour)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[key]++
+}
+
+// ! Flush --> writes everything counted so far, counts go back in the buffer when the write fails
+func (r *Recorder) Flush() error {
+	return errors.Join(r.flushClients(), r.flushUsers())
+}
+
+func (r *Recorder) flushUsers() error {
+	r.mu.Lock()
+	users := r.users
+	r.users = map[userDay]int64{}
+	r.mu.Unlock()
+
+	if len(users) == 0 {
+		return nil
+	}
+	counts := make([]*store.UserRequests, 0, len(users))
+	for key, requests := range users {
+		counts = append(counts, &store.UserRequests{UserID: key.userID, Day: key.day, Requests: requests})
+	}
+
+	err := r.Users.AddUserRequests(counts)
+	if err != nil {
+		r.mu.Lock()
+		for key, requests := range users {
+			r.users[key] += requests
+		}
+		r.mu.Unlock()
+	}
+	return err
+}
+
+func (r *Recorder) flushClients() error {
+	r.mu.Lock()
+	counts := r.counts
+	r.counts = map[Key]*counter{}
+	r.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+	usage := make([]*store.ClientUsage, 0, len(counts))
+	for key, c := range counts {
+		usage = append(usage, &store.ClientUsage{
+			Day:           key.Day,
+			ClientID:      key.ClientID,
+			ClientVersion: key.ClientVersion,
+			Method:        key.Method,
+			Route:         key.Route,
+			Requests:      c.requests,
+			LastSeenAt:    c.lastSeen,
+		})
+	}
+
+	err := r.Store.AddClientUsage(usage)
+	if err != nil {
+		r.mu.Lock()
+		for key, c := range counts {
+			existing, ok := r.counts[key]
+			if !ok {
+				r.counts[key] = c
+				continue
+			}
+			existing.requests += c.requests
+			if c.lastSeen.After(existing.lastSeen) {
+				existing.lastSeen = c.lastSeen
+			}
+		}
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// ! Run --> flushes every Interval until ctx is cancelled, then one last time
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			err := r.Flush()
+			if err != nil {
+				r.Logger.Printf("ERROR: client usage flush: %v", err)
+			}
+			return
+		case <-ticker.C:
+			err := r.Flush()
+			if err != nil {
+				r.Logger.Printf("ERROR: client usage flush: %v", err)
+			}
+		}
+	}
+}
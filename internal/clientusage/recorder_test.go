@@ -0,0 +1,92 @@
+package clientusage
+
+import (
+	"errors"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryUsageStore struct {
+	fail    bool
+	flushed []*store.ClientUsage
+}
+
+func (s *memoryUsageStore) AddClientUsage(usage []*store.ClientUsage) error {
+	if s.fail {
+		return errors.New("db down")
+	}
+	s.flushed = append(s.flushed, usage...)
+	return nil
+}
+
+func (s *memoryUsageStore) ListClientUsage(filter store.ClientUsageFilter) ([]*store.ClientUsage, error) {
+	return nil, nil
+}
+
+func TestRecorderFlush(t *testing.T) {
+	usageStore := &memoryUsageStore{fail: true}
+	recorder := NewRecorder(usageStore, time.Minute, log.New(io.Discard, "", 0))
+
+	key := Key{ClientID: "ios", ClientVersion: "2.1.0", Method: "GET", Route: "/workouts/{id}"}
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	recorder.Record(key, first)
+	recorder.Record(key, first.Add(time.Minute))
+
+	//* failed flush keeps the counts for the next attempt
+	assert.Error(t, recorder.Flush())
+	recorder.Record(key, first.Add(2*time.Minute))
+
+	usageStore.fail = false
+	require.NoError(t, recorder.Flush())
+	require.Len(t, usageStore.flushed, 1)
+
+	usage := usageStore.flushed[0]
+	assert.Equal(t, int64(3), usage.Requests)
+	assert.Equal(t, first.Add(2*time.Minute), usage.LastSeenAt)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), usage.Day)
+
+	//* nothing new --> nothing written
+	require.NoError(t, recorder.Flush())
+	assert.Len(t, usageStore.flushed, 1)
+}
+
+type memoryUserUsageStore struct {
+	flushed []*store.UserRequests
+}
+
+func (s *memoryUserUsageStore) AddUserRequests(counts []*store.UserRequests) error {
+	s.flushed = append(s.flushed, counts...)
+	return nil
+}
+
+func (s *memoryUserUsageStore) ListUserRequests(userID int, since time.Time) ([]*store.UserRequests, error) {
+	return nil, nil
+}
+
+func TestRecorderFlushUsers(t *testing.T) {
+	recorder := NewRecorder(&memoryUsageStore{}, time.Minute, log.New(io.Discard, "", 0))
+	recorder.RecordUser(7, time.Now()) //* no Users store --> not counted
+
+	users := &memoryUserUsageStore{}
+	recorder.Users = users
+	at := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	recorder.RecordUser(7, at)
+	recorder.RecordUser(7, at.Add(30*time.Second))
+	recorder.RecordUser(7, at.Add(2*time.Minute)) //* next day
+	require.NoError(t, recorder.Flush())
+
+	require.Len(t, users.flushed, 2)
+	perDay := map[time.Time]int64{}
+	for _, c := range users.flushed {
+		assert.Equal(t, 7, c.UserID)
+		perDay[c.Day] = c.Requests
+	}
+	assert.Equal(t, int64(2), perDay[time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)])
+	assert.Equal(t, int64(1), perDay[time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)])
+}
@@ -0,0 +1,113 @@
+// Package config reads the optional YAML config file (-config / CONFIG_FILE) into a typed Config.
+// ? startup settings are handed to the rest of the app as env vars, which keep priority over the file;
+// ? log_level, cors and public_api are reloadable and re-applied on SIGHUP without a restart
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fem/internal/logging"
+	"fem/internal/publicapi"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ! CORS --> browser origins allowed to call the API, "*" for any
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// ! Config --> the file's contents, unset fields leave the env / flag defaults alone
+type Config struct {
+	Port      *int   `yaml:"port"`       //* PORT
+	GRPCPort  *int   `yaml:"grpc_port"`  //* GRPC_PORT
+	Listen    string `yaml:"listen"`     //* LISTEN
+	AdminPort *int   `yaml:"admin_port"` //* ADMIN_PORT
+	AdminHost string `yaml:"admin_host"` //* ADMIN_HOST
+
+	//! reloadable on SIGHUP
+	LogLevel  string         `yaml:"log_level"`  //* debug, info or error (LOG_LEVEL)
+	CORS      *CORS          `yaml:"cors"`       //* CORS_ALLOWED_ORIGINS
+	PublicAPI map[string]any `yaml:"public_api"` //* same shape as the PUBLIC_API_FILE JSON
+
+	//* anything else by its env var name, e.g. SANDBOX_ENABLED: "true"
+	Env map[string]string `yaml:"env"`
+
+	publicAPI *publicapi.Config
+}
+
+// ! Load --> reads + validates the file, unknown keys are an error so typos don't silently do nothing
+func Load(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	err = decoder.Decode(c)
+	if err != nil && !errors.Is(err, io.EOF) { //* EOF --> empty file
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	_, err = logging.ParseLevel(c.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if c.PublicAPI != nil {
+		asJSON, err := json.Marshal(c.PublicAPI)
+		if err != nil {
+			return nil, fmt.Errorf("config %s: public_api: %w", path, err)
+		}
+		c.publicAPI, err = publicapi.Parse(asJSON)
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// ! PublicAPIConfig --> the parsed public_api block, nil when the file has none
+func (c *Config) PublicAPIConfig() *publicapi.Config {
+	return c.publicAPI
+}
+
+// ! ApplyEnv --> exports the file's settings as env vars, variables that are already set win
+func (c *Config) ApplyEnv() error {
+	settings := map[string]string{}
+	for name, value := range c.Env {
+		settings[name] = value
+	}
+	setInt := func(name string, value *int) {
+		if value != nil {
+			settings[name] = strconv.Itoa(*value)
+		}
+	}
+	setString := func(name, value string) {
+		if value != "" {
+			settings[name] = value
+		}
+	}
+	setInt("PORT", c.Port)
+	setInt("GRPC_PORT", c.GRPCPort)
+	setString("LISTEN", c.Listen)
+	setInt("ADMIN_PORT", c.AdminPort)
+	setString("ADMIN_HOST", c.AdminHost)
+	setString("LOG_LEVEL", c.LogLevel)
+
+	for name, value := range settings {
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		err := os.Setenv(name, value)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	c, err := Load(writeConfig(t, `
+port: 8081
+log_level: debug
+cors:
+  allowed_origins: ["https://app.example.com"]
+public_api:
+  requests_per_minute: 30
+  routes:
+    /leaderboards/xp: {}
+env:
+  CONFIG_TEST_FLAG: "on"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 8081, *c.Port)
+	assert.Equal(t, []string{"https://app.example.com"}, c.CORS.AllowedOrigins)
+	limit, ok := c.PublicAPIConfig().Exposed("/leaderboards/xp")
+	assert.True(t, ok)
+	assert.Equal(t, 30, limit.RequestsPerMinute)
+
+	_, err = Load(writeConfig(t, "prot: 8081\n"))
+	assert.Error(t, err, "unknown keys are rejected")
+	_, err = Load(writeConfig(t, "log_level: loud\n"))
+	assert.Error(t, err)
+
+	c, err = Load(writeConfig(t, ""))
+	require.NoError(t, err)
+	assert.Nil(t, c.PublicAPIConfig())
+}
+
+func TestApplyEnvKeepsExistingVars(t *testing.T) {
+	t.Setenv("PORT", "9999")
+	t.Setenv("CONFIG_TEST_FLAG", "")
+	os.Unsetenv("CONFIG_TEST_FLAG")
+	t.Setenv("ADMIN_HOST", "")
+	os.Unsetenv("ADMIN_HOST")
+
+	c, err := Load(writeConfig(t, "port: 8081\nadmin_host: 127.0.0.1\nenv:\n  CONFIG_TEST_FLAG: \"on\"\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.ApplyEnv())
+	assert.Equal(t, "9999", os.Getenv("PORT"))
+	assert.Equal(t, "127.0.0.1", os.Getenv("ADMIN_HOST"))
+	assert.Equal(t, "on", os.Getenv("CONFIG_TEST_FLAG"))
+}
@@ -0,0 +1,84 @@
+// Package cors answers browser cross-origin checks for the web frontends allowed in CORS_ALLOWED_ORIGINS.
+// ? the origin list can be swapped at runtime (SIGHUP reloads), requests in flight keep the list they started with
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ! what browsers may send + read, the API authenticates with bearer tokens so credentials stay off
+const (
+	allowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	allowHeaders  = "Authorization, Content-Type, Accept, If-None-Match, X-Client-Platform, X-Client-Version"
+	exposeHeaders = "Retry-After, ETag, Link, Deprecation, Sunset"
+	maxAge        = 10 * time.Minute //* how long browsers cache a preflight answer
+)
+
+// ! Policy --> allowed origins, "*" allows any; empty sends no CORS headers at all (same-origin only)
+type Policy struct {
+	origins atomic.Pointer[[]string]
+}
+
+func NewPolicy(origins []string) *Policy {
+	p := &Policy{}
+	p.SetOrigins(origins)
+	return p
+}
+
+// ! ParseOrigins --> comma separated list as in CORS_ALLOWED_ORIGINS, trailing slashes dropped
+func ParseOrigins(raw string) []string {
+	origins := []string{}
+	for _, origin := range strings.Split(raw, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+func (p *Policy) SetOrigins(origins []string) {
+	origins = slices.Clone(origins)
+	p.origins.Store(&origins)
+}
+
+func (p *Policy) Origins() []string {
+	return slices.Clone(*p.origins.Load())
+}
+
+func (p *Policy) allows(origin string) bool {
+	origins := *p.origins.Load()
+	return slices.Contains(origins, "*") || slices.Contains(origins, origin)
+}
+
+// ! Middleware --> root middleware, preflights are answered here and never reach auth or the handlers
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin") //* caches must not hand one origin's answer to another
+		allowed := p.allows(origin)
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent) //* a refused preflight just lacks the headers, the browser blocks the call
+	})
+}
@@ -0,0 +1,209 @@
+// ! package devseed --> bulk fixture data for local development, `fittrack seed` drives it
+// ? enough users, history and follows that stats, pagination and feeds have something to chew on
+package devseed
+
+import (
+	"context"
+	"fem/internal/store"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ! Password --> every seeded account logs in with it
+const Password = "seed-password"
+
+// ! Options --> how much to generate, the same Seed + Users + Days always produces the same data
+type Options struct {
+	Users  int
+	Days   int
+	Seed   int64
+	Prefix string //* usernames are <prefix>_001, <prefix>_002, ...
+}
+
+// ! Stores --> what the generator writes through, any implementation works (postgres, memstore)
+type Stores struct {
+	Users    store.UserStore
+	Workouts store.WorkoutStore
+	Follows  store.FollowStore
+}
+
+// ! Result --> counts of what was created
+type Result struct {
+	Users    int `json:"users"`
+	Workouts int `json:"workouts"`
+	Follows  int `json:"follows"`
+}
+
+// ? - one exercise in a template, zero Reps means a timed exercise
+type exercise struct {
+	Name    string
+	Sets    int
+	Reps    int
+	Seconds int
+	Weight  float64
+}
+
+// ? - a kind of session users repeat, minutes and calories per minute vary per workout
+type template struct {
+	Title     string
+	Minutes   [2]int
+	CalPerMin float64
+	Exercises []exercise
+}
+
+var templates = []template{
+	{"Push day", [2]int{45, 75}, 7, []exercise{{"Bench Press", 4, 8, 0, 70}, {"Overhead Press", 3, 10, 0, 40}, {"Dip", 3, 12, 0, 0}, {"Tricep Pushdown", 3, 15, 0, 25}}},
+	{"Pull day", [2]int{45, 70}, 7, []exercise{{"Deadlift", 3, 5, 0, 120}, {"Pull Up", 4, 8, 0, 0}, {"Barbell Row", 3, 10, 0, 60}, {"Bicep Curl", 3, 12, 0, 14}}},
+	{"Leg day", [2]int{50, 80}, 8, []exercise{{"Squat", 5, 5, 0, 100}, {"Romanian Deadlift", 3, 10, 0, 80}, {"Lunge", 3, 12, 0, 20}, {"Calf Raise", 4, 15, 0, 40}}},
+	{"Full body", [2]int{40, 60}, 8, []exercise{{"Kettlebell Swing", 4, 20, 0, 16}, {"Goblet Squat", 3, 12, 0, 24}, {"Push Up", 3, 15, 0, 0}, {"Plank", 3, 0, 60, 0}}},
+	{"Easy run", [2]int{25, 50}, 10, []exercise{{"Running", 1, 0, 0, 0}}},
+	{"Intervals", [2]int{20, 35}, 12, []exercise{{"Sprint", 8, 0, 30, 0}, {"Burpee", 4, 15, 0, 0}}},
+	{"Long ride", [2]int{60, 150}, 9, []exercise{{"Cycling", 1, 0, 0, 0}}},
+	{"Swim", [2]int{30, 60}, 9, []exercise{{"Freestyle", 10, 0, 120, 0}}},
+	{"Yoga", [2]int{30, 60}, 4, []exercise{{"Sun Salutation", 5, 0, 300, 0}, {"Pigeon Pose", 2, 0, 90, 0}}},
+	{"Core", [2]int{15, 30}, 6, []exercise{{"Plank", 3, 0, 60, 0}, {"Hanging Leg Raise", 3, 12, 0, 0}, {"Russian Twist", 3, 20, 0, 10}}},
+}
+
+// * visibilities --> weighted toward public so feeds fill up
+var visibilities = []string{store.VisibilityPublic, store.VisibilityPublic, store.VisibilityPublic, store.VisibilityFollowers, store.VisibilityPrivate}
+
+// ! Run --> users first, then a year (Options.Days) of workouts each, then follows with backfilled feeds
+// ? existing usernames are reused, so rerunning with a bigger Users only adds the missing accounts + their data
+func Run(ctx context.Context, stores Stores, opts Options, logger *log.Logger) (*Result, error) {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	result := &Result{}
+
+	//* bcrypt once, every account gets the same hash
+	hashed := &store.User{}
+	err := hashed.PasswordHash.Set(Password)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, opts.Users)
+	for i := 1; i <= opts.Users; i++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		username := fmt.Sprintf("%s_%03d", opts.Prefix, i)
+		existing, err := stores.Users.GetUserByUsername(username)
+		if err != nil {
+			return result, err
+		}
+		if existing != nil {
+			ids = append(ids, existing.ID)
+			continue
+		}
+
+		user := &store.User{Username: username, Email: username + "@seed.fittrack.local", Bio: fmt.Sprintf("Seeded account %d", i)}
+		user.PasswordHash.SetHash(hashed.PasswordHash.Hash())
+		err = stores.Users.CreateUser(user)
+		if err != nil {
+			return result, fmt.Errorf("creating %s: %w", username, err)
+		}
+		ids = append(ids, user.ID)
+		result.Users++
+
+		n, err := seedWorkouts(stores.Workouts, rng, user.ID, opts.Days)
+		result.Workouts += n
+		if err != nil {
+			return result, fmt.Errorf("workouts for %s: %w", username, err)
+		}
+		logger.Printf("seed : %s with %d workouts", username, n)
+	}
+
+	for _, followerID := range ids {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		for _, j := range rng.Perm(len(ids))[:min(len(ids), 3+rng.Intn(8))] {
+			followeeID := ids[j]
+			if followeeID == followerID {
+				continue
+			}
+			following, err := stores.Follows.IsFollowing(int64(followerID), int64(followeeID))
+			if err != nil {
+				return result, err
+			}
+			if following {
+				continue
+			}
+			err = stores.Follows.Follow(int64(followerID), int64(followeeID))
+			if err != nil {
+				return result, err
+			}
+			_, err = stores.Follows.BackfillFeed(int64(followerID), int64(followeeID))
+			if err != nil {
+				return result, err
+			}
+			result.Follows++
+		}
+	}
+	return result, nil
+}
+
+// * seedWorkouts --> each user trains 1-6 days a week on a couple of favourite templates, imported in one batch
+func seedWorkouts(workouts store.WorkoutStore, rng *rand.Rand, userID int, days int) (int, error) {
+	perWeek := 1 + rng.Intn(6)
+	favourites := rng.Perm(len(templates))[:2+rng.Intn(3)]
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+
+	batch := []*store.Workout{}
+	for d := days; d >= 0; d-- {
+		if rng.Intn(7) >= perWeek {
+			continue
+		}
+		t := templates[favourites[rng.Intn(len(favourites))]]
+		minutes := t.Minutes[0] + rng.Intn(t.Minutes[1]-t.Minutes[0]+1)
+		workout := &store.Workout{
+			UserID:          userID,
+			Title:           t.Title,
+			DurationMinutes: minutes,
+			CaloriesBurned:  int(float64(minutes) * t.CalPerMin * (0.8 + rng.Float64()*0.4)),
+			Visibility:      visibilities[rng.Intn(len(visibilities))],
+			CreatedAt:       today.AddDate(0, 0, -d).Add(time.Duration(6*60+rng.Intn(15*60)) * time.Minute), //* between 06:00 and 21:00
+		}
+		if workout.CreatedAt.After(now) {
+			workout.CreatedAt = now
+		}
+		for i, e := range t.Exercises {
+			entry := store.WorkoutEntry{ExerciseName: e.Name, Sets: e.Sets, OrderIndex: i + 1}
+			switch {
+			case e.Reps > 0:
+				reps := e.Reps
+				entry.Reps = &reps
+			case e.Seconds > 0:
+				seconds := e.Seconds
+				entry.DurationSeconds = &seconds
+			default:
+				seconds := minutes * 60 //* single continuous effort (run, ride)
+				entry.DurationSeconds = &seconds
+			}
+			if e.Weight > 0 {
+				weight := math.Round(e.Weight*(0.85+rng.Float64()*0.3)*2) / 2 //* plates come in 0.5 kg steps
+				entry.Weight = &weight
+			}
+			workout.Entries = append(workout.Entries, entry)
+		}
+		batch = append(batch, workout)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	rowErrs, err := workouts.ImportWorkouts(batch)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, rowErr := range rowErrs {
+		if rowErr == nil {
+			created++
+		}
+	}
+	return created, nil
+}
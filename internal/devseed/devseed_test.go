@@ -0,0 +1,38 @@
+package devseed
+
+import (
+	"context"
+	"fem/internal/memstore"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	db := memstore.New()
+	stores := Stores{Users: memstore.NewUserStore(db), Workouts: memstore.NewWorkoutStore(db), Follows: memstore.NewFollowStore(db)}
+	opts := Options{Users: 5, Days: 60, Seed: 1, Prefix: "seed"}
+	logger := log.New(io.Discard, "", 0)
+
+	result, err := Run(context.Background(), stores, opts, logger)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Users)
+	assert.NotZero(t, result.Workouts)
+	assert.NotZero(t, result.Follows)
+
+	user, err := stores.Users.GetUserByUsername("seed_003")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	ok, err := user.PasswordHash.Matches(Password)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	//* rerunning only adds what's missing
+	opts.Users = 6
+	again, err := Run(context.Background(), stores, opts, logger)
+	require.NoError(t, err)
+	assert.Equal(t, 1, again.Users)
+}
@@ -0,0 +1,166 @@
+// ! package digest --> the weekly summary email: last week's workouts, personal records and goal progress
+// ? a worker job sends to everyone whose local Monday reached SendAt, one digest per user per week
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/goals"
+	"fem/internal/mailer"
+	"fem/internal/reminders"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"fmt"
+	"log"
+	"time"
+	_ "time/tzdata" //* recipient timezones must resolve in the runtime image too
+)
+
+// ! JobSend --> background job type, sends every digest that is due
+const JobSend = "digest.send"
+
+// ! Digest --> what the weekly_digest templates are rendered with
+type Digest struct {
+	Username string
+	Week     string // * e.g. "Oct 5 - Oct 11"
+	store.DigestSummary
+	Goals []Goal
+}
+
+// ! Goal --> one line of goal progress
+type Goal struct {
+	Label   string
+	Percent float64
+}
+
+// ! Sender --> works out whose digest is due and enqueues it as an email
+type Sender struct {
+	Store  store.DigestStore
+	Goals  store.GoalStore
+	Jobs   worker.Enqueuer //* email.send
+	SendAt string          //* HH:MM local time on Monday
+	Logger *log.Logger
+}
+
+// ! NewSender --> constructor for the digest sender
+func NewSender(digestStore store.DigestStore, goalStore store.GoalStore, jobs worker.Enqueuer, sendAt string, logger *log.Logger) *Sender {
+	return &Sender{Store: digestStore, Goals: goalStore, Jobs: jobs, SendAt: sendAt, Logger: logger}
+}
+
+// ! SendJob --> worker handler for JobSend, meant to run every few minutes
+func SendJob(s *Sender) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		_, err := s.Send(ctx, time.Now())
+		return err
+	}
+}
+
+// ! Send --> enqueues every digest due at now, returns how many went out
+// ? due means it's Monday past SendAt where the user lives, the store only lists those; a week with no workouts
+// ? and no goals sends nothing. One user's failure is logged and skipped, their week is retried on the next run
+func (s *Sender) Send(ctx context.Context, now time.Time) (int, error) {
+	offset, err := reminders.ParseClock(s.SendAt)
+	if err != nil {
+		return 0, fmt.Errorf("digest: send time: %w", err)
+	}
+	recipients, err := s.Store.ListDigestRecipients(now, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, r := range recipients {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		ok, err := s.sendOne(r, now)
+		if err != nil {
+			s.Logger.Printf("ERROR: digest for user %d: %v", r.UserID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	if sent > 0 {
+		s.Logger.Printf("digest: sent %d", sent)
+	}
+	return sent, nil
+}
+
+// * sendOne --> claims r's week so other instances skip it, gives the claim back when the email didn't get enqueued
+func (s *Sender) sendOne(r *store.DigestRecipient, now time.Time) (bool, error) {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return false, err
+	}
+	local := now.In(loc)
+	monday := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	from := monday.AddDate(0, 0, -7)
+	week := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+
+	claimed, err := s.Store.MarkDigestSent(r.UserID, week)
+	if err != nil || !claimed {
+		return false, err
+	}
+	ok, err := s.deliver(r, from, monday)
+	if err != nil {
+		if unmarkErr := s.Store.UnmarkDigestSent(r.UserID, week); unmarkErr != nil {
+			s.Logger.Printf("ERROR: digest for user %d: releasing week: %v", r.UserID, unmarkErr)
+		}
+		return false, err
+	}
+	return ok, nil
+}
+
+// * deliver --> composes the week [from, to) and enqueues the email, false when there was nothing to tell
+func (s *Sender) deliver(r *store.DigestRecipient, from, to time.Time) (bool, error) {
+	summary, err := s.Store.GetDigestSummary(r.UserID, from, to)
+	if err != nil {
+		return false, err
+	}
+	list, err := s.Goals.ListGoals(int64(r.UserID))
+	if err != nil {
+		return false, err
+	}
+	if summary.Workouts == 0 && len(list) == 0 {
+		return false, nil
+	}
+
+	d := &Digest{Username: r.Username, Week: from.Format("Jan 2") + " - " + to.AddDate(0, 0, -1).Format("Jan 2"), DigestSummary: *summary}
+	for _, goal := range list {
+		d.Goals = append(d.Goals, goalLine(goal, summary))
+	}
+	text, html, err := mailer.Render("weekly_digest", d)
+	if err != nil {
+		return false, err
+	}
+	err = s.Jobs.Enqueue(worker.JobSendEmail, mailer.Message{To: r.Email, Subject: "Your week in training: " + d.Week, Body: text, HTML: html})
+	return err == nil, err
+}
+
+// * goalLine --> a weekly workouts goal is measured against the digest week, not the one that just started
+func goalLine(goal *store.Goal, summary *store.DigestSummary) Goal {
+	if goal.Type == store.GoalWeeklyWorkouts {
+		workouts := float64(summary.Workouts)
+		goal.CurrentValue = &workouts
+	}
+	goals.Fill(goal)
+
+	current := 0.0
+	if goal.CurrentValue != nil {
+		current = *goal.CurrentValue
+	}
+	var label string
+	switch goal.Type {
+	case store.GoalWeeklyWorkouts:
+		label = fmt.Sprintf("Weekly workouts: %.0f of %.0f", current, goal.TargetValue)
+	case store.GoalTotalMinutes:
+		label = fmt.Sprintf("Training minutes: %.0f of %.0f", current, goal.TargetValue)
+	case store.GoalWeightTarget:
+		label = fmt.Sprintf("Body weight: %.1f kg, target %.1f kg", current, goal.TargetValue)
+	default:
+		label = goal.Type
+	}
+	return Goal{Label: label, Percent: goal.PercentComplete}
+}
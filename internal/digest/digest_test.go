@@ -0,0 +1,150 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fem/internal/mailer"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingJobs --> keeps enqueued emails instead of running them, fail refuses mail to that address
+type recordingJobs struct {
+	emails []mailer.Message
+	fail   string
+}
+
+func (j *recordingJobs) Enqueue(jobType string, payload any) error {
+	msg := payload.(mailer.Message)
+	if msg.To == j.fail {
+		return errors.New("job store down")
+	}
+	j.emails = append(j.emails, msg)
+	return nil
+}
+
+// ! TestSend --> Monday 08:00 in the user's timezone, once per week, opted-out and idle users get nothing
+func TestSend(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	ids := map[string]int{}
+	for _, name := range []string{"ana", "bob", "cy"} {
+		user := &store.User{Username: name, Email: name + "@example.com"}
+		require.NoError(t, user.PasswordHash.Set("password"))
+		require.NoError(t, users.CreateUser(user))
+		ids[name] = user.ID
+	}
+	require.NoError(t, memstore.NewProfileStore(db).UpsertProfile(&store.Profile{UserID: ids["ana"], Units: store.UnitsMetric, Timezone: "America/New_York"}))
+	prefs := store.DefaultNotificationPreferences()
+	prefs.WeeklyDigest = false
+	require.NoError(t, memstore.NewNotificationStore(db).UpdateNotificationPreferences(ids["bob"], prefs))
+
+	//* ana squatted 100 before the week, 110 during it; bench is new, so no record
+	workouts := memstore.NewWorkoutStore(db)
+	weight := func(kg float64) *float64 { return &kg }
+	reps := 5
+	logged := []*store.Workout{
+		{Title: "Legs", DurationMinutes: 50, PerformedAt: time.Date(2026, 10, 1, 18, 0, 0, 0, time.UTC),
+			Entries: []store.WorkoutEntry{{ExerciseName: "Squat", Sets: 3, Reps: &reps, Weight: weight(100)}}},
+		{Title: "Legs", DurationMinutes: 60, PerformedAt: time.Date(2026, 10, 7, 18, 0, 0, 0, time.UTC),
+			Entries: []store.WorkoutEntry{{ExerciseName: "Squat", Sets: 3, Reps: &reps, Weight: weight(110)}}},
+		{Title: "Push", DurationMinutes: 40, PerformedAt: time.Date(2026, 10, 12, 3, 0, 0, 0, time.UTC), //* Sunday 23:00 in New York
+			Entries: []store.WorkoutEntry{{ExerciseName: "Bench", Sets: 3, Reps: &reps, Weight: weight(60)}}},
+		{Title: "Run", DurationMinutes: 30, PerformedAt: time.Date(2026, 10, 12, 11, 0, 0, 0, time.UTC)}, //* Monday, next week's
+	}
+	for _, w := range logged {
+		w.UserID = ids["ana"]
+		_, err := workouts.CreateWorkout(w)
+		require.NoError(t, err)
+	}
+	goalStore := memstore.NewGoalStore(db)
+	require.NoError(t, goalStore.CreateGoal(&store.Goal{UserID: ids["ana"], Type: store.GoalWeeklyWorkouts, TargetValue: 3}))
+
+	jobs := &recordingJobs{}
+	s := NewSender(memstore.NewDigestStore(db), goalStore, jobs, "08:00", logger)
+	ctx := context.Background()
+
+	sent, err := s.Send(ctx, time.Date(2026, 10, 12, 11, 59, 0, 0, time.UTC)) //* 07:59 in New York
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, jobs.emails, "past 08:00 UTC, but bob opted out and cy has nothing to report")
+
+	sent, err = s.Send(ctx, time.Date(2026, 10, 12, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, jobs.emails, 1)
+	msg := jobs.emails[0]
+	assert.Equal(t, "ana@example.com", msg.To)
+	assert.Equal(t, "Your week in training: Oct 5 - Oct 11", msg.Subject)
+	assert.Contains(t, msg.Body, "Workouts: 2")
+	assert.Contains(t, msg.Body, "Minutes trained: 100")
+	assert.Contains(t, msg.Body, "Squat: 110.0 kg (was 100.0 kg)")
+	assert.NotContains(t, msg.Body, "Bench:")
+	assert.Contains(t, msg.Body, "Weekly workouts: 2 of 3 (67%)")
+	assert.Contains(t, msg.HTML, "<strong>110.0 kg</strong>")
+
+	sent, err = s.Send(ctx, time.Date(2026, 10, 12, 20, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent, "once per week")
+
+	sent, err = s.Send(ctx, time.Date(2026, 10, 13, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent, "only on Mondays")
+}
+
+// ! TestSendRetriesFailedEnqueue --> a digest that couldn't be enqueued isn't lost, and doesn't hold up the others
+func TestSendRetriesFailedEnqueue(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	workouts := memstore.NewWorkoutStore(db)
+	for _, name := range []string{"ana", "bob"} {
+		user := &store.User{Username: name, Email: name + "@example.com"}
+		require.NoError(t, users.CreateUser(user))
+		_, err := workouts.CreateWorkout(&store.Workout{UserID: user.ID, Title: "Run", DurationMinutes: 30, PerformedAt: time.Date(2026, 10, 7, 18, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+	}
+
+	digests := memstore.NewDigestStore(db)
+	jobs := &recordingJobs{fail: "ana@example.com"}
+	s := NewSender(digests, memstore.NewGoalStore(db), jobs, "08:00", log.New(io.Discard, "", 0))
+	ctx := context.Background()
+	monday := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+
+	due, err := digests.ListDigestRecipients(monday, 8*time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, due, 2)
+
+	sent, err := s.Send(ctx, monday)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "bob still gets his")
+	require.Len(t, jobs.emails, 1)
+	assert.Equal(t, "bob@example.com", jobs.emails[0].To)
+
+	due, err = digests.ListDigestRecipients(monday, 8*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, due, 1, "only ana is still due")
+	assert.Equal(t, "ana", due[0].Username)
+
+	jobs.fail = ""
+	sent, err = s.Send(ctx, monday.Add(5*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, "ana@example.com", jobs.emails[1].To)
+}
+
+// ! TestRenderEscapesHTML --> usernames and exercise names are user input
+func TestRenderEscapesHTML(t *testing.T) {
+	d := &Digest{Username: "<b>ana</b>", Week: "Oct 5 - Oct 11", DigestSummary: store.DigestSummary{Workouts: 1}}
+	text, html, err := mailer.Render("weekly_digest", d)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Hi <b>ana</b>,")
+	assert.Contains(t, html, "Hi &lt;b&gt;ana&lt;/b&gt;,")
+}
@@ -0,0 +1,108 @@
+// ! package dualwrite --> migrates the workout store to a new backend while the old one keeps serving
+// ? rollout: write (copy every write) --> compare (read both, count divergences) --> read_secondary (cut reads over)
+package dualwrite
+
+import (
+	"errors"
+	"fem/internal/store"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ! Mode --> the feature flags, each one can be flipped at runtime through PUT /admin/dual-write
+type Mode struct {
+	Write         bool `json:"write"`          //* copy every successful primary write to the secondary
+	Compare       bool `json:"compare"`        //* read both stores on every read and count divergences
+	ReadSecondary bool `json:"read_secondary"` //* serve reads from the secondary (primary stays the write source of truth)
+}
+
+// ! ParseMode --> comma separated flag names, e.g. DUALWRITE_MODE=write,compare
+func ParseMode(raw string) (Mode, error) {
+	var mode Mode
+	for _, name := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "write":
+			mode.Write = true
+		case "compare":
+			mode.Compare = true
+		case "read_secondary":
+			mode.ReadSecondary = true
+		default:
+			return Mode{}, fmt.Errorf("dualwrite: unknown mode %q", name)
+		}
+	}
+	return mode, nil
+}
+
+// ! Flags --> Mode behind atomics, safe to flip while requests are in flight
+type Flags struct {
+	write, compare, readSecondary atomic.Bool
+}
+
+func NewFlags(mode Mode) *Flags {
+	f := &Flags{}
+	f.Set(mode)
+	return f
+}
+
+func (f *Flags) Get() Mode {
+	return Mode{Write: f.write.Load(), Compare: f.compare.Load(), ReadSecondary: f.readSecondary.Load()}
+}
+
+func (f *Flags) Set(mode Mode) {
+	f.write.Store(mode.Write)
+	f.compare.Store(mode.Compare)
+	f.readSecondary.Store(mode.ReadSecondary)
+}
+
+// ! OpStats --> counters for one store operation
+type OpStats struct {
+	Writes          int64 `json:"writes"`           //* secondary writes attempted
+	WriteFailures   int64 `json:"write_failures"`   //* secondary writes that errored, the secondary is now stale
+	Compares        int64 `json:"compares"`         //* reads served from both stores
+	Divergences     int64 `json:"divergences"`      //* reads where the stores disagreed
+	CompareFailures int64 `json:"compare_failures"` //* reads where the other store errored
+}
+
+// ! Metrics --> divergence counters per operation, reset on restart
+type Metrics struct {
+	mu  sync.Mutex
+	ops map[string]*OpStats
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{ops: map[string]*OpStats{}}
+}
+
+func (m *Metrics) add(op string, update func(*OpStats)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, ok := m.ops[op]
+	if !ok {
+		stats = &OpStats{}
+		m.ops[op] = stats
+	}
+	update(stats)
+}
+
+// ! Snapshot --> copy of the counters, keyed by store method
+func (m *Metrics) Snapshot() map[string]OpStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]OpStats, len(m.ops))
+	for op, stats := range m.ops {
+		out[op] = *stats
+	}
+	return out
+}
+
+// ! ignoreMissing --> deleting something the secondary never had isn't a failure
+func ignoreMissing(err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	return err
+}
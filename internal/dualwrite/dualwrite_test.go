@@ -0,0 +1,31 @@
+package dualwrite
+
+import (
+	"fem/internal/store"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("write, compare")
+	assert.NoError(t, err)
+	assert.Equal(t, Mode{Write: true, Compare: true}, mode)
+
+	_, err = ParseMode("write,yolo")
+	assert.Error(t, err)
+}
+
+func TestEqual(t *testing.T) {
+	created := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	reps := 5
+	a := &store.Workout{ID: 1, Title: "Legs", CreatedAt: created, Entries: []store.WorkoutEntry{{ID: 10, ExerciseName: "squat", Reps: &reps}}}
+	b := &store.Workout{ID: 1, Title: "Legs", CreatedAt: created.In(time.FixedZone("CET", 3600)), Entries: []store.WorkoutEntry{{ID: 99, ExerciseName: "squat", Reps: &reps}}}
+	assert.True(t, Equal(a, b))
+
+	b.Title = "legs"
+	assert.False(t, Equal(a, b))
+	assert.False(t, Equal(a, nil))
+	assert.True(t, Equal(nil, nil))
+}
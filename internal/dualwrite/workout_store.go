@@ -0,0 +1,176 @@
+package dualwrite
+
+import (
+	"errors"
+	"fem/internal/store"
+	"log"
+	"reflect"
+)
+
+// ! WorkoutStore --> store.WorkoutStore decorator, the primary stays the source of truth for writes
+// ? secondary failures never fail the request, they show up in Metrics and the log instead
+type WorkoutStore struct {
+	store.WorkoutStore
+	Secondary store.ReplicaWorkoutStore
+	Flags     *Flags
+	Metrics   *Metrics
+	Logger    *log.Logger
+}
+
+func NewWorkoutStore(primary store.WorkoutStore, secondary store.ReplicaWorkoutStore, flags *Flags, metrics *Metrics, logger *log.Logger) *WorkoutStore {
+	return &WorkoutStore{WorkoutStore: primary, Secondary: secondary, Flags: flags, Metrics: metrics, Logger: logger}
+}
+
+// ! copy --> mirrors a saved workout, only when the write flag is on
+func (s *WorkoutStore) copy(op string, workout *store.Workout) {
+	if !s.Flags.Get().Write {
+		return
+	}
+	err := s.Secondary.UpsertWorkout(workout)
+	s.recordWrite(op, workout.ID, err)
+}
+
+func (s *WorkoutStore) recordWrite(op string, id int, err error) {
+	s.Metrics.add(op, func(stats *OpStats) {
+		stats.Writes++
+		if err != nil {
+			stats.WriteFailures++
+		}
+	})
+	if err != nil {
+		s.Logger.Printf("ERROR: dualwrite %s workout %d: %v", op, id, err)
+	}
+}
+
+func (s *WorkoutStore) CreateWorkout(workout *store.Workout) (*store.Workout, error) {
+	created, err := s.WorkoutStore.CreateWorkout(workout)
+	if err == nil {
+		s.copy("CreateWorkout", created)
+	}
+	return created, err
+}
+
+func (s *WorkoutStore) ImportWorkouts(workouts []*store.Workout) ([]error, error) {
+	rowErrs, err := s.WorkoutStore.ImportWorkouts(workouts)
+	if err == nil {
+		for i, workout := range workouts {
+			if rowErrs[i] == nil {
+				s.copy("ImportWorkouts", workout)
+			}
+		}
+	}
+	return rowErrs, err
+}
+
+// ! BatchWorkouts --> what the primary applied is mirrored op by op, updates re-read like UpdateWorkout
+func (s *WorkoutStore) BatchWorkouts(ops []store.WorkoutOp, atomic bool) ([]error, error) {
+	opErrs, err := s.WorkoutStore.BatchWorkouts(ops, atomic)
+	if err != nil || !s.Flags.Get().Write {
+		return opErrs, err
+	}
+	for i, op := range ops {
+		if opErrs[i] != nil {
+			continue
+		}
+		switch op.Op {
+		case store.WorkoutOpCreate:
+			s.copy("BatchWorkouts", op.Workout)
+		case store.WorkoutOpUpdate:
+			saved, getErr := s.WorkoutStore.GetWorkoutByID(int64(op.Workout.ID))
+			if getErr != nil {
+				s.recordWrite("BatchWorkouts", op.Workout.ID, getErr)
+				continue
+			}
+			s.copy("BatchWorkouts", saved)
+		case store.WorkoutOpDelete:
+			s.recordWrite("BatchWorkouts", op.Workout.ID, ignoreMissing(s.Secondary.DeleteWorkout(int64(op.Workout.ID))))
+		}
+	}
+	return opErrs, nil
+}
+
+func (s *WorkoutStore) CreateExternalWorkout(workout *store.Workout, ref store.ExternalRef) (bool, error) {
+	created, err := s.WorkoutStore.CreateExternalWorkout(workout, ref)
+	if err == nil && created {
+		s.copy("CreateExternalWorkout", workout)
+	}
+	return created, err
+}
+
+// ! UpdateWorkout --> the primary rewrites fields (verified, entries), the secondary gets the primary's version
+func (s *WorkoutStore) UpdateWorkout(workout *store.Workout) error {
+	err := s.WorkoutStore.UpdateWorkout(workout)
+	if err != nil || !s.Flags.Get().Write {
+		return err
+	}
+	saved, err := s.WorkoutStore.GetWorkoutByID(int64(workout.ID))
+	if err != nil {
+		s.recordWrite("UpdateWorkout", workout.ID, err)
+		return nil
+	}
+	s.copy("UpdateWorkout", saved)
+	return nil
+}
+
+func (s *WorkoutStore) DeleteWorkout(id int64) error {
+	err := s.WorkoutStore.DeleteWorkout(id)
+	if err == nil && s.Flags.Get().Write {
+		s.recordWrite("DeleteWorkout", int(id), ignoreMissing(s.Secondary.DeleteWorkout(id)))
+	}
+	return err
+}
+
+// ! GetWorkoutByID --> served by whichever store the flags pick, compared against the other one when asked to
+func (s *WorkoutStore) GetWorkoutByID(id int64) (*store.Workout, error) {
+	mode := s.Flags.Get()
+	var serve, other func(int64) (*store.Workout, error) = s.WorkoutStore.GetWorkoutByID, s.Secondary.GetWorkoutByID
+	if mode.ReadSecondary {
+		serve, other = other, serve
+	}
+
+	workout, err := serve(id)
+	if (err != nil && !errors.Is(err, store.ErrNotFound)) || !mode.Compare {
+		return workout, err
+	}
+
+	otherWorkout, otherErr := other(id)
+	if errors.Is(otherErr, store.ErrNotFound) {
+		otherErr = nil //* missing on one side only is a divergence, Equal(nil, w) says so
+	}
+	diverged := otherErr == nil && !Equal(workout, otherWorkout)
+	s.Metrics.add("GetWorkoutByID", func(stats *OpStats) {
+		stats.Compares++
+		if otherErr != nil {
+			stats.CompareFailures++
+		}
+		if diverged {
+			stats.Divergences++
+		}
+	})
+	if otherErr != nil {
+		s.Logger.Printf("ERROR: dualwrite compare workout %d: %v", id, otherErr)
+	}
+	if diverged {
+		s.Logger.Printf("dualwrite: workout %d diverged between primary and secondary", id)
+	}
+	return workout, err
+}
+
+// ! Equal --> same workout in both stores, entry ids are ignored (the secondary numbers its own entries)
+func Equal(a, b *store.Workout) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(w *store.Workout) store.Workout {
+	out := *w
+	out.CreatedAt = w.CreatedAt.UTC()
+	out.Entries = make([]store.WorkoutEntry, len(w.Entries))
+	for i, entry := range w.Entries {
+		entry.ID = 0
+		out.Entries[i] = entry
+	}
+	return out
+}
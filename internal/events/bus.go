@@ -0,0 +1,120 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ! domain event types
+const (
+	WorkoutCreated = "workout.created"
+	WorkoutUpdated = "workout.updated"
+	WorkoutDeleted = "workout.deleted"
+
+	AchievementEarned = "achievement.earned"
+
+	FeedEntryAdded = "feed.entry_added" //* UserID is the follower whose feed got WorkoutID
+	CommentAdded   = "comment.added"    //* UserID owns WorkoutID, Ref is the commenter's id
+	FollowerAdded  = "follower.added"   //* UserID was followed, Ref is the new follower's id
+
+	ReminderDue      = "reminder.due"       //* Ref is the reminder id
+	TrainingLoadHigh = "training_load.high" //* Ref is the band, caution or high_risk
+
+	ChallengeEnded = "challenge.ended" //* one per participant, Ref is the challenge id; GET /challenges/{id}/results has the winners
+
+	UserRegistered = "user.registered" //* sign-ups, password or "sign in with"; only streamed, see stream.go
+)
+
+// ! Event --> something that happened to a user's data, published after the write succeeded
+type Event struct {
+	Type      string
+	UserID    int
+	WorkoutID int
+	Ref       string //* extra identifier, e.g. the achievement key
+	At        time.Time
+}
+
+// ! Handler --> subscriber callback, errors are logged by Publish and returned by Deliver
+type Handler func(Event) error
+
+// ! Bus --> in-process publish/subscribe for domain events
+// ? handlers run in their own goroutine so slow subscribers never hold up the request
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	logger   *log.Logger
+	wg       sync.WaitGroup
+}
+
+// ! NewBus --> constructor for the event bus
+func NewBus(logger *log.Logger) *Bus {
+	return &Bus{handlers: map[string][]Handler{}, logger: logger}
+}
+
+// ! Subscribe --> registers handler for one or more event types
+func (b *Bus) Subscribe(handler Handler, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, t := range types {
+		b.handlers[t] = append(b.handlers[t], handler)
+	}
+}
+
+// ! Publish --> fans the event out to every subscriber of its type
+func (b *Bus) Publish(event Event) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			err := call(handler, event)
+			if err != nil {
+				b.logger.Printf("ERROR: event handler for %s: %v", event.Type, err)
+			}
+		}()
+	}
+}
+
+// ! Deliver --> runs every subscriber of the event's type in the caller's goroutine, their errors joined
+// ? the outbox relay delivers this way so an event is only marked published once its subscribers are done;
+// ? a retry after an error reaches the subscribers that succeeded again too
+func (b *Bus) Deliver(event Event) error {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		errs = append(errs, call(handler, event))
+	}
+	return errors.Join(errs...)
+}
+
+// * call --> a panicking handler is an error like any other
+func call(handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panicked: %v", r)
+		}
+	}()
+	return handler(event)
+}
+
+// ! Wait --> blocks until in-flight handlers finish (tests + graceful shutdown)
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
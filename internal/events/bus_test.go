@@ -0,0 +1,81 @@
+package events
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestPublish --> only subscribers of the event type run, failing handlers don't stop the others
+func TestPublish(t *testing.T) {
+	bus := NewBus(log.New(log.Writer(), "", 0))
+	var created, deleted atomic.Int32
+
+	bus.Subscribe(func(Event) error { created.Add(1); return errors.New("boom") }, WorkoutCreated)
+	bus.Subscribe(func(Event) error { created.Add(1); return nil }, WorkoutCreated, WorkoutUpdated)
+	bus.Subscribe(func(Event) error { deleted.Add(1); return nil }, WorkoutDeleted)
+
+	bus.Publish(Event{Type: WorkoutCreated, UserID: 1})
+	bus.Wait()
+
+	assert.Equal(t, int32(2), created.Load())
+	assert.Equal(t, int32(0), deleted.Load())
+}
+
+// ! TestDeliver --> subscribers run before Deliver returns, every failure (panics too) comes back
+func TestDeliver(t *testing.T) {
+	bus := NewBus(log.New(log.Writer(), "", 0))
+	var created atomic.Int32
+
+	bus.Subscribe(func(Event) error { created.Add(1); return nil }, WorkoutCreated)
+	require.NoError(t, bus.Deliver(Event{Type: WorkoutCreated, UserID: 1}))
+	assert.Equal(t, int32(1), created.Load())
+
+	bus.Subscribe(func(Event) error { return errors.New("boom") }, WorkoutCreated)
+	bus.Subscribe(func(Event) error { panic("bad handler") }, WorkoutCreated)
+	err := bus.Deliver(Event{Type: WorkoutCreated, UserID: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+	assert.Contains(t, err.Error(), "bad handler")
+	assert.Equal(t, int32(2), created.Load(), "the others still ran")
+}
+
+// ! TestHubReplay --> reconnecting with Last-Event-ID replays only what the user missed
+func TestHubReplay(t *testing.T) {
+	hub := NewHub(2, time.Minute)
+
+	ch, missed, complete, cancel := hub.Listen(1, 0)
+	assert.Empty(t, missed)
+	assert.True(t, complete)
+
+	hub.Publish(1, WorkoutCreated, MessageData{WorkoutID: 10})
+	hub.Publish(2, WorkoutCreated, MessageData{WorkoutID: 20}) //* other user, never delivered to 1
+	first := <-ch
+	assert.Equal(t, 10, first.Data.WorkoutID)
+	cancel()
+	_, open := <-ch
+	assert.False(t, open)
+
+	hub.Publish(1, WorkoutUpdated, MessageData{WorkoutID: 10})
+	_, missed, complete, cancel = hub.Listen(1, first.ID)
+	defer cancel()
+	assert.True(t, complete)
+	require.Len(t, missed, 1)
+	assert.Equal(t, WorkoutUpdated, missed[0].Type)
+
+	//* two more messages push the one after first.ID out of the buffer
+	hub.Publish(1, WorkoutDeleted, MessageData{WorkoutID: 10})
+	hub.Publish(1, WorkoutDeleted, MessageData{WorkoutID: 11})
+	_, _, complete, cancel2 := hub.Listen(1, first.ID)
+	defer cancel2()
+	assert.False(t, complete)
+
+	_, _, complete, cancel3 := hub.Listen(1, 42) //* id from before a restart
+	defer cancel3()
+	assert.False(t, complete)
+}
@@ -0,0 +1,164 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// ! Message --> one pushed update, ID is what clients send back as Last-Event-ID
+type Message struct {
+	ID   uint64
+	Type string
+	Data MessageData
+}
+
+// ! MessageData --> JSON body of a pushed update
+type MessageData struct {
+	WorkoutID int       `json:"workout_id,omitempty"`
+	Ref       string    `json:"ref,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// ! Hub --> per-user fan-out of bus events to live connections (SSE), with a short replay buffer
+// ? in-process only: a client reconnecting to another instance gets a resync instead of a replay
+type Hub struct {
+	ReplayWindow time.Duration //* how long after disconnecting a user's messages are still buffered
+
+	mu          sync.Mutex
+	startID     uint64 //* ids at or below this are from before the restart
+	lastID      uint64
+	historySize int
+	users       map[int]*hubUser
+	published   int
+}
+
+type hubUser struct {
+	history   []Message //* newest last, at most historySize
+	trimmedID uint64    //* newest id pushed out of history, replays from before it are incomplete
+	listeners map[chan Message]struct{}
+	leftAt    time.Time //* when the last listener went away
+}
+
+// ! NewHub --> historySize messages per connected (or recently connected) user are kept for Last-Event-ID replay
+func NewHub(historySize int, replayWindow time.Duration) *Hub {
+	//* ids keep increasing across restarts, so an id from before a restart is recognised as stale
+	start := uint64(time.Now().UnixMilli()) * 1000
+	return &Hub{
+		ReplayWindow: replayWindow,
+		startID:      start,
+		lastID:       start,
+		historySize:  max(historySize, 1),
+		users:        map[int]*hubUser{},
+	}
+}
+
+// ! Forward --> pushes bus events of these types to the event's user
+func (h *Hub) Forward(bus *Bus, types ...string) {
+	bus.Subscribe(func(e Event) error {
+		h.Publish(e.UserID, e.Type, MessageData{WorkoutID: e.WorkoutID, Ref: e.Ref, At: e.At})
+		return nil
+	}, types...)
+}
+
+// ! Publish --> buffers the message and hands it to the user's open connections
+// ? users who aren't connected (and weren't within ReplayWindow) are skipped entirely
+// ? a connection that can't keep up is closed, the client reconnects and replays from Last-Event-ID
+func (h *Hub) Publish(userID int, msgType string, data MessageData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	h.published++
+	if h.published%1024 == 0 {
+		h.prune()
+	}
+
+	u := h.users[userID]
+	if u == nil {
+		return
+	}
+	msg := Message{ID: h.lastID, Type: msgType, Data: data}
+	u.history = append(u.history, msg)
+	if len(u.history) > h.historySize {
+		u.trimmedID = u.history[0].ID
+		u.history = u.history[1:]
+	}
+
+	for ch := range u.listeners {
+		select {
+		case ch <- msg:
+		default:
+			h.remove(userID, ch)
+		}
+	}
+}
+
+// ! Listen --> live messages for userID plus what was missed since lastEventID (0 for a fresh connection)
+// ? complete is false when the missed messages are no longer buffered, the client should refetch
+// ? the channel is closed when the connection falls behind or Close is called, cancel must always be called
+func (h *Hub) Listen(userID int, lastEventID uint64) (ch <-chan Message, missed []Message, complete bool, cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	u := h.users[userID]
+	if u == nil {
+		//* nothing was buffered for the user, anything published after lastEventID is lost
+		u = &hubUser{listeners: map[chan Message]struct{}{}, trimmedID: h.lastID}
+		h.users[userID] = u
+	}
+
+	complete = true
+	if lastEventID > 0 {
+		complete = lastEventID > h.startID && lastEventID <= h.lastID && lastEventID >= u.trimmedID
+		for _, msg := range u.history {
+			if msg.ID > lastEventID {
+				missed = append(missed, msg)
+			}
+		}
+	}
+
+	c := make(chan Message, 32)
+	u.listeners[c] = struct{}{}
+	return c, missed, complete, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(userID, c)
+	}
+}
+
+// ! remove --> drops + closes a listener, safe to call twice, h.mu must be held
+func (h *Hub) remove(userID int, ch chan Message) {
+	u := h.users[userID]
+	if u == nil {
+		return
+	}
+	if _, ok := u.listeners[ch]; !ok {
+		return
+	}
+	delete(u.listeners, ch)
+	close(ch)
+	if len(u.listeners) == 0 {
+		u.leftAt = time.Now()
+	}
+}
+
+// ! prune --> forgets users who have been gone longer than ReplayWindow, h.mu must be held
+func (h *Hub) prune() {
+	cutoff := time.Now().Add(-h.ReplayWindow)
+	for userID, u := range h.users {
+		if len(u.listeners) == 0 && u.leftAt.Before(cutoff) {
+			delete(h.users, userID)
+		}
+	}
+}
+
+// ! Close --> ends every open connection (server shutdown)
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, u := range h.users {
+		for ch := range u.listeners {
+			h.remove(userID, ch)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ! Kafka wire protocol --> only what a producer of one topic needs: Metadata v1 to find partition leaders,
+// ! Produce v3 with a v2 record batch (Kafka 0.11+). No compression, idempotence or batching across calls
+const (
+	kafkaAPIProduce  = 0
+	kafkaAPIMetadata = 3
+
+	kafkaAcksAll = -1 //* the leader waits for every in-sync replica
+
+	kafkaErrUnknownTopicOrPartition = 3
+	kafkaErrLeaderNotAvailable      = 5
+	kafkaErrNotLeaderForPartition   = 6
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ! KafkaPublisher --> every message goes to one topic, keyed by user id so a user's events stay in order
+// ? partitions are picked like Kafka's default partitioner (murmur2 of the key), so other producers agree
+type KafkaPublisher struct {
+	brokers  []string
+	topic    string
+	clientID string
+	timeout  time.Duration //* broker-side wait for the replicas, sent with every produce
+	dialer   net.Dialer
+
+	mu            sync.Mutex
+	correlationID int32
+	leaders       []string //* partition --> leader host:port, nil until the first metadata request
+	conns         map[string]net.Conn
+}
+
+// ! NewKafkaPublisher --> brokers are bootstrap host:port pairs, any one of them is enough to find the rest
+func NewKafkaPublisher(brokers []string, topic string) (*KafkaPublisher, error) {
+	list := []string{}
+	for _, broker := range brokers {
+		broker = strings.TrimSpace(broker)
+		if broker == "" {
+			continue
+		}
+		_, _, err := net.SplitHostPort(broker)
+		if err != nil {
+			return nil, fmt.Errorf("events: KAFKA_BROKERS: %w", err)
+		}
+		list = append(list, broker)
+	}
+	if len(list) == 0 {
+		return nil, errors.New("events: KAFKA_BROKERS must name at least one broker")
+	}
+	if topic == "" {
+		return nil, errors.New("events: KAFKA_TOPIC must not be empty")
+	}
+	return &KafkaPublisher{brokers: list, topic: topic, clientID: "fittrack", timeout: 10 * time.Second, conns: map[string]net.Conn{}}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msg *StreamMessage, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := []byte(strconv.Itoa(msg.Data.UserID))
+	err := p.produce(ctx, key, body, msg.OccurredAt)
+	if err != nil {
+		//* stale leaders or a broken connection, start over from the bootstrap brokers next time
+		p.reset()
+		return fmt.Errorf("events: kafka produce: %w", err)
+	}
+	return nil
+}
+
+// * produce --> p.mu must be held
+func (p *KafkaPublisher) produce(ctx context.Context, key, value []byte, at time.Time) error {
+	if p.leaders == nil {
+		err := p.refreshMetadata(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	partition := int32(kafkaPartition(key, len(p.leaders)))
+	leader := p.leaders[partition]
+	if leader == "" {
+		return fmt.Errorf("partition %d has no leader", partition)
+	}
+
+	batch := encodeRecordBatch(key, value, at)
+	req := &kafkaEncoder{}
+	req.int16(-1) //* transactional_id
+	req.int16(kafkaAcksAll)
+	req.int32(int32(p.timeout / time.Millisecond))
+	req.int32(1)
+	req.string(p.topic)
+	req.int32(1)
+	req.int32(partition)
+	req.int32(int32(len(batch)))
+	req.buf.Write(batch)
+
+	resp, err := p.roundTrip(ctx, leader, kafkaAPIProduce, 3, req.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	for topics := resp.int32(); topics > 0; topics-- {
+		resp.string()
+		for partitions := resp.int32(); partitions > 0; partitions-- {
+			resp.int32()
+			code := resp.int16()
+			resp.int64() //* base offset
+			resp.int64() //* log append time
+			if code != 0 && resp.err == nil {
+				return kafkaError(code)
+			}
+		}
+	}
+	return resp.err
+}
+
+// * refreshMetadata --> the topic's partition leaders from the first bootstrap broker that answers
+func (p *KafkaPublisher) refreshMetadata(ctx context.Context) error {
+	req := &kafkaEncoder{}
+	req.int32(1)
+	req.string(p.topic)
+
+	var lastErr error
+	for _, broker := range p.brokers {
+		resp, err := p.roundTrip(ctx, broker, kafkaAPIMetadata, 1, req.buf.Bytes())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		leaders, err := decodeMetadata(resp, p.topic)
+		if err != nil {
+			return err
+		}
+		p.leaders = leaders
+		return nil
+	}
+	return lastErr
+}
+
+// * decodeMetadata --> Metadata v1 response, partition index --> leader address
+func decodeMetadata(resp *kafkaDecoder, topic string) ([]string, error) {
+	hosts := map[int32]string{}
+	for brokers := resp.int32(); brokers > 0 && resp.err == nil; brokers-- {
+		nodeID := resp.int32()
+		host := resp.string()
+		port := resp.int32()
+		resp.string() //* rack
+		hosts[nodeID] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	resp.int32() //* controller id
+
+	var leaders []string
+	for topics := resp.int32(); topics > 0 && resp.err == nil; topics-- {
+		code := resp.int16()
+		name := resp.string()
+		resp.int8() //* is_internal
+		partitions := []string{}
+		for count := resp.int32(); count > 0 && resp.err == nil; count-- {
+			resp.int16() //* partition error, a missing leader shows up as leader -1
+			index := resp.int32()
+			leader := resp.int32()
+			resp.skipInt32Array() //* replicas
+			resp.skipInt32Array() //* isr
+			for int(index) >= len(partitions) {
+				partitions = append(partitions, "")
+			}
+			partitions[index] = hosts[leader]
+		}
+		if name != topic {
+			continue
+		}
+		if code != 0 {
+			return nil, kafkaError(code)
+		}
+		leaders = partitions
+	}
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	if len(leaders) == 0 {
+		return nil, fmt.Errorf("topic %s has no partitions", topic)
+	}
+	return leaders, nil
+}
+
+// * roundTrip --> one request on the (cached) connection to addr, the response body after the correlation id
+func (p *KafkaPublisher) roundTrip(ctx context.Context, addr string, apiKey, apiVersion int16, body []byte) (*kafkaDecoder, error) {
+	conn := p.conns[addr]
+	if conn == nil {
+		var err error
+		conn, err = p.dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		p.conns[addr] = conn
+	}
+	setDeadline(ctx, conn)
+
+	p.correlationID++
+	req := &kafkaEncoder{}
+	req.int16(apiKey)
+	req.int16(apiVersion)
+	req.int32(p.correlationID)
+	req.string(p.clientID)
+	req.buf.Write(body)
+
+	frame := binary.BigEndian.AppendUint32(nil, uint32(req.buf.Len()))
+	_, err := conn.Write(append(frame, req.buf.Bytes()...))
+	if err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	_, err = io.ReadFull(conn, size[:])
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint32(size[:]))
+	_, err = io.ReadFull(conn, resp)
+	if err != nil {
+		return nil, err
+	}
+	dec := &kafkaDecoder{b: resp}
+	if id := dec.int32(); id != p.correlationID {
+		return nil, fmt.Errorf("response %d to request %d", id, p.correlationID)
+	}
+	return dec, dec.err
+}
+
+// * reset --> p.mu must be held
+func (p *KafkaPublisher) reset() {
+	for addr, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, addr)
+	}
+	p.leaders = nil
+}
+
+func (p *KafkaPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
+
+// * kafkaError --> the codes a producer runs into, by name
+func kafkaError(code int16) error {
+	switch code {
+	case kafkaErrUnknownTopicOrPartition:
+		return errors.New("kafka: unknown topic or partition")
+	case kafkaErrLeaderNotAvailable:
+		return errors.New("kafka: leader not available")
+	case kafkaErrNotLeaderForPartition:
+		return errors.New("kafka: not leader for partition")
+	}
+	return fmt.Errorf("kafka: error code %d", code)
+}
+
+// ! encodeRecordBatch --> a v2 record batch holding one record, no headers
+func encodeRecordBatch(key, value []byte, at time.Time) []byte {
+	record := &kafkaEncoder{}
+	record.int8(0)   //* attributes
+	record.varint(0) //* timestamp delta
+	record.varint(0) //* offset delta
+	record.varint(int64(len(key)))
+	record.buf.Write(key)
+	record.varint(int64(len(value)))
+	record.buf.Write(value)
+	record.varint(0) //* headers
+
+	//* everything after the crc field, which is what it covers
+	tail := &kafkaEncoder{}
+	tail.int16(0) //* attributes: no compression, CreateTime
+	tail.int32(0) //* last offset delta
+	tail.int64(at.UnixMilli())
+	tail.int64(at.UnixMilli())
+	tail.int64(-1) //* producer id
+	tail.int16(-1) //* producer epoch
+	tail.int32(-1) //* base sequence
+	tail.int32(1)  //* records
+	tail.varint(int64(record.buf.Len()))
+	tail.buf.Write(record.buf.Bytes())
+
+	batch := &kafkaEncoder{}
+	batch.int64(0)                                 //* base offset, the broker assigns it
+	batch.int32(int32(4 + 1 + 4 + tail.buf.Len())) //* length from the leader epoch on
+	batch.int32(-1)                                //* partition leader epoch
+	batch.int8(2)                                  //* magic
+	batch.int32(int32(crc32.Checksum(tail.buf.Bytes(), castagnoli)))
+	batch.buf.Write(tail.buf.Bytes())
+	return batch.buf.Bytes()
+}
+
+// ! kafkaPartition --> Kafka's default partitioner for keyed records, toPositive(murmur2(key)) % partitions
+func kafkaPartition(key []byte, partitions int) int {
+	return int(murmur2(key)&0x7fffffff) % partitions
+}
+
+// * murmur2 --> the 32-bit variant with the seed the Java client uses
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	rest := data[length&^3:]
+	switch len(rest) {
+	case 3:
+		h ^= uint32(rest[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(rest[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(rest[0])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// * kafkaEncoder --> big-endian fields + zigzag varints into a buffer
+type kafkaEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *kafkaEncoder) int8(v int8)    { e.buf.WriteByte(byte(v)) }
+func (e *kafkaEncoder) int16(v int16)  { e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(v))) }
+func (e *kafkaEncoder) int32(v int32)  { e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(v))) }
+func (e *kafkaEncoder) int64(v int64)  { e.buf.Write(binary.BigEndian.AppendUint64(nil, uint64(v))) }
+func (e *kafkaEncoder) varint(v int64) { e.buf.Write(binary.AppendVarint(nil, v)) }
+
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf.WriteString(s)
+}
+
+// * kafkaDecoder --> reads fields off a response, the first short read sticks in err and zeroes the rest
+type kafkaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if len(d.b) < n {
+		d.err = io.ErrUnexpectedEOF
+		return make([]byte, n)
+	}
+	out := d.b[:n]
+	d.b = d.b[n:]
+	return out
+}
+
+func (d *kafkaDecoder) int8() int8   { return int8(d.next(1)[0]) }
+func (d *kafkaDecoder) int16() int16 { return int16(binary.BigEndian.Uint16(d.next(2))) }
+func (d *kafkaDecoder) int32() int32 { return int32(binary.BigEndian.Uint32(d.next(4))) }
+func (d *kafkaDecoder) int64() int64 { return int64(binary.BigEndian.Uint64(d.next(8))) }
+
+// * string --> nullable strings (length -1) read as ""
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+func (d *kafkaDecoder) skipInt32Array() {
+	for n := d.int32(); n > 0 && d.err == nil; n-- {
+		d.int32()
+	}
+}
+
+var _ Publisher = (*KafkaPublisher)(nil)
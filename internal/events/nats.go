@@ -0,0 +1,206 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ! NATSPublisher --> core NATS over its text protocol, one connection opened on first use and again after a failure
+// ? every PUB is followed by a PING, the PONG proves the server took it (or -ERR says why not)
+type NATSPublisher struct {
+	addr      string
+	tls       bool
+	connect   natsConnect
+	prefix    string
+	dialer    net.Dialer
+	tlsConfig *tls.Config
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// * natsConnect --> CONNECT options, the credentials come from the URL
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	Protocol int    `json:"protocol"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+// * natsInfo --> the part of the server's INFO we act on
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// ! NewNATSPublisher --> nats://[user:pass@|token@]host:port, tls:// to require TLS; subjects are prefix.<event type>
+func NewNATSPublisher(rawURL, prefix string) (*NATSPublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("events: invalid NATS_URL %q", rawURL)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("events: NATS_URL must start with nats:// or tls://")
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	connect := natsConnect{Name: "fittrack", Lang: "go", Version: "1", Protocol: 1}
+	if u.User != nil {
+		password, ok := u.User.Password()
+		if ok {
+			connect.User, connect.Pass = u.User.Username(), password
+		} else {
+			connect.Token = u.User.Username()
+		}
+	}
+	return &NATSPublisher{
+		addr:      addr,
+		tls:       u.Scheme == "tls",
+		connect:   connect,
+		prefix:    strings.TrimSuffix(prefix, "."),
+		tlsConfig: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12},
+	}, nil
+}
+
+// ! Subject --> where an event type is published, e.g. fittrack.workout.created
+func (p *NATSPublisher) Subject(eventType string) string {
+	if p.prefix == "" {
+		return eventType
+	}
+	return p.prefix + "." + eventType
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, msg *StreamMessage, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.publish(ctx, p.Subject(msg.Type), body)
+	if err != nil {
+		p.closeConn() //* the connection is in an unknown state, the next publish dials again
+		return fmt.Errorf("events: nats publish: %w", err)
+	}
+	return nil
+}
+
+// * publish --> p.mu must be held
+func (p *NATSPublisher) publish(ctx context.Context, subject string, body []byte) error {
+	if p.conn == nil {
+		err := p.dial(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	setDeadline(ctx, p.conn)
+
+	_, err := fmt.Fprintf(p.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(body), body)
+	if err != nil {
+		return err
+	}
+	return p.awaitPong()
+}
+
+// * dial --> TCP (+ TLS), INFO, CONNECT and a PING to see the server accepted the credentials
+func (p *NATSPublisher) dial(ctx context.Context) error {
+	conn, err := p.dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	setDeadline(ctx, conn)
+	p.conn, p.reader = conn, bufio.NewReader(conn)
+
+	line, err := p.readLine()
+	if err != nil {
+		return err
+	}
+	var info natsInfo
+	payload, ok := strings.CutPrefix(line, "INFO ")
+	if !ok || json.Unmarshal([]byte(payload), &info) != nil {
+		return fmt.Errorf("expected INFO, got %q", line)
+	}
+	if info.TLSRequired || p.tls {
+		tlsConn := tls.Client(conn, p.tlsConfig)
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			return err
+		}
+		p.conn, p.reader = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	options, err := json.Marshal(p.connect)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(p.conn, "CONNECT %s\r\nPING\r\n", options)
+	if err != nil {
+		return err
+	}
+	return p.awaitPong()
+}
+
+// * awaitPong --> answers the server's PINGs and skips +OK / INFO until our PONG, -ERR fails
+func (p *NATSPublisher) awaitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			_, err = p.conn.Write([]byte("PONG\r\n"))
+			if err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+	}
+}
+
+func (p *NATSPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// * closeConn --> p.mu must be held
+func (p *NATSPublisher) closeConn() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn, p.reader = nil, nil
+	}
+}
+
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeConn()
+	return nil
+}
+
+// * setDeadline --> ctx's deadline for the whole exchange, none without one
+func setDeadline(ctx context.Context, conn net.Conn) {
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+}
+
+var _ Publisher = (*NATSPublisher)(nil)
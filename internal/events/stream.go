@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// ! SchemaVersion --> version of StreamMessage, bumped on any change consumers could trip over
+// ? adding an optional field is not one of them; renaming, removing or retyping is
+const SchemaVersion = 1
+
+// ! StreamEvents --> the bus events EVENTS_PUBLISHER sends to NATS / Kafka
+var StreamEvents = []string{WorkoutCreated, WorkoutUpdated, WorkoutDeleted, UserRegistered}
+
+// ! StreamMessage --> the JSON document every backend publishes, described by schema/event.v1.json
+type StreamMessage struct {
+	SchemaVersion int        `json:"schema_version"`
+	ID            string     `json:"id"` //* random, the same event can arrive twice (the outbox is at-least-once), dedupe on it
+	Type          string     `json:"type"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+	Data          StreamData `json:"data"`
+}
+
+// ! StreamData --> ids are the database ids, not the encoded ones the HTTP API hands out
+type StreamData struct {
+	UserID    int `json:"user_id"`
+	WorkoutID int `json:"workout_id,omitempty"` //* workout events only
+}
+
+// ! NewStreamMessage --> the current schema's message for a bus event
+func NewStreamMessage(e Event) (*StreamMessage, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamMessage{
+		SchemaVersion: SchemaVersion,
+		ID:            hex.EncodeToString(id),
+		Type:          e.Type,
+		OccurredAt:    e.At.UTC(),
+		Data:          StreamData{UserID: e.UserID, WorkoutID: e.WorkoutID},
+	}, nil
+}
+
+// ! Publisher --> a message broker backend, safe for concurrent use
+type Publisher interface {
+	// * Publish --> body is msg marshalled, backends only pick the subject / topic + key from msg
+	Publish(ctx context.Context, msg *StreamMessage, body []byte) error
+	Close() error
+}
+
+// ! Streamer --> hands bus events to a Publisher
+// ? a failed publish is logged and the message dropped, never returned: the broker is for analytics, not for state,
+// ? and an error would hold the outbox (and every other subscriber) until the broker is back
+type Streamer struct {
+	Publisher Publisher
+	Timeout   time.Duration //* per publish, connecting included
+	Logger    *log.Logger
+}
+
+// ! Forward --> publishes bus events of these types
+func (s *Streamer) Forward(bus *Bus, types ...string) {
+	bus.Subscribe(func(e Event) error {
+		msg, err := NewStreamMessage(e)
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+		defer cancel()
+		err = s.Publisher.Publish(ctx, msg, body)
+		if err != nil {
+			s.Logger.Printf("ERROR: events publisher: %s dropped: %v", e.Type, err)
+		}
+		return nil
+	}, types...)
+}
+
+// ! NewPublisherFromEnv --> EVENTS_PUBLISHER=nats or kafka, nil when unset
+// ? nats: NATS_URL (credentials in the URL), subjects <NATS_SUBJECT_PREFIX>.<type>
+// ? kafka: KAFKA_BROKERS (comma separated host:port), one KAFKA_TOPIC keyed by user id
+func NewPublisherFromEnv() (Publisher, error) {
+	switch backend := os.Getenv("EVENTS_PUBLISHER"); backend {
+	case "":
+		return nil, nil
+	case "nats":
+		return NewNATSPublisher(utils.GetEnv("NATS_URL", "nats://localhost:4222"), utils.GetEnv("NATS_SUBJECT_PREFIX", "fittrack"))
+	case "kafka":
+		brokers := strings.Split(utils.GetEnv("KAFKA_BROKERS", "localhost:9092"), ",")
+		return NewKafkaPublisher(brokers, utils.GetEnv("KAFKA_TOPIC", "fittrack.events"))
+	default:
+		return nil, fmt.Errorf("events: EVENTS_PUBLISHER must be nats or kafka, got %q", backend)
+	}
+}
@@ -0,0 +1,236 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestStreamMessageSchema --> messages carry what schema/event.v1.json requires, for every streamed type
+func TestStreamMessageSchema(t *testing.T) {
+	raw, err := os.ReadFile("schema/event.v1.json")
+	require.NoError(t, err)
+	var schema struct {
+		Required   []string `json:"required"`
+		Properties struct {
+			SchemaVersion struct {
+				Const int `json:"const"`
+			} `json:"schema_version"`
+			Type struct {
+				Enum []string `json:"enum"`
+			} `json:"type"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &schema))
+	assert.Equal(t, SchemaVersion, schema.Properties.SchemaVersion.Const)
+	assert.ElementsMatch(t, StreamEvents, schema.Properties.Type.Enum)
+
+	msg, err := NewStreamMessage(Event{Type: WorkoutCreated, UserID: 7, WorkoutID: 42, At: time.Now()})
+	require.NoError(t, err)
+	body, err := json.Marshal(msg)
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(body, &fields))
+	for _, key := range schema.Required {
+		assert.Contains(t, fields, key)
+	}
+	assert.Len(t, msg.ID, 32)
+}
+
+// ! TestNATSPublisher --> token from the URL goes into CONNECT, the message lands on prefix.<type>
+func TestNATSPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	type published struct{ connect, subject, body string }
+	got := make(chan published, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		var p published
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				p.connect = line
+			case line == "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+				if p.subject != "" {
+					got <- p
+				}
+			case strings.HasPrefix(line, "PUB "):
+				parts := strings.Fields(line)
+				size, _ := strconv.Atoi(parts[2])
+				payload := make([]byte, size+2)
+				io.ReadFull(reader, payload)
+				p.subject, p.body = parts[1], string(payload[:size])
+			}
+		}
+	}()
+
+	publisher, err := NewNATSPublisher("nats://s3cret@"+listener.Addr().String(), "fittrack")
+	require.NoError(t, err)
+	defer publisher.Close()
+	msg := &StreamMessage{Type: UserRegistered, Data: StreamData{UserID: 3}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, publisher.Publish(ctx, msg, []byte(`{"hello":"nats"}`)))
+
+	p := <-got
+	assert.Contains(t, p.connect, `"auth_token":"s3cret"`)
+	assert.Equal(t, "fittrack.user.registered", p.subject)
+	assert.Equal(t, `{"hello":"nats"}`, p.body)
+}
+
+// ! TestKafkaPublisher --> metadata, then a produce to the key's partition with a record batch the broker would accept
+func TestKafkaPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	host, portText, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.Atoi(portText)
+
+	type produced struct {
+		partition int32
+		crcOK     bool
+		key       string
+		value     string
+	}
+	got := make(chan produced, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var size [4]byte
+			if _, err := io.ReadFull(conn, size[:]); err != nil {
+				return
+			}
+			req := &kafkaDecoder{b: make([]byte, binary.BigEndian.Uint32(size[:]))}
+			if _, err := io.ReadFull(conn, req.b); err != nil {
+				return
+			}
+			apiKey, _, correlationID := req.int16(), req.int16(), req.int32()
+			req.string() //* client id
+
+			resp := &kafkaEncoder{}
+			resp.int32(correlationID)
+			switch apiKey {
+			case kafkaAPIMetadata:
+				resp.int32(1) //* one broker, this one
+				resp.int32(0)
+				resp.string(host)
+				resp.int32(int32(port))
+				resp.int16(-1)
+				resp.int32(0)
+				resp.int32(1) //* one topic, three partitions led by broker 0
+				resp.int16(0)
+				resp.string("fittrack.events")
+				resp.int8(0)
+				resp.int32(3)
+				for i := range 3 {
+					resp.int16(0)
+					resp.int32(int32(i))
+					resp.int32(0)
+					resp.int32(0)
+					resp.int32(0)
+				}
+			case kafkaAPIProduce:
+				req.string() //* transactional id
+				req.int16()
+				req.int32()
+				req.int32()
+				topic := req.string()
+				req.int32()
+				var p produced
+				p.partition = req.int32()
+				batch := &kafkaDecoder{b: req.next(int(req.int32()))}
+				batch.int64()
+				batch.int32()
+				batch.int32()
+				batch.int8()
+				crc := uint32(batch.int32())
+				p.crcOK = crc == crc32.Checksum(batch.b, crc32.MakeTable(crc32.Castagnoli))
+				batch.next(2 + 4 + 8 + 8 + 8 + 2 + 4 + 4)
+				record := bufio.NewReader(strings.NewReader(string(batch.b)))
+				binary.ReadVarint(record) //* length
+				record.ReadByte()
+				binary.ReadVarint(record)
+				binary.ReadVarint(record)
+				keyLen, _ := binary.ReadVarint(record)
+				key := make([]byte, keyLen)
+				io.ReadFull(record, key)
+				valueLen, _ := binary.ReadVarint(record)
+				value := make([]byte, valueLen)
+				io.ReadFull(record, value)
+				p.key, p.value = string(key), string(value)
+				got <- p
+
+				resp.int32(1)
+				resp.string(topic)
+				resp.int32(1)
+				resp.int32(p.partition)
+				resp.int16(0)
+				resp.int64(0)
+				resp.int64(-1)
+				resp.int32(0)
+			}
+			frame := binary.BigEndian.AppendUint32(nil, uint32(resp.buf.Len()))
+			conn.Write(append(frame, resp.buf.Bytes()...))
+		}
+	}()
+
+	publisher, err := NewKafkaPublisher([]string{listener.Addr().String()}, "fittrack.events")
+	require.NoError(t, err)
+	defer publisher.Close()
+	msg := &StreamMessage{Type: WorkoutCreated, OccurredAt: time.Now(), Data: StreamData{UserID: 21, WorkoutID: 5}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, publisher.Publish(ctx, msg, []byte(`{"hello":"kafka"}`)))
+
+	p := <-got
+	assert.True(t, p.crcOK)
+	assert.Equal(t, "21", p.key)
+	assert.Equal(t, `{"hello":"kafka"}`, p.value)
+	assert.Equal(t, int32(kafkaPartition([]byte("21"), 3)), p.partition)
+}
+
+// ! TestMurmur2 --> the Java client's test vectors, so records land where other producers put them
+func TestMurmur2(t *testing.T) {
+	cases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+	for key, want := range cases {
+		assert.Equal(t, want, int32(murmur2([]byte(key))), key)
+	}
+}
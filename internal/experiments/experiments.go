@@ -0,0 +1,122 @@
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ! buckets --> assignment resolution, weights are spread over this many buckets
+const buckets = 10000
+
+// ! Variant --> one arm of an experiment, Weight is relative to the other variants
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// ! Experiment --> definition loaded from EXPERIMENTS_FILE
+type Experiment struct {
+	Key      string    `json:"key"`
+	Salt     string    `json:"salt"` //* change to reshuffle everyone, defaults to Key
+	Enabled  bool      `json:"enabled"`
+	Variants []Variant `json:"variants"`
+}
+
+// ! Assignment --> the variant a user sees
+type Assignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+}
+
+// ! Assigner --> deterministic user -> variant mapping, no state to store or sync between instances
+type Assigner struct {
+	Experiments []*Experiment
+}
+
+// ! LoadFromEnv --> reads the JSON list at EXPERIMENTS_FILE, no file means no experiments
+func LoadFromEnv() (*Assigner, error) {
+	path := os.Getenv("EXPERIMENTS_FILE")
+	if path == "" {
+		return &Assigner{}, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// ! Parse --> validates definitions so a typo fails at startup instead of skewing a test
+func Parse(raw []byte) (*Assigner, error) {
+	var list []*Experiment
+	err := json.Unmarshal(raw, &list)
+	if err != nil {
+		return nil, fmt.Errorf("experiments: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, e := range list {
+		if e.Key == "" {
+			return nil, fmt.Errorf("experiments: experiment without key")
+		}
+		if seen[e.Key] {
+			return nil, fmt.Errorf("experiments: duplicate key %q", e.Key)
+		}
+		seen[e.Key] = true
+
+		total := 0
+		for _, v := range e.Variants {
+			if v.Name == "" || v.Weight < 0 {
+				return nil, fmt.Errorf("experiments: %s has an invalid variant", e.Key)
+			}
+			total += v.Weight
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("experiments: %s needs at least one weighted variant", e.Key)
+		}
+		if e.Salt == "" {
+			e.Salt = e.Key
+		}
+	}
+	return &Assigner{Experiments: list}, nil
+}
+
+// ! bucket --> stable position of a user in [0, buckets) for one experiment
+func bucket(salt string, userID int) int {
+	sum := sha256.Sum256([]byte(salt + ":" + strconv.Itoa(userID)))
+	return int(binary.BigEndian.Uint64(sum[:8]) % buckets)
+}
+
+// ! Variant --> the variant userID lands in, same answer on every call and every instance
+func (e *Experiment) Variant(userID int) string {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	point := bucket(e.Salt, userID) * total / buckets
+	for _, v := range e.Variants {
+		if point < v.Weight {
+			return v.Name
+		}
+		point -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1].Name
+}
+
+// ! Assign --> every enabled experiment for the user
+func (a *Assigner) Assign(userID int) []Assignment {
+	assignments := []Assignment{}
+	for _, e := range a.Experiments {
+		if !e.Enabled {
+			continue
+		}
+		assignments = append(assignments, Assignment{Experiment: e.Key, Variant: e.Variant(userID)})
+	}
+	return assignments
+}
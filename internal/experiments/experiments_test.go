@@ -0,0 +1,43 @@
+package experiments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRejectsBadDefinitions(t *testing.T) {
+	cases := map[string]string{
+		"no key":    `[{"variants":[{"name":"a","weight":1}]}]`,
+		"duplicate": `[{"key":"x","variants":[{"name":"a","weight":1}]},{"key":"x","variants":[{"name":"a","weight":1}]}]`,
+		"no weight": `[{"key":"x","variants":[{"name":"a","weight":0}]}]`,
+		"not json":  `{`,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(raw))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAssignmentIsDeterministicAndWeighted(t *testing.T) {
+	assigner, err := Parse([]byte(`[
+		{"key":"feed_ranking","enabled":true,"variants":[{"name":"control","weight":1},{"name":"treatment","weight":3}]},
+		{"key":"paused","enabled":false,"variants":[{"name":"control","weight":1}]}
+	]`))
+	require.NoError(t, err)
+
+	first := assigner.Assign(42)
+	require.Len(t, first, 1)
+	assert.Equal(t, first, assigner.Assign(42))
+
+	counts := map[string]int{}
+	for userID := 1; userID <= 4000; userID++ {
+		counts[assigner.Experiments[0].Variant(userID)]++
+	}
+	//* 25/75 split within a couple of percent
+	assert.InDelta(t, 1000, counts["control"], 100)
+	assert.InDelta(t, 3000, counts["treatment"], 100)
+}
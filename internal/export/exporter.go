@@ -0,0 +1,431 @@
+package export
+
+import (
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+//! Exporter --> produces export bundles on disk and keeps the job row up to date
+type Exporter struct {
+	Dir          string //* where finished bundles are written
+	ExportStore  store.ExportStore
+	OrgStore     store.OrgStore
+	UserStore    store.UserStore
+	ProfileStore store.ProfileStore
+	AccountStore store.AccountStore
+	Logger       *log.Logger
+}
+
+//! NewExporter --> constructor, creates the output directory if missing
+func NewExporter(dir string, exportStore store.ExportStore, orgStore store.OrgStore, userStore store.UserStore, profileStore store.ProfileStore, accountStore store.AccountStore, logger *log.Logger) (*Exporter, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("export : creating dir %w", err)
+	}
+	return &Exporter{
+		Dir:          dir,
+		ExportStore:  exportStore,
+		OrgStore:     orgStore,
+		UserStore:    userStore,
+		ProfileStore: profileStore,
+		AccountStore: accountStore,
+		Logger:       logger,
+	}, nil
+}
+
+//! Run --> builds the bundle for job, marking it running → done; a failure is left to the caller, see Fail
+func (e *Exporter) Run(job *store.ExportJob) error {
+	job.Status = store.ExportStatusRunning
+	err := e.ExportStore.UpdateExport(job)
+	if err != nil {
+		return err
+	}
+
+	err = e.build(job)
+	if err != nil {
+		return err
+	}
+
+	job.Status = store.ExportStatusDone
+	return e.ExportStore.UpdateExport(job)
+}
+
+//! Fail --> marks job failed for whoever polls it, the cause only goes to the log
+func (e *Exporter) Fail(job *store.ExportJob, cause error) {
+	e.Logger.Printf("ERROR: export %d failed: %v", job.ID, cause)
+	job.Status = store.ExportStatusFailed
+	job.Error = "export failed"
+	err := e.ExportStore.UpdateExport(job)
+	if err != nil {
+		e.Logger.Printf("ERROR: export %d: marking failed: %v", job.ID, err)
+	}
+}
+
+//! build --> collects the tables for the job kind and writes them to a zip file
+func (e *Exporter) build(job *store.ExportJob) error {
+	var tables []*Table
+	var err error
+
+	switch job.Kind {
+	case store.ExportKindOrg:
+		tables, err = e.orgTables(job)
+	case store.ExportKindAccount:
+		tables, err = e.accountTables(job)
+	default:
+		err = fmt.Errorf("unknown export kind %q", job.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	//* unknown tags were rejected when the export was requested, a stale one falls back to the machine format
+	locale, _ := LookupLocale(job.Locale)
+
+	path := filepath.Join(e.Dir, fmt.Sprintf("export-%d.zip", job.ID))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = WriteBundle(file, job.Format, locale, tables)
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	job.FilePath = path
+	return file.Close()
+}
+
+//! pseudonym --> stable, non-reversible stand-in for a member inside one org
+//? same member always gets the same pseudonym so owners can still follow trends over time
+func pseudonym(orgID, userID int) string {
+	return "member-" + tokens.Sign(strconv.Itoa(orgID) + ":" + strconv.Itoa(userID))[:10]
+}
+
+//! orgTables --> member stats, attendance and raw workouts/entries/measurements for an org export
+//! members who switched share_stats off are always anonymized, everyone is when job.Anonymize is set
+func (e *Exporter) orgTables(job *store.ExportJob) ([]*Table, error) {
+	if job.OrgID == nil {
+		return nil, fmt.Errorf("org export %d without org id", job.ID)
+	}
+	orgID := int64(*job.OrgID)
+
+	displayName := func(userID int, username string, shareStats bool) string {
+		if job.Anonymize || !shareStats {
+			return pseudonym(*job.OrgID, userID)
+		}
+		return username
+	}
+
+	stats, err := e.OrgStore.GetMemberStats(orgID, job.From, job.To)
+	if err != nil {
+		return nil, err
+	}
+	members := &Table{
+		Name: "members",
+		Columns: []Column{
+			{Name: "member", Type: TypeString},
+			{Name: "workouts", Type: TypeInt},
+			{Name: "total_minutes", Type: TypeInt},
+			{Name: "total_calories", Type: TypeInt},
+			{Name: "last_workout_at", Type: TypeTime},
+		},
+	}
+	for _, row := range stats {
+		members.Rows = append(members.Rows, []any{
+			displayName(row.UserID, row.Username, row.ShareStats),
+			int64(row.Workouts),
+			int64(row.TotalMinutes),
+			int64(row.TotalCalories),
+			row.LastWorkoutAt,
+		})
+	}
+
+	days, err := e.OrgStore.GetAttendance(orgID, job.From, job.To)
+	if err != nil {
+		return nil, err
+	}
+	attendance := &Table{
+		Name: "attendance",
+		Columns: []Column{
+			{Name: "member", Type: TypeString},
+			{Name: "day", Type: TypeTime},
+			{Name: "workouts", Type: TypeInt},
+		},
+	}
+	for _, row := range days {
+		attendance.Rows = append(attendance.Rows, []any{
+			displayName(row.UserID, row.Username, row.ShareStats),
+			row.Day,
+			int64(row.Workouts),
+		})
+	}
+
+	workouts, err := e.OrgStore.GetMemberWorkouts(orgID, job.From, job.To)
+	if err != nil {
+		return nil, err
+	}
+	workoutTable := &Table{
+		Name: "workouts",
+		Columns: []Column{
+			{Name: "workout_id", Type: TypeInt},
+			{Name: "member", Type: TypeString},
+			{Name: "title", Type: TypeString},
+			{Name: "duration_minutes", Type: TypeInt},
+			{Name: "calories_burned", Type: TypeInt},
+			{Name: "calories_estimated", Type: TypeBool},
+			{Name: "created_at", Type: TypeTime},
+		},
+	}
+	for _, row := range workouts {
+		workoutTable.Rows = append(workoutTable.Rows, []any{
+			int64(row.WorkoutID),
+			displayName(row.UserID, row.Username, row.ShareStats),
+			row.Title,
+			int64(row.DurationMinutes),
+			int64(row.CaloriesBurned),
+			row.CaloriesEstimated,
+			row.CreatedAt,
+		})
+	}
+
+	entries, err := e.OrgStore.GetMemberEntries(orgID, job.From, job.To)
+	if err != nil {
+		return nil, err
+	}
+	entryTable := &Table{
+		Name: "entries",
+		Columns: []Column{
+			{Name: "entry_id", Type: TypeInt},
+			{Name: "workout_id", Type: TypeInt},
+			{Name: "member", Type: TypeString},
+			{Name: "exercise_name", Type: TypeString},
+			{Name: "sets", Type: TypeInt},
+			{Name: "reps", Type: TypeInt},
+			{Name: "duration_seconds", Type: TypeInt},
+			{Name: "weight", Type: TypeFloat},
+			{Name: "order_index", Type: TypeInt},
+		},
+	}
+	for _, row := range entries {
+		entryTable.Rows = append(entryTable.Rows, []any{
+			int64(row.EntryID),
+			int64(row.WorkoutID),
+			displayName(row.UserID, row.Username, row.ShareStats),
+			row.ExerciseName,
+			int64(row.Sets),
+			optionalInt(row.Reps),
+			optionalInt(row.DurationSeconds),
+			optionalFloat(row.Weight),
+			int64(row.OrderIndex),
+		})
+	}
+
+	weights, err := e.OrgStore.GetMemberWeights(orgID, job.From, job.To)
+	if err != nil {
+		return nil, err
+	}
+	measurements := &Table{
+		Name: "measurements",
+		Columns: []Column{
+			{Name: "member", Type: TypeString},
+			{Name: "weight_kg", Type: TypeFloat},
+			{Name: "measured_at", Type: TypeTime},
+		},
+	}
+	for _, row := range weights {
+		measurements.Rows = append(measurements.Rows, []any{
+			displayName(row.UserID, row.Username, row.ShareStats),
+			row.WeightKG,
+			row.MeasuredAt,
+		})
+	}
+
+	return []*Table{members, attendance, workoutTable, entryTable, measurements}, nil
+}
+
+//! accountTables --> everything stored about the requesting user (GDPR-style data export)
+//? token hashes are left out on purpose, the user gets scope + expiry only
+func (e *Exporter) accountTables(job *store.ExportJob) ([]*Table, error) {
+	userID := job.RequestedBy
+
+	user, err := e.UserStore.GetUserByID(int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("account export %d for missing user %d", job.ID, userID)
+	}
+	profile, err := e.ProfileStore.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	profileTable := &Table{
+		Name: "profile",
+		Columns: []Column{
+			{Name: "user_id", Type: TypeInt},
+			{Name: "username", Type: TypeString},
+			{Name: "email", Type: TypeString},
+			{Name: "bio", Type: TypeString},
+			{Name: "height_cm", Type: TypeFloat},
+			{Name: "weight_kg", Type: TypeFloat},
+			{Name: "birthdate", Type: TypeString},
+			{Name: "units", Type: TypeString},
+			{Name: "events_opt_in", Type: TypeBool},
+			{Name: "leaderboard_opt_out", Type: TypeBool},
+			{Name: "created_at", Type: TypeTime},
+		},
+		Rows: [][]any{{
+			int64(user.ID),
+			user.Username,
+			user.Email,
+			user.Bio,
+			optionalFloat(profile.HeightCM),
+			optionalFloat(profile.WeightKG),
+			optionalString(profile.Birthdate),
+			profile.Units,
+			profile.EventsOptIn,
+			profile.LeaderboardOptOut,
+			user.CreatedAt,
+		}},
+	}
+
+	workouts, err := e.AccountStore.ListAccountWorkouts(userID)
+	if err != nil {
+		return nil, err
+	}
+	workoutTable := &Table{
+		Name: "workouts",
+		Columns: []Column{
+			{Name: "workout_id", Type: TypeInt},
+			{Name: "title", Type: TypeString},
+			{Name: "description", Type: TypeString},
+			{Name: "duration_minutes", Type: TypeInt},
+			{Name: "calories_burned", Type: TypeInt},
+			{Name: "calories_estimated", Type: TypeBool},
+			{Name: "visibility", Type: TypeString},
+			{Name: "flagged", Type: TypeBool},
+			{Name: "verified", Type: TypeBool},
+			{Name: "created_at", Type: TypeTime},
+		},
+	}
+	entryTable := &Table{
+		Name: "entries",
+		Columns: []Column{
+			{Name: "entry_id", Type: TypeInt},
+			{Name: "workout_id", Type: TypeInt},
+			{Name: "exercise_name", Type: TypeString},
+			{Name: "sets", Type: TypeInt},
+			{Name: "reps", Type: TypeInt},
+			{Name: "duration_seconds", Type: TypeInt},
+			{Name: "weight", Type: TypeFloat},
+			{Name: "notes", Type: TypeString},
+			{Name: "order_index", Type: TypeInt},
+		},
+	}
+	for _, w := range workouts {
+		workoutTable.Rows = append(workoutTable.Rows, []any{
+			int64(w.ID),
+			w.Title,
+			w.Description,
+			int64(w.DurationMinutes),
+			int64(w.CaloriesBurned),
+			w.CaloriesEstimated,
+			w.Visibility,
+			w.Flagged,
+			w.Verified,
+			w.CreatedAt,
+		})
+		for _, entry := range w.Entries {
+			entryTable.Rows = append(entryTable.Rows, []any{
+				int64(entry.ID),
+				int64(w.ID),
+				entry.ExerciseName,
+				int64(entry.Sets),
+				optionalInt(entry.Reps),
+				optionalInt(entry.DurationSeconds),
+				optionalFloat(entry.Weight),
+				entry.Notes,
+				int64(entry.OrderIndex),
+			})
+		}
+	}
+
+	weights, err := e.ProfileStore.ListWeights(userID, job.From, job.To)
+	if err != nil {
+		return nil, err
+	}
+	measurements := &Table{
+		Name: "measurements",
+		Columns: []Column{
+			{Name: "weight_kg", Type: TypeFloat},
+			{Name: "measured_at", Type: TypeTime},
+		},
+	}
+	for _, row := range weights {
+		measurements.Rows = append(measurements.Rows, []any{row.WeightKG, row.MeasuredAt})
+	}
+
+	tokenInfos, err := e.AccountStore.ListAccountTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+	tokenTable := &Table{
+		Name: "tokens",
+		Columns: []Column{
+			{Name: "scope", Type: TypeString},
+			{Name: "expiry", Type: TypeTime},
+		},
+	}
+	for _, info := range tokenInfos {
+		tokenTable.Rows = append(tokenTable.Rows, []any{info.Scope, info.Expiry})
+	}
+
+	comments, err := e.AccountStore.ListAccountComments(userID)
+	if err != nil {
+		return nil, err
+	}
+	commentTable := &Table{
+		Name: "comments",
+		Columns: []Column{
+			{Name: "comment_id", Type: TypeInt},
+			{Name: "workout_id", Type: TypeInt},
+			{Name: "body", Type: TypeString},
+			{Name: "created_at", Type: TypeTime},
+		},
+	}
+	for _, c := range comments {
+		commentTable.Rows = append(commentTable.Rows, []any{int64(c.ID), int64(c.WorkoutID), c.Body, c.CreatedAt})
+	}
+
+	return []*Table{profileTable, workoutTable, entryTable, measurements, tokenTable, commentTable}, nil
+}
+
+//! optionalInt / optionalFloat --> nullable db columns become nil cells instead of typed nil pointers
+func optionalInt(value *int) any {
+	if value == nil {
+		return nil
+	}
+	return int64(*value)
+}
+
+func optionalFloat(value *float64) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func optionalString(value *string) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
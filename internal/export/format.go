@@ -0,0 +1,147 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+//! supported output formats
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+	FormatJSON    = "json"
+)
+
+//! column types --> stable schema so every format encodes values the same way
+const (
+	TypeString = "string"
+	TypeInt    = "int64"
+	TypeFloat  = "float64"
+	TypeBool   = "bool"
+	TypeTime   = "timestamp"
+)
+
+// ? - one column of an export table
+type Column struct {
+	Name string
+	Type string
+}
+
+// ? - a named table of rows, becomes one file inside the bundle (members.csv, attendance.csv ...)
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any // * values must match the column types, nil means null
+}
+
+//! ValidFormat --> true for formats WriteBundle knows how to produce
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatCSV, FormatParquet, FormatJSON:
+		return true
+	}
+	return false
+}
+
+//! WriteBundle --> zips every table into w using the requested format, locale only affects CSV
+func WriteBundle(w io.Writer, format string, locale Locale, tables []*Table) error {
+	archive := zip.NewWriter(w)
+	for _, table := range tables {
+		file, err := archive.Create(table.Name + "." + format)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case FormatCSV:
+			err = writeCSV(file, table, locale)
+		case FormatParquet:
+			err = writeParquet(file, table)
+		case FormatJSON:
+			err = writeJSON(file, table)
+		default:
+			err = fmt.Errorf("export : unsupported format %q", format)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+//! writeCSV --> header row + one line per row
+func writeCSV(w io.Writer, table *Table, locale Locale) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = locale.comma()
+
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Name
+	}
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, value := range row {
+			record[i] = formatCSVValue(value, locale)
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+//! writeJSON --> array of objects keyed by column name, nulls stay null
+func writeJSON(w io.Writer, table *Table) error {
+	records := make([]map[string]any, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		record := make(map[string]any, len(table.Columns))
+		for i, column := range table.Columns {
+			record[column.Name] = row[i]
+		}
+		records = append(records, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+//! formatCSVValue --> text in the locale's number / date format, nulls become empty cells
+func formatCSVValue(value any, locale Locale) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return locale.formatFloat(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return locale.formatTime(v)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return locale.formatTime(*v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,132 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! sampleTable --> covers every column type plus a null cell
+func sampleTable() *Table {
+	day := time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC)
+	return &Table{
+		Name: "workouts",
+		Columns: []Column{
+			{Name: "workout_id", Type: TypeInt},
+			{Name: "member", Type: TypeString},
+			{Name: "weight", Type: TypeFloat},
+			{Name: "estimated", Type: TypeBool},
+			{Name: "created_at", Type: TypeTime},
+		},
+		Rows: [][]any{
+			{int64(1), "alice", 82.5, true, day},
+			{int64(2), "member-ab12", nil, false, day.Add(time.Hour)},
+		},
+	}
+}
+
+// ! readBundle --> unzips a bundle into name --> contents
+func readBundle(t *testing.T, data []byte) map[string][]byte {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		contents, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		files[file.Name] = contents
+	}
+	return files
+}
+
+func TestWriteBundleCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteBundle(&buf, FormatCSV, Locale{}, []*Table{sampleTable()}))
+
+	files := readBundle(t, buf.Bytes())
+	assert.Equal(t,
+		"workout_id,member,weight,estimated,created_at\n"+
+			"1,alice,82.5,true,2024-03-01T07:30:00Z\n"+
+			"2,member-ab12,,false,2024-03-01T08:30:00Z\n",
+		string(files["workouts.csv"]))
+}
+
+func TestWriteBundleParquet(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteBundle(&buf, FormatParquet, Locale{}, []*Table{sampleTable()}))
+
+	files := readBundle(t, buf.Bytes())
+	data := files["workouts.parquet"]
+	require.NotEmpty(t, data)
+
+	// ? - reading back through a struct proves the schema names + types line up
+	type workoutRow struct {
+		WorkoutID int64     `parquet:"workout_id"`
+		Member    string    `parquet:"member"`
+		Weight    *float64  `parquet:"weight"`
+		Estimated bool      `parquet:"estimated"`
+		CreatedAt time.Time `parquet:"created_at,timestamp(millisecond)"`
+	}
+	rows, err := parquet.Read[workoutRow](bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, int64(1), rows[0].WorkoutID)
+	assert.Equal(t, "alice", rows[0].Member)
+	require.NotNil(t, rows[0].Weight)
+	assert.Equal(t, 82.5, *rows[0].Weight)
+	assert.True(t, rows[0].Estimated)
+	assert.True(t, rows[0].CreatedAt.Equal(time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC)))
+	assert.Nil(t, rows[1].Weight) // * null survives the round trip
+}
+
+func TestValidFormat(t *testing.T) {
+	assert.True(t, ValidFormat(FormatCSV))
+	assert.True(t, ValidFormat(FormatParquet))
+	assert.False(t, ValidFormat("xlsx"))
+}
+
+func TestWriteBundleJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteBundle(&buf, FormatJSON, Locale{}, []*Table{sampleTable()}))
+
+	files := readBundle(t, buf.Bytes())
+	assert.JSONEq(t, `[
+		{"workout_id": 1, "member": "alice", "weight": 82.5, "estimated": true, "created_at": "2024-03-01T07:30:00Z"},
+		{"workout_id": 2, "member": "member-ab12", "weight": null, "estimated": false, "created_at": "2024-03-01T08:30:00Z"}
+	]`, string(files["workouts.json"]))
+}
+
+func TestWriteBundleCSVLocale(t *testing.T) {
+	locale, ok := LookupLocale("de-AT")
+	require.True(t, ok)
+
+	table := sampleTable()
+	table.Rows = append(table.Rows, []any{int64(3), "bob", 70.25, false, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)})
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBundle(&buf, FormatCSV, locale, []*Table{table}))
+
+	files := readBundle(t, buf.Bytes())
+	assert.Equal(t,
+		"workout_id;member;weight;estimated;created_at\n"+
+			"1;alice;82,5;true;01.03.2024 07:30\n"+
+			"2;member-ab12;;false;01.03.2024 08:30\n"+
+			"3;bob;70,25;false;02.03.2024\n",
+		string(files["workouts.csv"]))
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	assert.Equal(t, "fr-FR", NegotiateLocale("xx-YY, fr-CH;q=0.8, en;q=0.5"))
+	assert.Equal(t, "en-GB", NegotiateLocale("en-gb"))
+	assert.Equal(t, "", NegotiateLocale("ja-JP"))
+	assert.Equal(t, "", NegotiateLocale(""))
+}
@@ -0,0 +1,43 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/store"
+	"fem/internal/worker"
+)
+
+// ! JobRun --> background job that builds one export bundle
+const JobRun = "export.run"
+
+// ! RunPayload --> export.run, the exports row to build
+type RunPayload struct {
+	ExportID int64 `json:"export_id"`
+}
+
+// ! RunJob --> builds the export unless it already finished (a retried job must not rebuild it)
+// ? a failed build goes back to the worker for a retry, the last attempt marks the export failed so polling ends
+func RunJob(e *Exporter) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p RunPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+
+		job, err := e.ExportStore.GetExport(p.ExportID)
+		if err != nil {
+			return err
+		}
+		if job == nil || job.Status == store.ExportStatusDone || job.Status == store.ExportStatusFailed {
+			return nil
+		}
+		err = e.Run(job)
+		if err != nil {
+			if info, ok := worker.Info(ctx); !ok || info.LastAttempt() {
+				e.Fail(job, err)
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * countingExportStore --> counts how often an export was set running, one per build attempt
+type countingExportStore struct {
+	store.ExportStore
+	mu      sync.Mutex
+	running int
+}
+
+func (s *countingExportStore) UpdateExport(job *store.ExportJob) error {
+	s.mu.Lock()
+	if job.Status == store.ExportStatusRunning {
+		s.running++
+	}
+	s.mu.Unlock()
+	return s.ExportStore.UpdateExport(job)
+}
+
+func (s *countingExportStore) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// * newTestExport --> an export of a kind the exporter can't build
+func newTestExport(t *testing.T) (*Exporter, *countingExportStore, *memstore.DB, *store.ExportJob) {
+	db := memstore.New()
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, memstore.NewUserStore(db).CreateUser(user))
+	exports := &countingExportStore{ExportStore: memstore.NewExportStore(db)}
+	exporter, err := NewExporter(t.TempDir(), exports, memstore.NewOrgStore(db), memstore.NewUserStore(db), nil, nil, log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+
+	job := &store.ExportJob{Kind: "bogus", RequestedBy: user.ID, Format: FormatCSV}
+	require.NoError(t, exports.CreateExport(job))
+	return exporter, exports, db, job
+}
+
+// ! TestRunJobRetriesThenFails --> a failing build is retried by the worker, only the last attempt marks the export failed
+func TestRunJobRetriesThenFails(t *testing.T) {
+	exporter, exports, db, job := newTestExport(t)
+	pool := worker.NewPool(memstore.NewJobStore(db), 1, time.Millisecond, log.New(io.Discard, "", 0))
+	pool.Backoff = worker.Backoff{Base: time.Millisecond, Max: time.Millisecond}
+	pool.Register(JobRun, RunJob(exporter))
+	require.NoError(t, pool.Enqueue(JobRun, RunPayload{ExportID: int64(job.ID)}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go pool.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		got, err := exports.GetExport(int64(job.ID))
+		return err == nil && got.Status == store.ExportStatusFailed
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 5, exports.attempts(), "every attempt the queue allows ran before the export failed")
+
+	got, err := exports.GetExport(int64(job.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "export failed", got.Error, "the cause stays in the log")
+}
+
+// ! TestRunJobOutsideWorker --> without retries left to it, a failed build marks the export failed right away
+func TestRunJobOutsideWorker(t *testing.T) {
+	exporter, exports, _, job := newTestExport(t)
+	payload, err := json.Marshal(RunPayload{ExportID: int64(job.ID)})
+	require.NoError(t, err)
+
+	require.Error(t, RunJob(exporter)(context.Background(), payload))
+	got, err := exports.GetExport(int64(job.ID))
+	require.NoError(t, err)
+	assert.Equal(t, store.ExportStatusFailed, got.Status)
+
+	require.NoError(t, RunJob(exporter)(context.Background(), payload), "a finished export is not built again")
+	assert.Equal(t, 1, exports.attempts())
+}
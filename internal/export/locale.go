@@ -0,0 +1,112 @@
+package export
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//! Locale --> how CSV cells render numbers and dates for one region
+//? the zero value is the machine-readable format (RFC3339, '.' decimals), Parquet and JSON always use it
+type Locale struct {
+	Tag            string
+	Decimal        byte   // * decimal separator, ',' across most of Europe
+	Comma          rune   // * CSV field separator, ';' wherever ',' is the decimal separator
+	DateLayout     string // * for values at midnight UTC (attendance days)
+	DateTimeLayout string
+}
+
+//! locales --> keyed by BCP 47 tag, a bare language picks its most common region
+var locales = map[string]Locale{
+	"en-US": {Tag: "en-US", Decimal: '.', Comma: ',', DateLayout: "01/02/2006", DateTimeLayout: "01/02/2006 03:04 PM"},
+	"en-GB": {Tag: "en-GB", Decimal: '.', Comma: ',', DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04"},
+	"de-DE": {Tag: "de-DE", Decimal: ',', Comma: ';', DateLayout: "02.01.2006", DateTimeLayout: "02.01.2006 15:04"},
+	"fr-FR": {Tag: "fr-FR", Decimal: ',', Comma: ';', DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04"},
+	"es-ES": {Tag: "es-ES", Decimal: ',', Comma: ';', DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04"},
+	"it-IT": {Tag: "it-IT", Decimal: ',', Comma: ';', DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04"},
+	"nl-NL": {Tag: "nl-NL", Decimal: ',', Comma: ';', DateLayout: "02-01-2006", DateTimeLayout: "02-01-2006 15:04"},
+	"pt-PT": {Tag: "pt-PT", Decimal: ',', Comma: ';', DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04"},
+	"pl-PL": {Tag: "pl-PL", Decimal: ',', Comma: ';', DateLayout: "02.01.2006", DateTimeLayout: "02.01.2006 15:04"},
+	"sv-SE": {Tag: "sv-SE", Decimal: ',', Comma: ';', DateLayout: "2006-01-02", DateTimeLayout: "2006-01-02 15:04"},
+}
+
+var defaultRegion = map[string]string{
+	"en": "en-US", "de": "de-DE", "fr": "fr-FR", "es": "es-ES", "it": "it-IT",
+	"nl": "nl-NL", "pt": "pt-PT", "pl": "pl-PL", "sv": "sv-SE",
+}
+
+//! LookupLocale --> exact tag first ("de-AT" isn't listed, so it falls back to "de" --> de-DE)
+//? "" gives the machine-readable zero Locale
+func LookupLocale(tag string) (Locale, bool) {
+	if tag == "" {
+		return Locale{}, true
+	}
+	for key, locale := range locales {
+		if strings.EqualFold(key, tag) {
+			return locale, true
+		}
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	if region, ok := defaultRegion[strings.ToLower(language)]; ok {
+		return locales[region], true
+	}
+	return Locale{}, false
+}
+
+//! NegotiateLocale --> best supported tag for an Accept-Language header, "" when nothing matches
+func NegotiateLocale(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag != "" && tag != "*" && q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if locale, ok := LookupLocale(c.tag); ok {
+			return locale.Tag
+		}
+	}
+	return ""
+}
+
+func (l Locale) comma() rune {
+	if l.Comma == 0 {
+		return ','
+	}
+	return l.Comma
+}
+
+func (l Locale) formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if l.Decimal != 0 && l.Decimal != '.' {
+		s = strings.Replace(s, ".", string(l.Decimal), 1)
+	}
+	return s
+}
+
+func (l Locale) formatTime(t time.Time) string {
+	t = t.UTC()
+	if l.Tag == "" {
+		return t.Format(time.RFC3339)
+	}
+	if t.Equal(t.Truncate(24 * time.Hour)) {
+		return t.Format(l.DateLayout)
+	}
+	return t.Format(l.DateTimeLayout)
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+//! parquetNode --> maps our column types onto parquet logical types
+//? every column is optional so nulls survive the round trip into the warehouse
+func parquetNode(columnType string) (parquet.Node, error) {
+	switch columnType {
+	case TypeString:
+		return parquet.Optional(parquet.String()), nil
+	case TypeInt:
+		return parquet.Optional(parquet.Int(64)), nil
+	case TypeFloat:
+		return parquet.Optional(parquet.Leaf(parquet.DoubleType)), nil
+	case TypeBool:
+		return parquet.Optional(parquet.Leaf(parquet.BooleanType)), nil
+	case TypeTime:
+		return parquet.Optional(parquet.Timestamp(parquet.Millisecond)), nil
+	}
+	return nil, fmt.Errorf("export : no parquet type for %q", columnType)
+}
+
+//! parquetValue --> converts a cell into a parquet value (nil --> null)
+func parquetValue(columnType string, value any) (parquet.Value, error) {
+	if value == nil {
+		return parquet.NullValue(), nil
+	}
+	switch v := value.(type) {
+	case string:
+		return parquet.ByteArrayValue([]byte(v)), nil
+	case int:
+		return parquet.Int64Value(int64(v)), nil
+	case int64:
+		return parquet.Int64Value(v), nil
+	case float64:
+		return parquet.DoubleValue(v), nil
+	case bool:
+		return parquet.BooleanValue(v), nil
+	case time.Time:
+		return parquet.Int64Value(v.UnixMilli()), nil
+	case *time.Time:
+		if v == nil {
+			return parquet.NullValue(), nil
+		}
+		return parquet.Int64Value(v.UnixMilli()), nil
+	}
+	return parquet.Value{}, fmt.Errorf("export : value %T does not fit column type %q", value, columnType)
+}
+
+//! writeParquet --> one parquet file per table, schema derived from table.Columns
+//! parquet groups order their fields by name, so cells are placed by the schema's field order
+func writeParquet(w io.Writer, table *Table) error {
+	group := parquet.Group{}
+	types := map[string]string{}
+	for _, column := range table.Columns {
+		node, err := parquetNode(column.Type)
+		if err != nil {
+			return err
+		}
+		group[column.Name] = node
+		types[column.Name] = column.Type
+	}
+	schema := parquet.NewSchema(table.Name, group)
+
+	//* position of each column inside our rows, looked up per schema field
+	position := map[string]int{}
+	for i, column := range table.Columns {
+		position[column.Name] = i
+	}
+
+	writer := parquet.NewWriter(w, schema)
+	fields := schema.Fields()
+	for _, cells := range table.Rows {
+		row := make(parquet.Row, len(fields))
+		for columnIndex, field := range fields {
+			value, err := parquetValue(types[field.Name()], cells[position[field.Name()]])
+			if err != nil {
+				return err
+			}
+			definitionLevel := 1
+			if value.IsNull() {
+				definitionLevel = 0
+			}
+			row[columnIndex] = value.Level(0, definitionLevel, columnIndex)
+		}
+		_, err := writer.WriteRows([]parquet.Row{row})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,79 @@
+package gamification
+
+import (
+	"fem/internal/store"
+	"fem/internal/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! Config --> point rules per activity, level thresholds and decay
+type Config struct {
+	PointsPerWorkout     int
+	PointsPerMinute      int
+	PointsPerAchievement int
+	Levels               []int //* XP needed for level 1, 2, 3 ... ascending, first is always 0
+	Decay                store.XPDecay
+}
+
+// ! defaultLevels --> roughly doubling gaps so early levels come quickly
+var defaultLevels = []int{0, 100, 250, 500, 1000, 2000, 3500, 5500, 8000, 11000}
+
+// ! ConfigFromEnv --> XP_* env vars override the defaults, decay is off unless XP_DECAY_PERCENT is set
+func ConfigFromEnv() Config {
+	return Config{
+		PointsPerWorkout:     utils.GetEnvInt("XP_POINTS_PER_WORKOUT", 10),
+		PointsPerMinute:      utils.GetEnvInt("XP_POINTS_PER_MINUTE", 1),
+		PointsPerAchievement: utils.GetEnvInt("XP_POINTS_PER_ACHIEVEMENT", 50),
+		Levels:               ParseLevels(utils.GetEnv("XP_LEVELS", "")),
+		Decay: store.XPDecay{
+			Percent: float64(utils.GetEnvInt("XP_DECAY_PERCENT", 0)),
+			Grace:   utils.GetEnvDuration("XP_DECAY_GRACE", 14*24*time.Hour),
+		},
+	}
+}
+
+// ! ParseLevels --> "0,100,250" style thresholds, falls back to the defaults when invalid
+func ParseLevels(raw string) []int {
+	if raw == "" {
+		return defaultLevels
+	}
+
+	levels := []int{0}
+	for _, part := range strings.Split(raw, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value < 0 {
+			return defaultLevels
+		}
+		if value > 0 {
+			levels = append(levels, value)
+		}
+	}
+	sort.Ints(levels)
+	return levels
+}
+
+// ! Level --> where an XP total sits on the level ladder
+type Level struct {
+	XP          int  `json:"xp"`
+	Level       int  `json:"level"`
+	LevelXP     int  `json:"level_xp"`      // * threshold of the current level
+	NextLevelXP *int `json:"next_level_xp"` // * nil at max level
+	Progress    int  `json:"progress"`      // * percent toward the next level
+}
+
+// ! LevelFor --> level numbers start at 1
+func LevelFor(xp int, levels []int) Level {
+	index := sort.Search(len(levels), func(i int) bool { return levels[i] > xp }) - 1
+	index = max(index, 0)
+
+	level := Level{XP: xp, Level: index + 1, LevelXP: levels[index], Progress: 100}
+	if index+1 < len(levels) {
+		next := levels[index+1]
+		level.NextLevelXP = &next
+		level.Progress = (xp - levels[index]) * 100 / (next - levels[index])
+	}
+	return level
+}
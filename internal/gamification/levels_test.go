@@ -0,0 +1,33 @@
+package gamification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ! TestLevelFor --> thresholds, progress and the max level
+func TestLevelFor(t *testing.T) {
+	levels := []int{0, 100, 300}
+
+	level := LevelFor(0, levels)
+	assert.Equal(t, 1, level.Level)
+	assert.Equal(t, 0, level.Progress)
+
+	level = LevelFor(200, levels)
+	assert.Equal(t, 2, level.Level)
+	assert.Equal(t, 50, level.Progress)
+	assert.Equal(t, 300, *level.NextLevelXP)
+
+	level = LevelFor(5000, levels)
+	assert.Equal(t, 3, level.Level)
+	assert.Nil(t, level.NextLevelXP)
+	assert.Equal(t, 100, level.Progress)
+}
+
+// ! TestParseLevels --> unsorted input is fine, garbage falls back to defaults
+func TestParseLevels(t *testing.T) {
+	assert.Equal(t, []int{0, 50, 200}, ParseLevels("200, 50"))
+	assert.Equal(t, defaultLevels, ParseLevels("10,abc"))
+	assert.Equal(t, defaultLevels, ParseLevels(""))
+}
@@ -0,0 +1,73 @@
+package gamification
+
+import (
+	"fem/internal/events"
+	"fem/internal/store"
+	"strconv"
+)
+
+// ! Service --> awards XP from domain events and reads totals back as levels
+type Service struct {
+	Store  store.XPStore
+	Config Config
+}
+
+// ! NewService --> constructor for the XP service
+func NewService(xpStore store.XPStore, config Config) *Service {
+	return &Service{Store: xpStore, Config: config}
+}
+
+// ! Subscribe --> workouts and achievements earn XP
+func (s *Service) Subscribe(bus *events.Bus) {
+	bus.Subscribe(s.HandleEvent, events.WorkoutCreated, events.WorkoutUpdated, events.WorkoutDeleted, events.AchievementEarned)
+}
+
+// ! HandleEvent --> keeps the ledger in step with the event, replays are harmless
+func (s *Service) HandleEvent(event events.Event) error {
+	switch event.Type {
+	case events.WorkoutCreated, events.WorkoutUpdated:
+		return s.Store.UpsertWorkoutXP(event.WorkoutID, s.Config.PointsPerWorkout, s.Config.PointsPerMinute)
+	case events.WorkoutDeleted:
+		return s.Store.DeleteXP(event.UserID, store.XPSourceWorkout, strconv.Itoa(event.WorkoutID))
+	case events.AchievementEarned:
+		return s.Store.UpsertXP(event.UserID, store.XPSourceAchievement, event.Ref, s.Config.PointsPerAchievement)
+	}
+	return nil
+}
+
+// ! LevelFor --> current (decayed) XP as a level
+func (s *Service) LevelFor(userID int) (*Level, error) {
+	total, err := s.Store.GetXP(userID, s.Config.Decay)
+	if err != nil {
+		return nil, err
+	}
+	level := LevelFor(total.XP, s.Config.Levels)
+	return &level, nil
+}
+
+// ! Standing --> one leaderboard row
+type Standing struct {
+	Rank     int    `json:"rank"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Level
+}
+
+// ! Leaderboard --> top users by decayed XP
+func (s *Service) Leaderboard(limit int) ([]*Standing, error) {
+	totals, err := s.Store.TopXP(s.Config.Decay, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := make([]*Standing, 0, len(totals))
+	for i, total := range totals {
+		standings = append(standings, &Standing{
+			Rank:     i + 1,
+			UserID:   total.UserID,
+			Username: total.Username,
+			Level:    LevelFor(total.XP, s.Config.Levels),
+		})
+	}
+	return standings, nil
+}
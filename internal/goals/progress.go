@@ -0,0 +1,41 @@
+package goals
+
+import (
+	"fem/internal/store"
+	"math"
+)
+
+// ! Percent --> 0-100 progress toward the goal, rounded to one decimal
+// ? count/minute goals grow toward the target, weight goals move from start_value toward it in either direction
+func Percent(goal *store.Goal) float64 {
+	if goal.CurrentValue == nil || goal.TargetValue <= 0 {
+		return 0
+	}
+	current := *goal.CurrentValue
+
+	var percent float64
+	switch goal.Type {
+	case store.GoalWeightTarget:
+		if goal.StartValue == nil {
+			return 0
+		}
+		total := goal.TargetValue - *goal.StartValue
+		if total == 0 {
+			percent = 100 //* already at the target when the goal was set
+			break
+		}
+		percent = (current - *goal.StartValue) / total * 100
+	default:
+		percent = current / goal.TargetValue * 100
+	}
+
+	percent = math.Max(0, math.Min(100, percent))
+	return math.Round(percent*10) / 10
+}
+
+// ! Fill --> sets PercentComplete on every goal before it goes out
+func Fill(goals ...*store.Goal) {
+	for _, goal := range goals {
+		goal.PercentComplete = Percent(goal)
+	}
+}
@@ -0,0 +1,51 @@
+package goals
+
+import (
+	"fem/internal/store"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func float(v float64) *float64 { return &v }
+
+// ! TestPercent --> table-driven checks for each goal type
+func TestPercent(t *testing.T) {
+	test := []struct {
+		name string
+		goal *store.Goal
+		want float64
+	}{
+		{
+			name: "weekly workouts part way",
+			goal: &store.Goal{Type: store.GoalWeeklyWorkouts, TargetValue: 4, CurrentValue: float(1)},
+			want: 25,
+		},
+		{
+			name: "minutes past the target are capped",
+			goal: &store.Goal{Type: store.GoalTotalMinutes, TargetValue: 600, CurrentValue: float(900)},
+			want: 100,
+		},
+		{
+			name: "weight loss",
+			goal: &store.Goal{Type: store.GoalWeightTarget, TargetValue: 80, StartValue: float(90), CurrentValue: float(87)},
+			want: 30,
+		},
+		{
+			name: "weight gain going the wrong way",
+			goal: &store.Goal{Type: store.GoalWeightTarget, TargetValue: 70, StartValue: float(65), CurrentValue: float(63)},
+			want: 0,
+		},
+		{
+			name: "no measurements yet",
+			goal: &store.Goal{Type: store.GoalWeightTarget, TargetValue: 80, StartValue: float(90)},
+			want: 0,
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Percent(tt.goal))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"context"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// ! ComplexityLimit --> rejects queries that would fan out too far (every field costs 1)
+const ComplexityLimit = 500
+
+type requestKey struct{}
+
+type requestState struct {
+	viewer  *store.User
+	loaders *Loaders
+}
+
+func state(ctx context.Context) *requestState {
+	return ctx.Value(requestKey{}).(*requestState)
+}
+
+// ! NewHandler --> POST /graphql (GET works for queries too), mount behind Authenticate + RequireUser
+func NewHandler(resolver *Resolver) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(ComplexityLimit))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		viewer := middleware.GetUser(req)
+		ctx := context.WithValue(req.Context(), requestKey{}, &requestState{
+			viewer:  viewer,
+			loaders: newLoaders(resolver.Graph, int64(viewer.ID)),
+		})
+		srv.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"fem/internal/store"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ! Loader --> collects the keys asked for within Wait and fetches them in one call (dataloader pattern)
+// ? gqlgen resolves sibling fields concurrently, so every workout in a list asking for its user lands in one batch
+type Loader[K comparable, V any] struct {
+	Fetch    func(ctx context.Context, keys []K) (map[K]V, error)
+	Wait     time.Duration
+	MaxBatch int
+
+	mu      sync.Mutex
+	pending *batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	once   sync.Once
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+// ! Load --> blocks until the batch holding key was fetched, missing keys answer the zero value
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	if l.pending == nil {
+		l.pending = &batch[K, V]{done: make(chan struct{})}
+		b := l.pending
+		time.AfterFunc(l.Wait, func() { l.run(ctx, b) })
+	}
+	b := l.pending
+	if !slices.Contains(b.keys, key) {
+		b.keys = append(b.keys, key)
+	}
+	if l.MaxBatch > 0 && len(b.keys) >= l.MaxBatch {
+		l.pending = nil //* full, the next key starts a new batch
+		go l.run(ctx, b)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.values[key], b.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// ! run --> called by the timer and when the batch fills up, whichever comes first fetches
+func (l *Loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending == b {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+
+	b.once.Do(func() {
+		b.values, b.err = l.Fetch(ctx, b.keys)
+		close(b.done)
+	})
+}
+
+// ! Loaders --> one set per request, so batches never mix viewers
+type Loaders struct {
+	Users   *Loader[int64, *store.User]
+	Entries *Loader[int64, []store.WorkoutEntry]
+	Stats   *Loader[int64, *store.WorkoutStats]
+}
+
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+func newLoaders(graph store.GraphStore, viewerID int64) *Loaders {
+	return &Loaders{
+		Users: &Loader[int64, *store.User]{
+			Fetch: func(ctx context.Context, ids []int64) (map[int64]*store.User, error) {
+				return graph.GetUsersByIDs(ids)
+			},
+			Wait: loaderWait, MaxBatch: loaderMaxBatch,
+		},
+		Entries: &Loader[int64, []store.WorkoutEntry]{
+			Fetch: func(ctx context.Context, ids []int64) (map[int64][]store.WorkoutEntry, error) {
+				return graph.GetEntriesByWorkoutIDs(ids)
+			},
+			Wait: loaderWait, MaxBatch: loaderMaxBatch,
+		},
+		Stats: &Loader[int64, *store.WorkoutStats]{
+			Fetch: func(ctx context.Context, ids []int64) (map[int64]*store.WorkoutStats, error) {
+				return graph.GetWorkoutStatsByUserIDs(viewerID, ids)
+			},
+			Wait: loaderWait, MaxBatch: loaderMaxBatch,
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ! TestLoaderBatches --> concurrent loads share one fetch, duplicate keys are asked for once
+func TestLoaderBatches(t *testing.T) {
+	var mu sync.Mutex
+	var calls [][]int
+	loader := &Loader[int, string]{
+		Fetch: func(ctx context.Context, keys []int) (map[int]string, error) {
+			mu.Lock()
+			calls = append(calls, keys)
+			mu.Unlock()
+			values := map[int]string{}
+			for _, k := range keys {
+				if k != 3 {
+					values[k] = string(rune('a' + k))
+				}
+			}
+			return values, nil
+		},
+		Wait:     10 * time.Millisecond,
+		MaxBatch: 10,
+	}
+
+	keys := []int{0, 1, 1, 2, 3}
+	results := make([]string, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = loader.Load(context.Background(), key)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, calls, 1)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3}, calls[0])
+	assert.Equal(t, []string{"a", "b", "b", "c", ""}, results)
+}
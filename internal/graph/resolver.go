@@ -0,0 +1,44 @@
+// ! package graph --> GraphQL API over the same stores + services as the REST handlers
+package graph
+
+//go:generate go tool gqlgen generate --config gqlgen.yml
+
+import (
+	"errors"
+	"fem/internal/service"
+	"fem/internal/store"
+	"log"
+)
+
+// ! Resolver --> shared dependencies, per-request state (viewer, loaders) lives in the context
+type Resolver struct {
+	Workouts *service.WorkoutService
+	Users    store.UserStore
+	Profiles store.ProfileStore
+	Graph    store.GraphStore
+	Logger   *log.Logger
+}
+
+// ! MaxLimit --> cap for list arguments
+const MaxLimit = 100
+
+// ! internal --> logs the real error, clients only see a generic message
+func (r *Resolver) internal(err error) error {
+	r.Logger.Printf("ERROR: graphql: %v", err)
+	return errors.New("internal server error")
+}
+
+func deref(n *int) int {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
+// ! clampLimit --> 25 when unset, otherwise between 1 and MaxLimit
+func clampLimit(limit *int) int {
+	if limit == nil {
+		return 25
+	}
+	return min(max(*limit, 1), MaxLimit)
+}
@@ -0,0 +1,124 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.94
+
+import (
+	"context"
+	"errors"
+	"fem/internal/service"
+	"fem/internal/store"
+)
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*store.User, error) {
+	return state(ctx).viewer, nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id int) (*store.User, error) {
+	user, err := state(ctx).loaders.Users.Load(ctx, int64(id))
+	if err != nil {
+		return nil, r.internal(err)
+	}
+	return user, nil
+}
+
+// Workout is the resolver for the workout field.
+func (r *queryResolver) Workout(ctx context.Context, id int) (*store.Workout, error) {
+	workout, err := r.Workouts.Get(ctx, state(ctx).viewer.ID, int64(id))
+	if errors.Is(err, service.ErrNotFound) {
+		return nil, nil //* hidden workouts look exactly like missing ones
+	}
+	if err != nil {
+		return nil, r.internal(err)
+	}
+	return workout, nil
+}
+
+// Email is the resolver for the email field.
+func (r *userResolver) Email(ctx context.Context, obj *store.User) (*string, error) {
+	if obj.ID != state(ctx).viewer.ID {
+		return nil, nil
+	}
+	return &obj.Email, nil
+}
+
+// Profile is the resolver for the profile field.
+func (r *userResolver) Profile(ctx context.Context, obj *store.User) (*store.Profile, error) {
+	if obj.ID != state(ctx).viewer.ID {
+		return nil, nil
+	}
+	profile, err := r.Profiles.GetProfile(obj.ID)
+	if err != nil {
+		return nil, r.internal(err)
+	}
+	return profile, nil
+}
+
+// Stats is the resolver for the stats field.
+func (r *userResolver) Stats(ctx context.Context, obj *store.User) (*store.WorkoutStats, error) {
+	stats, err := state(ctx).loaders.Stats.Load(ctx, int64(obj.ID))
+	if err != nil {
+		return nil, r.internal(err)
+	}
+	if stats == nil {
+		return &store.WorkoutStats{}, nil //* no visible workouts
+	}
+	return stats, nil
+}
+
+// Workouts is the resolver for the workouts field.
+func (r *userResolver) Workouts(ctx context.Context, obj *store.User, limit *int, offset *int) ([]*store.Workout, error) {
+	workouts, err := r.Graph.ListVisibleWorkouts(int64(state(ctx).viewer.ID), int64(obj.ID), clampLimit(limit), max(deref(offset), 0))
+	if err != nil {
+		return nil, r.internal(err)
+	}
+	return workouts, nil
+}
+
+// User is the resolver for the user field.
+func (r *workoutResolver) User(ctx context.Context, obj *store.Workout) (*store.User, error) {
+	user, err := state(ctx).loaders.Users.Load(ctx, int64(obj.UserID))
+	if err != nil {
+		return nil, r.internal(err)
+	}
+	if user == nil {
+		return nil, errors.New("user not found") //* owner deleted while the query ran
+	}
+	return user, nil
+}
+
+// Entries is the resolver for the entries field.
+func (r *workoutResolver) Entries(ctx context.Context, obj *store.Workout) ([]*store.WorkoutEntry, error) {
+	entries := obj.Entries //* single workout lookups come with their entries already
+	if entries == nil {
+		var err error
+		entries, err = state(ctx).loaders.Entries.Load(ctx, int64(obj.ID))
+		if err != nil {
+			return nil, r.internal(err)
+		}
+	}
+	out := make([]*store.WorkoutEntry, len(entries))
+	for i := range entries {
+		out[i] = &entries[i]
+	}
+	return out, nil
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+// Workout returns WorkoutResolver implementation.
+func (r *Resolver) Workout() WorkoutResolver { return &workoutResolver{r} }
+
+type (
+	queryResolver   struct{ *Resolver }
+	userResolver    struct{ *Resolver }
+	workoutResolver struct{ *Resolver }
+)
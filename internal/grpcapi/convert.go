@@ -0,0 +1,107 @@
+package grpcapi
+
+import (
+	"fem/internal/anomaly"
+	pb "fem/internal/grpcapi/fittrackv1"
+	"fem/internal/store"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ! toWorkout / fromWorkout --> store.Workout <-> proto, fromWorkout ignores server-owned fields (id, owner, flags)
+func toWorkout(w *store.Workout) *pb.Workout {
+	out := &pb.Workout{
+		Id:                int64(w.ID),
+		UserId:            int64(w.UserID),
+		Title:             w.Title,
+		Description:       w.Description,
+		DurationMinutes:   int32(w.DurationMinutes),
+		CaloriesBurned:    int32(w.CaloriesBurned),
+		CaloriesEstimated: w.CaloriesEstimated,
+		Visibility:        w.Visibility,
+		Flagged:           w.Flagged,
+		Verified:          w.Verified,
+		CreatedAt:         timestamppb.New(w.CreatedAt),
+	}
+	for _, e := range w.Entries {
+		out.Entries = append(out.Entries, &pb.WorkoutEntry{
+			Id:              int64(e.ID),
+			ExerciseName:    e.ExerciseName,
+			Sets:            int32(e.Sets),
+			Reps:            int32Ptr(e.Reps),
+			DurationSeconds: int32Ptr(e.DurationSeconds),
+			Weight:          e.Weight,
+			Notes:           e.Notes,
+			OrderIndex:      int32(e.OrderIndex),
+		})
+	}
+	return out
+}
+
+func fromWorkout(w *pb.Workout) *store.Workout {
+	return &store.Workout{
+		Title:           w.GetTitle(),
+		Description:     w.GetDescription(),
+		DurationMinutes: int(w.GetDurationMinutes()),
+		CaloriesBurned:  int(w.GetCaloriesBurned()),
+		Visibility:      w.GetVisibility(),
+		Entries:         fromEntries(w.GetEntries()),
+	}
+}
+
+// ! fromEntries --> never nil, an empty list means "no entries"
+func fromEntries(entries []*pb.WorkoutEntry) []store.WorkoutEntry {
+	out := make([]store.WorkoutEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, store.WorkoutEntry{
+			ExerciseName:    e.GetExerciseName(),
+			Sets:            int(e.GetSets()),
+			Reps:            intPtr(e.Reps),
+			DurationSeconds: intPtr(e.DurationSeconds),
+			Weight:          e.Weight,
+			Notes:           e.GetNotes(),
+			OrderIndex:      int(e.GetOrderIndex()),
+		})
+	}
+	return out
+}
+
+func toWarnings(warnings []anomaly.Warning) []*pb.AnomalyWarning {
+	var out []*pb.AnomalyWarning
+	for _, w := range warnings {
+		out = append(out, &pb.AnomalyWarning{
+			Path:    w.Path,
+			Value:   fmt.Sprint(w.Value),
+			Limit:   int32(w.Limit),
+			Message: w.Message,
+		})
+	}
+	return out
+}
+
+func toUser(u *store.User) *pb.User {
+	return &pb.User{
+		Id:        int64(u.ID),
+		Username:  u.Username,
+		Email:     u.Email,
+		Bio:       u.Bio,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+	}
+}
+
+func intPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
+
+func int32Ptr(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	n := int32(*v)
+	return &n
+}
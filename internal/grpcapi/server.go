@@ -0,0 +1,128 @@
+// ! package grpcapi --> typed API for internal services, same service layer + stores as the HTTP handlers
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=fem --go-grpc_out=../.. --go-grpc_opt=module=fem fittrack/v1/fittrack.proto
+
+import (
+	"context"
+	"errors"
+	"fem/internal/audit"
+	pb "fem/internal/grpcapi/fittrackv1"
+	"fem/internal/service"
+	"fem/internal/store"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ! publicMethods --> callable without a token, everything else needs "authorization: Bearer <token>"
+var publicMethods = map[string]bool{
+	pb.AuthService_CreateToken_FullMethodName:  true,
+	pb.UserService_RegisterUser_FullMethodName: true,
+}
+
+// ! NewServer --> grpc server with the workout, user and auth services registered
+func NewServer(workouts *service.WorkoutService, users *service.UserService, auth *service.AuthService, logger *log.Logger) *grpc.Server {
+	s := &server{workouts: workouts, users: users, auth: auth, logger: logger}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(s.authenticate))
+	pb.RegisterWorkoutServiceServer(srv, &workoutServer{server: s})
+	pb.RegisterUserServiceServer(srv, &userServer{server: s})
+	pb.RegisterAuthServiceServer(srv, &authServer{server: s})
+	return srv
+}
+
+type server struct {
+	workouts *service.WorkoutService
+	users    *service.UserService
+	auth     *service.AuthService
+	logger   *log.Logger
+}
+
+type userKey struct{}
+
+// ! currentUser --> set by authenticate, never nil inside a non-public method
+func currentUser(ctx context.Context) *store.User {
+	user, _ := ctx.Value(userKey{}).(*store.User)
+	return user
+}
+
+// ! authenticate --> unary interceptor, the gRPC counterpart of the Authenticate + RequireUser middleware
+func (s *server) authenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = audit.WithClient(ctx, callerOf(ctx, md))
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata")
+	}
+
+	user, err := s.auth.Authenticate(ctx, token)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		return nil, status.Error(codes.Unauthenticated, "token has been expired or invalid")
+	}
+	if err != nil {
+		return nil, s.toStatus(info.FullMethod, err)
+	}
+	return handler(context.WithValue(ctx, userKey{}, user), req)
+}
+
+// * callerOf --> the gRPC counterpart of audit.Capture
+func callerOf(ctx context.Context, md metadata.MD) audit.Client {
+	client := audit.Client{}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.IP = audit.RemoteIP(p.Addr.String())
+	}
+	if agents := md.Get("user-agent"); len(agents) > 0 {
+		client.UserAgent = agents[0]
+	}
+	return client
+}
+
+// ! toStatus --> service errors to grpc codes, unexpected ones are logged and hidden behind Internal
+func (s *server) toStatus(method string, err error) error {
+	var invalid *service.ValidationError
+	var anomalous *service.AnomalyError
+	var locked *service.LockedOutError
+	var twoFactor *service.TwoFactorRequiredError
+	var weak *service.PasswordError
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, service.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "not allowed")
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, "invalid credentials")
+	case errors.Is(err, service.ErrUnavailable):
+		return status.Error(codes.Unimplemented, "not available on this server")
+	case errors.As(err, &invalid):
+		return status.Error(codes.InvalidArgument, invalid.Message)
+	case errors.As(err, &weak):
+		return status.Error(codes.InvalidArgument, weak.Error())
+	case errors.As(err, &twoFactor):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication is enabled, sign in through POST /v1/tokens/authentication")
+	case errors.As(err, &locked):
+		return status.Errorf(codes.ResourceExhausted, "too many failed login attempts, locked until %s", locked.Until.UTC().Format(time.RFC3339))
+	case errors.As(err, &anomalous):
+		st := status.New(codes.FailedPrecondition, "workout contains implausible values, resend with confirm=true to save it anyway")
+		detailed, detailErr := st.WithDetails(&pb.AnomalyWarnings{Warnings: toWarnings(anomalous.Warnings)})
+		if detailErr != nil {
+			return st.Err()
+		}
+		return detailed.Err()
+	}
+	s.logger.Printf("ERROR: grpc %s: %v", method, err)
+	return status.Error(codes.Internal, "internal server error")
+}
@@ -0,0 +1,53 @@
+package grpcapi
+
+import (
+	"context"
+	"fem/internal/anomaly"
+	pb "fem/internal/grpcapi/fittrackv1"
+	"fem/internal/service"
+	"io"
+	"log"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestToStatus(t *testing.T) {
+	s := &server{logger: log.New(io.Discard, "", 0)}
+
+	assert.Equal(t, codes.NotFound, status.Code(s.toStatus("m", service.ErrNotFound)))
+	assert.Equal(t, codes.PermissionDenied, status.Code(s.toStatus("m", service.ErrForbidden)))
+	assert.Equal(t, codes.InvalidArgument, status.Code(s.toStatus("m", &service.ValidationError{Message: "bad"})))
+	assert.Equal(t, codes.Internal, status.Code(s.toStatus("m", io.ErrUnexpectedEOF)))
+
+	err := s.toStatus("m", &service.AnomalyError{Warnings: []anomaly.Warning{{Path: "duration_minutes", Value: 5000, Limit: 1440}}})
+	st := status.Convert(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	require.Len(t, st.Details(), 1)
+	warnings := st.Details()[0].(*pb.AnomalyWarnings)
+	assert.Equal(t, "5000", warnings.Warnings[0].Value)
+}
+
+// ! TestAuthenticate --> calls without a bearer token never reach the service
+func TestAuthenticate(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	srv := NewServer(nil, nil, nil, log.New(io.Discard, "", 0))
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = pb.NewWorkoutServiceClient(conn).GetWorkout(context.Background(), &pb.GetWorkoutRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,92 @@
+package grpcapi
+
+import (
+	"context"
+	pb "fem/internal/grpcapi/fittrackv1"
+	"fem/internal/service"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type workoutServer struct {
+	pb.UnimplementedWorkoutServiceServer
+	*server
+}
+
+func (s *workoutServer) GetWorkout(ctx context.Context, req *pb.GetWorkoutRequest) (*pb.GetWorkoutResponse, error) {
+	workout, err := s.workouts.Get(ctx, currentUser(ctx).ID, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(pb.WorkoutService_GetWorkout_FullMethodName, err)
+	}
+	return &pb.GetWorkoutResponse{Workout: toWorkout(workout)}, nil
+}
+
+func (s *workoutServer) CreateWorkout(ctx context.Context, req *pb.CreateWorkoutRequest) (*pb.CreateWorkoutResponse, error) {
+	workout, warnings, err := s.workouts.Create(ctx, currentUser(ctx).ID, fromWorkout(req.GetWorkout()), req.GetConfirm())
+	if err != nil {
+		return nil, s.toStatus(pb.WorkoutService_CreateWorkout_FullMethodName, err)
+	}
+	return &pb.CreateWorkoutResponse{Workout: toWorkout(workout), Warnings: toWarnings(warnings)}, nil
+}
+
+func (s *workoutServer) UpdateWorkout(ctx context.Context, req *pb.UpdateWorkoutRequest) (*pb.UpdateWorkoutResponse, error) {
+	patch := service.WorkoutPatch{
+		Title:           req.Title,
+		Description:     req.Description,
+		DurationMinutes: intPtr(req.DurationMinutes),
+		CaloriesBurned:  intPtr(req.CaloriesBurned),
+		Visibility:      req.Visibility,
+	}
+	if req.GetReplaceEntries() {
+		patch.Entries = fromEntries(req.GetEntries())
+	}
+
+	workout, warnings, err := s.workouts.Update(ctx, currentUser(ctx).ID, req.GetId(), patch, req.GetConfirm())
+	if err != nil {
+		return nil, s.toStatus(pb.WorkoutService_UpdateWorkout_FullMethodName, err)
+	}
+	return &pb.UpdateWorkoutResponse{Workout: toWorkout(workout), Warnings: toWarnings(warnings)}, nil
+}
+
+func (s *workoutServer) DeleteWorkout(ctx context.Context, req *pb.DeleteWorkoutRequest) (*pb.DeleteWorkoutResponse, error) {
+	err := s.workouts.Delete(ctx, currentUser(ctx).ID, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(pb.WorkoutService_DeleteWorkout_FullMethodName, err)
+	}
+	return &pb.DeleteWorkoutResponse{}, nil
+}
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	*server
+}
+
+func (s *userServer) RegisterUser(ctx context.Context, req *pb.RegisterUserRequest) (*pb.RegisterUserResponse, error) {
+	user, err := s.users.Register(ctx, service.Registration{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+		Email:    req.GetEmail(),
+		Bio:      req.GetBio(),
+	})
+	if err != nil {
+		return nil, s.toStatus(pb.UserService_RegisterUser_FullMethodName, err)
+	}
+	return &pb.RegisterUserResponse{User: toUser(user)}, nil
+}
+
+func (s *userServer) GetMe(ctx context.Context, req *pb.GetMeRequest) (*pb.GetMeResponse, error) {
+	return &pb.GetMeResponse{User: toUser(currentUser(ctx))}, nil
+}
+
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	*server
+}
+
+func (s *authServer) CreateToken(ctx context.Context, req *pb.CreateTokenRequest) (*pb.CreateTokenResponse, error) {
+	token, err := s.auth.Login(ctx, req.GetUsername(), req.GetPassword())
+	if err != nil {
+		return nil, s.toStatus(pb.AuthService_CreateToken_FullMethodName, err)
+	}
+	return &pb.CreateTokenResponse{Token: token.Plaintext, Expiry: timestamppb.New(token.Expiry)}, nil
+}
@@ -0,0 +1,113 @@
+// ! package hashid --> opaque public ids, so sequential database ids don't leak growth numbers
+// ? a keyed Feistel permutation scrambles the number, base62 over a salted alphabet makes it url safe
+package hashid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/rand/v2"
+)
+
+const (
+	alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	Length   = 11 //* 62^11 > 2^64, every id encodes to exactly this many characters
+	rounds   = 4
+)
+
+var ErrInvalid = errors.New("hashid: invalid id")
+
+// ! Codec --> encodes / decodes ids for one salt, changing the salt changes every public id
+type Codec struct {
+	alphabet [62]byte
+	index    [256]int8
+	keys     [rounds][]byte
+}
+
+// ! New --> salt is a secret, anyone holding it can map public ids back to row ids
+func New(salt string) (*Codec, error) {
+	if salt == "" {
+		return nil, errors.New("hashid: salt is required")
+	}
+	c := &Codec{}
+
+	seed := sha256.Sum256([]byte("alphabet:" + salt))
+	shuffled := []byte(alphabet)
+	rng := rand.New(rand.NewChaCha8(seed))
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	copy(c.alphabet[:], shuffled)
+
+	for i := range c.index {
+		c.index[i] = -1
+	}
+	for i, ch := range c.alphabet {
+		c.index[ch] = int8(i)
+	}
+
+	for i := range c.keys {
+		mac := hmac.New(sha256.New, []byte(salt))
+		mac.Write([]byte{byte(i)})
+		c.keys[i] = mac.Sum(nil)
+	}
+	return c, nil
+}
+
+// ! Encode --> ids are expected to be positive, like every serial column in the schema
+func (c *Codec) Encode(id int64) string {
+	n := c.permute(uint64(id))
+	out := make([]byte, Length)
+	for i := Length - 1; i >= 0; i-- {
+		out[i] = c.alphabet[n%62]
+		n /= 62
+	}
+	return string(out)
+}
+
+// ! Decode --> ErrInvalid for anything Encode couldn't have produced
+func (c *Codec) Decode(s string) (int64, error) {
+	if len(s) != Length {
+		return 0, ErrInvalid
+	}
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		digit := c.index[s[i]]
+		if digit < 0 {
+			return 0, ErrInvalid
+		}
+		//? 62^11 overflows uint64, reject instead of wrapping
+		if n > (^uint64(0)-uint64(digit))/62 {
+			return 0, ErrInvalid
+		}
+		n = n*62 + uint64(digit)
+	}
+	id := int64(c.unpermute(n))
+	if id <= 0 {
+		return 0, ErrInvalid
+	}
+	return id, nil
+}
+
+func (c *Codec) permute(n uint64) uint64 {
+	left, right := uint32(n>>32), uint32(n)
+	for i := 0; i < rounds; i++ {
+		left, right = right, left^c.round(i, right)
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+func (c *Codec) unpermute(n uint64) uint64 {
+	left, right := uint32(n>>32), uint32(n)
+	for i := rounds - 1; i >= 0; i-- {
+		left, right = right^c.round(i, left), left
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+func (c *Codec) round(i int, half uint32) uint32 {
+	mac := hmac.New(sha256.New, c.keys[i])
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], half)
+	mac.Write(buf[:])
+	return binary.BigEndian.Uint32(mac.Sum(nil))
+}
@@ -0,0 +1,46 @@
+package hashid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c, err := New("pepper")
+	require.NoError(t, err)
+
+	for _, id := range []int64{1, 2, 3, 42, 1 << 31, 1<<63 - 1} {
+		encoded := c.Encode(id)
+		assert.Len(t, encoded, Length)
+		decoded, err := c.Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, id, decoded)
+	}
+
+	//* neighbouring ids shouldn't look related
+	assert.NotEqual(t, c.Encode(1)[:Length-1], c.Encode(2)[:Length-1])
+}
+
+func TestDecodeRejectsGarbage(t *testing.T) {
+	c, _ := New("pepper")
+	other, _ := New("salt")
+
+	for _, raw := range []string{"", "42", "not-an-id!!", "zzzzzzzzzzz"} {
+		_, err := c.Decode(raw)
+		assert.ErrorIs(t, err, ErrInvalid, raw)
+	}
+	assert.NotEqual(t, c.Encode(7), other.Encode(7))
+}
+
+func TestRewriteJSON(t *testing.T) {
+	c, _ := New("pepper")
+	src := `{"workout":{"id":7,"user_id":3,"title":"Legs","entries":[{"id":9,"reps":5}]},"client_id":"ios","ids":[1,2],"total":3}`
+
+	out, err := c.RewriteJSON([]byte(src))
+	require.NoError(t, err)
+	want := `{"workout":{"id":"` + c.Encode(7) + `","user_id":"` + c.Encode(3) + `","title":"Legs","entries":[{"id":"` + c.Encode(9) +
+		`","reps":5}]},"client_id":"ios","ids":[1,2],"total":3}`
+	assert.Equal(t, want, string(out))
+}
@@ -0,0 +1,95 @@
+package hashid
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ! IsIDKey --> "id" and "*_id" fields carry row ids, "*_ids" and everything else pass through untouched
+func IsIDKey(key string) bool {
+	return key == "id" || strings.HasSuffix(key, "_id")
+}
+
+type frame struct {
+	object  bool
+	n       int    //* items written so far
+	key     string //* object only, the key of the value being read
+	wantKey bool
+}
+
+// ! RewriteJSON --> compact copy of src with integer id fields replaced by their encoded string
+// ? walks the token stream so field order survives, string ids (SCIM, client_id) are left alone
+func (c *Codec) RewriteJSON(src []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	var stack []*frame
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			out.WriteByte(byte(delim))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		//* object key --> remember it, the value comes with the next token
+		if top != nil && top.object && top.wantKey {
+			if top.n > 0 {
+				out.WriteByte(',')
+			}
+			key := tok.(string)
+			writeValue(&out, key)
+			out.WriteByte(':')
+			top.key, top.wantKey = key, false
+			top.n++
+			continue
+		}
+
+		if top != nil {
+			if !top.object && top.n > 0 {
+				out.WriteByte(',')
+			}
+			if top.object {
+				top.wantKey = true
+			} else {
+				top.n++
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			out.WriteByte(byte(v))
+			stack = append(stack, &frame{object: v == '{', wantKey: v == '{'})
+		case json.Number:
+			id, err := v.Int64()
+			if err == nil && id > 0 && top != nil && top.object && IsIDKey(top.key) {
+				writeValue(&out, c.Encode(id))
+			} else {
+				out.WriteString(v.String())
+			}
+		default:
+			writeValue(&out, v)
+		}
+	}
+}
+
+func writeValue(out *bytes.Buffer, v any) {
+	raw, _ := json.Marshal(v) //* strings, bools and nil always marshal
+	out.Write(raw)
+}
@@ -0,0 +1,130 @@
+package hooks
+
+import (
+	"context"
+	"fem/internal/store"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// ! Policy --> what a failing hook does to the rest of the chain
+type Policy int
+
+const (
+	Continue Policy = iota //* log the error, keep running later hooks (default)
+	Stop                   //* log the error, skip later hooks, the caller carries on
+	Fail                   //* skip later hooks and hand the error to the caller
+)
+
+// ! Func --> a hook body, the value is shared with later hooks so they see each other's changes
+type Func[T any] func(ctx context.Context, value T) error
+
+type entry[T any] struct {
+	name     string
+	priority int
+	policy   Policy
+	fn       Func[T]
+}
+
+// ! Hook --> ordered list of callbacks for one extension point
+// ? unlike events.Bus these run synchronously, in the request, lowest priority first
+type Hook[T any] struct {
+	name    string
+	logger  *log.Logger
+	mu      sync.RWMutex
+	entries []entry[T]
+}
+
+// ! Register --> adds fn, equal priorities run in registration order
+func (h *Hook[T]) Register(name string, priority int, policy Policy, fn Func[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e := entry[T]{name: name, priority: priority, policy: policy, fn: fn}
+	i := slices.IndexFunc(h.entries, func(existing entry[T]) bool { return existing.priority > priority })
+	if i < 0 {
+		i = len(h.entries)
+	}
+	h.entries = slices.Insert(h.entries, i, e)
+}
+
+// ! Names --> registered hooks in run order
+func (h *Hook[T]) Names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, len(h.entries))
+	for i, e := range h.entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+func (h *Hook[T]) empty() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.entries) == 0
+}
+
+// ! Run --> calls every hook in order, a panic counts as an error under that hook's policy
+// ? only Fail policies make Run return an error
+func (h *Hook[T]) Run(ctx context.Context, value T) error {
+	h.mu.RLock()
+	entries := h.entries
+	h.mu.RUnlock()
+
+	for _, e := range entries {
+		err := call(ctx, e.fn, value)
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("%s hook %q: %w", h.name, e.name, err)
+		switch e.policy {
+		case Fail:
+			return err
+		case Stop:
+			h.logger.Printf("ERROR: %v (skipping remaining hooks)", err)
+			return nil
+		default:
+			h.logger.Printf("ERROR: %v", err)
+		}
+	}
+	return nil
+}
+
+func call[T any](ctx context.Context, fn Func[T], value T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, value)
+}
+
+// ! Response --> BeforeResponse payload, hooks may change Status and Header before anything is sent
+type Response struct {
+	Request *http.Request
+	Status  int
+	Header  http.Header
+}
+
+// ! Registry --> every extension point, forks/plugins register on app.Hooks before the server starts
+// ? On* hooks run after the record is saved, so a Fail error is logged by the handler rather than undoing the write
+type Registry struct {
+	OnWorkoutCreated Hook[*store.Workout] //* after POST /workouts saved the workout
+	OnUserRegistered Hook[*store.User]    //* after POST /users saved the user
+	BeforeResponse   Hook[*Response]      //* right before the status line of any response goes out
+}
+
+// ! NewRegistry --> empty registry, running an empty hook is a no-op
+func NewRegistry(logger *log.Logger) *Registry {
+	r := &Registry{}
+	r.OnWorkoutCreated.name, r.OnWorkoutCreated.logger = "OnWorkoutCreated", logger
+	r.OnUserRegistered.name, r.OnUserRegistered.logger = "OnUserRegistered", logger
+	r.BeforeResponse.name, r.BeforeResponse.logger = "BeforeResponse", logger
+	return r
+}
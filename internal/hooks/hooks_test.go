@@ -0,0 +1,78 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fem/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookOrderingAndPolicies(t *testing.T) {
+	registry := NewRegistry(log.New(io.Discard, "", 0))
+	h := &registry.OnUserRegistered
+	ran := []string{}
+	record := func(name string, err error) Func[*store.User] {
+		return func(ctx context.Context, user *store.User) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	h.Register("late", 10, Continue, record("late", nil))
+	h.Register("early", -1, Continue, record("early", errors.New("logged only")))
+	h.Register("middle", 0, Continue, record("middle", nil))
+	assert.Equal(t, []string{"early", "middle", "late"}, h.Names())
+
+	value := &store.User{ID: 1}
+	assert.NoError(t, h.Run(context.Background(), value))
+	assert.Equal(t, []string{"early", "middle", "late"}, ran)
+
+	ran = nil
+	h.Register("stopper", 5, Stop, record("stopper", errors.New("stop")))
+	assert.NoError(t, h.Run(context.Background(), value))
+	assert.Equal(t, []string{"early", "middle", "stopper"}, ran)
+
+	ran = nil
+	h.Register("failer", 1, Fail, func(ctx context.Context, user *store.User) error { panic("boom") })
+	err := h.Run(context.Background(), value)
+	assert.ErrorContains(t, err, `"failer": panic: boom`)
+	assert.Equal(t, []string{"early", "middle"}, ran)
+}
+
+func TestBeforeResponseMiddleware(t *testing.T) {
+	registry := NewRegistry(log.New(io.Discard, "", 0))
+	registry.BeforeResponse.Register("header", 0, Continue, func(ctx context.Context, r *Response) error {
+		r.Header.Set("X-Plugin", "on")
+		if r.Request.URL.Path == "/teapot" {
+			r.Status = http.StatusTeapot
+		}
+		return nil
+	})
+	registry.BeforeResponse.Register("veto", 1, Fail, func(ctx context.Context, r *Response) error {
+		if r.Request.URL.Path == "/secret" {
+			return errors.New("blocked")
+		}
+		return nil
+	})
+
+	handler := registry.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/teapot", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, "on", rec.Header().Get("X-Plugin"))
+	assert.Equal(t, "body", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/secret", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "body")
+}
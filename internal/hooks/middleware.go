@@ -0,0 +1,77 @@
+package hooks
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+)
+
+// ! hookedWriter --> runs BeforeResponse the first time the status line is about to be written
+type hookedWriter struct {
+	http.ResponseWriter
+	req      *http.Request
+	hook     *Hook[*Response]
+	done     bool
+	rejected bool //* a Fail hook replaced the response, the handler's body is dropped
+}
+
+func (w *hookedWriter) WriteHeader(status int) {
+	if w.done {
+		return
+	}
+	w.done = true
+
+	resp := &Response{Request: w.req, Status: status, Header: w.Header()}
+	err := w.hook.Run(w.req.Context(), resp)
+	if err != nil {
+		w.hook.logger.Printf("ERROR: %v", err)
+		w.rejected = true
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w.ResponseWriter).Encode(map[string]string{"error": "internal server error"})
+		return
+	}
+	w.ResponseWriter.WriteHeader(resp.Status)
+}
+
+func (w *hookedWriter) Write(b []byte) (int, error) {
+	if !w.done {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// ! Unwrap --> lets http.ResponseController reach Flush etc. on the real writer
+func (w *hookedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ! Hijack --> websocket upgrades take the raw connection, BeforeResponse doesn't see the 101
+func (w *hookedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.done = true
+	}
+	return conn, rw, err
+}
+
+// ! Middleware --> pipeline stage that gives BeforeResponse hooks a look at every response
+func (r *Registry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.BeforeResponse.empty() {
+			next.ServeHTTP(w, req) //* nothing registered, skip the wrapper
+			return
+		}
+
+		hw := &hookedWriter{ResponseWriter: w, req: req, hook: &r.BeforeResponse}
+		next.ServeHTTP(hw, req)
+		if !hw.done {
+			hw.WriteHeader(http.StatusOK) //* handlers that write nothing still get a status line
+		}
+	})
+}
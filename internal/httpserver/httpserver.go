@@ -0,0 +1,136 @@
+// Package httpserver builds the API's http.Servers from environment settings and counts their connections.
+package httpserver
+
+import (
+	"fem/internal/metrics"
+	"fem/internal/utils"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ! Config --> per-environment server tuning, zero durations mean no limit (as in net/http)
+type Config struct {
+	ReadTimeout          time.Duration //* whole request incl. body, HTTP_READ_TIMEOUT
+	ReadHeaderTimeout    time.Duration //* headers only, slow-loris protection, HTTP_READ_HEADER_TIMEOUT
+	WriteTimeout         time.Duration //* HTTP_WRITE_TIMEOUT
+	IdleTimeout          time.Duration //* keep-alive connections are closed after this long unused, HTTP_IDLE_TIMEOUT
+	MaxHeaderBytes       int           //* HTTP_MAX_HEADER_BYTES
+	KeepAlives           bool          //* false closes every connection after one request, HTTP_KEEP_ALIVES
+	H2C                  bool          //* HTTP/2 without TLS for internal clients (prior knowledge), HTTP_H2C
+	MaxConcurrentStreams int           //* per HTTP/2 connection, HTTP2_MAX_CONCURRENT_STREAMS
+}
+
+// ! ConfigFromEnv --> defaults are the values main.go used to hardcode, plus a header timeout
+func ConfigFromEnv() Config {
+	return Config{
+		ReadTimeout:          utils.GetEnvDuration("HTTP_READ_TIMEOUT", 10*time.Second),
+		ReadHeaderTimeout:    utils.GetEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:         utils.GetEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:          utils.GetEnvDuration("HTTP_IDLE_TIMEOUT", time.Minute),
+		MaxHeaderBytes:       utils.GetEnvInt("HTTP_MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes),
+		KeepAlives:           os.Getenv("HTTP_KEEP_ALIVES") != "false",
+		H2C:                  os.Getenv("HTTP_H2C") == "true",
+		MaxConcurrentStreams: utils.GetEnvInt("HTTP2_MAX_CONCURRENT_STREAMS", 250),
+	}
+}
+
+// ! New --> server for addr, HTTP/2 is always offered over TLS, over plain TCP only with H2C
+// ? stats may be nil, otherwise every connection of this server is counted in it
+func New(addr string, handler http.Handler, config Config, stats *ConnStats) *http.Server {
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(config.H2C)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+		Protocols:         protocols,
+		HTTP2:             &http.HTTP2Config{MaxConcurrentStreams: config.MaxConcurrentStreams},
+	}
+	server.SetKeepAlivesEnabled(config.KeepAlives)
+	if stats != nil {
+		server.ConnState = stats.Track
+	}
+	return server
+}
+
+// ! ConnStats --> connection counts by state, fed by http.Server.ConnState
+type ConnStats struct {
+	mu       sync.Mutex
+	states   map[net.Conn]http.ConnState
+	active   int
+	idle     int
+	accepted int64
+	closed   int64
+}
+
+func NewConnStats() *ConnStats {
+	return &ConnStats{states: map[net.Conn]http.ConnState{}}
+}
+
+// ! Track --> http.Server.ConnState hook; hijacked connections (websocket upgrades) stop being counted
+func (s *ConnStats) Track(conn net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.states[conn] {
+	case http.StateActive:
+		s.active--
+	case http.StateIdle:
+		s.idle--
+	}
+	switch state {
+	case http.StateNew:
+		s.accepted++
+		s.states[conn] = state
+	case http.StateActive:
+		s.active++
+		s.states[conn] = state
+	case http.StateIdle:
+		s.idle++
+		s.states[conn] = state
+	case http.StateClosed, http.StateHijacked:
+		s.closed++
+		delete(s.states, conn)
+	}
+}
+
+// ! Snapshot --> open connections (any state), active ones serving a request, idle keep-alives + lifetime totals
+func (s *ConnStats) Snapshot() (open, active, idle int, accepted, closed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.states), s.active, s.idle, s.accepted, s.closed
+}
+
+// ! Register --> http_connections_* on GET /metrics
+func (s *ConnStats) Register(reg *metrics.Registry) {
+	reg.Gauge("http_connections_open", "Client connections currently open, any state.", func() float64 {
+		open, _, _, _, _ := s.Snapshot()
+		return float64(open)
+	})
+	reg.Gauge("http_connections_active", "Connections in the middle of a request.", func() float64 {
+		_, active, _, _, _ := s.Snapshot()
+		return float64(active)
+	})
+	reg.Gauge("http_connections_idle", "Keep-alive connections waiting for their next request.", func() float64 {
+		_, _, idle, _, _ := s.Snapshot()
+		return float64(idle)
+	})
+	reg.Counter("http_connections_accepted_total", "Connections accepted since start.", func() float64 {
+		_, _, _, accepted, _ := s.Snapshot()
+		return float64(accepted)
+	})
+	reg.Counter("http_connections_closed_total", "Connections closed (or hijacked) since start.", func() float64 {
+		_, _, _, _, closed := s.Snapshot()
+		return float64(closed)
+	})
+}
@@ -0,0 +1,51 @@
+package httpserver
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestH2CAndConnStats(t *testing.T) {
+	stats := NewConnStats()
+	config := ConfigFromEnv()
+	config.H2C = true
+	server := New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	}), config, stats)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Close()
+
+	//* prior-knowledge HTTP/2 over plain TCP, what internal clients with h2c enabled speak
+	protocols := &http.Protocols{}
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	for range 3 {
+		resp, err := client.Get("http://" + listener.Addr().String() + "/health")
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "HTTP/2.0", string(body))
+	}
+
+	open, _, _, accepted, _ := stats.Snapshot()
+	assert.Equal(t, int64(1), accepted) //* one multiplexed connection for all three requests
+	assert.Equal(t, 1, open)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("HTTP_KEEP_ALIVES", "false")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "2m")
+	config := ConfigFromEnv()
+	assert.False(t, config.KeepAlives)
+	assert.False(t, config.H2C)
+	assert.Equal(t, "2m0s", config.WriteTimeout.String())
+	assert.Equal(t, http.DefaultMaxHeaderBytes, config.MaxHeaderBytes)
+}
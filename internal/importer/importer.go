@@ -0,0 +1,262 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fem/internal/store"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! supported upload formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ! MaxWorkouts --> bigger histories have to be split into several uploads
+const MaxWorkouts = 5000
+
+// ! Row --> one workout from the upload, Line is the JSON array position or the CSV line it starts on
+type Row struct {
+	Line    int
+	Workout *store.Workout
+	Err     error //* parse or validation error, Workout is nil-safe to ignore when set
+}
+
+// ! Parse --> dispatches on format, the error is only for uploads that can't be read at all
+func Parse(format string, r io.Reader) ([]*Row, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatJSON:
+		return ParseJSON(r)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// ! ParseJSON --> an array of workouts shaped like the POST /workouts body, created_at optional
+func ParseJSON(r io.Reader) ([]*Row, error) {
+	var raw []json.RawMessage
+	err := json.NewDecoder(r).Decode(&raw)
+	if err != nil {
+		return nil, errors.New("file must contain a JSON array of workouts")
+	}
+	if len(raw) > MaxWorkouts {
+		return nil, fmt.Errorf("at most %d workouts per import", MaxWorkouts)
+	}
+
+	rows := make([]*Row, 0, len(raw))
+	for i, item := range raw {
+		row := &Row{Line: i + 1, Workout: &store.Workout{}}
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(row.Workout)
+		if err != nil {
+			row.Err = fmt.Errorf("invalid workout: %v", err)
+		} else {
+			row.Err = Validate(row.Workout)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ! ParseCSV --> one line per exercise entry, the header names the columns (any order)
+// ? lines sharing a "workout" value are one workout, its fields come from the first line
+// ? lines without a "workout" value are workouts of their own, exercise columns may then be blank
+func ParseCSV(r io.Reader) ([]*Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("file must start with a CSV header line")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"title", "duration_minutes"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	rows := []*Row{}
+	byKey := map[string]*Row{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, &Row{Line: parseErr.StartLine, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		get := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		key := get("workout")
+		row := byKey[key]
+		if key == "" || row == nil {
+			if len(rows) >= MaxWorkouts {
+				return nil, fmt.Errorf("at most %d workouts per import", MaxWorkouts)
+			}
+			row = &Row{Line: line}
+			row.Workout, row.Err = csvWorkout(get)
+			rows = append(rows, row)
+			if key != "" {
+				byKey[key] = row
+			}
+		}
+		if row.Err != nil || get("exercise_name") == "" {
+			continue
+		}
+
+		entry, err := csvEntry(get)
+		if err != nil {
+			row.Err = fmt.Errorf("line %d: %v", line, err)
+			continue
+		}
+		entry.OrderIndex = len(row.Workout.Entries)
+		row.Workout.Entries = append(row.Workout.Entries, entry)
+	}
+
+	for _, row := range rows {
+		if row.Err == nil {
+			row.Err = Validate(row.Workout)
+		}
+	}
+	return rows, nil
+}
+
+func csvWorkout(get func(string) string) (*store.Workout, error) {
+	workout := &store.Workout{
+		Title:       get("title"),
+		Description: get("description"),
+		Visibility:  get("visibility"),
+		Entries:     []store.WorkoutEntry{},
+	}
+
+	var err error
+	workout.DurationMinutes, err = strconv.Atoi(get("duration_minutes"))
+	if err != nil {
+		return nil, errors.New("duration_minutes must be a whole number")
+	}
+	if value := get("calories_burned"); value != "" {
+		workout.CaloriesBurned, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.New("calories_burned must be a whole number")
+		}
+	}
+	if value := get("date"); value != "" {
+		workout.CreatedAt, err = parseDate(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return workout, nil
+}
+
+func csvEntry(get func(string) string) (store.WorkoutEntry, error) {
+	entry := store.WorkoutEntry{ExerciseName: get("exercise_name"), Notes: get("notes"), Sets: 1}
+
+	var err error
+	if value := get("sets"); value != "" {
+		entry.Sets, err = strconv.Atoi(value)
+		if err != nil {
+			return entry, errors.New("sets must be a whole number")
+		}
+	}
+	for _, field := range []struct {
+		name string
+		dest **int
+	}{{"reps", &entry.Reps}, {"duration_seconds", &entry.DurationSeconds}} {
+		value := get(field.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return entry, fmt.Errorf("%s must be a whole number", field.name)
+		}
+		*field.dest = &n
+	}
+	if value := get("weight"); value != "" {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return entry, errors.New("weight must be a number")
+		}
+		entry.Weight = &weight
+	}
+	return entry, nil
+}
+
+// ! parseDate --> RFC 3339 timestamps or plain dates (midnight UTC), what most apps export
+func parseDate(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.New("date must be YYYY-MM-DD or an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// ! Validate --> the same limits the tables enforce, checked up front so the report can say why
+func Validate(workout *store.Workout) error {
+	workout.Title = strings.TrimSpace(workout.Title)
+	if workout.Title == "" || len(workout.Title) > 255 {
+		return errors.New("title is required and must be at most 255 characters")
+	}
+	if workout.DurationMinutes <= 0 {
+		return errors.New("duration_minutes must be positive")
+	}
+	if workout.CaloriesBurned < 0 {
+		return errors.New("calories_burned can't be negative")
+	}
+	switch workout.Visibility {
+	case "", store.VisibilityPrivate, store.VisibilityFollowers, store.VisibilityPublic:
+	default:
+		return errors.New("visibility must be private, followers or public")
+	}
+	if workout.CreatedAt.After(time.Now().Add(time.Minute)) {
+		return errors.New("date can't be in the future")
+	}
+
+	for i := range workout.Entries {
+		entry := &workout.Entries[i]
+		entry.OrderIndex = i
+		entry.ExerciseName = strings.TrimSpace(entry.ExerciseName)
+		switch {
+		case entry.ExerciseName == "" || len(entry.ExerciseName) > 255:
+			return fmt.Errorf("entries[%d]: exercise_name is required and must be at most 255 characters", i)
+		case entry.Sets <= 0:
+			return fmt.Errorf("entries[%d]: sets must be positive", i)
+		case (entry.Reps == nil) == (entry.DurationSeconds == nil):
+			return fmt.Errorf("entries[%d]: exactly one of reps or duration_seconds is required", i)
+		case entry.Weight != nil && (*entry.Weight < 0 || *entry.Weight >= 1000):
+			return fmt.Errorf("entries[%d]: weight must be between 0 and 999.99", i)
+		}
+	}
+	return nil
+}
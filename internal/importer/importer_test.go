@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSVGroupsEntries(t *testing.T) {
+	upload := `workout,date,title,duration_minutes,exercise_name,sets,reps,duration_seconds,weight
+a,2024-03-01,Push day,60,Bench press,3,8,,80
+a,2024-03-01,ignored,1,Dips,3,12,,
+,2024-03-02,Run,30,,,,,
+b,2024-03-03,Legs,45,Squat,3,,,100
+c,2024-03-04,Future,abc,,,,,
+`
+	rows, err := ParseCSV(strings.NewReader(upload))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	push := rows[0]
+	require.NoError(t, push.Err)
+	assert.Equal(t, 2, push.Line)
+	assert.Equal(t, "Push day", push.Workout.Title)
+	assert.Equal(t, 60, push.Workout.DurationMinutes)
+	require.Len(t, push.Workout.Entries, 2)
+	assert.Equal(t, "Dips", push.Workout.Entries[1].ExerciseName)
+	assert.Equal(t, 1, push.Workout.Entries[1].OrderIndex)
+	assert.Equal(t, "2024-03-01", push.Workout.CreatedAt.Format("2006-01-02"))
+
+	assert.NoError(t, rows[1].Err)
+	assert.Empty(t, rows[1].Workout.Entries)
+
+	assert.ErrorContains(t, rows[2].Err, "reps or duration_seconds")
+	assert.ErrorContains(t, rows[3].Err, "duration_minutes")
+	assert.Equal(t, 6, rows[3].Line)
+}
+
+func TestParseCSVRequiresColumns(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("title,notes\nRun,easy\n"))
+	assert.ErrorContains(t, err, "duration_minutes")
+}
+
+func TestParseJSON(t *testing.T) {
+	upload := `[
+		{"title": "Swim", "duration_minutes": 40, "created_at": "2024-01-05T07:00:00Z",
+		 "entries": [{"exercise_name": "Laps", "sets": 1, "duration_seconds": 2400}]},
+		{"title": "", "duration_minutes": 10},
+		{"title": "Row", "duration_minutes": 20, "bogus": true}
+	]`
+	rows, err := ParseJSON(strings.NewReader(upload))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, 2024, rows[0].Workout.CreatedAt.Year())
+	assert.ErrorContains(t, rows[1].Err, "title")
+	assert.ErrorContains(t, rows[2].Err, "bogus")
+
+	_, err = ParseJSON(strings.NewReader(`{"title": "not an array"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package integrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapActivity(t *testing.T) {
+	start := time.Date(2024, 6, 1, 6, 30, 0, 0, time.UTC)
+	workout := MapActivity(Activity{
+		ID:          987654321,
+		Name:        "Morning Run",
+		SportType:   "TrailRun",
+		StartDate:   start,
+		ElapsedTime: 3630,
+		MovingTime:  3400,
+		Distance:    10234,
+	})
+
+	assert.Equal(t, "Morning Run", workout.Title)
+	assert.Equal(t, 61, workout.DurationMinutes)
+	assert.Equal(t, 0, workout.CaloriesBurned)
+	assert.Equal(t, start, workout.CreatedAt)
+	require.Len(t, workout.Entries, 1)
+	assert.Equal(t, "running", workout.Entries[0].ExerciseName)
+	assert.Equal(t, 3400, *workout.Entries[0].DurationSeconds)
+	assert.Equal(t, "10.23 km", workout.Entries[0].Notes)
+
+	ride := MapActivity(Activity{ID: 1, SportType: "Kitesurf", ElapsedTime: 20, Kilojoules: 512.4})
+	assert.Equal(t, "Kitesurf", ride.Title)
+	assert.Equal(t, 1, ride.DurationMinutes)
+	assert.Equal(t, 512, ride.CaloriesBurned)
+	assert.Equal(t, "kitesurf", ride.Entries[0].ExerciseName)
+
+	assert.Equal(t, "987654321", ExternalRef(Activity{ID: 987654321}).ID)
+}
+
+func TestState(t *testing.T) {
+	state := SignState("strava", 42, time.Minute)
+
+	userID, ok := VerifyState("strava", state)
+	assert.True(t, ok)
+	assert.Equal(t, 42, userID)
+
+	_, ok = VerifyState("garmin", state)
+	assert.False(t, ok)
+	_, ok = VerifyState("strava", "43"+state[2:])
+	assert.False(t, ok)
+	_, ok = VerifyState("strava", SignState("strava", 42, -time.Minute))
+	assert.False(t, ok)
+}
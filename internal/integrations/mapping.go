@@ -0,0 +1,82 @@
+package integrations
+
+import (
+	"fem/internal/store"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ! sportExercises --> Strava sport types onto exercise names the MET table knows
+var sportExercises = map[string]string{
+	"Run":                           "running",
+	"TrailRun":                      "running",
+	"VirtualRun":                    "running",
+	"Walk":                          "walking",
+	"Hike":                          "hiking",
+	"Ride":                          "cycling",
+	"VirtualRide":                   "cycling",
+	"MountainBikeRide":              "cycling",
+	"GravelRide":                    "cycling",
+	"EBikeRide":                     "cycling",
+	"Swim":                          "swimming",
+	"Rowing":                        "rowing",
+	"VirtualRow":                    "rowing",
+	"Elliptical":                    "elliptical",
+	"StairStepper":                  "stair climber",
+	"Yoga":                          "yoga",
+	"Pilates":                       "pilates",
+	"HighIntensityIntervalTraining": "hiit",
+}
+
+// ! ExternalRef --> dedup key of a Strava activity
+func ExternalRef(a Activity) store.ExternalRef {
+	return store.ExternalRef{Source: store.ProviderStrava, ID: strconv.FormatInt(a.ID, 10)}
+}
+
+// ! MapActivity --> one workout with a single timed entry for the activity
+// ? calories only come from kilojoules (rides with a power meter), otherwise the caller estimates
+func MapActivity(a Activity) *store.Workout {
+	title := strings.TrimSpace(a.Name)
+	if title == "" {
+		title = a.SportType
+	}
+	if len(title) > 255 {
+		title = title[:255]
+	}
+
+	exercise, ok := sportExercises[a.SportType]
+	if !ok {
+		exercise = strings.ToLower(a.SportType)
+	}
+	if exercise == "" {
+		exercise = "workout"
+	}
+
+	seconds := a.MovingTime
+	if seconds <= 0 {
+		seconds = a.ElapsedTime
+	}
+	seconds = max(seconds, 1)
+
+	var notes string
+	if a.Distance > 0 {
+		notes = fmt.Sprintf("%.2f km", a.Distance/1000)
+	}
+
+	return &store.Workout{
+		Title:           title,
+		Description:     "Imported from Strava",
+		DurationMinutes: max(int(math.Ceil(float64(a.ElapsedTime)/60)), 1),
+		CaloriesBurned:  int(math.Round(a.Kilojoules)), //* ~1 kcal burned per kJ of work at typical efficiency
+		Visibility:      store.VisibilityPrivate,
+		CreatedAt:       a.StartDate,
+		Entries: []store.WorkoutEntry{{
+			ExerciseName:    exercise,
+			Sets:            1,
+			DurationSeconds: &seconds,
+			Notes:           notes,
+		}},
+	}
+}
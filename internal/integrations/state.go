@@ -0,0 +1,39 @@
+package integrations
+
+import (
+	"crypto/hmac"
+	"fem/internal/tokens"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! SignState --> OAuth state tying the callback to the user who started the connect flow
+// ? stateless: "<userID>.<expires>.<hmac>", the callback needs no session or db row
+func SignState(provider string, userID int, ttl time.Duration) string {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	payload := strconv.Itoa(userID) + "." + expires
+	return payload + "." + tokens.Sign(provider+"|connect|"+payload)
+}
+
+// ! VerifyState --> the user id from a state made by SignState, false when forged or expired
+func VerifyState(provider, state string) (int, bool) {
+	parts := strings.Split(state, ".")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(tokens.Sign(provider+"|connect|"+payload)), []byte(parts[2])) {
+		return 0, false
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return 0, false
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,161 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! ErrUnauthorized --> Strava rejected the token, the user has to reconnect
+var ErrUnauthorized = errors.New("strava: authorization revoked or expired")
+
+// ! StravaClient --> OAuth2 + the two API calls the sync needs, plain net/http like the ClickHouse sink
+type StravaClient struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string //* where Strava sends the user back, i.e. our /integrations/strava/callback
+	AuthURL      string
+	TokenURL     string
+	APIURL       string
+	client       *http.Client
+}
+
+// ! Token --> token endpoint response, Athlete is only set on the initial code exchange
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"`
+	Athlete      *struct {
+		ID int64 `json:"id"`
+	} `json:"athlete"`
+}
+
+// ! Activity --> the summary fields of GET /athlete/activities we map to a workout
+type Activity struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	SportType   string    `json:"sport_type"`
+	StartDate   time.Time `json:"start_date"`
+	ElapsedTime int       `json:"elapsed_time"` //* seconds
+	MovingTime  int       `json:"moving_time"`  //* seconds
+	Distance    float64   `json:"distance"`     //* meters
+	Kilojoules  float64   `json:"kilojoules"`   //* rides with power data only
+	Private     bool      `json:"private"`
+}
+
+// ! NewStravaClientFromEnv --> nil when STRAVA_CLIENT_ID / STRAVA_CLIENT_SECRET aren't set, the integration is then off
+func NewStravaClientFromEnv() *StravaClient {
+	clientID, secret := os.Getenv("STRAVA_CLIENT_ID"), os.Getenv("STRAVA_CLIENT_SECRET")
+	if clientID == "" || secret == "" {
+		return nil
+	}
+	return &StravaClient{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		RedirectURL:  os.Getenv("STRAVA_REDIRECT_URL"),
+		AuthURL:      "https://www.strava.com/oauth/authorize",
+		TokenURL:     "https://www.strava.com/oauth/token",
+		APIURL:       "https://www.strava.com/api/v3",
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ! AuthorizeURL --> where to send the user to grant access, state comes back on the callback
+func (c *StravaClient) AuthorizeURL(state string) string {
+	query := url.Values{}
+	query.Set("client_id", c.ClientID)
+	query.Set("redirect_uri", c.RedirectURL)
+	query.Set("response_type", "code")
+	query.Set("approval_prompt", "auto")
+	query.Set("scope", "read,activity:read_all")
+	query.Set("state", state)
+	return c.AuthURL + "?" + query.Encode()
+}
+
+// ! Exchange --> authorization code for tokens + the athlete id
+func (c *StravaClient) Exchange(ctx context.Context, code string) (*Token, error) {
+	return c.token(ctx, url.Values{"grant_type": {"authorization_code"}, "code": {code}})
+}
+
+// ! Refresh --> Strava access tokens live six hours, the refresh token may rotate too
+func (c *StravaClient) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	return c.token(ctx, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+}
+
+func (c *StravaClient) token(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	token := &Token{}
+	err = c.do(req, token)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ! Deauthorize --> revokes our access at Strava, used on disconnect
+func (c *StravaClient) Deauthorize(ctx context.Context, accessToken string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.AuthURL, "/authorize")+"/deauthorize", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return c.do(req, nil)
+}
+
+// ! Activities --> one page of activities that started after `after`, oldest first
+func (c *StravaClient) Activities(ctx context.Context, accessToken string, after time.Time, page, perPage int) ([]Activity, error) {
+	query := url.Values{}
+	query.Set("after", strconv.FormatInt(after.Unix(), 10))
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.APIURL+"/athlete/activities?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	activities := []Activity{}
+	err = c.do(req, &activities)
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// ! do --> sends req and decodes a JSON body into out (nil to discard)
+func (c *StravaClient) do(req *http.Request, out any) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("strava: %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
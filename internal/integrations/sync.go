@@ -0,0 +1,164 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fem/internal/anomaly"
+	"fem/internal/calories"
+	"fem/internal/outbox"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"log"
+	"time"
+)
+
+// ! background job types for third-party syncs
+const (
+	JobSync    = "integrations.sync"     //* one connection
+	JobSyncAll = "integrations.sync_all" //* enqueues a JobSync per connection
+)
+
+const (
+	activitiesPerPage = 100
+	maxPagesPerSync   = 10 //* anything beyond is picked up by the next run, the cursor moved forward
+)
+
+// ! SyncPayload --> integrations.sync
+type SyncPayload struct {
+	ConnectionID int64 `json:"connection_id"`
+}
+
+// ! Syncer --> pulls new activities of a connection and stores them as workouts
+type Syncer struct {
+	Store         store.IntegrationStore
+	Workouts      store.WorkoutStore
+	Profiles      store.ProfileStore //* body weight for calorie estimates
+	Detector      *anomaly.Detector  //* flags implausible imports, same as POST /workouts
+	Strava        *StravaClient
+	Relay         *outbox.Relay //* the workout store writes workout.created to the outbox, Notify hurries it along
+	InitialWindow time.Duration //* how far back the first sync after connecting reaches
+	Logger        *log.Logger
+}
+
+// ! Sync --> one run for one connection, returns how many workouts were created
+// ? a revoked authorization is recorded on the connection instead of retried
+func (s *Syncer) Sync(ctx context.Context, connectionID int64) (int, error) {
+	conn, err := s.Store.GetConnectionByID(connectionID)
+	if err != nil {
+		return 0, err
+	}
+	if conn == nil {
+		return 0, nil //* disconnected since the job was queued
+	}
+
+	created, newest, err := s.pull(ctx, conn)
+	syncErr := ""
+	if err != nil {
+		syncErr = err.Error()
+	}
+	recordErr := s.Store.RecordSync(conn.ID, newest, syncErr)
+	if errors.Is(err, ErrUnauthorized) {
+		return created, recordErr
+	}
+	if err != nil {
+		return created, err
+	}
+	return created, recordErr
+}
+
+func (s *Syncer) pull(ctx context.Context, conn *store.IntegrationConnection) (int, *time.Time, error) {
+	if time.Until(conn.TokenExpiresAt) < time.Minute {
+		token, err := s.Strava.Refresh(ctx, conn.RefreshToken)
+		if err != nil {
+			return 0, nil, err
+		}
+		conn.AccessToken, conn.RefreshToken, conn.TokenExpiresAt = token.AccessToken, token.RefreshToken, time.Unix(token.ExpiresAt, 0)
+		err = s.Store.UpdateConnectionTokens(conn.ID, conn.AccessToken, conn.RefreshToken, conn.TokenExpiresAt)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	after := time.Now().Add(-s.InitialWindow)
+	if conn.SyncedUntil != nil {
+		after = *conn.SyncedUntil
+	}
+
+	weightKG := calories.DefaultWeightKG
+	profile, err := s.Profiles.GetProfile(conn.UserID)
+	if err != nil {
+		s.Logger.Printf("ERROR: getProfile for strava sync: %v", err)
+	} else if profile.WeightKG != nil {
+		weightKG = *profile.WeightKG
+	}
+
+	created := 0
+	var newest *time.Time
+	for page := 1; page <= maxPagesPerSync; page++ {
+		activities, err := s.Strava.Activities(ctx, conn.AccessToken, after, page, activitiesPerPage)
+		if err != nil {
+			return created, newest, err
+		}
+
+		for _, activity := range activities {
+			workout := MapActivity(activity)
+			workout.UserID = conn.UserID
+			if workout.CaloriesBurned == 0 {
+				workout.CaloriesBurned = calories.EstimateWorkout(workout, weightKG)
+				workout.CaloriesEstimated = true
+			}
+			workout.Flagged = len(s.Detector.Check(workout)) > 0
+
+			inserted, err := s.Workouts.CreateExternalWorkout(workout, ExternalRef(activity))
+			if err != nil {
+				return created, newest, err
+			}
+			if newest == nil || activity.StartDate.After(*newest) {
+				start := activity.StartDate
+				newest = &start
+			}
+			if inserted {
+				created++
+				s.Relay.Notify()
+			}
+		}
+		if len(activities) < activitiesPerPage {
+			break
+		}
+	}
+	return created, newest, nil
+}
+
+// ! SyncJob --> payload is a SyncPayload
+func SyncJob(s *Syncer) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p SyncPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+		created, err := s.Sync(ctx, p.ConnectionID)
+		if created > 0 {
+			s.Logger.Printf("integrations: connection %d synced %d workouts", p.ConnectionID, created)
+		}
+		return err
+	}
+}
+
+// ! SyncAllJob --> one job per connection so a slow or broken account doesn't hold up the rest
+func SyncAllJob(integrationStore store.IntegrationStore, jobs worker.Enqueuer) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		ids, err := integrationStore.ListConnectionIDs(store.ProviderStrava)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			err = jobs.Enqueue(JobSync, SyncPayload{ConnectionID: id})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
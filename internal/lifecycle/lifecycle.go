@@ -0,0 +1,89 @@
+// ! package lifecycle --> readiness + drain state for orchestrators (Kubernetes probes, rolling deploys)
+// ? shutdown order: SIGTERM --> Drain (readiness goes 503) --> wait DrainDelay for endpoints to update --> stop servers
+package lifecycle
+
+import (
+	"fem/internal/utils"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ! Check --> a dependency the instance can't serve without, Ready returns false while it's missing
+type Check struct {
+	Name  string
+	Ready func() bool
+}
+
+// ! Instance --> downward API fields (POD_NAME, POD_NAMESPACE, NODE_NAME), hostname outside a cluster
+type Instance struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Node      string `json:"node,omitempty"`
+}
+
+func InstanceFromEnv() Instance {
+	name := os.Getenv("POD_NAME")
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	return Instance{Name: name, Namespace: os.Getenv("POD_NAMESPACE"), Node: os.Getenv("NODE_NAME")}
+}
+
+// ! Lifecycle --> built once in app, checks are registered before the server starts
+type Lifecycle struct {
+	Instance   Instance
+	DrainDelay time.Duration //* SHUTDOWN_DRAIN_DELAY, time for load balancers to stop sending new requests
+	Timeout    time.Duration //* SHUTDOWN_TIMEOUT, budget for in-flight requests + jobs after the drain delay
+
+	mu       sync.Mutex
+	checks   []Check
+	draining atomic.Bool
+}
+
+func New(instance Instance, drainDelay, timeout time.Duration) *Lifecycle {
+	return &Lifecycle{Instance: instance, DrainDelay: drainDelay, Timeout: timeout}
+}
+
+func (l *Lifecycle) AddCheck(name string, ready func() bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checks = append(l.checks, Check{Name: name, Ready: ready})
+}
+
+// ! Drain --> readiness fails from now on, the process keeps serving whatever still reaches it
+func (l *Lifecycle) Drain() {
+	l.draining.Store(true)
+}
+
+func (l *Lifecycle) Draining() bool {
+	return l.draining.Load()
+}
+
+// ! Failing --> names of the checks that aren't ready, "draining" once shutdown started
+func (l *Lifecycle) Failing() []string {
+	failing := []string{}
+	if l.Draining() {
+		failing = append(failing, "draining")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, check := range l.checks {
+		if !check.Ready() {
+			failing = append(failing, check.Name)
+		}
+	}
+	return failing
+}
+
+// ! HandleReady --> GET /ready, the readiness probe; 503 keeps the pod out of the service endpoints
+func (l *Lifecycle) HandleReady(w http.ResponseWriter, req *http.Request) {
+	failing := l.Failing()
+	if len(failing) > 0 {
+		utils.WriteJson(w, http.StatusServiceUnavailable, utils.Envelope{"status": "not ready", "failing": failing, "instance": l.Instance})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"status": "ready", "instance": l.Instance})
+}
@@ -0,0 +1,33 @@
+package lifecycle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleReady(t *testing.T) {
+	dbUp := true
+	l := New(Instance{Name: "api-0"}, time.Second, time.Second)
+	l.AddCheck("database", func() bool { return dbUp })
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		l.HandleReady(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, ready())
+
+	dbUp = false
+	assert.Equal(t, http.StatusServiceUnavailable, ready())
+	assert.Equal(t, []string{"database"}, l.Failing())
+
+	dbUp = true
+	l.Drain()
+	assert.Equal(t, http.StatusServiceUnavailable, ready())
+	assert.Equal(t, []string{"draining"}, l.Failing())
+}
@@ -0,0 +1,136 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ! ErrNotActivated --> "systemd" was asked for but the process wasn't started by a .socket unit
+var ErrNotActivated = errors.New("listener: no socket passed by systemd (LISTEN_FDS / LISTEN_PID not set for this process)")
+
+// ! first fd systemd hands over, 0-2 are stdio
+const listenFDsStart = 3
+
+// ! Listen --> opens the listener an address spec describes
+//
+//	unix:/run/fittrack/api.sock  --> unix domain socket, a stale socket file is removed first
+//	systemd                      --> first socket inherited from systemd socket activation
+//	systemd:<name>               --> the inherited socket with FileDescriptorName=<name>
+//	anything else                --> tcp address, e.g. :8080 or 127.0.0.1:8080
+func Listen(spec string, socketMode fs.FileMode) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(spec, "unix:"):
+		return listenUnix(strings.TrimPrefix(spec, "unix:"), socketMode)
+	case spec == "systemd":
+		return Systemd("")
+	case strings.HasPrefix(spec, "systemd:"):
+		return Systemd(strings.TrimPrefix(spec, "systemd:"))
+	default:
+		return net.Listen("tcp", spec)
+	}
+}
+
+// ! Loopback --> tcp listener that refuses any address reachable from outside the host, e.g. 127.0.0.1:6060 or localhost:6060
+// ? for endpoints without auth of their own (DEBUG_ADDR), a typo must not expose them
+func Loopback(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(host)
+	if host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("listener: %q is not a loopback address, use 127.0.0.1, ::1 or localhost", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ! listenUnix --> chmods the socket so the reverse proxy's user can connect
+func listenUnix(path string, mode fs.FileMode) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("listener: unix socket path is empty")
+	}
+	// ? - a socket left behind by a crashed process would make bind fail, anything else is not ours to delete
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("listener: %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// ! Systemd --> takes over a socket systemd opened for this service, the socket stays open across restarts
+// ? an empty name picks the first one, the LISTEN_* variables are cleared so child processes don't inherit them
+func Systemd(name string) (net.Listener, error) {
+	names, err := activatedNames(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES"), os.Getpid())
+	if err != nil {
+		return nil, err
+	}
+	index := pickFD(names, name)
+	if index < 0 {
+		return nil, fmt.Errorf("listener: systemd passed no socket named %q (got %v)", name, names)
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(uintptr(listenFDsStart+index), names[index])
+	defer file.Close() // ? - FileListener dups the fd
+	return net.FileListener(file)
+}
+
+// ! activatedNames --> one name per passed fd, "unknown" when the unit sets no FileDescriptorName
+func activatedNames(pid, fds, fdNames string, self int) ([]string, error) {
+	if pid == "" || fds == "" {
+		return nil, ErrNotActivated
+	}
+	listenPID, err := strconv.Atoi(pid)
+	if err != nil || listenPID != self {
+		return nil, ErrNotActivated
+	}
+	count, err := strconv.Atoi(fds)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("listener: invalid LISTEN_FDS %q", fds)
+	}
+
+	names := make([]string, count)
+	given := strings.Split(fdNames, ":")
+	for i := range names {
+		names[i] = "unknown"
+		if fdNames != "" && i < len(given) {
+			names[i] = given[i]
+		}
+	}
+	return names, nil
+}
+
+// ! pickFD --> index of the named fd, the first one for an empty name, -1 when missing
+func pickFD(names []string, name string) int {
+	if name == "" {
+		return 0
+	}
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
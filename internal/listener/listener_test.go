@@ -0,0 +1,83 @@
+package listener
+
+import (
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+
+	l, err := Listen("unix:"+path, 0o660)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o660), info.Mode().Perm())
+
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conn.Write([]byte("ok"))
+			conn.Close()
+		}
+	}()
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	buf := make([]byte, 2)
+	_, err = conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(buf))
+	conn.Close()
+
+	// ? - unix listeners unlink on Close, a socket file left by a crash is replaced
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	l, err = Listen("unix:"+path, 0)
+	require.NoError(t, err)
+	l.Close()
+}
+
+func TestLoopback(t *testing.T) {
+	l, err := Loopback("127.0.0.1:0")
+	require.NoError(t, err)
+	l.Close()
+
+	for _, addr := range []string{":6060", "0.0.0.0:6060", "10.0.0.5:6060", "example.com:6060", "127.0.0.1"} {
+		_, err := Loopback(addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+func TestListenUnixRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	_, err := Listen("unix:"+path, 0)
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestActivatedNames(t *testing.T) {
+	_, err := activatedNames("", "", "", 42)
+	assert.ErrorIs(t, err, ErrNotActivated)
+	_, err = activatedNames("41", "1", "", 42)
+	assert.ErrorIs(t, err, ErrNotActivated)
+
+	names, err := activatedNames("42", "2", "http:admin", 42)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http", "admin"}, names)
+	assert.Equal(t, 1, pickFD(names, "admin"))
+	assert.Equal(t, 0, pickFD(names, ""))
+	assert.Equal(t, -1, pickFD(names, "grpc"))
+
+	names, err = activatedNames("42", "1", "", 42)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unknown"}, names)
+}
@@ -0,0 +1,179 @@
+package live
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ! message types, client --> server
+const (
+	TypeStart  = "start"  //* {title} host opens a session
+	TypeJoin   = "join"   //* {session_id} follower starts watching
+	TypeLeave  = "leave"  //* viewer stops watching, host ends the session
+	TypeUpdate = "update" //* {exercise, set, elapsed_seconds} host only, relayed to viewers as-is
+)
+
+// ! message types, server --> client
+const (
+	TypeSession = "session" //* reply to start / join with the session snapshot
+	TypeViewers = "viewers" //* audience size changed
+	TypeEnded   = "ended"   //* host left, the viewer is out of the room
+	TypeError   = "error"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 4096
+	sendBuffer     = 32
+	maxTitleLength = 100
+)
+
+// ! Incoming --> any client message, fields depend on Type
+type Incoming struct {
+	Type      string `json:"type"`
+	Title     string `json:"title,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	State
+}
+
+// ! Outgoing --> any server message, fields depend on Type
+type Outgoing struct {
+	Type      string   `json:"type"`
+	SessionID string   `json:"session_id,omitempty"`
+	Session   *Session `json:"session,omitempty"`
+	State     *State   `json:"state,omitempty"`
+	Viewers   int      `json:"viewers,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ! Client --> one websocket connection of an authenticated user
+type Client struct {
+	UserID int
+
+	conn      *websocket.Conn
+	out       chan Outgoing
+	closeOnce sync.Once
+	session   string //* room the client is in, guarded by Hub.mu
+}
+
+// ! NewClient --> wraps an upgraded connection
+func NewClient(conn *websocket.Conn, userID int) *Client {
+	return &Client{UserID: userID, conn: conn, out: make(chan Outgoing, sendBuffer)}
+}
+
+// ! send --> never blocks the hub, a client that can't keep up is disconnected
+func (c *Client) send(msg Outgoing) {
+	select {
+	case c.out <- msg:
+	default:
+		c.close()
+	}
+}
+
+func (c *Client) close() {
+	c.closeOnce.Do(func() { c.conn.Close() })
+}
+
+// ! Run --> serves the connection until it closes, allowed is the follow check for joins
+func (c *Client) Run(hub *Hub, allowed func(hostID int) (bool, error)) {
+	done := make(chan struct{})
+	go c.writeLoop(done)
+	defer func() {
+		hub.Leave(c)
+		close(done)
+		c.close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg Incoming
+		err = json.Unmarshal(raw, &msg)
+		if err != nil {
+			c.send(Outgoing{Type: TypeError, Error: "invalid message"})
+			continue
+		}
+		c.handle(hub, msg, allowed)
+	}
+}
+
+// ! handle --> one client message, errors go back to the client, never close the connection
+func (c *Client) handle(hub *Hub, msg Incoming, allowed func(hostID int) (bool, error)) {
+	var err error
+	switch msg.Type {
+	case TypeStart:
+		if msg.Title == "" || len(msg.Title) > maxTitleLength {
+			c.send(Outgoing{Type: TypeError, Error: "title is required and must be at most 100 characters"})
+			return
+		}
+		var session Session
+		session, err = hub.Start(c, msg.Title)
+		if err == nil {
+			c.send(Outgoing{Type: TypeSession, SessionID: session.ID, Session: &session})
+		}
+	case TypeJoin:
+		var session Session
+		session, err = hub.Join(c, msg.SessionID, allowed)
+		if err == nil {
+			c.send(Outgoing{Type: TypeSession, SessionID: session.ID, Session: &session})
+		}
+	case TypeLeave:
+		err = hub.Leave(c)
+	case TypeUpdate:
+		err = hub.Update(c, msg.State)
+	default:
+		c.send(Outgoing{Type: TypeError, Error: "unknown message type"})
+		return
+	}
+
+	if err != nil {
+		switch err {
+		case ErrAlreadyLive, ErrNotHost, ErrNoSession, ErrNotAllowed, ErrRoomFull, ErrNotInSession, ErrInvalidUpdate:
+			c.send(Outgoing{Type: TypeError, Error: err.Error()})
+		default:
+			c.send(Outgoing{Type: TypeError, Error: "internal server error"})
+		}
+	}
+}
+
+// ! writeLoop --> the only goroutine writing to the connection (gorilla allows one writer)
+func (c *Client) writeLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		case msg := <-c.out:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			err := c.conn.WriteJSON(msg)
+			if err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			err := c.conn.WriteMessage(websocket.PingMessage, nil)
+			if err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
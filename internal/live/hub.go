@@ -0,0 +1,210 @@
+package live
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ! errors surfaced to clients as {"type":"error"}
+var (
+	ErrAlreadyLive   = errors.New("you are already broadcasting a session")
+	ErrNotHost       = errors.New("only the host can do that")
+	ErrNoSession     = errors.New("session not found or already ended")
+	ErrNotAllowed    = errors.New("you must follow the host to watch")
+	ErrRoomFull      = errors.New("session has reached its viewer limit")
+	ErrNotInSession  = errors.New("not in a session")
+	ErrInvalidUpdate = errors.New("exercise is required, set and elapsed_seconds cannot be negative")
+)
+
+// ! State --> what the host is doing right now
+type State struct {
+	Exercise       string `json:"exercise"`
+	Set            int    `json:"set"`
+	ElapsedSeconds int    `json:"elapsed_seconds"`
+}
+
+// ! Session --> one live broadcast, exposed by GET /live-sessions and the join reply
+type Session struct {
+	ID        string    `json:"id"`
+	HostID    int       `json:"host_id"`
+	Title     string    `json:"title"`
+	StartedAt time.Time `json:"started_at"`
+	State     *State    `json:"state"` //* nil until the first update
+	Viewers   int       `json:"viewers"`
+}
+
+// * room --> a session plus everyone connected to it
+type room struct {
+	session Session
+	host    *Client
+	viewers map[*Client]struct{}
+}
+
+// ! Hub --> in-process registry of live sessions, one room per session
+// ? rooms live as long as the host's connection, a second instance has its own hub
+type Hub struct {
+	MaxViewers int
+
+	mu     sync.Mutex
+	rooms  map[string]*room
+	byHost map[int]string //* host user id --> session id, one live session per user
+}
+
+// ! NewHub --> constructor, maxViewers caps each room
+func NewHub(maxViewers int) *Hub {
+	return &Hub{MaxViewers: max(maxViewers, 1), rooms: map[string]*room{}, byHost: map[int]string{}}
+}
+
+func newSessionID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ! Start --> opens a room hosted by c
+func (h *Hub) Start(c *Client, title string) (Session, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c.session != "" {
+		return Session{}, ErrAlreadyLive
+	}
+	if _, ok := h.byHost[c.UserID]; ok {
+		return Session{}, ErrAlreadyLive //* live from another device
+	}
+
+	r := &room{
+		session: Session{ID: newSessionID(), HostID: c.UserID, Title: title, StartedAt: time.Now()},
+		host:    c,
+		viewers: map[*Client]struct{}{},
+	}
+	h.rooms[r.session.ID] = r
+	h.byHost[c.UserID] = r.session.ID
+	c.session = r.session.ID
+	return r.session, nil
+}
+
+// ! Update --> host pushes new state, every viewer gets it
+func (h *Hub) Update(c *Client, state State) error {
+	if state.Exercise == "" || state.Set < 0 || state.ElapsedSeconds < 0 {
+		return ErrInvalidUpdate
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.rooms[c.session]
+	if r == nil {
+		return ErrNotInSession
+	}
+	if r.host != c {
+		return ErrNotHost
+	}
+	r.session.State = &state
+	h.broadcast(r, Outgoing{Type: TypeUpdate, SessionID: r.session.ID, State: &state})
+	return nil
+}
+
+// ! Join --> adds c as a viewer, allowed decides whether c may watch the host (followers only)
+func (h *Hub) Join(c *Client, sessionID string, allowed func(hostID int) (bool, error)) (Session, error) {
+	h.mu.Lock()
+	r := h.rooms[sessionID]
+	h.mu.Unlock()
+	if r == nil {
+		return Session{}, ErrNoSession
+	}
+
+	//* the follow check hits the db, don't hold the lock for it
+	ok, err := allowed(r.session.HostID)
+	if err != nil {
+		return Session{}, err
+	}
+	if !ok {
+		return Session{}, ErrNotAllowed
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rooms[sessionID] != r {
+		return Session{}, ErrNoSession //* ended while we were checking
+	}
+	if c.session != "" {
+		h.leave(c)
+	}
+	if len(r.viewers) >= h.MaxViewers {
+		return Session{}, ErrRoomFull
+	}
+	r.viewers[c] = struct{}{}
+	c.session = sessionID
+	r.session.Viewers = len(r.viewers)
+	h.broadcastViewers(r)
+	return r.session, nil
+}
+
+// ! Leave --> c stops watching, or ends the session when c is the host
+func (h *Hub) Leave(c *Client) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.session == "" {
+		return ErrNotInSession
+	}
+	h.leave(c)
+	return nil
+}
+
+// ! leave --> h.mu must be held
+func (h *Hub) leave(c *Client) {
+	r := h.rooms[c.session]
+	c.session = ""
+	if r == nil {
+		return
+	}
+
+	if r.host == c {
+		delete(h.rooms, r.session.ID)
+		delete(h.byHost, r.session.HostID)
+		for viewer := range r.viewers {
+			viewer.session = ""
+			viewer.send(Outgoing{Type: TypeEnded, SessionID: r.session.ID})
+		}
+		return
+	}
+	delete(r.viewers, c)
+	r.session.Viewers = len(r.viewers)
+	h.broadcastViewers(r)
+}
+
+// ! Sessions --> snapshot of every live session whose host passes keep
+func (h *Hub) Sessions(keep func(hostID int) bool) []Session {
+	h.mu.Lock()
+	all := make([]Session, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		all = append(all, r.session)
+	}
+	h.mu.Unlock()
+
+	sessions := []Session{}
+	for _, s := range all {
+		if keep(s.HostID) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions
+}
+
+// ! broadcast --> h.mu must be held
+func (h *Hub) broadcast(r *room, msg Outgoing) {
+	for viewer := range r.viewers {
+		viewer.send(msg)
+	}
+}
+
+// ! broadcastViewers --> the host and viewers see the audience size, h.mu must be held
+func (h *Hub) broadcastViewers(r *room) {
+	msg := Outgoing{Type: TypeViewers, SessionID: r.session.ID, Viewers: len(r.viewers)}
+	r.host.send(msg)
+	h.broadcast(r, msg)
+}
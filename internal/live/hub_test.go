@@ -0,0 +1,45 @@
+package live
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClient(userID int) *Client {
+	return &Client{UserID: userID, out: make(chan Outgoing, sendBuffer)}
+}
+
+func allowAll(int) (bool, error) { return true, nil }
+
+// ! TestHubSession --> viewers get the host's updates and are told when the host leaves
+func TestHubSession(t *testing.T) {
+	hub := NewHub(10)
+	host, viewer := testClient(1), testClient(2)
+
+	session, err := hub.Start(host, "Leg day")
+	require.NoError(t, err)
+	_, err = hub.Start(testClient(1), "second device")
+	assert.ErrorIs(t, err, ErrAlreadyLive)
+
+	_, err = hub.Join(viewer, session.ID, func(int) (bool, error) { return false, nil })
+	assert.ErrorIs(t, err, ErrNotAllowed)
+	joined, err := hub.Join(viewer, session.ID, allowAll)
+	require.NoError(t, err)
+	assert.Equal(t, 1, joined.Viewers)
+	assert.Equal(t, TypeViewers, (<-host.out).Type)
+	assert.Equal(t, TypeViewers, (<-viewer.out).Type)
+
+	assert.ErrorIs(t, hub.Update(viewer, State{Exercise: "Squat", Set: 1}), ErrNotHost)
+	require.NoError(t, hub.Update(host, State{Exercise: "Squat", Set: 2, ElapsedSeconds: 300}))
+	update := <-viewer.out
+	assert.Equal(t, TypeUpdate, update.Type)
+	assert.Equal(t, 2, update.State.Set)
+
+	require.NoError(t, hub.Leave(host))
+	assert.Equal(t, TypeEnded, (<-viewer.out).Type)
+	assert.Empty(t, hub.Sessions(func(int) bool { return true }))
+	_, err = hub.Join(testClient(3), session.ID, allowAll)
+	assert.ErrorIs(t, err, ErrNoSession)
+}
@@ -0,0 +1,193 @@
+// ! package loadtest --> k6 + vegeta scripts for the API's hot paths, generated from the router that is actually mounted
+// ? routes switched off by config (ADMIN_PORT split, features without a store) drop out instead of failing the run
+package loadtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ! Scenario --> one request a virtual user makes, Weight is its share of the traffic
+type Scenario struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Path   string `json:"path"` //* route pattern, {id} is the workout the run created in setup
+	Body   string `json:"body,omitempty"`
+	Weight int    `json:"weight"`
+}
+
+const workoutBody = `{"title":"load test","duration_minutes":45,"visibility":"private","entries":[{"exercise_name":"squat","sets":5,"reps":5,"weight":100,"order_index":1},{"exercise_name":"plank","sets":3,"duration_seconds":60,"order_index":2}]}`
+
+// ! HotPaths --> roughly the production mix: reads of own workouts + the feed dominate, writes are rarer
+var HotPaths = []Scenario{
+	{Name: "list_workouts", Method: http.MethodGet, Path: "/v1/workouts", Weight: 25},
+	{Name: "get_workout", Method: http.MethodGet, Path: "/v1/workouts/{id}", Weight: 25},
+	{Name: "feed", Method: http.MethodGet, Path: "/v1/feed", Weight: 15},
+	{Name: "create_workout", Method: http.MethodPost, Path: "/v1/workouts", Body: workoutBody, Weight: 10},
+	{Name: "me", Method: http.MethodGet, Path: "/v1/users/me", Weight: 10},
+	{Name: "goals", Method: http.MethodGet, Path: "/v1/goals", Weight: 5},
+	{Name: "training_load", Method: http.MethodGet, Path: "/v1/stats/training-load", Weight: 5},
+	{Name: "leaderboard", Method: http.MethodGet, Path: "/v1/leaderboards/xp", Weight: 5},
+}
+
+// ! Available --> the scenarios router serves, in the given order
+func Available(router chi.Routes, scenarios []Scenario) ([]Scenario, error) {
+	mounted := map[string]bool{}
+	err := chi.Walk(router, func(method, pattern string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		mounted[method+" "+pattern] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var available []Scenario
+	for _, s := range scenarios {
+		if mounted[s.Method+" "+s.Path] {
+			available = append(available, s)
+		}
+	}
+	return available, nil
+}
+
+// ! Options --> where the run goes and who it signs in as
+type Options struct {
+	BaseURL   string //* e.g. http://localhost:8080
+	Username  string //* k6 registers it in setup when it doesn't exist yet
+	Password  string
+	Token     string //* vegeta only, a bearer token for Username
+	WorkoutID string //* vegeta only, fills {id}; "" drops scenarios that need it
+}
+
+// ! K6 --> a k6 script: setup signs in + creates the workout {id} points at, every iteration picks a weighted scenario
+// ? run with: k6 run --vus 50 --duration 2m script.js; BASE_URL overrides the base URL baked in
+func K6(scenarios []Scenario, opts Options) (string, error) {
+	if len(scenarios) == 0 {
+		return "", fmt.Errorf("loadtest: no scenarios are mounted on this router")
+	}
+	raw, err := json.MarshalIndent(scenarios, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	credentials, err := json.Marshal(map[string]string{"username": opts.Username, "password": opts.Password})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// generated by fittrack loadtest -format k6, hot paths of %s\n", opts.BaseURL)
+	b.WriteString("import http from 'k6/http';\nimport { check } from 'k6';\n\n")
+	fmt.Fprintf(&b, "const BASE_URL = __ENV.BASE_URL || %q;\n", opts.BaseURL)
+	fmt.Fprintf(&b, "const CREDENTIALS = %s;\n", credentials)
+	fmt.Fprintf(&b, "const SCENARIOS = %s;\n", raw)
+	fmt.Fprintf(&b, "const WORKOUT = %s;\n\n", workoutBody)
+	b.WriteString(`export const options = {
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    http_req_duration: ['p(95)<300'],
+  },
+};
+
+const JSON_HEADERS = { 'Content-Type': 'application/json' };
+
+export function setup() {
+  const email = CREDENTIALS.username + '@loadtest.invalid';
+  http.post(BASE_URL + '/v1/users', JSON.stringify({ ...CREDENTIALS, email }), { headers: JSON_HEADERS }); // 409 when it exists
+  const login = http.post(BASE_URL + '/v1/tokens/authentication', JSON.stringify(CREDENTIALS), { headers: JSON_HEADERS });
+  check(login, { 'signed in': (r) => r.status === 201 });
+  const token = login.json('auth_token.token');
+  const headers = { ...JSON_HEADERS, Authorization: 'Bearer ' + token };
+  const created = http.post(BASE_URL + '/v1/workouts', JSON.stringify(WORKOUT), { headers });
+  check(created, { 'workout created': (r) => r.status === 201 });
+  return { headers, workoutID: created.json('workout.id') };
+}
+
+function pick() {
+  const total = SCENARIOS.reduce((sum, s) => sum + s.weight, 0);
+  let n = Math.random() * total;
+  for (const s of SCENARIOS) {
+    n -= s.weight;
+    if (n < 0) return s;
+  }
+  return SCENARIOS[SCENARIOS.length - 1];
+}
+
+export default function (data) {
+  const s = pick();
+  const url = BASE_URL + s.path.replace('{id}', data.workoutID);
+  const res = http.request(s.method, url, s.body || null, { headers: data.headers, tags: { name: s.name } });
+  check(res, { [s.name + ' ok']: (r) => r.status < 400 });
+}
+`)
+	return b.String(), nil
+}
+
+// * vegetaTarget --> one line of vegeta's JSON target format (-format=json)
+type vegetaTarget struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Header map[string][]string `json:"header"`
+	Body   string              `json:"body,omitempty"` //* base64
+}
+
+// ! Vegeta --> JSON targets, one line per weight unit so vegeta's round robin follows the mix
+// ? run with: vegeta attack -format=json -targets=targets.json -rate=200 -duration=2m | vegeta report
+func Vegeta(scenarios []Scenario, opts Options) (string, error) {
+	if opts.Token == "" {
+		return "", fmt.Errorf("loadtest: vegeta targets need a bearer token (-token)")
+	}
+	var b strings.Builder
+	for _, s := range scenarios {
+		path := s.Path
+		if strings.Contains(path, "{id}") {
+			if opts.WorkoutID == "" {
+				continue
+			}
+			path = strings.ReplaceAll(path, "{id}", opts.WorkoutID)
+		}
+		target := vegetaTarget{
+			Method: s.Method,
+			URL:    strings.TrimSuffix(opts.BaseURL, "/") + path,
+			Header: map[string][]string{"Authorization": {"Bearer " + opts.Token}},
+		}
+		if s.Body != "" {
+			target.Header["Content-Type"] = []string{"application/json"}
+			target.Body = base64.StdEncoding.EncodeToString([]byte(s.Body))
+		}
+		line, err := json.Marshal(target)
+		if err != nil {
+			return "", err
+		}
+		for range max(s.Weight, 1) {
+			b.Write(line)
+			b.WriteByte('\n')
+		}
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("loadtest: no scenarios left, pass -workout for the {id} routes")
+	}
+	return b.String(), nil
+}
+
+// ! Formats --> what fittrack loadtest -format accepts
+var Formats = []string{"k6", "vegeta"}
+
+// ! Generate --> the script in format for the scenarios router serves
+func Generate(format string, router chi.Routes, opts Options) (string, error) {
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("loadtest: unknown format %q, want one of %v", format, Formats)
+	}
+	scenarios, err := Available(router, HotPaths)
+	if err != nil {
+		return "", err
+	}
+	if format == "vegeta" {
+		return Vegeta(scenarios, opts)
+	}
+	return K6(scenarios, opts)
+}
@@ -0,0 +1,51 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noop(w http.ResponseWriter, r *http.Request) {}
+
+func TestGenerate(t *testing.T) {
+	r := chi.NewRouter()
+	r.Route("/v1", func(r chi.Router) {
+		r.Get("/workouts", noop)
+		r.Get("/workouts/{id}", noop)
+		r.Post("/workouts", noop)
+	})
+
+	scenarios, err := Available(r, HotPaths)
+	require.NoError(t, err)
+	names := []string{}
+	for _, s := range scenarios {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"list_workouts", "get_workout", "create_workout"}, names, "unmounted routes drop out")
+
+	script, err := Generate("k6", r, Options{BaseURL: "http://api.test", Username: "lt", Password: "pw"})
+	require.NoError(t, err)
+	assert.Contains(t, script, `const BASE_URL = __ENV.BASE_URL || "http://api.test";`)
+	assert.Contains(t, script, `"path": "/v1/workouts/{id}"`)
+	assert.NotContains(t, script, "/v1/feed")
+
+	_, err = Generate("vegeta", r, Options{BaseURL: "http://api.test"})
+	assert.ErrorContains(t, err, "bearer token")
+	targets, err := Generate("vegeta", r, Options{BaseURL: "http://api.test/", Token: "abc"})
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(targets), "\n")
+	assert.Len(t, lines, 25+10, "weights repeat targets, {id} routes are skipped without -workout")
+	var target vegetaTarget
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &target))
+	assert.Equal(t, "http://api.test/v1/workouts", target.URL)
+	assert.Equal(t, []string{"Bearer abc"}, target.Header["Authorization"])
+
+	_, err = Generate("jmeter", r, Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+// Package logging filters the app's *log.Logger output by level, switchable at runtime (SIGHUP reloads).
+// ? lines keep their existing markers: "ERROR:" (or "Error :") is an error, "DEBUG:" is debug, anything else is info
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// ! Level --> lines below the writer's level are dropped
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ! ParseLevel --> debug, info or error; "" is info
+func ParseLevel(raw string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("log level %q: want debug, info or error", raw)
+}
+
+// ! LevelWriter --> io.Writer for log.New, each Write is one log line
+type LevelWriter struct {
+	out   io.Writer
+	level atomic.Int32
+}
+
+func NewLevelWriter(out io.Writer, level Level) *LevelWriter {
+	w := &LevelWriter{out: out}
+	w.SetLevel(level)
+	return w
+}
+
+func (w *LevelWriter) SetLevel(level Level) {
+	w.level.Store(int32(level))
+}
+
+func (w *LevelWriter) Level() Level {
+	return Level(w.level.Load())
+}
+
+// ! Enabled --> for callers that would do work just to log (dumping bodies at debug)
+func (w *LevelWriter) Enabled(level Level) bool {
+	return level >= w.Level()
+}
+
+func (w *LevelWriter) Write(line []byte) (int, error) {
+	if !w.Enabled(levelOf(line)) {
+		return len(line), nil //* dropped on purpose, log.Logger must not see an error
+	}
+	return w.out.Write(line)
+}
+
+// * levelOf --> looks for the marker right after log's date + time prefix
+func levelOf(line []byte) Level {
+	head := line[:min(len(line), 48)]
+	switch {
+	case bytes.Contains(head, []byte("ERROR")), bytes.Contains(head, []byte("Error :")), bytes.Contains(head, []byte("ERORR")):
+		return LevelError
+	case bytes.Contains(head, []byte("DEBUG:")):
+		return LevelDebug
+	}
+	return LevelInfo
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := NewLevelWriter(out, LevelInfo)
+	logger := log.New(w, "", log.Ldate|log.Ltime)
+
+	logger.Printf("DEBUG: dropped")
+	logger.Printf("kept info")
+	logger.Printf("ERROR: kept error")
+	assert.NotContains(t, out.String(), "dropped")
+	assert.Contains(t, out.String(), "kept info")
+
+	out.Reset()
+	w.SetLevel(LevelError)
+	logger.Printf("info dropped")
+	logger.Printf("Error : kept")
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("\n")))
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
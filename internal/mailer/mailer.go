@@ -0,0 +1,107 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+// ! Message --> plain-text email, with an HTML alternative when HTML is set
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	HTML    string `json:"html,omitempty"` // * clients that can't show it fall back to Body
+}
+
+// ! Mailer --> anything that can deliver a Message (SMTP in production, the log in development)
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ! NewFromEnv --> SMTP when SMTP_ADDR is set, otherwise emails are only logged
+func NewFromEnv(logger *log.Logger) Mailer {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return &LogMailer{Logger: logger}
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return &SMTPMailer{
+		Addr: addr,
+		From: utils.GetEnv("SMTP_FROM", "no-reply@fittrack.local"),
+		Auth: auth,
+	}
+}
+
+// ! SMTPMailer --> delivers through a relay with net/smtp
+type SMTPMailer struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("mailer: header values must not contain line breaks")
+	}
+
+	contentType, content, err := encodeBody(msg)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n%s",
+		m.From, msg.To, msg.Subject, contentType, content)
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{msg.To}, []byte(body))
+}
+
+// * encodeBody --> plain text as is, text + HTML as multipart/alternative (plain part first, clients show the last one they can)
+func encodeBody(msg Message) (string, string, error) {
+	if msg.HTML == "" {
+		return "text/plain; charset=UTF-8", msg.Body, nil
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Body},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+	for _, p := range parts {
+		part, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {p.contentType}})
+		if err != nil {
+			return "", "", err
+		}
+		_, err = part.Write([]byte(p.content))
+		if err != nil {
+			return "", "", err
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		return "", "", err
+	}
+	return "multipart/alternative; boundary=" + w.Boundary(), buf.String(), nil
+}
+
+// ! LogMailer --> development fallback, writes the email to the app log instead of sending it
+type LogMailer struct {
+	Logger *log.Logger
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.Logger.Printf("mailer: to=%s subject=%q html=%t\n%s", msg.To, msg.Subject, msg.HTML != "", msg.Body)
+	return nil
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"text/template"
+)
+
+// * templates --> <name>.txt is the plain-text body, <name>.html its HTML alternative
+//
+//go:embed templates
+var templates embed.FS
+
+var (
+	textTemplates = template.Must(template.ParseFS(templates, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/*.html"))
+)
+
+// ! Render --> Body + HTML of a Message from the templates/<name>.txt + templates/<name>.html pair
+// ? the HTML side escapes everything it is given, user input (workout titles, usernames) can go in as is
+func Render(name string, data any) (string, string, error) {
+	var text, html bytes.Buffer
+	err := textTemplates.ExecuteTemplate(&text, name+".txt", data)
+	if err != nil {
+		return "", "", err
+	}
+	err = htmlTemplates.ExecuteTemplate(&html, name+".html", data)
+	if err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
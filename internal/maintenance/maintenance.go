@@ -0,0 +1,102 @@
+// Package maintenance answers 503 + Retry-After while operators run long migrations or repairs.
+// ? the switch is per process (env at start, the admin endpoint after), flip it on every instance
+package maintenance
+
+import (
+	"fem/internal/utils"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ! DefaultMessage --> what clients see unless the operator says more
+const DefaultMessage = "the service is down for maintenance, please retry later"
+
+// ! exempt --> probes, metrics, the admin UI + API and sign-in keep working, otherwise maintenance could never be switched off
+var exempt = []string{
+	"/health", "/ready", "/metrics", "/debug/",
+	"/admin", "/v1/admin/",
+	"/tokens/authentication", "/v1/tokens/authentication",
+}
+
+// ! Status --> what GET/PUT /admin/maintenance report
+type Status struct {
+	Enabled    bool       `json:"enabled"`
+	Message    string     `json:"message"`
+	RetryAfter int        `json:"retry_after_seconds"` //* sent as Retry-After, clients back off this long
+	Since      *time.Time `json:"since"`
+}
+
+// ! Mode --> the maintenance switch, safe for concurrent use
+type Mode struct {
+	mu     sync.RWMutex
+	status Status
+	now    func() time.Time
+}
+
+// ! FromEnv --> MAINTENANCE_MODE=true starts the instance already in maintenance
+func FromEnv() *Mode {
+	m := &Mode{now: time.Now}
+	m.status.RetryAfter = utils.GetEnvInt("MAINTENANCE_RETRY_AFTER", 120)
+	if os.Getenv("MAINTENANCE_MODE") == "true" {
+		m.Enable(os.Getenv("MAINTENANCE_MESSAGE"), m.status.RetryAfter)
+	}
+	return m
+}
+
+// ! Enable --> "" keeps DefaultMessage, retryAfter < 1 keeps the current value; Since is kept while already on
+func (m *Mode) Enable(message string, retryAfter int) Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if message == "" {
+		message = DefaultMessage
+	}
+	if retryAfter > 0 {
+		m.status.RetryAfter = retryAfter
+	}
+	if !m.status.Enabled {
+		now := m.now()
+		m.status.Since = &now
+	}
+	m.status.Enabled, m.status.Message = true, message
+	return m.status
+}
+
+func (m *Mode) Disable() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.Enabled, m.status.Message, m.status.Since = false, "", nil
+	return m.status
+}
+
+func (m *Mode) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// ! Middleware --> root middleware, 503 for everything but the exempt paths while maintenance is on
+func (m *Mode) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := m.Status()
+		if !status.Enabled || isExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(status.RetryAfter))
+		utils.WriteJson(w, http.StatusServiceUnavailable, utils.Envelope{"error": status.Message, "maintenance": true})
+	})
+}
+
+func isExempt(path string) bool {
+	for _, prefix := range exempt {
+		if path == prefix || strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, prefix) ||
+			strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
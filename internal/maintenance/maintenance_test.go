@@ -0,0 +1,40 @@
+package maintenance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	mode := FromEnv()
+	handler := mode.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/v1/workouts").Code)
+
+	status := mode.Enable("", 300)
+	assert.Equal(t, DefaultMessage, status.Message)
+	w := serve("/v1/workouts")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "300", w.Header().Get("Retry-After"))
+	for _, path := range []string{"/health", "/ready", "/v1/admin/maintenance", "/admin/assets/app.js", "/v1/tokens/authentication/2fa"} {
+		assert.Equal(t, http.StatusOK, serve(path).Code, path)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/healthz").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/administrators").Code)
+
+	//* switching on again keeps the original start
+	since := status.Since
+	assert.Equal(t, since, mode.Enable("migrating workouts", 0).Since)
+	assert.Equal(t, 300, mode.Status().RetryAfter)
+
+	mode.Disable()
+	assert.Equal(t, http.StatusOK, serve("/v1/workouts").Code)
+}
@@ -0,0 +1,182 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+// ! AccountStore --> store.AccountStore on a DB
+type AccountStore struct {
+	db *DB
+}
+
+func NewAccountStore(db *DB) *AccountStore {
+	return &AccountStore{db: db}
+}
+
+// * userWorkouts --> a user's workouts oldest first, entries in order_index order, caller holds mu
+func (db *DB) userWorkouts(userID int) []*store.Workout {
+	workouts := []*store.Workout{}
+	for _, row := range db.workouts {
+		if row.workout.UserID == userID {
+			w := copyWorkout(row.workout)
+			sort.SliceStable(w.Entries, func(i, j int) bool { return w.Entries[i].OrderIndex < w.Entries[j].OrderIndex })
+			workouts = append(workouts, w)
+		}
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		if !workouts[i].CreatedAt.Equal(workouts[j].CreatedAt) {
+			return workouts[i].CreatedAt.Before(workouts[j].CreatedAt)
+		}
+		return workouts[i].ID < workouts[j].ID
+	})
+	return workouts
+}
+
+func (s *AccountStore) ListAccountWorkouts(userID int) ([]*store.Workout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	workouts := s.db.userWorkouts(userID)
+	for _, w := range workouts {
+		if w.Entries == nil {
+			w.Entries = []store.WorkoutEntry{}
+		}
+	}
+	return workouts, nil
+}
+
+func (s *AccountStore) ListAccountTokens(userID int) ([]*store.TokenInfo, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	infos := []*store.TokenInfo{}
+	for _, t := range s.db.tokens {
+		if t.userID == userID {
+			infos = append(infos, &store.TokenInfo{Scope: t.scope, Expiry: t.expiry})
+		}
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].Expiry.Before(infos[j].Expiry) })
+	return infos, nil
+}
+
+func (s *AccountStore) ListAccountComments(userID int) ([]*store.Comment, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	comments := []*store.Comment{}
+	if _, ok := s.db.users[userID]; !ok {
+		return comments, nil
+	}
+	for _, row := range s.db.comments {
+		if row.comment.UserID == userID {
+			comments = append(comments, s.db.commentView(row))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+	return comments, nil
+}
+
+type achievementKey struct {
+	userID int
+	key    string
+}
+
+// ! AchievementStore --> store.AchievementStore on a DB
+type AchievementStore struct {
+	db *DB
+}
+
+func NewAchievementStore(db *DB) *AchievementStore {
+	return &AchievementStore{db: db}
+}
+
+// ! GetAchievementStats --> same numbers as the gaps-and-islands query, days are dates in the user's timezone
+func (s *AchievementStore) GetAchievementStats(userID int) (*store.AchievementStats, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stats := &store.AchievementStats{}
+	days := map[time.Time]bool{}
+	loc := s.db.location(userID)
+	for _, row := range s.db.workouts {
+		w := row.workout
+		if w.UserID != userID || w.Flagged {
+			continue
+		}
+		stats.TotalWorkouts++
+		local := w.PerformedAt.In(loc)
+		days[time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)] = true
+		for _, e := range w.Entries {
+			if e.Weight != nil && *e.Weight > stats.MaxWeightKG {
+				stats.MaxWeightKG = *e.Weight
+			}
+			if e.Reps != nil && e.Weight != nil {
+				stats.TotalVolumeKG += float64(e.Sets) * float64(*e.Reps) * *e.Weight
+			}
+		}
+	}
+
+	for day := range days {
+		if days[day.AddDate(0, 0, -1)] {
+			continue //* not the start of a run
+		}
+		length := 1
+		for days[day.AddDate(0, 0, length)] {
+			length++
+		}
+		stats.LongestStreakDays = max(stats.LongestStreakDays, length)
+	}
+	return stats, nil
+}
+
+func (s *AchievementStore) AwardAchievement(userID int, key string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return false, errForeignKey("user_achievements_user_id_fkey")
+	}
+	k := achievementKey{userID: userID, key: key}
+	if _, ok := s.db.achievements[k]; ok {
+		return false, nil
+	}
+	s.db.achievements[k] = s.db.now()
+	return true, nil
+}
+
+func (s *AchievementStore) ListAchievements(userID int) ([]*store.UserAchievement, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	achievements := []*store.UserAchievement{}
+	for k, earnedAt := range s.db.achievements {
+		if k.userID == userID {
+			achievements = append(achievements, &store.UserAchievement{Key: k.key, EarnedAt: earnedAt})
+		}
+	}
+	sort.Slice(achievements, func(i, j int) bool {
+		if !achievements[i].EarnedAt.Equal(achievements[j].EarnedAt) {
+			return achievements[i].EarnedAt.Before(achievements[j].EarnedAt)
+		}
+		return achievements[i].Key < achievements[j].Key
+	})
+	return achievements, nil
+}
+
+func (s *AchievementStore) GetAchievement(userID int, key string) (*store.UserAchievement, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	earnedAt, ok := s.db.achievements[achievementKey{userID: userID, key: key}]
+	if !ok {
+		return nil, nil
+	}
+	return &store.UserAchievement{Key: key, EarnedAt: earnedAt}, nil
+}
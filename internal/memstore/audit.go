@@ -0,0 +1,61 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"maps"
+)
+
+// ! AuditStore --> store.AuditStore on a DB
+type AuditStore struct {
+	db *DB
+}
+
+func NewAuditStore(db *DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+// * copyAuditEntry --> the caller gets its own ids + metadata
+func copyAuditEntry(e store.AuditEntry) *store.AuditEntry {
+	if e.ActorID != nil {
+		id := *e.ActorID
+		e.ActorID = &id
+	}
+	if e.TargetID != nil {
+		id := *e.TargetID
+		e.TargetID = &id
+	}
+	e.Metadata = maps.Clone(e.Metadata)
+	return &e
+}
+
+func (s *AuditStore) RecordAudit(entry *store.AuditEntry) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	entry.ID = s.db.nextID("audit_log")
+	entry.CreatedAt = s.db.now()
+	s.db.audit = append(s.db.audit, copyAuditEntry(*entry))
+	return nil
+}
+
+// ! ListAudit --> appended in id order, so walking backwards is ORDER BY id DESC
+func (s *AuditStore) ListAudit(filter store.AuditFilter) ([]*store.AuditEntry, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.AuditEntry{}
+	for i := len(s.db.audit) - 1; i >= 0 && len(list) < filter.Limit; i-- {
+		e := s.db.audit[i]
+		switch {
+		case filter.Action != "" && e.Action != filter.Action,
+			filter.ActorID != 0 && (e.ActorID == nil || *e.ActorID != filter.ActorID),
+			filter.TargetID != 0 && (e.TargetID == nil || *e.TargetID != filter.TargetID),
+			!filter.Since.IsZero() && e.CreatedAt.Before(filter.Since),
+			!filter.Until.IsZero() && !e.CreatedAt.Before(filter.Until),
+			filter.BeforeID != 0 && e.ID >= filter.BeforeID:
+			continue
+		}
+		list = append(list, copyAuditEntry(*e))
+	}
+	return list, nil
+}
@@ -0,0 +1,134 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+// ! AutomationStore --> store.AutomationStore on a DB
+type AutomationStore struct {
+	db *DB
+}
+
+func NewAutomationStore(db *DB) *AutomationStore {
+	return &AutomationStore{db: db}
+}
+
+// * copyRule --> condition and last_fired_at are pointers, the caller gets its own
+func copyRule(r store.AutomationRule) *store.AutomationRule {
+	if r.Condition != nil {
+		condition := *r.Condition
+		r.Condition = &condition
+	}
+	if r.LastFiredAt != nil {
+		at := *r.LastFiredAt
+		r.LastFiredAt = &at
+	}
+	return &r
+}
+
+func (s *AutomationStore) CreateRule(rule *store.AutomationRule) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[rule.UserID]; !ok {
+		return errForeignKey("automation_rules_user_id_fkey")
+	}
+	now := s.db.now()
+	rule.ID = s.db.nextID("automation_rules")
+	rule.LastFiredAt = nil
+	rule.CreatedAt, rule.UpdatedAt = now, now
+	s.db.rules[rule.ID] = copyRule(*rule)
+	return nil
+}
+
+func (s *AutomationStore) GetRule(id int64) (*store.AutomationRule, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	rule, ok := s.db.rules[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyRule(*rule), nil
+}
+
+// * listRules --> ORDER BY id
+func (s *AutomationStore) listRules(match func(*store.AutomationRule) bool) []*store.AutomationRule {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.AutomationRule{}
+	for _, rule := range s.db.rules {
+		if match(rule) {
+			list = append(list, copyRule(*rule))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (s *AutomationStore) ListRules(userID int) ([]*store.AutomationRule, error) {
+	return s.listRules(func(r *store.AutomationRule) bool { return r.UserID == userID }), nil
+}
+
+func (s *AutomationStore) ListEnabledRules(userID int, trigger string) ([]*store.AutomationRule, error) {
+	return s.listRules(func(r *store.AutomationRule) bool { return r.UserID == userID && r.Trigger == trigger && r.Enabled }), nil
+}
+
+func (s *AutomationStore) UpdateRule(rule *store.AutomationRule) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.rules[rule.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	rule.LastFiredAt = nil
+	rule.UpdatedAt = s.db.now()
+	updated := copyRule(*rule)
+	updated.UserID, updated.CreatedAt = stored.UserID, stored.CreatedAt
+	s.db.rules[rule.ID] = updated
+	return nil
+}
+
+func (s *AutomationStore) DeleteRule(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.rules[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.rules, id)
+	return nil
+}
+
+// ! MarkRuleFired --> check and set under mu, the conditional UPDATE's guarantee
+func (s *AutomationStore) MarkRuleFired(id int64, at, notSince time.Time) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	rule, ok := s.db.rules[id]
+	if !ok || rule.LastFiredAt != nil && !rule.LastFiredAt.Before(notSince) {
+		return false, nil
+	}
+	rule.LastFiredAt = &at
+	return true, nil
+}
+
+func (s *AutomationStore) GetAutomationStats(userID int, since time.Time) (*store.AutomationStats, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stats := &store.AutomationStats{Since: since}
+	for _, row := range s.db.workouts {
+		w := row.workout
+		if w.UserID == userID && !w.Flagged && !w.CreatedAt.Before(since) {
+			stats.Workouts++
+			stats.Minutes += w.DurationMinutes
+			stats.Calories += w.CaloriesBurned
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,32 @@
+package memstore
+
+// ! AvatarStore --> store.AvatarStore on a DB
+type AvatarStore struct {
+	db *DB
+}
+
+func NewAvatarStore(db *DB) *AvatarStore {
+	return &AvatarStore{db: db}
+}
+
+func (s *AvatarStore) GetAvatarKey(userID int) (string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.db.avatars[userID], nil
+}
+
+func (s *AvatarStore) SetAvatarKey(userID int, key string) (string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return "", errForeignKey("user_profiles_user_id_fkey")
+	}
+	previous := s.db.avatars[userID]
+	if key == "" {
+		delete(s.db.avatars, userID)
+	} else {
+		s.db.avatars[userID] = key
+	}
+	return previous, nil
+}
@@ -0,0 +1,305 @@
+package memstore
+
+import (
+	"fem/internal/store"
+)
+
+// ! compile-time checks, every store interface has a memstore implementation
+var (
+	_ store.AccountStore        = (*AccountStore)(nil)
+	_ store.AchievementStore    = (*AchievementStore)(nil)
+	_ store.AdminStore          = (*AdminStore)(nil)
+	_ store.AuditStore          = (*AuditStore)(nil)
+	_ store.AutomationStore     = (*AutomationStore)(nil)
+	_ store.AvatarStore         = (*AvatarStore)(nil)
+	_ store.ClientUsageStore    = (*ClientUsageStore)(nil)
+	_ store.CommentStore        = (*CommentStore)(nil)
+	_ store.DigestStore         = (*DigestStore)(nil)
+	_ store.ExperimentStore     = (*ExperimentStore)(nil)
+	_ store.ExportStore         = (*ExportStore)(nil)
+	_ store.FollowStore         = (*FollowStore)(nil)
+	_ store.GoalStore           = (*GoalStore)(nil)
+	_ store.GraphStore          = (*GraphStore)(nil)
+	_ store.IntegrationStore    = (*IntegrationStore)(nil)
+	_ store.JobStore            = (*JobStore)(nil)
+	_ store.LinkedAccountStore  = (*LinkedAccountStore)(nil)
+	_ store.LoginLockoutStore   = (*LoginLockoutStore)(nil)
+	_ store.OrgStore            = (*OrgStore)(nil)
+	_ store.PhotoStore          = (*PhotoStore)(nil)
+	_ store.ProfileStore        = (*ProfileStore)(nil)
+	_ store.ProgramStore        = (*ProgramStore)(nil)
+	_ store.ReminderStore       = (*ReminderStore)(nil)
+	_ store.ScheduleStore       = (*ScheduleStore)(nil)
+	_ store.SeasonalEventStore  = (*SeasonalEventStore)(nil)
+	_ store.SessionStore        = (*SessionStore)(nil)
+	_ store.TagStore            = (*TagStore)(nil)
+	_ store.ShadowStore         = (*ShadowStore)(nil)
+	_ store.ShareStore          = (*ShareStore)(nil)
+	_ store.TokenStore          = (*TokenStore)(nil)
+	_ store.TrainingLoadStore   = (*TrainingLoadStore)(nil)
+	_ store.TwoFactorStore      = (*TwoFactorStore)(nil)
+	_ store.UserStore           = (*UserStore)(nil)
+	_ store.UserUsageStore      = (*UserUsageStore)(nil)
+	_ store.VerificationStore   = (*VerificationStore)(nil)
+	_ store.WarehouseStore      = (*WarehouseStore)(nil)
+	_ store.WebhookStore        = (*WebhookStore)(nil)
+	_ store.WorkoutStore        = (*WorkoutStore)(nil)
+	_ store.ReplicaWorkoutStore = (*WorkoutStore)(nil)
+	_ store.SandboxStore        = (*SandboxStore)(nil)
+	_ store.XPStore             = (*XPStore)(nil)
+)
+
+// * cascadeWorkout --> the ON DELETE CASCADEs on workouts(id), caller holds mu
+func (db *DB) cascadeWorkout(id int) {
+	for cid, c := range db.comments {
+		if c.comment.WorkoutID == id {
+			delete(db.comments, cid)
+		}
+	}
+	for key := range db.reactions {
+		if key.workoutID == id {
+			delete(db.reactions, key)
+		}
+	}
+	for key := range db.feed {
+		if key.workoutID == id {
+			delete(db.feed, key)
+		}
+	}
+	kept := db.shares[:0]
+	for _, row := range db.shares {
+		if row.share.WorkoutID != id {
+			kept = append(kept, row)
+		}
+	}
+	db.shares = kept
+	delete(db.verifications, id)
+	for key := range db.tags {
+		if key.workoutID == id {
+			delete(db.tags, key)
+		}
+	}
+	for pid, photo := range db.photos {
+		if photo.WorkoutID == id {
+			delete(db.photos, pid)
+		}
+	}
+}
+
+// * anonymizeUser --> the DeleteAccount cleanup list past tokens and visibility, caller holds mu
+func (db *DB) anonymizeUser(userID int) {
+	owned := map[int]bool{}
+	for id, w := range db.workouts {
+		if w.workout.UserID == userID {
+			owned[id] = true
+		}
+	}
+
+	for _, c := range db.comments {
+		if c.comment.UserID == userID {
+			c.comment.UserID = 0
+		}
+	}
+	for key := range db.reactions {
+		if key.userID == userID {
+			delete(db.reactions, key)
+		}
+	}
+	for key := range db.follows {
+		if key.followerID == userID || key.followeeID == userID {
+			delete(db.follows, key)
+		}
+	}
+	for key := range db.feed {
+		if key.userID == userID || owned[key.workoutID] {
+			delete(db.feed, key)
+		}
+	}
+	db.revokeShares(func(row *shareRow) bool { return owned[row.share.WorkoutID] })
+	for id, c := range db.connections {
+		if c.UserID == userID {
+			delete(db.connections, id)
+		}
+	}
+	for id, w := range db.webhooks {
+		if w.UserID == userID {
+			db.deleteWebhook(id)
+		}
+	}
+	for id, d := range db.devices {
+		if d.UserID == userID {
+			delete(db.devices, id)
+		}
+	}
+	for _, r := range db.reminders {
+		if r.UserID == userID && r.Enabled {
+			r.Enabled = false
+			r.UpdatedAt = db.now()
+		}
+	}
+}
+
+// * purgeUser --> hard delete, every users(id) foreign key cascades or is set null, caller holds mu
+func (db *DB) purgeUser(userID int) {
+	delete(db.users, userID)
+	db.deleteTokens(func(t *tokenRow) bool { return t.userID == userID })
+	for id, w := range db.workouts {
+		if w.workout.UserID == userID {
+			db.deleteWorkout(id)
+		}
+	}
+	db.anonymizeUser(userID)
+
+	delete(db.profiles, userID)
+	delete(db.avatars, userID)
+	delete(db.twoFactor, userID)
+	for id, a := range db.identities {
+		if a.UserID == userID {
+			delete(db.identities, id)
+		}
+	}
+	keptWeights := db.weights[:0]
+	for _, w := range db.weights {
+		if w.UserID != userID {
+			keptWeights = append(keptWeights, w)
+			continue
+		}
+		db.recordWarehouseChange("user_weights", int64(w.ID), true)
+	}
+	db.weights = keptWeights
+	for id, g := range db.goals {
+		if g.UserID == userID {
+			delete(db.goals, id)
+		}
+	}
+	for key := range db.achievements {
+		if key.userID == userID {
+			delete(db.achievements, key)
+		}
+	}
+	for key := range db.xp {
+		if key.userID == userID {
+			delete(db.xp, key)
+		}
+	}
+	for id, r := range db.rules {
+		if r.UserID == userID {
+			delete(db.rules, id)
+		}
+	}
+	for id, s := range db.schedules {
+		if s.UserID == userID {
+			delete(db.schedules, id)
+		}
+	}
+	for id, o := range db.occurrences {
+		if o.userID == userID {
+			delete(db.occurrences, id)
+		}
+	}
+	for id, r := range db.reminders {
+		if r.UserID == userID {
+			delete(db.reminders, id)
+		}
+	}
+	for id, d := range db.devices {
+		if d.UserID == userID {
+			delete(db.devices, id)
+		}
+	}
+	delete(db.notifyPrefs, userID)
+	for id, n := range db.notifications {
+		if n.UserID == userID {
+			delete(db.notifications, id)
+		}
+	}
+	for id, program := range db.programs {
+		if program.UserID == userID {
+			db.deleteProgram(id)
+		}
+	}
+	for id, e := range db.enrollments {
+		if e.UserID == userID {
+			delete(db.enrollments, id)
+		}
+	}
+	for key := range db.digests {
+		if key.userID == userID {
+			delete(db.digests, key)
+		}
+	}
+	for key := range db.members {
+		if key.userID == userID {
+			delete(db.members, key)
+		}
+	}
+	for key := range db.provisioned {
+		if key.userID == userID {
+			delete(db.provisioned, key)
+		}
+	}
+	for id, e := range db.exports {
+		if e.RequestedBy == userID {
+			delete(db.exports, id)
+		}
+	}
+	for key := range db.participants {
+		if key.userID == userID {
+			delete(db.participants, key)
+		}
+	}
+	for eventID, results := range db.eventResults {
+		kept := results[:0]
+		for _, r := range results {
+			if r.UserID != userID {
+				kept = append(kept, r)
+			}
+		}
+		db.eventResults[eventID] = kept
+	}
+	for key := range db.challengeMembers {
+		if key.userID == userID {
+			delete(db.challengeMembers, key)
+		}
+	}
+	for challengeID, results := range db.challengeResults {
+		kept := results[:0]
+		for _, r := range results {
+			if r.UserID != userID {
+				kept = append(kept, r)
+			}
+		}
+		db.challengeResults[challengeID] = kept
+	}
+	for key, e := range db.exposures {
+		if key.userID == userID {
+			delete(db.exposures, key)
+			db.recordWarehouseChange("experiment_exposures", e.id, true)
+		}
+	}
+	for key := range db.userRequests {
+		if key.userID == userID {
+			delete(db.userRequests, key)
+		}
+	}
+	for key := range db.loadAlerts {
+		if key.userID == userID {
+			delete(db.loadAlerts, key)
+		}
+	}
+	for _, v := range db.verifications {
+		if v.AttestedBy != nil && *v.AttestedBy == userID {
+			v.AttestedBy = nil
+		}
+	}
+	for _, e := range db.events {
+		if e.CreatedBy != nil && *e.CreatedBy == userID {
+			e.CreatedBy = nil
+		}
+	}
+	for _, c := range db.challenges {
+		if c.CreatedBy != nil && *c.CreatedBy == userID {
+			c.CreatedBy = nil
+		}
+	}
+}
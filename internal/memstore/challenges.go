@@ -0,0 +1,254 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type challengeKey struct {
+	challengeID int
+	userID      int
+}
+
+// ! ChallengeStore --> store.ChallengeStore on a DB
+type ChallengeStore struct {
+	db *DB
+}
+
+func NewChallengeStore(db *DB) *ChallengeStore {
+	return &ChallengeStore{db: db}
+}
+
+// * challengeView --> copy with Status + Participants derived like challengeColumns, caller holds mu
+func (db *DB) challengeView(stored *store.Challenge) *store.Challenge {
+	challenge := *stored
+	now := db.now()
+	switch {
+	case challenge.ClosedAt != nil:
+		challenge.Status = store.EventStatusClosed
+	case now.Before(challenge.StartsAt):
+		challenge.Status = store.EventStatusUpcoming
+	case now.Before(challenge.EndsAt):
+		challenge.Status = store.EventStatusActive
+	default:
+		challenge.Status = store.EventStatusEnded
+	}
+	challenge.Participants = 0
+	for key := range db.challengeMembers {
+		if key.challengeID == challenge.ID {
+			challenge.Participants++
+		}
+	}
+	return &challenge
+}
+
+// * challengeOpen --> what the join / leave WHERE clauses check, caller holds mu
+func (db *DB) challengeOpen(id int) bool {
+	challenge, ok := db.challenges[id]
+	return ok && challenge.ClosedAt == nil && challenge.EndsAt.After(db.now())
+}
+
+func (s *ChallengeStore) CreateChallenge(challenge *store.Challenge) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if !store.ValidEventMetric(challenge.Metric) {
+		return errCheck("challenges_metric_check")
+	}
+	if !challenge.EndsAt.After(challenge.StartsAt) {
+		return errCheck("challenge_window")
+	}
+	if challenge.CreatedBy != nil {
+		if _, ok := s.db.users[*challenge.CreatedBy]; !ok {
+			return errForeignKey("challenges_created_by_fkey")
+		}
+	}
+	now := s.db.now()
+	stored := *challenge
+	stored.ID = int(s.db.nextID("challenges"))
+	stored.ClosedAt = nil
+	stored.CreatedAt, stored.UpdatedAt = now, now
+	s.db.challenges[stored.ID] = &stored
+	if stored.CreatedBy != nil {
+		s.db.challengeMembers[challengeKey{challengeID: stored.ID, userID: *stored.CreatedBy}] = now
+	}
+	*challenge = *s.db.challengeView(&stored)
+	return nil
+}
+
+func (s *ChallengeStore) GetChallenge(id int64) (*store.Challenge, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	challenge, ok := s.db.challenges[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.challengeView(challenge), nil
+}
+
+func (s *ChallengeStore) ListChallenges(userID int, limit int) ([]*store.Challenge, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := s.db.now()
+	challenges := []*store.Challenge{}
+	for key := range s.db.challengeMembers {
+		if key.userID == userID {
+			challenges = append(challenges, s.db.challengeView(s.db.challenges[key.challengeID]))
+		}
+	}
+	sort.Slice(challenges, func(i, j int) bool {
+		a, b := challenges[i], challenges[j]
+		if aEnded, bEnded := a.EndsAt.Before(now), b.EndsAt.Before(now); aEnded != bEnded {
+			return !aEnded
+		}
+		if !a.StartsAt.Equal(b.StartsAt) {
+			return a.StartsAt.After(b.StartsAt)
+		}
+		return a.ID > b.ID
+	})
+	return page(challenges, 0, limit), nil
+}
+
+func (s *ChallengeStore) JoinChallenge(id int64, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if !s.db.challengeOpen(int(id)) {
+		return store.ErrNotFound
+	}
+	if _, ok := s.db.users[userID]; !ok {
+		return errForeignKey("challenge_participants_user_id_fkey")
+	}
+	key := challengeKey{challengeID: int(id), userID: userID}
+	if _, ok := s.db.challengeMembers[key]; !ok {
+		s.db.challengeMembers[key] = s.db.now()
+	}
+	return nil
+}
+
+func (s *ChallengeStore) LeaveChallenge(id int64, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := challengeKey{challengeID: int(id), userID: userID}
+	if _, ok := s.db.challengeMembers[key]; !ok || !s.db.challengeOpen(int(id)) {
+		return store.ErrNotFound
+	}
+	delete(s.db.challengeMembers, key)
+	return nil
+}
+
+// * liveChallengeStandings --> challengeStandingsQuery, scored on performed_at, caller holds mu
+func (db *DB) liveChallengeStandings(challenge *store.Challenge) ([]*store.EventStanding, error) {
+	if !store.ValidEventMetric(challenge.Metric) {
+		return nil, fmt.Errorf("unknown challenge metric %q", challenge.Metric)
+	}
+	scores := map[int]*store.EventStanding{}
+	standings := []*store.EventStanding{}
+	for key := range db.challengeMembers {
+		user, ok := db.users[key.userID]
+		if key.challengeID != challenge.ID || !ok {
+			continue
+		}
+		standing := &store.EventStanding{UserID: key.userID, Username: user.user.Username}
+		scores[key.userID] = standing
+		standings = append(standings, standing)
+	}
+	for _, row := range db.workouts {
+		w := &row.workout
+		if standing := scores[w.UserID]; standing != nil && !w.Flagged && inWindow(w.PerformedAt, challenge.StartsAt, challenge.EndsAt) {
+			standing.Score += eventScore(challenge.Metric, w)
+		}
+	}
+	rankStandings(standings)
+	return standings, nil
+}
+
+// * rankStandings --> RANK() OVER (ORDER BY score DESC), ties share a rank and leave a gap
+func rankStandings(standings []*store.EventStanding) {
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		return standings[i].UserID < standings[j].UserID
+	})
+	for i, standing := range standings {
+		standing.Rank = i + 1
+		if i > 0 && standing.Score == standings[i-1].Score {
+			standing.Rank = standings[i-1].Rank
+		}
+	}
+}
+
+func (s *ChallengeStore) GetChallengeStandings(challenge *store.Challenge) ([]*store.EventStanding, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if challenge.ClosedAt == nil {
+		return s.db.liveChallengeStandings(challenge)
+	}
+	standings := []*store.EventStanding{}
+	for _, result := range s.db.challengeResults[challenge.ID] {
+		user, ok := s.db.users[result.UserID]
+		if !ok {
+			continue
+		}
+		standing := *result
+		standing.Username = user.user.Username
+		standings = append(standings, &standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Rank != standings[j].Rank {
+			return standings[i].Rank < standings[j].Rank
+		}
+		return standings[i].UserID < standings[j].UserID
+	})
+	return standings, nil
+}
+
+func (s *ChallengeStore) ListChallengesToClose(now time.Time) ([]*store.Challenge, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	challenges := []*store.Challenge{}
+	for _, challenge := range s.db.challenges {
+		if challenge.ClosedAt == nil && !challenge.EndsAt.After(now) {
+			challenges = append(challenges, s.db.challengeView(challenge))
+		}
+	}
+	sort.Slice(challenges, func(i, j int) bool {
+		if !challenges[i].EndsAt.Equal(challenges[j].EndsAt) {
+			return challenges[i].EndsAt.Before(challenges[j].EndsAt)
+		}
+		return challenges[i].ID < challenges[j].ID
+	})
+	return challenges, nil
+}
+
+func (s *ChallengeStore) CloseChallenge(id int64) ([]*store.EventStanding, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	challenge, ok := s.db.challenges[int(id)]
+	if !ok || challenge.ClosedAt != nil {
+		return nil, nil
+	}
+	standings, err := s.db.liveChallengeStandings(challenge)
+	if err != nil {
+		return nil, err
+	}
+	now := s.db.now()
+	challenge.ClosedAt = &now
+	challenge.UpdatedAt = now
+
+	for _, standing := range standings {
+		standing.Username = "" //* RETURNING doesn't join users
+		stored := *standing
+		s.db.challengeResults[challenge.ID] = append(s.db.challengeResults[challenge.ID], &stored)
+	}
+	return standings, nil
+}
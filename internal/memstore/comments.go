@@ -0,0 +1,153 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+)
+
+type commentRow struct {
+	comment store.Comment //* UserID 0 once the author's account is deleted
+}
+
+type reactionKey struct {
+	workoutID int
+	userID    int
+	emoji     string
+}
+
+// ! CommentStore --> store.CommentStore on a DB
+type CommentStore struct {
+	db *DB
+}
+
+func NewCommentStore(db *DB) *CommentStore {
+	return &CommentStore{db: db}
+}
+
+// * commentView --> the LEFT JOIN on users, caller holds mu
+func (db *DB) commentView(row *commentRow) *store.Comment {
+	comment := row.comment
+	comment.Username = "[deleted]"
+	if user, ok := db.users[comment.UserID]; ok {
+		comment.Username = user.user.Username
+	}
+	return &comment
+}
+
+func (s *CommentStore) CreateComment(comment *store.Comment) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[comment.WorkoutID]; !ok {
+		return errForeignKey("workout_comments_workout_id_fkey")
+	}
+	user, ok := s.db.users[comment.UserID]
+	if !ok {
+		return errForeignKey("workout_comments_user_id_fkey")
+	}
+	comment.ID = int(s.db.nextID("workout_comments"))
+	comment.Username = user.user.Username
+	comment.CreatedAt = s.db.now()
+	s.db.comments[comment.ID] = &commentRow{comment: *comment}
+	return nil
+}
+
+func (s *CommentStore) GetComment(id int64) (*store.Comment, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.comments[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.commentView(row), nil
+}
+
+func (s *CommentStore) ListComments(workoutID int64, offset, limit int) ([]*store.Comment, int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	comments := []*store.Comment{}
+	for _, row := range s.db.comments {
+		if row.comment.WorkoutID == int(workoutID) {
+			comments = append(comments, s.db.commentView(row))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+	return page(comments, offset, limit), len(comments), nil
+}
+
+func (s *CommentStore) DeleteComment(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.comments[int(id)]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.comments, int(id))
+	return nil
+}
+
+func (s *CommentStore) AddReaction(workoutID, userID int64, emoji string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[int(workoutID)]; !ok {
+		return errForeignKey("workout_reactions_workout_id_fkey")
+	}
+	if _, ok := s.db.users[int(userID)]; !ok {
+		return errForeignKey("workout_reactions_user_id_fkey")
+	}
+	key := reactionKey{workoutID: int(workoutID), userID: int(userID), emoji: emoji}
+	if _, ok := s.db.reactions[key]; !ok {
+		s.db.reactions[key] = s.db.now()
+	}
+	return nil
+}
+
+func (s *CommentStore) RemoveReaction(workoutID, userID int64, emoji string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := reactionKey{workoutID: int(workoutID), userID: int(userID), emoji: emoji}
+	if _, ok := s.db.reactions[key]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.reactions, key)
+	return nil
+}
+
+func (s *CommentStore) GetCounts(workoutID int64) (*store.SocialCounts, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	counts := &store.SocialCounts{Reactions: map[string]int{}}
+	for _, row := range s.db.comments {
+		if row.comment.WorkoutID == int(workoutID) {
+			counts.Comments++
+		}
+	}
+	for key := range s.db.reactions {
+		if key.workoutID == int(workoutID) {
+			counts.Reactions[key.emoji]++
+		}
+	}
+	return counts, nil
+}
+
+// * page --> OFFSET/LIMIT on an already sorted slice, a negative limit means no limit
+func page[T any](list []T, offset, limit int) []T {
+	if offset >= len(list) {
+		return list[:0]
+	}
+	list = list[max(offset, 0):]
+	if limit >= 0 && limit < len(list) {
+		list = list[:limit]
+	}
+	return list
+}
@@ -0,0 +1,184 @@
+// Package memstore keeps every store interface in process memory, for tests and -demo mode.
+// One DB holds all tables behind a single mutex so cross-table reads (feeds, stats, exports)
+// see a consistent snapshot, the same way one postgres query would.
+package memstore
+
+import (
+	"fem/internal/store"
+	"sync"
+	"time"
+)
+
+// ! DB --> the in-memory "database", every store built from it shares its tables
+type DB struct {
+	mu  sync.Mutex
+	now func() time.Time
+	seq map[string]int64 //* per-table id sequences, like BIGSERIAL
+
+	users    map[int]*userRow
+	tokens   []*tokenRow
+	workouts map[int]*workoutRow
+
+	comments      map[int]*commentRow
+	reactions     map[reactionKey]time.Time
+	follows       map[followKey]time.Time
+	feed          map[feedKey]time.Time
+	shares        []*shareRow
+	verifications map[int]*store.WorkoutVerification
+	tags          map[tagKey]time.Time
+	photos        map[int64]*store.WorkoutPhoto
+
+	profiles     map[int]*store.Profile
+	avatars      map[int]string //* user id --> blob key, the avatar_key column
+	weights      []*weightRow
+	goals        map[int]*store.Goal
+	achievements map[achievementKey]time.Time
+	xp           map[xpKey]int
+	rules        map[int64]*store.AutomationRule
+	schedules    map[int]*store.Schedule
+	occurrences  map[int]*occurrenceRow
+	reminders    map[int64]*store.Reminder
+	devices      map[int64]*store.Device
+	notifyPrefs  map[int]*store.NotificationPreferences
+
+	notifications map[int64]*store.Notification //* the in-app inbox
+	digests       map[digestKey]time.Time       //* digest_deliveries, see digests.go
+
+	orgs         map[int]*orgRow
+	members      map[memberKey]*memberRow
+	provisioned  map[memberKey]time.Time //* scim_provisioned_users
+	exports      map[int]*store.ExportJob
+	events       map[int]*store.SeasonalEvent
+	participants map[participantKey]time.Time
+	eventResults map[int][]*store.EventStanding
+
+	programs    map[int]*store.Program
+	enrollments map[int64]*store.ProgramEnrollment
+
+	challenges       map[int]*store.Challenge
+	challengeMembers map[challengeKey]time.Time
+	challengeResults map[int][]*store.EventStanding
+
+	jobs         map[int64]*jobRow
+	connections  map[int64]*store.IntegrationConnection
+	webhooks     map[int64]*store.Webhook
+	deliveries   map[int64]*store.WebhookDelivery
+	clientUsage  map[clientUsageKey]*store.ClientUsage
+	userRequests map[userRequestKey]int64
+	loadAlerts   map[trainingLoadAlertKey]float64
+	audit        []*store.AuditEntry //* append-only, survives purges like the FK-less table
+	lockouts     map[loginLockoutKey]*store.LoginLockout
+	twoFactor    map[int]*twoFactorRow
+	identities   map[int64]*store.LinkedAccount
+	exposures    map[exposureKey]*exposureRow
+	shadowDiffs  []*store.ShadowDiff
+	syncCursors  map[string]store.SyncCursor
+	changes      map[int]*changeRow                 //* workout id --> its latest change, see sync.go
+	warehouseLog map[warehouseKey]*store.ChangeMeta //* synced row --> its latest change, see warehouse.go
+	outbox       []*outboxRow                       //* id order, see outbox.go
+	leaderboard  map[int]*leaderboardRow            //* the leaderboard_stats snapshot, empty until the first refresh
+}
+
+type userRow struct {
+	user             store.User
+	deletedAt        *time.Time
+	sandboxExpiresAt *time.Time
+}
+
+type tokenRow struct {
+	id        int64
+	hash      []byte
+	userID    int
+	expiry    time.Time
+	scope     string
+	createdAt time.Time
+	lastUsed  *time.Time //* session metadata, see sessions.go
+	ip        string
+	userAgent string
+}
+
+type workoutRow struct {
+	workout        store.Workout
+	updatedAt      time.Time
+	externalSource string
+	externalID     string
+	entryCreatedAt time.Time
+}
+
+// ! New --> empty database, ids start at 1 like a fresh postgres
+func New() *DB {
+	return &DB{
+		now:      func() time.Time { return time.Now().UTC() },
+		seq:      map[string]int64{},
+		users:    map[int]*userRow{},
+		workouts: map[int]*workoutRow{},
+
+		comments:      map[int]*commentRow{},
+		reactions:     map[reactionKey]time.Time{},
+		follows:       map[followKey]time.Time{},
+		feed:          map[feedKey]time.Time{},
+		verifications: map[int]*store.WorkoutVerification{},
+		tags:          map[tagKey]time.Time{},
+		photos:        map[int64]*store.WorkoutPhoto{},
+
+		profiles:     map[int]*store.Profile{},
+		avatars:      map[int]string{},
+		goals:        map[int]*store.Goal{},
+		achievements: map[achievementKey]time.Time{},
+		xp:           map[xpKey]int{},
+		rules:        map[int64]*store.AutomationRule{},
+		schedules:    map[int]*store.Schedule{},
+		occurrences:  map[int]*occurrenceRow{},
+		reminders:    map[int64]*store.Reminder{},
+		devices:      map[int64]*store.Device{},
+		notifyPrefs:  map[int]*store.NotificationPreferences{},
+
+		notifications: map[int64]*store.Notification{},
+		digests:       map[digestKey]time.Time{},
+
+		orgs:         map[int]*orgRow{},
+		members:      map[memberKey]*memberRow{},
+		provisioned:  map[memberKey]time.Time{},
+		exports:      map[int]*store.ExportJob{},
+		events:       map[int]*store.SeasonalEvent{},
+		participants: map[participantKey]time.Time{},
+		eventResults: map[int][]*store.EventStanding{},
+
+		programs:    map[int]*store.Program{},
+		enrollments: map[int64]*store.ProgramEnrollment{},
+
+		challenges:       map[int]*store.Challenge{},
+		challengeMembers: map[challengeKey]time.Time{},
+		challengeResults: map[int][]*store.EventStanding{},
+
+		jobs:         map[int64]*jobRow{},
+		connections:  map[int64]*store.IntegrationConnection{},
+		webhooks:     map[int64]*store.Webhook{},
+		deliveries:   map[int64]*store.WebhookDelivery{},
+		clientUsage:  map[clientUsageKey]*store.ClientUsage{},
+		userRequests: map[userRequestKey]int64{},
+		loadAlerts:   map[trainingLoadAlertKey]float64{},
+		lockouts:     map[loginLockoutKey]*store.LoginLockout{},
+		twoFactor:    map[int]*twoFactorRow{},
+		identities:   map[int64]*store.LinkedAccount{},
+		exposures:    map[exposureKey]*exposureRow{},
+		syncCursors:  map[string]store.SyncCursor{},
+		changes:      map[int]*changeRow{},
+		warehouseLog: map[warehouseKey]*store.ChangeMeta{},
+	}
+}
+
+// * nextID --> caller holds mu
+func (db *DB) nextID(table string) int64 {
+	db.seq[table]++
+	return db.seq[table]
+}
+
+// * liveUser --> nil for missing and soft-deleted users, caller holds mu
+func (db *DB) liveUser(id int) *userRow {
+	row, ok := db.users[id]
+	if !ok || row.deletedAt != nil {
+		return nil
+	}
+	return row
+}
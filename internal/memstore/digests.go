@@ -0,0 +1,123 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+// * digestKey --> one digest_deliveries row
+type digestKey struct {
+	userID int
+	week   time.Time
+}
+
+// ! DigestStore --> store.DigestStore on a DB
+type DigestStore struct {
+	db *DB
+}
+
+func NewDigestStore(db *DB) *DigestStore {
+	return &DigestStore{db: db}
+}
+
+func (s *DigestStore) ListDigestRecipients(now time.Time, sendAt time.Duration) ([]*store.DigestRecipient, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	recipients := []*store.DigestRecipient{}
+	for id, row := range s.db.users {
+		if row.deletedAt != nil || row.sandboxExpiresAt != nil {
+			continue
+		}
+		if prefs, ok := s.db.notifyPrefs[id]; ok && !prefs.WeeklyDigest {
+			continue
+		}
+		timezone := store.DefaultTimezone
+		if profile, ok := s.db.profiles[id]; ok && profile.Timezone != "" {
+			timezone = profile.Timezone
+		}
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err //* like AT TIME ZONE with a name postgres doesn't know
+		}
+		local := now.In(loc)
+		clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+		if local.Weekday() != time.Monday || clock < sendAt {
+			continue
+		}
+		if _, ok := s.db.digests[digestKey{userID: id, week: time.Date(local.Year(), local.Month(), local.Day()-7, 0, 0, 0, 0, time.UTC)}]; ok {
+			continue
+		}
+		recipients = append(recipients, &store.DigestRecipient{UserID: id, Username: row.user.Username, Email: row.user.Email, Timezone: timezone})
+	}
+	sort.Slice(recipients, func(i, j int) bool { return recipients[i].UserID < recipients[j].UserID })
+	return recipients, nil
+}
+
+// ! GetDigestSummary --> same numbers as the postgres query, records need an earlier weighted set of the exercise
+func (s *DigestStore) GetDigestSummary(userID int, from, to time.Time) (*store.DigestSummary, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	summary := &store.DigestSummary{Records: []*store.PersonalRecord{}}
+	week, before := map[string]float64{}, map[string]float64{}
+	for _, row := range s.db.workouts {
+		w := row.workout
+		if w.UserID != userID || w.Flagged || !w.PerformedAt.Before(to) {
+			continue
+		}
+		inWeek := !w.PerformedAt.Before(from)
+		if inWeek {
+			summary.Workouts++
+			summary.Minutes += w.DurationMinutes
+		}
+		for _, e := range w.Entries {
+			if e.Weight == nil {
+				continue
+			}
+			if inWeek {
+				if e.Reps != nil {
+					summary.VolumeKG += float64(e.Sets**e.Reps) * *e.Weight
+				}
+				week[e.ExerciseName] = max(week[e.ExerciseName], *e.Weight)
+				continue
+			}
+			if best, ok := before[e.ExerciseName]; !ok || *e.Weight > best {
+				before[e.ExerciseName] = *e.Weight
+			}
+		}
+	}
+
+	for name, best := range week {
+		previous, ok := before[name]
+		if ok && best > previous {
+			summary.Records = append(summary.Records, &store.PersonalRecord{ExerciseName: name, WeightKG: best, PreviousKG: previous})
+		}
+	}
+	sort.Slice(summary.Records, func(i, j int) bool { return summary.Records[i].ExerciseName < summary.Records[j].ExerciseName })
+	return summary, nil
+}
+
+func (s *DigestStore) MarkDigestSent(userID int, week time.Time) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return false, errForeignKey("digest_deliveries_user_id_fkey")
+	}
+	key := digestKey{userID: userID, week: time.Date(week.Year(), week.Month(), week.Day(), 0, 0, 0, 0, time.UTC)}
+	if _, ok := s.db.digests[key]; ok {
+		return false, nil
+	}
+	s.db.digests[key] = s.db.now()
+	return true, nil
+}
+
+func (s *DigestStore) UnmarkDigestSent(userID int, week time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.digests, digestKey{userID: userID, week: time.Date(week.Year(), week.Month(), week.Day(), 0, 0, 0, 0, time.UTC)})
+	return nil
+}
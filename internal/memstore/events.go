@@ -0,0 +1,316 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type participantKey struct {
+	eventID int
+	userID  int
+}
+
+// ! SeasonalEventStore --> store.SeasonalEventStore on a DB
+type SeasonalEventStore struct {
+	db *DB
+}
+
+func NewSeasonalEventStore(db *DB) *SeasonalEventStore {
+	return &SeasonalEventStore{db: db}
+}
+
+// * eventView --> copy with Status derived like seasonalEventColumns, caller holds mu
+func (db *DB) eventView(stored *store.SeasonalEvent) *store.SeasonalEvent {
+	event := *stored
+	now := db.now()
+	switch {
+	case event.ClosedAt != nil:
+		event.Status = store.EventStatusClosed
+	case now.Before(event.StartsAt):
+		event.Status = store.EventStatusUpcoming
+	case now.Before(event.EndsAt):
+		event.Status = store.EventStatusActive
+	default:
+		event.Status = store.EventStatusEnded
+	}
+	return &event
+}
+
+// * checkEvent --> the metric CHECK and seasonal_event_window
+func checkEvent(event *store.SeasonalEvent) error {
+	if !store.ValidEventMetric(event.Metric) {
+		return errCheck("seasonal_events_metric_check")
+	}
+	if !event.EndsAt.After(event.StartsAt) {
+		return errCheck("seasonal_event_window")
+	}
+	return nil
+}
+
+func (s *SeasonalEventStore) CreateSeasonalEvent(event *store.SeasonalEvent) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if err := checkEvent(event); err != nil {
+		return err
+	}
+	if event.CreatedBy != nil {
+		if _, ok := s.db.users[*event.CreatedBy]; !ok {
+			return errForeignKey("seasonal_events_created_by_fkey")
+		}
+	}
+	now := s.db.now()
+	stored := *event
+	stored.ID = int(s.db.nextID("seasonal_events"))
+	if stored.BadgeColor == "" {
+		stored.BadgeColor = "#9c27b0"
+	}
+	stored.ClosedAt = nil
+	stored.CreatedAt, stored.UpdatedAt = now, now
+	s.db.events[stored.ID] = &stored
+	*event = *s.db.eventView(&stored)
+	return nil
+}
+
+func (s *SeasonalEventStore) GetSeasonalEvent(id int64) (*store.SeasonalEvent, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	event, ok := s.db.events[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.eventView(event), nil
+}
+
+func (s *SeasonalEventStore) ListSeasonalEvents(limit int) ([]*store.SeasonalEvent, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := s.db.now()
+	events := []*store.SeasonalEvent{}
+	for _, event := range s.db.events {
+		events = append(events, s.db.eventView(event))
+	}
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if aEnded, bEnded := a.EndsAt.Before(now), b.EndsAt.Before(now); aEnded != bEnded {
+			return !aEnded
+		}
+		if !a.StartsAt.Equal(b.StartsAt) {
+			return a.StartsAt.After(b.StartsAt)
+		}
+		return a.ID > b.ID
+	})
+	return page(events, 0, limit), nil
+}
+
+func (s *SeasonalEventStore) UpdateSeasonalEvent(event *store.SeasonalEvent) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.events[event.ID]
+	if !ok || stored.ClosedAt != nil {
+		return store.ErrNotFound
+	}
+	if err := checkEvent(event); err != nil {
+		return err
+	}
+	stored.Name, stored.Description, stored.Metric = event.Name, event.Description, event.Metric
+	stored.StartsAt, stored.EndsAt, stored.BadgeColor = event.StartsAt, event.EndsAt, event.BadgeColor
+	stored.UpdatedAt = s.db.now()
+	*event = *s.db.eventView(stored)
+	return nil
+}
+
+func (s *SeasonalEventStore) DeleteSeasonalEvent(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.events[int(id)]; !ok {
+		return store.ErrNotFound
+	}
+	s.db.deleteEvent(int(id))
+	return nil
+}
+
+// * deleteEvent --> participants and results cascade, caller holds mu
+func (db *DB) deleteEvent(id int) {
+	delete(db.events, id)
+	delete(db.eventResults, id)
+	for key := range db.participants {
+		if key.eventID == id {
+			delete(db.participants, key)
+		}
+	}
+}
+
+func (s *SeasonalEventStore) EnrollOptedInUsers() (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := s.db.now()
+	var enrolled int64
+	for _, event := range s.db.events {
+		if event.ClosedAt != nil || !event.EndsAt.After(now) {
+			continue
+		}
+		for userID, profile := range s.db.profiles {
+			key := participantKey{eventID: event.ID, userID: userID}
+			if _, ok := s.db.participants[key]; profile.EventsOptIn && !ok {
+				s.db.participants[key] = now
+				enrolled++
+			}
+		}
+	}
+	return enrolled, nil
+}
+
+// * eventScore --> the eventMetricExpr aggregate for one workout
+func eventScore(metric string, w *store.Workout) float64 {
+	switch metric {
+	case store.EventMetricWorkouts:
+		return 1
+	case store.EventMetricDurationMinutes:
+		return float64(w.DurationMinutes)
+	case store.EventMetricCalories:
+		return float64(w.CaloriesBurned)
+	}
+	var volume float64
+	for _, e := range w.Entries {
+		if e.Reps != nil && e.Weight != nil {
+			volume += float64(e.Sets) * float64(*e.Reps) * *e.Weight
+		}
+	}
+	return volume
+}
+
+// * liveStandings --> standingsQuery, caller holds mu
+func (db *DB) liveStandings(event *store.SeasonalEvent) ([]*store.EventStanding, error) {
+	if !store.ValidEventMetric(event.Metric) {
+		return nil, fmt.Errorf("unknown event metric %q", event.Metric)
+	}
+	scores := map[int]*store.EventStanding{}
+	standings := []*store.EventStanding{}
+	for key := range db.participants {
+		user, ok := db.users[key.userID]
+		if key.eventID != event.ID || !ok {
+			continue
+		}
+		standing := &store.EventStanding{UserID: key.userID, Username: user.user.Username}
+		scores[key.userID] = standing
+		standings = append(standings, standing)
+	}
+	for _, row := range db.workouts {
+		w := &row.workout
+		if standing := scores[w.UserID]; standing != nil && !w.Flagged && inWindow(w.CreatedAt, event.StartsAt, event.EndsAt) {
+			standing.Score += eventScore(event.Metric, w)
+		}
+	}
+	rankStandings(standings)
+	return standings, nil
+}
+
+// * frozenStandings --> results written at close with the current usernames, caller holds mu
+func (db *DB) frozenStandings(eventID int) []*store.EventStanding {
+	standings := []*store.EventStanding{}
+	for _, result := range db.eventResults[eventID] {
+		user, ok := db.users[result.UserID]
+		if !ok {
+			continue
+		}
+		standing := *result
+		standing.Username = user.user.Username
+		standings = append(standings, &standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Rank != standings[j].Rank {
+			return standings[i].Rank < standings[j].Rank
+		}
+		return standings[i].UserID < standings[j].UserID
+	})
+	return standings
+}
+
+func (s *SeasonalEventStore) GetStandings(event *store.SeasonalEvent, limit int) ([]*store.EventStanding, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if event.ClosedAt != nil {
+		return page(s.db.frozenStandings(event.ID), 0, limit), nil
+	}
+	standings, err := s.db.liveStandings(event)
+	if err != nil {
+		return nil, err
+	}
+	return page(standings, 0, limit), nil
+}
+
+func (s *SeasonalEventStore) GetStanding(event *store.SeasonalEvent, userID int) (*store.EventStanding, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	standings := s.db.frozenStandings(event.ID)
+	if event.ClosedAt == nil {
+		var err error
+		standings, err = s.db.liveStandings(event)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, standing := range standings {
+		if standing.UserID == userID {
+			return standing, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *SeasonalEventStore) ListEventsToClose(now time.Time) ([]*store.SeasonalEvent, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	events := []*store.SeasonalEvent{}
+	for _, event := range s.db.events {
+		if event.ClosedAt == nil && !event.EndsAt.After(now) {
+			events = append(events, s.db.eventView(event))
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].EndsAt.Equal(events[j].EndsAt) {
+			return events[i].EndsAt.Before(events[j].EndsAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+	return events, nil
+}
+
+func (s *SeasonalEventStore) CloseSeasonalEvent(id int64) ([]*store.EventStanding, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	event, ok := s.db.events[int(id)]
+	if !ok || event.ClosedAt != nil {
+		return nil, nil
+	}
+	standings, err := s.db.liveStandings(event)
+	if err != nil {
+		return nil, err
+	}
+	now := s.db.now()
+	event.ClosedAt = &now
+	event.UpdatedAt = now
+
+	results := []*store.EventStanding{}
+	for _, standing := range standings {
+		if standing.Score > 0 {
+			standing.Username = "" //* RETURNING doesn't join users
+			results = append(results, standing)
+			stored := *standing
+			s.db.eventResults[event.ID] = append(s.db.eventResults[event.ID], &stored)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,157 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+)
+
+type followKey struct {
+	followerID int
+	followeeID int
+}
+
+type feedKey struct {
+	userID    int
+	workoutID int
+}
+
+// ! FollowStore --> store.FollowStore on a DB, feed_entries is kept fanned out like in postgres
+type FollowStore struct {
+	db *DB
+}
+
+func NewFollowStore(db *DB) *FollowStore {
+	return &FollowStore{db: db}
+}
+
+func (s *FollowStore) Follow(followerID, followeeID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if followerID == followeeID {
+		return errCheck("no_self_follow")
+	}
+	if _, ok := s.db.users[int(followerID)]; !ok {
+		return errForeignKey("follows_follower_id_fkey")
+	}
+	if _, ok := s.db.users[int(followeeID)]; !ok {
+		return errForeignKey("follows_followee_id_fkey")
+	}
+	key := followKey{followerID: int(followerID), followeeID: int(followeeID)}
+	if _, ok := s.db.follows[key]; !ok {
+		s.db.follows[key] = s.db.now()
+	}
+	return nil
+}
+
+func (s *FollowStore) Unfollow(followerID, followeeID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := followKey{followerID: int(followerID), followeeID: int(followeeID)}
+	if _, ok := s.db.follows[key]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.follows, key)
+	for entry := range s.db.feed {
+		if w, ok := s.db.workouts[entry.workoutID]; entry.userID == int(followerID) && ok && w.workout.UserID == int(followeeID) {
+			delete(s.db.feed, entry)
+		}
+	}
+	return nil
+}
+
+func (s *FollowStore) IsFollowing(followerID, followeeID int64) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	_, ok := s.db.follows[followKey{followerID: int(followerID), followeeID: int(followeeID)}]
+	return ok, nil
+}
+
+func (s *FollowStore) GetFeed(userID int64, before *store.FeedCursor, limit int) ([]*store.FeedItem, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	items := []*store.FeedItem{}
+	for entry, at := range s.db.feed {
+		if entry.userID != int(userID) {
+			continue
+		}
+		if before != nil && !(at.Before(before.At) || at.Equal(before.At) && int64(entry.workoutID) < before.ID) {
+			continue
+		}
+		row, ok := s.db.workouts[entry.workoutID]
+		if !ok || row.workout.Visibility == store.VisibilityPrivate {
+			continue
+		}
+		w := row.workout
+		var username string
+		if user, ok := s.db.users[w.UserID]; ok {
+			username = user.user.Username
+		}
+		items = append(items, &store.FeedItem{
+			WorkoutID:       w.ID,
+			UserID:          w.UserID,
+			Username:        username,
+			Title:           w.Title,
+			Description:     w.Description,
+			DurationMinutes: w.DurationMinutes,
+			CaloriesBurned:  w.CaloriesBurned,
+			Visibility:      w.Visibility,
+			Verified:        w.Verified,
+			CreatedAt:       at,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].WorkoutID > items[j].WorkoutID
+	})
+	return page(items, 0, limit), nil
+}
+
+func (s *FollowStore) FanOutWorkout(workoutID int64) ([]int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	followers := []int{}
+	row, ok := s.db.workouts[int(workoutID)]
+	if !ok || row.workout.Visibility == store.VisibilityPrivate {
+		return followers, nil
+	}
+	for key := range s.db.follows {
+		if key.followeeID != row.workout.UserID {
+			continue
+		}
+		entry := feedKey{userID: key.followerID, workoutID: row.workout.ID}
+		if _, ok := s.db.feed[entry]; !ok {
+			s.db.feed[entry] = row.workout.CreatedAt
+			followers = append(followers, key.followerID)
+		}
+	}
+	sort.Ints(followers)
+	return followers, nil
+}
+
+func (s *FollowStore) BackfillFeed(followerID, followeeID int64) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.follows[followKey{followerID: int(followerID), followeeID: int(followeeID)}]; !ok {
+		return 0, nil
+	}
+	var added int64
+	for _, row := range s.db.workouts {
+		if row.workout.UserID != int(followeeID) || row.workout.Visibility == store.VisibilityPrivate {
+			continue
+		}
+		entry := feedKey{userID: int(followerID), workoutID: row.workout.ID}
+		if _, ok := s.db.feed[entry]; !ok {
+			s.db.feed[entry] = row.workout.CreatedAt
+			added++
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,229 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ! GraphStore --> store.GraphStore on a DB
+type GraphStore struct {
+	db *DB
+}
+
+func NewGraphStore(db *DB) *GraphStore {
+	return &GraphStore{db: db}
+}
+
+// * visibleTo --> the visibleToViewer rule, caller holds mu
+func (db *DB) visibleTo(viewerID int, w *store.Workout) bool {
+	switch {
+	case w.UserID == viewerID || w.Visibility == store.VisibilityPublic:
+		return true
+	case w.Visibility == store.VisibilityFollowers:
+		_, ok := db.follows[followKey{followerID: viewerID, followeeID: w.UserID}]
+		return ok
+	}
+	return false
+}
+
+func (s *GraphStore) GetUsersByIDs(ids []int64) (map[int64]*store.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	users := map[int64]*store.User{}
+	for _, id := range ids {
+		if row := s.db.liveUser(int(id)); row != nil {
+			user := row.user
+			user.PasswordHash = store.User{}.PasswordHash //* the graph never selects the hash
+			users[id] = &user
+		}
+	}
+	return users, nil
+}
+
+func (s *GraphStore) GetEntriesByWorkoutIDs(ids []int64) (map[int64][]store.WorkoutEntry, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	entries := map[int64][]store.WorkoutEntry{}
+	for _, id := range ids {
+		row, ok := s.db.workouts[int(id)]
+		if !ok || len(row.workout.Entries) == 0 {
+			continue
+		}
+		list := append([]store.WorkoutEntry(nil), row.workout.Entries...)
+		sort.SliceStable(list, func(i, j int) bool { return list[i].OrderIndex < list[j].OrderIndex })
+		entries[id] = list
+	}
+	return entries, nil
+}
+
+func (s *GraphStore) GetWorkoutStatsByUserIDs(viewerID int64, ids []int64) (map[int64]*store.WorkoutStats, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	wanted := map[int]bool{}
+	for _, id := range ids {
+		wanted[int(id)] = true
+	}
+	stats := map[int64]*store.WorkoutStats{}
+	for _, row := range s.db.workouts {
+		w := &row.workout
+		if !wanted[w.UserID] || w.Flagged || !s.db.visibleTo(int(viewerID), w) {
+			continue
+		}
+		stat := stats[int64(w.UserID)]
+		if stat == nil {
+			stat = &store.WorkoutStats{}
+			stats[int64(w.UserID)] = stat
+		}
+		stat.Workouts++
+		stat.Minutes += w.DurationMinutes
+		stat.Calories += w.CaloriesBurned
+	}
+	return stats, nil
+}
+
+func (s *GraphStore) ListVisibleWorkouts(viewerID, ownerID int64, limit, offset int) ([]*store.Workout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	workouts := []*store.Workout{}
+	for _, row := range s.db.workouts {
+		if row.workout.UserID == int(ownerID) && s.db.visibleTo(int(viewerID), &row.workout) {
+			w := row.workout
+			w.Entries = nil
+			workouts = append(workouts, &w)
+		}
+	}
+	sortNewestFirst(workouts)
+	return page(workouts, offset, limit), nil
+}
+
+// * sortNewestFirst --> ORDER BY created_at DESC, id DESC
+func sortNewestFirst(workouts []*store.Workout) {
+	sort.Slice(workouts, func(i, j int) bool {
+		if !workouts[i].CreatedAt.Equal(workouts[j].CreatedAt) {
+			return workouts[i].CreatedAt.After(workouts[j].CreatedAt)
+		}
+		return workouts[i].ID > workouts[j].ID
+	})
+}
+
+type xpKey struct {
+	userID int
+	source string
+	ref    string
+}
+
+// ! XPStore --> store.XPStore on a DB
+type XPStore struct {
+	db *DB
+}
+
+func NewXPStore(db *DB) *XPStore {
+	return &XPStore{db: db}
+}
+
+func (s *XPStore) UpsertXP(userID int, source, ref string, points int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return errForeignKey("xp_events_user_id_fkey")
+	}
+	s.db.xp[xpKey{userID: userID, source: source, ref: ref}] = points
+	return nil
+}
+
+func (s *XPStore) UpsertWorkoutXP(workoutID int, base, perMinute int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.workouts[workoutID]
+	if !ok {
+		return nil
+	}
+	points := base + row.workout.DurationMinutes*perMinute
+	if row.workout.Flagged {
+		points = 0
+	}
+	s.db.xp[xpKey{userID: row.workout.UserID, source: store.XPSourceWorkout, ref: strconv.Itoa(workoutID)}] = points
+	return nil
+}
+
+func (s *XPStore) DeleteXP(userID int, source, ref string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.xp, xpKey{userID: userID, source: source, ref: ref})
+	return nil
+}
+
+// * xpTotals --> xpTotalsQuery, users with no ledger rows and deleted users are left out, caller holds mu
+func (db *DB) xpTotals(decay store.XPDecay) map[int]*store.XPTotal {
+	totals := map[int]*store.XPTotal{}
+	for key, points := range db.xp {
+		row := db.liveUser(key.userID)
+		if row == nil {
+			continue
+		}
+		total := totals[key.userID]
+		if total == nil {
+			total = &store.XPTotal{UserID: key.userID, Username: row.user.Username}
+			totals[key.userID] = total
+		}
+		total.Earned += points
+	}
+
+	now := db.now()
+	for _, w := range db.workouts {
+		total := totals[w.workout.UserID]
+		if total != nil && (total.LastActiveAt == nil || w.workout.CreatedAt.After(*total.LastActiveAt)) {
+			at := w.workout.CreatedAt
+			total.LastActiveAt = &at
+		}
+	}
+	for _, total := range totals {
+		weeks := 0.0
+		if total.LastActiveAt != nil {
+			weeks = math.Max(0, math.Floor((now.Sub(*total.LastActiveAt)-decay.Grace).Seconds()/(7*24*time.Hour).Seconds()))
+		}
+		total.XP = int(math.Floor(float64(total.Earned) * math.Pow(1-decay.Percent/100, weeks)))
+	}
+	return totals
+}
+
+func (s *XPStore) GetXP(userID int, decay store.XPDecay) (*store.XPTotal, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	total, ok := s.db.xpTotals(decay)[userID]
+	if !ok {
+		return &store.XPTotal{UserID: userID}, nil
+	}
+	return total, nil
+}
+
+func (s *XPStore) TopXP(decay store.XPDecay, limit int) ([]*store.XPTotal, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	totals := []*store.XPTotal{}
+	for userID, total := range s.db.xpTotals(decay) {
+		if profile, ok := s.db.profiles[userID]; ok && profile.LeaderboardOptOut {
+			continue
+		}
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].XP != totals[j].XP {
+			return totals[i].XP > totals[j].XP
+		}
+		return totals[i].UserID < totals[j].UserID
+	})
+	return page(totals, 0, limit), nil
+}
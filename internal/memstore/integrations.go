@@ -0,0 +1,147 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+// ! IntegrationStore --> store.IntegrationStore on a DB
+type IntegrationStore struct {
+	db *DB
+}
+
+func NewIntegrationStore(db *DB) *IntegrationStore {
+	return &IntegrationStore{db: db}
+}
+
+func copyConnection(c store.IntegrationConnection) *store.IntegrationConnection {
+	if c.SyncedUntil != nil {
+		until := *c.SyncedUntil
+		c.SyncedUntil = &until
+	}
+	if c.LastSyncedAt != nil {
+		at := *c.LastSyncedAt
+		c.LastSyncedAt = &at
+	}
+	return &c
+}
+
+// * findConnection --> caller holds mu
+func (db *DB) findConnection(userID int, provider string) *store.IntegrationConnection {
+	for _, c := range db.connections {
+		if c.UserID == userID && c.Provider == provider {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *IntegrationStore) UpsertConnection(c *store.IntegrationConnection) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[c.UserID]; !ok {
+		return errForeignKey("integration_connections_user_id_fkey")
+	}
+	stored := s.db.findConnection(c.UserID, c.Provider)
+	if stored == nil {
+		stored = &store.IntegrationConnection{ID: s.db.nextID("integration_connections"), UserID: c.UserID, Provider: c.Provider, CreatedAt: s.db.now()}
+		s.db.connections[stored.ID] = stored
+	}
+	stored.ExternalUserID, stored.AccessToken, stored.RefreshToken = c.ExternalUserID, c.AccessToken, c.RefreshToken
+	stored.TokenExpiresAt, stored.Scope, stored.LastSyncError = c.TokenExpiresAt, c.Scope, ""
+	*c = *copyConnection(*stored)
+	return nil
+}
+
+func (s *IntegrationStore) GetConnection(userID int, provider string) (*store.IntegrationConnection, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	c := s.db.findConnection(userID, provider)
+	if c == nil {
+		return nil, nil
+	}
+	return copyConnection(*c), nil
+}
+
+func (s *IntegrationStore) GetConnectionByID(id int64) (*store.IntegrationConnection, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	c, ok := s.db.connections[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyConnection(*c), nil
+}
+
+func (s *IntegrationStore) ListConnections(userID int) ([]*store.IntegrationConnection, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.IntegrationConnection{}
+	for _, c := range s.db.connections {
+		if c.UserID == userID {
+			list = append(list, copyConnection(*c))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Provider < list[j].Provider })
+	return list, nil
+}
+
+func (s *IntegrationStore) ListConnectionIDs(provider string) ([]int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	ids := []int64{}
+	for id, c := range s.db.connections {
+		if c.Provider == provider {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *IntegrationStore) DeleteConnection(userID int, provider string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	c := s.db.findConnection(userID, provider)
+	if c == nil {
+		return store.ErrNotFound
+	}
+	delete(s.db.connections, c.ID)
+	return nil
+}
+
+func (s *IntegrationStore) UpdateConnectionTokens(id int64, accessToken, refreshToken string, expiresAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if c, ok := s.db.connections[id]; ok {
+		c.AccessToken, c.RefreshToken, c.TokenExpiresAt = accessToken, refreshToken, expiresAt
+	}
+	return nil
+}
+
+// ! RecordSync --> GREATEST skips NULLs, so a nil cursor on either side keeps the other
+func (s *IntegrationStore) RecordSync(id int64, syncedUntil *time.Time, syncErr string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	c, ok := s.db.connections[id]
+	if !ok {
+		return nil
+	}
+	if syncedUntil != nil && (c.SyncedUntil == nil || syncedUntil.After(*c.SyncedUntil)) {
+		until := *syncedUntil
+		c.SyncedUntil = &until
+	}
+	now := s.db.now()
+	c.LastSyncedAt = &now
+	c.LastSyncError = syncErr
+	return nil
+}
@@ -0,0 +1,240 @@
+package memstore
+
+import (
+	"encoding/json"
+	"fem/internal/store"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type jobRow struct {
+	job      store.Job
+	lockedAt *time.Time
+}
+
+// * copyJob --> payload and last_error are copied so handlers can't edit the queue
+func copyJob(j store.Job) *store.Job {
+	j.Payload = append(json.RawMessage(nil), j.Payload...)
+	if j.LastError != nil {
+		lastError := *j.LastError
+		j.LastError = &lastError
+	}
+	return &j
+}
+
+// ! JobStore --> store.JobStore on a DB, one mutex stands in for FOR UPDATE SKIP LOCKED
+type JobStore struct {
+	db *DB
+}
+
+func NewJobStore(db *DB) *JobStore {
+	return &JobStore{db: db}
+}
+
+func (s *JobStore) EnqueueJob(job *store.Job) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if len(job.Payload) == 0 {
+		job.Payload = json.RawMessage(`{}`)
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = 5
+	}
+	now := s.db.now()
+	job.ID = s.db.nextID("jobs")
+	job.Status = store.JobQueued
+	job.Attempts = 0
+	job.LastError = nil
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	job.CreatedAt = now
+	s.db.jobs[job.ID] = &jobRow{job: *copyJob(*job)}
+	return nil
+}
+
+func (s *JobStore) ClaimJob() (*store.Job, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := s.db.now()
+	var next *jobRow
+	for _, row := range s.db.jobs {
+		if row.job.Status != store.JobQueued || row.job.RunAt.After(now) {
+			continue
+		}
+		if next == nil || row.job.RunAt.Before(next.job.RunAt) || row.job.RunAt.Equal(next.job.RunAt) && row.job.ID < next.job.ID {
+			next = row
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.job.Status = store.JobRunning
+	next.job.Attempts++
+	next.lockedAt = &now
+	return copyJob(next.job), nil
+}
+
+// * setJob --> the UPDATE ... WHERE id = $1 the other methods share, a missing id is a no-op
+func (s *JobStore) setJob(id int64, update func(row *jobRow)) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if row, ok := s.db.jobs[id]; ok {
+		update(row)
+		row.lockedAt = nil
+	}
+	return nil
+}
+
+func (s *JobStore) CompleteJob(id int64) error {
+	return s.setJob(id, func(row *jobRow) {
+		row.job.Status = store.JobDone
+		row.job.LastError = nil
+	})
+}
+
+func (s *JobStore) RetryJob(id int64, runAt time.Time, lastError string) error {
+	return s.setJob(id, func(row *jobRow) {
+		row.job.Status = store.JobQueued
+		row.job.RunAt = runAt
+		row.job.LastError = &lastError
+	})
+}
+
+func (s *JobStore) FailJob(id int64, lastError string) error {
+	return s.setJob(id, func(row *jobRow) {
+		row.job.Status = store.JobFailed
+		row.job.LastError = &lastError
+	})
+}
+
+func (s *JobStore) RequeueStaleJobs(lockedBefore time.Time) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var requeued int64
+	lastError := "worker lease expired"
+	for _, row := range s.db.jobs {
+		if row.job.Status != store.JobRunning || row.lockedAt == nil || !row.lockedAt.Before(lockedBefore) {
+			continue
+		}
+		row.job.Status = store.JobQueued
+		if row.job.Attempts >= row.job.MaxAttempts {
+			row.job.Status = store.JobFailed
+		}
+		row.job.LastError = &lastError
+		row.lockedAt = nil
+		requeued++
+	}
+	return requeued, nil
+}
+
+// ! AdminStore --> store.AdminStore on a DB
+type AdminStore struct {
+	db *DB
+}
+
+func NewAdminStore(db *DB) *AdminStore {
+	return &AdminStore{db: db}
+}
+
+// * adminUser --> caller holds mu
+func (db *DB) adminUser(row *userRow) *store.AdminUser {
+	user := &store.AdminUser{
+		ID:        row.user.ID,
+		Username:  row.user.Username,
+		Email:     row.user.Email,
+		IsAdmin:   row.user.IsAdmin,
+		CreatedAt: row.user.CreatedAt,
+		DeletedAt: row.deletedAt,
+	}
+	now := time.Now()
+	for _, t := range db.tokens {
+		if t.userID == user.ID && t.expiry.After(now) {
+			user.ActiveTokens++
+		}
+	}
+	for _, w := range db.workouts {
+		if w.workout.UserID == user.ID {
+			user.Workouts++
+		}
+	}
+	return user
+}
+
+func (s *AdminStore) SearchUsers(query string, limit int) ([]*store.AdminUser, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	prefix := strings.ToLower(query)
+	users := []*store.AdminUser{}
+	for id, row := range s.db.users {
+		if strings.HasPrefix(strings.ToLower(row.user.Username), prefix) || strings.HasPrefix(strings.ToLower(row.user.Email), prefix) ||
+			strconv.Itoa(id) == query {
+			users = append(users, s.db.adminUser(row))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return page(users, 0, limit), nil
+}
+
+func (s *AdminStore) GetAdminUser(id int64) (*store.AdminUser, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.users[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.adminUser(row), nil
+}
+
+func (s *AdminStore) CountJobs() ([]*store.JobCount, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	byKey := map[[2]string]*store.JobCount{}
+	counts := []*store.JobCount{}
+	for _, row := range s.db.jobs {
+		if row.job.Status == store.JobDone {
+			continue
+		}
+		key := [2]string{row.job.Type, row.job.Status}
+		if byKey[key] == nil {
+			byKey[key] = &store.JobCount{Type: row.job.Type, Status: row.job.Status}
+			counts = append(counts, byKey[key])
+		}
+		byKey[key].Count++
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Type != counts[j].Type {
+			return counts[i].Type < counts[j].Type
+		}
+		return counts[i].Status < counts[j].Status
+	})
+	return counts, nil
+}
+
+func (s *AdminStore) ListJobs(filter store.JobFilter) ([]*store.Job, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	jobs := []*store.Job{}
+	for _, row := range s.db.jobs {
+		if (filter.Status == "" || row.job.Status == filter.Status) && (filter.Type == "" || row.job.Type == filter.Type) {
+			jobs = append(jobs, copyJob(row.job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+	return page(jobs, 0, filter.Limit), nil
+}
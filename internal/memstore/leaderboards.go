@@ -0,0 +1,122 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+// * leaderboardRow --> one leaderboard_stats row, the snapshot only changes on RefreshLeaderboards
+type leaderboardRow struct {
+	userID         int
+	weeklyMinutes  int
+	weeklyWorkouts int
+	streakDays     int
+	refreshedAt    time.Time
+}
+
+// * value --> the row's number for a metric, see store.leaderboardColumns
+func (r *leaderboardRow) value(metric string) int {
+	switch metric {
+	case store.LeaderboardWeeklyMinutes:
+		return r.weeklyMinutes
+	case store.LeaderboardWeeklyWorkouts:
+		return r.weeklyWorkouts
+	default:
+		return r.streakDays
+	}
+}
+
+// ! LeaderboardStore --> store.LeaderboardStore on a DB
+type LeaderboardStore struct {
+	db *DB
+}
+
+func NewLeaderboardStore(db *DB) *LeaderboardStore {
+	return &LeaderboardStore{db: db}
+}
+
+// ! RefreshLeaderboards --> same numbers as the leaderboard_stats view, weeks start Monday at local midnight
+func (s *LeaderboardStore) RefreshLeaderboards() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := s.db.now()
+	rows := map[int]*leaderboardRow{}
+	days := map[int]map[time.Time]bool{}
+	for id, user := range s.db.users {
+		if user.deletedAt == nil {
+			rows[id] = &leaderboardRow{userID: id, refreshedAt: now}
+			days[id] = map[time.Time]bool{}
+		}
+	}
+
+	for _, w := range s.db.workouts {
+		row, ok := rows[w.workout.UserID]
+		if !ok || w.workout.Flagged {
+			continue
+		}
+		local := w.workout.PerformedAt.In(s.db.location(row.userID))
+		if !local.Before(weekStart(now.In(local.Location()))) {
+			row.weeklyMinutes += w.workout.DurationMinutes
+			row.weeklyWorkouts++
+		}
+		if !w.workout.PerformedAt.After(now) {
+			days[row.userID][time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)] = true
+		}
+	}
+
+	for id, row := range rows {
+		today := now.In(s.db.location(id))
+		day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+		if !days[id][day] {
+			day = day.AddDate(0, 0, -1) //* a run that ended yesterday is still alive today
+		}
+		for days[id][day] {
+			row.streakDays++
+			day = day.AddDate(0, 0, -1)
+		}
+	}
+	s.db.leaderboard = rows
+	return nil
+}
+
+// * weekStart --> Monday 00:00 of t's week in t's location, DATE_TRUNC('week', ...)
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+func (s *LeaderboardStore) TopLeaderboard(metric string, scope store.LeaderboardScope, limit int) ([]*store.LeaderboardEntry, error) {
+	if !store.ValidLeaderboardMetric(metric) {
+		return nil, store.ErrNotFound
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	entries := []*store.LeaderboardEntry{}
+	for id, row := range s.db.leaderboard {
+		user := s.db.liveUser(id)
+		if user == nil || row.value(metric) == 0 {
+			continue
+		}
+		if profile, ok := s.db.profiles[id]; ok && profile.LeaderboardOptOut {
+			continue
+		}
+		if scope.FriendsOf != 0 && id != scope.FriendsOf {
+			if _, ok := s.db.follows[followKey{followerID: scope.FriendsOf, followeeID: id}]; !ok {
+				continue
+			}
+		}
+		entries = append(entries, &store.LeaderboardEntry{
+			UserID: id, Username: user.user.Username, Value: row.value(metric), RefreshedAt: row.refreshedAt,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	return page(entries, 0, limit), nil
+}
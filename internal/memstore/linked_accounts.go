@@ -0,0 +1,122 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"strings"
+)
+
+// ! LinkedAccountStore --> store.LinkedAccountStore on a DB
+type LinkedAccountStore struct {
+	db *DB
+}
+
+func NewLinkedAccountStore(db *DB) *LinkedAccountStore {
+	return &LinkedAccountStore{db: db}
+}
+
+// * copyLinkedAccount --> the caller gets its own last_login_at
+func copyLinkedAccount(a store.LinkedAccount) *store.LinkedAccount {
+	if a.LastLoginAt != nil {
+		at := *a.LastLoginAt
+		a.LastLoginAt = &at
+	}
+	return &a
+}
+
+func (s *LinkedAccountStore) GetLinkedAccount(provider, subject string) (*store.LinkedAccount, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, a := range s.db.identities {
+		if a.Provider == provider && a.Subject == subject {
+			return copyLinkedAccount(*a), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *LinkedAccountStore) ListLinkedAccounts(userID int) ([]*store.LinkedAccount, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.LinkedAccount{}
+	for _, a := range s.db.identities {
+		if a.UserID == userID {
+			list = append(list, copyLinkedAccount(*a))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Provider < list[j].Provider })
+	return list, nil
+}
+
+func (s *LinkedAccountStore) CreateLinkedAccount(a *store.LinkedAccount) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[a.UserID]; !ok {
+		return errForeignKey("linked_accounts_user_id_fkey")
+	}
+	for _, other := range s.db.identities {
+		if other.Provider == a.Provider && (other.Subject == a.Subject || other.UserID == a.UserID) {
+			return errUnique("linked_accounts_provider_subject_key")
+		}
+	}
+	now := s.db.now()
+	a.ID = s.db.nextID("linked_accounts")
+	a.CreatedAt = now
+	a.LastLoginAt = &now
+	s.db.identities[a.ID] = copyLinkedAccount(*a)
+	return nil
+}
+
+func (s *LinkedAccountStore) TouchLinkedAccount(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if a, ok := s.db.identities[id]; ok {
+		now := s.db.now()
+		a.LastLoginAt = &now
+	}
+	return nil
+}
+
+func (s *LinkedAccountStore) DeleteLinkedAccount(userID int, provider string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var deleted *store.LinkedAccount
+	for id, a := range s.db.identities {
+		if a.UserID == userID && a.Provider == provider {
+			deleted = a
+			delete(s.db.identities, id)
+		}
+	}
+	if deleted == nil {
+		return store.ErrNotFound
+	}
+	if deleted.SignedUp {
+		var first *store.LinkedAccount
+		for _, a := range s.db.identities {
+			if a.UserID == userID && (first == nil || a.ID < first.ID) {
+				first = a
+			}
+		}
+		if first != nil {
+			first.SignedUp = true
+		}
+	}
+	return nil
+}
+
+func (s *LinkedAccountStore) UserIDByEmail(email string) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, row := range s.db.users {
+		if row.deletedAt == nil && strings.EqualFold(row.user.Email, email) {
+			return id, nil
+		}
+	}
+	return 0, nil
+}
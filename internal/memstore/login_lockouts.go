@@ -0,0 +1,112 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+type loginLockoutKey struct {
+	kind string
+	key  string
+}
+
+// ! LoginLockoutStore --> store.LoginLockoutStore on a DB
+type LoginLockoutStore struct {
+	db *DB
+}
+
+func NewLoginLockoutStore(db *DB) *LoginLockoutStore {
+	return &LoginLockoutStore{db: db}
+}
+
+// * copyLoginLockout --> the caller gets its own locked_until
+func copyLoginLockout(l store.LoginLockout) *store.LoginLockout {
+	if l.LockedUntil != nil {
+		until := *l.LockedUntil
+		l.LockedUntil = &until
+	}
+	return &l
+}
+
+func (s *LoginLockoutStore) GetLoginLockout(kind, key string) (*store.LoginLockout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	l, ok := s.db.lockouts[loginLockoutKey{kind: kind, key: key}]
+	if !ok {
+		return nil, nil
+	}
+	return copyLoginLockout(*l), nil
+}
+
+func (s *LoginLockoutStore) RecordLoginFailure(kind, key string, at time.Time, window time.Duration) (*store.LoginLockout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	k := loginLockoutKey{kind: kind, key: key}
+	l, ok := s.db.lockouts[k]
+	if !ok {
+		l = &store.LoginLockout{Kind: kind, Key: key}
+		s.db.lockouts[k] = l
+	}
+	last := l.LastFailedAt
+	if l.LockedUntil != nil && l.LockedUntil.After(last) {
+		last = *l.LockedUntil
+	}
+	if last.Before(at.Add(-window)) {
+		l.Failures = 0
+		l.Lockouts = 0
+	}
+	l.Failures++
+	l.LastFailedAt = at
+	return copyLoginLockout(*l), nil
+}
+
+func (s *LoginLockoutStore) LockLogin(kind, key string, until time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	l, ok := s.db.lockouts[loginLockoutKey{kind: kind, key: key}]
+	if !ok {
+		return nil
+	}
+	l.LockedUntil = &until
+	l.Failures = 0
+	l.Lockouts++
+	return nil
+}
+
+func (s *LoginLockoutStore) ClearLoginLockout(kind, key string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	k := loginLockoutKey{kind: kind, key: key}
+	if _, ok := s.db.lockouts[k]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.lockouts, k)
+	return nil
+}
+
+func (s *LoginLockoutStore) ListLoginLockouts(now time.Time) ([]*store.LoginLockout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.LoginLockout{}
+	for _, l := range s.db.lockouts {
+		if l.Locked(now) {
+			list = append(list, copyLoginLockout(*l))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].LockedUntil.Equal(*list[j].LockedUntil) {
+			return list[i].LockedUntil.Before(*list[j].LockedUntil)
+		}
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list, nil
+}
@@ -0,0 +1,343 @@
+package memstore
+
+import (
+	"crypto/sha256"
+	"fem/internal/store"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemStores(t *testing.T) {
+	db := New()
+	users := NewUserStore(db)
+	tokenStore := NewTokenStore(db)
+	workouts := NewWorkoutStore(db)
+	follows := NewFollowStore(db)
+	comments := NewCommentStore(db)
+
+	// * user + token round trip
+	ana := &store.User{Username: "ana", Email: "ana@example.com", IsAdmin: true}
+	require.NoError(t, ana.PasswordHash.Set("correct horse"))
+	require.NoError(t, users.CreateUser(ana))
+	assert.NotZero(t, ana.ID)
+	assert.False(t, ana.IsAdmin)
+	assert.Error(t, users.CreateUser(&store.User{Username: "ana", Email: "other@example.com"}))
+
+	token, err := tokenStore.CreateNewToken(ana.ID, time.Hour, "authentication")
+	require.NoError(t, err)
+	authed, err := users.GetUserToken("authentication", token.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, authed)
+	assert.Equal(t, ana.ID, authed.ID)
+
+	// * workout create / read / update / delete
+	created, err := workouts.CreateWorkout(&store.Workout{
+		UserID:          ana.ID,
+		Title:           "push day",
+		DurationMinutes: 60,
+		Entries:         []store.WorkoutEntry{{ExerciseName: "bench press", Sets: 4, Reps: intPtr(10), OrderIndex: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, store.VisibilityPrivate, created.Visibility)
+	retrieved, err := workouts.GetWorkoutByID(int64(created.ID))
+	require.NoError(t, err)
+	assert.Equal(t, created.Entries, retrieved.Entries)
+
+	retrieved.Title = "pull day"
+	require.NoError(t, workouts.UpdateWorkout(retrieved))
+	updated, err := workouts.GetWorkoutByID(int64(created.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "pull day", updated.Title)
+
+	require.NoError(t, workouts.DeleteWorkout(int64(created.ID)))
+	assert.ErrorIs(t, workouts.DeleteWorkout(int64(created.ID)), store.ErrNotFound)
+	missing, err := workouts.GetWorkoutByID(int64(created.ID))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	assert.Nil(t, missing)
+
+	// * follow -> fan out -> feed, private workouts stay out
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ben))
+	assert.Error(t, follows.Follow(int64(ben.ID), int64(ben.ID)))
+	require.NoError(t, follows.Follow(int64(ben.ID), int64(ana.ID)))
+
+	public, err := workouts.CreateWorkout(&store.Workout{UserID: ana.ID, Title: "run", DurationMinutes: 30, Visibility: store.VisibilityPublic})
+	require.NoError(t, err)
+	_, err = workouts.CreateWorkout(&store.Workout{UserID: ana.ID, Title: "secret", DurationMinutes: 30})
+	require.NoError(t, err)
+	_, err = follows.FanOutWorkout(int64(public.ID))
+	require.NoError(t, err)
+
+	feed, err := follows.GetFeed(int64(ben.ID), nil, 10)
+	require.NoError(t, err)
+	require.Len(t, feed, 1)
+	assert.Equal(t, public.ID, feed[0].WorkoutID)
+
+	// * account deletion signs out and anonymizes comments
+	comment := &store.Comment{WorkoutID: public.ID, UserID: ben.ID, Body: "nice"}
+	require.NoError(t, comments.CreateComment(comment))
+	_, err = users.DeleteAccount(int64(ben.ID))
+	require.NoError(t, err)
+
+	kept, err := comments.GetComment(int64(comment.ID))
+	require.NoError(t, err)
+	require.NotNil(t, kept)
+	assert.Equal(t, "[deleted]", kept.Username)
+	following, err := follows.IsFollowing(int64(ben.ID), int64(ana.ID))
+	require.NoError(t, err)
+	assert.False(t, following)
+}
+
+func TestSeed(t *testing.T) {
+	db := New()
+	usernames, err := Seed(db)
+	require.NoError(t, err)
+	users := NewUserStore(db)
+
+	for _, username := range usernames {
+		user, err := users.GetUserByUsername(username)
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		ok, err := user.PasswordHash.Matches(DemoPassword)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	demo, err := users.GetUserByUsername("demo")
+	require.NoError(t, err)
+	assert.True(t, demo.IsAdmin)
+	feed, err := NewFollowStore(db).GetFeed(int64(demo.ID), nil, 50)
+	require.NoError(t, err)
+	assert.NotEmpty(t, feed)
+}
+
+func TestStreakUsesLocalDays(t *testing.T) {
+	db := New()
+	users := NewUserStore(db)
+	workouts := NewWorkoutStore(db)
+	achievements := NewAchievementStore(db)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+
+	// * morning of Mar 1 and evening of Mar 2 in Los Angeles --> Mar 1 and Mar 3 in UTC
+	pst := time.FixedZone("PST", -8*60*60)
+	for _, at := range []time.Time{time.Date(2025, 3, 1, 10, 0, 0, 0, pst), time.Date(2025, 3, 2, 20, 0, 0, 0, pst)} {
+		_, err := workouts.CreateWorkout(&store.Workout{UserID: ana.ID, Title: "run", DurationMinutes: 30, PerformedAt: at})
+		require.NoError(t, err)
+	}
+
+	stats, err := achievements.GetAchievementStats(ana.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.LongestStreakDays) //* no profile --> UTC days, the streak breaks
+
+	require.NoError(t, NewProfileStore(db).UpsertProfile(&store.Profile{UserID: ana.ID, Units: store.UnitsMetric, Timezone: "America/Los_Angeles"}))
+	stats, err = achievements.GetAchievementStats(ana.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.LongestStreakDays)
+}
+
+func TestLeaderboards(t *testing.T) {
+	db := New()
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC) //* a Wednesday
+	db.now = func() time.Time { return now }
+	users := NewUserStore(db)
+	workouts := NewWorkoutStore(db)
+	leaderboards := NewLeaderboardStore(db)
+
+	ids := map[string]int{}
+	for _, name := range []string{"ana", "bob", "cid"} {
+		user := &store.User{Username: name, Email: name + "@example.com"}
+		require.NoError(t, users.CreateUser(user))
+		ids[name] = user.ID
+	}
+	log := func(name string, day, minutes int) {
+		_, err := workouts.CreateWorkout(&store.Workout{UserID: ids[name], Title: "run", DurationMinutes: minutes, PerformedAt: time.Date(2025, 3, day, 9, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+	}
+	log("ana", 3, 30)
+	log("ana", 4, 45)
+	log("ana", 5, 20)
+	log("bob", 1, 90) //* last week's Saturday, counts for the streak only
+	log("bob", 4, 60)
+	log("cid", 5, 200)
+	require.NoError(t, NewProfileStore(db).UpsertProfile(&store.Profile{UserID: ids["cid"], Units: store.UnitsMetric, LeaderboardOptOut: true}))
+	require.NoError(t, NewFollowStore(db).Follow(int64(ids["ana"]), int64(ids["bob"])))
+
+	entries, err := leaderboards.TopLeaderboard(store.LeaderboardWeeklyMinutes, store.LeaderboardScope{}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries) //* nothing until the first refresh
+
+	require.NoError(t, leaderboards.RefreshLeaderboards())
+	values := func(metric string, scope store.LeaderboardScope) map[string]int {
+		entries, err := leaderboards.TopLeaderboard(metric, scope, 10)
+		require.NoError(t, err)
+		got := map[string]int{}
+		for _, entry := range entries {
+			got[entry.Username] = entry.Value
+		}
+		return got
+	}
+	assert.Equal(t, map[string]int{"ana": 95, "bob": 60}, values(store.LeaderboardWeeklyMinutes, store.LeaderboardScope{}))
+	assert.Equal(t, map[string]int{"ana": 3, "bob": 1}, values(store.LeaderboardWeeklyWorkouts, store.LeaderboardScope{}))
+	assert.Equal(t, map[string]int{"ana": 3, "bob": 1}, values(store.LeaderboardStreak, store.LeaderboardScope{}))
+	assert.Equal(t, map[string]int{"bob": 60}, values(store.LeaderboardWeeklyMinutes, store.LeaderboardScope{FriendsOf: ids["bob"]}))
+	assert.Equal(t, map[string]int{"ana": 95, "bob": 60}, values(store.LeaderboardWeeklyMinutes, store.LeaderboardScope{FriendsOf: ids["ana"]}))
+
+	_, err = leaderboards.TopLeaderboard("calories", store.LeaderboardScope{}, 10)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestChallenges(t *testing.T) {
+	db := New()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	db.now = func() time.Time { return now }
+	users := NewUserStore(db)
+	workouts := NewWorkoutStore(db)
+	challenges := NewChallengeStore(db)
+
+	ids := map[string]int{}
+	for _, name := range []string{"ana", "bob", "cid"} {
+		user := &store.User{Username: name, Email: name + "@example.com"}
+		require.NoError(t, users.CreateUser(user))
+		ids[name] = user.ID
+	}
+	creator := ids["ana"]
+	challenge := &store.Challenge{Title: "March minutes", Metric: store.EventMetricDurationMinutes, StartsAt: now.AddDate(0, 0, -1), EndsAt: now.AddDate(0, 0, 7), CreatedBy: &creator}
+	require.NoError(t, challenges.CreateChallenge(challenge))
+	assert.Equal(t, store.EventStatusActive, challenge.Status)
+	assert.Equal(t, 1, challenge.Participants) //* the creator joins with it
+
+	require.NoError(t, challenges.JoinChallenge(int64(challenge.ID), ids["bob"]))
+	require.NoError(t, challenges.JoinChallenge(int64(challenge.ID), ids["bob"])) //* twice is fine
+	require.NoError(t, challenges.JoinChallenge(int64(challenge.ID), ids["cid"]))
+	require.NoError(t, challenges.LeaveChallenge(int64(challenge.ID), ids["cid"]))
+	assert.ErrorIs(t, challenges.LeaveChallenge(int64(challenge.ID), ids["cid"]), store.ErrNotFound)
+
+	log := func(name string, performedAt time.Time, minutes int) {
+		_, err := workouts.CreateWorkout(&store.Workout{UserID: ids[name], Title: "run", DurationMinutes: minutes, PerformedAt: performedAt})
+		require.NoError(t, err)
+	}
+	log("ana", now, 30)
+	log("ana", now.AddDate(0, 0, -3), 500) //* before the window
+	log("bob", now, 45)
+	log("cid", now, 90) //* left, doesn't count
+
+	got, err := challenges.GetChallenge(int64(challenge.ID))
+	require.NoError(t, err)
+	standings, err := challenges.GetChallengeStandings(got)
+	require.NoError(t, err)
+	require.Len(t, standings, 2)
+	assert.Equal(t, "bob", standings[0].Username)
+	assert.Equal(t, 45.0, standings[0].Score)
+	assert.Equal(t, 2, standings[1].Rank)
+
+	due, err := challenges.ListChallengesToClose(now)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	now = now.AddDate(0, 0, 8)
+	due, err = challenges.ListChallengesToClose(now)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, store.EventStatusEnded, due[0].Status)
+	assert.ErrorIs(t, challenges.JoinChallenge(int64(challenge.ID), ids["cid"]), store.ErrNotFound)
+
+	results, err := challenges.CloseChallenge(int64(challenge.ID))
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	results, err = challenges.CloseChallenge(int64(challenge.ID))
+	require.NoError(t, err)
+	assert.Nil(t, results) //* already closed
+
+	got, err = challenges.GetChallenge(int64(challenge.ID))
+	require.NoError(t, err)
+	assert.Equal(t, store.EventStatusClosed, got.Status)
+	frozen, err := challenges.GetChallengeStandings(got)
+	require.NoError(t, err)
+	require.Len(t, frozen, 2)
+	assert.Equal(t, ids["bob"], frozen[0].UserID)
+	assert.Equal(t, "bob", frozen[0].Username)
+
+	list, err := challenges.ListChallenges(ids["bob"], 10)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	list, err = challenges.ListChallenges(ids["cid"], 10)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestOrgSCIM(t *testing.T) {
+	db := New()
+	users := NewUserStore(db)
+	orgs := NewOrgStore(db)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	acme := &store.Org{Name: "acme"}
+	globex := &store.Org{Name: "globex"}
+	require.NoError(t, orgs.CreateOrg(acme, ana.ID))
+	require.NoError(t, orgs.CreateOrg(globex, ben.ID))
+
+	// * a token resolves only the org it was set on
+	hash := sha256.Sum256([]byte("acme-token"))
+	require.NoError(t, orgs.SetSCIMTokenHash(int64(acme.ID), hash[:]))
+	org, err := orgs.GetOrgBySCIMToken("acme-token")
+	require.NoError(t, err)
+	require.NotNil(t, org)
+	assert.Equal(t, acme.ID, org.ID)
+	org, err = orgs.GetOrgBySCIMToken("globex-token")
+	require.NoError(t, err)
+	assert.Nil(t, org)
+
+	// * provisioning is remembered for acme alone, and outlives the membership
+	member := &store.OrgMember{OrgID: acme.ID, User: &store.User{Username: "cleo", Email: "cleo@example.com"}, Active: true}
+	require.NoError(t, orgs.ProvisionUser(member))
+	provisioned, err := orgs.IsProvisioned(int64(acme.ID), member.User.ID)
+	require.NoError(t, err)
+	assert.True(t, provisioned)
+	provisioned, err = orgs.IsProvisioned(int64(globex.ID), member.User.ID)
+	require.NoError(t, err)
+	assert.False(t, provisioned)
+	provisioned, err = orgs.IsProvisioned(int64(acme.ID), ben.ID)
+	require.NoError(t, err)
+	assert.False(t, provisioned)
+
+	require.NoError(t, orgs.RemoveMember(int64(acme.ID), member.User.ID))
+	provisioned, err = orgs.IsProvisioned(int64(acme.ID), member.User.ID)
+	require.NoError(t, err)
+	assert.True(t, provisioned)
+
+	// * a taken email leaves no half-provisioned membership behind
+	assert.ErrorIs(t, orgs.ProvisionUser(&store.OrgMember{OrgID: globex.ID, User: &store.User{Username: "dora", Email: "ana@example.com"}}), store.ErrConflict)
+	_, total, err := orgs.ListMembers(int64(globex.ID), "", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "only the owner")
+}
+
+func TestDeleteAccountStopsReminders(t *testing.T) {
+	db := New()
+	users := NewUserStore(db)
+	reminders := NewReminderStore(db)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	reminder := &store.Reminder{UserID: ana.ID, Name: "Train", RemindAt: "18:00", Timezone: "UTC", Days: store.ReminderEveryDay, Enabled: true}
+	require.NoError(t, reminders.CreateReminder(reminder))
+
+	_, err := users.DeleteAccount(int64(ana.ID))
+	require.NoError(t, err)
+	stored, err := reminders.GetReminder(reminder.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Enabled)
+
+	require.NoError(t, reminders.UpdateReminder(reminder)) //* re-enabled by a request still in flight
+	enabled, err := reminders.ListEnabledReminders()
+	require.NoError(t, err)
+	assert.Empty(t, enabled, "a deleted account's reminders never come due")
+}
@@ -0,0 +1,189 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"maps"
+	"sort"
+)
+
+// ! NotificationStore --> store.NotificationStore on a DB
+type NotificationStore struct {
+	db *DB
+}
+
+func NewNotificationStore(db *DB) *NotificationStore {
+	return &NotificationStore{db: db}
+}
+
+func (s *NotificationStore) RegisterDevice(d *store.Device) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if d.Platform != store.DevicePlatformFCM && d.Platform != store.DevicePlatformAPNs {
+		return errCheck("devices_platform_check")
+	}
+	if _, ok := s.db.users[d.UserID]; !ok {
+		return errForeignKey("devices_user_id_fkey")
+	}
+	now := s.db.now()
+	for _, stored := range s.db.devices {
+		if stored.Token == d.Token {
+			stored.UserID, stored.Platform, stored.Name, stored.LastSeenAt = d.UserID, d.Platform, d.Name, now
+			*d = *stored
+			return nil
+		}
+	}
+	stored := *d
+	stored.ID = s.db.nextID("devices")
+	stored.CreatedAt, stored.LastSeenAt = now, now
+	s.db.devices[stored.ID] = &stored
+	*d = stored
+	return nil
+}
+
+func (s *NotificationStore) ListDevices(userID int) ([]*store.Device, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	devices := []*store.Device{}
+	for _, d := range s.db.devices {
+		if d.UserID == userID {
+			device := *d
+			devices = append(devices, &device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices, nil
+}
+
+func (s *NotificationStore) DeleteDevice(id int64, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	d, ok := s.db.devices[id]
+	if !ok || d.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.db.devices, id)
+	return nil
+}
+
+func (s *NotificationStore) DeleteDeviceToken(token string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, d := range s.db.devices {
+		if d.Token == token {
+			delete(s.db.devices, id)
+		}
+	}
+	return nil
+}
+
+func (s *NotificationStore) GetNotificationPreferences(userID int) (*store.NotificationPreferences, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	prefs, ok := s.db.notifyPrefs[userID]
+	if !ok {
+		return store.DefaultNotificationPreferences(), nil
+	}
+	copied := *prefs
+	return &copied, nil
+}
+
+func (s *NotificationStore) UpdateNotificationPreferences(userID int, prefs *store.NotificationPreferences) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return errForeignKey("notification_preferences_user_id_fkey")
+	}
+	stored := *prefs
+	s.db.notifyPrefs[userID] = &stored
+	return nil
+}
+
+// * copyNotification --> Data + ReadAt are shared otherwise, the caller gets its own
+func copyNotification(n store.Notification) *store.Notification {
+	n.Data = maps.Clone(n.Data)
+	if n.ReadAt != nil {
+		at := *n.ReadAt
+		n.ReadAt = &at
+	}
+	return &n
+}
+
+func (s *NotificationStore) CreateNotification(n *store.Notification) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[n.UserID]; !ok {
+		return errForeignKey("notifications_user_id_fkey")
+	}
+	if n.Data == nil {
+		n.Data = map[string]string{}
+	}
+	n.ID = s.db.nextID("notifications")
+	n.ReadAt = nil
+	n.CreatedAt = s.db.now()
+	s.db.notifications[n.ID] = copyNotification(*n)
+	return nil
+}
+
+func (s *NotificationStore) ListNotifications(userID int, unreadOnly bool, offset, limit int) ([]*store.Notification, int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.Notification{}
+	for _, n := range s.db.notifications {
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			list = append(list, copyNotification(*n))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return page(list, offset, limit), len(list), nil
+}
+
+func (s *NotificationStore) CountUnreadNotifications(userID int) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	count := 0
+	for _, n := range s.db.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *NotificationStore) MarkNotificationRead(id int64, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	n, ok := s.db.notifications[id]
+	if !ok || n.UserID != userID {
+		return store.ErrNotFound
+	}
+	if n.ReadAt == nil {
+		now := s.db.now()
+		n.ReadAt = &now
+	}
+	return nil
+}
+
+func (s *NotificationStore) MarkAllNotificationsRead(userID int) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := s.db.now()
+	var count int64
+	for _, n := range s.db.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &now
+			count++
+		}
+	}
+	return count, nil
+}
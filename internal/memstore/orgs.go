@@ -0,0 +1,425 @@
+package memstore
+
+import (
+	"crypto/sha256"
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+type orgRow struct {
+	org      store.Org
+	scimHash []byte
+}
+
+type memberKey struct {
+	orgID  int
+	userID int
+}
+
+type memberRow struct {
+	role       string
+	active     bool
+	externalID string
+	shareStats bool
+	createdAt  time.Time
+	updatedAt  time.Time
+}
+
+// ! OrgStore --> store.OrgStore on a DB
+type OrgStore struct {
+	db *DB
+}
+
+func NewOrgStore(db *DB) *OrgStore {
+	return &OrgStore{db: db}
+}
+
+func (s *OrgStore) CreateOrg(org *store.Org, ownerID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[ownerID]; !ok {
+		return errForeignKey("org_members_user_id_fkey")
+	}
+	now := s.db.now()
+	org.ID = int(s.db.nextID("orgs"))
+	org.CreatedAt = now
+	s.db.orgs[org.ID] = &orgRow{org: *org}
+	s.db.members[memberKey{orgID: org.ID, userID: ownerID}] = &memberRow{role: store.OrgRoleOwner, active: true, shareStats: true, createdAt: now, updatedAt: now}
+	return nil
+}
+
+func (s *OrgStore) GetOrgByID(id int64) (*store.Org, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.orgs[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	org := row.org
+	return &org, nil
+}
+
+func (s *OrgStore) GetOrgBySCIMToken(plaintext string) (*store.Org, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	hash := sha256.Sum256([]byte(plaintext))
+	for _, row := range s.db.orgs {
+		if row.scimHash != nil && string(row.scimHash) == string(hash[:]) {
+			org := row.org
+			return &org, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *OrgStore) SetSCIMTokenHash(orgID int64, hash []byte) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.orgs[int(orgID)]
+	if !ok {
+		return store.ErrNotFound
+	}
+	row.scimHash = append([]byte(nil), hash...)
+	return nil
+}
+
+// * memberView --> the memberColumns join, caller holds mu
+func (db *DB) memberView(key memberKey, m *memberRow) *store.OrgMember {
+	user := db.users[key.userID].user
+	return &store.OrgMember{
+		OrgID:      key.orgID,
+		User:       &store.User{ID: user.ID, Username: user.Username, Email: user.Email, Bio: user.Bio, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt},
+		Role:       m.role,
+		Active:     m.active,
+		ExternalID: m.externalID,
+		ShareStats: m.shareStats,
+		CreatedAt:  m.createdAt,
+		UpdatedAt:  m.updatedAt,
+	}
+}
+
+func (s *OrgStore) GetMember(orgID int64, userID int) (*store.OrgMember, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := memberKey{orgID: int(orgID), userID: userID}
+	m, ok := s.db.members[key]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.memberView(key, m), nil
+}
+
+func (s *OrgStore) ListMembers(orgID int64, username string, offset, limit int) ([]*store.OrgMember, int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	members := []*store.OrgMember{}
+	for key, m := range s.db.members {
+		if key.orgID == int(orgID) && (username == "" || s.db.users[key.userID].user.Username == username) {
+			members = append(members, s.db.memberView(key, m))
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].User.ID < members[j].User.ID })
+	return page(members, offset, limit), len(members), nil
+}
+
+func (s *OrgStore) UpsertMember(member *store.OrgMember) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if member.Role == "" {
+		member.Role = store.OrgRoleMember
+	}
+	if _, ok := s.db.orgs[member.OrgID]; !ok {
+		return errForeignKey("org_members_org_id_fkey")
+	}
+	if _, ok := s.db.users[member.User.ID]; !ok {
+		return errForeignKey("org_members_user_id_fkey")
+	}
+	now := s.db.now()
+	key := memberKey{orgID: member.OrgID, userID: member.User.ID}
+	m, ok := s.db.members[key]
+	if !ok {
+		m = &memberRow{shareStats: true, createdAt: now}
+		s.db.members[key] = m
+	}
+	m.role, m.active, m.externalID, m.updatedAt = member.Role, member.Active, member.ExternalID, now
+	member.ShareStats, member.CreatedAt, member.UpdatedAt = m.shareStats, m.createdAt, m.updatedAt
+	return nil
+}
+
+func (s *OrgStore) ProvisionUser(member *store.OrgMember) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if member.Role == "" {
+		member.Role = store.OrgRoleMember
+	}
+	if _, ok := s.db.orgs[member.OrgID]; !ok {
+		return errForeignKey("scim_provisioned_users_org_id_fkey")
+	}
+	if err := s.db.insertUser(member.User); err != nil {
+		return err
+	}
+	now := s.db.now()
+	key := memberKey{orgID: member.OrgID, userID: member.User.ID}
+	s.db.provisioned[key] = now
+	s.db.members[key] = &memberRow{role: member.Role, active: member.Active, externalID: member.ExternalID, shareStats: true, createdAt: now, updatedAt: now}
+	member.ShareStats, member.CreatedAt, member.UpdatedAt = true, now, now
+	return nil
+}
+
+func (s *OrgStore) IsProvisioned(orgID int64, userID int) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	_, ok := s.db.provisioned[memberKey{orgID: int(orgID), userID: userID}]
+	return ok, nil
+}
+
+func (s *OrgStore) RemoveMember(orgID int64, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := memberKey{orgID: int(orgID), userID: userID}
+	if _, ok := s.db.members[key]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.members, key)
+	return nil
+}
+
+func (s *OrgStore) CountActiveOwners(orgID int64) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	owners := 0
+	for key, m := range s.db.members {
+		if key.orgID == int(orgID) && m.role == store.OrgRoleOwner && m.active {
+			owners++
+		}
+	}
+	return owners, nil
+}
+
+// * updateMember --> store.ErrNotFound when the user isn't a member
+func (s *OrgStore) updateMember(orgID int64, userID int, update func(m *memberRow)) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	m, ok := s.db.members[memberKey{orgID: int(orgID), userID: userID}]
+	if !ok {
+		return store.ErrNotFound
+	}
+	update(m)
+	m.updatedAt = s.db.now()
+	return nil
+}
+
+func (s *OrgStore) SetShareStats(orgID int64, userID int, share bool) error {
+	return s.updateMember(orgID, userID, func(m *memberRow) { m.shareStats = share })
+}
+
+func (s *OrgStore) SetMemberRole(orgID int64, userID int, role string) error {
+	return s.updateMember(orgID, userID, func(m *memberRow) { m.role = role })
+}
+
+func (s *OrgStore) IsCoachFor(coachID, athleteID int) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for key, c := range s.db.members {
+		if key.userID != coachID || !c.active || (c.role != store.OrgRoleCoach && c.role != store.OrgRoleOwner) {
+			continue
+		}
+		if a, ok := s.db.members[memberKey{orgID: key.orgID, userID: athleteID}]; ok && a.active {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type activeMember struct {
+	userID     int
+	username   string
+	shareStats bool
+}
+
+// * activeMembers --> the org's active members by user id, caller holds mu
+func (db *DB) activeMembers(orgID int64) []activeMember {
+	members := []activeMember{}
+	for key, m := range db.members {
+		if key.orgID == int(orgID) && m.active {
+			members = append(members, activeMember{userID: key.userID, username: db.users[key.userID].user.Username, shareStats: m.shareStats})
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].userID < members[j].userID })
+	return members
+}
+
+// * inWindow --> [from, to)
+func inWindow(at, from, to time.Time) bool {
+	return !at.Before(from) && at.Before(to)
+}
+
+// * memberWorkouts --> active members' workouts inside [from, to) ordered by id, caller holds mu
+func (db *DB) memberWorkouts(orgID int64, from, to time.Time) ([]activeMember, []*store.Workout) {
+	members := db.activeMembers(orgID)
+	byUser := map[int]bool{}
+	for _, m := range members {
+		byUser[m.userID] = true
+	}
+	workouts := []*store.Workout{}
+	for _, row := range db.workouts {
+		if byUser[row.workout.UserID] && inWindow(row.workout.CreatedAt, from, to) {
+			workouts = append(workouts, &row.workout)
+		}
+	}
+	sort.Slice(workouts, func(i, j int) bool { return workouts[i].ID < workouts[j].ID })
+	return members, workouts
+}
+
+func memberOf(members []activeMember, userID int) activeMember {
+	for _, m := range members {
+		if m.userID == userID {
+			return m
+		}
+	}
+	return activeMember{}
+}
+
+func (s *OrgStore) GetMemberStats(orgID int64, from, to time.Time) ([]*store.MemberStats, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	members, workouts := s.db.memberWorkouts(orgID, from, to)
+	stats := []*store.MemberStats{}
+	byUser := map[int]*store.MemberStats{}
+	for _, m := range members {
+		row := &store.MemberStats{UserID: m.userID, Username: m.username, ShareStats: m.shareStats}
+		byUser[m.userID] = row
+		stats = append(stats, row)
+	}
+	for _, w := range workouts {
+		if w.Flagged {
+			continue
+		}
+		row := byUser[w.UserID]
+		row.Workouts++
+		row.TotalMinutes += w.DurationMinutes
+		row.TotalCalories += w.CaloriesBurned
+		if row.LastWorkoutAt == nil || w.CreatedAt.After(*row.LastWorkoutAt) {
+			at := w.CreatedAt
+			row.LastWorkoutAt = &at
+		}
+	}
+	return stats, nil
+}
+
+func (s *OrgStore) GetAttendance(orgID int64, from, to time.Time) ([]*store.AttendanceDay, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	members, workouts := s.db.memberWorkouts(orgID, from, to)
+	type dayKey struct {
+		userID int
+		day    time.Time
+	}
+	byDay := map[dayKey]*store.AttendanceDay{}
+	days := []*store.AttendanceDay{}
+	for _, w := range workouts {
+		if w.Flagged {
+			continue
+		}
+		key := dayKey{userID: w.UserID, day: w.CreatedAt.UTC().Truncate(24 * time.Hour)}
+		row := byDay[key]
+		if row == nil {
+			m := memberOf(members, w.UserID)
+			row = &store.AttendanceDay{UserID: m.userID, Username: m.username, ShareStats: m.shareStats, Day: key.day}
+			byDay[key] = row
+			days = append(days, row)
+		}
+		row.Workouts++
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if !days[i].Day.Equal(days[j].Day) {
+			return days[i].Day.Before(days[j].Day)
+		}
+		return days[i].UserID < days[j].UserID
+	})
+	return days, nil
+}
+
+func (s *OrgStore) GetMemberWorkouts(orgID int64, from, to time.Time) ([]*store.MemberWorkout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	members, workouts := s.db.memberWorkouts(orgID, from, to)
+	list := []*store.MemberWorkout{}
+	for _, w := range workouts {
+		m := memberOf(members, w.UserID)
+		list = append(list, &store.MemberWorkout{
+			UserID:            m.userID,
+			Username:          m.username,
+			ShareStats:        m.shareStats,
+			WorkoutID:         w.ID,
+			Title:             w.Title,
+			DurationMinutes:   w.DurationMinutes,
+			CaloriesBurned:    w.CaloriesBurned,
+			CaloriesEstimated: w.CaloriesEstimated,
+			CreatedAt:         w.CreatedAt,
+		})
+	}
+	return list, nil
+}
+
+func (s *OrgStore) GetMemberEntries(orgID int64, from, to time.Time) ([]*store.MemberEntry, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	members, workouts := s.db.memberWorkouts(orgID, from, to)
+	list := []*store.MemberEntry{}
+	for _, w := range workouts {
+		m := memberOf(members, w.UserID)
+		entries := append([]store.WorkoutEntry(nil), w.Entries...)
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].OrderIndex < entries[j].OrderIndex })
+		for _, e := range entries {
+			list = append(list, &store.MemberEntry{
+				UserID:          m.userID,
+				Username:        m.username,
+				ShareStats:      m.shareStats,
+				WorkoutID:       w.ID,
+				EntryID:         e.ID,
+				ExerciseName:    e.ExerciseName,
+				Sets:            e.Sets,
+				Reps:            e.Reps,
+				DurationSeconds: e.DurationSeconds,
+				Weight:          e.Weight,
+				OrderIndex:      e.OrderIndex,
+			})
+		}
+	}
+	return list, nil
+}
+
+func (s *OrgStore) GetMemberWeights(orgID int64, from, to time.Time) ([]*store.MemberWeight, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	members := s.db.activeMembers(orgID)
+	list := []*store.MemberWeight{}
+	for _, w := range s.db.weights {
+		m := memberOf(members, w.UserID)
+		if m.userID != 0 && inWindow(w.MeasuredAt, from, to) {
+			list = append(list, &store.MemberWeight{UserID: m.userID, Username: m.username, ShareStats: m.shareStats, WeightKG: w.WeightKG, MeasuredAt: w.MeasuredAt})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].MeasuredAt.Before(list[j].MeasuredAt) })
+	return list, nil
+}
@@ -0,0 +1,84 @@
+package memstore
+
+import (
+	"fem/internal/events"
+	"fem/internal/store"
+	"time"
+)
+
+// * outboxRow --> one events_outbox row, published stays nil until the relay hands it on
+type outboxRow struct {
+	event     store.OutboxEvent
+	published *time.Time
+}
+
+// * enqueueEvent --> what the sql workout stores write in the mutation's transaction, caller holds mu
+func (db *DB) enqueueEvent(eventType string, workoutID, userID int) {
+	db.outbox = append(db.outbox, &outboxRow{event: store.OutboxEvent{
+		ID: db.nextID("events_outbox"), Type: eventType, UserID: userID, WorkoutID: workoutID, CreatedAt: db.now(),
+	}})
+}
+
+// * enqueueUpdated --> workout.updated for a workout that's still there, an update of a missing row matched nothing
+func (db *DB) enqueueUpdated(workoutID int) {
+	if row, ok := db.workouts[workoutID]; ok {
+		db.enqueueEvent(events.WorkoutUpdated, workoutID, row.workout.UserID)
+	}
+}
+
+// ! OutboxStore --> store.OutboxStore on a DB
+type OutboxStore struct {
+	db *DB
+}
+
+func NewOutboxStore(db *DB) *OutboxStore {
+	return &OutboxStore{db: db}
+}
+
+// ! RelayEvents --> publish runs without mu, it may call back into stores built on the same DB
+func (s *OutboxStore) RelayEvents(limit int, publish func([]*store.OutboxEvent) (int, error)) (int, error) {
+	s.db.mu.Lock()
+	rows := []*outboxRow{}
+	pending := []*store.OutboxEvent{}
+	for _, row := range s.db.outbox {
+		if len(pending) == limit {
+			break
+		}
+		if row.published == nil {
+			event := row.event
+			rows = append(rows, row)
+			pending = append(pending, &event)
+		}
+	}
+	s.db.mu.Unlock()
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	delivered, err := publish(pending)
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	now := s.db.now()
+	for _, row := range rows[:delivered] {
+		row.published = &now
+	}
+	return delivered, err
+}
+
+func (s *OutboxStore) PurgePublishedEvents(publishedBefore time.Time) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var purged int64
+	kept := s.db.outbox[:0]
+	for _, row := range s.db.outbox {
+		if row.published != nil && row.published.Before(publishedBefore) {
+			purged++
+			continue
+		}
+		kept = append(kept, row)
+	}
+	s.db.outbox = kept
+	return purged, nil
+}
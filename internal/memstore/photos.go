@@ -0,0 +1,80 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+)
+
+// ! PhotoStore --> store.PhotoStore on a DB
+type PhotoStore struct {
+	db *DB
+}
+
+func NewPhotoStore(db *DB) *PhotoStore {
+	return &PhotoStore{db: db}
+}
+
+func (s *PhotoStore) CreatePhoto(photo *store.WorkoutPhoto) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[photo.WorkoutID]; !ok {
+		return errForeignKey("workout_photos_workout_id_fkey")
+	}
+	photo.ID = s.db.nextID("workout_photos")
+	photo.CreatedAt = s.db.now()
+	p := *photo
+	s.db.photos[p.ID] = &p
+	return nil
+}
+
+func (s *PhotoStore) ListPhotos(workoutID int64) ([]*store.WorkoutPhoto, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	photos := []*store.WorkoutPhoto{}
+	for _, photo := range s.db.photos {
+		if int64(photo.WorkoutID) == workoutID {
+			p := *photo
+			photos = append(photos, &p)
+		}
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].ID < photos[j].ID })
+	return photos, nil
+}
+
+func (s *PhotoStore) CountPhotos(workoutID int64) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	count := 0
+	for _, photo := range s.db.photos {
+		if int64(photo.WorkoutID) == workoutID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *PhotoStore) GetPhoto(id int64) (*store.WorkoutPhoto, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	photo, ok := s.db.photos[id]
+	if !ok {
+		return nil, nil
+	}
+	p := *photo
+	return &p, nil
+}
+
+func (s *PhotoStore) DeletePhoto(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.photos[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.photos, id)
+	return nil
+}
@@ -0,0 +1,227 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+type weightRow struct {
+	store.WeightEntry
+	createdAt time.Time //* the warehouse created_at column, measured_at can be back-filled
+}
+
+// ! ProfileStore --> store.ProfileStore on a DB
+type ProfileStore struct {
+	db *DB
+}
+
+func NewProfileStore(db *DB) *ProfileStore {
+	return &ProfileStore{db: db}
+}
+
+func (s *ProfileStore) GetProfile(userID int) (*store.Profile, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	profile, ok := s.db.profiles[userID]
+	if !ok {
+		return &store.Profile{UserID: userID, Units: store.UnitsMetric, Timezone: store.DefaultTimezone}, nil
+	}
+	p := *profile
+	return &p, nil
+}
+
+func (s *ProfileStore) UpsertProfile(profile *store.Profile) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[profile.UserID]; !ok {
+		return errForeignKey("user_profiles_user_id_fkey")
+	}
+	if profile.Timezone == "" {
+		profile.Timezone = store.DefaultTimezone
+	}
+	profile.UpdatedAt = s.db.now()
+	p := *profile
+	s.db.profiles[profile.UserID] = &p
+	return nil
+}
+
+// * location --> the user's profile zone, UTC without a profile, caller holds mu
+func (db *DB) location(userID int) *time.Location {
+	return db.profiles[userID].Location()
+}
+
+func (s *ProfileStore) AddWeight(entry *store.WeightEntry) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[entry.UserID]; !ok {
+		return errForeignKey("user_weights_user_id_fkey")
+	}
+	newest := true
+	for _, w := range s.db.weights {
+		if w.UserID == entry.UserID && w.MeasuredAt.After(entry.MeasuredAt) {
+			newest = false
+		}
+	}
+	entry.ID = int(s.db.nextID("user_weights"))
+	s.db.weights = append(s.db.weights, &weightRow{WeightEntry: *entry, createdAt: s.db.now()})
+	s.db.recordWarehouseChange("user_weights", int64(entry.ID), false)
+
+	if newest {
+		profile, ok := s.db.profiles[entry.UserID]
+		if !ok {
+			profile = &store.Profile{UserID: entry.UserID, Units: store.UnitsMetric, Timezone: store.DefaultTimezone}
+			s.db.profiles[entry.UserID] = profile
+		}
+		weight := entry.WeightKG
+		profile.WeightKG = &weight
+		profile.UpdatedAt = s.db.now()
+	}
+	return nil
+}
+
+func (s *ProfileStore) ListWeights(userID int, from, to time.Time) ([]*store.WeightEntry, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	weights := []*store.WeightEntry{}
+	for _, w := range s.db.weights {
+		if w.UserID == userID && !w.MeasuredAt.Before(from) && !w.MeasuredAt.After(to) {
+			e := w.WeightEntry
+			weights = append(weights, &e)
+		}
+	}
+	sort.SliceStable(weights, func(i, j int) bool { return weights[i].MeasuredAt.Before(weights[j].MeasuredAt) })
+	return weights, nil
+}
+
+// ! GoalStore --> store.GoalStore on a DB, CurrentValue is computed on read like goalColumns does
+type GoalStore struct {
+	db *DB
+}
+
+func NewGoalStore(db *DB) *GoalStore {
+	return &GoalStore{db: db}
+}
+
+// * goalView --> caller holds mu
+func (db *DB) goalView(stored *store.Goal) *store.Goal {
+	goal := *stored
+	goal.CurrentValue = nil
+	goal.PercentComplete = 0
+
+	switch goal.Type {
+	case store.GoalWeeklyWorkouts:
+		now := db.now().In(db.location(goal.UserID))
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		weekStart := today.AddDate(0, 0, -(int(now.Weekday())+6)%7) //* DATE_TRUNC('week') starts on monday, local midnight
+		var count float64
+		for _, row := range db.workouts {
+			if row.workout.UserID == goal.UserID && !row.workout.Flagged && !row.workout.PerformedAt.Before(weekStart) {
+				count++
+			}
+		}
+		goal.CurrentValue = &count
+	case store.GoalTotalMinutes:
+		var end *time.Time
+		if goal.Deadline != nil {
+			if deadline, err := time.Parse("2006-01-02", *goal.Deadline); err == nil {
+				next := deadline.AddDate(0, 0, 1)
+				end = &next
+			}
+		}
+		var minutes float64
+		for _, row := range db.workouts {
+			w := row.workout
+			if w.UserID == goal.UserID && !w.Flagged && !w.PerformedAt.Before(goal.CreatedAt) && (end == nil || w.PerformedAt.Before(*end)) {
+				minutes += float64(w.DurationMinutes)
+			}
+		}
+		goal.CurrentValue = &minutes
+	case store.GoalWeightTarget:
+		var latest *store.WeightEntry
+		for _, w := range db.weights {
+			if w.UserID == goal.UserID && (latest == nil || w.MeasuredAt.After(latest.MeasuredAt)) {
+				latest = &w.WeightEntry
+			}
+		}
+		if latest != nil {
+			weight := latest.WeightKG
+			goal.CurrentValue = &weight
+		}
+	}
+	return &goal
+}
+
+func (s *GoalStore) CreateGoal(goal *store.Goal) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[goal.UserID]; !ok {
+		return errForeignKey("goals_user_id_fkey")
+	}
+	now := s.db.now()
+	goal.ID = int(s.db.nextID("goals"))
+	goal.CreatedAt, goal.UpdatedAt = now, now
+	g := *goal
+	s.db.goals[goal.ID] = &g
+	return nil
+}
+
+func (s *GoalStore) GetGoal(id int64) (*store.Goal, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	goal, ok := s.db.goals[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.goalView(goal), nil
+}
+
+func (s *GoalStore) ListGoals(userID int64) ([]*store.Goal, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	goals := []*store.Goal{}
+	for _, goal := range s.db.goals {
+		if goal.UserID == int(userID) {
+			goals = append(goals, s.db.goalView(goal))
+		}
+	}
+	sort.Slice(goals, func(i, j int) bool {
+		if !goals[i].CreatedAt.Equal(goals[j].CreatedAt) {
+			return goals[i].CreatedAt.Before(goals[j].CreatedAt)
+		}
+		return goals[i].ID < goals[j].ID
+	})
+	return goals, nil
+}
+
+func (s *GoalStore) UpdateGoal(goal *store.Goal) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.goals[goal.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	stored.TargetValue, stored.Deadline = goal.TargetValue, goal.Deadline
+	stored.UpdatedAt = s.db.now()
+	goal.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (s *GoalStore) DeleteGoal(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.goals[int(id)]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.goals, int(id))
+	return nil
+}
@@ -0,0 +1,253 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+)
+
+// ! ProgramStore --> store.ProgramStore on a DB
+type ProgramStore struct {
+	db *DB
+}
+
+func NewProgramStore(db *DB) *ProgramStore {
+	return &ProgramStore{db: db}
+}
+
+// * copyWeeks --> deep copy of the week tree, Number set by position like insertProgramWeeks
+func copyWeeks(weeks []store.ProgramWeek) []store.ProgramWeek {
+	copied := make([]store.ProgramWeek, len(weeks))
+	for i, week := range weeks {
+		week.Number = i + 1
+		days := make([]store.ProgramDay, len(week.Days))
+		for j, day := range week.Days {
+			day.Templates = append([]store.ProgramTemplate{}, day.Templates...)
+			days[j] = day
+		}
+		week.Days = days
+		copied[i] = week
+	}
+	return copied
+}
+
+// * checkProgram --> the CHECK, UNIQUE and foreign keys the program tables have, caller holds mu
+func (db *DB) checkProgram(program *store.Program) error {
+	if program.Visibility != store.ProgramPrivate && program.Visibility != store.ProgramPublic {
+		return errCheck("programs_visibility_check")
+	}
+	if _, ok := db.users[program.UserID]; !ok {
+		return errForeignKey("programs_user_id_fkey")
+	}
+	for _, week := range program.Weeks {
+		seen := map[int]bool{}
+		for _, day := range week.Days {
+			if day.Day < 1 || day.Day > 7 {
+				return errCheck("program_days_day_check")
+			}
+			if seen[day.Day] {
+				return errUnique("program_days_week_id_day_key")
+			}
+			seen[day.Day] = true
+			for _, template := range day.Templates {
+				if _, ok := db.workouts[template.WorkoutID]; !ok {
+					return errForeignKey("program_day_templates_workout_id_fkey")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// * programView --> copy with days sorted and template titles read from the workouts, caller holds mu
+// ? a deleted template workout drops out of its day, like the ON DELETE CASCADE
+func (db *DB) programView(stored *store.Program, withWeeks bool) *store.Program {
+	program := *stored
+	program.Weeks = nil
+	if !withWeeks {
+		return &program
+	}
+	program.Weeks = copyWeeks(stored.Weeks)
+	for i := range program.Weeks {
+		days := program.Weeks[i].Days
+		sort.Slice(days, func(a, b int) bool { return days[a].Day < days[b].Day })
+		for j := range days {
+			templates := []store.ProgramTemplate{}
+			for _, template := range days[j].Templates {
+				row, ok := db.workouts[template.WorkoutID]
+				if !ok {
+					continue
+				}
+				templates = append(templates, store.ProgramTemplate{WorkoutID: template.WorkoutID, Title: row.workout.Title, DurationMinutes: row.workout.DurationMinutes})
+			}
+			days[j].Templates = templates
+		}
+	}
+	return &program
+}
+
+func (s *ProgramStore) CreateProgram(program *store.Program) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if err := s.db.checkProgram(program); err != nil {
+		return err
+	}
+	now := s.db.now()
+	program.ID = int(s.db.nextID("programs"))
+	program.CreatedAt, program.UpdatedAt = now, now
+	program.Weeks = copyWeeks(program.Weeks)
+	stored := *program
+	stored.Weeks = copyWeeks(program.Weeks)
+	s.db.programs[program.ID] = &stored
+	return nil
+}
+
+func (s *ProgramStore) GetProgram(id int64) (*store.Program, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	program, ok := s.db.programs[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.programView(program, true), nil
+}
+
+func (s *ProgramStore) ListPrograms(userID int) ([]*store.Program, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	programs := []*store.Program{}
+	for _, program := range s.db.programs {
+		if program.UserID == userID || s.db.enrollment(program.ID, userID) != nil {
+			programs = append(programs, s.db.programView(program, false))
+		}
+	}
+	sort.Slice(programs, func(i, j int) bool {
+		if !programs[i].CreatedAt.Equal(programs[j].CreatedAt) {
+			return programs[i].CreatedAt.After(programs[j].CreatedAt)
+		}
+		return programs[i].ID > programs[j].ID
+	})
+	return programs, nil
+}
+
+func (s *ProgramStore) UpdateProgram(program *store.Program) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.programs[program.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	program.UserID = stored.UserID
+	if err := s.db.checkProgram(program); err != nil {
+		return err
+	}
+	stored.Title, stored.Description, stored.Visibility = program.Title, program.Description, program.Visibility
+	stored.Weeks = copyWeeks(program.Weeks)
+	stored.UpdatedAt = s.db.now()
+	program.Weeks = copyWeeks(program.Weeks)
+	program.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (s *ProgramStore) DeleteProgram(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.programs[int(id)]; !ok {
+		return store.ErrNotFound
+	}
+	s.db.deleteProgram(int(id))
+	return nil
+}
+
+// * deleteProgram --> enrollments cascade, the schedules they planned stay as plain schedules, caller holds mu
+func (db *DB) deleteProgram(id int) {
+	delete(db.programs, id)
+	for eid, e := range db.enrollments {
+		if e.ProgramID == id {
+			db.deleteEnrollment(eid)
+		}
+	}
+}
+
+// * deleteEnrollment --> ON DELETE SET NULL on schedules.program_enrollment_id, caller holds mu
+func (db *DB) deleteEnrollment(id int64) {
+	delete(db.enrollments, id)
+	for _, schedule := range db.schedules {
+		if schedule.ProgramEnrollment != nil && *schedule.ProgramEnrollment == id {
+			schedule.ProgramEnrollment = nil
+		}
+	}
+}
+
+// * enrollment --> nil when userID isn't enrolled, caller holds mu
+func (db *DB) enrollment(programID, userID int) *store.ProgramEnrollment {
+	for _, e := range db.enrollments {
+		if e.ProgramID == programID && e.UserID == userID {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *ProgramStore) Enroll(e *store.ProgramEnrollment, schedules []*store.Schedule) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.programs[e.ProgramID]; !ok {
+		return errForeignKey("program_enrollments_program_id_fkey")
+	}
+	if _, ok := s.db.users[e.UserID]; !ok {
+		return errForeignKey("program_enrollments_user_id_fkey")
+	}
+	if s.db.enrollment(e.ProgramID, e.UserID) != nil {
+		return errUnique("program_enrollments_program_id_user_id_key")
+	}
+
+	now := s.db.now()
+	e.ID = s.db.nextID("program_enrollments")
+	e.CreatedAt = now
+	stored := *e
+	s.db.enrollments[e.ID] = &stored
+	for _, schedule := range schedules {
+		id := e.ID
+		schedule.ID = int(s.db.nextID("schedules"))
+		schedule.MaterializedUntil = nil
+		schedule.ProgramEnrollment = &id
+		schedule.CreatedAt, schedule.UpdatedAt = now, now
+		s.db.schedules[schedule.ID] = copySchedule(*schedule)
+	}
+	return nil
+}
+
+func (s *ProgramStore) GetEnrollment(programID int64, userID int) (*store.ProgramEnrollment, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	e := s.db.enrollment(int(programID), userID)
+	if e == nil {
+		return nil, nil
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (s *ProgramStore) Unenroll(programID int64, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	e := s.db.enrollment(int(programID), userID)
+	if e == nil {
+		return store.ErrNotFound
+	}
+	for id, schedule := range s.db.schedules {
+		if schedule.ProgramEnrollment != nil && *schedule.ProgramEnrollment == e.ID {
+			s.db.deleteSchedule(id)
+		}
+	}
+	delete(s.db.enrollments, e.ID)
+	return nil
+}
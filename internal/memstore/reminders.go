@@ -0,0 +1,134 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+// ! ReminderStore --> store.ReminderStore on a DB
+type ReminderStore struct {
+	db *DB
+}
+
+func NewReminderStore(db *DB) *ReminderStore {
+	return &ReminderStore{db: db}
+}
+
+// * copyReminder --> snooze + delivery times are pointers, the caller gets its own
+func copyReminder(r store.Reminder) *store.Reminder {
+	for _, t := range []**time.Time{&r.SnoozedUntil, &r.LastSentOn, &r.LastSentAt} {
+		if *t != nil {
+			v := **t
+			*t = &v
+		}
+	}
+	return &r
+}
+
+func (s *ReminderStore) CreateReminder(r *store.Reminder) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[r.UserID]; !ok {
+		return errForeignKey("reminders_user_id_fkey")
+	}
+	now := s.db.now()
+	r.ID = s.db.nextID("reminders")
+	r.SnoozedUntil, r.LastSentOn, r.LastSentAt = nil, nil, nil
+	r.CreatedAt, r.UpdatedAt = now, now
+	s.db.reminders[r.ID] = copyReminder(*r)
+	return nil
+}
+
+func (s *ReminderStore) GetReminder(id int64) (*store.Reminder, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	r, ok := s.db.reminders[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyReminder(*r), nil
+}
+
+// * listReminders --> ORDER BY id
+func (s *ReminderStore) listReminders(match func(*store.Reminder) bool) []*store.Reminder {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.Reminder{}
+	for _, r := range s.db.reminders {
+		if match(r) {
+			list = append(list, copyReminder(*r))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (s *ReminderStore) ListReminders(userID int) ([]*store.Reminder, error) {
+	return s.listReminders(func(r *store.Reminder) bool { return r.UserID == userID }), nil
+}
+
+func (s *ReminderStore) ListEnabledReminders() ([]*store.Reminder, error) {
+	return s.listReminders(func(r *store.Reminder) bool { return r.Enabled && s.db.liveUser(r.UserID) != nil }), nil
+}
+
+func (s *ReminderStore) UpdateReminder(r *store.Reminder) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.reminders[r.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	stored.Name, stored.RemindAt, stored.Timezone, stored.Days = r.Name, r.RemindAt, r.Timezone, r.Days
+	stored.Email, stored.Enabled = r.Email, r.Enabled
+	stored.UpdatedAt = s.db.now()
+	r.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (s *ReminderStore) DeleteReminder(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.reminders[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.reminders, id)
+	return nil
+}
+
+func (s *ReminderStore) SnoozeReminder(id int64, until time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	r, ok := s.db.reminders[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	r.SnoozedUntil = &until
+	return nil
+}
+
+// ! MarkReminderSent --> check and set under mu, the conditional UPDATE's guarantee
+func (s *ReminderStore) MarkReminderSent(id int64, day, at time.Time) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	r, ok := s.db.reminders[id]
+	if !ok {
+		return false, nil
+	}
+	if r.SnoozedUntil != nil {
+		if r.SnoozedUntil.After(at) {
+			return false, nil
+		}
+	} else if r.LastSentOn != nil && !r.LastSentOn.Before(day) {
+		return false, nil
+	}
+	r.LastSentOn, r.LastSentAt, r.SnoozedUntil = &day, &at, nil
+	return true, nil
+}
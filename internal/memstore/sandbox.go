@@ -0,0 +1,41 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"time"
+)
+
+// ! SandboxStore --> store.SandboxStore on a DB
+type SandboxStore struct {
+	db *DB
+}
+
+func NewSandboxStore(db *DB) *SandboxStore {
+	return &SandboxStore{db: db}
+}
+
+func (s *SandboxStore) CreateSandboxUser(user *store.User, expiresAt time.Time) error {
+	err := NewUserStore(s.db).CreateUser(user)
+	if err != nil {
+		return err
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.users[user.ID].sandboxExpiresAt = &expiresAt
+	return nil
+}
+
+func (s *SandboxStore) PurgeExpiredSandboxes(now time.Time) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var purged int64
+	for id, row := range s.db.users {
+		if row.sandboxExpiresAt != nil && !row.sandboxExpiresAt.After(now) {
+			s.db.purgeUser(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
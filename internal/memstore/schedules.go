@@ -0,0 +1,246 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+type occurrenceRow struct {
+	id              int
+	scheduleID      int
+	userID          int
+	originalAt      time.Time
+	occursAt        time.Time
+	title           *string //* nil falls back to the schedule
+	durationMinutes *int
+	status          string
+	edited          bool
+}
+
+// ! ScheduleStore --> store.ScheduleStore on a DB
+type ScheduleStore struct {
+	db *DB
+}
+
+func NewScheduleStore(db *DB) *ScheduleStore {
+	return &ScheduleStore{db: db}
+}
+
+func copySchedule(s store.Schedule) *store.Schedule {
+	if s.MaterializedUntil != nil {
+		until := *s.MaterializedUntil
+		s.MaterializedUntil = &until
+	}
+	if s.ProgramEnrollment != nil {
+		enrollment := *s.ProgramEnrollment
+		s.ProgramEnrollment = &enrollment
+	}
+	return &s
+}
+
+func (s *ScheduleStore) CreateSchedule(schedule *store.Schedule) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[schedule.UserID]; !ok {
+		return errForeignKey("schedules_user_id_fkey")
+	}
+	now := s.db.now()
+	schedule.ID = int(s.db.nextID("schedules"))
+	schedule.MaterializedUntil = nil
+	schedule.CreatedAt, schedule.UpdatedAt = now, now
+	s.db.schedules[schedule.ID] = copySchedule(*schedule)
+	return nil
+}
+
+func (s *ScheduleStore) GetSchedule(id int64) (*store.Schedule, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	schedule, ok := s.db.schedules[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return copySchedule(*schedule), nil
+}
+
+func (s *ScheduleStore) ListSchedules(userID int) ([]*store.Schedule, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	schedules := []*store.Schedule{}
+	for _, schedule := range s.db.schedules {
+		if schedule.UserID == userID {
+			schedules = append(schedules, copySchedule(*schedule))
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].StartsAt.Equal(schedules[j].StartsAt) {
+			return schedules[i].StartsAt.Before(schedules[j].StartsAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+func (s *ScheduleStore) UpdateSchedule(schedule *store.Schedule) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.schedules[schedule.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	now := s.db.now()
+	stored.Title, stored.Description, stored.DurationMinutes = schedule.Title, schedule.Description, schedule.DurationMinutes
+	stored.StartsAt, stored.RRule = schedule.StartsAt, schedule.RRule
+	stored.MaterializedUntil = nil
+	stored.UpdatedAt = now
+	schedule.MaterializedUntil, schedule.UpdatedAt = nil, now
+
+	for id, o := range s.db.occurrences {
+		if o.scheduleID == schedule.ID && !o.originalAt.Before(now) {
+			delete(s.db.occurrences, id)
+		}
+	}
+	return nil
+}
+
+func (s *ScheduleStore) DeleteSchedule(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.schedules[int(id)]; !ok {
+		return store.ErrNotFound
+	}
+	s.db.deleteSchedule(int(id))
+	return nil
+}
+
+// * deleteSchedule --> the schedule and its occurrences, caller holds mu
+func (db *DB) deleteSchedule(id int) {
+	delete(db.schedules, id)
+	for oid, o := range db.occurrences {
+		if o.scheduleID == id {
+			delete(db.occurrences, oid)
+		}
+	}
+}
+
+func (s *ScheduleStore) ListSchedulesToMaterialize(horizon time.Time, limit int) ([]*store.Schedule, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	schedules := []*store.Schedule{}
+	for _, schedule := range s.db.schedules {
+		if schedule.MaterializedUntil == nil || schedule.MaterializedUntil.Before(horizon) {
+			schedules = append(schedules, copySchedule(*schedule))
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		a, b := schedules[i].MaterializedUntil, schedules[j].MaterializedUntil
+		switch {
+		case a == nil && b == nil:
+			return schedules[i].ID < schedules[j].ID
+		case a == nil || b == nil:
+			return a == nil //* NULLS FIRST
+		case !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return page(schedules, 0, limit), nil
+}
+
+func (s *ScheduleStore) SaveOccurrences(schedule *store.Schedule, times []time.Time, until time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.schedules[schedule.ID]
+	if !ok {
+		return errForeignKey("schedule_occurrences_schedule_id_fkey")
+	}
+	existing := map[time.Time]bool{}
+	for _, o := range s.db.occurrences {
+		if o.scheduleID == schedule.ID {
+			existing[o.originalAt.UTC()] = true
+		}
+	}
+	for _, t := range times {
+		if existing[t.UTC()] {
+			continue
+		}
+		existing[t.UTC()] = true
+		id := int(s.db.nextID("schedule_occurrences"))
+		s.db.occurrences[id] = &occurrenceRow{id: id, scheduleID: schedule.ID, userID: schedule.UserID, originalAt: t, occursAt: t, status: store.OccurrenceScheduled}
+	}
+	stored.MaterializedUntil = &until
+	return nil
+}
+
+// * occurrenceView --> the occurrenceColumns COALESCEs, caller holds mu
+func (db *DB) occurrenceView(o *occurrenceRow) *store.Occurrence {
+	schedule := db.schedules[o.scheduleID]
+	occurrence := &store.Occurrence{
+		ID:              o.id,
+		ScheduleID:      o.scheduleID,
+		UserID:          o.userID,
+		OriginalAt:      o.originalAt,
+		OccursAt:        o.occursAt,
+		Title:           schedule.Title,
+		DurationMinutes: schedule.DurationMinutes,
+		Status:          o.status,
+		Edited:          o.edited,
+	}
+	if o.title != nil {
+		occurrence.Title = *o.title
+	}
+	if o.durationMinutes != nil {
+		occurrence.DurationMinutes = *o.durationMinutes
+	}
+	return occurrence
+}
+
+func (s *ScheduleStore) ListOccurrences(userID int, from, to time.Time) ([]*store.Occurrence, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	occurrences := []*store.Occurrence{}
+	for _, o := range s.db.occurrences {
+		if o.userID == userID && inWindow(o.occursAt, from, to) {
+			occurrences = append(occurrences, s.db.occurrenceView(o))
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		if !occurrences[i].OccursAt.Equal(occurrences[j].OccursAt) {
+			return occurrences[i].OccursAt.Before(occurrences[j].OccursAt)
+		}
+		return occurrences[i].ID < occurrences[j].ID
+	})
+	return occurrences, nil
+}
+
+func (s *ScheduleStore) GetOccurrence(id int64) (*store.Occurrence, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	o, ok := s.db.occurrences[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	return s.db.occurrenceView(o), nil
+}
+
+func (s *ScheduleStore) UpdateOccurrence(o *store.Occurrence) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.occurrences[o.ID]
+	if ok {
+		title, duration := o.Title, o.DurationMinutes
+		stored.occursAt, stored.title, stored.durationMinutes, stored.status, stored.edited = o.OccursAt, &title, &duration, o.Status, true
+	}
+	o.Edited = true
+	return nil
+}
@@ -0,0 +1,164 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"fmt"
+	"time"
+)
+
+// ! DemoPassword --> every seeded account logs in with it, -demo mode prints it on start
+const DemoPassword = "fittrack-demo"
+
+// ? - one seeded workout, DaysAgo is counted back from the seed time
+type demoWorkout struct {
+	DaysAgo    int
+	Title      string
+	Minutes    int
+	Calories   int
+	Visibility string
+	Entries    []store.WorkoutEntry
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+// * demoPlans --> a couple of weeks per user so feeds, streaks, goals and the XP board have something to show
+var demoPlans = map[string][]demoWorkout{
+	"demo": {
+		{DaysAgo: 0, Title: "Push day", Minutes: 55, Calories: 420, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Bench Press", Sets: 4, Reps: intPtr(8), Weight: floatPtr(80), OrderIndex: 1},
+			{ExerciseName: "Overhead Press", Sets: 3, Reps: intPtr(10), Weight: floatPtr(45), OrderIndex: 2},
+			{ExerciseName: "Plank", Sets: 3, DurationSeconds: intPtr(60), OrderIndex: 3},
+		}},
+		{DaysAgo: 1, Title: "Easy run", Minutes: 30, Calories: 310, Visibility: store.VisibilityFollowers, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Running", Sets: 1, DurationSeconds: intPtr(1800), OrderIndex: 1},
+		}},
+		{DaysAgo: 2, Title: "Pull day", Minutes: 50, Calories: 380, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Deadlift", Sets: 3, Reps: intPtr(5), Weight: floatPtr(140), OrderIndex: 1},
+			{ExerciseName: "Pull Up", Sets: 4, Reps: intPtr(8), OrderIndex: 2},
+		}},
+		{DaysAgo: 4, Title: "Leg day", Minutes: 65, Calories: 510, Visibility: store.VisibilityPrivate, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Squat", Sets: 5, Reps: intPtr(5), Weight: floatPtr(110), OrderIndex: 1},
+			{ExerciseName: "Lunge", Sets: 3, Reps: intPtr(12), Weight: floatPtr(20), OrderIndex: 2},
+		}},
+		{DaysAgo: 9, Title: "Long ride", Minutes: 95, Calories: 820, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Cycling", Sets: 1, DurationSeconds: intPtr(5700), OrderIndex: 1},
+		}},
+	},
+	"alice": {
+		{DaysAgo: 0, Title: "Morning yoga", Minutes: 40, Calories: 160, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Sun Salutation", Sets: 5, DurationSeconds: intPtr(300), OrderIndex: 1},
+		}},
+		{DaysAgo: 3, Title: "Intervals", Minutes: 35, Calories: 390, Visibility: store.VisibilityFollowers, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Sprint", Sets: 8, DurationSeconds: intPtr(30), OrderIndex: 1},
+			{ExerciseName: "Burpee", Sets: 4, Reps: intPtr(15), OrderIndex: 2},
+		}},
+		{DaysAgo: 6, Title: "Full body", Minutes: 60, Calories: 450, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Kettlebell Swing", Sets: 4, Reps: intPtr(20), Weight: floatPtr(16), OrderIndex: 1},
+			{ExerciseName: "Goblet Squat", Sets: 3, Reps: intPtr(12), Weight: floatPtr(24), OrderIndex: 2},
+		}},
+	},
+	"bob": {
+		{DaysAgo: 1, Title: "Swim", Minutes: 45, Calories: 400, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Freestyle", Sets: 10, DurationSeconds: intPtr(120), OrderIndex: 1},
+		}},
+		{DaysAgo: 5, Title: "Arms", Minutes: 40, Calories: 260, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+			{ExerciseName: "Bicep Curl", Sets: 4, Reps: intPtr(12), Weight: floatPtr(14), OrderIndex: 1},
+			{ExerciseName: "Dip", Sets: 3, Reps: intPtr(10), OrderIndex: 2},
+		}},
+	},
+}
+
+// ! Seed --> demo, alice and bob with workouts, follows, feeds, a goal, weights and a small org
+// ? demo is an admin so the admin UI has an account to log in with, returns the usernames created
+func Seed(db *DB) ([]string, error) {
+	users := NewUserStore(db)
+	workouts := NewWorkoutStore(db)
+	follows := NewFollowStore(db)
+	profiles := NewProfileStore(db)
+	xp := NewXPStore(db)
+	now := db.now()
+
+	usernames := []string{"demo", "alice", "bob"}
+	ids := map[string]int{}
+	for _, username := range usernames {
+		user := &store.User{Username: username, Email: username + "@demo.fittrack.local", Bio: fmt.Sprintf("Seeded %s account", username)}
+		err := user.PasswordHash.Set(DemoPassword)
+		if err != nil {
+			return nil, err
+		}
+		err = users.CreateUser(user)
+		if err != nil {
+			return nil, err
+		}
+		ids[username] = user.ID
+
+		for _, plan := range demoPlans[username] {
+			workout := &store.Workout{
+				UserID:          user.ID,
+				Title:           plan.Title,
+				DurationMinutes: plan.Minutes,
+				CaloriesBurned:  plan.Calories,
+				Visibility:      plan.Visibility,
+				CreatedAt:       now.AddDate(0, 0, -plan.DaysAgo).Add(-time.Hour),
+				Entries:         append([]store.WorkoutEntry(nil), plan.Entries...),
+			}
+			_, err = workouts.CreateWorkout(workout)
+			if err != nil {
+				return nil, err
+			}
+			err = xp.UpsertWorkoutXP(workout.ID, 10, 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	db.mu.Lock()
+	db.users[ids["demo"]].user.IsAdmin = true
+	db.mu.Unlock()
+
+	for _, pair := range [][2]string{{"alice", "demo"}, {"bob", "demo"}, {"demo", "alice"}, {"alice", "bob"}} {
+		follower, followee := int64(ids[pair[0]]), int64(ids[pair[1]])
+		err := follows.Follow(follower, followee)
+		if err != nil {
+			return nil, err
+		}
+		_, err = follows.BackfillFeed(follower, followee)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	demoID := ids["demo"]
+	height := 180.0
+	err := profiles.UpsertProfile(&store.Profile{UserID: demoID, HeightCM: &height, Units: store.UnitsMetric, EventsOptIn: true})
+	if err != nil {
+		return nil, err
+	}
+	for i, kg := range []float64{82.4, 81.9, 81.5} {
+		err = profiles.AddWeight(&store.WeightEntry{UserID: demoID, WeightKG: kg, MeasuredAt: now.AddDate(0, 0, -14+7*i)})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = NewGoalStore(db).CreateGoal(&store.Goal{UserID: demoID, Type: store.GoalWeeklyWorkouts, TargetValue: 4})
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := NewOrgStore(db)
+	org := &store.Org{Name: "Demo Gym"}
+	err = orgs.CreateOrg(org, demoID)
+	if err != nil {
+		return nil, err
+	}
+	for username, role := range map[string]string{"alice": store.OrgRoleCoach, "bob": store.OrgRoleMember} {
+		err = orgs.UpsertMember(&store.OrgMember{OrgID: org.ID, User: &store.User{ID: ids[username]}, Role: role, Active: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return usernames, nil
+}
@@ -0,0 +1,91 @@
+package memstore
+
+import (
+	"crypto/sha256"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"sort"
+	"time"
+)
+
+// ! SessionStore --> store.SessionStore on a DB, sessions are the auth rows of the tokens table
+type SessionStore struct {
+	db *DB
+}
+
+func NewSessionStore(db *DB) *SessionStore {
+	return &SessionStore{db: db}
+}
+
+// * lastActive --> ORDER BY COALESCE(last_used_at, created_at)
+func (t *tokenRow) lastActive() time.Time {
+	if t.lastUsed != nil {
+		return *t.lastUsed
+	}
+	return t.createdAt
+}
+
+func (s *SessionStore) ListSessions(userID int, now time.Time) ([]*store.Session, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	rows := []*tokenRow{}
+	for _, t := range s.db.tokens {
+		if t.userID == userID && t.scope == tokens.ScopeAuth && t.expiry.After(now) {
+			rows = append(rows, t)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].lastActive().Equal(rows[j].lastActive()) {
+			return rows[i].lastActive().After(rows[j].lastActive())
+		}
+		return rows[i].id > rows[j].id
+	})
+
+	sessions := make([]*store.Session, 0, len(rows))
+	for _, t := range rows {
+		session := &store.Session{
+			ID:        t.id,
+			Hash:      append([]byte(nil), t.hash...),
+			CreatedAt: t.createdAt,
+			IP:        t.ip,
+			UserAgent: t.userAgent,
+			Expiry:    t.expiry,
+		}
+		if t.lastUsed != nil {
+			at := *t.lastUsed
+			session.LastUsedAt = &at
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s *SessionStore) TouchSession(token, ip, userAgent string, at time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	hash := sha256.Sum256([]byte(token))
+	t := s.db.findToken(hash[:], tokens.ScopeAuth)
+	if t == nil {
+		return nil
+	}
+	if t.lastUsed != nil && !t.lastUsed.Before(at.Add(-store.SessionTouchInterval)) && t.ip == ip {
+		return nil
+	}
+	t.lastUsed, t.ip, t.userAgent = &at, ip, userAgent
+	return nil
+}
+
+func (s *SessionStore) DeleteSession(userID int, id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	deleted := s.db.deleteTokens(func(t *tokenRow) bool {
+		return t.userID == userID && t.id == id && t.scope == tokens.ScopeAuth
+	})
+	if deleted == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package memstore
+
+import (
+	"crypto/sha256"
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+type shareRow struct {
+	share     store.WorkoutShare
+	revokedAt *time.Time
+}
+
+// ! ShareStore --> store.ShareStore on a DB
+type ShareStore struct {
+	db *DB
+}
+
+func NewShareStore(db *DB) *ShareStore {
+	return &ShareStore{db: db}
+}
+
+func (s *ShareStore) CreateShare(share *store.WorkoutShare) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[share.WorkoutID]; !ok {
+		return errForeignKey("workout_shares_workout_id_fkey")
+	}
+	for _, row := range s.db.shares {
+		if string(row.share.TokenHash) == string(share.TokenHash) {
+			return errUnique("workout_shares_token_hash_key")
+		}
+	}
+	share.ID = int(s.db.nextID("workout_shares"))
+	share.CreatedAt = s.db.now()
+	stored := *share
+	stored.TokenHash = append([]byte(nil), share.TokenHash...)
+	s.db.shares = append(s.db.shares, &shareRow{share: stored})
+	return nil
+}
+
+func (s *ShareStore) GetSharedWorkoutID(plaintext string) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	hash := sha256.Sum256([]byte(plaintext))
+	now := time.Now()
+	for _, row := range s.db.shares {
+		if string(row.share.TokenHash) == string(hash[:]) && row.revokedAt == nil && row.share.ExpiresAt.After(now) {
+			return int64(row.share.WorkoutID), nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *ShareStore) RevokeShares(workoutID int64) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.db.revokeShares(func(row *shareRow) bool { return row.share.WorkoutID == int(workoutID) }), nil
+}
+
+// * revokeShares --> caller holds mu, already revoked links are left alone
+func (db *DB) revokeShares(match func(*shareRow) bool) int64 {
+	now := db.now()
+	var revoked int64
+	for _, row := range db.shares {
+		if row.revokedAt == nil && match(row) {
+			row.revokedAt = &now
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// ! VerificationStore --> store.VerificationStore on a DB
+type VerificationStore struct {
+	db *DB
+}
+
+func NewVerificationStore(db *DB) *VerificationStore {
+	return &VerificationStore{db: db}
+}
+
+func (s *VerificationStore) GetVerification(workoutID int64) (*store.WorkoutVerification, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	v, ok := s.db.verifications[int(workoutID)]
+	if !ok {
+		return nil, nil
+	}
+	copied := *v
+	return &copied, nil
+}
+
+// ! SaveVerification --> upsert plus workouts.verified, both under mu like the postgres transaction
+func (s *VerificationStore) SaveVerification(v *store.WorkoutVerification) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.workouts[v.WorkoutID]
+	if !ok {
+		return errForeignKey("workout_verifications_workout_id_fkey")
+	}
+	if v.AttestedBy != nil {
+		if _, ok := s.db.users[*v.AttestedBy]; !ok {
+			return errForeignKey("workout_verifications_attested_by_fkey")
+		}
+	}
+	v.UpdatedAt = s.db.now()
+	stored := *v
+	s.db.verifications[v.WorkoutID] = &stored
+	row.workout.Verified = v.Status == store.VerificationVerified
+	s.db.recordChange(v.WorkoutID, row.workout.UserID, false)
+	s.db.recordWarehouseChange("workouts", int64(v.WorkoutID), false)
+	return nil
+}
+
+// * resetVerification --> an edited workout goes back to pending, caller holds mu
+func (db *DB) resetVerification(workoutID int) {
+	v, ok := db.verifications[workoutID]
+	if !ok {
+		return
+	}
+	v.Status = store.VerificationPending
+	v.AttestedBy, v.Approved, v.AttestedAt = nil, nil, nil
+	v.UpdatedAt = db.now()
+}
+
+// ! ExportStore --> store.ExportStore on a DB
+type ExportStore struct {
+	db *DB
+}
+
+func NewExportStore(db *DB) *ExportStore {
+	return &ExportStore{db: db}
+}
+
+func (s *ExportStore) CreateExport(job *store.ExportJob) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[job.RequestedBy]; !ok {
+		return errForeignKey("exports_requested_by_fkey")
+	}
+	if job.OrgID != nil {
+		if _, ok := s.db.orgs[*job.OrgID]; !ok {
+			return errForeignKey("exports_org_id_fkey")
+		}
+	}
+	job.Status = store.ExportStatusPending
+	job.ID = int(s.db.nextID("exports"))
+	job.CreatedAt = s.db.now()
+	stored := *job
+	s.db.exports[job.ID] = &stored
+	return nil
+}
+
+func (s *ExportStore) GetExport(id int64) (*store.ExportJob, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	job, ok := s.db.exports[int(id)]
+	if !ok {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (s *ExportStore) UpdateExport(job *store.ExportJob) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if job.Status == store.ExportStatusDone || job.Status == store.ExportStatusFailed {
+		now := time.Now()
+		job.CompletedAt = &now
+	}
+	stored, ok := s.db.exports[job.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status, stored.FilePath, stored.Error, stored.CompletedAt = job.Status, job.FilePath, job.Error, job.CompletedAt
+	return nil
+}
+
+func (s *ExportStore) ListUserExports(userID int, since time.Time) ([]*store.ExportJob, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	jobs := []*store.ExportJob{}
+	for _, job := range s.db.exports {
+		if job.RequestedBy == userID && job.Kind == store.ExportKindAccount && !job.CreatedAt.Before(since) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+	return jobs, nil
+}
@@ -0,0 +1,60 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+// * changeRow --> one workout_changes row, keyed by workout id like its unique index
+type changeRow struct {
+	seq     int64
+	userID  int
+	deleted bool
+	at      time.Time
+}
+
+// * recordChange --> what the triggers on workouts + workout_tags do, caller holds mu
+func (db *DB) recordChange(workoutID, userID int, deleted bool) {
+	db.changes[workoutID] = &changeRow{seq: db.nextID("workout_changes"), userID: userID, deleted: deleted, at: db.now()}
+}
+
+// ! SyncStore --> store.SyncStore on a DB
+type SyncStore struct {
+	db *DB
+}
+
+func NewSyncStore(db *DB) *SyncStore {
+	return &SyncStore{db: db}
+}
+
+func (s *SyncStore) ListChanges(userID int, since int64, limit int) ([]*store.SyncChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	changes := []*store.SyncChange{}
+	for workoutID, row := range s.db.changes {
+		if row.userID == userID && row.seq > since {
+			changes = append(changes, &store.SyncChange{Seq: row.seq, WorkoutID: int64(workoutID), Deleted: row.deleted, ChangedAt: row.at})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Seq < changes[j].Seq })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	for _, change := range changes {
+		row, ok := s.db.workouts[int(change.WorkoutID)]
+		if change.Deleted || !ok {
+			continue
+		}
+		change.Workout = copyWorkout(row.workout)
+		change.Workout.Tags = s.db.workoutTags(row.workout.ID)
+		if change.Workout.Entries == nil {
+			change.Workout.Entries = []store.WorkoutEntry{} //* like the sql stores, clients replace entries wholesale
+		}
+		sort.SliceStable(change.Workout.Entries, func(i, j int) bool {
+			return change.Workout.Entries[i].OrderIndex < change.Workout.Entries[j].OrderIndex
+		})
+	}
+	return changes, nil
+}
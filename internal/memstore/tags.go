@@ -0,0 +1,155 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"strings"
+)
+
+type tagKey struct {
+	workoutID int
+	tag       string
+}
+
+// ! TagStore --> store.TagStore on a DB
+type TagStore struct {
+	db *DB
+}
+
+func NewTagStore(db *DB) *TagStore {
+	return &TagStore{db: db}
+}
+
+// * workoutTags --> sorted tags of one workout, caller holds mu
+func (db *DB) workoutTags(workoutID int) []string {
+	tags := []string{}
+	for key := range db.tags {
+		if key.workoutID == workoutID {
+			tags = append(tags, key.tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// * tagsChanged --> the workout_tags trigger, a workout that's gone keeps its tombstone; caller holds mu
+func (db *DB) tagsChanged(workoutID int) {
+	if row, ok := db.workouts[workoutID]; ok {
+		db.recordChange(workoutID, row.workout.UserID, false)
+	}
+}
+
+func (s *TagStore) ListWorkoutTags(workoutID int64) ([]string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.db.workoutTags(int(workoutID)), nil
+}
+
+func (s *TagStore) SetWorkoutTags(workoutID int64, tags []string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[int(workoutID)]; !ok && len(tags) > 0 {
+		return errForeignKey("workout_tags_workout_id_fkey")
+	}
+	s.db.replaceWorkoutTags(int(workoutID), tags)
+	return nil
+}
+
+// * replaceWorkoutTags --> SetWorkoutTags without the checks, caller holds mu
+func (db *DB) replaceWorkoutTags(workoutID int, tags []string) {
+	for key := range db.tags {
+		if key.workoutID == workoutID {
+			delete(db.tags, key)
+		}
+	}
+	for _, tag := range tags {
+		db.tags[tagKey{workoutID: workoutID, tag: tag}] = db.now()
+	}
+	db.tagsChanged(workoutID)
+}
+
+func (s *TagStore) AddWorkoutTag(workoutID int64, tag string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[int(workoutID)]; !ok {
+		return errForeignKey("workout_tags_workout_id_fkey")
+	}
+	key := tagKey{workoutID: int(workoutID), tag: tag}
+	if _, ok := s.db.tags[key]; !ok {
+		s.db.tags[key] = s.db.now()
+		s.db.tagsChanged(int(workoutID))
+	}
+	return nil
+}
+
+func (s *TagStore) RemoveWorkoutTag(workoutID int64, tag string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := tagKey{workoutID: int(workoutID), tag: tag}
+	if _, ok := s.db.tags[key]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.tags, key)
+	s.db.tagsChanged(int(workoutID))
+	return nil
+}
+
+func (s *TagStore) ListTagCounts(userID int, prefix string, limit int) ([]*store.TagCount, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	counts := map[string]int{}
+	for key := range s.db.tags {
+		row, ok := s.db.workouts[key.workoutID]
+		if ok && row.workout.UserID == userID && strings.HasPrefix(key.tag, prefix) {
+			counts[key.tag]++
+		}
+	}
+	list := make([]*store.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		list = append(list, &store.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (s *TagStore) ListWorkoutsByTag(userID int, tag string, limit, offset int) ([]*store.Workout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	workouts := []*store.Workout{}
+	for id, row := range s.db.workouts {
+		if row.workout.UserID != userID {
+			continue
+		}
+		if _, ok := s.db.tags[tagKey{workoutID: id, tag: tag}]; tag != "" && !ok {
+			continue
+		}
+		w := row.workout
+		w.Entries = []store.WorkoutEntry{}
+		w.Tags = s.db.workoutTags(id)
+		workouts = append(workouts, &w)
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		if !workouts[i].PerformedAt.Equal(workouts[j].PerformedAt) {
+			return workouts[i].PerformedAt.After(workouts[j].PerformedAt)
+		}
+		return workouts[i].ID > workouts[j].ID
+	})
+	if offset >= len(workouts) {
+		return []*store.Workout{}, nil
+	}
+	return workouts[offset:min(offset+limit, len(workouts))], nil
+}
@@ -0,0 +1,74 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+type trainingLoadAlertKey struct {
+	userID int
+	day    string //* YYYY-MM-DD
+	band   string
+}
+
+// ! TrainingLoadStore --> store.TrainingLoadStore on a DB
+type TrainingLoadStore struct {
+	db *DB
+}
+
+func NewTrainingLoadStore(db *DB) *TrainingLoadStore {
+	return &TrainingLoadStore{db: db}
+}
+
+func (s *TrainingLoadStore) ListDailyLoad(userID int, since time.Time) ([]*store.DailyLoad, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	days := map[time.Time]*store.DailyLoad{}
+	loc := s.db.location(userID)
+	since = since.UTC()
+	since = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, time.UTC)
+	for _, row := range s.db.workouts {
+		w := row.workout
+		at := w.PerformedAt.In(loc)
+		day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+		if w.UserID != userID || w.Flagged || day.Before(since) {
+			continue
+		}
+		d, ok := days[day]
+		if !ok {
+			d = &store.DailyLoad{Day: day}
+			days[day] = d
+		}
+		d.Sessions++
+		d.Minutes += w.DurationMinutes
+		for _, e := range w.Entries {
+			if e.Reps != nil && e.Weight != nil {
+				d.Volume += float64(e.Sets) * float64(*e.Reps) * *e.Weight
+			}
+		}
+	}
+
+	list := make([]*store.DailyLoad, 0, len(days))
+	for _, d := range days {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Day.Before(list[j].Day) })
+	return list, nil
+}
+
+func (s *TrainingLoadStore) MarkTrainingLoadAlert(userID int, day time.Time, band string, ratio float64) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return false, errForeignKey("training_load_alerts_user_id_fkey")
+	}
+	key := trainingLoadAlertKey{userID: userID, day: day.Format("2006-01-02"), band: band}
+	if _, ok := s.db.loadAlerts[key]; ok {
+		return false, nil
+	}
+	s.db.loadAlerts[key] = ratio
+	return true, nil
+}
@@ -0,0 +1,115 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"time"
+)
+
+type twoFactorRow struct {
+	twoFactor   store.TwoFactor
+	backupCodes map[string]*time.Time //* hash --> used_at
+}
+
+// ! TwoFactorStore --> store.TwoFactorStore on a DB
+type TwoFactorStore struct {
+	db *DB
+}
+
+func NewTwoFactorStore(db *DB) *TwoFactorStore {
+	return &TwoFactorStore{db: db}
+}
+
+func (s *TwoFactorStore) GetTwoFactor(userID int) (*store.TwoFactor, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.twoFactor[userID]
+	if !ok {
+		return nil, nil
+	}
+	t := row.twoFactor
+	if t.ConfirmedAt != nil {
+		at := *t.ConfirmedAt
+		t.ConfirmedAt = &at
+	}
+	for _, usedAt := range row.backupCodes {
+		if usedAt == nil {
+			t.BackupCodesLeft++
+		}
+	}
+	return &t, nil
+}
+
+func (s *TwoFactorStore) SaveTwoFactorSecret(userID int, secret string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return errForeignKey("two_factor_user_id_fkey")
+	}
+	row, ok := s.db.twoFactor[userID]
+	if !ok {
+		row = &twoFactorRow{backupCodes: map[string]*time.Time{}}
+		s.db.twoFactor[userID] = row
+	}
+	row.twoFactor = store.TwoFactor{UserID: userID, Secret: secret, CreatedAt: s.db.now()}
+	return nil
+}
+
+func (s *TwoFactorStore) ConfirmTwoFactor(userID int, step int64, backupHashes [][]byte) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.twoFactor[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	now := s.db.now()
+	row.twoFactor.ConfirmedAt = &now
+	row.twoFactor.LastStep = step
+	row.backupCodes = map[string]*time.Time{}
+	for _, hash := range backupHashes {
+		row.backupCodes[string(hash)] = nil
+	}
+	return nil
+}
+
+func (s *TwoFactorStore) MarkTwoFactorStep(userID int, step int64) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.twoFactor[userID]
+	if !ok || row.twoFactor.LastStep >= step {
+		return false, nil
+	}
+	row.twoFactor.LastStep = step
+	return true, nil
+}
+
+func (s *TwoFactorStore) UseBackupCode(userID int, hash []byte) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.twoFactor[userID]
+	if !ok {
+		return false, nil
+	}
+	usedAt, ok := row.backupCodes[string(hash)]
+	if !ok || usedAt != nil {
+		return false, nil
+	}
+	now := s.db.now()
+	row.backupCodes[string(hash)] = &now
+	return true, nil
+}
+
+func (s *TwoFactorStore) DeleteTwoFactor(userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.twoFactor[userID]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.twoFactor, userID)
+	return nil
+}
@@ -0,0 +1,201 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+	"time"
+)
+
+type clientUsageKey struct {
+	day           string //* YYYY-MM-DD, the date column
+	clientID      string
+	clientVersion string
+	method        string
+	route         string
+}
+
+// ! ClientUsageStore --> store.ClientUsageStore on a DB
+type ClientUsageStore struct {
+	db *DB
+}
+
+func NewClientUsageStore(db *DB) *ClientUsageStore {
+	return &ClientUsageStore{db: db}
+}
+
+func (s *ClientUsageStore) AddClientUsage(usage []*store.ClientUsage) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, u := range usage {
+		key := clientUsageKey{day: u.Day.Format("2006-01-02"), clientID: u.ClientID, clientVersion: u.ClientVersion, method: u.Method, route: u.Route}
+		stored, ok := s.db.clientUsage[key]
+		if !ok {
+			c := *u
+			s.db.clientUsage[key] = &c
+			continue
+		}
+		stored.Requests += u.Requests
+		if u.LastSeenAt.After(stored.LastSeenAt) {
+			stored.LastSeenAt = u.LastSeenAt
+		}
+	}
+	return nil
+}
+
+func (s *ClientUsageStore) ListClientUsage(filter store.ClientUsageFilter) ([]*store.ClientUsage, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	since := filter.Since.Format("2006-01-02")
+	byKey := map[clientUsageKey]*store.ClientUsage{}
+	list := []*store.ClientUsage{}
+	for key, u := range s.db.clientUsage {
+		if key.day < since || filter.Route != "" && u.Route != filter.Route || filter.ClientID != "" && u.ClientID != filter.ClientID {
+			continue
+		}
+		key.day = ""
+		total := byKey[key]
+		if total == nil {
+			total = &store.ClientUsage{ClientID: u.ClientID, ClientVersion: u.ClientVersion, Method: u.Method, Route: u.Route}
+			byKey[key] = total
+			list = append(list, total)
+		}
+		total.Requests += u.Requests
+		if u.LastSeenAt.After(total.LastSeenAt) {
+			total.LastSeenAt = u.LastSeenAt
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch {
+		case a.Requests != b.Requests:
+			return a.Requests > b.Requests
+		case a.Route != b.Route:
+			return a.Route < b.Route
+		case a.ClientID != b.ClientID:
+			return a.ClientID < b.ClientID
+		case a.ClientVersion != b.ClientVersion:
+			return a.ClientVersion < b.ClientVersion
+		}
+		return a.Method < b.Method
+	})
+	return page(list, 0, filter.Limit), nil
+}
+
+type userRequestKey struct {
+	userID int
+	day    string //* YYYY-MM-DD, the date column
+}
+
+// ! UserUsageStore --> store.UserUsageStore on a DB
+type UserUsageStore struct {
+	db *DB
+}
+
+func NewUserUsageStore(db *DB) *UserUsageStore {
+	return &UserUsageStore{db: db}
+}
+
+func (s *UserUsageStore) AddUserRequests(counts []*store.UserRequests) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, c := range counts {
+		if _, ok := s.db.users[c.UserID]; !ok {
+			continue //* the account may be gone by the time its counts are flushed
+		}
+		s.db.userRequests[userRequestKey{userID: c.UserID, day: c.Day.Format("2006-01-02")}] += c.Requests
+	}
+	return nil
+}
+
+func (s *UserUsageStore) ListUserRequests(userID int, since time.Time) ([]*store.UserRequests, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	from := since.Format("2006-01-02")
+	list := []*store.UserRequests{}
+	for key, requests := range s.db.userRequests {
+		if key.userID == userID && key.day >= from {
+			day, _ := time.Parse("2006-01-02", key.day)
+			list = append(list, &store.UserRequests{UserID: userID, Day: day, Requests: requests})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Day.Before(list[j].Day) })
+	return list, nil
+}
+
+type exposureKey struct {
+	userID     int
+	experiment string
+	variant    string
+}
+
+type exposureRow struct {
+	id        int64
+	exposedAt time.Time
+}
+
+// ! ExperimentStore --> store.ExperimentStore on a DB
+type ExperimentStore struct {
+	db *DB
+}
+
+func NewExperimentStore(db *DB) *ExperimentStore {
+	return &ExperimentStore{db: db}
+}
+
+func (s *ExperimentStore) LogExposures(userID int, exposures []store.ExperimentExposure) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if len(exposures) == 0 {
+		return nil
+	}
+	if _, ok := s.db.users[userID]; !ok {
+		return errForeignKey("experiment_exposures_user_id_fkey")
+	}
+	for _, exposure := range exposures {
+		key := exposureKey{userID: userID, experiment: exposure.Experiment, variant: exposure.Variant}
+		if _, ok := s.db.exposures[key]; !ok {
+			s.db.exposures[key] = &exposureRow{id: s.db.nextID("experiment_exposures"), exposedAt: s.db.now()}
+			s.db.recordWarehouseChange("experiment_exposures", s.db.exposures[key].id, false)
+		}
+	}
+	return nil
+}
+
+// ! ShadowStore --> store.ShadowStore on a DB
+type ShadowStore struct {
+	db *DB
+}
+
+func NewShadowStore(db *DB) *ShadowStore {
+	return &ShadowStore{db: db}
+}
+
+func (s *ShadowStore) RecordShadowDiff(diff *store.ShadowDiff) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	diff.ID = s.db.nextID("shadow_diffs")
+	diff.CreatedAt = s.db.now()
+	d := *diff
+	d.Differences = append([]string(nil), diff.Differences...)
+	s.db.shadowDiffs = append(s.db.shadowDiffs, &d)
+	return nil
+}
+
+func (s *ShadowStore) ListShadowDiffs(limit int) ([]*store.ShadowDiff, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	diffs := []*store.ShadowDiff{}
+	for i := len(s.db.shadowDiffs) - 1; i >= 0 && len(diffs) < limit; i-- {
+		d := *s.db.shadowDiffs[i]
+		d.Differences = append([]string(nil), d.Differences...)
+		diffs = append(diffs, &d)
+	}
+	return diffs, nil
+}
@@ -0,0 +1,245 @@
+package memstore
+
+import (
+	"crypto/sha256"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"time"
+)
+
+// ! UserStore --> store.UserStore on a DB
+type UserStore struct {
+	db *DB
+}
+
+func NewUserStore(db *DB) *UserStore {
+	return &UserStore{db: db}
+}
+
+// * copyUser --> the hash is copied too, SetHash on the result must not reach the table
+func copyUser(u store.User) *store.User {
+	u.PasswordHash.SetHash(append([]byte(nil), u.PasswordHash.Hash()...))
+	return &u
+}
+
+func (s *UserStore) CreateUser(user *store.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.db.insertUser(user)
+}
+
+// * insertUser --> the INSERT INTO users, caller holds mu
+func (db *DB) insertUser(user *store.User) error {
+	//* the unique constraints cover deleted accounts too, like the postgres table
+	for _, row := range db.users {
+		if row.user.Username == user.Username {
+			return errUnique("users_username_key")
+		}
+		if row.user.Email == user.Email {
+			return errUnique("users_email_key")
+		}
+	}
+
+	now := db.now()
+	user.ID = int(db.nextID("users"))
+	user.IsAdmin = false //* granted directly in the db, never through the API
+	user.CreatedAt, user.UpdatedAt = now, now
+	db.users[user.ID] = &userRow{user: *copyUser(*user)}
+	return nil
+}
+
+func (s *UserStore) GetUserByUsername(username string) (*store.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, row := range s.db.users {
+		if row.user.Username == username && row.deletedAt == nil {
+			return copyUser(row.user), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *UserStore) GetUserByID(id int64) (*store.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row := s.db.liveUser(int(id))
+	if row == nil {
+		return nil, nil
+	}
+	return copyUser(row.user), nil
+}
+
+func (s *UserStore) UpdateUser(user *store.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.users[user.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	for id, other := range s.db.users {
+		if id != user.ID && other.user.Username == user.Username {
+			return errUnique("users_username_key")
+		}
+		if id != user.ID && other.user.Email == user.Email {
+			return errUnique("users_email_key")
+		}
+	}
+	row.user.Username, row.user.Email, row.user.Bio = user.Username, user.Email, user.Bio
+	row.user.UpdatedAt = s.db.now()
+	return nil
+}
+
+func (s *UserStore) UpdatePassword(user *store.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row := s.db.liveUser(user.ID)
+	if row == nil {
+		return store.ErrNotFound
+	}
+	row.user.PasswordHash.SetHash(user.PasswordHash.Hash())
+	row.user.UpdatedAt = s.db.now()
+	return nil
+}
+
+func (s *UserStore) GetUserToken(scope string, tokenPlainText string) (*store.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	hash := sha256.Sum256([]byte(tokenPlainText))
+	token := s.db.findToken(hash[:], scope)
+	if token == nil || !token.expiry.After(time.Now()) {
+		return nil, nil
+	}
+	row := s.db.liveUser(token.userID)
+	if row == nil {
+		return nil, nil
+	}
+	return copyUser(row.user), nil
+}
+
+// ! DeleteAccount --> soft delete plus the same cleanup the postgres transaction does
+func (s *UserStore) DeleteAccount(userID int64) (time.Time, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row := s.db.liveUser(int(userID))
+	if row == nil {
+		return time.Time{}, store.ErrNotFound
+	}
+	now := s.db.now()
+	row.deletedAt = &now
+	row.user.UpdatedAt = now
+	s.db.deleteTokens(func(t *tokenRow) bool { return t.userID == int(userID) })
+	s.db.anonymizeUser(int(userID))
+	for _, w := range s.db.workouts {
+		if w.workout.UserID == int(userID) {
+			w.workout.Visibility = store.VisibilityPrivate
+			s.db.recordChange(w.workout.ID, w.workout.UserID, false)
+			s.db.recordWarehouseChange("workouts", int64(w.workout.ID), false)
+		}
+	}
+	return now, nil
+}
+
+// ! PurgeDeletedUsers --> hard delete, everything owned by the user cascades
+func (s *UserStore) PurgeDeletedUsers(deletedBefore time.Time) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var purged int64
+	for id, row := range s.db.users {
+		if row.deletedAt != nil && row.deletedAt.Before(deletedBefore) {
+			s.db.purgeUser(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// ! TokenStore --> store.TokenStore on a DB
+type TokenStore struct {
+	db *DB
+}
+
+func NewTokenStore(db *DB) *TokenStore {
+	return &TokenStore{db: db}
+}
+
+// * findToken --> caller holds mu
+func (db *DB) findToken(hash []byte, scope string) *tokenRow {
+	for _, t := range db.tokens {
+		if t.scope == scope && string(t.hash) == string(hash) {
+			return t
+		}
+	}
+	return nil
+}
+
+// * deleteTokens --> caller holds mu, returns how many matched
+func (db *DB) deleteTokens(match func(*tokenRow) bool) int64 {
+	kept := db.tokens[:0]
+	var deleted int64
+	for _, t := range db.tokens {
+		if match(t) {
+			deleted++
+			continue
+		}
+		kept = append(kept, t)
+	}
+	db.tokens = kept
+	return deleted
+}
+
+func (s *TokenStore) CreateNewToken(userID int, ttl time.Duration, scope string) (*tokens.Token, error) {
+	token, err := tokens.GenerateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+	return token, s.Insert(token)
+}
+
+func (s *TokenStore) Insert(token *tokens.Token) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[token.UserID]; !ok {
+		return errForeignKey("tokens_user_id_fkey")
+	}
+	for _, t := range s.db.tokens {
+		if string(t.hash) == string(token.Hash) {
+			return errUnique("tokens_pkey")
+		}
+	}
+	s.db.tokens = append(s.db.tokens, &tokenRow{id: s.db.nextID("tokens"), hash: append([]byte(nil), token.Hash...), userID: token.UserID, expiry: token.Expiry, scope: token.Scope, createdAt: s.db.now()})
+	return nil
+}
+
+func (s *TokenStore) DeleteAllTokensForUser(userID int, scope string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.deleteTokens(func(t *tokenRow) bool { return t.userID == userID && t.scope == scope })
+	return nil
+}
+
+func (s *TokenStore) DeleteToken(scope string, tokenPlainText string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	hash := sha256.Sum256([]byte(tokenPlainText))
+	s.db.deleteTokens(func(t *tokenRow) bool { return t.scope == scope && string(t.hash) == string(hash[:]) })
+	return nil
+}
+
+func (s *TokenStore) DeleteExpiredTokens() (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := time.Now()
+	return s.db.deleteTokens(func(t *tokenRow) bool { return t.expiry.Before(now) }), nil
+}
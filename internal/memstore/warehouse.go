@@ -0,0 +1,184 @@
+package memstore
+
+import (
+	"fem/internal/store"
+	"sort"
+)
+
+// ! WarehouseStore --> store.WarehouseStore on a DB
+type WarehouseStore struct {
+	db *DB
+}
+
+func NewWarehouseStore(db *DB) *WarehouseStore {
+	return &WarehouseStore{db: db}
+}
+
+// * warehouseKey --> a warehouse_changes row's unique (table_name, row_id)
+type warehouseKey struct {
+	table string
+	id    int64
+}
+
+// * recordWarehouseChange --> what the warehouse_changes triggers do, caller holds mu
+// ? one writer, so seq order is commit order and Tx stays 0
+func (db *DB) recordWarehouseChange(table string, id int64, deleted bool) {
+	db.warehouseLog[warehouseKey{table, id}] = &store.ChangeMeta{Cursor: store.SyncCursor{Seq: db.nextID("warehouse_changes")}, Deleted: deleted}
+}
+
+// * recordWarehouseWorkout --> the workout row + its entries, caller holds mu
+func (db *DB) recordWarehouseWorkout(w store.Workout, deleted bool) {
+	db.recordWarehouseChange("workouts", int64(w.ID), deleted)
+	db.recordWarehouseEntries(w.Entries, deleted)
+}
+
+// * recordWarehouseEntries --> entries alone, an update deletes the old ones, caller holds mu
+func (db *DB) recordWarehouseEntries(entries []store.WorkoutEntry, deleted bool) {
+	for _, e := range entries {
+		db.recordWarehouseChange("workout_entries", int64(e.ID), deleted)
+	}
+}
+
+// * warehouseChange --> one warehouse_changes row of a table
+type warehouseChange struct {
+	id int64
+	store.ChangeMeta
+}
+
+// * warehousePage --> table's changes after the cursor, ORDER BY tx, seq then LIMIT, plus their row ids
+func (db *DB) warehousePage(table string, after store.SyncCursor, limit int) ([]warehouseChange, map[int64]bool) {
+	page := []warehouseChange{}
+	for key, change := range db.warehouseLog {
+		if key.table == table && change.Cursor.Seq > after.Seq {
+			page = append(page, warehouseChange{id: key.id, ChangeMeta: *change})
+		}
+	}
+	sort.Slice(page, func(i, j int) bool { return page[i].Cursor.Seq < page[j].Cursor.Seq })
+	page = page[:min(limit, len(page))]
+
+	ids := map[int64]bool{}
+	for _, change := range page {
+		ids[change.id] = true
+	}
+	return page, ids
+}
+
+// * byChange --> the page joined with the live rows, a change whose row is gone reads as deleted
+func byChange[T any](page []warehouseChange, live map[int64]*T, tombstone func(id int64) *T, meta func(*T) *store.ChangeMeta) []*T {
+	changes := make([]*T, 0, len(page))
+	for _, c := range page {
+		change, ok := live[c.id]
+		if c.Deleted || !ok {
+			change = tombstone(c.id)
+		}
+		*meta(change) = c.ChangeMeta
+		meta(change).Deleted = c.Deleted || !ok
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func (s *WarehouseStore) GetSyncCursor(name string) (store.SyncCursor, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.db.syncCursors[name], nil
+}
+
+func (s *WarehouseStore) SaveSyncCursor(name string, cursor store.SyncCursor) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.syncCursors[name] = cursor
+	return nil
+}
+
+func (s *WarehouseStore) ListWorkoutChanges(after store.SyncCursor, limit int) ([]*store.WorkoutChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	page, ids := s.db.warehousePage("workouts", after, limit)
+	live := map[int64]*store.WorkoutChange{}
+	for id := range ids {
+		row, ok := s.db.workouts[int(id)]
+		if !ok {
+			continue
+		}
+		w := row.workout
+		live[id] = &store.WorkoutChange{
+			ID:                int64(w.ID),
+			UserID:            int64(w.UserID),
+			Title:             w.Title,
+			DurationMinutes:   w.DurationMinutes,
+			CaloriesBurned:    w.CaloriesBurned,
+			CaloriesEstimated: w.CaloriesEstimated,
+			Flagged:           w.Flagged,
+			CreatedAt:         w.CreatedAt,
+			UpdatedAt:         row.updatedAt,
+		}
+	}
+	return byChange(page, live,
+		func(id int64) *store.WorkoutChange { return &store.WorkoutChange{ID: id} },
+		func(c *store.WorkoutChange) *store.ChangeMeta { return &c.ChangeMeta }), nil
+}
+
+func (s *WarehouseStore) ListEntryChanges(after store.SyncCursor, limit int) ([]*store.EntryChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	page, ids := s.db.warehousePage("workout_entries", after, limit)
+	live := map[int64]*store.EntryChange{}
+	for _, row := range s.db.workouts {
+		for _, e := range row.workout.Entries {
+			if ids[int64(e.ID)] {
+				live[int64(e.ID)] = &store.EntryChange{
+					ID:               int64(e.ID),
+					WorkoutID:        int64(row.workout.ID),
+					ExerciseName:     e.ExerciseName,
+					Sets:             e.Sets,
+					Reps:             e.Reps,
+					DurationSeconds:  e.DurationSeconds,
+					Weight:           e.Weight,
+					OrderIndex:       e.OrderIndex,
+					CreatedAt:        row.entryCreatedAt,
+					WorkoutUpdatedAt: row.updatedAt,
+				}
+			}
+		}
+	}
+	return byChange(page, live,
+		func(id int64) *store.EntryChange { return &store.EntryChange{ID: id} },
+		func(c *store.EntryChange) *store.ChangeMeta { return &c.ChangeMeta }), nil
+}
+
+func (s *WarehouseStore) ListWeightChanges(after store.SyncCursor, limit int) ([]*store.WeightChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	page, ids := s.db.warehousePage("user_weights", after, limit)
+	live := map[int64]*store.WeightChange{}
+	for _, w := range s.db.weights {
+		if ids[int64(w.ID)] {
+			live[int64(w.ID)] = &store.WeightChange{ID: int64(w.ID), UserID: int64(w.UserID), WeightKG: w.WeightKG, MeasuredAt: w.MeasuredAt, CreatedAt: w.createdAt}
+		}
+	}
+	return byChange(page, live,
+		func(id int64) *store.WeightChange { return &store.WeightChange{ID: id} },
+		func(c *store.WeightChange) *store.ChangeMeta { return &c.ChangeMeta }), nil
+}
+
+func (s *WarehouseStore) ListExposureChanges(after store.SyncCursor, limit int) ([]*store.ExposureChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	page, ids := s.db.warehousePage("experiment_exposures", after, limit)
+	live := map[int64]*store.ExposureChange{}
+	for key, e := range s.db.exposures {
+		if ids[e.id] {
+			live[e.id] = &store.ExposureChange{ID: e.id, UserID: int64(key.userID), Experiment: key.experiment, Variant: key.variant, ExposedAt: e.exposedAt}
+		}
+	}
+	return byChange(page, live,
+		func(id int64) *store.ExposureChange { return &store.ExposureChange{ID: id} },
+		func(c *store.ExposureChange) *store.ChangeMeta { return &c.ChangeMeta }), nil
+}
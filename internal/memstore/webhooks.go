@@ -0,0 +1,171 @@
+package memstore
+
+import (
+	"encoding/json"
+	"fem/internal/store"
+	"slices"
+	"sort"
+)
+
+// ! WebhookStore --> store.WebhookStore on a DB
+type WebhookStore struct {
+	db *DB
+}
+
+func NewWebhookStore(db *DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+func copyWebhook(w store.Webhook) *store.Webhook {
+	w.Events = append([]string(nil), w.Events...)
+	return &w
+}
+
+func copyDelivery(d store.WebhookDelivery) *store.WebhookDelivery {
+	d.Payload = append(json.RawMessage(nil), d.Payload...)
+	if d.ResponseStatus != nil {
+		status := *d.ResponseStatus
+		d.ResponseStatus = &status
+	}
+	if d.DeliveredAt != nil {
+		at := *d.DeliveredAt
+		d.DeliveredAt = &at
+	}
+	return &d
+}
+
+func (s *WebhookStore) CreateWebhook(w *store.Webhook) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[w.UserID]; !ok {
+		return errForeignKey("webhooks_user_id_fkey")
+	}
+	w.ID = s.db.nextID("webhooks")
+	w.CreatedAt = s.db.now()
+	s.db.webhooks[w.ID] = copyWebhook(*w)
+	return nil
+}
+
+func (s *WebhookStore) GetWebhook(id int64) (*store.Webhook, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	w, ok := s.db.webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyWebhook(*w), nil
+}
+
+// * listWebhooks --> ORDER BY id
+func (s *WebhookStore) listWebhooks(match func(*store.Webhook) bool) []*store.Webhook {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.Webhook{}
+	for _, w := range s.db.webhooks {
+		if match(w) {
+			list = append(list, copyWebhook(*w))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (s *WebhookStore) ListWebhooks(userID int) ([]*store.Webhook, error) {
+	return s.listWebhooks(func(w *store.Webhook) bool { return w.UserID == userID }), nil
+}
+
+func (s *WebhookStore) ListWebhooksForEvent(userID int, event string) ([]*store.Webhook, error) {
+	return s.listWebhooks(func(w *store.Webhook) bool { return w.UserID == userID && slices.Contains(w.Events, event) }), nil
+}
+
+func (s *WebhookStore) DeleteWebhook(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.webhooks[id]; !ok {
+		return store.ErrNotFound
+	}
+	s.db.deleteWebhook(id)
+	return nil
+}
+
+// * deleteWebhook --> the delivery log cascades, caller holds mu
+func (db *DB) deleteWebhook(id int64) {
+	delete(db.webhooks, id)
+	for did, d := range db.deliveries {
+		if d.WebhookID == id {
+			delete(db.deliveries, did)
+		}
+	}
+}
+
+func (s *WebhookStore) CreateDelivery(d *store.WebhookDelivery) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.webhooks[d.WebhookID]; !ok {
+		return errForeignKey("webhook_deliveries_webhook_id_fkey")
+	}
+	d.ID = s.db.nextID("webhook_deliveries")
+	d.Status = store.DeliveryPending
+	d.Attempts = 0
+	d.CreatedAt = s.db.now()
+	s.db.deliveries[d.ID] = copyDelivery(*d)
+	return nil
+}
+
+func (s *WebhookStore) GetDelivery(id int64) (*store.WebhookDelivery, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	d, ok := s.db.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyDelivery(*d), nil
+}
+
+func (s *WebhookStore) ListDeliveries(webhookID int64, limit int) ([]*store.WebhookDelivery, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	list := []*store.WebhookDelivery{}
+	for _, d := range s.db.deliveries {
+		if d.WebhookID == webhookID {
+			list = append(list, copyDelivery(*d))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return page(list, 0, limit), nil
+}
+
+func (s *WebhookStore) RecordDeliveryAttempt(id int64, status string, responseStatus *int, lastError string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	d, ok := s.db.deliveries[id]
+	if !ok {
+		return nil
+	}
+	d.Status = status
+	d.Attempts++
+	d.ResponseStatus = nil
+	if responseStatus != nil {
+		code := *responseStatus
+		d.ResponseStatus = &code
+	}
+	d.LastError = lastError
+	if status == store.DeliverySucceeded {
+		now := s.db.now()
+		d.DeliveredAt = &now
+	}
+	return nil
+}
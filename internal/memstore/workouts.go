@@ -0,0 +1,351 @@
+package memstore
+
+import (
+	"fem/internal/events"
+	"fem/internal/store"
+	"fmt"
+	"sort"
+)
+
+// ! errUnique / errCheck --> what postgres would refuse, worded like its messages
+// ? unique + foreign key violations match store.ErrConflict / store.ErrForeignKey like the postgres stores' errors
+func errUnique(constraint string) error {
+	return &store.ConstraintError{Kind: store.ErrConflict, Constraint: constraint,
+		Err: fmt.Errorf("memstore: duplicate key value violates unique constraint %q", constraint)}
+}
+
+func errForeignKey(constraint string) error {
+	return &store.ConstraintError{Kind: store.ErrForeignKey, Constraint: constraint,
+		Err: fmt.Errorf("memstore: insert or update violates foreign key constraint %q", constraint)}
+}
+
+func errCheck(constraint string) error {
+	return fmt.Errorf("memstore: new row violates check constraint %q", constraint)
+}
+
+// ! WorkoutStore --> store.WorkoutStore on a DB
+type WorkoutStore struct {
+	db *DB
+}
+
+func NewWorkoutStore(db *DB) *WorkoutStore {
+	return &WorkoutStore{db: db}
+}
+
+// * copyWorkout --> callers get their own entries slice, like a fresh scan
+func copyWorkout(w store.Workout) *store.Workout {
+	w.Entries = append([]store.WorkoutEntry(nil), w.Entries...)
+	w.Tags = nil //* tags live in db.tags like the workout_tags table
+	return &w
+}
+
+// * validEntries --> the valid_workout_entry CHECK: reps or duration, never both
+func validEntries(entries []store.WorkoutEntry) error {
+	for _, e := range entries {
+		if (e.Reps == nil) == (e.DurationSeconds == nil) {
+			return errCheck("valid_workout_entry")
+		}
+	}
+	return nil
+}
+
+// * insertWorkout --> caller holds mu, store.ErrNotFound for an external activity synced before
+func (db *DB) insertWorkout(workout *store.Workout, ref *store.ExternalRef) error {
+	if _, ok := db.users[workout.UserID]; !ok {
+		return errForeignKey("workouts_user_id_fkey")
+	}
+	if err := validEntries(workout.Entries); err != nil {
+		return err
+	}
+	if ref != nil {
+		for _, row := range db.workouts {
+			if row.workout.UserID == workout.UserID && row.externalSource == ref.Source && row.externalID == ref.ID {
+				return store.ErrNotFound
+			}
+		}
+	}
+
+	now := db.now()
+	workout.ID = int(db.nextID("workouts"))
+	if workout.Visibility == "" {
+		workout.Visibility = store.VisibilityPrivate
+	}
+	workout.Verified = false
+	if workout.CreatedAt.IsZero() {
+		workout.CreatedAt = now
+	}
+	if workout.PerformedAt.IsZero() {
+		workout.PerformedAt = workout.CreatedAt
+	}
+	for i := range workout.Entries {
+		workout.Entries[i].ID = int(db.nextID("workout_entries"))
+	}
+	row := &workoutRow{workout: *copyWorkout(*workout), updatedAt: now, entryCreatedAt: now}
+	if ref != nil {
+		row.externalSource, row.externalID = ref.Source, ref.ID
+	}
+	db.workouts[workout.ID] = row
+	db.recordChange(workout.ID, workout.UserID, false)
+	db.recordWarehouseWorkout(row.workout, false)
+	return nil
+}
+
+func (s *WorkoutStore) CreateWorkout(workout *store.Workout) (*store.Workout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	err := s.db.insertWorkout(workout, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.db.enqueueEvent(events.WorkoutCreated, workout.ID, workout.UserID)
+	return workout, nil
+}
+
+func (s *WorkoutStore) ImportWorkouts(workouts []*store.Workout) ([]error, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	rowErrs := make([]error, len(workouts))
+	for i, workout := range workouts {
+		rowErrs[i] = s.db.insertWorkout(workout, nil)
+		if rowErrs[i] != nil {
+			workout.ID = 0
+		}
+	}
+	return rowErrs, nil
+}
+
+func (s *WorkoutStore) CreateExternalWorkout(workout *store.Workout, ref store.ExternalRef) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	err := s.db.insertWorkout(workout, &ref)
+	if err == store.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s.db.enqueueEvent(events.WorkoutCreated, workout.ID, workout.UserID)
+	return true, nil
+}
+
+func (s *WorkoutStore) GetWorkoutByID(id int64) (*store.Workout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.workouts[int(id)]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	workout := copyWorkout(row.workout)
+	sort.SliceStable(workout.Entries, func(i, j int) bool { return workout.Entries[i].OrderIndex < workout.Entries[j].OrderIndex })
+	return workout, nil
+}
+
+// ! UpdateWorkout --> entries replaced, verification goes back to pending like in postgres
+func (s *WorkoutStore) UpdateWorkout(workout *store.Workout) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	err := s.db.updateWorkout(workout)
+	if err == nil {
+		s.db.enqueueUpdated(workout.ID)
+	}
+	return err
+}
+
+// * updateWorkout --> caller holds mu
+func (db *DB) updateWorkout(workout *store.Workout) error {
+	row, ok := db.workouts[workout.ID]
+	if !ok {
+		return nil //* postgres UPDATE of a missing row is not an error either
+	}
+	if err := validEntries(workout.Entries); err != nil {
+		return err
+	}
+	for i := range workout.Entries {
+		workout.Entries[i].ID = int(db.nextID("workout_entries"))
+	}
+	workout.Verified = false
+
+	stored := copyWorkout(*workout)
+	stored.UserID, stored.CreatedAt = row.workout.UserID, row.workout.CreatedAt
+	if stored.PerformedAt.IsZero() {
+		stored.PerformedAt = row.workout.PerformedAt
+	}
+	db.recordWarehouseEntries(row.workout.Entries, true) //* the DELETE FROM workout_entries
+	row.workout = *stored
+	row.updatedAt = db.now()
+	row.entryCreatedAt = row.updatedAt
+	db.resetVerification(workout.ID)
+	db.recordChange(workout.ID, stored.UserID, false)
+	db.recordWarehouseWorkout(row.workout, false)
+	return nil
+}
+
+func (s *WorkoutStore) DeleteWorkout(id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[int(id)]; !ok {
+		return store.ErrNotFound
+	}
+	s.db.enqueueEvent(events.WorkoutDeleted, int(id), s.db.workouts[int(id)].workout.UserID)
+	s.db.deleteWorkout(int(id))
+	return nil
+}
+
+// ! BatchWorkouts --> store.WorkoutStore's contract; there's no rollback in memory, so an atomic batch
+// ? checks every op up front (against what the earlier ones leave behind) and runs none when one would fail
+func (s *WorkoutStore) BatchWorkouts(ops []store.WorkoutOp, atomic bool) ([]error, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	opErrs := make([]error, len(ops))
+	if atomic {
+		deleted := map[int]bool{}
+		for i, op := range ops {
+			opErrs[i] = s.db.checkWorkoutOp(op, deleted)
+			if opErrs[i] != nil {
+				for j, other := range ops {
+					if j != i {
+						opErrs[j] = store.ErrBatchAborted
+					}
+					if other.Op == store.WorkoutOpCreate {
+						other.Workout.ID = 0
+					}
+				}
+				return opErrs, nil
+			}
+		}
+	}
+
+	for i, op := range ops {
+		switch op.Op {
+		case store.WorkoutOpCreate:
+			opErrs[i] = s.db.insertWorkout(op.Workout, nil)
+			if opErrs[i] != nil {
+				op.Workout.ID = 0
+				continue
+			}
+			if op.SetTags {
+				s.db.replaceWorkoutTags(op.Workout.ID, op.Workout.Tags)
+			}
+			s.db.enqueueEvent(events.WorkoutCreated, op.Workout.ID, op.Workout.UserID)
+		case store.WorkoutOpUpdate:
+			opErrs[i] = s.db.checkWorkoutTags(op)
+			if opErrs[i] == nil {
+				opErrs[i] = s.db.updateWorkout(op.Workout)
+			}
+			if opErrs[i] == nil {
+				if op.SetTags {
+					s.db.replaceWorkoutTags(op.Workout.ID, op.Workout.Tags)
+				}
+				s.db.enqueueUpdated(op.Workout.ID)
+			}
+		case store.WorkoutOpDelete:
+			row, ok := s.db.workouts[op.Workout.ID]
+			if !ok {
+				opErrs[i] = store.ErrNotFound
+				continue
+			}
+			s.db.enqueueEvent(events.WorkoutDeleted, op.Workout.ID, row.workout.UserID)
+			s.db.deleteWorkout(op.Workout.ID)
+		default:
+			opErrs[i] = fmt.Errorf("memstore: unknown workout op %q", op.Op)
+		}
+	}
+	return opErrs, nil
+}
+
+// * checkWorkoutOp --> the error op would fail with, deleted collects what earlier ops of the batch removed
+func (db *DB) checkWorkoutOp(op store.WorkoutOp, deleted map[int]bool) error {
+	_, exists := db.workouts[op.Workout.ID]
+	exists = exists && !deleted[op.Workout.ID]
+	switch op.Op {
+	case store.WorkoutOpCreate:
+		if _, ok := db.users[op.Workout.UserID]; !ok {
+			return errForeignKey("workouts_user_id_fkey")
+		}
+		return validEntries(op.Workout.Entries)
+	case store.WorkoutOpUpdate:
+		if !exists && op.SetTags && len(op.Workout.Tags) > 0 {
+			return errForeignKey("workout_tags_workout_id_fkey")
+		}
+		if !exists {
+			return nil
+		}
+		return validEntries(op.Workout.Entries)
+	case store.WorkoutOpDelete:
+		if !exists {
+			return store.ErrNotFound
+		}
+		deleted[op.Workout.ID] = true
+		return nil
+	}
+	return fmt.Errorf("memstore: unknown workout op %q", op.Op)
+}
+
+// * checkWorkoutTags --> the workout_tags foreign key, tags for an update that matched no workout
+func (db *DB) checkWorkoutTags(op store.WorkoutOp) error {
+	if _, ok := db.workouts[op.Workout.ID]; !ok && op.SetTags && len(op.Workout.Tags) > 0 {
+		return errForeignKey("workout_tags_workout_id_fkey")
+	}
+	return nil
+}
+
+func (s *WorkoutStore) GetWorkoutOwner(id int64) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.workouts[int(id)]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	return row.workout.UserID, nil
+}
+
+// * deleteWorkout --> the ON DELETE CASCADEs hanging off workouts, caller holds mu
+func (db *DB) deleteWorkout(id int) {
+	if row, ok := db.workouts[id]; ok {
+		db.recordChange(id, row.workout.UserID, true)
+		db.recordWarehouseWorkout(row.workout, true)
+	}
+	delete(db.workouts, id)
+	db.cascadeWorkout(id)
+}
+
+// ! UpsertWorkout --> store.ReplicaWorkoutStore, the id comes from the primary and the sequence is left alone
+func (s *WorkoutStore) UpsertWorkout(workout *store.Workout) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[workout.UserID]; !ok {
+		return errForeignKey("workouts_user_id_fkey")
+	}
+	if err := validEntries(workout.Entries); err != nil {
+		return err
+	}
+	now := s.db.now()
+	stored := copyWorkout(*workout)
+	if stored.PerformedAt.IsZero() {
+		stored.PerformedAt = stored.CreatedAt
+	}
+	for i := range stored.Entries {
+		stored.Entries[i].ID = int(s.db.nextID("workout_entries"))
+	}
+	s.db.recordChange(workout.ID, workout.UserID, false)
+	row, ok := s.db.workouts[workout.ID]
+	if !ok {
+		s.db.workouts[workout.ID] = &workoutRow{workout: *stored, updatedAt: now, entryCreatedAt: now}
+		s.db.recordWarehouseWorkout(*stored, false)
+		return nil
+	}
+	s.db.recordWarehouseEntries(row.workout.Entries, true)
+	s.db.recordWarehouseWorkout(*stored, false)
+	row.workout = *stored
+	row.updatedAt, row.entryCreatedAt = now, now
+	return nil
+}
@@ -0,0 +1,66 @@
+// ! package metrics --> GET /metrics in the Prometheus text format, values are read when scraped
+// ? no client library on purpose, everything exported is a gauge or counter computed from state we already keep
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	TypeGauge   = "gauge"
+	TypeCounter = "counter"
+)
+
+type metric struct {
+	name, help, kind string
+	value            func() float64
+}
+
+// ! Registry --> register during startup, serve with ServeHTTP
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]metric{}}
+}
+
+// ! Gauge --> value can go up and down (open connections)
+func (r *Registry) Gauge(name, help string, value func() float64) {
+	r.register(metric{name: name, help: help, kind: TypeGauge, value: value})
+}
+
+// ! Counter --> value only grows while the process lives (total waits)
+func (r *Registry) Counter(name, help string, value func() float64) {
+	r.register(metric{name: name, help: help, kind: TypeCounter, value: value})
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[m.name]; exists {
+		panic("metrics: duplicate metric " + m.name)
+	}
+	r.metrics[m.name] = m
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	metrics := make([]metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.Unlock()
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name < metrics[j].name })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", m.name, m.help, m.name, m.kind, m.name,
+			strconv.FormatFloat(m.value(), 'g', -1, 64))
+	}
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("db_in_use", "Connections in use.", func() float64 { return 3 })
+	r.Counter("db_wait_seconds_total", "Time spent waiting.", func() float64 { return 0.25 })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, "# HELP db_in_use Connections in use.\n# TYPE db_in_use gauge\ndb_in_use 3\n"+
+		"# HELP db_wait_seconds_total Time spent waiting.\n# TYPE db_wait_seconds_total counter\ndb_wait_seconds_total 0.25\n",
+		rec.Body.String())
+	assert.Panics(t, func() { r.Gauge("db_in_use", "again", func() float64 { return 0 }) })
+}
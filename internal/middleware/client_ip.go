@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const ClientIPContextKey = contextKey("client_ip")
+
+//! TrustedProxies --> the hops allowed to tell us who the client is (X-Forwarded-For / X-Real-IP)
+//? headers from anyone else are ignored, otherwise every caller could pick its own IP and dodge rate limits + lockouts
+type TrustedProxies struct {
+	nets []*net.IPNet
+	unix bool //* "unix" in the list, the peer of a unix socket (LISTEN=unix:...) is a local proxy
+}
+
+//! ParseTrustedProxies --> comma separated IPs + CIDRs, e.g. "10.0.0.0/8,127.0.0.1,unix"; "" trusts nobody
+func ParseTrustedProxies(spec string) (*TrustedProxies, error) {
+	t := &TrustedProxies{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+			continue
+		case part == "unix":
+			t.unix = true
+		case strings.Contains(part, "/"):
+			_, ipNet, err := net.ParseCIDR(part)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+			}
+			t.nets = append(t.nets, ipNet)
+		default:
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: %q is not an IP or CIDR", part)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			t.nets = append(t.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return t, nil
+}
+
+func (t *TrustedProxies) trusts(ip net.IP) bool {
+	for _, ipNet := range t.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+//! Resolve --> the client IP for r: the peer itself unless it's a trusted proxy
+//? X-Forwarded-For is read right to left, the first hop we don't trust is the client (left of it is client controlled)
+func (t *TrustedProxies) Resolve(r *http.Request) string {
+	peer := remoteHost(r.RemoteAddr)
+	peerIP := net.ParseIP(peer)
+	if peerIP == nil && !t.unix || peerIP != nil && !t.trusts(peerIP) {
+		return peer
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break //* garbage in the chain, trust nothing left of it
+			}
+			client = ip.String()
+			if !t.trusts(ip) {
+				return client
+			}
+		}
+		if client != "" {
+			return client //* every hop was a proxy, the leftmost is as close to the client as it gets
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return peer
+}
+
+//! Middleware --> root middleware, puts the resolved client IP on the request context for ClientIP
+func (t *TrustedProxies) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ClientIPContextKey, t.Resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+//! ClientIP --> the caller's IP as resolved by TrustedProxies.Middleware, the peer address when it didn't run
+//? the one source for rate limits, login lockouts, sessions + the audit log, so they all agree on who called
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+//* remoteHost --> host part of a RemoteAddr, unchanged when it has no port (unix sockets)
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedProxiesResolve(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 127.0.0.1")
+	require.NoError(t, err)
+
+	request := func(remoteAddr string, headers map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/workouts", nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return req
+	}
+
+	//* untrusted peer --> its headers are ignored
+	assert.Equal(t, "203.0.113.9", proxies.Resolve(request("203.0.113.9:5000", map[string]string{"X-Forwarded-For": "1.2.3.4"})))
+	//* right to left, stops at the first hop that isn't a proxy (the spoofed entry left of it is ignored)
+	assert.Equal(t, "198.51.100.7", proxies.Resolve(request("10.1.2.3:5000", map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.7, 10.0.0.5"})))
+	assert.Equal(t, "198.51.100.7", proxies.Resolve(request("127.0.0.1:5000", map[string]string{"X-Real-IP": "198.51.100.7"})))
+	assert.Equal(t, "10.1.2.3", proxies.Resolve(request("10.1.2.3:5000", nil)))
+	//* unix socket peers are only trusted when "unix" is listed
+	assert.Equal(t, "@", proxies.Resolve(request("@", map[string]string{"X-Real-IP": "198.51.100.7"})))
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies("proxy.internal")
+	assert.Error(t, err)
+}
+
+func TestClientIPFallsBackToPeer(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/workouts", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "192.0.2.1", ClientIP(req))
+
+	proxies, err := ParseTrustedProxies("unix")
+	require.NoError(t, err)
+	req.RemoteAddr = "@"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	var seen string
+	proxies.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { seen = ClientIP(r) })).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "198.51.100.7", seen)
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fem/internal/clientusage"
+	"fem/internal/store"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//! maxClientLabel --> headers are client controlled, long values would blow up the counter table
+const maxClientLabel = 64
+
+//! ClientUsageMiddleware --> counts requests per client build and route pattern
+type ClientUsageMiddleware struct {
+	Recorder *clientusage.Recorder //* buffers counts, flushed to client_usage in the background
+}
+
+//! clientLabel --> trimmed + capped header value
+func clientLabel(r *http.Request, header string) string {
+	value := strings.TrimSpace(r.Header.Get(header))
+	if len(value) > maxClientLabel {
+		value = value[:maxClientLabel]
+	}
+	return value
+}
+
+//! Track --> must sit on the root router, the route pattern is only known once chi has routed
+//? client id comes from X-Client-ID, falling back to X-Client-Platform, "unknown" for everything else
+func (cm *ClientUsageMiddleware) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil || rctx.RoutePattern() == "" {
+			return //* unmatched paths (404s) say nothing about endpoint usage
+		}
+
+		clientID := clientLabel(r, "X-Client-ID")
+		if clientID == "" {
+			clientID = strings.ToLower(clientLabel(r, "X-Client-Platform"))
+		}
+		if clientID == "" {
+			clientID = "unknown"
+		}
+
+		cm.Recorder.Record(clientusage.Key{
+			ClientID:      clientID,
+			ClientVersion: clientLabel(r, "X-Client-Version"),
+			Method:        r.Method,
+			Route:         rctx.RoutePattern(),
+		}, time.Now())
+	})
+}
+
+//! TrackUser --> user pipeline stage after authenticate, counts the caller's requests for GET /users/me/usage
+//? anonymous requests (missing or bad token) aren't anyone's usage
+func (cm *ClientUsageMiddleware) TrackUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(UserContextKey).(*store.User)
+		if ok && !user.IsAnonymousUser() {
+			cm.Recorder.RecordUser(user.ID, time.Now())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
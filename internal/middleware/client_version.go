@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"fem/internal/clientconfig"
+	"fem/internal/utils"
+	"net/http"
+	"strings"
+)
+
+//! ClientVersionMiddleware --> turns away app builds older than the configured minimum
+type ClientVersionMiddleware struct {
+	Config *clientconfig.Config //* min_version floor + per-platform policies
+	Exempt []string             //* paths an outdated app still needs, e.g. /client-config to learn it must upgrade
+}
+
+//! RequireMinVersion --> 426 Upgrade Required for clients below min_version
+//? only clients that send X-Client-Version are checked, browsers + scripts pass through untouched
+func (cm *ClientVersionMiddleware) RequireMinVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := strings.TrimSpace(r.Header.Get("X-Client-Version"))
+		if version == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for _, path := range cm.Exempt {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		platform := strings.ToLower(strings.TrimSpace(r.Header.Get("X-Client-Platform")))
+		upgrade := cm.Config.UpgradeFor(platform, version)
+		if upgrade == nil || !upgrade.Required {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		//* same upgrade block /client-config returns, so apps can share the handling
+		utils.WriteJson(w, http.StatusUpgradeRequired, utils.Envelope{
+			"error":   "client version is no longer supported, please update the app",
+			"upgrade": upgrade,
+		})
+	})
+}
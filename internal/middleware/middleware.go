@@ -3,17 +3,22 @@ package middleware
 // importing packages
 import (
 	"context"
+	"crypto/sha256"
 	"fem/internal/store"
 	"fem/internal/tokens"
 	"fem/internal/utils"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
 //! type declaration
 type contextKey string //* custom type for context keys to avoid collisions
 type UserMiddleware struct {
 	UserStore store.UserStore //* needed to fetch user from token
+	Sessions store.SessionStore //* records where each token was last used, nil records nothing
+	Touches *SessionTouches //* skips session writes inside SessionTouchInterval, nil writes on every request
 }
 
 
@@ -21,6 +26,56 @@ type UserMiddleware struct {
 //? using custom type prevents accidental key conflicts with other middleware
 const UserContextKey = contextKey("user") 
 
+//! maxSessionUserAgent --> client controlled, capped before it is stored with the session
+const maxSessionUserAgent = 512
+
+//! maxSessionTouches --> tokens remembered by SessionTouches before stale ones are dropped
+const maxSessionTouches = 100000
+
+//! SessionTouches --> when this process last wrote each token's session, so most requests never reach the store
+//? per process, the store still filters on SessionTouchInterval for writes racing in from other replicas
+type SessionTouches struct {
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]sessionTouch //* keyed by token hash, plaintext tokens aren't kept around
+}
+
+type sessionTouch struct {
+	at time.Time
+	ip string
+}
+
+func NewSessionTouches() *SessionTouches {
+	return &SessionTouches{seen: map[[sha256.Size]byte]sessionTouch{}}
+}
+
+//! due --> true when token's session needs a write at now, same rule as the store (interval passed or IP changed)
+func (st *SessionTouches) due(token,ip string,now time.Time) bool {
+	key := sha256.Sum256([]byte(token))
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	last,ok := st.seen[key]
+	if ok && last.ip == ip && now.Sub(last.at) < store.SessionTouchInterval {
+		return false
+	}
+	if !ok && len(st.seen) >= maxSessionTouches {
+		st.prune(now)
+	}
+	st.seen[key] = sessionTouch{at: now,ip: ip}
+	return true
+}
+
+//! prune --> drops touches older than the interval, all of them if that frees nothing (costs one extra write each)
+func (st *SessionTouches) prune(now time.Time) {
+	for key,touch := range st.seen {
+		if now.Sub(touch.at) >= store.SessionTouchInterval {
+			delete(st.seen,key)
+		}
+	}
+	if len(st.seen) >= maxSessionTouches {
+		clear(st.seen)
+	}
+}
+
 
 
 //! SetUser --> injects user into request context for downstream handlers
@@ -84,13 +139,32 @@ func (um *UserMiddleware) Authenticate(next http.Handler) http.Handler {
 			utils.WriteJson(w,http.StatusUnauthorized,utils.Envelope{"error":"token has been expired or invalid"})
 			return
 		}
-		//* valid token! attach authenticated user to request context
+		//* valid token! note where it was used from for GET /users/me/sessions
+		um.touchSession(r,token)
+		//* attach authenticated user to request context
 		r = SetUser(r,user)
 		next.ServeHTTP(w,r) //* call next handler with authenticated user
 	})
 }
 
 
+//! touchSession --> best effort, a failed write never fails the request it rode on
+func (um *UserMiddleware) touchSession(r *http.Request,token string) {
+	if um.Sessions == nil {
+		return
+	}
+	ip := ClientIP(r)
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxSessionUserAgent {
+		userAgent = userAgent[:maxSessionUserAgent]
+	}
+	now := time.Now()
+	if um.Touches != nil && !um.Touches.due(token,ip,now) {
+		return //* written recently from this IP, the store would skip it anyway
+	}
+	_ = um.Sessions.TouchSession(token,ip,userAgent,now)
+}
+
 //! RequireUser --> ensures user is authenticated (not anonymous)
 //! Must be used after Authenticate middleware
 func (um *UserMiddleware) RequireUser(next http.HandlerFunc) http.HandlerFunc {
@@ -106,4 +180,17 @@ func (um *UserMiddleware) RequireUser(next http.HandlerFunc) http.HandlerFunc {
 		//* user is authenticated, proceed to handler
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+//! RequireAdmin --> like RequireUser but only lets admins through
+//! Must be used after Authenticate middleware
+func (um *UserMiddleware) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return um.RequireUser(func(w http.ResponseWriter, r *http.Request) {
+		if !GetUser(r).IsAdmin {
+			//? logged in, just not allowed here
+			utils.WriteJson(w, http.StatusForbidden, utils.Envelope{"error": "admin access required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! BenchmarkAuthenticate --> header parsing, token hash + lookup and the session touch every user route pays
+// ? the memory store keeps the database out of the numbers, what's left is the middleware's own cost
+func BenchmarkAuthenticate(b *testing.B) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	user := &store.User{Username: "bench", Email: "bench@example.com"}
+	if err := users.CreateUser(user); err != nil {
+		b.Fatal(err)
+	}
+	token, err := memstore.NewTokenStore(db).CreateNewToken(user.ID, time.Hour, tokens.ScopeAuth)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	um := &UserMiddleware{UserStore: users, Sessions: memstore.NewSessionStore(db)}
+	handler := um.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetUser(r).ID != user.ID {
+			b.Fatal("wrong user")
+		}
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/v1/workouts", nil)
+	req.Header.Set("Authorization", "Bearer "+token.Plaintext)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("status %d", w.Code)
+		}
+	}
+}
+
+// * countingSessionStore --> counts the session writes that reach the store
+type countingSessionStore struct {
+	store.SessionStore
+	touches int
+}
+
+func (s *countingSessionStore) TouchSession(token, ip, userAgent string, at time.Time) error {
+	s.touches++
+	return s.SessionStore.TouchSession(token, ip, userAgent, at)
+}
+
+// ! TestAuthenticateThrottlesSessionTouch --> repeat requests inside SessionTouchInterval don't reach the store, a new IP does
+func TestAuthenticateThrottlesSessionTouch(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, users.CreateUser(user))
+	token, err := memstore.NewTokenStore(db).CreateNewToken(user.ID, time.Hour, tokens.ScopeAuth)
+	require.NoError(t, err)
+
+	sessions := &countingSessionStore{SessionStore: memstore.NewSessionStore(db)}
+	um := &UserMiddleware{UserStore: users, Sessions: sessions, Touches: NewSessionTouches()}
+	handler := um.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(remoteAddr string) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/workouts", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+token.Plaintext)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	for range 5 {
+		call("10.0.0.1:1234")
+	}
+	assert.Equal(t, 1, sessions.touches)
+	call("10.0.0.2:1234")
+	assert.Equal(t, 2, sessions.touches, "a new IP is written right away")
+
+	// * once the interval has passed the next request writes again
+	now := time.Now()
+	touches := NewSessionTouches()
+	assert.True(t, touches.due(token.Plaintext, "10.0.0.1", now))
+	assert.False(t, touches.due(token.Plaintext, "10.0.0.1", now.Add(store.SessionTouchInterval-time.Second)))
+	assert.True(t, touches.due(token.Plaintext, "10.0.0.1", now.Add(store.SessionTouchInterval)))
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"net/http"
+	"strings"
+)
+
+//! SCIMMiddleware --> authenticates identity providers calling the SCIM API
+type SCIMMiddleware struct {
+	OrgStore store.OrgStore //* needed to resolve the org from its SCIM token
+}
+
+//! OrgContextKey --> key for storing the provisioning org in request context
+const OrgContextKey = contextKey("org")
+
+//! SetOrg --> injects the authenticated org into request context
+func SetOrg(r *http.Request, org *store.Org) *http.Request {
+	contxt := context.WithValue(r.Context(), OrgContextKey, org)
+	return r.WithContext(contxt)
+}
+
+//! GetOrg --> extracts the org set by SCIMMiddleware.Authenticate
+func GetOrg(r *http.Request) *store.Org {
+	org, ok := r.Context().Value(OrgContextKey).(*store.Org)
+	if !ok {
+		panic("missing org in request") //* SCIM handlers must run behind Authenticate
+	}
+	return org
+}
+
+//! Authenticate --> validates the org's SCIM bearer token, rejecting anything else
+//? unlike user auth there is no anonymous fallback, every SCIM call needs a token
+func (sm *SCIMMiddleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		headerParts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+			utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "invalid authorization header"})
+			return
+		}
+
+		org, err := sm.OrgStore.GetOrgBySCIMToken(headerParts[1])
+		if err != nil || org == nil {
+			utils.WriteJson(w, http.StatusUnauthorized, utils.Envelope{"error": "invalid token"})
+			return
+		}
+
+		r = SetOrg(r, org)
+		next.ServeHTTP(w, r)
+	})
+}
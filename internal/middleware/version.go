@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fem/internal/utils"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ! APIVersion --> the version served under /v1, bump together with a new /vN mount
+const APIVersion = "1"
+
+// ! VersionMiddleware --> path versioning (/v1/...) with Accept header negotiation for unprefixed routes
+// ? clients pick a version with the path, or with Accept: application/vnd.fittrack.v1+json on the old paths
+type VersionMiddleware struct {
+	DeprecatedAt time.Time //* when the unprefixed routes were deprecated
+	Sunset       time.Time //* when they go away, zero leaves the Sunset header off
+}
+
+// ! RequestedVersion --> "2" for Accept: application/vnd.fittrack.v2+json, "" when no version is named
+func RequestedVersion(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		version, ok := strings.CutPrefix(mediaType, "application/vnd.fittrack.v")
+		if !ok {
+			continue
+		}
+		version = strings.TrimSuffix(version, "+json")
+		if version != "" {
+			return version
+		}
+	}
+	return ""
+}
+
+// ! Versioned --> routes mounted under /vN, a conflicting Accept version is a 406
+func (vm VersionMiddleware) Versioned(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested := RequestedVersion(r.Header.Get("Accept"))
+			if requested != "" && requested != version {
+				utils.WriteJson(w, http.StatusNotAcceptable, utils.Envelope{"error": fmt.Sprintf("API version %s is not served at this path", requested)})
+				return
+			}
+			w.Header().Set("API-Version", version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ! Legacy --> unprefixed routes, they answer as v1 but carry Deprecation + Sunset headers pointing at /v1
+// ? a client that negotiated v1 through Accept already made the switch, it gets no deprecation headers
+func (vm VersionMiddleware) Legacy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested := RequestedVersion(r.Header.Get("Accept"))
+		switch {
+		case requested == APIVersion:
+		case requested != "":
+			utils.WriteJson(w, http.StatusNotAcceptable, utils.Envelope{"error": fmt.Sprintf("unsupported API version %s", requested)})
+			return
+		default:
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", vm.DeprecatedAt.Unix())) //* RFC 9745
+			if !vm.Sunset.IsZero() {
+				w.Header().Set("Sunset", vm.Sunset.UTC().Format(http.TimeFormat)) //* RFC 8594
+			}
+			w.Header().Add("Link", fmt.Sprintf(`</v%s%s>; rel="successor-version"`, APIVersion, r.URL.Path))
+		}
+		w.Header().Set("API-Version", APIVersion)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestedVersion(t *testing.T) {
+	assert.Equal(t, "2", RequestedVersion("application/vnd.fittrack.v2+json"))
+	assert.Equal(t, "1", RequestedVersion("text/html, application/vnd.fittrack.v1+json; q=0.9"))
+	assert.Equal(t, "", RequestedVersion("application/json"))
+	assert.Equal(t, "", RequestedVersion(""))
+}
+
+func TestLegacy(t *testing.T) {
+	vm := VersionMiddleware{DeprecatedAt: time.Unix(1700000000, 0), Sunset: time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)}
+	handler := vm.Legacy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workouts/5", nil))
+	assert.Equal(t, "@1700000000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/workouts/5>; rel="successor-version"`, w.Header().Get("Link"))
+
+	//* negotiated v1 through Accept --> no deprecation
+	req := httptest.NewRequest(http.MethodGet, "/workouts/5", nil)
+	req.Header.Set("Accept", "application/vnd.fittrack.v1+json")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Equal(t, "1", w.Header().Get("API-Version"))
+
+	req.Header.Set("Accept", "application/vnd.fittrack.v3+json")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
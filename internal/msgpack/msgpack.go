@@ -0,0 +1,143 @@
+// ! package msgpack --> MessagePack (https://msgpack.org/) encoding of JSON-shaped values, for bandwidth-tight clients
+// ? only what a decoded JSON document holds: nil, bool, json.Number / float64, string, []any, map[string]any;
+// ? map keys are written sorted so the same response always gets the same bytes (ETags, caches)
+package msgpack
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// ! Encode --> v as MessagePack into w, integers in their smallest form
+func Encode(w io.Writer, v any) error {
+	e := encoder{}
+	err := e.value(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(e.buf)
+	return err
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) value(v any) error {
+	switch value := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if value {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			e.int(n)
+			return nil
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return fmt.Errorf("msgpack: number %q: %w", value, err)
+		}
+		e.float(f)
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			e.int(int64(value))
+			return nil
+		}
+		e.float(value)
+	case int:
+		e.int(int64(value))
+	case int64:
+		e.int(value)
+	case string:
+		e.str(value)
+	case []any:
+		e.header(len(value), 0x90, 0xdc, 0xdd)
+		for _, item := range value {
+			err := e.value(item)
+			if err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		e.header(len(keys), 0x80, 0xde, 0xdf)
+		for _, key := range keys {
+			e.str(key)
+			err := e.value(value[key])
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+func (e *encoder) int(n int64) {
+	switch {
+	case n >= 0 && n <= 0x7f:
+		e.buf = append(e.buf, byte(n)) //* positive fixint
+	case n >= -32 && n < 0:
+		e.buf = append(e.buf, byte(0xe0|(n+32))) //* negative fixint
+	case n >= 0 && n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(n))
+	case n >= 0 && n <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(n))
+	case n >= 0:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), uint64(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(n))
+	}
+}
+
+func (e *encoder) float(f float64) {
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(f))
+}
+
+func (e *encoder) str(s string) {
+	switch n := len(s); {
+	case n <= 31:
+		e.buf = append(e.buf, byte(0xa0|n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xda), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdb), uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+// * header --> array / map length: fix is the 4 bit form (up to 15), then the 16 + 32 bit forms
+func (e *encoder) header(n int, fix, b16, b32 byte) {
+	switch {
+	case n <= 15:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, b16), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, b32), uint32(n))
+	}
+}
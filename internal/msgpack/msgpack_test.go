@@ -0,0 +1,41 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		in   any
+		want []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{json.Number("7"), []byte{0x07}},
+		{json.Number("-3"), []byte{0xfd}},
+		{json.Number("200"), []byte{0xcc, 0xc8}},
+		{json.Number("-200"), []byte{0xd1, 0xff, 0x38}},
+		{json.Number("70000"), []byte{0xce, 0x00, 0x01, 0x11, 0x70}},
+		{json.Number("1.5"), []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"hi", []byte{0xa2, 'h', 'i'}},
+		{[]any{json.Number("1"), false}, []byte{0x92, 0x01, 0xc2}},
+		{map[string]any{"b": nil, "a": "x"}, []byte{0x82, 0xa1, 'a', 0xa1, 'x', 0xa1, 'b', 0xc0}},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, c.in), "%v", c.in)
+		assert.Equal(t, c.want, buf.Bytes(), "%v", c.in)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, strings.Repeat("x", 40)))
+	assert.Equal(t, []byte{0xd9, 40}, buf.Bytes()[:2])
+
+	assert.Error(t, Encode(&buf, struct{}{}))
+}
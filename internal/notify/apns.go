@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ! APNs endpoints, APNS_ENVIRONMENT=sandbox for development builds of the app
+const (
+	APNsProduction = "https://api.push.apple.com"
+	APNsSandbox    = "https://api.sandbox.push.apple.com"
+)
+
+// ! apnsTokenLifetime --> Apple rejects provider tokens older than an hour and refreshes more often than every 20 minutes
+const apnsTokenLifetime = 50 * time.Minute
+
+// ! APNsSender --> Apple Push Notification service over HTTP/2 with token-based (.p8 key) auth
+type APNsSender struct {
+	KeyID  string
+	TeamID string
+	Topic  string //* the app's bundle id
+	Key    *ecdsa.PrivateKey
+	URL    string
+	client *http.Client
+
+	mu       sync.Mutex
+	jwt      string
+	issuedAt time.Time
+}
+
+// ! NewAPNsSender --> keyPEM is the AuthKey_<KeyID>.p8 file from the Apple developer portal
+func NewAPNsSender(keyPEM []byte, keyID, teamID, topic string, production bool) (*APNsSender, error) {
+	if keyID == "" || teamID == "" || topic == "" {
+		return nil, errors.New("notify: APNs needs APNS_KEY_ID, APNS_TEAM_ID and APNS_TOPIC")
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("notify: APNs key is not PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("notify: APNs key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("notify: APNs key is not an EC key")
+	}
+
+	endpoint := APNsProduction
+	if !production {
+		endpoint = APNsSandbox
+	}
+	return &APNsSender{
+		KeyID:  keyID,
+		TeamID: teamID,
+		Topic:  topic,
+		Key:    key,
+		URL:    endpoint,
+		client: &http.Client{Timeout: 30 * time.Second}, //* the default transport negotiates HTTP/2, which APNs requires
+	}, nil
+}
+
+// * providerToken --> ES256 JWT, reused for apnsTokenLifetime
+func (s *APNsSender) providerToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jwt != "" && time.Since(s.issuedAt) < apnsTokenLifetime {
+		return s.jwt, nil
+	}
+
+	now := time.Now()
+	token, err := signJWT(
+		map[string]any{"alg": "ES256", "kid": s.KeyID},
+		map[string]any{"iss": s.TeamID, "iat": now.Unix()},
+		func(digest []byte) ([]byte, error) {
+			r, sig, err := ecdsa.Sign(rand.Reader, s.Key, digest)
+			if err != nil {
+				return nil, err
+			}
+			//* JWS wants r || s as fixed 32 byte big-endian halves, not ASN.1
+			signature := make([]byte, 64)
+			r.FillBytes(signature[:32])
+			sig.FillBytes(signature[32:])
+			return signature, nil
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	s.jwt, s.issuedAt = token, now
+	return token, nil
+}
+
+func (s *APNsSender) Send(ctx context.Context, token string, n Notification) error {
+	providerToken, err := s.providerToken()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"aps": map[string]any{
+			"alert": map[string]string{"title": n.Title, "body": n.Body},
+			"sound": "default",
+		},
+	}
+	for key, value := range n.Data {
+		payload[key] = value
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", s.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	switch {
+	case resp.StatusCode == http.StatusGone, result.Reason == "BadDeviceToken", result.Reason == "DeviceTokenNotForTopic":
+		return ErrInvalidToken
+	case result.Reason == "ExpiredProviderToken":
+		s.mu.Lock()
+		s.jwt = "" //* the retry signs a new one
+		s.mu.Unlock()
+	}
+	return fmt.Errorf("notify: APNs send: %s: %s", resp.Status, result.Reason)
+}
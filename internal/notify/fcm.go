@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// ! FCMSender --> Firebase Cloud Messaging HTTP v1, authenticated as a service account
+// ? the OAuth access token is cached until shortly before it expires, endpoints are fields so tests can point them at httptest
+type FCMSender struct {
+	ProjectID   string
+	ClientEmail string
+	Key         *rsa.PrivateKey
+	TokenURL    string
+	APIURL      string
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// * serviceAccount --> the fields of a Google service account JSON key we use
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// ! NewFCMSender --> from a service account key file downloaded from the Firebase console
+func NewFCMSender(credentials []byte) (*FCMSender, error) {
+	var account serviceAccount
+	err := json.Unmarshal(credentials, &account)
+	if err != nil {
+		return nil, fmt.Errorf("notify: FCM credentials: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" {
+		return nil, errors.New("notify: FCM credentials need project_id and client_email")
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("notify: FCM credentials have no PEM private_key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("notify: FCM private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("notify: FCM private_key is not an RSA key")
+	}
+
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = "https://oauth2.googleapis.com/token"
+	}
+	return &FCMSender{
+		ProjectID:   account.ProjectID,
+		ClientEmail: account.ClientEmail,
+		Key:         key,
+		TokenURL:    tokenURL,
+		APIURL:      "https://fcm.googleapis.com",
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// * token --> cached access token, a fresh one from a signed JWT assertion a minute before the old one expires
+func (s *FCMSender) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && time.Now().Before(s.expiresAt.Add(-time.Minute)) {
+		return s.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(
+		map[string]any{"alg": "RS256", "typ": "JWT"},
+		map[string]any{"iss": s.ClientEmail, "scope": fcmScope, "aud": s.TokenURL, "iat": now.Unix(), "exp": now.Add(time.Hour).Unix()},
+		func(digest []byte) ([]byte, error) {
+			return rsa.SignPKCS1v15(rand.Reader, s.Key, crypto.SHA256, digest)
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("notify: FCM token exchange: %s: %s", resp.Status, body)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", err
+	}
+	s.accessToken = result.AccessToken
+	s.expiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
+
+func (s *FCMSender) Send(ctx context.Context, token string, n Notification) error {
+	accessToken, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"message": map[string]any{
+			"token":        token,
+			"notification": map[string]string{"title": n.Title, "body": n.Body},
+			"data":         n.Data,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", s.APIURL, url.PathEscape(s.ProjectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	//? 404 (UNREGISTERED) is an uninstalled app, a 400 naming the token is one that was never valid
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusNotFound || bytes.Contains(respBody, []byte("UNREGISTERED")) ||
+		(resp.StatusCode == http.StatusBadRequest && bytes.Contains(respBody, []byte("registration token"))) {
+		return ErrInvalidToken
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		s.mu.Lock()
+		s.accessToken = "" //* revoked early, the retry fetches a new one
+		s.mu.Unlock()
+	}
+	return fmt.Errorf("notify: FCM send: %s: %s", resp.Status, respBody)
+}
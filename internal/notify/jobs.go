@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fem/internal/achievements"
+	"fem/internal/events"
+	"fem/internal/mailer"
+	"fem/internal/reminders"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// ! background job types for notification delivery
+const (
+	JobDispatch = "notify.dispatch" //* one event --> inbox row, a delivery per device + an email, as the user's switches say
+	JobDeliver  = "notify.deliver"  //* one push, retried with the pool's backoff
+)
+
+// ! DispatchPayload --> notify.dispatch, built from the bus event
+type DispatchPayload struct {
+	Event     string `json:"event"`
+	UserID    int    `json:"user_id"`
+	WorkoutID int    `json:"workout_id,omitempty"`
+	Ref       string `json:"ref,omitempty"`
+}
+
+// ! DeliverPayload --> notify.deliver
+type DeliverPayload struct {
+	Platform     string       `json:"platform"`
+	Token        string       `json:"token"`
+	Notification Notification `json:"notification"`
+}
+
+// ! Notifier --> turns events into inbox entries, pushes on every device the user registered and emails
+type Notifier struct {
+	Store        store.NotificationStore
+	Users        store.UserStore      //* who commented / followed, the email address
+	Workouts     store.WorkoutStore   //* the commented workout's title
+	Challenges   store.ChallengeStore //* optional, nil on sqlite
+	Reminders    store.ReminderStore  //* the reminder's name
+	Achievements *achievements.Engine //* optional, badge names; seasonal badges aren't in it
+	Senders      map[string]Sender    //* by store.DevicePlatform*
+	Jobs         worker.Enqueuer      //* notify.deliver + email.send
+	Logger       *log.Logger
+}
+
+// ! Subscribe --> events are handed to the worker right away, lookups + deliveries happen there
+func (n *Notifier) Subscribe(bus *events.Bus) {
+	types := make([]string, 0, len(Categories))
+	for eventType := range Categories {
+		types = append(types, eventType)
+	}
+	bus.Subscribe(func(e events.Event) error {
+		return n.Jobs.Enqueue(JobDispatch, DispatchPayload{Event: e.Type, UserID: e.UserID, WorkoutID: e.WorkoutID, Ref: e.Ref})
+	}, types...)
+}
+
+// ! DispatchJob --> payload is a DispatchPayload, the category must be on and then every channel that is on gets it
+// ? reminders keep their own email switch, the email channel leaves them alone so nobody gets two
+func DispatchJob(n *Notifier) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p DispatchPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+
+		category := Categories[p.Event]
+		prefs, err := n.Store.GetNotificationPreferences(p.UserID)
+		if err != nil {
+			return err
+		}
+		email := prefs.Email && p.Event != events.ReminderDue
+		if !prefs.Enabled(category) || !(prefs.InApp || prefs.Push || email) {
+			return nil
+		}
+
+		msg, ok, err := n.message(p)
+		if err != nil || !ok {
+			return err
+		}
+
+		//? a deleted account keeps its inbox for the grace period, pushes + emails stop right away
+		var user *store.User
+		if prefs.Push || email {
+			user, err = n.Users.GetUserByID(int64(p.UserID))
+			if err != nil {
+				return err
+			}
+		}
+		if prefs.Push && user != nil {
+			devices, err := n.Store.ListDevices(p.UserID)
+			if err != nil {
+				return err
+			}
+			for _, d := range devices {
+				err = n.Jobs.Enqueue(JobDeliver, DeliverPayload{Platform: d.Platform, Token: d.Token, Notification: msg})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if email && user != nil {
+			err = n.Jobs.Enqueue(worker.JobSendEmail, mailer.Message{To: user.Email, Subject: msg.Title, Body: msg.Body})
+			if err != nil {
+				return err
+			}
+		}
+		if prefs.InApp {
+			return n.Store.CreateNotification(&store.Notification{UserID: p.UserID, Category: category, Title: msg.Title, Body: msg.Body, Data: msg.Data})
+		}
+		return nil
+	}
+}
+
+// * message --> what every channel says about an event, false when what it was about is gone already
+func (n *Notifier) message(p DispatchPayload) (Notification, bool, error) {
+	category := Categories[p.Event]
+	data := map[string]string{"category": category, "event": p.Event}
+
+	switch p.Event {
+	case events.CommentAdded:
+		commenter, err := n.user(p.Ref)
+		if err != nil || commenter == nil {
+			return Notification{}, false, err
+		}
+		workout, err := n.Workouts.GetWorkoutByID(int64(p.WorkoutID))
+		if errors.Is(err, store.ErrNotFound) {
+			return Notification{}, false, nil
+		}
+		if err != nil {
+			return Notification{}, false, err
+		}
+		data["workout_id"] = strconv.Itoa(p.WorkoutID)
+		return Notification{Title: "New comment", Body: fmt.Sprintf("%s commented on %s", commenter.Username, workout.Title), Data: data}, true, nil
+
+	case events.FollowerAdded:
+		follower, err := n.user(p.Ref)
+		if err != nil || follower == nil {
+			return Notification{}, false, err
+		}
+		data["user_id"] = p.Ref
+		return Notification{Title: "New follower", Body: follower.Username + " started following you", Data: data}, true, nil
+
+	case events.ChallengeEnded:
+		data["challenge_id"] = p.Ref
+		msg := Notification{Title: "Challenge results are in", Body: "See how you did.", Data: data}
+		if n.Challenges == nil {
+			return msg, true, nil
+		}
+		id, err := strconv.ParseInt(p.Ref, 10, 64)
+		if err != nil {
+			return Notification{}, false, err
+		}
+		challenge, err := n.Challenges.GetChallenge(id)
+		if err != nil || challenge == nil {
+			return Notification{}, false, err
+		}
+		msg.Body = challenge.Title + " has ended."
+		results, err := n.Challenges.GetChallengeStandings(challenge)
+		if err != nil {
+			return Notification{}, false, err
+		}
+		for _, result := range results {
+			if result.UserID == p.UserID {
+				msg.Body = fmt.Sprintf("%s has ended, you finished #%d.", challenge.Title, result.Rank)
+			}
+		}
+		return msg, true, nil
+
+	case events.ReminderDue:
+		id, err := strconv.ParseInt(p.Ref, 10, 64)
+		if err != nil {
+			return Notification{}, false, err
+		}
+		r, err := n.Reminders.GetReminder(id)
+		if err != nil || r == nil {
+			return Notification{}, false, err
+		}
+		data["reminder_id"] = p.Ref
+		return Notification{Title: r.Name, Body: reminders.Body(r, nil), Data: data}, true, nil
+
+	case events.AchievementEarned:
+		data["achievement"] = p.Ref
+		msg := Notification{Title: "Achievement unlocked", Body: "You earned a new badge.", Data: data}
+		if n.Achievements != nil {
+			if rule := n.Achievements.Rule(p.Ref); rule != nil {
+				msg.Body = fmt.Sprintf("%s: %s", rule.Name, rule.Description)
+			}
+		}
+		return msg, true, nil
+	}
+	return Notification{}, false, fmt.Errorf("notify: no message for event %q", p.Event)
+}
+
+// * user --> the user an event's Ref names, nil once they are gone
+func (n *Notifier) user(ref string) (*store.User, error) {
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return n.Users.GetUserByID(id)
+}
+
+// ! DeliverJob --> payload is a DeliverPayload, rejected tokens are deleted instead of retried
+func DeliverJob(n *Notifier) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p DeliverPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+		sender, ok := n.Senders[p.Platform]
+		if !ok {
+			n.Logger.Printf("notify: no sender for platform %q, dropped", p.Platform)
+			return nil
+		}
+
+		err = sender.Send(ctx, p.Token, p.Notification)
+		if errors.Is(err, ErrInvalidToken) {
+			n.Logger.Printf("notify: %s token %s rejected, device removed", p.Platform, shortToken(p.Token))
+			return n.Store.DeleteDeviceToken(p.Token)
+		}
+		return err
+	}
+}
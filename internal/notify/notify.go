@@ -0,0 +1,114 @@
+// ! package notify --> tells users what happened: the in-app inbox, push to their phones (FCM + APNs) and email
+// ? bus events become a notify.dispatch job, which checks preferences, writes the inbox row and enqueues one
+// ? notify.deliver per device + an email.send
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fem/internal/events"
+	"fem/internal/store"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// ! ErrInvalidToken --> the push service says the token will never work again, the device is dropped
+var ErrInvalidToken = errors.New("notify: device token is no longer valid")
+
+// ! Categories --> which preference switch covers each event the notifier handles
+var Categories = map[string]string{
+	events.CommentAdded:      store.NotifyComments,
+	events.FollowerAdded:     store.NotifyFollowers,
+	events.ChallengeEnded:    store.NotifyChallenges,
+	events.ReminderDue:       store.NotifyReminders,
+	events.AchievementEarned: store.NotifyAchievements,
+}
+
+// ! Notification --> what the phone shows, Data is handed to the app for deep links
+type Notification struct {
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"`
+}
+
+// ! Sender --> anything that can push a Notification to one device token
+type Sender interface {
+	Send(ctx context.Context, token string, n Notification) error
+}
+
+// ! SendersFromEnv --> FCM_* / APNS_* credentials, a platform without them only logs its pushes
+// ? a credentials file that is set but broken stops startup, like a broken EXPERIMENTS_FILE
+func SendersFromEnv(logger *log.Logger) (map[string]Sender, error) {
+	senders := map[string]Sender{
+		store.DevicePlatformFCM:  &LogSender{Platform: store.DevicePlatformFCM, Logger: logger},
+		store.DevicePlatformAPNs: &LogSender{Platform: store.DevicePlatformAPNs, Logger: logger},
+	}
+
+	if path := os.Getenv("FCM_CREDENTIALS_FILE"); path != "" {
+		credentials, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("notify: FCM_CREDENTIALS_FILE: %w", err)
+		}
+		fcm, err := NewFCMSender(credentials)
+		if err != nil {
+			return nil, err
+		}
+		senders[store.DevicePlatformFCM] = fcm
+	}
+
+	if path := os.Getenv("APNS_KEY_FILE"); path != "" {
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("notify: APNS_KEY_FILE: %w", err)
+		}
+		production := os.Getenv("APNS_ENVIRONMENT") != "sandbox"
+		apns, err := NewAPNsSender(key, os.Getenv("APNS_KEY_ID"), os.Getenv("APNS_TEAM_ID"), os.Getenv("APNS_TOPIC"), production)
+		if err != nil {
+			return nil, err
+		}
+		senders[store.DevicePlatformAPNs] = apns
+	}
+	return senders, nil
+}
+
+// ! LogSender --> development fallback, writes the push to the app log instead of sending it
+type LogSender struct {
+	Platform string
+	Logger   *log.Logger
+}
+
+func (s *LogSender) Send(ctx context.Context, token string, n Notification) error {
+	s.Logger.Printf("notify: %s token=%s title=%q body=%q data=%v", s.Platform, shortToken(token), n.Title, n.Body, n.Data)
+	return nil
+}
+
+// * shortToken --> enough of a token to tell devices apart in logs
+func shortToken(token string) string {
+	if len(token) <= 8 {
+		return token
+	}
+	return token[:8] + "..."
+}
+
+// * signJWT --> compact JWS over header + claims, sign gets the SHA-256 digest of the signing input
+func signJWT(header, claims map[string]any, sign func(digest []byte) ([]byte, error)) (string, error) {
+	parts := make([]string, 0, 3)
+	for _, part := range []map[string]any{header, claims} {
+		raw, err := json.Marshal(part)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, base64.RawURLEncoding.EncodeToString(raw))
+	}
+	digest := sha256.Sum256([]byte(strings.Join(parts, ".")))
+	signature, err := sign(digest[:])
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(parts, ".") + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
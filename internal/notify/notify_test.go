@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fem/internal/events"
+	"fem/internal/mailer"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * recordingJobs --> worker.Enqueuer that keeps the deliveries + emails instead of running them
+type recordingJobs struct {
+	deliveries []DeliverPayload
+	emails     []mailer.Message
+}
+
+func (j *recordingJobs) Enqueue(jobType string, payload any) error {
+	switch p := payload.(type) {
+	case DeliverPayload:
+		j.deliveries = append(j.deliveries, p)
+	case mailer.Message:
+		j.emails = append(j.emails, p)
+	}
+	return nil
+}
+
+// * rejectingSender --> FCM / APNs saying every token is gone
+type rejectingSender struct{}
+
+func (rejectingSender) Send(ctx context.Context, token string, n Notification) error {
+	return ErrInvalidToken
+}
+
+// ! TestDispatch --> an inbox entry + one delivery per device while the category is on, email only once it's switched on,
+// ! nothing once the category is off, rejected tokens are dropped
+func TestDispatch(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	workouts := memstore.NewWorkoutStore(db)
+	notifications := memstore.NewNotificationStore(db)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	bo := &store.User{Username: "bo", Email: "bo@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(bo))
+	workout, err := workouts.CreateWorkout(&store.Workout{UserID: ana.ID, Title: "Legs", DurationMinutes: 45})
+	require.NoError(t, err)
+	require.NoError(t, notifications.RegisterDevice(&store.Device{UserID: ana.ID, Platform: store.DevicePlatformFCM, Token: "android-1"}))
+	require.NoError(t, notifications.RegisterDevice(&store.Device{UserID: ana.ID, Platform: store.DevicePlatformAPNs, Token: "iphone-1"}))
+
+	jobs := &recordingJobs{}
+	notifier := &Notifier{
+		Store:    notifications,
+		Users:    users,
+		Workouts: workouts,
+		Senders:  map[string]Sender{store.DevicePlatformFCM: rejectingSender{}},
+		Jobs:     jobs,
+		Logger:   log.New(io.Discard, "", 0),
+	}
+	comment, err := json.Marshal(DispatchPayload{Event: events.CommentAdded, UserID: ana.ID, WorkoutID: workout.ID, Ref: strconv.Itoa(bo.ID)})
+	require.NoError(t, err)
+
+	require.NoError(t, DispatchJob(notifier)(context.Background(), comment))
+	require.Len(t, jobs.deliveries, 2)
+	assert.Equal(t, "New comment", jobs.deliveries[0].Notification.Title)
+	assert.Equal(t, "bo commented on Legs", jobs.deliveries[0].Notification.Body)
+	assert.Equal(t, strconv.Itoa(workout.ID), jobs.deliveries[0].Notification.Data["workout_id"])
+	assert.Empty(t, jobs.emails)
+	inbox, total, err := notifications.ListNotifications(ana.ID, true, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, store.NotifyComments, inbox[0].Category)
+	assert.Equal(t, "bo commented on Legs", inbox[0].Body)
+
+	prefs := store.DefaultNotificationPreferences()
+	prefs.Email, prefs.Push, prefs.InApp = true, false, false
+	require.NoError(t, notifications.UpdateNotificationPreferences(ana.ID, prefs))
+	jobs.deliveries = nil
+	require.NoError(t, DispatchJob(notifier)(context.Background(), comment))
+	assert.Empty(t, jobs.deliveries)
+	require.Len(t, jobs.emails, 1)
+	assert.Equal(t, "ana@example.com", jobs.emails[0].To)
+	assert.Equal(t, "New comment", jobs.emails[0].Subject)
+
+	prefs = store.DefaultNotificationPreferences()
+	prefs.Comments = false
+	require.NoError(t, notifications.UpdateNotificationPreferences(ana.ID, prefs))
+	require.NoError(t, DispatchJob(notifier)(context.Background(), comment))
+	assert.Empty(t, jobs.deliveries)
+	unread, err := notifications.CountUnreadNotifications(ana.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, unread)
+
+	assert.ErrorIs(t, notifications.MarkNotificationRead(inbox[0].ID, bo.ID), store.ErrNotFound)
+	require.NoError(t, notifications.MarkNotificationRead(inbox[0].ID, ana.ID))
+	unread, err = notifications.CountUnreadNotifications(ana.ID)
+	require.NoError(t, err)
+	assert.Zero(t, unread)
+
+	deliver, err := json.Marshal(DeliverPayload{Platform: store.DevicePlatformFCM, Token: "android-1"})
+	require.NoError(t, err)
+	require.NoError(t, DeliverJob(notifier)(context.Background(), deliver))
+	devices, err := notifications.ListDevices(ana.ID)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "iphone-1", devices[0].Token)
+}
+
+// ! TestDispatchDeletedAccount --> a soft-deleted account gets no pushes or emails, even from a device registered too late
+func TestDispatchDeletedAccount(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	notifications := memstore.NewNotificationStore(db)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	bo := &store.User{Username: "bo", Email: "bo@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(bo))
+	require.NoError(t, notifications.RegisterDevice(&store.Device{UserID: ana.ID, Platform: store.DevicePlatformFCM, Token: "android-1"}))
+	prefs := store.DefaultNotificationPreferences()
+	prefs.Email = true
+	require.NoError(t, notifications.UpdateNotificationPreferences(ana.ID, prefs))
+
+	_, err := users.DeleteAccount(int64(ana.ID))
+	require.NoError(t, err)
+	devices, err := notifications.ListDevices(ana.ID)
+	require.NoError(t, err)
+	assert.Empty(t, devices, "DeleteAccount drops the devices")
+	require.NoError(t, notifications.RegisterDevice(&store.Device{UserID: ana.ID, Platform: store.DevicePlatformFCM, Token: "android-2"}))
+
+	jobs := &recordingJobs{}
+	notifier := &Notifier{Store: notifications, Users: users, Jobs: jobs, Logger: log.New(io.Discard, "", 0)}
+	follow, err := json.Marshal(DispatchPayload{Event: events.FollowerAdded, UserID: ana.ID, Ref: strconv.Itoa(bo.ID)})
+	require.NoError(t, err)
+	require.NoError(t, DispatchJob(notifier)(context.Background(), follow))
+	assert.Empty(t, jobs.deliveries)
+	assert.Empty(t, jobs.emails)
+}
+
+// ! TestFCMSend --> one token exchange for several sends, 404 means the app is gone
+func TestFCMSend(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	exchanges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			exchanges++
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "ya29.test", "expires_in": 3600})
+		case "/v1/projects/fittrack/messages:send":
+			assert.Equal(t, "Bearer ya29.test", r.Header.Get("Authorization"))
+			var body struct {
+				Message struct {
+					Token string `json:"token"`
+				} `json:"message"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Message.Token == "stale" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+				return
+			}
+			w.Write([]byte(`{"name":"projects/fittrack/messages/1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	credentials, err := json.Marshal(map[string]string{
+		"project_id":   "fittrack",
+		"client_email": "push@fittrack.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	sender, err := NewFCMSender(credentials)
+	require.NoError(t, err)
+	sender.APIURL = server.URL
+
+	n := Notification{Title: "New follower", Body: "bo started following you"}
+	require.NoError(t, sender.Send(context.Background(), "fresh", n))
+	assert.ErrorIs(t, sender.Send(context.Background(), "stale", n), ErrInvalidToken)
+	assert.Equal(t, 1, exchanges)
+}
+
+// ! TestAPNsSend --> ES256 provider token Apple can verify, 410 means the token is gone
+func TestAPNsSend(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "app.fittrack", r.Header.Get("apns-topic"))
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")
+		parts := strings.Split(jwt, ".")
+		require.Len(t, parts, 3)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		require.Len(t, signature, 64)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])))
+
+		if r.URL.Path == "/3/device/gone" {
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+		}
+	}))
+	defer server.Close()
+
+	sender, err := NewAPNsSender(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), "KEY123", "TEAM123", "app.fittrack", false)
+	require.NoError(t, err)
+	assert.Equal(t, APNsSandbox, sender.URL)
+	sender.URL = server.URL
+
+	n := Notification{Title: "Morning run", Body: "Time to train!", Data: map[string]string{"category": store.NotifyReminders}}
+	require.NoError(t, sender.Send(context.Background(), "present", n))
+	assert.ErrorIs(t, sender.Send(context.Background(), "gone", n), ErrInvalidToken)
+}
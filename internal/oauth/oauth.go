@@ -0,0 +1,202 @@
+// ! package oauth --> "sign in with" providers: authorization code flow + one profile call, plain net/http like the Strava client
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! provider names, also the {provider} route slug
+const (
+	Google = "google"
+	GitHub = "github"
+)
+
+// ! ErrNoEmail --> the provider didn't share a verified email, we can't create or link an account without one
+var ErrNoEmail = errors.New("oauth: provider returned no verified email")
+
+// ! Identity --> who the provider says signed in
+type Identity struct {
+	Provider      string
+	Subject       string //* the provider's stable user id, never the email
+	Email         string
+	EmailVerified bool
+	Username      string //* suggestion for new accounts (GitHub login, Google email local part)
+}
+
+// ! Provider --> one configured provider, endpoints are fields so tests can point them at httptest
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string //* our /auth/{provider}/callback
+	AuthURL      string
+	TokenURL     string
+	APIURL       string
+	Scopes       []string
+	client       *http.Client
+}
+
+// ! ProvidersFromEnv --> GOOGLE_* / GITHUB_* client credentials, a provider without them is off
+func ProvidersFromEnv() map[string]*Provider {
+	providers := map[string]*Provider{}
+	if id, secret := os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET"); id != "" && secret != "" {
+		providers[Google] = &Provider{
+			Name:         Google,
+			ClientID:     id,
+			ClientSecret: secret,
+			RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
+			AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL:     "https://oauth2.googleapis.com/token",
+			APIURL:       "https://openidconnect.googleapis.com/v1",
+			Scopes:       []string{"openid", "email", "profile"},
+			client:       &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+	if id, secret := os.Getenv("GITHUB_CLIENT_ID"), os.Getenv("GITHUB_CLIENT_SECRET"); id != "" && secret != "" {
+		providers[GitHub] = &Provider{
+			Name:         GitHub,
+			ClientID:     id,
+			ClientSecret: secret,
+			RedirectURL:  os.Getenv("GITHUB_REDIRECT_URL"),
+			AuthURL:      "https://github.com/login/oauth/authorize",
+			TokenURL:     "https://github.com/login/oauth/access_token",
+			APIURL:       "https://api.github.com",
+			Scopes:       []string{"read:user", "user:email"},
+			client:       &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+	return providers
+}
+
+// ! AuthorizeURL --> where the browser goes to sign in, state comes back on the callback
+func (p *Provider) AuthorizeURL(state string) string {
+	query := url.Values{}
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", p.RedirectURL)
+	query.Set("response_type", "code")
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	return p.AuthURL + "?" + query.Encode()
+}
+
+// ! Exchange --> authorization code for an access token, only used for the profile call right after
+func (p *Provider) Exchange(ctx context.Context, code string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("client_secret", p.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json") //* GitHub answers form encoded without it
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	err = p.do(req, &token)
+	if err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("oauth: %s token exchange: %s", p.Name, token.Error) //* GitHub reports errors with a 200
+	}
+	return token.AccessToken, nil
+}
+
+// ! Identity --> the signed-in user's id + verified email
+func (p *Provider) Identity(ctx context.Context, accessToken string) (*Identity, error) {
+	switch p.Name {
+	case Google:
+		return p.googleIdentity(ctx, accessToken)
+	case GitHub:
+		return p.githubIdentity(ctx, accessToken)
+	}
+	return nil, fmt.Errorf("oauth: unknown provider %q", p.Name)
+}
+
+func (p *Provider) googleIdentity(ctx context.Context, accessToken string) (*Identity, error) {
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	err := p.get(ctx, accessToken, "/userinfo", &info)
+	if err != nil {
+		return nil, err
+	}
+	local, _, _ := strings.Cut(info.Email, "@")
+	return &Identity{Provider: Google, Subject: info.Sub, Email: info.Email, EmailVerified: info.EmailVerified, Username: local}, nil
+}
+
+func (p *Provider) githubIdentity(ctx context.Context, accessToken string) (*Identity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	}
+	err := p.get(ctx, accessToken, "/user", &user)
+	if err != nil {
+		return nil, err
+	}
+	//* the profile email may be empty or unverified, /user/emails says which one is the verified primary
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	err = p.get(ctx, accessToken, "/user/emails", &emails)
+	if err != nil {
+		return nil, err
+	}
+	identity := &Identity{Provider: GitHub, Subject: strconv.FormatInt(user.ID, 10), Username: user.Login}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email, identity.EmailVerified = e.Email, e.Verified
+		}
+	}
+	return identity, nil
+}
+
+func (p *Provider) get(ctx context.Context, accessToken, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.APIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return p.do(req, out)
+}
+
+// ! do --> sends req and decodes the JSON body into out
+func (p *Provider) do(req *http.Request, out any) error {
+	client := p.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("oauth: %s %s %s: %s: %s", p.Name, req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
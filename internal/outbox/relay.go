@@ -0,0 +1,112 @@
+// Package outbox relays the events the workout stores write to events_outbox onto the in-process bus.
+// The row commits with the workout it describes, so an event is never lost to a crash between
+// the write and the publish. A row is only marked once every subscriber handled it; a failing
+// subscriber or a crash before the mark sends it again, so subscribers see each event at least once.
+package outbox
+
+import (
+	"context"
+	"fem/internal/events"
+	"fem/internal/store"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ! Relay --> background loop that drains events_outbox into the bus (webhooks, SSE, achievements, ... subscribe there)
+type Relay struct {
+	Store     store.OutboxStore
+	Bus       *events.Bus
+	Interval  time.Duration //* poll period, Notify skips the wait after a local write
+	BatchSize int
+	Retention time.Duration //* published rows are kept this long, then purged
+	Logger    *log.Logger
+
+	wake chan struct{}
+}
+
+// ! NewRelay --> constructor with sensible defaults for the batch size
+func NewRelay(outboxStore store.OutboxStore, bus *events.Bus, interval, retention time.Duration, logger *log.Logger) *Relay {
+	return &Relay{
+		Store:     outboxStore,
+		Bus:       bus,
+		Interval:  interval,
+		BatchSize: 100,
+		Retention: retention,
+		Logger:    logger,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// ! Notify --> a write just committed an event, relay it now instead of on the next tick
+// ? never blocks, a wake-up already pending covers this one too; nil-safe for services built without a relay
+func (r *Relay) Notify() {
+	if r == nil {
+		return
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ! Run --> relays on every tick or Notify, purges old published rows about once an hour, returns when ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	var purgedAt time.Time
+
+	for {
+		_, err := r.RunOnce()
+		if err != nil {
+			r.Logger.Printf("ERROR: outbox relay: %v", err)
+		}
+
+		if now := time.Now(); now.Sub(purgedAt) >= time.Hour {
+			purgedAt = now
+			purged, err := r.Store.PurgePublishedEvents(now.Add(-r.Retention))
+			if err != nil {
+				r.Logger.Printf("ERROR: outbox purge: %v", err)
+			} else if purged > 0 {
+				r.Logger.Printf("outbox purge: %d published events removed", purged)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// ! RunOnce --> relays pending events one batch at a time until the outbox is empty
+func (r *Relay) RunOnce() (int, error) {
+	total := 0
+	for {
+		count, err := r.Store.RelayEvents(r.BatchSize, r.publish)
+		total += count
+		if err != nil || count < r.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// * publish --> delivers oldest first and stops at the first event a subscriber fails,
+// * it and everything after it stay pending so the order holds on the retry
+func (r *Relay) publish(pending []*store.OutboxEvent) (int, error) {
+	for i, event := range pending {
+		err := r.Bus.Deliver(events.Event{
+			Type:      event.Type,
+			UserID:    event.UserID,
+			WorkoutID: event.WorkoutID,
+			Ref:       event.Ref,
+			At:        event.CreatedAt,
+		})
+		if err != nil {
+			return i, fmt.Errorf("event %d (%s): %w", event.ID, event.Type, err)
+		}
+	}
+	return len(pending), nil
+}
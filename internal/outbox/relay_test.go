@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"errors"
+	"fem/internal/events"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestRelayRetriesFailedSubscribers --> an event a subscriber fails stays pending and comes again, in order
+func TestRelayRetriesFailedSubscribers(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, memstore.NewUserStore(db).CreateUser(user))
+	workouts := memstore.NewWorkoutStore(db)
+	first, err := workouts.CreateWorkout(&store.Workout{UserID: user.ID, Title: "run", DurationMinutes: 30})
+	require.NoError(t, err)
+	second, err := workouts.CreateWorkout(&store.Workout{UserID: user.ID, Title: "ride", DurationMinutes: 60})
+	require.NoError(t, err)
+
+	bus := events.NewBus(logger)
+	failing := true
+	var seen []int
+	bus.Subscribe(func(e events.Event) error {
+		if e.WorkoutID == second.ID && failing {
+			return errors.New("webhook store down")
+		}
+		seen = append(seen, e.WorkoutID)
+		return nil
+	}, events.WorkoutCreated)
+
+	relay := NewRelay(memstore.NewOutboxStore(db), bus, time.Second, time.Hour, logger)
+	count, err := relay.RunOnce()
+	require.Error(t, err)
+	assert.Equal(t, 1, count, "the first event went through")
+
+	failing = false
+	count, err = relay.RunOnce()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "only the failed one is retried")
+	assert.Equal(t, []int{first.ID, second.ID}, seen)
+
+	count, err = relay.RunOnce()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,65 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fem/internal/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! HIBPChecker --> HaveIBeenPwned range API, only the first 5 hex chars of the SHA-1 ever leave the server
+// ? k-anonymity: the API answers every suffix under that prefix and the match happens here
+type HIBPChecker struct {
+	BaseURL string //* https://api.pwnedpasswords.com, a field so tests can point it at httptest
+	client  *http.Client
+}
+
+// ! NewHIBPChecker --> checker against baseURL with a short timeout, registration shouldn't hang on it
+func NewHIBPChecker(baseURL string) *HIBPChecker {
+	return &HIBPChecker{BaseURL: strings.TrimSuffix(baseURL, "/"), client: &http.Client{Timeout: 3 * time.Second}}
+}
+
+// ! CheckerFromEnv --> PASSWORD_BREACH_CHECK=hibp turns the lookup on, nil otherwise
+func CheckerFromEnv() Checker {
+	if utils.GetEnv("PASSWORD_BREACH_CHECK", "") != "hibp" {
+		return nil
+	}
+	return NewHIBPChecker(utils.GetEnv("HIBP_API_URL", "https://api.pwnedpasswords.com"))
+}
+
+func (c *HIBPChecker) Breached(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Add-Padding", "true") //* padded responses hide the prefix's real result count
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("hibp: range %s: status %d", prefix, resp.StatusCode)
+	}
+
+	//* one "SUFFIX:COUNT" per line, padding lines have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || line != suffix {
+			continue
+		}
+		return strconv.Atoi(count)
+	}
+	return 0, scanner.Err()
+}
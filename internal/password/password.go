@@ -0,0 +1,123 @@
+// ! package password --> strength rules for new passwords plus an optional breached-password check
+// ? every failed rule is reported at once with a code + message the client can show next to the field
+package password
+
+import (
+	"context"
+	"fem/internal/utils"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ! MaxBytes --> bcrypt ignores everything past 72 bytes, longer passwords are refused instead of silently cut
+const MaxBytes = 72
+
+// ! Problem --> one rule the password failed
+type Problem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ! Policy --> strength rules, the zero value only enforces MaxBytes
+type Policy struct {
+	MinLength      int  //* in characters, not bytes
+	MinClasses     int  //* of lower case, upper case, digits, symbols
+	RejectUserInfo bool //* no username or email local part inside the password
+}
+
+// ! PolicyFromEnv --> PASSWORD_MIN_LENGTH + PASSWORD_MIN_CLASSES, user info is always rejected
+func PolicyFromEnv() Policy {
+	return Policy{
+		MinLength:      utils.GetEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MinClasses:     utils.GetEnvInt("PASSWORD_MIN_CLASSES", 1),
+		RejectUserInfo: true,
+	}
+}
+
+// ! Check --> every rule password fails, nil when it passes
+func (p Policy) Check(password, username, email string) []Problem {
+	problems := []Problem{}
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		problems = append(problems, Problem{"too_short", fmt.Sprintf("use at least %d characters", p.MinLength)})
+	}
+	if len(password) > MaxBytes {
+		problems = append(problems, Problem{"too_long", fmt.Sprintf("use at most %d bytes", MaxBytes)})
+	}
+	if classes := countClasses(password); classes < p.MinClasses {
+		problems = append(problems, Problem{"too_simple", fmt.Sprintf("mix at least %d of lower case, upper case, digits and symbols", p.MinClasses)})
+	}
+	if p.RejectUserInfo && containsUserInfo(password, username, email) {
+		problems = append(problems, Problem{"contains_user_info", "don't use your username or email in the password"})
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+func countClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// * containsUserInfo --> parts shorter than 3 characters would match too much to mean anything
+func containsUserInfo(password, username, email string) bool {
+	lowered := strings.ToLower(password)
+	local, _, _ := strings.Cut(email, "@")
+	for _, part := range []string{username, local} {
+		if len(part) >= 3 && strings.Contains(lowered, strings.ToLower(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ! Checker --> looks a password up in a breach corpus, returns how often it was seen (0 = never)
+type Checker interface {
+	Breached(ctx context.Context, password string) (int, error)
+}
+
+// ! Validator --> a Policy plus an optional Checker
+type Validator struct {
+	Policy  Policy
+	Checker Checker //* nil skips the breach lookup
+	Logger  *log.Logger
+}
+
+// ! NewValidator --> constructor for the password validator
+func NewValidator(policy Policy, checker Checker, logger *log.Logger) *Validator {
+	return &Validator{Policy: policy, Checker: checker, Logger: logger}
+}
+
+// ! Validate --> the problems password has, nil when it is acceptable
+// ? a failed breach lookup is logged and the password let through: an outage shouldn't block sign-ups
+func (v *Validator) Validate(ctx context.Context, password, username, email string) []Problem {
+	problems := v.Policy.Check(password, username, email)
+	if v.Checker == nil || len(problems) > 0 {
+		return problems
+	}
+	seen, err := v.Checker.Breached(ctx, password)
+	if err != nil {
+		v.Logger.Printf("ERROR: breached password lookup: %v", err)
+		return nil
+	}
+	if seen > 0 {
+		return []Problem{{"breached", fmt.Sprintf("this password has appeared in %d known data breaches, choose another one", seen)}}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func codes(problems []Problem) []string {
+	out := []string{}
+	for _, p := range problems {
+		out = append(out, p.Code)
+	}
+	return out
+}
+
+// ! TestPolicyCheck --> every failed rule is reported, not just the first
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{MinLength: 10, MinClasses: 3, RejectUserInfo: true}
+
+	assert.Nil(t, policy.Check("Correct-Horse-42", "ana", "ana@example.com"))
+	assert.Equal(t, []string{"too_short", "too_simple"}, codes(policy.Check("short", "bo", "bo@example.com")))
+	assert.Equal(t, []string{"contains_user_info"}, codes(policy.Check("Hello-Anabel-1", "anabel", "x@example.com")))
+	assert.Equal(t, []string{"contains_user_info"}, codes(policy.Check("Marathon-42!", "ana", "marathon@example.com")), "email local part")
+	assert.Equal(t, []string{"too_long"}, codes(policy.Check(strings.Repeat("Ab1-", 20), "ana", "ana@example.com")))
+}
+
+// ! TestHIBPChecker --> only the 5 char prefix is sent, the count comes from the matching suffix line
+func TestHIBPChecker(t *testing.T) {
+	sum := sha1.Sum([]byte("password123"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/range/"+hash[:5], r.URL.Path)
+		assert.Equal(t, "true", r.Header.Get("Add-Padding"))
+		fmt.Fprintf(w, "0000000000000000000000000000000000A:0\r\n%s:2254650\r\n", hash[5:])
+	}))
+	defer server.Close()
+
+	checker := NewHIBPChecker(server.URL)
+	seen, err := checker.Breached(context.Background(), "password123")
+	require.NoError(t, err)
+	assert.Equal(t, 2254650, seen)
+
+	validator := NewValidator(Policy{MinLength: 8}, checker, log.New(io.Discard, "", 0))
+	assert.Equal(t, []string{"breached"}, codes(validator.Validate(context.Background(), "password123", "ana", "ana@example.com")))
+
+	server.Close()
+	assert.Nil(t, validator.Validate(context.Background(), "password123", "ana", "ana@example.com"), "lookup failure lets the password through")
+}
@@ -0,0 +1,441 @@
+// ! package patch --> PATCH bodies applied to a resource's current JSON: RFC 7386 merge patch + RFC 6902 JSON Patch
+// ? handlers hand over what the client can edit (a pointer struct filled with the current values) and get back
+// ? the same struct type holding only the fields the patch changed, so the existing partial update code saves it
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaMergePatch = "application/merge-patch+json" //* RFC 7386, plain application/json is read the same way
+	MediaJSONPatch  = "application/json-patch+json"  //* RFC 6902
+)
+
+// ! AcceptPatch --> value for the Accept-Patch header (RFC 5789) on 415 answers
+const AcceptPatch = MediaMergePatch + ", " + MediaJSONPatch
+
+var (
+	// ! ErrUnsupportedMediaType --> 415, the Content-Type is neither kind of patch
+	ErrUnsupportedMediaType = errors.New("patch: Content-Type must be " + MediaMergePatch + " or " + MediaJSONPatch)
+	// ! ErrTestFailed --> 409, a JSON Patch "test" op didn't match, nothing was applied
+	ErrTestFailed = errors.New("patch: test operation failed")
+)
+
+// ! Changes --> applies the patch in body to current's JSON and decodes what changed into dst
+// ? dst is usually a new value of current's type; fields the patch didn't touch stay at their zero value (nil pointers),
+// ? members that aren't in current are refused, as is removing one that has a value (there's no "clear" in pointer structs)
+func Changes(contentType string, body io.Reader, current, dst any) error {
+	before, err := toValue(current)
+	if err != nil {
+		return err
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+	var after any
+	switch mediaType {
+	case MediaMergePatch, "application/json":
+		var p any
+		p, err = decode(raw)
+		if err != nil {
+			return fmt.Errorf("patch: invalid merge patch: %w", err)
+		}
+		after = Merge(copyValue(before), p)
+	case MediaJSONPatch:
+		var ops []Operation
+		err = json.Unmarshal(raw, &ops)
+		if err != nil {
+			return fmt.Errorf("patch: invalid JSON Patch, want an array of operations: %w", err)
+		}
+		after, err = Apply(copyValue(before), ops)
+		if err != nil {
+			return err
+		}
+	default:
+		return ErrUnsupportedMediaType
+	}
+
+	changed, err := diff(before, after)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(changed)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(dst)
+	if err != nil {
+		return fmt.Errorf("patch: %w", err)
+	}
+	return nil
+}
+
+// * diff --> the top-level members of after that differ from before, removed ones as null
+func diff(before, after any) (map[string]any, error) {
+	old, _ := before.(map[string]any)
+	patched, ok := after.(map[string]any)
+	if !ok {
+		return nil, errors.New("patch: the patched document must stay an object")
+	}
+	changed := map[string]any{}
+	for key, value := range patched {
+		if previous, seen := old[key]; !seen || !Equal(previous, value) {
+			changed[key] = value
+		}
+	}
+	for key, value := range old {
+		if _, kept := patched[key]; !kept && value != nil {
+			return nil, fmt.Errorf("patch: %s can't be removed", key)
+		}
+	}
+	return changed, nil
+}
+
+// ! Merge --> RFC 7386: objects merge member by member, null removes a member, anything else replaces
+func Merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	merged, ok := target.(map[string]any)
+	if !ok {
+		merged = map[string]any{}
+	}
+	for key, value := range members {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = Merge(merged[key], value)
+	}
+	return merged
+}
+
+// ! Operation --> one RFC 6902 step; Value is kept raw so a missing value can be told apart from null
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ! Apply --> RFC 6902 operations in order on doc, all or nothing (doc may be modified, use the result on success only)
+func Apply(doc any, ops []Operation) (any, error) {
+	for i, op := range ops {
+		var err error
+		doc, err = op.apply(doc)
+		if err != nil {
+			if errors.Is(err, ErrTestFailed) {
+				return nil, fmt.Errorf("%w: operation %d (%s)", ErrTestFailed, i, op.Path)
+			}
+			return nil, fmt.Errorf("patch: operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func (op Operation) value() (any, error) {
+	if len(op.Value) == 0 {
+		return nil, errors.New("value is missing")
+	}
+	return decode(op.Value)
+}
+
+func (op Operation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "replace" {
+			_, err = get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return add(doc, path, value, op.Op == "replace")
+	case "remove":
+		_, doc, err = remove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("can't move a value into itself")
+			}
+			var value any
+			value, doc, err = remove(doc, from)
+			if err != nil {
+				return nil, fmt.Errorf("from: %w", err)
+			}
+			return add(doc, path, value, false)
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		return add(doc, path, copyValue(value), false)
+	case "test":
+		want, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := get(doc, path)
+		if err != nil || !Equal(got, want) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown op %q, want add, remove, replace, move, copy or test", op.Op)
+	}
+}
+
+// * parsePointer --> RFC 6901 reference tokens, "" is the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// * index --> an array position, end allows len(array) ("-" means the same) for adds
+func index(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > length || (i == length && !end) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%q doesn't exist", token)
+			}
+			doc = value
+		case []any:
+			i, err := index(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%q doesn't exist", token)
+		}
+	}
+	return doc, nil
+}
+
+// * modify --> walks to the parent of path's last token and lets leaf change it, returns the (possibly new) doc
+func modify(doc any, path []string, leaf func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return leaf(doc, path[0])
+	}
+	child, err := get(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = modify(child, path[1:], leaf)
+	if err != nil {
+		return nil, err
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		node[path[0]] = child
+	case []any:
+		i, _ := index(path[0], len(node), false)
+		node[i] = child
+	}
+	return doc, nil
+}
+
+func add(doc any, path []string, value any, replace bool) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return modify(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i, err := index(token, len(node), !replace)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				node[i] = value
+				return node, nil
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("%q has no parent object or array", token)
+		}
+	})
+}
+
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("can't remove the whole document")
+	}
+	var removed any
+	doc, err := modify(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%q doesn't exist", token)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []any:
+			i, err := index(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return append(node[:i], node[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%q doesn't exist", token)
+		}
+	})
+	return removed, doc, err
+}
+
+// ! Equal --> JSON equality: numbers by value (1 == 1.0), objects regardless of member order
+func Equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !Equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		m, okX := new(big.Rat).SetString(x.String())
+		n, okY := new(big.Rat).SetString(y.String())
+		return okX && okY && m.Cmp(n) == 0
+	default:
+		return a == b //* nil, bool, string
+	}
+}
+
+// * decode --> JSON with numbers kept as json.Number, ids + large ints survive the round trip
+func decode(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("trailing data after the JSON value")
+	}
+	return v, nil
+}
+
+func toValue(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decode(raw)
+}
+
+func copyValue(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(node))
+		for key, value := range node {
+			out[key] = copyValue(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(node))
+		for i, value := range node {
+			out[i] = copyValue(value)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,118 @@
+package patch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecode(t *testing.T, raw string) any {
+	t.Helper()
+	v, err := decode([]byte(raw))
+	require.NoError(t, err)
+	return v
+}
+
+func TestMerge(t *testing.T) {
+	//* examples from RFC 7386 appendix A
+	cases := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+	}
+	for _, c := range cases {
+		got := Merge(mustDecode(t, c.target), mustDecode(t, c.patch))
+		assert.True(t, Equal(mustDecode(t, c.want), got), "%s + %s = %v", c.target, c.patch, got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	apply := func(doc, ops string) (any, error) {
+		var parsed []Operation
+		require.NoError(t, json.Unmarshal([]byte(ops), &parsed))
+		return Apply(mustDecode(t, doc), parsed)
+	}
+
+	//* examples from RFC 6902 appendix A
+	cases := []struct{ doc, ops, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"copy","from":"/~1","path":"/a"}]`, `{"/":9,"~1":10,"a":9}`},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null},{"op":"replace","path":"/foo","value":1.0}]`, `{"foo":1}`},
+	}
+	for _, c := range cases {
+		got, err := apply(c.doc, c.ops)
+		require.NoError(t, err, c.ops)
+		assert.True(t, Equal(mustDecode(t, c.want), got), "%s: %v", c.ops, got)
+	}
+
+	for _, ops := range []string{
+		`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"add","path":"/foo"}]`,
+		`[{"op":"add","path":"foo","value":1}]`,
+		`[{"op":"move","from":"/foo","path":"/foo/child"}]`,
+		`[{"op":"frobnicate","path":"/foo"}]`,
+	} {
+		_, err := apply(`{"foo":{}}`, ops)
+		assert.Error(t, err, ops)
+	}
+	_, err := apply(`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`)
+	assert.ErrorIs(t, err, ErrTestFailed)
+}
+
+type editable struct {
+	Title    *string   `json:"title"`
+	Duration *int      `json:"duration_minutes"`
+	Notes    *string   `json:"notes"`
+	Tags     *[]string `json:"tags"`
+}
+
+func TestChanges(t *testing.T) {
+	title, duration, tags := "legs", 45, []string{"gym"}
+	current := editable{Title: &title, Duration: &duration, Tags: &tags}
+
+	var got editable
+	err := Changes(MediaMergePatch, strings.NewReader(`{"title":"legs","duration_minutes":50}`), current, &got)
+	require.NoError(t, err)
+	assert.Nil(t, got.Title, "unchanged")
+	require.NotNil(t, got.Duration)
+	assert.Equal(t, 50, *got.Duration)
+
+	got = editable{}
+	err = Changes(MediaJSONPatch+"; charset=utf-8", strings.NewReader(`[{"op":"test","path":"/title","value":"legs"},{"op":"add","path":"/tags/-","value":"pr"}]`), current, &got)
+	require.NoError(t, err)
+	require.NotNil(t, got.Tags)
+	assert.Equal(t, []string{"gym", "pr"}, *got.Tags)
+	assert.Equal(t, []string{"gym"}, tags, "current isn't touched")
+
+	err = Changes(MediaJSONPatch, strings.NewReader(`[{"op":"test","path":"/title","value":"arms"}]`), current, &editable{})
+	assert.ErrorIs(t, err, ErrTestFailed)
+	err = Changes("text/plain", strings.NewReader(`{}`), current, &editable{})
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+	err = Changes(MediaMergePatch, strings.NewReader(`{"user_id":3}`), current, &editable{})
+	assert.ErrorContains(t, err, "user_id")
+	err = Changes(MediaMergePatch, strings.NewReader(`{"title":null}`), current, &editable{})
+	assert.ErrorContains(t, err, "title can't be removed")
+	err = Changes(MediaMergePatch, strings.NewReader(`{"notes":null}`), current, &editable{})
+	assert.NoError(t, err, "already null")
+}
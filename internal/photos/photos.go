@@ -0,0 +1,124 @@
+// Package photos checks uploaded images (workout photos, avatars) and renders their thumbnails, stdlib codecs only.
+package photos
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	_ "image/gif" //* registered for image.Decode / DecodeConfig
+	_ "image/png"
+)
+
+// ! limits --> MaxPixels keeps a tiny file that decodes to a huge bitmap (decompression bomb) out
+const (
+	MaxSide       = 8000
+	MaxPixels     = 30_000_000
+	ThumbnailSide = 320
+)
+
+var (
+	ErrUnsupported = errors.New("photos: not a JPEG, PNG or GIF image")
+	ErrDimensions  = errors.New("photos: image dimensions out of range")
+)
+
+// ! Info --> what the header says, ContentType comes from the bytes, never from the client
+type Info struct {
+	Format      string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// ! Inspect --> reads only the header, so oversized images are rejected before anything is decoded
+func Inspect(data []byte) (Info, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, ErrUnsupported
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width > MaxSide || config.Height > MaxSide ||
+		config.Width*config.Height > MaxPixels {
+		return Info{}, ErrDimensions
+	}
+	return Info{Format: format, ContentType: "image/" + format, Width: config.Width, Height: config.Height}, nil
+}
+
+// ! Thumbnail --> JPEG fitting in side x side with the aspect kept, transparency flattened onto white
+// ? call Inspect first, Thumbnail decodes the whole image
+func Thumbnail(data []byte, side int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > side || h > side {
+		if w >= h {
+			w, h = side, max(1, h*side/w)
+		} else {
+			w, h = max(1, w*side/h), side
+		}
+	}
+	return render(src, bounds, w, h)
+}
+
+// ! Square --> JPEG of the centered square crop, shrunk to side x side (small images keep their size)
+// ? call Inspect first, Square decodes the whole image
+func Square(data []byte, side int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	bounds := src.Bounds()
+	edge := min(bounds.Dx(), bounds.Dy())
+	x0, y0 := bounds.Min.X+(bounds.Dx()-edge)/2, bounds.Min.Y+(bounds.Dy()-edge)/2
+	crop := image.Rect(x0, y0, x0+edge, y0+edge)
+	side = min(side, edge)
+	return render(src, crop, side, side)
+}
+
+// * render --> area of src scaled to w x h and encoded as JPEG
+func render(src image.Image, area image.Rectangle, w, h int) ([]byte, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			dst.SetRGBA(x, y, average(src, boxOf(area, x, y, w, h)))
+		}
+	}
+
+	var out bytes.Buffer
+	err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80})
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// * boxOf --> the source rectangle that lands on output pixel (x, y)
+func boxOf(bounds image.Rectangle, x, y, w, h int) image.Rectangle {
+	sw, sh := bounds.Dx(), bounds.Dy()
+	return image.Rect(
+		bounds.Min.X+x*sw/w, bounds.Min.Y+y*sh/h,
+		bounds.Min.X+max((x+1)*sw/w, x*sw/w+1), bounds.Min.Y+max((y+1)*sh/h, y*sh/h+1),
+	)
+}
+
+// * average --> mean colour of up to 4x4 samples spread over box, composited onto white
+// ? sampling instead of visiting every source pixel keeps an 8000px photo fast to shrink
+func average(src image.Image, box image.Rectangle) color.RGBA {
+	const grid = 4
+	stepX, stepY := max(box.Dx()/grid, 1), max(box.Dy()/grid, 1)
+	var r, g, b, n uint64
+	for y := box.Min.Y; y < box.Max.Y; y += stepY {
+		for x := box.Min.X; x < box.Max.X; x += stepX {
+			cr, cg, cb, ca := src.At(x, y).RGBA() //* alpha-premultiplied, so adding the uncovered part of white is enough
+			r += uint64(cr + 0xffff - ca)
+			g += uint64(cg + 0xffff - ca)
+			b += uint64(cb + 0xffff - ca)
+			n++
+		}
+	}
+	return color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: 0xff}
+}
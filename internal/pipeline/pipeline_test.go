@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tag --> middleware that appends its name to the X-Trace response header
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestPipelineOrdering(t *testing.T) {
+	p := New(Stage{"usage", tag("usage")}, Stage{"auth", tag("auth")})
+
+	require.NoError(t, p.Before("auth", "corp_sso", tag("corp_sso")))
+	require.NoError(t, p.After("auth", "audit", tag("audit")))
+	require.NoError(t, p.Use("last", tag("last")))
+	require.NoError(t, p.Replace("usage", tag("usage2")))
+	assert.Equal(t, []string{"usage", "corp_sso", "auth", "audit", "last"}, p.Names())
+
+	require.NoError(t, p.Remove("last"))
+	rec := httptest.NewRecorder()
+	p.Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "usage2,corp_sso,auth,audit", strings.Join(rec.Header().Values("X-Trace"), ","))
+}
+
+func TestPipelineErrors(t *testing.T) {
+	p := New(Stage{"auth", tag("auth")})
+
+	assert.Error(t, p.Use("auth", tag("again")))
+	assert.Error(t, p.Before("missing", "x", tag("x")))
+	assert.Error(t, p.After("missing", "x", tag("x")))
+	assert.Error(t, p.Remove("missing"))
+	assert.Error(t, p.Use("nil", nil))
+	assert.Panics(t, func() { New(Stage{"a", tag("a")}, Stage{"a", tag("a")}) })
+}
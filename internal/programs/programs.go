@@ -0,0 +1,157 @@
+// ! package programs --> multi-week training programs: validation, and turning an enrollment into planned sessions
+// ? every program day an enrollment covers becomes a one-off schedule, so occurrences, reminders and the
+// ? calendar work on it like on any other plan
+package programs
+
+import (
+	"errors"
+	"fem/internal/reminders"
+	"fem/internal/store"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" //* enrollment timezones must resolve in the runtime image too
+)
+
+// ! limits on user input
+const (
+	MaxWeeks           = 52
+	MaxTemplatesPerDay = 5
+	maxTitleLength     = 100
+	maxDayTitleLength  = 255
+)
+
+// ! DefaultTimeOfDay --> when planned sessions start unless the enrollment says otherwise
+const DefaultTimeOfDay = "18:00"
+
+// ! DefaultDurationMinutes --> planned length of a day whose templates are all gone
+const DefaultDurationMinutes = 60
+
+// ! oneOff --> the rrule of a program day's schedule, a single occurrence at its starts_at
+const oneOff = "FREQ=DAILY;COUNT=1"
+
+// ! Validate --> checks a program from the API, error messages are safe to show
+// ? Visibility defaults to private; that the templates are workouts the author may use is the handler's job
+func Validate(p *store.Program) error {
+	p.Title = strings.TrimSpace(p.Title)
+	if p.Title == "" || len(p.Title) > maxTitleLength {
+		return fmt.Errorf("title is required and must be at most %d characters", maxTitleLength)
+	}
+	if p.Visibility == "" {
+		p.Visibility = store.ProgramPrivate
+	}
+	if p.Visibility != store.ProgramPrivate && p.Visibility != store.ProgramPublic {
+		return errors.New("visibility must be private or public")
+	}
+	if len(p.Weeks) == 0 || len(p.Weeks) > MaxWeeks {
+		return fmt.Errorf("a program needs between 1 and %d weeks", MaxWeeks)
+	}
+
+	days := 0
+	for i, week := range p.Weeks {
+		seen := map[int]bool{}
+		for j := range week.Days {
+			day := &p.Weeks[i].Days[j]
+			day.Title = strings.TrimSpace(day.Title)
+			if day.Day < 1 || day.Day > 7 {
+				return fmt.Errorf("week %d: day must be between 1 and 7", i+1)
+			}
+			if seen[day.Day] {
+				return fmt.Errorf("week %d: day %d is listed twice", i+1, day.Day)
+			}
+			seen[day.Day] = true
+			if len(day.Title) > maxDayTitleLength {
+				return fmt.Errorf("week %d, day %d: title must be at most %d characters", i+1, day.Day, maxDayTitleLength)
+			}
+			if len(day.Templates) == 0 || len(day.Templates) > MaxTemplatesPerDay {
+				return fmt.Errorf("week %d, day %d: needs between 1 and %d template workouts", i+1, day.Day, MaxTemplatesPerDay)
+			}
+			days++
+		}
+	}
+	if days == 0 {
+		return errors.New("a program needs at least one training day")
+	}
+	return nil
+}
+
+// ! WorkoutIDs --> every template the program refers to, once each
+func WorkoutIDs(p *store.Program) []int {
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, week := range p.Weeks {
+		for _, day := range week.Days {
+			for _, template := range day.Templates {
+				if !seen[template.WorkoutID] {
+					seen[template.WorkoutID] = true
+					ids = append(ids, template.WorkoutID)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// ! Plan --> one schedule per program day for the enrolled user, day 1 of week 1 falls on StartsOn
+// ? sessions start at TimeOfDay in the enrollment's timezone, DST-safe like reminders
+func Plan(p *store.Program, e *store.ProgramEnrollment) ([]*store.Schedule, error) {
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	startsOn, err := time.Parse(time.DateOnly, e.StartsOn)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := reminders.ParseClock(e.TimeOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := []*store.Schedule{}
+	for _, week := range p.Weeks {
+		for _, day := range week.Days {
+			date := startsOn.AddDate(0, 0, (week.Number-1)*7+day.Day-1)
+			schedules = append(schedules, &store.Schedule{
+				UserID:          e.UserID,
+				Title:           dayTitle(day),
+				Description:     fmt.Sprintf("%s - week %d, day %d", p.Title, week.Number, day.Day),
+				DurationMinutes: dayDuration(day),
+				StartsAt:        time.Date(date.Year(), date.Month(), date.Day(), int(offset.Hours()), int(offset.Minutes())%60, 0, 0, loc),
+				RRule:           oneOff,
+			})
+		}
+	}
+	return schedules, nil
+}
+
+// * dayTitle --> the day's own title, the template titles joined otherwise
+func dayTitle(day store.ProgramDay) string {
+	if day.Title != "" {
+		return day.Title
+	}
+	titles := []string{}
+	for _, template := range day.Templates {
+		titles = append(titles, template.Title)
+	}
+	if len(titles) == 0 {
+		return fmt.Sprintf("Day %d", day.Day)
+	}
+	title := strings.Join(titles, " + ")
+	if len(title) > maxDayTitleLength {
+		title = strings.ToValidUTF8(title[:maxDayTitleLength], "") //* schedules.title is a VARCHAR(255)
+	}
+	return title
+}
+
+// * dayDuration --> the templates back to back
+func dayDuration(day store.ProgramDay) int {
+	minutes := 0
+	for _, template := range day.Templates {
+		minutes += template.DurationMinutes
+	}
+	if minutes <= 0 {
+		return DefaultDurationMinutes
+	}
+	return minutes
+}
@@ -0,0 +1,126 @@
+package programs
+
+import (
+	"fem/internal/memstore"
+	"fem/internal/schedule"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	day := func(n int, workoutIDs ...int) store.ProgramDay {
+		d := store.ProgramDay{Day: n}
+		for _, id := range workoutIDs {
+			d.Templates = append(d.Templates, store.ProgramTemplate{WorkoutID: id})
+		}
+		return d
+	}
+
+	p := &store.Program{Title: "  5x5  ", Weeks: []store.ProgramWeek{{Days: []store.ProgramDay{day(1, 1), day(3, 2, 1)}}}}
+	require.NoError(t, Validate(p))
+	assert.Equal(t, "5x5", p.Title)
+	assert.Equal(t, store.ProgramPrivate, p.Visibility)
+	assert.Equal(t, []int{1, 2}, WorkoutIDs(p))
+
+	bad := map[string]*store.Program{
+		"no title":     {Weeks: p.Weeks},
+		"visibility":   {Title: "x", Visibility: "friends", Weeks: p.Weeks},
+		"no weeks":     {Title: "x"},
+		"no days":      {Title: "x", Weeks: []store.ProgramWeek{{}}},
+		"day 8":        {Title: "x", Weeks: []store.ProgramWeek{{Days: []store.ProgramDay{day(8, 1)}}}},
+		"day twice":    {Title: "x", Weeks: []store.ProgramWeek{{Days: []store.ProgramDay{day(2, 1), day(2, 1)}}}},
+		"no templates": {Title: "x", Weeks: []store.ProgramWeek{{Days: []store.ProgramDay{day(2)}}}},
+	}
+	for name, program := range bad {
+		assert.Error(t, Validate(program), name)
+	}
+}
+
+// ! TestPlan --> days are counted from starts_on, the local time holds across the DST change
+func TestPlan(t *testing.T) {
+	p := &store.Program{Title: "Base", Weeks: []store.ProgramWeek{
+		{Number: 1, Days: []store.ProgramDay{{Day: 1, Templates: []store.ProgramTemplate{{WorkoutID: 1, Title: "Run", DurationMinutes: 30}, {WorkoutID: 2, Title: "Core", DurationMinutes: 15}}}}},
+		{Number: 2, Days: []store.ProgramDay{{Day: 3, Title: "Long run", Templates: []store.ProgramTemplate{{WorkoutID: 1, Title: "Run", DurationMinutes: 30}}}}},
+	}}
+	e := &store.ProgramEnrollment{UserID: 7, StartsOn: "2026-10-20", TimeOfDay: "07:30", Timezone: "Europe/Berlin"}
+
+	schedules, err := Plan(p, e)
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	assert.Equal(t, "Run + Core", schedules[0].Title)
+	assert.Equal(t, 45, schedules[0].DurationMinutes)
+	assert.Equal(t, time.Date(2026, 10, 20, 5, 30, 0, 0, time.UTC), schedules[0].StartsAt.UTC(), "CEST")
+	assert.Equal(t, "Long run", schedules[1].Title)
+	assert.Equal(t, "Base - week 2, day 3", schedules[1].Description)
+	assert.Equal(t, time.Date(2026, 10, 29, 6, 30, 0, 0, time.UTC), schedules[1].StartsAt.UTC(), "CET")
+	for _, s := range schedules {
+		_, err := schedule.Parse(s.RRule)
+		require.NoError(t, err)
+		assert.Equal(t, 7, s.UserID)
+	}
+}
+
+// ! TestEnrollment --> enrolling puts every day on the schedule once, leaving takes them off again
+func TestEnrollment(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	ids := map[string]int{}
+	for _, name := range []string{"coach", "ana"} {
+		user := &store.User{Username: name, Email: name + "@example.com"}
+		require.NoError(t, user.PasswordHash.Set("password"))
+		require.NoError(t, users.CreateUser(user))
+		ids[name] = user.ID
+	}
+	template, err := memstore.NewWorkoutStore(db).CreateWorkout(&store.Workout{UserID: ids["coach"], Title: "Intervals", DurationMinutes: 40, Visibility: store.VisibilityPublic})
+	require.NoError(t, err)
+
+	programStore := memstore.NewProgramStore(db)
+	p := &store.Program{UserID: ids["coach"], Title: "Couch to 5k", Visibility: store.ProgramPublic, Weeks: []store.ProgramWeek{
+		{Days: []store.ProgramDay{{Day: 1, Templates: []store.ProgramTemplate{{WorkoutID: template.ID}}}, {Day: 4, Templates: []store.ProgramTemplate{{WorkoutID: template.ID}}}}},
+		{Days: []store.ProgramDay{{Day: 2, Templates: []store.ProgramTemplate{{WorkoutID: template.ID}}}}},
+	}}
+	require.NoError(t, Validate(p))
+	require.NoError(t, programStore.CreateProgram(p))
+	p, err = programStore.GetProgram(int64(p.ID))
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.Weeks[1].Number)
+	assert.Equal(t, "Intervals", p.Weeks[0].Days[0].Templates[0].Title, "titles come from the template workout")
+
+	e := &store.ProgramEnrollment{ProgramID: p.ID, UserID: ids["ana"], StartsOn: "2026-11-02", TimeOfDay: "18:00", Timezone: "UTC"}
+	planned, err := Plan(p, e)
+	require.NoError(t, err)
+	require.NoError(t, programStore.Enroll(e, planned))
+	assert.ErrorIs(t, programStore.Enroll(&store.ProgramEnrollment{ProgramID: p.ID, UserID: ids["ana"], StartsOn: "2026-11-02", TimeOfDay: "18:00", Timezone: "UTC"}, nil), store.ErrConflict)
+
+	schedules := memstore.NewScheduleStore(db)
+	materializer := schedule.NewMaterializer(schedules, 8*7*24*time.Hour, time.Hour, log.New(io.Discard, "", 0))
+	now := time.Date(2026, 10, 30, 12, 0, 0, 0, time.UTC)
+	_, err = materializer.RunOnce(now)
+	require.NoError(t, err)
+	_, err = materializer.RunOnce(now.Add(time.Hour))
+	require.NoError(t, err)
+
+	occurrences, err := schedules.ListOccurrences(ids["ana"], now, now.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 3, "one per program day, not repeated")
+	assert.Equal(t, time.Date(2026, 11, 2, 18, 0, 0, 0, time.UTC), occurrences[0].OccursAt)
+	assert.Equal(t, time.Date(2026, 11, 5, 18, 0, 0, 0, time.UTC), occurrences[1].OccursAt)
+	assert.Equal(t, time.Date(2026, 11, 10, 18, 0, 0, 0, time.UTC), occurrences[2].OccursAt)
+	assert.Equal(t, "Intervals", occurrences[0].Title)
+
+	listed, err := programStore.ListPrograms(ids["ana"])
+	require.NoError(t, err)
+	require.Len(t, listed, 1, "enrolled programs are listed")
+
+	require.NoError(t, programStore.Unenroll(int64(p.ID), ids["ana"]))
+	occurrences, err = schedules.ListOccurrences(ids["ana"], now, now.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	assert.Empty(t, occurrences)
+	assert.ErrorIs(t, programStore.Unenroll(int64(p.ID), ids["ana"]), store.ErrNotFound)
+}
@@ -0,0 +1,84 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ! defaults for routes that don't set their own limit
+const (
+	DefaultRequestsPerMinute = 60
+	DefaultBurst             = 20
+)
+
+// ! Limit --> token bucket per client IP, zero fields fall back to the file defaults
+type Limit struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst"`
+}
+
+// ! Config --> read endpoints an operator opened to anonymous callers, loaded from PUBLIC_API_FILE
+// ? keys are route patterns without the version prefix, e.g. "/users/{id}/profile"
+type Config struct {
+	Limit
+	Routes map[string]Limit `json:"routes"`
+}
+
+// ! LoadFromEnv --> no PUBLIC_API_FILE means nothing is exposed and everything stays behind auth
+func LoadFromEnv() (*Config, error) {
+	path := os.Getenv("PUBLIC_API_FILE")
+	if path == "" {
+		return Parse([]byte(`{}`))
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// ! Parse --> fills in defaults so every exposed route ends up with a concrete limit
+func Parse(raw []byte) (*Config, error) {
+	config := &Config{}
+	err := json.Unmarshal(raw, config)
+	if err != nil {
+		return nil, fmt.Errorf("public api config: %w", err)
+	}
+	if config.RequestsPerMinute == 0 {
+		config.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+	if config.Burst == 0 {
+		config.Burst = DefaultBurst
+	}
+	if config.RequestsPerMinute < 0 || config.Burst < 0 {
+		return nil, fmt.Errorf("public api config: limits must be positive")
+	}
+
+	routes := make(map[string]Limit, len(config.Routes))
+	for pattern, limit := range config.Routes {
+		if !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("public api config: route %q must start with /", pattern)
+		}
+		if limit.RequestsPerMinute < 0 || limit.Burst < 0 {
+			return nil, fmt.Errorf("public api config: %s: limits must be positive", pattern)
+		}
+		if limit.RequestsPerMinute == 0 {
+			limit.RequestsPerMinute = config.RequestsPerMinute
+		}
+		if limit.Burst == 0 {
+			limit.Burst = config.Burst
+		}
+		routes[pattern] = limit
+	}
+	config.Routes = routes
+	return config, nil
+}
+
+// ! Exposed --> limit for a route pattern, false when the route isn't public
+func (c *Config) Exposed(pattern string) (Limit, bool) {
+	limit, ok := c.Routes[pattern]
+	return limit, ok
+}
@@ -0,0 +1,155 @@
+package publicapi
+
+import (
+	"fem/internal/middleware"
+	"fem/internal/utils"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ! sweepInterval --> how often idle buckets are dropped so one-off IPs don't pile up
+const sweepInterval = time.Minute
+
+// ! bucket --> tokens left for one (route, ip) pair
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+type bucketKey struct {
+	pattern string
+	ip      string
+}
+
+// ! Gate --> lets anonymous GETs through on exposed routes, everything else keeps its normal auth
+type Gate struct {
+	config atomic.Pointer[Config] //* swapped whole by SetConfig (SIGHUP reloads)
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+// ! NewGate --> constructor, a nil config exposes nothing
+func NewGate(config *Config) *Gate {
+	g := &Gate{now: time.Now, buckets: map[bucketKey]*bucket{}}
+	g.SetConfig(config)
+	return g
+}
+
+// ! SetConfig --> new routes + limits from the next request on, nil exposes nothing
+// ? buckets are dropped so changed limits apply right away, callers get a fresh burst
+func (g *Gate) SetConfig(config *Config) {
+	if config == nil {
+		config = &Config{Routes: map[string]Limit{}}
+	}
+	g.config.Store(config)
+	g.mu.Lock()
+	clear(g.buckets)
+	g.mu.Unlock()
+}
+
+// ! Or --> wraps an auth check (RequireUser), anonymous reads of exposed routes skip it but are rate limited
+// ? must run after Authenticate, signed-in callers always take the normal path and aren't limited here
+func (g *Gate) Or(auth func(http.HandlerFunc) http.HandlerFunc) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		protected := auth(next)
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !isRead(r) || !middleware.GetUser(r).IsAnonymousUser() {
+				protected(w, r)
+				return
+			}
+			pattern := routePattern(r)
+			limit, ok := g.config.Load().Exposed(pattern)
+			if !ok {
+				protected(w, r)
+				return
+			}
+			if !g.allow(w, pattern, middleware.ClientIP(r), limit) {
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// ! Limit --> for routes that are public anyway (shared links, badges), applies the route's limit when it's listed
+func (g *Gate) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pattern := routePattern(r)
+		limit, ok := g.config.Load().Exposed(pattern)
+		if ok && !g.allow(w, pattern, middleware.ClientIP(r), limit) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ! allow --> takes one token, writes the 429 itself when the bucket is empty
+func (g *Gate) allow(w http.ResponseWriter, pattern, ip string, limit Limit) bool {
+	now := g.now()
+	rate := float64(limit.RequestsPerMinute) / 60 //* tokens per second
+
+	g.mu.Lock()
+	if now.Sub(g.lastSweep) >= sweepInterval {
+		g.sweep(now)
+	}
+	key := bucketKey{pattern: pattern, ip: ip}
+	b, ok := g.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now, limit: limit}
+		g.buckets[key] = b
+	}
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	missing := 1 - b.tokens
+	g.mu.Unlock()
+
+	if !allowed {
+		wait := int(math.Ceil(missing / rate))
+		w.Header().Set("Retry-After", strconv.Itoa(max(wait, 1)))
+		utils.WriteJson(w, http.StatusTooManyRequests, utils.Envelope{"error": "rate limit exceeded, sign in for higher limits"})
+	}
+	return allowed
+}
+
+// ! sweep --> drops buckets that have refilled completely, caller holds mu
+func (g *Gate) sweep(now time.Time) {
+	for key, b := range g.buckets {
+		full := float64(b.limit.Burst) / (float64(b.limit.RequestsPerMinute) / 60)
+		if now.Sub(b.updated).Seconds() >= full {
+			delete(g.buckets, key)
+		}
+	}
+	g.lastSweep = now
+}
+
+// ! routePattern --> matched chi pattern with the version prefix cut off, so /v1/x and legacy /x share a config entry
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return r.URL.Path
+	}
+	pattern := rctx.RoutePattern()
+	if trimmed, ok := strings.CutPrefix(pattern, "/v"+middleware.APIVersion+"/"); ok {
+		return "/" + trimmed
+	}
+	return pattern
+}
+
+func isRead(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
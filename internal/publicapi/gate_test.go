@@ -0,0 +1,92 @@
+package publicapi
+
+import (
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefaults(t *testing.T) {
+	config, err := Parse([]byte(`{"burst": 5, "routes": {"/leaderboards/xp": {}, "/users/{id}/profile": {"requests_per_minute": 10}}}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, Limit{RequestsPerMinute: DefaultRequestsPerMinute, Burst: 5}, config.Routes["/leaderboards/xp"])
+	assert.Equal(t, Limit{RequestsPerMinute: 10, Burst: 5}, config.Routes["/users/{id}/profile"])
+
+	_, err = Parse([]byte(`{"routes": {"leaderboards/xp": {}}}`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`{"routes": {"/leaderboards/xp": {"burst": -1}}}`))
+	assert.Error(t, err)
+}
+
+func TestGateOr(t *testing.T) {
+	config, err := Parse([]byte(`{"routes": {"/open": {"requests_per_minute": 60, "burst": 2}}}`))
+	require.NoError(t, err)
+	gate := NewGate(config)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	gate.now = func() time.Time { return now }
+
+	requireUser := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if middleware.GetUser(r).IsAnonymousUser() {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	public := gate.Or(requireUser)
+
+	user := &store.User{ID: 7}
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "" {
+				next.ServeHTTP(w, middleware.SetUser(req, user))
+				return
+			}
+			next.ServeHTTP(w, middleware.SetUser(req, store.AnonymousUser))
+		})
+	})
+	r.Route("/v"+middleware.APIVersion, func(r chi.Router) {
+		r.Get("/open", public(ok))
+	})
+	r.Get("/open", public(ok))
+	r.Post("/open", public(ok))
+	r.Get("/closed", public(ok))
+
+	call := func(method, path string, signedIn bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "203.0.113.9:4000"
+		if signedIn {
+			req.Header.Set("Authorization", "Bearer x")
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/closed", false).Code)
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodPost, "/open", false).Code) //* only reads are exposed
+
+	//* versioned and legacy paths share one bucket
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/v"+middleware.APIVersion+"/open", false).Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/open", false).Code)
+	limited := call(http.MethodGet, "/open", false)
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+
+	//* signed-in callers are not limited by the public gate
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/open", true).Code)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/open", false).Code)
+}
@@ -0,0 +1,66 @@
+// ! package reminders --> per-user workout reminder rules, evaluated by a worker job in each rule's timezone
+// ? delivery goes through the notification paths the app already has: the live event stream + optional email
+package reminders
+
+import (
+	"fem/internal/store"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" //* the runtime image has no zoneinfo, rule timezones must still resolve
+)
+
+// ! JobEvaluate --> background job type, sends every reminder that is due
+const JobEvaluate = "reminders.evaluate"
+
+// ! limits on user input
+const (
+	MaxRemindersPerUser = 10
+	MaxSnooze           = 24 * time.Hour
+	DefaultSnooze       = 10 * time.Minute
+	maxNameLength       = 100
+)
+
+// ! LateWindow --> a regular reminder not sent within this long after its time is dropped for the day
+// ? keeps a scheduler outage from delivering this morning's reminder in the evening
+const LateWindow = time.Hour
+
+// * Days --> accepted values of Reminder.Days
+var Days = []string{store.ReminderPlannedDays, store.ReminderEveryDay}
+
+// ! Validate --> checks a reminder from the API, error messages are safe to show
+// ? Timezone defaults to UTC and Days to planned
+func Validate(r *store.Reminder) error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > maxNameLength {
+		return fmt.Errorf("name is required and must be at most %d characters", maxNameLength)
+	}
+	offset, err := ParseClock(r.RemindAt)
+	if err != nil {
+		return fmt.Errorf("remind_at must be a 24h time like 18:00")
+	}
+	r.RemindAt = fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60) //* 6:00 --> 06:00
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil || r.Timezone == "Local" {
+		return fmt.Errorf("timezone must be an IANA name like Europe/Berlin")
+	}
+	if r.Days == "" {
+		r.Days = store.ReminderPlannedDays
+	}
+	if !slices.Contains(Days, r.Days) {
+		return fmt.Errorf("days must be one of %s", strings.Join(Days, ", "))
+	}
+	return nil
+}
+
+// ! ParseClock --> "HH:MM" as the offset from local midnight
+func ParseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
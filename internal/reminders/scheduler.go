@@ -0,0 +1,163 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/events"
+	"fem/internal/mailer"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! Scheduler --> works out which reminders are due and delivers them
+type Scheduler struct {
+	Store     store.ReminderStore
+	Schedules store.ScheduleStore //* planned occurrences, for Days = planned
+	Users     store.UserStore     //* email address for email reminders
+	Bus       *events.Bus         //* ReminderDue, forwarded to the live event stream
+	Jobs      worker.Enqueuer     //* email.send
+	Logger    *log.Logger
+}
+
+// ! NewScheduler --> constructor for the reminder scheduler
+func NewScheduler(reminderStore store.ReminderStore, scheduleStore store.ScheduleStore, userStore store.UserStore, bus *events.Bus, jobs worker.Enqueuer, logger *log.Logger) *Scheduler {
+	return &Scheduler{Store: reminderStore, Schedules: scheduleStore, Users: userStore, Bus: bus, Jobs: jobs, Logger: logger}
+}
+
+// ! EvaluateJob --> worker handler for JobEvaluate, meant to run every minute or so
+func EvaluateJob(s *Scheduler) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		_, err := s.Evaluate(ctx, time.Now())
+		return err
+	}
+}
+
+// ! Evaluate --> delivers every reminder due at now, returns how many went out
+// ? one broken reminder (bad timezone, vanished user) is logged and skipped, the rest still run
+func (s *Scheduler) Evaluate(ctx context.Context, now time.Time) (int, error) {
+	list, err := s.Store.ListEnabledReminders()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, r := range list {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		planned, day, due, err := s.due(r, now)
+		if err != nil {
+			s.Logger.Printf("ERROR: reminder %d: %v", r.ID, err)
+			continue
+		}
+		if !due {
+			continue
+		}
+
+		claimed, err := s.Store.MarkReminderSent(r.ID, day, now)
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+		err = s.deliver(r, planned)
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	if sent > 0 {
+		s.Logger.Printf("reminders: sent %d", sent)
+	}
+	return sent, nil
+}
+
+// * due --> whether r fires at now, the local day it counts for, and today's planned sessions
+// ? a snoozed reminder waits for its snooze and its time of day, it ignores LateWindow and the sent-today check
+func (s *Scheduler) due(r *store.Reminder, now time.Time) ([]*store.Occurrence, time.Time, bool, error) {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	offset, err := ParseClock(r.RemindAt)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	remindAt := time.Date(local.Year(), local.Month(), local.Day(), int(offset.Hours()), int(offset.Minutes())%60, 0, 0, loc) //* DST-safe, not midnight + offset
+	if local.Before(remindAt) {
+		return nil, day, false, nil
+	}
+
+	if r.SnoozedUntil == nil {
+		if !local.Before(remindAt.Add(LateWindow)) {
+			return nil, day, false, nil
+		}
+		if r.LastSentOn != nil && !r.LastSentOn.Before(day) {
+			return nil, day, false, nil
+		}
+	} else if now.Before(*r.SnoozedUntil) {
+		return nil, day, false, nil
+	}
+
+	occurrences, err := s.Schedules.ListOccurrences(r.UserID, midnight, midnight.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, day, false, err
+	}
+	planned := []*store.Occurrence{}
+	for _, o := range occurrences {
+		if o.Status == store.OccurrenceScheduled {
+			planned = append(planned, o)
+		}
+	}
+	if r.Days == store.ReminderPlannedDays && len(planned) == 0 && r.SnoozedUntil == nil {
+		return nil, day, false, nil
+	}
+	return planned, day, true, nil
+}
+
+// * deliver --> live event always, email when the rule asks for it
+func (s *Scheduler) deliver(r *store.Reminder, planned []*store.Occurrence) error {
+	s.Bus.Publish(events.Event{Type: events.ReminderDue, UserID: r.UserID, Ref: strconv.FormatInt(r.ID, 10)})
+	if !r.Email {
+		return nil
+	}
+
+	user, err := s.Users.GetUserByID(int64(r.UserID))
+	if err != nil || user == nil {
+		return err
+	}
+	return s.Jobs.Enqueue(worker.JobSendEmail, mailer.Message{
+		To:      user.Email,
+		Subject: r.Name,
+		Body:    Body(r, planned),
+	})
+}
+
+// ! Body --> email text, lists today's planned sessions in the reminder's timezone
+func Body(r *store.Reminder, planned []*store.Occurrence) string {
+	if len(planned) == 0 {
+		return "Time to train! Log your workout when you're done."
+	}
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	var b strings.Builder
+	b.WriteString("Planned for today:\n\n")
+	for _, o := range planned {
+		fmt.Fprintf(&b, "- %s %s (%d min)\n", o.OccursAt.In(loc).Format("15:04"), o.Title, o.DurationMinutes)
+	}
+	b.WriteString("\nSnooze or change this reminder under /reminders.")
+	return b.String()
+}
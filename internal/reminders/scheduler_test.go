@@ -0,0 +1,92 @@
+package reminders
+
+import (
+	"context"
+	"fem/internal/events"
+	"fem/internal/mailer"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingJobs --> keeps enqueued emails instead of running them
+type recordingJobs struct {
+	emails []mailer.Message
+}
+
+func (j *recordingJobs) Enqueue(jobType string, payload any) error {
+	j.emails = append(j.emails, payload.(mailer.Message))
+	return nil
+}
+
+// ! TestEvaluate --> fires once on a planned day in the rule's timezone, not on other days, again after a snooze
+func TestEvaluate(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("password"))
+	require.NoError(t, memstore.NewUserStore(db).CreateUser(user))
+
+	//* one planned session, Wednesday 2026-10-14 19:00 Berlin (17:00 UTC)
+	schedules := memstore.NewScheduleStore(db)
+	plan := &store.Schedule{UserID: user.ID, Title: "Leg day", DurationMinutes: 60, StartsAt: time.Date(2026, 10, 14, 17, 0, 0, 0, time.UTC), RRule: "FREQ=WEEKLY"}
+	require.NoError(t, schedules.CreateSchedule(plan))
+	require.NoError(t, schedules.SaveOccurrences(plan, []time.Time{plan.StartsAt}, plan.StartsAt))
+
+	reminderStore := memstore.NewReminderStore(db)
+	reminder := &store.Reminder{UserID: user.ID, Name: "Train tonight", RemindAt: "18:00", Timezone: "Europe/Berlin", Email: true, Enabled: true}
+	require.NoError(t, Validate(reminder))
+	require.NoError(t, reminderStore.CreateReminder(reminder))
+
+	bus := events.NewBus(logger)
+	jobs := &recordingJobs{}
+	s := NewScheduler(reminderStore, schedules, memstore.NewUserStore(db), bus, jobs, logger)
+	ctx := context.Background()
+	at := func(hour, minute int) time.Time { return time.Date(2026, 10, 14, hour, minute, 0, 0, time.UTC) }
+
+	sent, err := s.Evaluate(ctx, at(15, 59)) //* 17:59 in Berlin
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	sent, err = s.Evaluate(ctx, at(16, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, jobs.emails, 1)
+	assert.Equal(t, "ana@example.com", jobs.emails[0].To)
+	assert.Contains(t, jobs.emails[0].Body, "19:00 Leg day (60 min)")
+
+	sent, err = s.Evaluate(ctx, at(16, 5))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent, "once per day")
+
+	require.NoError(t, reminderStore.SnoozeReminder(reminder.ID, at(16, 15)))
+	sent, err = s.Evaluate(ctx, at(16, 10))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent, "still snoozed")
+	sent, err = s.Evaluate(ctx, at(16, 15))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "snooze over")
+
+	sent, err = s.Evaluate(ctx, at(16, 0).AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent, "nothing planned on Thursday")
+}
+
+func TestValidate(t *testing.T) {
+	r := &store.Reminder{Name: " Evening ", RemindAt: "6:30"}
+	require.NoError(t, Validate(r))
+	assert.Equal(t, "Evening", r.Name)
+	assert.Equal(t, "06:30", r.RemindAt)
+	assert.Equal(t, "UTC", r.Timezone)
+	assert.Equal(t, store.ReminderPlannedDays, r.Days)
+
+	assert.Error(t, Validate(&store.Reminder{Name: "x", RemindAt: "25:00"}))
+	assert.Error(t, Validate(&store.Reminder{Name: "x", RemindAt: "18:00", Timezone: "Mars/Olympus"}))
+	assert.Error(t, Validate(&store.Reminder{Name: "x", RemindAt: "18:00", Days: "weekends"}))
+}
@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"database/sql"
+	"expvar"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"net/http"
+	"net/http/pprof"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ! RouteInfo --> one method + pattern registered on the router
+type RouteInfo struct {
+	Method      string   `json:"method"`
+	Pattern     string   `json:"pattern"`
+	Handler     string   `json:"handler"`
+	Middlewares []string `json:"middlewares"` //* outermost first, inline wrappers like RequireUser show up in Handler
+}
+
+// ! ListRoutes --> walks the chi tree, sorted by pattern then method
+func ListRoutes(r chi.Routes) ([]RouteInfo, error) {
+	routes := []RouteInfo{}
+	err := chi.Walk(r, func(method, pattern string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		info := RouteInfo{Method: method, Pattern: pattern, Handler: funcName(handler), Middlewares: []string{}}
+		for _, mw := range middlewares {
+			info.Middlewares = append(info.Middlewares, funcName(mw))
+		}
+		routes = append(routes, info)
+		return nil
+	})
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, err
+}
+
+// ! funcName --> "api.(*WorkoutHandler).HandleCreateWorkout" style name of a handler or middleware
+func funcName(fn any) string {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func {
+		return reflect.TypeOf(fn).String() //* http.Handler values that aren't funcs, e.g. *spa.Handler
+	}
+	name := runtime.FuncForPC(value.Pointer()).Name()
+	name = strings.TrimPrefix(name, "fem/internal/")
+	return strings.TrimSuffix(name, "-fm") //* method values
+}
+
+// ! debugRoutes --> GET /debug/routes, the API surface as the router sees it
+func debugRoutes(r chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		routes, err := ListRoutes(r)
+		if err != nil {
+			utils.WriteJson(w, http.StatusInternalServerError, utils.Envelope{"error": "internal server error"})
+			return
+		}
+		utils.WriteJson(w, http.StatusOK, utils.Envelope{"routes": routes})
+	}
+}
+
+// ! debugDB --> GET /debug/db, configured pool limits next to live sql.DBStats
+func debugDB(db *sql.DB, pool store.PoolConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		utils.WriteJson(w, http.StatusOK, utils.Envelope{"config": pool, "stats": store.GetPoolStats(db)})
+	}
+}
+
+// * started --> process start as far as uptime is concerned
+var started = time.Now()
+
+// ! RuntimeStats --> GET /debug/runtime, the numbers worth a glance before pulling a profile
+type RuntimeStats struct {
+	GoVersion     string    `json:"go_version"`
+	Uptime        string    `json:"uptime"`
+	NumCPU        int       `json:"num_cpu"`
+	GOMAXPROCS    int       `json:"gomaxprocs"`
+	Goroutines    int       `json:"goroutines"`
+	HeapAlloc     uint64    `json:"heap_alloc_bytes"`
+	HeapInuse     uint64    `json:"heap_inuse_bytes"`
+	HeapObjects   uint64    `json:"heap_objects"`
+	Sys           uint64    `json:"sys_bytes"` //* everything obtained from the OS
+	NumGC         uint32    `json:"num_gc"`
+	LastGC        time.Time `json:"last_gc"`
+	PauseTotal    string    `json:"gc_pause_total"`
+	GCCPUFraction float64   `json:"gc_cpu_fraction"`
+}
+
+// ! ReadRuntimeStats --> ReadMemStats stops the world briefly, fine on demand, not for a scrape loop
+func ReadRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return RuntimeStats{
+		GoVersion:     runtime.Version(),
+		Uptime:        time.Since(started).Round(time.Second).String(),
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		Sys:           mem.Sys,
+		NumGC:         mem.NumGC,
+		LastGC:        time.Unix(0, int64(mem.LastGC)).UTC(),
+		PauseTotal:    time.Duration(mem.PauseTotalNs).String(),
+		GCCPUFraction: mem.GCCPUFraction,
+	}
+}
+
+func debugRuntime(w http.ResponseWriter, req *http.Request) {
+	utils.WriteJson(w, http.StatusOK, utils.Envelope{"runtime": ReadRuntimeStats()})
+}
+
+// ! debugEndpoints --> every /debug/* route on r, the caller decides who may reach them
+// ? root is the router /debug/routes describes, r may be a group of it
+func debugEndpoints(r chi.Router, root chi.Routes, db *sql.DB, pool store.PoolConfig) {
+	r.Get("/debug/routes", debugRoutes(root))
+	r.Get("/debug/db", debugDB(db, pool))
+	r.Get("/debug/runtime", debugRuntime)
+	r.Get("/debug/vars", expvar.Handler().ServeHTTP) //* cmdline + memstats + anything published with expvar
+
+	//* go tool pprof http://host/debug/pprof/profile?seconds=30 --> CPU, /debug/pprof/heap --> heap
+	r.Get("/debug/pprof/", pprof.Index)
+	r.Get("/debug/pprof/cmdline", pprof.Cmdline)
+	r.Get("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol) //* GET + POST
+	r.Get("/debug/pprof/trace", pprof.Trace)
+	r.Get("/debug/pprof/{profile}", pprof.Index) //* heap, goroutine, allocs, block, mutex, threadcreate
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"fem/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noop(w http.ResponseWriter, req *http.Request) {}
+
+func passthrough(next http.Handler) http.Handler { return next }
+
+func TestListRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/health", noop)
+	r.Group(func(r chi.Router) {
+		r.Use(passthrough)
+		r.Delete("/workouts/{id}", noop)
+		r.Get("/workouts/{id}", noop)
+	})
+
+	routes, err := ListRoutes(r)
+	require.NoError(t, err)
+	require.Len(t, routes, 3)
+	assert.Equal(t, RouteInfo{Method: "GET", Pattern: "/health", Handler: "routes.noop", Middlewares: []string{}}, routes[0])
+	assert.Equal(t, "DELETE", routes[1].Method)
+	assert.Equal(t, []string{"routes.passthrough"}, routes[2].Middlewares)
+}
+
+func TestDebugEndpoints(t *testing.T) {
+	r := chi.NewRouter()
+	debugEndpoints(r, r, nil, store.PoolConfig{})
+
+	for _, path := range []string{"/debug/runtime", "/debug/vars", "/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+	var body struct {
+		Runtime RuntimeStats `json:"runtime"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Positive(t, body.Runtime.Goroutines)
+	assert.Positive(t, body.Runtime.HeapAlloc)
+}
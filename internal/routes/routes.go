@@ -1,33 +1,302 @@
 package routes
 
 import (
+	"fem/internal/adminui"
 	"fem/internal/app"
+	"fem/internal/middleware"
+	"net/http"
 
 	"github.com/go-chi/chi/v5"
 )
 
-//! SetupRoutes --> configures all HTTP routes for the application
+//! SetupRoutes --> configures all HTTP routes for the application on one router (public + admin)
 //! Request flow: Server → Router → Middleware → Handler → Response
 func SetupRoutes(app *app.Application) *chi.Mux {
+	return setupRoutes(app,true,true)
+}
+
+//! SetupPublicRoutes --> only the public API, admin + debug + metrics live on the admin port (ADMIN_PORT)
+func SetupPublicRoutes(app *app.Application) *chi.Mux {
+	return setupRoutes(app,true,false)
+}
+
+//! SetupAdminRoutes --> admin UI + API, /metrics and /debug/* for the internal admin port
+//? sign-in is served here too, so the admin UI works without reaching the public port
+func SetupAdminRoutes(app *app.Application) *chi.Mux {
+	return setupRoutes(app,false,true)
+}
+
+//! SetupDebugRoutes --> only /debug/* (pprof, runtime stats, routes, db pool), no auth --> for the loopback DEBUG_ADDR listener
+func SetupDebugRoutes(app *app.Application) *chi.Mux {
+	r := chi.NewRouter()
+	debugEndpoints(r,SetupRoutes(app),app.DB,app.DBPool) //* /debug/routes still describes the API
+	return r
+}
+
+func setupRoutes(app *app.Application, public, admin bool) *chi.Mux {
 
 	//* create new chi router instance
 	r := chi.NewRouter()
+	r.Use(app.Pipeline.Middlewares()...) //* global middleware chain, order lives in app.Pipeline
+
+	//! versioned API --> /v1/... is the stable surface, a breaking change gets its own /vN mount
+	r.Route("/v"+middleware.APIVersion,func (r chi.Router) {
+		r.Use(app.VersionMiddleware.Versioned(middleware.APIVersion))
+		apiRoutes(r,app,public,admin)
+	})
+
+	//! legacy unprefixed paths --> same handlers, answered with Deprecation + Sunset headers until they are removed
+	r.Group(func (r chi.Router) {
+		r.Use(app.VersionMiddleware.Legacy)
+		apiRoutes(r,app,public,admin)
+	})
+
+	//* both ports answer probes, so each listener can be health checked on its own
+	r.Get("/health",app.HealthCheck) //* health check endpoint
+	r.Get("/ready",app.Lifecycle.HandleReady) //* readiness probe, 503 while draining or a dependency is down
+
+	if admin {
+		adminRoutes(r,app)
+	}
+	if !public {
+		return r
+	}
+
+	//! SCIM routes --> called by identity providers with an org SCIM token, not a user token
+	r.Route("/scim/v2",func (r chi.Router) {
+		r.Use(app.SCIMMiddleware.Authenticate)
+		r.Get("/Users",app.SCIMHandler.HandleListUsers)
+		r.Post("/Users",app.SCIMHandler.HandleCreateUser)
+		r.Get("/Users/{id}",app.SCIMHandler.HandleGetUser)
+		r.Put("/Users/{id}",app.SCIMHandler.HandleReplaceUser)
+		r.Patch("/Users/{id}",app.SCIMHandler.HandlePatchUser)
+		r.Delete("/Users/{id}",app.SCIMHandler.HandleDeleteUser)
+	})
+
+	//! SPA fallback --> anything the API doesn't match goes to the web frontend (when WEB_UI is set)
+	if app.SPA != nil {
+		r.NotFound(app.SPA.ServeHTTP)
+	}
+	return r //* return configured router
+
+}
+
+//! adminRoutes --> unversioned admin + infrastructure routes, not part of the API contract
+func adminRoutes(r chi.Router, app *app.Application) {
+	r.Handle("/admin",adminui.Handler()) //* embedded admin UI, signs in against /v1/tokens/authentication
+	r.Handle("/admin/assets/*",adminui.Handler())
+
+	r.Get("/metrics",app.Metrics.ServeHTTP) //* Prometheus scrape endpoint
+
+	//! debug endpoints --> anyone in development (APP_ENV=development), admins only everywhere else
+	//? DEBUG_ADDR serves them without auth on a loopback-only listener, see SetupDebugRoutes
+	r.Group(func (debug chi.Router) {
+		if !app.DevMode {
+			debug.Use(app.UserPipeline.Middlewares()...)
+			debug.Use(func (next http.Handler) http.Handler { return app.Middleware.RequireAdmin(next.ServeHTTP) })
+		}
+		debugEndpoints(debug,r,app.DB,app.DBPool)
+	})
+}
+
+//! apiRoutes --> every API endpoint, mounted once per version prefix (and once more unprefixed for old clients)
+//? public / admin pick the halves, the admin port only gets sign-in next to the admin API
+func apiRoutes(r chi.Router, app *app.Application, public, admin bool) {
+	if admin {
+		//! admin API --> RequireAdmin on every route, on top of whichever port serves it
+		r.Group(func (r chi.Router) {
+			r.Use(app.UserPipeline.Middlewares()...)
+			r.Use(app.Audit.AdminActions) //* writes below land in the audit log, denied ones included
+
+			r.Post("/admin/seasonal-events",app.Middleware.RequireAdmin(app.SeasonalEventHandler.HandleCreateEvent)) //* CREATE seasonal event (admins)
+			r.Put("/admin/seasonal-events/{id}",app.Middleware.RequireAdmin(app.SeasonalEventHandler.HandleUpdateEvent)) //* UPDATE seasonal event (admins)
+			r.Delete("/admin/seasonal-events/{id}",app.Middleware.RequireAdmin(app.SeasonalEventHandler.HandleDeleteEvent)) //* DELETE seasonal event (admins)
+			r.Get("/admin/clients/usage",app.Middleware.RequireAdmin(app.ClientUsageHandler.HandleGetUsage)) //* requests per client version + route (admins)
+			r.Get("/admin/users",app.Middleware.RequireAdmin(app.AdminHandler.HandleSearchUsers)) //* SEARCH users (admins)
+			r.Get("/admin/users/{id}",app.Middleware.RequireAdmin(app.AdminHandler.HandleGetUser)) //* GET user (admins)
+			r.Delete("/admin/users/{id}/tokens",app.Middleware.RequireAdmin(app.AdminHandler.HandleRevokeTokens)) //* REVOKE user's sessions (admins)
+			r.Delete("/admin/users/{id}/lockout",app.Middleware.RequireAdmin(app.AdminHandler.HandleUnlockUser)) //* LIFT user's login lockout (admins)
+			r.Get("/admin/lockouts",app.Middleware.RequireAdmin(app.AdminHandler.HandleListLockouts)) //* usernames + IPs locked out of login (admins)
+			r.Delete("/admin/lockouts/ip/{ip}",app.Middleware.RequireAdmin(app.AdminHandler.HandleUnlockIP)) //* LIFT an IP's login lockout (admins)
+			r.Get("/admin/feature-flags",app.Middleware.RequireAdmin(app.AdminHandler.HandleGetFeatureFlags)) //* client feature flags (admins)
+			r.Get("/admin/jobs",app.Middleware.RequireAdmin(app.AdminHandler.HandleListJobs)) //* job queue status (admins)
+			r.Get("/admin/shadow-diffs",app.Middleware.RequireAdmin(app.AdminHandler.HandleListShadowDiffs)) //* traffic mirror mismatches (admins)
+			r.Get("/admin/cache",app.Middleware.RequireAdmin(app.CacheHandler.HandleGetStats)) //* token cache hit rate (admins)
+			r.Get("/admin/dual-write",app.Middleware.RequireAdmin(app.DualWriteHandler.HandleGetDualWrite)) //* dual-write flags + metrics (admins)
+			r.Put("/admin/dual-write",app.Middleware.RequireAdmin(app.DualWriteHandler.HandleSetDualWrite)) //* FLIP dual-write flags (admins)
+			r.Get("/admin/maintenance",app.Middleware.RequireAdmin(app.MaintenanceHandler.HandleGetMaintenance)) //* maintenance mode status (admins)
+			r.Put("/admin/maintenance",app.Middleware.RequireAdmin(app.MaintenanceHandler.HandleSetMaintenance)) //* SWITCH maintenance mode on this instance (admins)
+			r.Get("/admin/audit-log",app.Middleware.RequireAdmin(app.AdminHandler.HandleListAuditLog)) //* security audit log, newest first (admins)
+		})
+	}
+	if !public {
+		r.Post("/tokens/authentication",app.TokenHandler.HandleCreateToken) //* admin UI sign-in
+		r.Post("/tokens/authentication/2fa",app.TokenHandler.HandleVerifyTwoFactor) //* admin UI sign-in, second step
+		r.With(app.UserPipeline.Middlewares()...).Delete("/tokens/authentication",app.Middleware.RequireUser(app.TokenHandler.HandleDeleteToken)) //* admin UI sign-out
+		return
+	}
 
 	//! Protected routes group --> requires valid authentication token
 	//! Middleware chain: Authenticate → RequireUser → Handler
 	r.Group(func (r chi.Router) {
-		r.Use(app.Middleware.Authenticate) //* extracts token from Authorization header and validates it
+		r.Use(app.UserPipeline.Middlewares()...) //* authenticate (+ any custom stages) --> user in request context
+		public := app.PublicAPI.Or(app.Middleware.RequireUser) //* like RequireUser, unless PUBLIC_API_FILE opens the route to anonymous reads
 		//* all routes in this group are protected by authentication
+		r.Get("/workouts",app.Middleware.RequireUser(app.WorkoutHandler.HandleListWorkouts)) //* LIST own workouts (?tag= filter, paginated)
 		r.Get("/workouts/{id}",app.Middleware.RequireUser(app.WorkoutHandler.HandleWorkoutByID)) //* GET single workout
 		r.Post("/workouts",app.Middleware.RequireUser(app.WorkoutHandler.HandleCreateWorkout)) //* CREATE new workout
+		r.Post("/workouts/import",app.Middleware.RequireUser(app.WorkoutHandler.HandleImportWorkouts)) //* IMPORT workouts from a CSV/JSON file
+		r.Post("/workouts/batch",app.Middleware.RequireUser(app.WorkoutHandler.HandleBatchWorkouts)) //* BATCH create/update/delete in one transaction
+		r.Get("/sync",app.Middleware.RequireUser(app.SyncHandler.HandleSync)) //* workout changes + tombstones since a cursor
 		r.Put("/workouts/{id}",app.Middleware.RequireUser(app.WorkoutHandler.HandleUpdateWorkoutByID)) //* UPDATE existing workout
+		r.Patch("/workouts/{id}",app.Middleware.RequireUser(app.WorkoutHandler.HandlePatchWorkoutByID)) //* PATCH existing workout (merge patch / JSON Patch)
 		r.Delete("/workouts/{id}",app.Middleware.RequireUser(app.WorkoutHandler.HandleDeleteWorkoutByID)) //* DELETE workout
+		r.Post("/workouts/{id}/share",app.Middleware.RequireUser(app.ShareHandler.HandleCreateShare)) //* CREATE public share link
+		r.Delete("/workouts/{id}/share",app.Middleware.RequireUser(app.ShareHandler.HandleRevokeShares)) //* REVOKE share links
+		r.Put("/workouts/{id}/tags",app.Middleware.RequireUser(app.TagHandler.HandleSetTags)) //* REPLACE workout tags
+		r.Post("/workouts/{id}/tags",app.Middleware.RequireUser(app.TagHandler.HandleAddTag)) //* ADD one tag
+		r.Delete("/workouts/{id}/tags/{tag}",app.Middleware.RequireUser(app.TagHandler.HandleRemoveTag)) //* REMOVE one tag
+		r.Get("/tags",app.Middleware.RequireUser(app.TagHandler.HandleListTags)) //* own tags with usage counts (?prefix= autocomplete)
+		r.Post("/workouts/{id}/photos",app.Middleware.RequireUser(app.PhotoHandler.HandleUploadPhoto)) //* UPLOAD a photo (multipart "photo" field)
+		r.Delete("/workouts/{id}/photos/{photoID}",app.Middleware.RequireUser(app.PhotoHandler.HandleDeletePhoto)) //* DELETE a photo + its thumbnail
+		r.Post("/workouts/{id}/comments",app.Middleware.RequireUser(app.CommentHandler.HandleCreateComment)) //* ADD comment
+		r.Get("/workouts/{id}/comments",app.Middleware.RequireUser(app.CommentHandler.HandleListComments)) //* LIST comments (paginated)
+		r.Delete("/workouts/{id}/comments/{commentID}",app.Middleware.RequireUser(app.CommentHandler.HandleDeleteComment)) //* DELETE comment (author or owner)
+		r.Post("/workouts/{id}/reactions",app.Middleware.RequireUser(app.CommentHandler.HandleAddReaction)) //* REACT with an emoji
+		r.Delete("/workouts/{id}/reactions/{emoji}",app.Middleware.RequireUser(app.CommentHandler.HandleRemoveReaction)) //* REMOVE reaction
+		r.Get("/workouts/{id}/verification",app.Middleware.RequireUser(app.VerificationHandler.HandleGetVerification)) //* verification status
+		r.Post("/workouts/{id}/verification",app.Middleware.RequireUser(app.VerificationHandler.HandleAttachVideo)) //* ATTACH video proof (owner)
+		r.Post("/workouts/{id}/verification/attest",app.Middleware.RequireUser(app.VerificationHandler.HandleAttest)) //* ATTEST workout (coach)
+
+		r.Delete("/tokens/authentication",app.Middleware.RequireUser(app.TokenHandler.HandleDeleteToken)) //* logout, revokes the current token
+
+		r.Get("/users/me",app.Middleware.RequireUser(app.ProfileHandler.HandleGetMe)) //* GET own profile
+		r.Get("/users/{id}/profile",public(app.ProfileHandler.HandleGetPublicProfile)) //* public profile: username, bio + level
+		r.Put("/users/me",app.Middleware.RequireUser(app.ProfileHandler.HandleUpdateMe)) //* UPDATE own profile
+		r.Patch("/users/me",app.Middleware.RequireUser(app.ProfileHandler.HandlePatchMe)) //* PATCH own profile (merge patch / JSON Patch)
+		r.Put("/users/me/avatar",app.Middleware.RequireUser(app.AvatarHandler.HandleSetAvatar)) //* UPLOAD avatar (cropped + resized to 256px)
+		r.Delete("/users/me/avatar",app.Middleware.RequireUser(app.AvatarHandler.HandleDeleteAvatar)) //* REMOVE avatar
+		r.Delete("/users/me",app.Middleware.RequireUser(app.UserHandler.HandleDeleteMe)) //* delete own account (password required)
+		r.Put("/users/me/password",app.Middleware.RequireUser(app.UserHandler.HandleChangePassword)) //* change password (current one required)
+		r.Get("/users/me/2fa",app.Middleware.RequireUser(app.TwoFactorHandler.HandleGetTwoFactor)) //* 2FA status + backup codes left
+		r.Post("/users/me/2fa/setup",app.Middleware.RequireUser(app.TwoFactorHandler.HandleSetup)) //* START 2FA: secret + otpauth URI
+		r.Post("/users/me/2fa/confirm",app.Middleware.RequireUser(app.TwoFactorHandler.HandleConfirm)) //* CONFIRM with a code, returns backup codes
+		r.Delete("/users/me/2fa",app.Middleware.RequireUser(app.TwoFactorHandler.HandleDisable)) //* DISABLE 2FA, needs a code
+		r.Get("/users/me/linked-accounts",app.Middleware.RequireUser(app.OAuthHandler.HandleListLinkedAccounts)) //* Google/GitHub identities on this account
+		r.Get("/users/me/sessions",app.Middleware.RequireUser(app.SessionHandler.HandleListSessions)) //* active tokens + device/IP/last used
+		r.Delete("/users/me/sessions",app.Middleware.RequireUser(app.SessionHandler.HandleRevokeAllSessions)) //* LOGOUT everywhere, this token included
+		r.Delete("/users/me/sessions/{id}",app.Middleware.RequireUser(app.SessionHandler.HandleRevokeSession)) //* REVOKE one session
+		r.Post("/users/me/linked-accounts/{provider}",app.Middleware.RequireUser(app.OAuthHandler.HandleStartLink)) //* LINK Google/GitHub to this account, answers authorize_url
+		r.Delete("/users/me/linked-accounts/{provider}",app.Middleware.RequireUser(app.OAuthHandler.HandleUnlink)) //* UNLINK, refused for the sign-up identity while it's the only one
+		r.Post("/users/me/weights",app.Middleware.RequireUser(app.ProfileHandler.HandleAddWeight)) //* LOG body weight
+		r.Get("/users/me/weights",app.Middleware.RequireUser(app.ProfileHandler.HandleListWeights)) //* weight time series
+		r.Get("/users/me/achievements",app.Middleware.RequireUser(app.AchievementHandler.HandleListMyAchievements)) //* achievements + earned badges
+		r.Post("/users/me/export",app.Middleware.RequireUser(app.ExportHandler.HandleCreateAccountExport)) //* START account data export
+		r.Get("/users/me/export/{jobID}",app.Middleware.RequireUser(app.ExportHandler.HandleGetAccountExport)) //* POLL account data export
+		r.Get("/users/me/usage",app.Middleware.RequireUser(app.UsageHandler.HandleGetMyUsage)) //* own request counts, storage + export quota
+		r.Get("/users/me/experiments",app.Middleware.RequireUser(app.ExperimentHandler.HandleGetMyExperiments)) //* A/B test variants (logs exposure)
+		r.Post("/users/{id}/follow",app.Middleware.RequireUser(app.FollowHandler.HandleFollow)) //* FOLLOW user
+		r.Delete("/users/{id}/follow",app.Middleware.RequireUser(app.FollowHandler.HandleUnfollow)) //* UNFOLLOW user
+		r.Get("/feed",app.Middleware.RequireUser(app.FollowHandler.HandleGetFeed)) //* activity feed from followed users
+		r.Get("/events",app.Middleware.RequireUser(app.EventStreamHandler.HandleStream)) //* SSE stream of own workout + feed updates
+		r.Get("/ws",app.LiveHandler.HandleWebSocket) //* live workout sessions, checks auth itself (header or bearer.<token> subprotocol)
+		r.Get("/live-sessions",app.Middleware.RequireUser(app.LiveHandler.HandleListLiveSessions)) //* live sessions of followed users
+		r.Post("/graphql",app.Middleware.RequireUser(app.GraphQLHandler.ServeHTTP)) //* GraphQL queries (workouts + entries, users, stats)
+		r.Get("/graphql",app.Middleware.RequireUser(app.GraphQLHandler.ServeHTTP))
+		r.Get("/leaderboards/xp",public(app.LeaderboardHandler.HandleXPLeaderboard)) //* XP + level standings
+		r.Get("/leaderboards/{metric}",public(app.LeaderboardHandler.HandleMetricLeaderboard)) //* weekly minutes / workouts + streak standings (?scope=friends)
+
+		r.Post("/goals",app.Middleware.RequireUser(app.GoalHandler.HandleCreateGoal)) //* CREATE goal
+		r.Get("/goals",app.Middleware.RequireUser(app.GoalHandler.HandleListGoals)) //* LIST goals with progress
+		r.Get("/goals/{id}",app.Middleware.RequireUser(app.GoalHandler.HandleGetGoal)) //* GET single goal
+		r.Put("/goals/{id}",app.Middleware.RequireUser(app.GoalHandler.HandleUpdateGoal)) //* UPDATE goal target / deadline
+		r.Delete("/goals/{id}",app.Middleware.RequireUser(app.GoalHandler.HandleDeleteGoal)) //* DELETE goal
+
+		r.Post("/schedules",app.Middleware.RequireUser(app.ScheduleHandler.HandleCreateSchedule)) //* CREATE recurring schedule
+		r.Get("/schedules",app.Middleware.RequireUser(app.ScheduleHandler.HandleListSchedules)) //* LIST schedules
+		r.Get("/schedules/occurrences",app.Middleware.RequireUser(app.ScheduleHandler.HandleListOccurrences)) //* upcoming sessions (?from=&to=)
+		r.Get("/schedules/{id}",app.Middleware.RequireUser(app.ScheduleHandler.HandleGetSchedule)) //* GET single schedule
+		r.Put("/schedules/{id}",app.Middleware.RequireUser(app.ScheduleHandler.HandleUpdateSchedule)) //* UPDATE whole series
+		r.Delete("/schedules/{id}",app.Middleware.RequireUser(app.ScheduleHandler.HandleDeleteSchedule)) //* DELETE series
+		r.Put("/schedules/{id}/occurrences/{occurrenceID}",app.Middleware.RequireUser(app.ScheduleHandler.HandleUpdateOccurrence)) //* EDIT single occurrence
+		r.Post("/schedules/{id}/occurrences/{occurrenceID}/skip",app.Middleware.RequireUser(app.ScheduleHandler.HandleSkipOccurrence)) //* SKIP single occurrence
+
+		r.Get("/seasonal-events",public(app.SeasonalEventHandler.HandleListEvents)) //* LIST seasonal events
+		r.Get("/seasonal-events/{id}",public(app.SeasonalEventHandler.HandleGetEvent)) //* GET event + own standing
+		r.Get("/seasonal-events/{id}/standings",public(app.SeasonalEventHandler.HandleGetStandings)) //* event standings
+
+		r.Post("/challenges",app.Middleware.RequireUser(app.ChallengeHandler.HandleCreateChallenge)) //* CREATE challenge, creator joins
+		r.Get("/challenges",app.Middleware.RequireUser(app.ChallengeHandler.HandleListChallenges)) //* LIST own challenges
+		r.Get("/challenges/{id}",app.Middleware.RequireUser(app.ChallengeHandler.HandleGetChallenge)) //* GET challenge + live progress
+		r.Post("/challenges/{id}/join",app.Middleware.RequireUser(app.ChallengeHandler.HandleJoinChallenge)) //* JOIN challenge
+		r.Post("/challenges/{id}/leave",app.Middleware.RequireUser(app.ChallengeHandler.HandleLeaveChallenge)) //* LEAVE challenge
+		r.Get("/challenges/{id}/results",app.Middleware.RequireUser(app.ChallengeHandler.HandleGetResults)) //* final results + winners
+		r.Post("/programs",app.Middleware.RequireUser(app.ProgramHandler.HandleCreateProgram)) //* CREATE program with its weeks, days + templates
+		r.Get("/programs",app.Middleware.RequireUser(app.ProgramHandler.HandleListPrograms)) //* LIST own + enrolled programs
+		r.Get("/programs/{id}",app.Middleware.RequireUser(app.ProgramHandler.HandleGetProgram)) //* GET program + own enrollment
+		r.Put("/programs/{id}",app.Middleware.RequireUser(app.ProgramHandler.HandleUpdateProgram)) //* REPLACE program, author only
+		r.Delete("/programs/{id}",app.Middleware.RequireUser(app.ProgramHandler.HandleDeleteProgram)) //* DELETE program, author only
+		r.Post("/programs/{id}/enroll",app.Middleware.RequireUser(app.ProgramHandler.HandleEnroll)) //* ENROLL, plans every day on the schedule
+		r.Delete("/programs/{id}/enroll",app.Middleware.RequireUser(app.ProgramHandler.HandleUnenroll)) //* LEAVE, planned sessions are removed
+
+		r.Get("/integrations",app.Middleware.RequireUser(app.IntegrationHandler.HandleListIntegrations)) //* connected third-party accounts
+		r.Post("/integrations/strava/connect",app.Middleware.RequireUser(app.IntegrationHandler.HandleConnectStrava)) //* START Strava OAuth flow
+		r.Post("/integrations/strava/sync",app.Middleware.RequireUser(app.IntegrationHandler.HandleSyncStrava)) //* SYNC Strava activities now
+		r.Delete("/integrations/strava",app.Middleware.RequireUser(app.IntegrationHandler.HandleDisconnectStrava)) //* DISCONNECT Strava
+
+		r.Post("/webhooks",app.Middleware.RequireUser(app.WebhookHandler.HandleCreateWebhook)) //* REGISTER webhook (secret shown once)
+		r.Get("/webhooks",app.Middleware.RequireUser(app.WebhookHandler.HandleListWebhooks)) //* LIST webhooks
+		r.Delete("/webhooks/{id}",app.Middleware.RequireUser(app.WebhookHandler.HandleDeleteWebhook)) //* DELETE webhook
+		r.Get("/webhooks/{id}/deliveries",app.Middleware.RequireUser(app.WebhookHandler.HandleListDeliveries)) //* delivery log, newest first
+
+		r.Post("/automation-rules",app.Middleware.RequireUser(app.AutomationHandler.HandleCreateRule)) //* CREATE rule (trigger + condition + action)
+		r.Get("/automation-rules",app.Middleware.RequireUser(app.AutomationHandler.HandleListRules)) //* LIST rules
+		r.Get("/automation-rules/{id}",app.Middleware.RequireUser(app.AutomationHandler.HandleGetRule)) //* GET single rule
+		r.Put("/automation-rules/{id}",app.Middleware.RequireUser(app.AutomationHandler.HandleUpdateRule)) //* REPLACE rule
+		r.Delete("/automation-rules/{id}",app.Middleware.RequireUser(app.AutomationHandler.HandleDeleteRule)) //* DELETE rule
+
+		r.Post("/reminders",app.Middleware.RequireUser(app.ReminderHandler.HandleCreateReminder)) //* CREATE reminder (time + timezone + days)
+		r.Get("/reminders",app.Middleware.RequireUser(app.ReminderHandler.HandleListReminders)) //* LIST reminders
+		r.Get("/reminders/{id}",app.Middleware.RequireUser(app.ReminderHandler.HandleGetReminder)) //* GET single reminder
+		r.Put("/reminders/{id}",app.Middleware.RequireUser(app.ReminderHandler.HandleUpdateReminder)) //* REPLACE reminder
+		r.Delete("/reminders/{id}",app.Middleware.RequireUser(app.ReminderHandler.HandleDeleteReminder)) //* DELETE reminder
+		r.Post("/reminders/{id}/snooze",app.Middleware.RequireUser(app.ReminderHandler.HandleSnoozeReminder)) //* SNOOZE, {"minutes": 10}
+
+		r.Post("/devices",app.Middleware.RequireUser(app.NotificationHandler.HandleRegisterDevice)) //* REGISTER push token (fcm / apns)
+		r.Get("/devices",app.Middleware.RequireUser(app.NotificationHandler.HandleListDevices)) //* LIST own devices
+		r.Delete("/devices/{id}",app.Middleware.RequireUser(app.NotificationHandler.HandleDeleteDevice)) //* DELETE device, e.g. on logout
+		r.Get("/users/me/notification-preferences",app.Middleware.RequireUser(app.NotificationHandler.HandleGetPreferences)) //* GET categories + channels
+		r.Put("/users/me/notification-preferences",app.Middleware.RequireUser(app.NotificationHandler.HandleUpdatePreferences)) //* SWITCH categories + channels on/off
+		r.Get("/notifications",app.Middleware.RequireUser(app.NotificationHandler.HandleListNotifications)) //* LIST inbox, newest first + unread count
+		r.Get("/notifications/unread-count",app.Middleware.RequireUser(app.NotificationHandler.HandleGetUnreadCount)) //* GET unread count for the badge
+		r.Post("/notifications/read-all",app.Middleware.RequireUser(app.NotificationHandler.HandleMarkAllRead)) //* MARK every notification read
+		r.Post("/notifications/{id}/read",app.Middleware.RequireUser(app.NotificationHandler.HandleMarkRead)) //* MARK one notification read
+
+		r.Get("/stats/training-load",app.Middleware.RequireUser(app.TrainingLoadHandler.HandleGetTrainingLoad)) //* acute:chronic workload ratio + risk band
+
+		r.Post("/orgs",app.Middleware.RequireUser(app.OrgHandler.HandleCreateOrg)) //* CREATE org (caller becomes owner)
+		r.Post("/orgs/{id}/scim-token",app.Middleware.RequireUser(app.OrgHandler.HandleRotateSCIMToken)) //* ROTATE org SCIM token
+		r.Put("/orgs/{id}/members/me/privacy",app.Middleware.RequireUser(app.OrgHandler.HandleUpdateMyPrivacy)) //* member export privacy
+		r.Put("/orgs/{id}/members/{userID}/role",app.Middleware.RequireUser(app.OrgHandler.HandleUpdateMemberRole)) //* SET member role (owners)
+		r.Post("/orgs/{id}/exports",app.Middleware.RequireUser(app.ExportHandler.HandleCreateOrgExport)) //* START org export (owners)
+		r.Get("/orgs/{id}/exports/{exportID}",app.Middleware.RequireUser(app.ExportHandler.HandleGetOrgExport)) //* POLL org export status
 	})
 
 	//! Public routes --> no authentication required
-	r.Get("/health",app.HealthCheck) //* health check endpoint
 	r.Post("/users",app.UserHandler.HandleRegisterUser) //* user registration
 	r.Post("/tokens/authentication",app.TokenHandler.HandleCreateToken) //* login / get auth token
-	return r //* return configured router
-
-}
\ No newline at end of file
+	r.Post("/tokens/authentication/2fa",app.TokenHandler.HandleVerifyTwoFactor) //* login second step: challenge token + TOTP / backup code
+	r.Get("/auth/{provider}/login",app.OAuthHandler.HandleLogin) //* social login, redirects to Google/GitHub
+	r.Get("/auth/{provider}/callback",app.OAuthHandler.HandleCallback) //* provider redirects back here, answers with our auth token
+	r.Get("/shared/{token}",app.PublicAPI.Limit(app.ShareHandler.HandleGetShared)) //* read-only shared workout
+	r.Get("/users/{id}/badges/{key}.svg",app.PublicAPI.Limit(app.AchievementHandler.HandleGetBadge)) //* shareable badge image
+	r.Get("/users/{id}/avatar",app.PublicAPI.Limit(app.AvatarHandler.HandleGetAvatar)) //* avatar image, ETag + If-None-Match
+	r.Get("/seasonal-events/{id}/badges/{userID}.svg",app.PublicAPI.Limit(app.SeasonalEventHandler.HandleGetBadge)) //* shareable seasonal event badge
+	r.Get("/integrations/strava/callback",app.IntegrationHandler.HandleStravaCallback) //* OAuth redirect, signed state is the credential
+	r.Get("/client-config",app.ClientConfigHandler.HandleGetClientConfig) //* feature flags, min app version + remote settings
+	r.Get("/exports/{id}/download",app.ExportHandler.HandleDownloadExport) //* signed URL is the credential
+	r.Get("/blobs/*",app.PhotoHandler.HandleDownloadBlob) //* photo + thumbnail downloads (disk blob store), signed URL is the credential
+	if app.SandboxHandler != nil {
+		r.Post("/sandbox",app.SandboxHandler.HandleCreateSandbox) //* throwaway account with sample workouts (SANDBOX_ENABLED)
+	}
+}
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"context"
+	"fem/internal/store"
+	"log"
+	"time"
+)
+
+// ! Materializer --> background job that keeps every schedule's occurrences generated up to Horizon ahead
+type Materializer struct {
+	Store     store.ScheduleStore
+	Horizon   time.Duration
+	BatchSize int
+	Interval  time.Duration
+	Logger    *log.Logger
+}
+
+// ! NewMaterializer --> constructor with sensible defaults for the batch size
+func NewMaterializer(scheduleStore store.ScheduleStore, horizon, interval time.Duration, logger *log.Logger) *Materializer {
+	return &Materializer{
+		Store:     scheduleStore,
+		Horizon:   horizon,
+		BatchSize: 100,
+		Interval:  interval,
+		Logger:    logger,
+	}
+}
+
+// ! Run --> materializes on every tick, returns when ctx is cancelled
+func (m *Materializer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := m.RunOnce(time.Now())
+		if err != nil {
+			m.Logger.Printf("ERROR: schedule materializer: %v", err)
+		} else if count > 0 {
+			m.Logger.Printf("schedule materializer: %d schedules extended", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ! RunOnce --> extends every schedule that falls short of the horizon, one batch at a time
+func (m *Materializer) RunOnce(now time.Time) (int, error) {
+	horizon := now.Add(m.Horizon)
+	total := 0
+	for {
+		schedules, err := m.Store.ListSchedulesToMaterialize(horizon, m.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, s := range schedules {
+			err = m.Materialize(s, now)
+			if err != nil {
+				return total, err
+			}
+		}
+		total += len(schedules)
+		if len(schedules) < m.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// ! Materialize --> generates occurrences between the last high-water mark (or now) and the horizon
+// ? slots in the past are never backfilled, missing a session you can't attend anymore is just noise
+func (m *Materializer) Materialize(s *store.Schedule, now time.Time) error {
+	rule, err := Parse(s.RRule)
+	if err != nil {
+		//* rules are validated on write, a bad one here means it was edited in the db --> park it at the horizon
+		m.Logger.Printf("ERROR: schedule %d has invalid rrule: %v", s.ID, err)
+		return m.Store.SaveOccurrences(s, nil, now.Add(m.Horizon))
+	}
+
+	from := now
+	if s.MaterializedUntil != nil && s.MaterializedUntil.After(now) {
+		from = *s.MaterializedUntil
+	}
+	until := now.Add(m.Horizon)
+
+	return m.Store.SaveOccurrences(s, rule.Between(s.StartsAt, from, until), until)
+}
@@ -0,0 +1,176 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! supported frequencies --> the RRULE subset people actually use for training plans
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// ! maxOccurrences --> safety valve so a bad rule can't generate an unbounded series in one pass
+const maxOccurrences = 1000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ! Rule --> parsed recurrence, e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR;COUNT=12
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday //* WEEKLY only, defaults to the weekday of the start
+	Count    int            //* 0 = unlimited
+	Until    *time.Time
+}
+
+// ! Parse --> strict parser, unknown parts are rejected rather than silently ignored
+func Parse(raw string) (*Rule, error) {
+	rule := &Rule{Interval: 1}
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "RRULE:")
+	if raw == "" {
+		return nil, errors.New("rrule is required")
+	}
+
+	for _, part := range strings.Split(raw, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+			if rule.Freq != FreqDaily && rule.Freq != FreqWeekly && rule.Freq != FreqMonthly {
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > 52 {
+				return nil, errors.New("INTERVAL must be between 1 and 52")
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 || count > maxOccurrences {
+				return nil, fmt.Errorf("COUNT must be between 1 and %d", maxOccurrences)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != FreqWeekly {
+		return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot be combined")
+	}
+	return rule, nil
+}
+
+// ! parseUntil --> RFC 5545 date or UTC date-time (20250131 / 20250131T180000Z)
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil //* whole day is included
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// ! Between --> occurrence start times in [from, to)
+// ? COUNT is always counted from start, so asking for a later window gives the same series
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	occurrences := []time.Time{}
+	n := 0
+
+	emit := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		if !t.Before(to) {
+			return false
+		}
+		n++
+		if r.Count > 0 && n > r.Count {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return true
+	}
+
+	switch r.Freq {
+	case FreqDaily:
+		for t := start; emit(t); t = t.AddDate(0, 0, r.Interval) {
+		}
+	case FreqMonthly:
+		//? months without the start day (e.g. the 31st) are skipped like RFC 5545 does
+		for i := 0; ; i += r.Interval {
+			t := start.AddDate(0, i, 0)
+			if t.Day() != start.Day() {
+				continue
+			}
+			if !emit(t) {
+				break
+			}
+		}
+	case FreqWeekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		//* walk week by week from the Sunday of the start week
+		weekStart := start.AddDate(0, 0, -int(start.Weekday()))
+		for week := 0; ; week += r.Interval {
+			base := weekStart.AddDate(0, 0, 7*week)
+			for wd := time.Sunday; wd <= time.Saturday; wd++ {
+				if !slices.Contains(days, wd) {
+					continue
+				}
+				if !emit(base.AddDate(0, 0, int(wd))) {
+					return occurrences
+				}
+			}
+		}
+	}
+	return occurrences
+}
@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dates(times []time.Time) []string {
+	out := []string{}
+	for _, t := range times {
+		out = append(out, t.Format("2006-01-02 Mon"))
+	}
+	return out
+}
+
+// ! TestParse --> accepted and rejected rules
+func TestParse(t *testing.T) {
+	rule, err := Parse("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR;COUNT=6")
+	require.NoError(t, err)
+	assert.Equal(t, FreqWeekly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Wednesday, time.Friday}, rule.ByDay)
+	assert.Equal(t, 6, rule.Count)
+
+	for _, bad := range []string{"", "FREQ=YEARLY", "FREQ=DAILY;BYDAY=MO", "FREQ=WEEKLY;BYDAY=XX", "FREQ=DAILY;COUNT=2;UNTIL=20250101", "INTERVAL=2"} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// ! TestBetween --> table-driven expansion checks
+func TestBetween(t *testing.T) {
+	start := time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC) // * a Monday
+	from := start
+	to := start.AddDate(0, 0, 21)
+
+	test := []struct {
+		name  string
+		rrule string
+		from  time.Time
+		want  []string
+	}{
+		{
+			name:  "mon wed fri",
+			rrule: "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4",
+			from:  from,
+			want:  []string{"2025-01-06 Mon", "2025-01-08 Wed", "2025-01-10 Fri", "2025-01-13 Mon"},
+		},
+		{
+			name:  "every 2 weeks on the start weekday",
+			rrule: "FREQ=WEEKLY;INTERVAL=2",
+			from:  from,
+			want:  []string{"2025-01-06 Mon", "2025-01-20 Mon"},
+		},
+		{
+			name:  "daily until",
+			rrule: "FREQ=DAILY;INTERVAL=3;UNTIL=20250112",
+			from:  from,
+			want:  []string{"2025-01-06 Mon", "2025-01-09 Thu", "2025-01-12 Sun"},
+		},
+		{
+			name:  "later window keeps counting from start",
+			rrule: "FREQ=DAILY;COUNT=5",
+			from:  start.AddDate(0, 0, 3),
+			want:  []string{"2025-01-09 Thu", "2025-01-10 Fri"},
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rrule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, dates(rule.Between(start, tt.from, to)))
+		})
+	}
+}
+
+// ! TestBetweenMonthly --> the 31st only exists in some months
+func TestBetweenMonthly(t *testing.T) {
+	start := time.Date(2025, 1, 31, 7, 0, 0, 0, time.UTC)
+	rule, err := Parse("FREQ=MONTHLY")
+	require.NoError(t, err)
+
+	got := rule.Between(start, start, start.AddDate(0, 4, 0))
+	assert.Equal(t, []string{"2025-01-31 Fri", "2025-03-31 Mon"}, dates(got))
+}
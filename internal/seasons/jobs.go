@@ -0,0 +1,61 @@
+package seasons
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/events"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ! background job types for seasonal events
+const (
+	JobEnroll = "seasonal_events.enroll"
+	JobClose  = "seasonal_events.close"
+)
+
+// ! BadgeKey --> achievement key of an event badge, also the XP ledger reference
+func BadgeKey(eventID int) string {
+	return fmt.Sprintf("season_%d", eventID)
+}
+
+// ! EnrollJob --> auto-enrolls opted-in users into every open event
+func EnrollJob(eventStore store.SeasonalEventStore, logger *log.Logger) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		count, err := eventStore.EnrollOptedInUsers()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logger.Printf("seasonal events: enrolled %d participants", count)
+		}
+		return nil
+	}
+}
+
+// ! CloseJob --> freezes standings of every finished event and announces the badges
+// ? closing is transactional per event, a retry only picks up events that are still open
+func CloseJob(eventStore store.SeasonalEventStore, bus *events.Bus, logger *log.Logger) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		due, err := eventStore.ListEventsToClose(time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, event := range due {
+			results, err := eventStore.CloseSeasonalEvent(int64(event.ID))
+			if err != nil {
+				return err
+			}
+			logger.Printf("seasonal event %d (%s) closed, %d badges awarded", event.ID, event.Name, len(results))
+
+			for _, result := range results {
+				bus.Publish(events.Event{Type: events.AchievementEarned, UserID: result.UserID, Ref: BadgeKey(event.ID)})
+			}
+		}
+		return nil
+	}
+}
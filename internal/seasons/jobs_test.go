@@ -0,0 +1,63 @@
+package seasons
+
+import (
+	"context"
+	"fem/internal/events"
+	"fem/internal/store"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryEventStore --> only what the close job touches
+type memoryEventStore struct {
+	store.SeasonalEventStore
+	due     []*store.SeasonalEvent
+	results map[int64][]*store.EventStanding
+	closed  []int64
+}
+
+func (s *memoryEventStore) ListEventsToClose(now time.Time) ([]*store.SeasonalEvent, error) {
+	return s.due, nil
+}
+
+func (s *memoryEventStore) CloseSeasonalEvent(id int64) ([]*store.EventStanding, error) {
+	s.closed = append(s.closed, id)
+	return s.results[id], nil
+}
+
+func TestCloseJobPublishesBadges(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	eventStore := &memoryEventStore{
+		due: []*store.SeasonalEvent{{ID: 3, Name: "January Volume Challenge"}, {ID: 4, Name: "Empty"}},
+		results: map[int64][]*store.EventStanding{
+			3: {{Rank: 1, UserID: 10, Score: 500}, {Rank: 2, UserID: 11, Score: 200}},
+		},
+	}
+
+	bus := events.NewBus(logger)
+	var mu sync.Mutex
+	earned := []events.Event{}
+	bus.Subscribe(func(e events.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		earned = append(earned, e)
+		return nil
+	}, events.AchievementEarned)
+
+	err := CloseJob(eventStore, bus, logger)(context.Background(), nil)
+	require.NoError(t, err)
+	bus.Wait()
+
+	assert.Equal(t, []int64{3, 4}, eventStore.closed)
+	require.Len(t, earned, 2)
+	for _, e := range earned {
+		assert.Equal(t, "season_3", e.Ref)
+		assert.Contains(t, []int{10, 11}, e.UserID)
+	}
+}
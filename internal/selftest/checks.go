@@ -0,0 +1,213 @@
+package selftest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fem/internal/cache"
+	"fem/internal/dualwrite"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! enumSettings --> env vars with a fixed set of values, empty means the default
+var enumSettings = map[string][]string{
+	"DB_DRIVER":            {"postgres", "sqlite"},
+	"WORKOUT_STORE_DRIVER": {"sql", "pgxpool"},
+	"CACHE_BACKEND":        {"off", "memory", "redis"},
+	"ID_OBFUSCATION":       {"off", "compat", "strict"},
+}
+
+// ! intSettings / durationSettings --> the app falls back to the default on a typo, the self-test reports it instead
+var intSettings = []string{
+	"PORT", "GRPC_PORT", "ADMIN_PORT", "CACHE_SIZE", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "LIVE_MAX_VIEWERS",
+	"WORKER_CONCURRENCY", "SHADOW_PERCENT", "SHADOW_CONCURRENCY", "SSE_REPLAY_SIZE", "WAREHOUSE_BATCH_SIZE",
+}
+
+var durationSettings = []string{
+	"CACHE_TTL", "TOKEN_CACHE_TTL", "DB_WAIT_TIMEOUT", "DB_PING_INTERVAL", "DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME",
+	"SHUTDOWN_DRAIN_DELAY", "SHUTDOWN_TIMEOUT", "WORKER_POLL_INTERVAL", "TOKEN_CLEANUP_INTERVAL", "SSE_HEARTBEAT_INTERVAL",
+	"WEBHOOKS_TIMEOUT", "SHADOW_TIMEOUT",
+}
+
+// ! DefaultChecks --> what `-selftest` runs: config, database, migrations, export storage, mailer, cache
+// ? read only apart from a probe file in EXPORT_DIR, nothing is migrated or sent
+func DefaultChecks(migrationFS fs.FS) []Check {
+	return []Check{
+		{Name: "config", Run: checkConfig},
+		{Name: "database", Run: checkDatabase},
+		{Name: "migrations", Run: func(ctx context.Context) (string, error) { return checkMigrations(ctx, migrationFS) }},
+		{Name: "blob_storage", Run: checkBlobStorage},
+		{Name: "mailer", Run: checkMailer},
+		{Name: "cache", Run: checkCache},
+	}
+}
+
+// ! checkConfig --> every problem at once, so one CI run shows them all
+func checkConfig(ctx context.Context) (string, error) {
+	var problems []string
+	for key, allowed := range enumSettings {
+		if value := os.Getenv(key); value != "" && !slices.Contains(allowed, value) {
+			problems = append(problems, fmt.Sprintf("%s=%q, want one of %s", key, value, strings.Join(allowed, "|")))
+		}
+	}
+	for _, key := range intSettings {
+		if value := os.Getenv(key); value != "" {
+			if _, err := strconv.Atoi(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s=%q is not an integer", key, value))
+			}
+		}
+	}
+	for _, key := range durationSettings {
+		if value := os.Getenv(key); value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s=%q is not a duration like 30s or 5m", key, value))
+			}
+		}
+	}
+	if mode := os.Getenv("ID_OBFUSCATION"); (mode == "compat" || mode == "strict") && os.Getenv("ID_SALT") == "" {
+		problems = append(problems, "ID_OBFUSCATION="+mode+" needs ID_SALT")
+	}
+	if os.Getenv("DUALWRITE_DATABASE_URL") != "" {
+		if _, err := dualwrite.ParseMode(os.Getenv("DUALWRITE_MODE")); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if os.Getenv("WORKOUT_STORE_DRIVER") == "pgxpool" && os.Getenv("DB_DRIVER") == "sqlite" {
+		problems = append(problems, "WORKOUT_STORE_DRIVER=pgxpool needs DB_DRIVER=postgres")
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+	return "environment parses", nil
+}
+
+// ! openDB --> the database the app would use, without the startup wait
+func openDB() (*sql.DB, string, error) {
+	if utils.GetEnv("DB_DRIVER", "postgres") == "sqlite" {
+		db, err := store.OpenSQLite(utils.GetEnv("SQLITE_PATH", "fittrack.db"))
+		return db, "sqlite", err
+	}
+	db, err := store.Open()
+	return db, "postgres", err
+}
+
+func checkDatabase(ctx context.Context) (string, error) {
+	db, driver, err := openDB()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if driver == "sqlite" {
+		var version string
+		err = db.QueryRowContext(ctx, `SELECT sqlite_version()`).Scan(&version)
+		return "sqlite " + version, err
+	}
+	var version string
+	err = db.QueryRowContext(ctx, `SHOW server_version`).Scan(&version)
+	return "postgres " + version, err
+}
+
+// ! checkMigrations --> pending ones are fine (applied on start), a database ahead of this build is not
+func checkMigrations(ctx context.Context, migrationFS fs.FS) (string, error) {
+	if utils.GetEnv("DB_DRIVER", "postgres") == "sqlite" {
+		return "sqlite schema is created on start", ErrSkipped
+	}
+	db, err := store.Open()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	current, latest, err := store.MigrationStatus(db, migrationFS, ".")
+	if err != nil {
+		return "", err
+	}
+	if current > latest {
+		return "", fmt.Errorf("database is at version %d, this build only knows up to %d (rolled back binary?)", current, latest)
+	}
+	if current < latest {
+		return fmt.Sprintf("version %d, %d pending up to %d (applied on start)", current, latest-current, latest), nil
+	}
+	return fmt.Sprintf("version %d, up to date", current), nil
+}
+
+// ! checkBlobStorage --> EXPORT_DIR exists (or can be created) and takes a write
+func checkBlobStorage(ctx context.Context) (string, error) {
+	dir := utils.GetEnv("EXPORT_DIR", filepath.Join(os.TempDir(), "fittrack-exports"))
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return "", err
+	}
+	probe, err := os.CreateTemp(dir, ".selftest-*")
+	if err != nil {
+		return "", err
+	}
+	probe.Close()
+	return dir + " is writable", os.Remove(probe.Name())
+}
+
+// ! checkMailer --> SMTP relay answers EHLO, nothing is sent
+func checkMailer(ctx context.Context) (string, error) {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return "SMTP_ADDR not set, emails go to the log", ErrSkipped
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer client.Close()
+	err = client.Hello("localhost")
+	if err != nil {
+		return "", err
+	}
+	return addr + " answers", client.Quit()
+}
+
+// ! checkCache --> redis gets a PING, the in-process backends have nothing to reach
+func checkCache(ctx context.Context) (string, error) {
+	backend := utils.GetEnv("CACHE_BACKEND", "off")
+	if backend != "redis" {
+		return "CACHE_BACKEND=" + backend + ", nothing to reach", ErrSkipped
+	}
+	redis, err := cache.NewRedis(utils.GetEnv("REDIS_URL", "redis://localhost:6379/0"), "fem:")
+	if err != nil {
+		return "", err
+	}
+	defer redis.Close()
+	return "redis answers", redis.Ping(ctx)
+}
+
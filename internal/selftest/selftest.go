@@ -0,0 +1,62 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ! ErrSkipped --> a check whose dependency isn't configured, reported but not a failure
+var ErrSkipped = errors.New("skipped")
+
+// ! statuses a Result can have
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// ! Check --> one named probe, detail says what was verified ("postgres 16.2", "3 pending")
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (detail string, err error)
+}
+
+// ! Result --> outcome of one check, the shape CI scripts read from the report
+type Result struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ! Report --> OK is false as soon as one check failed, skipped checks don't count
+type Report struct {
+	OK        bool      `json:"ok"`
+	StartedAt time.Time `json:"started_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// ! Run --> checks run in order, each with its own timeout so one hanging dependency can't eat the others' budget
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{OK: true, StartedAt: time.Now().UTC(), Checks: make([]Result, 0, len(checks))}
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		detail, err := check.Run(checkCtx)
+		cancel()
+
+		result := Result{Name: check.Name, Status: StatusOK, Detail: detail, DurationMS: time.Since(start).Milliseconds()}
+		switch {
+		case errors.Is(err, ErrSkipped):
+			result.Status = StatusSkip
+		case err != nil:
+			result.Status = StatusFail
+			result.Error = err.Error()
+			report.OK = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
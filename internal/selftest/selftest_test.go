@@ -0,0 +1,46 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "ok", Run: func(ctx context.Context) (string, error) { return "fine", nil }},
+		{Name: "skip", Run: func(ctx context.Context) (string, error) { return "not configured", ErrSkipped }},
+		{Name: "slow", Run: func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}},
+		{Name: "after", Run: func(ctx context.Context) (string, error) { return "", errors.New("boom") }},
+	}
+
+	report := Run(context.Background(), checks, 20*time.Millisecond)
+	require.Len(t, report.Checks, 4)
+	assert.False(t, report.OK)
+	assert.Equal(t, StatusOK, report.Checks[0].Status)
+	assert.Equal(t, StatusSkip, report.Checks[1].Status)
+	assert.Equal(t, StatusFail, report.Checks[2].Status) //* timed out, the next check still ran with a fresh budget
+	assert.Equal(t, "boom", report.Checks[3].Error)
+
+	report = Run(context.Background(), checks[:2], time.Second)
+	assert.True(t, report.OK)
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Setenv("CACHE_BACKEND", "memcached")
+	t.Setenv("CACHE_TTL", "5 minutes")
+	t.Setenv("PORT", "8080")
+
+	_, err := checkConfig(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `CACHE_BACKEND="memcached"`)
+	assert.Contains(t, err.Error(), `CACHE_TTL="5 minutes"`)
+	assert.NotContains(t, err.Error(), "PORT")
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fem/internal/audit"
+	"fem/internal/password"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"time"
+)
+
+// ! AuthTokenTTL --> lifetime of tokens handed out by Login
+const AuthTokenTTL = 24 * time.Hour
+
+// ! AuthService --> trades credentials for auth tokens and tokens back for users
+type AuthService struct {
+	tokens         store.TokenStore
+	users          store.UserStore
+	now            func() time.Time
+	Audit          *audit.Recorder         //* logins, logouts + password changes, nil records nothing
+	Lockouts       store.LoginLockoutStore //* failed login counts, nil disables lockouts
+	Policy         LockoutPolicy
+	TwoFactor      store.TwoFactorStore     //* TOTP secrets + backup codes, nil means single-factor logins only
+	Issuer         string                   //* shown next to the code in authenticator apps
+	LinkedAccounts store.LinkedAccountStore //* "sign in with" identities, nil turns provider logins off
+	Registrations  *UserService             //* creates accounts for first-time provider logins
+	Sessions       store.SessionStore       //* token metadata for the session list, nil turns it off
+	Passwords      *password.Validator      //* rules for ChangePassword, nil only requires a password
+}
+
+func NewAuthService(tokenStore store.TokenStore, userStore store.UserStore) *AuthService {
+	return &AuthService{tokens: tokenStore, users: userStore, now: time.Now}
+}
+
+// ! Login --> unknown usernames and wrong passwords both answer ErrInvalidCredentials
+// ? a locked username or IP answers LockedOutError before the password is even looked at,
+// ? with 2FA on a right password answers TwoFactorRequiredError instead of a token
+func (s *AuthService) Login(ctx context.Context, username, password string) (*tokens.Token, error) {
+	err := s.checkLockout(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		s.Audit.Record(ctx, store.AuditEntry{Action: audit.LoginFailed, Metadata: map[string]any{"username": username}})
+		return nil, s.loginFailed(ctx, username, 0)
+	}
+
+	ok, err := user.PasswordHash.Matches(password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.Audit.Record(ctx, store.AuditEntry{Action: audit.LoginFailed, TargetID: audit.UserID(user.ID), Metadata: map[string]any{"username": username}})
+		return nil, s.loginFailed(ctx, username, user.ID)
+	}
+
+	token, err := s.issue(ctx, user, nil) //* with 2FA on, failures stay counted until the code is right too
+	if err != nil {
+		return nil, err
+	}
+	err = s.clearUsernameLockout(username)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ! issue --> auth token for a user whose first factor checked out, or TwoFactorRequiredError when 2FA is on
+func (s *AuthService) issue(ctx context.Context, user *store.User, metadata map[string]any) (*tokens.Token, error) {
+	tf, err := s.twoFactorFor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil {
+		challenge, err := s.tokens.CreateNewToken(user.ID, TwoFactorChallengeTTL, tokens.ScopeTwoFactor)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &TwoFactorRequiredError{Challenge: challenge}
+	}
+	token, err := s.tokens.CreateNewToken(user.ID, AuthTokenTTL, tokens.ScopeAuth)
+	if err != nil {
+		return nil, err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.Login, ActorID: audit.UserID(user.ID), Metadata: metadata})
+	return token, nil
+}
+
+// ! loginFailed --> ErrInvalidCredentials, or the lockout this failure just triggered
+func (s *AuthService) loginFailed(ctx context.Context, username string, userID int) error {
+	err := s.recordFailure(ctx, username, userID)
+	if err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// ! Authenticate --> the user behind an auth token, ErrInvalidCredentials when it is unknown or expired
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*store.User, error) {
+	user, err := s.users.GetUserToken(tokens.ScopeAuth, token)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// ! Logout --> revokes the token userID authenticated with, its other sessions stay valid
+func (s *AuthService) Logout(ctx context.Context, userID int, token string) error {
+	err := s.tokens.DeleteToken(tokens.ScopeAuth, token)
+	if err != nil {
+		return err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.Logout, ActorID: audit.UserID(userID)})
+	return nil
+}
+
+// ! ChangePassword --> the current password is asked again, a stolen token alone can't take the account over
+func (s *AuthService) ChangePassword(ctx context.Context, user *store.User, current, next string) error {
+	if next == "" {
+		return invalid("new_password is required")
+	}
+	ok, err := user.PasswordHash.Matches(current)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidCredentials
+	}
+	err = checkPassword(ctx, s.Passwords, next, user.Username, user.Email)
+	if err != nil {
+		return err
+	}
+
+	err = user.PasswordHash.Set(next)
+	if err != nil {
+		return err
+	}
+	err = s.users.UpdatePassword(user)
+	if err != nil {
+		return err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.PasswordChanged, ActorID: audit.UserID(user.ID), TargetID: audit.UserID(user.ID)})
+	return nil
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fem/internal/audit"
+	"fem/internal/hooks"
+	"fem/internal/memstore"
+	"fem/internal/oauth"
+	"fem/internal/store"
+	"fem/internal/totp"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestAuthServiceAudit --> failed + successful logins, password change and logout each leave one entry
+func TestAuthServiceAudit(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("old-password"))
+	require.NoError(t, users.CreateUser(user))
+
+	auditStore := memstore.NewAuditStore(db)
+	auth := NewAuthService(memstore.NewTokenStore(db), users)
+	auth.Audit = audit.NewRecorder(auditStore, log.New(io.Discard, "", 0))
+	ctx := audit.WithClient(context.Background(), audit.Client{IP: "203.0.113.7", UserAgent: "test"})
+
+	_, err := auth.Login(ctx, "ana", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = auth.Login(ctx, "nobody", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	token, err := auth.Login(ctx, "ana", "old-password")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, auth.ChangePassword(ctx, user, "wrong", "new-password"), ErrInvalidCredentials)
+	require.NoError(t, auth.ChangePassword(ctx, user, "old-password", "new-password"))
+	stored, err := users.GetUserByUsername("ana")
+	require.NoError(t, err)
+	ok, err := stored.PasswordHash.Matches("new-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, auth.Logout(ctx, user.ID, token.Plaintext))
+
+	entries, err := auditStore.ListAudit(store.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	actions := []string{}
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+		assert.Equal(t, "203.0.113.7", e.IP)
+	}
+	assert.Equal(t, []string{audit.Logout, audit.PasswordChanged, audit.Login, audit.LoginFailed, audit.LoginFailed}, actions)
+	assert.Equal(t, user.ID, *entries[4].TargetID, "known username, wrong password")
+	assert.Nil(t, entries[3].TargetID, "unknown username")
+}
+
+// ! TestLoginLockout --> the limit locks the username, the lockout doubles and a correct login clears it
+func TestLoginLockout(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("password"))
+	require.NoError(t, users.CreateUser(user))
+
+	lockouts := memstore.NewLoginLockoutStore(db)
+	auth := NewAuthService(memstore.NewTokenStore(db), users)
+	auth.Lockouts = lockouts
+	auth.Policy = LockoutPolicy{MaxFailures: 3, MaxFailuresPerIP: 100, Window: 15 * time.Minute, BaseLockout: time.Minute, MaxLockout: time.Hour}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+	ctx := audit.WithClient(context.Background(), audit.Client{IP: "203.0.113.7"})
+
+	var locked *LockedOutError
+	for range 2 {
+		_, err := auth.Login(ctx, "ana", "wrong")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err := auth.Login(ctx, "ANA", "wrong")
+	require.ErrorAs(t, err, &locked, "the third failure locks, usernames are case-insensitive")
+	assert.Equal(t, now.Add(time.Minute), locked.Until)
+
+	_, err = auth.Login(ctx, "ana", "password")
+	require.ErrorAs(t, err, &locked, "even the right password is refused while locked")
+
+	now = now.Add(time.Minute)
+	for range 3 {
+		_, err = auth.Login(ctx, "ana", "wrong")
+	}
+	require.ErrorAs(t, err, &locked)
+	assert.Equal(t, now.Add(2*time.Minute), locked.Until, "second lockout in a row doubles")
+
+	now = now.Add(2 * time.Minute)
+	_, err = auth.Login(ctx, "ana", "password")
+	require.NoError(t, err)
+	l, err := lockouts.GetLoginLockout(store.LockoutUsername, "ana")
+	require.NoError(t, err)
+	assert.Nil(t, l, "a successful login forgets the username's failures")
+	l, err = lockouts.GetLoginLockout(store.LockoutIP, "203.0.113.7")
+	require.NoError(t, err)
+	assert.Equal(t, 6, l.Failures, "the IP keeps counting")
+
+	assert.Equal(t, time.Hour, auth.Policy.Duration(10))
+}
+
+// ! TestTwoFactorLogin --> setup + confirm, then logins need a fresh code or an unused backup code
+func TestTwoFactorLogin(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("password"))
+	require.NoError(t, users.CreateUser(user))
+
+	auth := NewAuthService(memstore.NewTokenStore(db), users)
+	auth.TwoFactor = memstore.NewTwoFactorStore(db)
+	auth.Issuer = "FitTrack"
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	setup, err := auth.SetupTwoFactor(ctx, user)
+	require.NoError(t, err)
+	assert.Contains(t, setup.URI, "otpauth://totp/FitTrack:ana?")
+	_, err = auth.Login(ctx, "ana", "password")
+	require.NoError(t, err, "pending setup doesn't change logins yet")
+
+	code, err := totp.CodeAt(setup.Secret, totp.Step(now))
+	require.NoError(t, err)
+	backup, err := auth.ConfirmTwoFactor(ctx, user, code)
+	require.NoError(t, err)
+	assert.Len(t, backup, BackupCodeCount)
+
+	var required *TwoFactorRequiredError
+	_, err = auth.Login(ctx, "ana", "password")
+	require.ErrorAs(t, err, &required)
+	_, err = auth.VerifyTwoFactor(ctx, required.Challenge.Plaintext, code)
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "the confirming code can't be replayed")
+
+	now = now.Add(totp.Period)
+	code, err = totp.CodeAt(setup.Secret, totp.Step(now))
+	require.NoError(t, err)
+	token, err := auth.VerifyTwoFactor(ctx, required.Challenge.Plaintext, code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.Plaintext)
+	_, err = auth.VerifyTwoFactor(ctx, required.Challenge.Plaintext, code)
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "challenges are single-use")
+
+	_, err = auth.Login(ctx, "ana", "password")
+	require.ErrorAs(t, err, &required)
+	_, err = auth.VerifyTwoFactor(ctx, required.Challenge.Plaintext, backup[0])
+	require.NoError(t, err)
+	status, err := auth.TwoFactorStatus(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, BackupCodeCount-1, status.BackupCodesLeft)
+
+	assert.ErrorIs(t, auth.DisableTwoFactor(ctx, user, backup[0]), ErrInvalidCredentials, "backup codes are single-use")
+	require.NoError(t, auth.DisableTwoFactor(ctx, user, backup[1]))
+	_, err = auth.Login(ctx, "ana", "password")
+	require.NoError(t, err)
+}
+
+// ! TestLoginWithProvider --> new identity creates an account, an existing email is only linked by its signed-in owner, sign-up identity can't be unlinked alone
+func TestLoginWithProvider(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, ana.PasswordHash.Set("password"))
+	require.NoError(t, users.CreateUser(ana))
+
+	logger := log.New(io.Discard, "", 0)
+	auth := NewAuthService(memstore.NewTokenStore(db), users)
+	auth.LinkedAccounts = memstore.NewLinkedAccountStore(db)
+	auth.Registrations = NewUserService(users, hooks.NewRegistry(logger), logger)
+	ctx := context.Background()
+
+	github := &oauth.Identity{Provider: oauth.GitHub, Subject: "1", Email: "ana@example.com", EmailVerified: true, Username: "ana"}
+	var invalid *ValidationError
+	_, _, err := auth.LoginWithProvider(ctx, github)
+	assert.ErrorAs(t, err, &invalid, "a matching email is never linked on its own")
+	linked, err := auth.LinkProvider(ctx, ana.ID, github)
+	require.NoError(t, err)
+	assert.False(t, linked.SignedUp)
+	token, created, err := auth.LoginWithProvider(ctx, github)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, ana.ID, token.UserID)
+
+	_, _, err = auth.LoginWithProvider(ctx, &oauth.Identity{Provider: oauth.Google, Subject: "2", Email: "bo@example.com"})
+	assert.ErrorIs(t, err, oauth.ErrNoEmail, "unverified email")
+
+	token, created, err = auth.LoginWithProvider(ctx, &oauth.Identity{Provider: oauth.Google, Subject: "2", Email: "bo@example.com", EmailVerified: true, Username: "ana"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	bo, err := users.GetUserByID(int64(token.UserID))
+	require.NoError(t, err)
+	assert.NotEqual(t, "ana", bo.Username, "taken username gets a suffix")
+
+	again, created, err := auth.LoginWithProvider(ctx, &oauth.Identity{Provider: oauth.Google, Subject: "2"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, token.UserID, again.UserID, "known identity needs no email")
+	_, err = auth.LinkProvider(ctx, ana.ID, &oauth.Identity{Provider: oauth.Google, Subject: "2"})
+	assert.ErrorAs(t, err, &invalid, "identity belongs to bo")
+
+	assert.ErrorAs(t, auth.UnlinkProvider(ctx, bo, oauth.Google), &invalid)
+	assert.ErrorIs(t, auth.UnlinkProvider(ctx, ana, oauth.Google), ErrNotFound)
+	require.NoError(t, auth.UnlinkProvider(ctx, ana, oauth.GitHub))
+}
+
+// ! TestSessions --> touched tokens list with their metadata, one can be revoked by id, logout everywhere clears the rest
+func TestSessions(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, ana.PasswordHash.Set("password"))
+	require.NoError(t, users.CreateUser(ana))
+
+	sessions := memstore.NewSessionStore(db)
+	auth := NewAuthService(memstore.NewTokenStore(db), users)
+	auth.Sessions = sessions
+	ctx := context.Background()
+
+	phone, err := auth.Login(ctx, "ana", "password")
+	require.NoError(t, err)
+	laptop, err := auth.Login(ctx, "ana", "password")
+	require.NoError(t, err)
+	require.NoError(t, sessions.TouchSession(phone.Plaintext, "203.0.113.7", "phone", time.Now()))
+
+	list, err := auth.ListSessions(ctx, ana, laptop.Plaintext)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "203.0.113.7", list[0].IP, "most recently used first")
+	assert.False(t, list[0].Current)
+	assert.True(t, list[1].Current)
+
+	bo := &store.User{Username: "bo", Email: "bo@example.com"}
+	require.NoError(t, bo.PasswordHash.Set("password"))
+	require.NoError(t, users.CreateUser(bo))
+	assert.ErrorIs(t, auth.RevokeSession(ctx, bo, list[0].ID), ErrNotFound, "not bo's session")
+	require.NoError(t, auth.RevokeSession(ctx, ana, list[0].ID))
+	_, err = auth.Authenticate(ctx, phone.Plaintext)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.NoError(t, auth.LogoutEverywhere(ctx, ana))
+	list, err = auth.ListSessions(ctx, ana, laptop.Plaintext)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fem/internal/blob"
+	"fem/internal/photos"
+	"fem/internal/store"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+)
+
+// ! AvatarSide --> avatars are stored as square JPEGs of at most this many pixels a side
+const AvatarSide = 256
+
+// ! Avatar --> a user's current avatar, ETag is derived from the bytes so it changes with every new image
+type Avatar struct {
+	Key  string
+	ETag string
+}
+
+// ! AvatarService --> avatar uploads: checks, square resize and blob storage
+type AvatarService struct {
+	avatars store.AvatarStore
+	blobs   blob.Store
+	logger  *log.Logger
+
+	MaxBytes int64 //* per upload, AVATAR_MAX_BYTES
+}
+
+// ! NewAvatarService --> 5 MiB uploads
+func NewAvatarService(avatarStore store.AvatarStore, blobs blob.Store, logger *log.Logger) *AvatarService {
+	return &AvatarService{avatars: avatarStore, blobs: blobs, logger: logger, MaxBytes: 5 << 20}
+}
+
+// * avatarFor --> keys are content addressed (avatars/{user}/{hash}.jpeg), the hash doubles as the ETag
+func avatarFor(key string) *Avatar {
+	if key == "" {
+		return nil
+	}
+	return &Avatar{Key: key, ETag: `"` + strings.TrimSuffix(path.Base(key), ".jpeg") + `"`}
+}
+
+// ! Set --> replaces userID's avatar with data cropped + shrunk to AvatarSide, the old blob is removed
+func (s *AvatarService) Set(ctx context.Context, userID int, data []byte) (*Avatar, error) {
+	if int64(len(data)) > s.MaxBytes {
+		return nil, invalid(fmt.Sprintf("avatar can be at most %d MB", s.MaxBytes>>20))
+	}
+	_, err := photos.Inspect(data)
+	if errors.Is(err, photos.ErrDimensions) {
+		return nil, invalid(fmt.Sprintf("avatar can be at most %dx%d pixels", photos.MaxSide, photos.MaxSide))
+	}
+	if err != nil {
+		return nil, invalid("avatar must be a JPEG, PNG or GIF image")
+	}
+	resized, err := photos.Square(data, AvatarSide)
+	if err != nil {
+		return nil, invalid("avatar could not be decoded")
+	}
+
+	sum := sha256.Sum256(resized)
+	key := fmt.Sprintf("avatars/%d/%s.jpeg", userID, hex.EncodeToString(sum[:8]))
+	err = s.blobs.Put(ctx, key, resized, "image/jpeg")
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.avatars.SetAvatarKey(userID, key)
+	if err != nil {
+		s.deleteBlob(key) //* no row points at it
+		return nil, err
+	}
+	if previous != key {
+		s.deleteBlob(previous)
+	}
+	return avatarFor(key), nil
+}
+
+// ! Get --> userID's current avatar, nil when there is none
+func (s *AvatarService) Get(userID int) (*Avatar, error) {
+	key, err := s.avatars.GetAvatarKey(userID)
+	if err != nil {
+		return nil, err
+	}
+	return avatarFor(key), nil
+}
+
+// ! Open --> the avatar's JPEG bytes, ErrNotFound when the blob is gone
+func (s *AvatarService) Open(ctx context.Context, avatar *Avatar) (io.ReadCloser, error) {
+	body, err := s.blobs.Open(ctx, avatar.Key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return body, err
+}
+
+// ! Remove --> clears userID's avatar, ErrNotFound when there was none
+func (s *AvatarService) Remove(ctx context.Context, userID int) error {
+	previous, err := s.avatars.SetAvatarKey(userID, "")
+	if err != nil {
+		return err
+	}
+	if previous == "" {
+		return ErrNotFound
+	}
+	s.deleteBlob(previous)
+	return nil
+}
+
+// * deleteBlob --> best effort, a leftover object only costs storage
+func (s *AvatarService) deleteBlob(key string) {
+	if key == "" {
+		return
+	}
+	err := s.blobs.Delete(context.Background(), key)
+	if err != nil {
+		s.logger.Printf("ERROR: delete avatar blob %s: %v", key, err)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fem/internal/blob"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvatarReplaceAndRemove(t *testing.T) {
+	ctx := context.Background()
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	disk, err := blob.NewDisk(t.TempDir(), "/v1/blobs")
+	require.NoError(t, err)
+	s := NewAvatarService(memstore.NewAvatarStore(db), disk, log.New(io.Discard, "", 0))
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+
+	encode := func(w, h int) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+		return buf.Bytes()
+	}
+
+	first, err := s.Set(ctx, ana.ID, encode(800, 600))
+	require.NoError(t, err)
+	body, err := s.Open(ctx, first)
+	require.NoError(t, err)
+	resized, err := jpeg.DecodeConfig(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, AvatarSide, resized.Width)
+	assert.Equal(t, AvatarSide, resized.Height)
+
+	// * a new image gets a new ETag and the old blob goes
+	second, err := s.Set(ctx, ana.ID, encode(100, 120))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ETag, second.ETag)
+	_, err = s.Open(ctx, first)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	current, err := s.Get(ana.ID)
+	require.NoError(t, err)
+	assert.Equal(t, second, current)
+
+	require.NoError(t, s.Remove(ctx, ana.ID))
+	assert.ErrorIs(t, s.Remove(ctx, ana.ID), ErrNotFound)
+	current, err = s.Get(ana.ID)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+}
@@ -0,0 +1,61 @@
+// ! package service --> business rules shared by the HTTP handlers and the gRPC server
+// ? services take plain arguments and return typed errors, each transport maps them to its own status codes
+package service
+
+import (
+	"context"
+	"errors"
+	"fem/internal/anomaly"
+	"fem/internal/password"
+	"fem/internal/store"
+)
+
+var (
+	ErrNotFound           = store.ErrNotFound //* the same value, a store miss passes through as the service's not found
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUnavailable        = errors.New("not available on this server") //* the feature's store isn't configured (e.g. sqlite mode)
+)
+
+// ! ValidationError --> the input was rejected, Message is safe to show the client
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func invalid(message string) error {
+	return &ValidationError{Message: message}
+}
+
+// ! PasswordError --> the new password failed the strength rules or was found in a breach
+type PasswordError struct {
+	Problems []password.Problem
+}
+
+func (e *PasswordError) Error() string {
+	return "password is too weak: " + e.Problems[0].Message
+}
+
+// * checkPassword --> nil validator accepts anything non-empty
+func checkPassword(ctx context.Context, v *password.Validator, plaintext, username, email string) error {
+	if v == nil {
+		return nil
+	}
+	problems := v.Validate(ctx, plaintext, username, email)
+	if len(problems) > 0 {
+		return &PasswordError{Problems: problems}
+	}
+	return nil
+}
+
+// ! AnomalyError --> the workout looks implausible and the caller didn't confirm it
+type AnomalyError struct {
+	Warnings []anomaly.Warning
+}
+
+func (e *AnomalyError) Error() string {
+	return "workout contains implausible values"
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fem/internal/audit"
+	"fem/internal/store"
+	"fem/internal/utils"
+	"strings"
+	"time"
+)
+
+// ! LockoutPolicy --> when repeated failed logins lock a username or client IP, and for how long
+type LockoutPolicy struct {
+	MaxFailures      int           //* per username before it is locked
+	MaxFailuresPerIP int           //* per client IP, higher since one NAT can hide many users
+	Window           time.Duration //* failures older than this (and past any lockout) are forgotten
+	BaseLockout      time.Duration //* first lockout, doubled for every consecutive one
+	MaxLockout       time.Duration
+}
+
+// ! LockoutPolicyFromEnv --> LOGIN_* env vars override the defaults, LOGIN_MAX_FAILURES=0 turns lockouts off
+func LockoutPolicyFromEnv() LockoutPolicy {
+	return LockoutPolicy{
+		MaxFailures:      utils.GetEnvInt("LOGIN_MAX_FAILURES", 5),
+		MaxFailuresPerIP: utils.GetEnvInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		Window:           utils.GetEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		BaseLockout:      utils.GetEnvDuration("LOGIN_LOCKOUT", time.Minute),
+		MaxLockout:       utils.GetEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+	}
+}
+
+// ! Duration --> BaseLockout * 2^lockouts, capped at MaxLockout
+func (p LockoutPolicy) Duration(lockouts int) time.Duration {
+	d := p.BaseLockout
+	for range lockouts {
+		if d >= p.MaxLockout {
+			break
+		}
+		d *= 2
+	}
+	return min(d, p.MaxLockout)
+}
+
+// ! limit --> failures allowed for a kind, 0 means never lock
+func (p LockoutPolicy) limit(kind string) int {
+	if kind == store.LockoutIP {
+		return p.MaxFailuresPerIP
+	}
+	return p.MaxFailures
+}
+
+// ! LockedOutError --> too many failed logins, nothing is checked until Until
+type LockedOutError struct {
+	Until time.Time
+}
+
+func (e *LockedOutError) Error() string {
+	return "too many failed login attempts"
+}
+
+// ! RetryAfter --> time left from now, at least a second
+func (e *LockedOutError) RetryAfter(now time.Time) time.Duration {
+	return max(e.Until.Sub(now).Round(time.Second), time.Second)
+}
+
+// ! UsernameLockoutKey --> usernames are matched case-insensitively, so "Ana" and "ana" share a count
+func UsernameLockoutKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// ! lockoutKeys --> username + caller IP, an unknown IP (no transport set it) is not tracked
+func lockoutKeys(ctx context.Context, username string) map[string]string {
+	keys := map[string]string{store.LockoutUsername: UsernameLockoutKey(username)}
+	if ip := audit.ClientFrom(ctx).IP; ip != "" {
+		keys[store.LockoutIP] = ip
+	}
+	return keys
+}
+
+// ! checkLockout --> LockedOutError for the latest lockout covering this attempt
+func (s *AuthService) checkLockout(ctx context.Context, username string) error {
+	if s.Lockouts == nil {
+		return nil
+	}
+	now := s.now()
+	var locked *LockedOutError
+	for kind, key := range lockoutKeys(ctx, username) {
+		l, err := s.Lockouts.GetLoginLockout(kind, key)
+		if err != nil {
+			return err
+		}
+		if l.Locked(now) && (locked == nil || l.LockedUntil.After(locked.Until)) {
+			locked = &LockedOutError{Until: *l.LockedUntil}
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// ! recordFailure --> counts the failure, locks whatever crossed its limit and returns that lockout
+func (s *AuthService) recordFailure(ctx context.Context, username string, userID int) error {
+	if s.Lockouts == nil {
+		return nil
+	}
+	now := s.now()
+	var locked *LockedOutError
+	for kind, key := range lockoutKeys(ctx, username) {
+		limit := s.Policy.limit(kind)
+		if limit <= 0 {
+			continue
+		}
+		l, err := s.Lockouts.RecordLoginFailure(kind, key, now, s.Policy.Window)
+		if err != nil {
+			return err
+		}
+		if l.Failures < limit {
+			continue
+		}
+
+		until := now.Add(s.Policy.Duration(l.Lockouts))
+		err = s.Lockouts.LockLogin(kind, key, until)
+		if err != nil {
+			return err
+		}
+		entry := store.AuditEntry{Action: audit.LoginLocked, Metadata: map[string]any{"kind": kind, "key": key, "until": until, "lockouts": l.Lockouts + 1}}
+		if kind == store.LockoutUsername && userID != 0 {
+			entry.TargetID = audit.UserID(userID)
+		}
+		s.Audit.Record(ctx, entry)
+		if locked == nil || until.After(locked.Until) {
+			locked = &LockedOutError{Until: until}
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// ! clearUsernameLockout --> a successful login forgets the username's failures, the IP's count on
+func (s *AuthService) clearUsernameLockout(username string) error {
+	if s.Lockouts == nil {
+		return nil
+	}
+	err := s.Lockouts.ClearLoginLockout(store.LockoutUsername, UsernameLockoutKey(username))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	return nil
+}
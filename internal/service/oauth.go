@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fem/internal/oauth"
+	"fem/internal/store"
+	"fem/internal/tokens"
+)
+
+// ! LoginWithProvider --> auth token for a provider identity, created says whether a new account was made
+// ? known identity --> its user; otherwise a new account. Local emails aren't verified, so an identity is never
+// ? linked to an existing account by email; its owner links it while signed in (LinkProvider)
+func (s *AuthService) LoginWithProvider(ctx context.Context, identity *oauth.Identity) (*tokens.Token, bool, error) {
+	if s.LinkedAccounts == nil || s.Registrations == nil {
+		return nil, false, invalid("sign in with a provider is not available on this server")
+	}
+	account, err := s.LinkedAccounts.GetLinkedAccount(identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, false, err
+	}
+
+	created := false
+	if account == nil {
+		if identity.Email == "" || !identity.EmailVerified {
+			return nil, false, oauth.ErrNoEmail
+		}
+		userID, err := s.LinkedAccounts.UserIDByEmail(identity.Email)
+		if err != nil {
+			return nil, false, err
+		}
+		if userID != 0 {
+			return nil, false, invalid("an account with this email already exists, sign in to it and link " + identity.Provider + " from your account")
+		}
+		user, err := s.Registrations.RegisterExternal(ctx, identity.Username, identity.Email)
+		if err != nil {
+			return nil, false, err
+		}
+		created = true
+		account = &store.LinkedAccount{UserID: user.ID, Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email, SignedUp: true}
+		err = s.LinkedAccounts.CreateLinkedAccount(account)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		err = s.LinkedAccounts.TouchLinkedAccount(account.ID)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	user, err := s.users.GetUserByID(int64(account.UserID))
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, false, ErrInvalidCredentials //* account deleted, its link goes with the purge
+	}
+	token, err := s.issue(ctx, user, map[string]any{"provider": identity.Provider, "created": created})
+	return token, created, err
+}
+
+// ! LinkProvider --> attaches a provider identity to the signed-in user who started the link flow
+// ? linking again to the same user is a no-op, an identity already on another account is refused
+func (s *AuthService) LinkProvider(ctx context.Context, userID int, identity *oauth.Identity) (*store.LinkedAccount, error) {
+	if s.LinkedAccounts == nil {
+		return nil, invalid("sign in with a provider is not available on this server")
+	}
+	user, err := s.users.GetUserByID(int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	account, err := s.LinkedAccounts.GetLinkedAccount(identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		if account.UserID != user.ID {
+			return nil, invalid("this " + identity.Provider + " account is already linked to another user")
+		}
+		return account, nil
+	}
+
+	account = &store.LinkedAccount{UserID: user.ID, Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email}
+	err = s.LinkedAccounts.CreateLinkedAccount(account)
+	if errors.Is(err, store.ErrConflict) {
+		return nil, invalid("another " + identity.Provider + " account is already linked, unlink it first")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ! UnlinkProvider --> refuses to remove the only way into an account that was created through a provider
+func (s *AuthService) UnlinkProvider(ctx context.Context, user *store.User, provider string) error {
+	if s.LinkedAccounts == nil {
+		return ErrNotFound
+	}
+	accounts, err := s.LinkedAccounts.ListLinkedAccounts(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		if a.Provider == provider && a.SignedUp && len(accounts) == 1 {
+			return invalid("this is the only way to sign in to your account, link another provider first")
+		}
+	}
+
+	err = s.LinkedAccounts.DeleteLinkedAccount(user.ID, provider)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ! LinkedProviders --> the user's linked identities, empty when the feature is off
+func (s *AuthService) LinkedProviders(ctx context.Context, user *store.User) ([]*store.LinkedAccount, error) {
+	if s.LinkedAccounts == nil {
+		return []*store.LinkedAccount{}, nil
+	}
+	return s.LinkedAccounts.ListLinkedAccounts(user.ID)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fem/internal/blob"
+	"fem/internal/events"
+	"fem/internal/photos"
+	"fem/internal/store"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ! PhotoService --> workout photo uploads: checks, thumbnails, blob storage and signed links
+type PhotoService struct {
+	photos   store.PhotoStore
+	workouts store.WorkoutStore
+	blobs    blob.Store
+	logger   *log.Logger
+
+	MaxBytes  int64         //* per upload, PHOTO_MAX_BYTES
+	MaxPhotos int           //* per workout
+	LinkTTL   time.Duration //* how long signed URLs in responses work
+}
+
+// ! NewPhotoService --> 10 MiB uploads, 10 photos a workout, hour long links
+func NewPhotoService(photoStore store.PhotoStore, workoutStore store.WorkoutStore, blobs blob.Store, logger *log.Logger) *PhotoService {
+	return &PhotoService{
+		photos:    photoStore,
+		workouts:  workoutStore,
+		blobs:     blobs,
+		logger:    logger,
+		MaxBytes:  10 << 20,
+		MaxPhotos: 10,
+		LinkTTL:   time.Hour,
+	}
+}
+
+// ! Subscribe --> a deleted workout's rows go by cascade, its blobs are removed here
+func (s *PhotoService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(s.HandleEvent, events.WorkoutDeleted)
+}
+
+// ! HandleEvent --> events.Handler for WorkoutDeleted
+func (s *PhotoService) HandleEvent(event events.Event) error {
+	return s.blobs.DeletePrefix(context.Background(), workoutPhotoPrefix(int64(event.WorkoutID)))
+}
+
+func workoutPhotoPrefix(workoutID int64) string {
+	return fmt.Sprintf("workouts/%d/", workoutID)
+}
+
+// * ownWorkout --> ErrNotFound / ErrForbidden unless userID owns workoutID
+func (s *PhotoService) ownWorkout(userID int, workoutID int64) error {
+	owner, err := s.workouts.GetWorkoutOwner(workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if owner != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ! Upload --> stores data as a photo of a workout userID owns, the returned photo carries signed URLs
+// ? the content type comes from the bytes, the client's header and file name are ignored
+func (s *PhotoService) Upload(ctx context.Context, userID int, workoutID int64, data []byte) (*store.WorkoutPhoto, error) {
+	err := s.ownWorkout(userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.MaxBytes {
+		return nil, invalid(fmt.Sprintf("photo can be at most %d MB", s.MaxBytes>>20))
+	}
+	info, err := photos.Inspect(data)
+	if errors.Is(err, photos.ErrDimensions) {
+		return nil, invalid(fmt.Sprintf("photo can be at most %dx%d pixels (%d megapixels)", photos.MaxSide, photos.MaxSide, photos.MaxPixels/1_000_000))
+	}
+	if err != nil {
+		return nil, invalid("photo must be a JPEG, PNG or GIF image")
+	}
+	count, err := s.photos.CountPhotos(workoutID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= s.MaxPhotos {
+		return nil, invalid(fmt.Sprintf("a workout can have at most %d photos", s.MaxPhotos))
+	}
+	thumbnail, err := photos.Thumbnail(data, photos.ThumbnailSide)
+	if err != nil {
+		return nil, invalid("photo could not be decoded")
+	}
+
+	base := workoutPhotoPrefix(workoutID) + strings.ToLower(rand.Text())
+	photo := &store.WorkoutPhoto{
+		WorkoutID:    int(workoutID),
+		Key:          base + "." + info.Format,
+		ThumbnailKey: base + "_thumb.jpeg",
+		ContentType:  info.ContentType,
+		SizeBytes:    int64(len(data)),
+		Width:        info.Width,
+		Height:       info.Height,
+	}
+	err = s.blobs.Put(ctx, photo.Key, data, photo.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	err = s.blobs.Put(ctx, photo.ThumbnailKey, thumbnail, "image/jpeg")
+	if err == nil {
+		err = s.photos.CreatePhoto(photo)
+	}
+	if err != nil {
+		s.deleteBlobs(photo) //* no row points at them
+		return nil, err
+	}
+	return photo, s.sign(photo)
+}
+
+// ! List --> the workout's photos with fresh signed URLs, visibility is the caller's check
+func (s *PhotoService) List(ctx context.Context, workoutID int64) ([]*store.WorkoutPhoto, error) {
+	list, err := s.photos.ListPhotos(workoutID)
+	if err != nil {
+		return nil, err
+	}
+	for _, photo := range list {
+		err = s.sign(photo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// ! Delete --> removes a photo of a workout userID owns, ErrNotFound when it belongs to another workout
+func (s *PhotoService) Delete(ctx context.Context, userID int, workoutID, photoID int64) error {
+	err := s.ownWorkout(userID, workoutID)
+	if err != nil {
+		return err
+	}
+	photo, err := s.photos.GetPhoto(photoID)
+	if err != nil {
+		return err
+	}
+	if photo == nil || int64(photo.WorkoutID) != workoutID {
+		return ErrNotFound
+	}
+	err = s.photos.DeletePhoto(photoID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.deleteBlobs(photo)
+	return nil
+}
+
+// * deleteBlobs --> best effort, a leftover object only costs storage
+func (s *PhotoService) deleteBlobs(photo *store.WorkoutPhoto) {
+	for _, key := range []string{photo.Key, photo.ThumbnailKey} {
+		err := s.blobs.Delete(context.Background(), key)
+		if err != nil {
+			s.logger.Printf("ERROR: delete photo blob %s: %v", key, err)
+		}
+	}
+}
+
+func (s *PhotoService) sign(photo *store.WorkoutPhoto) error {
+	var err error
+	photo.URL, err = s.blobs.SignedURL(photo.Key, s.LinkTTL)
+	if err != nil {
+		return err
+	}
+	photo.ThumbnailURL, err = s.blobs.SignedURL(photo.ThumbnailKey, s.LinkTTL)
+	return err
+}
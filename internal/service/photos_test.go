@@ -0,0 +1,65 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fem/internal/blob"
+	"fem/internal/events"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhotoUpload(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	workouts := memstore.NewWorkoutStore(db)
+	disk, err := blob.NewDisk(t.TempDir(), "/v1/blobs")
+	require.NoError(t, err)
+	s := NewPhotoService(memstore.NewPhotoStore(db), workouts, disk, logger)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	workout, err := workouts.CreateWorkout(&store.Workout{UserID: ana.ID, Title: "run", DurationMinutes: 30})
+	require.NoError(t, err)
+	workoutID := int64(workout.ID)
+
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	img.Set(10, 10, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	photo, err := s.Upload(ctx, ana.ID, workoutID, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", photo.ContentType)
+	assert.Equal(t, 640, photo.Width)
+	assert.Contains(t, photo.URL, "/v1/blobs/workouts/")
+	assert.Contains(t, photo.ThumbnailURL, "_thumb.jpeg")
+
+	_, err = s.Upload(ctx, ben.ID, workoutID, buf.Bytes())
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.Upload(ctx, ana.ID, workoutID, []byte("not an image"))
+	var invalid *ValidationError
+	assert.ErrorAs(t, err, &invalid)
+
+	listed, err := s.List(ctx, workoutID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+
+	// * deleting the workout takes the blobs with it
+	require.NoError(t, s.HandleEvent(events.Event{Type: events.WorkoutDeleted, WorkoutID: workout.ID}))
+	_, err = disk.Open(ctx, photo.Key)
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"time"
+)
+
+// ! Sandbox --> a freshly provisioned throwaway account, Password is only ever shown here
+type Sandbox struct {
+	Username  string        `json:"username"`
+	Password  string        `json:"password"`
+	Token     *tokens.Token `json:"auth_token"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// * sandboxWorkouts --> what a new sandbox starts with, DaysAgo counts back from provisioning
+var sandboxWorkouts = []struct {
+	DaysAgo int
+	Workout store.Workout
+}{
+	{0, store.Workout{Title: "Upper body", DurationMinutes: 50, CaloriesBurned: 380, Visibility: store.VisibilityPublic, Entries: []store.WorkoutEntry{
+		{ExerciseName: "Bench Press", Sets: 4, Reps: intPtr(8), Weight: floatPtr(70), OrderIndex: 1},
+		{ExerciseName: "Pull Up", Sets: 4, Reps: intPtr(8), OrderIndex: 2},
+	}}},
+	{2, store.Workout{Title: "5k run", DurationMinutes: 28, CaloriesBurned: 320, Visibility: store.VisibilityFollowers, Entries: []store.WorkoutEntry{
+		{ExerciseName: "Running", Sets: 1, DurationSeconds: intPtr(1680), OrderIndex: 1},
+	}}},
+	{4, store.Workout{Title: "Leg day", DurationMinutes: 60, CaloriesBurned: 470, Visibility: store.VisibilityPrivate, Entries: []store.WorkoutEntry{
+		{ExerciseName: "Squat", Sets: 5, Reps: intPtr(5), Weight: floatPtr(100), OrderIndex: 1},
+		{ExerciseName: "Plank", Sets: 3, DurationSeconds: intPtr(60), OrderIndex: 2},
+	}}},
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+// ! SandboxService --> provisions throwaway accounts for product demos + API evaluation, the sandbox purge job removes them
+type SandboxService struct {
+	sandboxes store.SandboxStore
+	workouts  store.WorkoutStore
+	tokens    store.TokenStore
+	ttl       time.Duration
+}
+
+func NewSandboxService(sandboxStore store.SandboxStore, workoutStore store.WorkoutStore, tokenStore store.TokenStore, ttl time.Duration) *SandboxService {
+	return &SandboxService{sandboxes: sandboxStore, workouts: workoutStore, tokens: tokenStore, ttl: ttl}
+}
+
+// ! Provision --> random credentials, sample workouts and an auth token that expires with the account
+func (s *SandboxService) Provision(ctx context.Context) (*Sandbox, error) {
+	suffix := make([]byte, 5)
+	_, err := rand.Read(suffix)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 15)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	username := "sandbox-" + hex.EncodeToString(suffix)
+	password := base32.StdEncoding.EncodeToString(secret)
+	user := &store.User{Username: username, Email: username + "@sandbox.fittrack.local", Bio: "Sandbox account, deleted automatically"}
+	err = user.PasswordHash.Set(password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	err = s.sandboxes.CreateSandboxUser(user, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	//* imported like history, sample workouts leave no events in the outbox (no webhooks, XP or feed entries)
+	samples := make([]*store.Workout, len(sandboxWorkouts))
+	for i, sample := range sandboxWorkouts {
+		workout := sample.Workout
+		workout.UserID = user.ID
+		workout.CreatedAt = now.AddDate(0, 0, -sample.DaysAgo)
+		workout.Entries = append([]store.WorkoutEntry(nil), sample.Workout.Entries...)
+		samples[i] = &workout
+	}
+	rowErrs, err := s.workouts.ImportWorkouts(samples)
+	if err != nil {
+		return nil, err
+	}
+	for _, rowErr := range rowErrs {
+		if rowErr != nil {
+			return nil, rowErr
+		}
+	}
+
+	token, err := s.tokens.CreateNewToken(user.ID, s.ttl, tokens.ScopeAuth)
+	if err != nil {
+		return nil, err
+	}
+	return &Sandbox{Username: username, Password: password, Token: token, ExpiresAt: expiresAt}, nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fem/internal/memstore"
+	"fem/internal/tokens"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxProvisionAndPurge(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	sandboxes := memstore.NewSandboxStore(db)
+	s := NewSandboxService(sandboxes, memstore.NewWorkoutStore(db), memstore.NewTokenStore(db), time.Hour)
+
+	sandbox, err := s.Provision(context.Background())
+	require.NoError(t, err)
+	user, err := users.GetUserToken(tokens.ScopeAuth, sandbox.Token.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	assert.Equal(t, sandbox.Username, user.Username)
+	ok, err := user.PasswordHash.Matches(sandbox.Password)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	workouts, err := memstore.NewAccountStore(db).ListAccountWorkouts(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, workouts, len(sandboxWorkouts))
+
+	// * nothing is due yet, an hour later the whole account is gone
+	purged, err := sandboxes.PurgeExpiredSandboxes(time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = sandboxes.PurgeExpiredSandboxes(sandbox.ExpiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	gone, err := users.GetUserByUsername(sandbox.Username)
+	require.NoError(t, err)
+	assert.Nil(t, gone)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fem/internal/audit"
+	"fem/internal/store"
+	"fem/internal/tokens"
+)
+
+// ! ListSessions --> user's active auth tokens, the one current authenticated with is flagged
+func (s *AuthService) ListSessions(ctx context.Context, user *store.User, current string) ([]*store.Session, error) {
+	if s.Sessions == nil {
+		return nil, invalid("session listing is not available on this server")
+	}
+	sessions, err := s.Sessions.ListSessions(user.ID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(current))
+	for _, session := range sessions {
+		session.Current = subtle.ConstantTimeCompare(session.Hash, hash[:]) == 1
+	}
+	return sessions, nil
+}
+
+// ! RevokeSession --> signs one of user's sessions out, ErrNotFound when it isn't theirs (or already gone)
+func (s *AuthService) RevokeSession(ctx context.Context, user *store.User, id int64) error {
+	if s.Sessions == nil {
+		return ErrNotFound
+	}
+	err := s.Sessions.DeleteSession(user.ID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.Logout, ActorID: audit.UserID(user.ID), Metadata: map[string]any{"session_id": id}})
+	return nil
+}
+
+// ! LogoutEverywhere --> revokes every auth token of user, including the one asking
+func (s *AuthService) LogoutEverywhere(ctx context.Context, user *store.User) error {
+	err := s.tokens.DeleteAllTokensForUser(user.ID, tokens.ScopeAuth)
+	if err != nil {
+		return err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.TokensRevoked, ActorID: audit.UserID(user.ID), TargetID: audit.UserID(user.ID)})
+	return nil
+}
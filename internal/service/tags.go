@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fem/internal/store"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ! tag limits --> tags are short labels for filtering, not notes
+const (
+	MaxWorkoutTags = 20
+	maxTagLength   = 32
+)
+
+// ! NormalizeTag --> lowercase, trimmed, a leading # dropped; letters, digits, spaces, - and _ only
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" {
+		return "", invalid("tags cannot be empty")
+	}
+	if len(tag) > maxTagLength {
+		return "", invalid(fmt.Sprintf("tags can be at most %d characters", maxTagLength))
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && r != '-' && r != '_' {
+			return "", invalid("tags may only contain letters, digits, spaces, - and _")
+		}
+	}
+	return tag, nil
+}
+
+// ! NormalizeTags --> NormalizeTag for each, deduped and sorted, at most MaxWorkoutTags
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, raw := range tags {
+		tag, err := NormalizeTag(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxWorkoutTags {
+		return nil, invalid(fmt.Sprintf("a workout can have at most %d tags", MaxWorkoutTags))
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// * normalizeNewTags --> nil when the request didn't send tags, ErrUnavailable when it did but tags are off
+func (s *WorkoutService) normalizeNewTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+	if s.Tags == nil {
+		return nil, ErrUnavailable
+	}
+	return NormalizeTags(tags)
+}
+
+// * ownedWorkout --> ErrNotFound / ErrForbidden unless userID owns workoutID
+func (s *WorkoutService) ownedWorkout(userID int, workoutID int64) error {
+	owner, err := s.workouts.GetWorkoutOwner(workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if owner != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ! LoadTags --> fills workout.Tags, a no-op without a tag store or workout
+func (s *WorkoutService) LoadTags(workout *store.Workout) error {
+	if s.Tags == nil || workout == nil {
+		return nil
+	}
+	tags, err := s.Tags.ListWorkoutTags(int64(workout.ID))
+	if err != nil {
+		return err
+	}
+	workout.Tags = tags
+	return nil
+}
+
+// ! SetTags --> replaces the tags of a workout userID owns, returns them normalized
+func (s *WorkoutService) SetTags(ctx context.Context, userID int, workoutID int64, tags []string) ([]string, error) {
+	if s.Tags == nil {
+		return nil, ErrUnavailable
+	}
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	err = s.ownedWorkout(userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Tags.SetWorkoutTags(workoutID, normalized)
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// ! AddTag --> tags a workout userID owns, adding one it already has is fine
+func (s *WorkoutService) AddTag(ctx context.Context, userID int, workoutID int64, tag string) ([]string, error) {
+	if s.Tags == nil {
+		return nil, ErrUnavailable
+	}
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	err = s.ownedWorkout(userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.Tags.ListWorkoutTags(workoutID)
+	if err != nil {
+		return nil, err
+	}
+	//? a concurrent add can still slip past the limit, it only keeps tag lists usable
+	tags, err := NormalizeTags(append(current, tag))
+	if err != nil {
+		return nil, err
+	}
+	err = s.Tags.AddWorkoutTag(workoutID, tag)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ! RemoveTag --> ErrNotFound when the workout doesn't carry tag
+func (s *WorkoutService) RemoveTag(ctx context.Context, userID int, workoutID int64, tag string) error {
+	if s.Tags == nil {
+		return ErrUnavailable
+	}
+	err := s.ownedWorkout(userID, workoutID)
+	if err != nil {
+		return err
+	}
+	//* an invalid tag can't be stored, so it can't be removed either
+	tag, err = NormalizeTag(tag)
+	if err != nil {
+		return ErrNotFound
+	}
+	err = s.Tags.RemoveWorkoutTag(workoutID, tag)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ! ListTags --> userID's tags starting with prefix and how often each is used, for autocomplete
+func (s *WorkoutService) ListTags(ctx context.Context, userID int, prefix string, limit int) ([]*store.TagCount, error) {
+	if s.Tags == nil {
+		return nil, ErrUnavailable
+	}
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(prefix), "#"))
+	return s.Tags.ListTagCounts(userID, prefix, limit)
+}
+
+// ! List --> userID's own workouts newest first, only ones tagged tag unless it's empty
+func (s *WorkoutService) List(ctx context.Context, userID int, tag string, limit, offset int) ([]*store.Workout, error) {
+	if s.Tags == nil {
+		return nil, ErrUnavailable
+	}
+	if tag != "" {
+		normalized, err := NormalizeTag(tag)
+		if err != nil {
+			return []*store.Workout{}, nil //* nothing can carry an invalid tag
+		}
+		tag = normalized
+	}
+	return s.Tags.ListWorkoutsByTag(userID, tag, limit, offset)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fem/internal/anomaly"
+	"fem/internal/hooks"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" Legs", "#legs", "leg day", "PR"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"leg day", "legs", "pr"}, tags)
+
+	for _, bad := range []string{"", "#", "legs!", "a-very-long-tag-that-goes-past-the-limit"} {
+		_, err = NormalizeTags([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestWorkoutTags(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	s := NewWorkoutService(memstore.NewWorkoutStore(db), memstore.NewProfileStore(db), memstore.NewFollowStore(db),
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+
+	// * no tag store --> tags are refused, not silently dropped
+	_, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "squats", DurationMinutes: 30, Tags: []string{"legs"}}, true)
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	s.Tags = memstore.NewTagStore(db)
+	legs, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "squats", DurationMinutes: 30, Tags: []string{"Legs", "gym"}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gym", "legs"}, legs.Tags)
+	run, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "run", DurationMinutes: 30}, true)
+	require.NoError(t, err)
+
+	tags, err := s.AddTag(ctx, ana.ID, int64(run.ID), "#legs")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legs"}, tags)
+	_, err = s.AddTag(ctx, ben.ID, int64(run.ID), "mine")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	counts, err := s.ListTags(ctx, ana.ID, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*store.TagCount{{Tag: "legs", Count: 2}, {Tag: "gym", Count: 1}}, counts)
+
+	tagged, err := s.List(ctx, ana.ID, "gym", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, tagged, 1)
+	assert.Equal(t, legs.ID, tagged[0].ID)
+
+	require.NoError(t, s.RemoveTag(ctx, ana.ID, int64(run.ID), "legs"))
+	assert.ErrorIs(t, s.RemoveTag(ctx, ana.ID, int64(run.ID), "legs"), ErrNotFound)
+	got, err := s.Get(ctx, ana.ID, int64(run.ID))
+	require.NoError(t, err)
+	assert.Empty(t, got.Tags)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fem/internal/audit"
+	"fem/internal/store"
+	"fem/internal/tokens"
+	"fem/internal/totp"
+	"time"
+)
+
+const (
+	// ! TwoFactorChallengeTTL --> how long the password step stays good while the user finds their phone
+	TwoFactorChallengeTTL = 5 * time.Minute
+	// ! BackupCodeCount --> recovery codes issued when 2FA is confirmed
+	BackupCodeCount = 10
+)
+
+// ! TwoFactorRequiredError --> the password was right, trade Challenge + a code for the auth token
+type TwoFactorRequiredError struct {
+	Challenge *tokens.Token
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor code required"
+}
+
+// ! TwoFactorSetup --> returned once by SetupTwoFactor, the secret isn't readable afterwards
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`      //* for typing in by hand
+	URI    string `json:"otpauth_uri"` //* for the QR code
+}
+
+// ! twoFactorFor --> the user's enabled 2FA, nil when it is off or the store isn't configured
+func (s *AuthService) twoFactorFor(userID int) (*store.TwoFactor, error) {
+	if s.TwoFactor == nil {
+		return nil, nil
+	}
+	tf, err := s.TwoFactor.GetTwoFactor(userID)
+	if err != nil || !tf.Enabled() {
+		return nil, err
+	}
+	return tf, nil
+}
+
+// ! checkCode --> a current TOTP code (each accepted once) or an unused backup code, "" when neither matches
+func (s *AuthService) checkCode(tf *store.TwoFactor, code string) (string, error) {
+	if step, ok := totp.Verify(tf.Secret, code, s.now()); ok {
+		fresh, err := s.TwoFactor.MarkTwoFactorStep(tf.UserID, step)
+		if err != nil || !fresh {
+			return "", err
+		}
+		return "totp", nil
+	}
+	used, err := s.TwoFactor.UseBackupCode(tf.UserID, totp.HashBackupCode(code))
+	if err != nil || !used {
+		return "", err
+	}
+	return "backup_code", nil
+}
+
+// ! VerifyTwoFactor --> second login step, failures count towards the same lockout as wrong passwords
+func (s *AuthService) VerifyTwoFactor(ctx context.Context, challenge, code string) (*tokens.Token, error) {
+	user, err := s.users.GetUserToken(tokens.ScopeTwoFactor, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	err = s.checkLockout(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := s.twoFactorFor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, ErrInvalidCredentials //* turned off since the challenge was issued
+	}
+	method, err := s.checkCode(tf, code)
+	if err != nil {
+		return nil, err
+	}
+	if method == "" {
+		s.Audit.Record(ctx, store.AuditEntry{Action: audit.LoginFailed, TargetID: audit.UserID(user.ID), Metadata: map[string]any{"username": user.Username, "two_factor": true}})
+		return nil, s.loginFailed(ctx, user.Username, user.ID)
+	}
+
+	err = s.tokens.DeleteToken(tokens.ScopeTwoFactor, challenge)
+	if err != nil {
+		return nil, err
+	}
+	err = s.clearUsernameLockout(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.tokens.CreateNewToken(user.ID, AuthTokenTTL, tokens.ScopeAuth)
+	if err != nil {
+		return nil, err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.Login, ActorID: audit.UserID(user.ID), Metadata: map[string]any{"two_factor": method}})
+	return token, nil
+}
+
+// ! SetupTwoFactor --> new pending secret, 2FA only turns on once ConfirmTwoFactor sees a code from it
+func (s *AuthService) SetupTwoFactor(ctx context.Context, user *store.User) (*TwoFactorSetup, error) {
+	if s.TwoFactor == nil {
+		return nil, invalid("two-factor authentication is not available on this server")
+	}
+	tf, err := s.twoFactorFor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil {
+		return nil, invalid("two-factor authentication is already enabled, disable it first")
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = s.TwoFactor.SaveTwoFactorSecret(user.ID, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorSetup{Secret: secret, URI: totp.URI(s.Issuer, user.Username, secret)}, nil
+}
+
+// ! ConfirmTwoFactor --> enables 2FA and returns the backup codes, the only time they are shown
+func (s *AuthService) ConfirmTwoFactor(ctx context.Context, user *store.User, code string) ([]string, error) {
+	if s.TwoFactor == nil {
+		return nil, invalid("two-factor authentication is not available on this server")
+	}
+	tf, err := s.TwoFactor.GetTwoFactor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, invalid("start with POST /users/me/2fa/setup")
+	}
+	if tf.Enabled() {
+		return nil, invalid("two-factor authentication is already enabled")
+	}
+	step, ok := totp.Verify(tf.Secret, code, s.now())
+	if !ok {
+		return nil, invalid("code doesn't match, check the authenticator app's clock")
+	}
+
+	codes, err := totp.NewBackupCodes(BackupCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([][]byte, len(codes))
+	for i, c := range codes {
+		hashes[i] = totp.HashBackupCode(c)
+	}
+	err = s.TwoFactor.ConfirmTwoFactor(user.ID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.TwoFactorEnabled, ActorID: audit.UserID(user.ID), TargetID: audit.UserID(user.ID)})
+	return codes, nil
+}
+
+// ! DisableTwoFactor --> needs a current code too, a stolen token alone can't strip the second factor
+func (s *AuthService) DisableTwoFactor(ctx context.Context, user *store.User, code string) error {
+	tf, err := s.twoFactorFor(user.ID)
+	if err != nil {
+		return err
+	}
+	if tf == nil {
+		if s.TwoFactor == nil {
+			return ErrNotFound
+		}
+		err = s.TwoFactor.DeleteTwoFactor(user.ID) //* drop a pending setup, if any
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		return ErrNotFound
+	}
+	method, err := s.checkCode(tf, code)
+	if err != nil {
+		return err
+	}
+	if method == "" {
+		return ErrInvalidCredentials
+	}
+
+	err = s.TwoFactor.DeleteTwoFactor(user.ID)
+	if err != nil {
+		return err
+	}
+	s.Audit.Record(ctx, store.AuditEntry{Action: audit.TwoFactorDisabled, ActorID: audit.UserID(user.ID), TargetID: audit.UserID(user.ID), Metadata: map[string]any{"with": method}})
+	return nil
+}
+
+// ! TwoFactorStatus --> nil when 2FA was never set up
+func (s *AuthService) TwoFactorStatus(ctx context.Context, user *store.User) (*store.TwoFactor, error) {
+	if s.TwoFactor == nil {
+		return nil, nil
+	}
+	return s.TwoFactor.GetTwoFactor(user.ID)
+}
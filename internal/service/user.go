@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fem/internal/events"
+	"fem/internal/hooks"
+	"fem/internal/password"
+	"fem/internal/store"
+	"log"
+	"regexp"
+	"strconv"
+)
+
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// ! Registration --> what a new account needs, Password is plaintext and only ever hashed
+type Registration struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+	Bio      string `json:"bio"`
+}
+
+// ! Validate --> server-side checks before anything touches the database
+func (r *Registration) Validate() error {
+	switch {
+	case r.Username == "":
+		return invalid("Username is required")
+	case len(r.Username) > 50:
+		return invalid("Username cannot be greater than 50 characters")
+	case r.Email == "":
+		return invalid("Email is required")
+	case r.Password == "":
+		return invalid("password is required")
+	case !emailPattern.MatchString(r.Email):
+		return invalid("Invalid email format")
+	}
+	return nil
+}
+
+// ! UserService --> account registration and lookup
+type UserService struct {
+	users     store.UserStore
+	hooks     *hooks.Registry
+	logger    *log.Logger
+	Passwords *password.Validator //* strength + breach rules, nil only requires a password
+	Bus       *events.Bus         //* user.registered goes out on it, nil publishes nothing
+}
+
+func NewUserService(userStore store.UserStore, hookRegistry *hooks.Registry, logger *log.Logger) *UserService {
+	return &UserService{users: userStore, hooks: hookRegistry, logger: logger}
+}
+
+// ! Register --> validates, hashes the password (bcrypt) and saves the user
+func (s *UserService) Register(ctx context.Context, r Registration) (*store.User, error) {
+	err := r.Validate()
+	if err != nil {
+		return nil, err
+	}
+	err = checkPassword(ctx, s.Passwords, r.Password, r.Username, r.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &store.User{Username: r.Username, Email: r.Email, Bio: r.Bio}
+	err = user.PasswordHash.Set(r.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.users.CreateUser(user)
+	if err != nil {
+		return nil, err
+	}
+	s.registered(ctx, user)
+	return user, nil
+}
+
+// * registered --> the event + plugin hooks once a new user is saved, a failing hook only gets logged
+func (s *UserService) registered(ctx context.Context, user *store.User) {
+	if s.Bus != nil {
+		s.Bus.Publish(events.Event{Type: events.UserRegistered, UserID: user.ID})
+	}
+	err := s.hooks.OnUserRegistered.Run(ctx, user)
+	if err != nil {
+		s.logger.Printf("ERROR : onUserRegistered hooks %v ", err)
+	}
+}
+
+// * usernameChars --> what a generated username keeps from the provider's suggestion
+var usernameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ! RegisterExternal --> account for a "sign in with" identity, random password nobody knows
+// ? the suggested username gets a number appended until it is free
+func (s *UserService) RegisterExternal(ctx context.Context, suggested, email string) (*store.User, error) {
+	if !emailPattern.MatchString(email) {
+		return nil, invalid("provider returned an invalid email")
+	}
+	base := usernameChars.ReplaceAllString(suggested, "")
+	if base == "" {
+		base = "user"
+	}
+	base = base[:min(len(base), 40)]
+
+	username := base
+	for n := 2; ; n++ {
+		existing, err := s.users.GetUserByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			break
+		}
+		if n > 100 {
+			return nil, invalid("could not pick a free username")
+		}
+		username = base + strconv.Itoa(n)
+	}
+
+	user := &store.User{Username: username, Email: email}
+	err := user.PasswordHash.Set(rand.Text())
+	if err != nil {
+		return nil, err
+	}
+	err = s.users.CreateUser(user)
+	if err != nil {
+		return nil, err
+	}
+	s.registered(ctx, user)
+	return user, nil
+}
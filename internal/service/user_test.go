@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationValidate(t *testing.T) {
+	r := Registration{Username: "sam", Password: "hunter22", Email: "sam@example.com"}
+	assert.NoError(t, r.Validate())
+
+	r.Email = "sam@example"
+	err := r.Validate()
+	var invalid *ValidationError
+	assert.True(t, errors.As(err, &invalid))
+	assert.Equal(t, "Invalid email format", invalid.Message)
+
+	r.Email = "sam@example.com"
+	r.Password = ""
+	assert.EqualError(t, r.Validate(), "password is required")
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fem/internal/anomaly"
+	"fem/internal/calories"
+	"fem/internal/hooks"
+	"fem/internal/outbox"
+	"fem/internal/store"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ! WorkoutPatch --> partial update, nil fields keep their current value
+type WorkoutPatch struct {
+	Title           *string              `json:"title"`
+	Description     *string              `json:"description"`
+	DurationMinutes *int                 `json:"duration_minutes"`
+	CaloriesBurned  *int                 `json:"calories_burned"`
+	Visibility      *string              `json:"visibility"`
+	PerformedAt     *time.Time           `json:"performed_at"`
+	Tags            *[]string            `json:"tags"` // * replaces every tag, [] clears them
+	Entries         []store.WorkoutEntry `json:"entries"`
+}
+
+// ! PatchOf --> the patch that sets every field to workout's current values, the document PATCH requests edit
+func PatchOf(workout *store.Workout) WorkoutPatch {
+	tags := workout.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	entries := workout.Entries
+	if entries == nil {
+		entries = []store.WorkoutEntry{}
+	}
+	return WorkoutPatch{
+		Title:           &workout.Title,
+		Description:     &workout.Description,
+		DurationMinutes: &workout.DurationMinutes,
+		CaloriesBurned:  &workout.CaloriesBurned,
+		Visibility:      &workout.Visibility,
+		PerformedAt:     &workout.PerformedAt,
+		Tags:            &tags,
+		Entries:         entries,
+	}
+}
+
+// * performedAtSkew --> client clocks run ahead, performed_at may be this far past the server's now
+const performedAtSkew = time.Hour
+
+// ! Workouts --> what a transport (HTTP, gRPC, GraphQL, CLI) needs from the workout rules, *WorkoutService implements it
+type Workouts interface {
+	Get(ctx context.Context, viewerID int, workoutID int64) (*store.Workout, error)
+	List(ctx context.Context, userID int, tag string, limit, offset int) ([]*store.Workout, error)
+	Create(ctx context.Context, userID int, workout *store.Workout, confirm bool) (*store.Workout, []anomaly.Warning, error)
+	Update(ctx context.Context, userID int, workoutID int64, patch WorkoutPatch, confirm bool) (*store.Workout, []anomaly.Warning, error)
+	Delete(ctx context.Context, userID int, workoutID int64) error
+	Import(ctx context.Context, userID int, workouts []*store.Workout) ([]error, error)
+	Batch(ctx context.Context, userID int, ops []BatchOp, atomic, confirm bool) ([]BatchResult, error)
+}
+
+var _ Workouts = (*WorkoutService)(nil)
+
+// ! WorkoutService --> create/read/update/delete for workouts with ownership, visibility, calories and anomaly checks
+type WorkoutService struct {
+	workouts store.WorkoutStore
+	profiles store.ProfileStore
+	follows  store.FollowStore
+	detector *anomaly.Detector
+	relay    *outbox.Relay //* the store writes workout events to the outbox, Notify just hurries the relay along
+	hooks    *hooks.Registry
+	logger   *log.Logger
+
+	Tags    store.TagStore     //* nil turns workout tags off, see tags.go
+	Primary store.WorkoutStore //* unrouted + uncached, reads that guard a write go here; nil means workouts
+}
+
+func NewWorkoutService(workoutStore store.WorkoutStore, profileStore store.ProfileStore, followStore store.FollowStore, detector *anomaly.Detector, relay *outbox.Relay, hookRegistry *hooks.Registry, logger *log.Logger) *WorkoutService {
+	return &WorkoutService{
+		workouts: workoutStore,
+		profiles: profileStore,
+		follows:  followStore,
+		detector: detector,
+		relay:    relay,
+		hooks:    hookRegistry,
+		logger:   logger,
+	}
+}
+
+// * primary --> the store read-modify-writes read from, a lagging replica copy would be written back over newer changes
+func (s *WorkoutService) primary() store.WorkoutStore {
+	if s.Primary != nil {
+		return s.Primary
+	}
+	return s.workouts
+}
+
+// ! ValidVisibility --> empty means "keep the default / current value"
+func ValidVisibility(visibility string) bool {
+	switch visibility {
+	case "", store.VisibilityPrivate, store.VisibilityFollowers, store.VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+// ! CanViewWorkout --> owner always, everyone for public, followers only when the viewer follows the owner
+func CanViewWorkout(followStore store.FollowStore, workout *store.Workout, viewerID int) (bool, error) {
+	switch {
+	case workout.UserID == viewerID, workout.Visibility == store.VisibilityPublic:
+		return true, nil
+	case workout.Visibility == store.VisibilityFollowers:
+		return followStore.IsFollowing(int64(viewerID), int64(workout.UserID))
+	}
+	return false, nil
+}
+
+// ! VisibleWorkout --> loads a workout viewerID may see, ErrNotFound for missing + hidden ones so private ones can't be probed
+// ? for handlers of workout sub-resources (comments, evidence) that only hold the stores
+func VisibleWorkout(workoutStore store.WorkoutStore, followStore store.FollowStore, workoutID int64, viewerID int) (*store.Workout, error) {
+	workout, err := workoutStore.GetWorkoutByID(workoutID)
+	if err != nil {
+		return nil, err //* ErrNotFound is the store's, see errors.go
+	}
+
+	visible, err := CanViewWorkout(followStore, workout, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrNotFound
+	}
+	return workout, nil
+}
+
+// ! CheckWorkoutOwner --> ErrNotFound for a missing workout, ErrForbidden when userID doesn't own it
+func CheckWorkoutOwner(workoutStore store.WorkoutStore, workoutID int64, userID int) error {
+	owner, err := workoutStore.GetWorkoutOwner(workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if owner != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ! Get --> ErrNotFound for missing workouts and for ones the viewer may not see, tags included
+func (s *WorkoutService) Get(ctx context.Context, viewerID int, workoutID int64) (*store.Workout, error) {
+	workout, err := VisibleWorkout(s.workouts, s.follows, workoutID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.LoadTags(workout)
+	if err != nil {
+		return nil, err
+	}
+	return workout, nil
+}
+
+// ! Create --> saves a new workout for userID, warnings are returned even when the save went through
+// ? confirm=false with warnings that need confirmation answers *AnomalyError and nothing is saved
+func (s *WorkoutService) Create(ctx context.Context, userID int, workout *store.Workout, confirm bool) (*store.Workout, []anomaly.Warning, error) {
+	tags, warnings, err := s.prepareCreate(userID, workout, confirm)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	workout.Tags = tags
+	err = s.save(store.WorkoutOp{Op: store.WorkoutOpCreate, Workout: workout, SetTags: tags != nil})
+	if err != nil {
+		return nil, warnings, err
+	}
+	s.finishCreate(ctx, workout)
+	return workout, warnings, nil
+}
+
+// * save --> a single create / update, tags go through a one-op batch so they commit with the workout + its outbox event
+func (s *WorkoutService) save(op store.WorkoutOp) error {
+	if !op.SetTags {
+		if op.Op == store.WorkoutOpCreate {
+			_, err := s.workouts.CreateWorkout(op.Workout)
+			return err
+		}
+		return s.workouts.UpdateWorkout(op.Workout)
+	}
+	opErrs, err := s.workouts.BatchWorkouts([]store.WorkoutOp{op}, true)
+	if err != nil {
+		return err
+	}
+	return opErrs[0]
+}
+
+// * prepareCreate --> everything Create does before the save, tags come back normalized (nil --> none)
+func (s *WorkoutService) prepareCreate(userID int, workout *store.Workout, confirm bool) ([]string, []anomaly.Warning, error) {
+	//* new workouts are private unless the client opts in
+	if !ValidVisibility(workout.Visibility) {
+		return nil, nil, invalid("visibility must be private, followers or public")
+	}
+	err := validateEntries(workout.Entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = validatePerformedAt(workout.PerformedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err := s.normalizeNewTags(workout.Tags)
+	if err != nil {
+		return nil, nil, err
+	}
+	workout.UserID = userID
+
+	//* client didn't send calories --> estimate them and flag the value as an estimate
+	workout.CaloriesEstimated = false
+	if workout.CaloriesBurned == 0 {
+		s.estimateCalories(workout, userID)
+	}
+
+	warnings, err := s.checkAnomalies(workout, confirm)
+	if err != nil {
+		return nil, warnings, err
+	}
+	return tags, warnings, nil
+}
+
+// * finishCreate --> the event + plugin hooks once the workout (and its tags) are saved
+func (s *WorkoutService) finishCreate(ctx context.Context, created *store.Workout) {
+	s.relay.Notify() //* the store wrote workout.created to the outbox with the workout
+
+	//* plugin hooks --> the workout is already saved, a failing hook only gets logged
+	err := s.hooks.OnWorkoutCreated.Run(ctx, created)
+	if err != nil {
+		s.logger.Printf("Error : onWorkoutCreated hooks : %v ", err)
+	}
+}
+
+// * validateEntries --> weights + distances are stored unsigned, a negative one is a client bug
+func validateEntries(entries []store.WorkoutEntry) error {
+	for i, e := range entries {
+		if e.Weight != nil && *e.Weight < 0 {
+			return invalid(fmt.Sprintf("entries[%d].weight cannot be negative", i))
+		}
+		if e.Distance != nil && *e.Distance < 0 {
+			return invalid(fmt.Sprintf("entries[%d].distance cannot be negative", i))
+		}
+	}
+	return nil
+}
+
+// * validatePerformedAt --> zero means "now", anything well in the future is a typo or a wrong offset
+func validatePerformedAt(at time.Time) error {
+	if !at.IsZero() && at.After(time.Now().Add(performedAtSkew)) {
+		return invalid("performed_at cannot be in the future")
+	}
+	return nil
+}
+
+// ! Update --> applies patch to a workout userID owns
+func (s *WorkoutService) Update(ctx context.Context, userID int, workoutID int64, patch WorkoutPatch, confirm bool) (*store.Workout, []anomaly.Warning, error) {
+	workout, tags, warnings, err := s.prepareUpdate(userID, workoutID, patch, confirm)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	if patch.Tags != nil {
+		workout.Tags = tags
+	}
+	err = s.save(store.WorkoutOp{Op: store.WorkoutOpUpdate, Workout: workout, SetTags: patch.Tags != nil})
+	if err != nil {
+		return nil, warnings, err
+	}
+	err = s.finishUpdate(workout, patch.Tags != nil)
+	if err != nil {
+		return nil, warnings, err
+	}
+	return workout, warnings, nil
+}
+
+// * prepareUpdate --> everything Update does before the save: the owned workout with patch applied + its new tags
+func (s *WorkoutService) prepareUpdate(userID int, workoutID int64, patch WorkoutPatch, confirm bool) (*store.Workout, []string, []anomaly.Warning, error) {
+	workout, err := s.primary().GetWorkoutByID(workoutID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if workout.UserID != userID {
+		return nil, nil, nil, ErrForbidden
+	}
+
+	err = patch.apply(workout)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var tags []string
+	if patch.Tags != nil {
+		tags, err = s.normalizeNewTags(*patch.Tags)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	workout.ID = int(workoutID)
+
+	//? duration or entries changed on an estimated workout --> the old estimate is stale
+	if patch.CaloriesBurned == nil && (workout.CaloriesEstimated || workout.CaloriesBurned == 0) {
+		s.estimateCalories(workout, userID)
+	}
+
+	warnings, err := s.checkAnomalies(workout, confirm)
+	if err != nil {
+		return nil, nil, warnings, err
+	}
+	return workout, tags, warnings, nil
+}
+
+// * finishUpdate --> the tags the update left alone are loaded, the event is hurried along once the workout is saved
+func (s *WorkoutService) finishUpdate(workout *store.Workout, tagsSaved bool) error {
+	if !tagsSaved {
+		err := s.LoadTags(workout)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.relay.Notify()
+	return nil
+}
+
+// * apply --> validates + merges the set fields into workout, tags are left to the caller (they live in their own store)
+func (patch WorkoutPatch) apply(workout *store.Workout) error {
+	if patch.Title != nil {
+		workout.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		workout.Description = *patch.Description
+	}
+	if patch.DurationMinutes != nil {
+		workout.DurationMinutes = *patch.DurationMinutes
+	}
+	if patch.CaloriesBurned != nil {
+		workout.CaloriesBurned = *patch.CaloriesBurned
+		workout.CaloriesEstimated = false //* client supplied a real value
+	}
+	if patch.Visibility != nil {
+		if *patch.Visibility == "" || !ValidVisibility(*patch.Visibility) {
+			return invalid("visibility must be private, followers or public")
+		}
+		workout.Visibility = *patch.Visibility
+	}
+	if patch.PerformedAt != nil {
+		err := validatePerformedAt(*patch.PerformedAt)
+		if err != nil {
+			return err
+		}
+		workout.PerformedAt = *patch.PerformedAt
+	}
+	if patch.Entries != nil {
+		err := validateEntries(patch.Entries)
+		if err != nil {
+			return err
+		}
+		workout.Entries = patch.Entries
+	}
+	return nil
+}
+
+// ! Delete --> removes a workout userID owns
+func (s *WorkoutService) Delete(ctx context.Context, userID int, workoutID int64) error {
+	err := CheckWorkoutOwner(s.primary(), workoutID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.workouts.DeleteWorkout(workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	s.relay.Notify()
+	return nil
+}
+
+// ! Import --> saves a batch of already parsed workouts for userID in one transaction, one error slot per workout
+// ? imported history doesn't publish workout events --> no feed spam, XP or achievements for old workouts
+func (s *WorkoutService) Import(ctx context.Context, userID int, workouts []*store.Workout) ([]error, error) {
+	s.prepareImported(userID, workouts)
+	return s.workouts.ImportWorkouts(workouts)
+}
+
+// * prepareImported --> the treatment Create gives a workout, minus the confirmation step
+func (s *WorkoutService) prepareImported(userID int, workouts []*store.Workout) {
+	weightKG := s.weightKG(userID)
+	for _, workout := range workouts {
+		workout.ID = 0
+		workout.UserID = userID
+		workout.Verified = false
+		workout.CaloriesEstimated = false
+		if workout.CaloriesBurned == 0 {
+			workout.CaloriesBurned = calories.EstimateWorkout(workout, weightKG)
+			workout.CaloriesEstimated = true
+		}
+		workout.Flagged = len(s.detector.Check(workout)) > 0
+	}
+}
+
+// ! estimateCalories --> fills calories_burned from MET values + the user's latest weight
+// ? estimation failures are logged, never fatal --> the workout still saves with the default weight
+func (s *WorkoutService) estimateCalories(workout *store.Workout, userID int) {
+	workout.CaloriesBurned = calories.EstimateWorkout(workout, s.weightKG(userID))
+	workout.CaloriesEstimated = true
+}
+
+// ! weightKG --> latest logged body weight, the estimator default when there is none
+func (s *WorkoutService) weightKG(userID int) float64 {
+	profile, err := s.profiles.GetProfile(userID)
+	if err != nil {
+		s.logger.Printf("Error : getProfile for calorie estimate : %v ", err)
+		return calories.DefaultWeightKG
+	}
+	if profile.WeightKG == nil {
+		return calories.DefaultWeightKG
+	}
+	return *profile.WeightKG
+}
+
+// ! checkAnomalies --> flags the workout, or refuses it with *AnomalyError when confirmation is required
+func (s *WorkoutService) checkAnomalies(workout *store.Workout, confirm bool) ([]anomaly.Warning, error) {
+	warnings := s.detector.Check(workout)
+	if s.detector.RequiresConfirmation(warnings, confirm) {
+		return warnings, &AnomalyError{Warnings: warnings}
+	}
+	workout.Flagged = len(warnings) > 0
+	return warnings, nil
+}
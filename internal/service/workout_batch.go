@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fem/internal/anomaly"
+	"fem/internal/store"
+	"fmt"
+)
+
+// ! MaxBatchOps --> POST /workouts/batch takes at most this many operations, it's one transaction
+const MaxBatchOps = 100
+
+// ! ErrBatchAborted --> an atomic batch stopped at another operation, this one wasn't saved
+var ErrBatchAborted = store.ErrBatchAborted
+
+// ! BatchOp --> one queued change: create takes Workout, update ID + Patch, delete only ID
+type BatchOp struct {
+	Op      string //* store.WorkoutOpCreate | store.WorkoutOpUpdate | store.WorkoutOpDelete
+	ID      int64
+	Workout *store.Workout
+	Patch   WorkoutPatch
+}
+
+// ! BatchResult --> how one op went: the saved workout (create + update) and its warnings, or why it failed
+type BatchResult struct {
+	Workout  *store.Workout
+	Warnings []anomaly.Warning
+	Err      error
+}
+
+// * batchStep --> an op that passed the same checks Create / Update / Delete make, waiting for the store
+type batchStep struct {
+	result int //* index into the results
+	op     store.WorkoutOp
+}
+
+// ! Batch --> ops for userID's workouts, in order and in one store transaction, a result per op
+// ? every op gets the checks of its single-workout call first (ownership, validation, anomalies with confirm);
+// ? atomic --> one failing op, in the checks or in the store, leaves the others unsaved with ErrBatchAborted
+func (s *WorkoutService) Batch(ctx context.Context, userID int, ops []BatchOp, atomic, confirm bool) ([]BatchResult, error) {
+	if len(ops) > MaxBatchOps {
+		return nil, invalid(fmt.Sprintf("a batch takes at most %d operations", MaxBatchOps))
+	}
+	results := make([]BatchResult, len(ops))
+	steps := make([]batchStep, 0, len(ops))
+	for i, op := range ops {
+		step, warnings, err := s.prepareBatchOp(userID, op, confirm)
+		results[i] = BatchResult{Warnings: warnings, Err: err}
+		if err != nil && atomic {
+			return abortResults(results, i), nil
+		}
+		if err == nil {
+			step.result = i
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 {
+		return results, nil
+	}
+
+	storeOps := make([]store.WorkoutOp, len(steps))
+	for i, step := range steps {
+		storeOps[i] = step.op
+	}
+	opErrs, err := s.workouts.BatchWorkouts(storeOps, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, step := range steps {
+		result := &results[step.result]
+		result.Err = opErrs[i]
+		if result.Err != nil {
+			continue
+		}
+		if step.op.Op != store.WorkoutOpDelete {
+			result.Workout = step.op.Workout
+		}
+		result.Err = s.finishBatchOp(ctx, step)
+	}
+	return results, nil
+}
+
+// * prepareBatchOp --> the checks of the single-workout call for op, nothing is saved
+func (s *WorkoutService) prepareBatchOp(userID int, op BatchOp, confirm bool) (batchStep, []anomaly.Warning, error) {
+	switch op.Op {
+	case store.WorkoutOpCreate:
+		if op.Workout == nil {
+			return batchStep{}, nil, invalid("create needs a workout")
+		}
+		tags, warnings, err := s.prepareCreate(userID, op.Workout, confirm)
+		if err != nil {
+			return batchStep{}, warnings, err
+		}
+		op.Workout.Tags = tags
+		return batchStep{op: store.WorkoutOp{Op: op.Op, Workout: op.Workout, SetTags: tags != nil}}, warnings, nil
+	case store.WorkoutOpUpdate:
+		workout, tags, warnings, err := s.prepareUpdate(userID, op.ID, op.Patch, confirm)
+		if err != nil {
+			return batchStep{}, warnings, err
+		}
+		if op.Patch.Tags != nil {
+			workout.Tags = tags
+		}
+		return batchStep{op: store.WorkoutOp{Op: op.Op, Workout: workout, SetTags: op.Patch.Tags != nil}}, warnings, nil
+	case store.WorkoutOpDelete:
+		err := CheckWorkoutOwner(s.workouts, op.ID, userID)
+		return batchStep{op: store.WorkoutOp{Op: op.Op, Workout: &store.Workout{ID: int(op.ID), UserID: userID}}}, nil, err
+	}
+	return batchStep{}, nil, invalid(fmt.Sprintf("op must be %s, %s or %s", store.WorkoutOpCreate, store.WorkoutOpUpdate, store.WorkoutOpDelete))
+}
+
+// * finishBatchOp --> what the single-workout call does after its save, only once the batch committed
+// ? tags + outbox rows went in with the batch transaction, what's left is waking the relay and the hooks
+func (s *WorkoutService) finishBatchOp(ctx context.Context, step batchStep) error {
+	switch {
+	case step.op.Op == store.WorkoutOpCreate:
+		s.finishCreate(ctx, step.op.Workout)
+		return nil
+	case step.op.Op == store.WorkoutOpUpdate && !step.op.SetTags:
+		return s.finishUpdate(step.op.Workout, false) //* loads the tags the update left alone
+	default:
+		s.relay.Notify()
+		return nil
+	}
+}
+
+// * abortResults --> results once the op at failed sank an atomic batch before the store saw it
+func abortResults(results []BatchResult, failed int) []BatchResult {
+	for i := range results {
+		if i != failed {
+			results[i].Err = ErrBatchAborted
+		}
+	}
+	return results
+}
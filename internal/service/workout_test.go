@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fem/internal/anomaly"
+	"fem/internal/hooks"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkoutPatchApply(t *testing.T) {
+	title, visibility, calories := "intervals", store.VisibilityPublic, 320
+	workout := &store.Workout{Title: "run", Description: "easy", DurationMinutes: 30, CaloriesBurned: 250, CaloriesEstimated: true}
+
+	err := WorkoutPatch{Title: &title, Visibility: &visibility, CaloriesBurned: &calories}.apply(workout)
+	require.NoError(t, err)
+	assert.Equal(t, "intervals", workout.Title)
+	assert.Equal(t, "easy", workout.Description, "nil fields keep their value")
+	assert.Equal(t, store.VisibilityPublic, workout.Visibility)
+	assert.Equal(t, 320, workout.CaloriesBurned)
+	assert.False(t, workout.CaloriesEstimated)
+
+	empty := ""
+	var invalid *ValidationError
+	assert.ErrorAs(t, WorkoutPatch{Visibility: &empty}.apply(workout), &invalid)
+	assert.Equal(t, store.VisibilityPublic, workout.Visibility)
+}
+
+func TestWorkoutOwnershipAndVisibility(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	workouts := memstore.NewWorkoutStore(db)
+	follows := memstore.NewFollowStore(db)
+	s := NewWorkoutService(workouts, memstore.NewProfileStore(db), follows,
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	workout, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "run", DurationMinutes: 30, Visibility: store.VisibilityFollowers}, true)
+	require.NoError(t, err)
+	id := int64(workout.ID)
+
+	_, err = s.Get(ctx, ben.ID, id)
+	assert.ErrorIs(t, err, ErrNotFound, "hidden workouts look missing")
+	require.NoError(t, follows.Follow(int64(ben.ID), int64(ana.ID)))
+	_, err = s.Get(ctx, ben.ID, id)
+	assert.NoError(t, err)
+	_, err = s.Get(ctx, ana.ID, id+100)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	title := "tempo run"
+	_, _, err = s.Update(ctx, ben.ID, id, WorkoutPatch{Title: &title}, true)
+	assert.ErrorIs(t, err, ErrForbidden)
+	updated, _, err := s.Update(ctx, ana.ID, id, WorkoutPatch{Title: &title}, true)
+	require.NoError(t, err)
+	assert.Equal(t, "tempo run", updated.Title)
+	assert.Equal(t, 30, updated.DurationMinutes)
+
+	assert.ErrorIs(t, CheckWorkoutOwner(workouts, id, ben.ID), ErrForbidden)
+	assert.ErrorIs(t, s.Delete(ctx, ben.ID, id), ErrForbidden)
+	require.NoError(t, s.Delete(ctx, ana.ID, id))
+	assert.ErrorIs(t, s.Delete(ctx, ana.ID, id), ErrNotFound)
+}
+
+func TestWorkoutBatch(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	workouts := memstore.NewWorkoutStore(db)
+	s := NewWorkoutService(workouts, memstore.NewProfileStore(db), memstore.NewFollowStore(db),
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	ben := &store.User{Username: "ben", Email: "ben@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	require.NoError(t, users.CreateUser(ben))
+	own, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "run", DurationMinutes: 30}, true)
+	require.NoError(t, err)
+	other, _, err := s.Create(ctx, ben.ID, &store.Workout{Title: "swim", DurationMinutes: 40}, true)
+	require.NoError(t, err)
+
+	title := "tempo run"
+	ops := []BatchOp{
+		{Op: store.WorkoutOpCreate, Workout: &store.Workout{Title: "legs", DurationMinutes: 50}},
+		{Op: store.WorkoutOpUpdate, ID: int64(own.ID), Patch: WorkoutPatch{Title: &title}},
+		{Op: store.WorkoutOpDelete, ID: int64(other.ID)},
+	}
+
+	// * atomic: ben's workout sinks the whole batch
+	results, err := s.Batch(ctx, ana.ID, ops, true, true)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrBatchAborted)
+	assert.ErrorIs(t, results[1].Err, ErrBatchAborted)
+	assert.ErrorIs(t, results[2].Err, ErrForbidden)
+	saved, err := workouts.GetWorkoutByID(int64(own.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "run", saved.Title)
+
+	// * one by one: the others go through
+	ops[0].Workout = &store.Workout{Title: "legs", DurationMinutes: 50}
+	results, err = s.Batch(ctx, ana.ID, ops, false, true)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	assert.NotZero(t, results[0].Workout.ID)
+	assert.Equal(t, ana.ID, results[0].Workout.UserID)
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, "tempo run", results[1].Workout.Title)
+	assert.ErrorIs(t, results[2].Err, ErrForbidden)
+
+	_, err = s.Batch(ctx, ana.ID, make([]BatchOp, MaxBatchOps+1), false, true)
+	var invalid *ValidationError
+	assert.ErrorAs(t, err, &invalid)
+}
+
+// ! TestWorkoutBatchTags --> tags are written in the batch transaction, a rolled back batch leaves none behind
+func TestWorkoutBatchTags(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	tags := memstore.NewTagStore(db)
+	s := NewWorkoutService(memstore.NewWorkoutStore(db), memstore.NewProfileStore(db), memstore.NewFollowStore(db),
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+	s.Tags = tags
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	own, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "run", DurationMinutes: 30, Tags: []string{"easy"}}, true)
+	require.NoError(t, err)
+
+	reps, seconds := 10, 30
+	newTags := []string{"tempo"}
+	ops := []BatchOp{
+		{Op: store.WorkoutOpCreate, Workout: &store.Workout{Title: "legs", DurationMinutes: 50, Tags: []string{"legs"}}},
+		{Op: store.WorkoutOpUpdate, ID: int64(own.ID), Patch: WorkoutPatch{Tags: &newTags}},
+		//* reps and a duration both --> the store's check fails, after the ops before it were applied
+		{Op: store.WorkoutOpCreate, Workout: &store.Workout{Title: "bad", DurationMinutes: 10, Entries: []store.WorkoutEntry{
+			{ExerciseName: "squat", Sets: 1, Reps: &reps, DurationSeconds: &seconds},
+		}}},
+	}
+	results, err := s.Batch(ctx, ana.ID, ops, true, true)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrBatchAborted)
+	assert.ErrorIs(t, results[1].Err, ErrBatchAborted)
+	assert.Error(t, results[2].Err)
+	saved, err := tags.ListWorkoutTags(int64(own.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"easy"}, saved, "the aborted update kept its tags")
+	counts, err := tags.ListTagCounts(ana.ID, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, counts, 1, "no tags for the create that was rolled back")
+
+	ops[0].Workout = &store.Workout{Title: "legs", DurationMinutes: 50, Tags: []string{"legs"}}
+	results, err = s.Batch(ctx, ana.ID, ops[:2], true, true)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, []string{"legs"}, results[0].Workout.Tags)
+	assert.Equal(t, []string{"tempo"}, results[1].Workout.Tags)
+	saved, err = tags.ListWorkoutTags(int64(results[0].Workout.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legs"}, saved)
+	saved, err = tags.ListWorkoutTags(int64(own.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tempo"}, saved)
+}
+
+// * separateTagWrites --> a TagStore whose own SetWorkoutTags always fails, tags only land through the workout's transaction
+type separateTagWrites struct {
+	store.TagStore
+}
+
+func (separateTagWrites) SetWorkoutTags(workoutID int64, tags []string) error {
+	return errors.New("tag store down")
+}
+
+// ! TestWorkoutTagsSavedWithWorkout --> create + update write the tags in the workout transaction, not in a second call after it
+func TestWorkoutTagsSavedWithWorkout(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	tags := memstore.NewTagStore(db)
+	s := NewWorkoutService(memstore.NewWorkoutStore(db), memstore.NewProfileStore(db), memstore.NewFollowStore(db),
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+	s.Tags = separateTagWrites{TagStore: tags}
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	workout, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "run", DurationMinutes: 30, Tags: []string{"Easy"}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"easy"}, workout.Tags)
+	saved, err := tags.ListWorkoutTags(int64(workout.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"easy"}, saved)
+
+	newTags, title := []string{"tempo"}, "tempo run"
+	updated, _, err := s.Update(ctx, ana.ID, int64(workout.ID), WorkoutPatch{Tags: &newTags}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tempo"}, updated.Tags)
+	updated, _, err = s.Update(ctx, ana.ID, int64(workout.ID), WorkoutPatch{Title: &title}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tempo"}, updated.Tags, "tags the patch left alone are loaded")
+	saved, err = tags.ListWorkoutTags(int64(workout.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tempo"}, saved)
+}
+
+// * laggingWorkoutStore --> reads answer the copy a replica had before the last writes
+type laggingWorkoutStore struct {
+	store.WorkoutStore
+	stale map[int64]store.Workout
+}
+
+func (s *laggingWorkoutStore) GetWorkoutByID(id int64) (*store.Workout, error) {
+	workout, ok := s.stale[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &workout, nil
+}
+
+func (s *laggingWorkoutStore) GetWorkoutOwner(id int64) (int, error) {
+	workout, err := s.GetWorkoutByID(id)
+	if err != nil {
+		return 0, err
+	}
+	return workout.UserID, nil
+}
+
+// ! TestWorkoutUpdateReadsPrimary --> a patch is applied to the primary's copy, the replica's older one would undo the last change
+func TestWorkoutUpdateReadsPrimary(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	primary := memstore.NewWorkoutStore(db)
+	replica := &laggingWorkoutStore{WorkoutStore: primary, stale: map[int64]store.Workout{}}
+	s := NewWorkoutService(replica, memstore.NewProfileStore(db), memstore.NewFollowStore(db),
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+	s.Primary = primary
+
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, users.CreateUser(ana))
+	workout, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "run", DurationMinutes: 30}, true)
+	require.NoError(t, err)
+	id := int64(workout.ID)
+	replica.stale[id] = *workout
+
+	title, duration := "tempo run", 45
+	_, _, err = s.Update(ctx, ana.ID, id, WorkoutPatch{Title: &title}, true)
+	require.NoError(t, err)
+	_, _, err = s.Update(ctx, ana.ID, id, WorkoutPatch{DurationMinutes: &duration}, true)
+	require.NoError(t, err)
+
+	saved, err := primary.GetWorkoutByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, "tempo run", saved.Title, "the first update survives the second")
+	assert.Equal(t, 45, saved.DurationMinutes)
+
+	other, _, err := s.Create(ctx, ana.ID, &store.Workout{Title: "swim", DurationMinutes: 20}, true)
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(ctx, ana.ID, int64(other.ID)), "not on the replica yet, still deletable")
+}
+
+// ! BenchmarkWorkoutCreate --> validation, calorie estimate, anomaly check, insert + the created event
+func BenchmarkWorkoutCreate(b *testing.B) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	s := NewWorkoutService(memstore.NewWorkoutStore(db), memstore.NewProfileStore(db), memstore.NewFollowStore(db),
+		anomaly.NewDetectorFromEnv(), nil, hooks.NewRegistry(logger), logger)
+	user := &store.User{Username: "bench", Email: "bench@example.com"}
+	if err := users.CreateUser(user); err != nil {
+		b.Fatal(err)
+	}
+	reps, weight := 10, 80.5
+
+	b.ReportAllocs()
+	for b.Loop() {
+		workout := &store.Workout{Title: "push day", DurationMinutes: 60, Entries: []store.WorkoutEntry{
+			{ExerciseName: "bench press", Sets: 4, Reps: &reps, Weight: &weight, OrderIndex: 1},
+			{ExerciseName: "dips", Sets: 3, Reps: &reps, OrderIndex: 2},
+		}}
+		_, _, err := s.Create(ctx, user.ID, workout, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ! MaxDifferences --> a rewrite that breaks everything doesn't need every path listed
+const MaxDifferences = 20
+
+// ! Diff --> JSON paths ("$.workout.entries[0].reps") where two bodies differ, "$" for non-JSON bodies that differ
+func Diff(a, b []byte) []string {
+	var left, right any
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		if bytes.Equal(a, b) {
+			return nil
+		}
+		return []string{"$"}
+	}
+	var paths []string
+	diffValues("$", left, right, &paths)
+	return paths
+}
+
+func diffValues(path string, a, b any, paths *[]string) {
+	if len(*paths) >= MaxDifferences {
+		return
+	}
+	switch left := a.(type) {
+	case map[string]any:
+		right, ok := b.(map[string]any)
+		if !ok {
+			*paths = append(*paths, path)
+			return
+		}
+		keys := make([]string, 0, len(left)+len(right))
+		for k := range left {
+			keys = append(keys, k)
+		}
+		for k := range right {
+			if _, seen := left[k]; !seen {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys) //* stable output, maps iterate randomly
+		for _, k := range keys {
+			diffValues(path+"."+k, left[k], right[k], paths)
+		}
+	case []any:
+		right, ok := b.([]any)
+		if !ok || len(left) != len(right) {
+			*paths = append(*paths, path)
+			return
+		}
+		for i := range left {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), left[i], right[i], paths)
+		}
+	default:
+		if !reflect.DeepEqual(a, b) {
+			*paths = append(*paths, path)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package shadow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	primary := []byte(`{"workout":{"id":1,"title":"Legs","entries":[{"reps":5},{"reps":8}]},"warnings":null}`)
+	assert.Empty(t, Diff(primary, []byte(`{"warnings":null,"workout":{"title":"Legs","id":1,"entries":[{"reps":5},{"reps":8}]}}`)))
+
+	shadow := []byte(`{"workout":{"id":1,"title":"legs","entries":[{"reps":5},{"reps":9}],"extra":true},"warnings":null}`)
+	assert.Equal(t, []string{"$.workout.entries[1].reps", "$.workout.extra", "$.workout.title"}, Diff(primary, shadow))
+
+	assert.Equal(t, []string{"$.workout.entries"}, Diff(primary, []byte(`{"workout":{"id":1,"title":"Legs","entries":[]},"warnings":null}`)))
+	assert.Equal(t, []string{"$"}, Diff([]byte("404 page not found"), []byte("not found")))
+	assert.Empty(t, Diff([]byte("ok"), []byte("ok")))
+}
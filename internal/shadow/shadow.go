@@ -0,0 +1,195 @@
+// ! package shadow --> mirrors a sample of read traffic to a secondary deployment and records where responses differ
+// ? used to roll out rewrites (e.g. a new workout store) against real traffic before they serve anyone
+package shadow
+
+import (
+	"context"
+	"fem/internal/store"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ! HeaderShadow --> set on mirrored requests so the secondary never mirrors them again
+const HeaderShadow = "X-Shadow-Request"
+
+// ! MaxBody --> responses larger than this are compared by status only
+const MaxBody = 1 << 20
+
+// ! Mirror --> pipeline middleware, the primary response is never delayed or changed by the mirror
+type Mirror struct {
+	Target  *url.URL
+	Percent int //* 0-100 of eligible requests
+	Client  *http.Client
+	Store   store.ShadowStore
+	Logger  *log.Logger
+
+	inFlight chan struct{} //* bounds concurrent mirrored requests, extra samples are dropped
+}
+
+// ! New --> target is the secondary base URL, e.g. http://workouts-v2.internal:8080
+func New(target string, percent, concurrency int, timeout time.Duration, shadowStore store.ShadowStore, logger *log.Logger) (*Mirror, error) {
+	base, err := url.Parse(target)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("shadow: invalid target %q", target)
+	}
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("shadow: percent must be between 0 and 100, got %d", percent)
+	}
+	return &Mirror{
+		Target:   base,
+		Percent:  percent,
+		Client:   &http.Client{Timeout: timeout},
+		Store:    shadowStore,
+		Logger:   logger,
+		inFlight: make(chan struct{}, max(concurrency, 1)),
+	}, nil
+}
+
+// ! eligible --> plain GETs only, streams and upgrades never end the way a comparison needs
+func eligible(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		r.Header.Get(HeaderShadow) == "" &&
+		r.Header.Get("Upgrade") == "" &&
+		!strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// ! Middleware --> records the primary response, then replays the request against Target in the background
+func (m *Mirror) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !eligible(r) || rand.IntN(100) >= m.Percent {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		primary := response{status: rec.status, body: rec.body, truncated: rec.truncated, elapsed: time.Since(start)}
+
+		select {
+		case m.inFlight <- struct{}{}:
+			req := mirroredRequest{method: r.Method, uri: r.URL.RequestURI(), header: r.Header.Clone()}
+			go func() {
+				defer func() { <-m.inFlight }()
+				m.compare(req, primary)
+			}()
+		default:
+			//* secondary is falling behind, skip this sample rather than queue up
+		}
+	})
+}
+
+type mirroredRequest struct {
+	method string
+	uri    string
+	header http.Header
+}
+
+type response struct {
+	status    int
+	body      []byte
+	truncated bool
+	elapsed   time.Duration
+}
+
+// ! compare --> replays req against Target, a mismatch or failure is stored as a ShadowDiff
+func (m *Mirror) compare(req mirroredRequest, primary response) {
+	diff := &store.ShadowDiff{
+		Method:        req.method,
+		Path:          req.uri,
+		PrimaryStatus: primary.status,
+		PrimaryMS:     int(primary.elapsed.Milliseconds()),
+		Differences:   []string{},
+	}
+
+	shadow, err := m.send(req)
+	diff.ShadowMS = int(shadow.elapsed.Milliseconds())
+	switch {
+	case err != nil:
+		message := err.Error()
+		diff.Error = &message
+	default:
+		diff.ShadowStatus = &shadow.status
+		if shadow.status != primary.status {
+			diff.Differences = append(diff.Differences, "status")
+		}
+		if !primary.truncated && !shadow.truncated {
+			diff.Differences = append(diff.Differences, Diff(primary.body, shadow.body)...)
+		}
+		if len(diff.Differences) == 0 {
+			return //* match, nothing to record
+		}
+	}
+
+	m.Logger.Printf("shadow: %s %s differs: %v", diff.Method, diff.Path, diff.Differences)
+	err = m.Store.RecordShadowDiff(diff)
+	if err != nil {
+		m.Logger.Printf("ERROR: recordShadowDiff: %v", err)
+	}
+}
+
+func (m *Mirror) send(req mirroredRequest) (response, error) {
+	start := time.Now()
+	out, err := http.NewRequestWithContext(context.Background(), req.method, strings.TrimSuffix(m.Target.String(), "/")+req.uri, nil)
+	if err != nil {
+		return response{}, err
+	}
+	out.Header = req.header
+	out.Header.Set(HeaderShadow, "1")
+	out.Header.Del("Accept-Encoding") //* compare plain bodies
+
+	resp, err := m.Client.Do(out)
+	if err != nil {
+		return response{elapsed: time.Since(start)}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBody+1))
+	if err != nil {
+		return response{elapsed: time.Since(start)}, err
+	}
+	return response{
+		status:    resp.StatusCode,
+		body:      body[:min(len(body), MaxBody)],
+		truncated: len(body) > MaxBody,
+		elapsed:   time.Since(start),
+	}, nil
+}
+
+// ! recorder --> passes the response through untouched, keeps a copy of status + body (up to MaxBody)
+type recorder struct {
+	http.ResponseWriter
+	status    int
+	body      []byte
+	truncated bool
+	wrote     bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wrote {
+		r.wrote = true
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wrote = true
+	if room := MaxBody - len(r.body); room >= len(b) {
+		r.body = append(r.body, b...)
+	} else {
+		r.truncated = true
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// ! Unwrap --> lets http.ResponseController reach Flush etc. on the real writer
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
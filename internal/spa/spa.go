@@ -0,0 +1,66 @@
+package spa
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ! ImmutablePrefix --> build tools put content-hashed files here, they can be cached forever
+const ImmutablePrefix = "assets/"
+
+// ! Handler --> serves a single-page app from fsys (index.html at the root)
+// ? mount as the router's NotFound handler so API routes always win
+type Handler struct {
+	files http.Handler
+	fsys  fs.FS
+}
+
+// ! New --> fsys must contain index.html
+func New(fsys fs.FS) (*Handler, error) {
+	_, err := fs.Stat(fsys, "index.html")
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{files: http.FileServer(http.FS(fsys)), fsys: fsys}, nil
+}
+
+// ! ServeHTTP --> real files as-is, other browser navigations get index.html so client-side routes work
+// ? anything that isn't a GET/HEAD or doesn't accept HTML keeps the plain 404 (API clients)
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.NotFound(w, req)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+req.URL.Path), "/")
+	if name != "" && name != "index.html" {
+		info, err := fs.Stat(h.fsys, name)
+		if err == nil && !info.IsDir() {
+			if strings.HasPrefix(name, ImmutablePrefix) {
+				w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else {
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+			h.files.ServeHTTP(w, req)
+			return
+		}
+		//* a missing hashed asset is a stale deploy, index.html would only confuse the browser
+		if strings.HasPrefix(name, ImmutablePrefix) || !acceptsHTML(req) {
+			http.NotFound(w, req)
+			return
+		}
+	}
+
+	//* index.html is revalidated on every load so a deploy is picked up right away
+	w.Header().Set("Cache-Control", "no-cache")
+	req = req.Clone(req.Context())
+	req.URL.Path = "/" //* FileServer redirects /index.html to /
+	h.files.ServeHTTP(w, req)
+}
+
+func acceptsHTML(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "text/html") || strings.Contains(accept, "*/*")
+}
@@ -0,0 +1,44 @@
+package spa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	handler, err := New(fstest.MapFS{
+		"index.html":             {Data: []byte("<html>app</html>")},
+		"favicon.ico":            {Data: []byte("icon")},
+		"assets/app.3f2a1b9c.js": {Data: []byte("console.log(1)")},
+	})
+	require.NoError(t, err)
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/assets/app.3f2a1b9c.js", "*/*")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Cache-Control"), "immutable")
+
+	rec = get("/workouts/42", "text/html")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<html>app</html>", rec.Body.String())
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+
+	assert.Equal(t, http.StatusNotFound, get("/workouts/42", "application/json").Code)
+	assert.Equal(t, http.StatusNotFound, get("/assets/app.old.js", "text/html").Code)
+	assert.Equal(t, http.StatusOK, get("/favicon.ico", "*/*").Code)
+
+	_, err = New(fstest.MapFS{})
+	assert.Error(t, err)
+}
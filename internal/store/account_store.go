@@ -0,0 +1,136 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - what we tell a user about their tokens, the hash itself never leaves the db
+type TokenInfo struct {
+	Scope  string    `json:"scope"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// * holds the db connection for whole-account reads (data export)
+type PostgresAccountStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new account store instance
+func NewPostgresAccountStore(db *sql.DB) *PostgresAccountStore {
+	return &PostgresAccountStore{db: db}
+}
+
+//! AccountStore interface --> everything stored about one user, for GDPR-style exports
+type AccountStore interface {
+	ListAccountWorkouts(userID int) ([]*Workout, error)
+	ListAccountTokens(userID int) ([]*TokenInfo, error)
+	ListAccountComments(userID int) ([]*Comment, error)
+}
+
+//! ListAccountWorkouts --> every workout the user logged, entries included, flagged ones too
+func (s *PostgresAccountStore) ListAccountWorkouts(userID int) ([]*Workout, error) {
+	query := `
+  SELECT id, user_id, title, COALESCE(description, ''), duration_minutes, COALESCE(calories_burned, 0),
+         calories_estimated, visibility, flagged, verified, created_at, performed_at
+  FROM workouts
+  WHERE user_id = $1
+  ORDER BY created_at, id
+  `
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := []*Workout{}
+	byID := map[int]*Workout{}
+	for rows.Next() {
+		w := &Workout{Entries: []WorkoutEntry{}}
+		err = rows.Scan(&w.ID, &w.UserID, &w.Title, &w.Description, &w.DurationMinutes, &w.CaloriesBurned,
+			&w.CaloriesEstimated, &w.Visibility, &w.Flagged, &w.Verified, &w.CreatedAt, &w.PerformedAt)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+		byID[w.ID] = w
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	entryQuery := `
+  SELECT e.workout_id, e.id, e.exercise_name, e.sets, e.reps, e.duration_seconds, e.weight::float8, e.distance::float8,
+         COALESCE(e.notes, ''), e.order_index
+  FROM workout_entries e
+  INNER JOIN workouts w ON w.id = e.workout_id
+  WHERE w.user_id = $1
+  ORDER BY e.workout_id, e.order_index
+  `
+	entryRows, err := s.db.Query(entryQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer entryRows.Close()
+
+	for entryRows.Next() {
+		var workoutID int
+		var entry WorkoutEntry
+		err = entryRows.Scan(&workoutID, &entry.ID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds,
+			&entry.Weight, &entry.Distance, &entry.Notes, &entry.OrderIndex)
+		if err != nil {
+			return nil, err
+		}
+		if w, ok := byID[workoutID]; ok {
+			w.Entries = append(w.Entries, entry)
+		}
+	}
+	return workouts, entryRows.Err()
+}
+
+func (s *PostgresAccountStore) ListAccountTokens(userID int) ([]*TokenInfo, error) {
+	rows, err := s.db.Query(`SELECT scope, expiry FROM tokens WHERE user_id = $1 ORDER BY expiry`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []*TokenInfo{}
+	for rows.Next() {
+		info := &TokenInfo{}
+		err = rows.Scan(&info.Scope, &info.Expiry)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+//! ListAccountComments --> comments the user wrote, on anyone's workout
+func (s *PostgresAccountStore) ListAccountComments(userID int) ([]*Comment, error) {
+	query := `
+  SELECT c.id, c.workout_id, c.user_id, u.username, c.body, c.created_at
+  FROM workout_comments c
+  INNER JOIN users u ON u.id = c.user_id
+  WHERE c.user_id = $1
+  ORDER BY c.created_at, c.id
+  `
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*Comment{}
+	for rows.Next() {
+		comment := &Comment{}
+		err = rows.Scan(&comment.ID, &comment.WorkoutID, &comment.UserID, &comment.Username, &comment.Body, &comment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
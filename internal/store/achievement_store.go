@@ -0,0 +1,126 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - numbers the achievement rules are evaluated against, flagged workouts never count
+type AchievementStats struct {
+	TotalWorkouts     int
+	LongestStreakDays int
+	MaxWeightKG       float64
+	TotalVolumeKG     float64 // * sets * reps * weight summed over every entry
+}
+
+// ? - an achievement a user has earned
+type UserAchievement struct {
+	Key      string    `json:"key"`
+	EarnedAt time.Time `json:"earned_at"`
+}
+
+// * holds the db connection for achievement operations
+type PostgresAchievementStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new achievement store instance
+func NewPostgresAchievementStore(db *sql.DB) *PostgresAchievementStore {
+	return &PostgresAchievementStore{db: db}
+}
+
+//! AchievementStore interface --> contract for rule inputs and earned badges
+type AchievementStore interface {
+	GetAchievementStats(userID int) (*AchievementStats, error)
+	AwardAchievement(userID int, key string) (bool, error)
+	ListAchievements(userID int) ([]*UserAchievement, error)
+	GetAchievement(userID int, key string) (*UserAchievement, error)
+}
+
+//! GetAchievementStats --> totals, longest run of consecutive training days, heaviest logged lift and lifetime volume
+//? streaks use gaps-and-islands: consecutive days minus their row number land on the same value
+//? days are local dates in the user's timezone, a late workout in UTC-8 doesn't land on tomorrow
+func (s *PostgresAchievementStore) GetAchievementStats(userID int) (*AchievementStats, error) {
+	stats := &AchievementStats{}
+	query := `
+  WITH tz AS (
+    SELECT COALESCE((SELECT timezone FROM user_profiles WHERE user_id = $1), 'UTC') AS name
+  ),
+  days AS (
+    SELECT DISTINCT (w.performed_at AT TIME ZONE tz.name)::date AS day
+    FROM workouts w, tz
+    WHERE w.user_id = $1 AND NOT w.flagged
+  ),
+  streaks AS (
+    SELECT COUNT(*) AS length
+    FROM (SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::int AS grp FROM days) d
+    GROUP BY grp
+  )
+  SELECT
+    (SELECT COUNT(*) FROM workouts WHERE user_id = $1 AND NOT flagged),
+    (SELECT COALESCE(MAX(length), 0) FROM streaks),
+    (SELECT COALESCE(MAX(e.weight), 0)::float8
+     FROM workout_entries e
+     INNER JOIN workouts w ON w.id = e.workout_id
+     WHERE w.user_id = $1 AND NOT w.flagged),
+    (SELECT COALESCE(SUM(e.sets * COALESCE(e.reps, 0) * COALESCE(e.weight, 0)), 0)::float8
+     FROM workout_entries e
+     INNER JOIN workouts w ON w.id = e.workout_id
+     WHERE w.user_id = $1 AND NOT w.flagged)
+  `
+	err := s.db.QueryRow(query, userID).Scan(&stats.TotalWorkouts, &stats.LongestStreakDays, &stats.MaxWeightKG, &stats.TotalVolumeKG)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+//! AwardAchievement --> true only the first time, earned badges are never taken back
+func (s *PostgresAchievementStore) AwardAchievement(userID int, key string) (bool, error) {
+	query := `
+  INSERT INTO user_achievements (user_id, achievement)
+  VALUES ($1, $2)
+  ON CONFLICT DO NOTHING
+  `
+	result, err := s.db.Exec(query, userID, key)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (s *PostgresAchievementStore) ListAchievements(userID int) ([]*UserAchievement, error) {
+	rows, err := s.db.Query(`SELECT achievement, earned_at FROM user_achievements WHERE user_id = $1 ORDER BY earned_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	achievements := []*UserAchievement{}
+	for rows.Next() {
+		achievement := &UserAchievement{}
+		err = rows.Scan(&achievement.Key, &achievement.EarnedAt)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, achievement)
+	}
+	return achievements, rows.Err()
+}
+
+func (s *PostgresAchievementStore) GetAchievement(userID int, key string) (*UserAchievement, error) {
+	achievement := &UserAchievement{}
+	query := `SELECT achievement, earned_at FROM user_achievements WHERE user_id = $1 AND achievement = $2`
+	err := s.db.QueryRow(query, userID, key).Scan(&achievement.Key, &achievement.EarnedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return achievement, nil
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// ? - a user as the admin UI sees it, deleted accounts included
+type AdminUser struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	IsAdmin      bool       `json:"is_admin"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeletedAt    *time.Time `json:"deleted_at"`
+	ActiveTokens int        `json:"active_tokens"`
+	Workouts     int        `json:"workouts"`
+}
+
+// ? - jobs per type and status, for the queue overview
+type JobCount struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// ? - GET /admin/jobs filters, empty strings match everything
+type JobFilter struct {
+	Status string
+	Type   string
+	Limit  int
+}
+
+// * holds the db connection for admin-only reads
+type PostgresAdminStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new admin store instance
+func NewPostgresAdminStore(db *sql.DB) *PostgresAdminStore {
+	return &PostgresAdminStore{db: db}
+}
+
+//! AdminStore interface --> cross-cutting lookups for the admin UI
+type AdminStore interface {
+	SearchUsers(query string, limit int) ([]*AdminUser, error)
+	GetAdminUser(id int64) (*AdminUser, error)
+	CountJobs() ([]*JobCount, error)
+	ListJobs(filter JobFilter) ([]*Job, error)
+}
+
+const adminUserColumns = `
+  u.id, u.username, u.email, u.is_admin, u.created_at, u.deleted_at,
+  (SELECT COUNT(*) FROM tokens t WHERE t.user_id = u.id AND t.expiry > now()),
+  (SELECT COUNT(*) FROM workouts w WHERE w.user_id = u.id)
+`
+
+func scanAdminUser(row interface{ Scan(...any) error }) (*AdminUser, error) {
+	user := &AdminUser{}
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.CreatedAt, &user.DeletedAt,
+		&user.ActiveTokens, &user.Workouts)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+//! SearchUsers --> username / email prefix match (case-insensitive), an id matches exactly
+func (s *PostgresAdminStore) SearchUsers(query string, limit int) ([]*AdminUser, error) {
+	sqlQuery := `
+  SELECT ` + adminUserColumns + `
+  FROM users u
+  WHERE u.username ILIKE $1 || '%' OR u.email ILIKE $1 || '%' OR u.id::text = $1
+  ORDER BY u.username
+  LIMIT $2
+  `
+	rows, err := s.db.Query(sqlQuery, escapeLike(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*AdminUser{}
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+//* escapeLike --> user input is matched literally, % and _ are not wildcards
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *PostgresAdminStore) GetAdminUser(id int64) (*AdminUser, error) {
+	user, err := scanAdminUser(s.db.QueryRow(`SELECT `+adminUserColumns+` FROM users u WHERE u.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return user, err
+}
+
+//! CountJobs --> done jobs are left out, they pile up and say nothing about queue health
+func (s *PostgresAdminStore) CountJobs() ([]*JobCount, error) {
+	query := `
+  SELECT type, status, COUNT(*)
+  FROM jobs
+  WHERE status <> 'done'
+  GROUP BY type, status
+  ORDER BY type, status
+  `
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*JobCount{}
+	for rows.Next() {
+		c := &JobCount{}
+		err = rows.Scan(&c.Type, &c.Status, &c.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+//! ListJobs --> newest first
+func (s *PostgresAdminStore) ListJobs(filter JobFilter) ([]*Job, error) {
+	query := `
+  SELECT id, type, payload, status, attempts, max_attempts, run_at, last_error, created_at
+  FROM jobs
+  WHERE ($1 = '' OR status = $1) AND ($2 = '' OR type = $2)
+  ORDER BY created_at DESC, id DESC
+  LIMIT $3
+  `
+	rows, err := s.db.Query(query, filter.Status, filter.Type, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*Job{}
+	for rows.Next() {
+		job := &Job{}
+		var payload []byte
+		err = rows.Scan(&job.ID, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt,
+			&job.LastError, &job.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		job.Payload = payload
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ? - one security-relevant event, see the audit package for the action names
+type AuditEntry struct {
+	ID        int64          `json:"id"`
+	Action    string         `json:"action"`
+	ActorID   *int           `json:"actor_id"`
+	TargetID  *int           `json:"target_id"`
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"user_agent"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ? - GET /admin/audit-log filters, zero values match everything, newest first below BeforeID
+type AuditFilter struct {
+	Action   string
+	ActorID  int
+	TargetID int
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64
+	Limit    int
+}
+
+// * holds the db connection for the audit log
+type PostgresAuditStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new audit store instance
+func NewPostgresAuditStore(db *sql.DB) *PostgresAuditStore {
+	return &PostgresAuditStore{db: db}
+}
+
+// ! AuditStore interface --> append-only, entries are never updated or deleted through the app
+type AuditStore interface {
+	RecordAudit(*AuditEntry) error
+	ListAudit(AuditFilter) ([]*AuditEntry, error)
+}
+
+func (s *PostgresAuditStore) RecordAudit(entry *AuditEntry) error {
+	var metadata []byte
+	if len(entry.Metadata) > 0 {
+		raw, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = raw
+	}
+	query := `
+  INSERT INTO audit_log (action, actor_id, target_id, ip, user_agent, metadata)
+  VALUES ($1, $2, $3, $4, $5, $6)
+  RETURNING id, created_at
+  `
+	return s.db.QueryRow(query, entry.Action, entry.ActorID, entry.TargetID, entry.IP, entry.UserAgent, metadata).
+		Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (s *PostgresAuditStore) ListAudit(filter AuditFilter) ([]*AuditEntry, error) {
+	where := []string{"TRUE"}
+	args := []any{}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		where = append(where, strings.ReplaceAll(clause, "?", "$"+strconv.Itoa(len(args))))
+	}
+	if filter.Action != "" {
+		add("action = ?", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		add("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != 0 {
+		add("target_id = ?", filter.TargetID)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < ?", filter.Until)
+	}
+	if filter.BeforeID != 0 {
+		add("id < ?", filter.BeforeID)
+	}
+	args = append(args, filter.Limit)
+
+	query := `
+  SELECT id, action, actor_id, target_id, ip, user_agent, metadata, created_at
+  FROM audit_log
+  WHERE ` + strings.Join(where, " AND ") + `
+  ORDER BY id DESC
+  LIMIT $` + strconv.Itoa(len(args))
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*AuditEntry{}
+	for rows.Next() {
+		entry := &AuditEntry{}
+		var metadata []byte
+		err = rows.Scan(&entry.ID, &entry.Action, &entry.ActorID, &entry.TargetID, &entry.IP, &entry.UserAgent, &metadata, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if metadata != nil {
+			err = json.Unmarshal(metadata, &entry.Metadata)
+			if err != nil {
+				return nil, err
+			}
+		}
+		list = append(list, entry)
+	}
+	return list, rows.Err()
+}
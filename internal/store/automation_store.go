@@ -0,0 +1,210 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// ? - a user's automation, Condition nil means every Trigger event fires the action
+type AutomationRule struct {
+	ID          int64                `json:"id"`
+	UserID      int                  `json:"-"`
+	Name        string               `json:"name"`
+	Trigger     string               `json:"trigger"`
+	Condition   *AutomationCondition `json:"condition"`
+	Action      AutomationAction     `json:"action"`
+	Enabled     bool                 `json:"enabled"`
+	LastFiredAt *time.Time           `json:"last_fired_at"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+// ? - "Metric over the current Window Op Value", e.g. workouts this week >= 3
+type AutomationCondition struct {
+	Metric string  `json:"metric"` // * workouts | minutes | calories
+	Window string  `json:"window"` // * day | week | month
+	Op     string  `json:"op"`     // * >= | > | == | <= | <
+	Value  float64 `json:"value"`
+}
+
+// ? - what happens when the rule fires
+type AutomationAction struct {
+	Type    string `json:"type"` // * email
+	Subject string `json:"subject,omitempty"`
+	Message string `json:"message,omitempty"` // * {{placeholders}} are filled in, see automation.Render
+}
+
+// ? - a user's training totals since the start of a window
+type AutomationStats struct {
+	Workouts int       `json:"workouts"`
+	Minutes  int       `json:"minutes"`
+	Calories int       `json:"calories"`
+	Since    time.Time `json:"since"`
+}
+
+// * holds the db connection for automation rules
+type PostgresAutomationStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new automation store instance
+func NewPostgresAutomationStore(db *sql.DB) *PostgresAutomationStore {
+	return &PostgresAutomationStore{db: db}
+}
+
+// ! AutomationStore interface --> rules + the stats their conditions are checked against
+type AutomationStore interface {
+	CreateRule(*AutomationRule) error
+	GetRule(id int64) (*AutomationRule, error)
+	ListRules(userID int) ([]*AutomationRule, error)
+	ListEnabledRules(userID int, trigger string) ([]*AutomationRule, error)
+	UpdateRule(*AutomationRule) error
+	DeleteRule(id int64) error
+	MarkRuleFired(id int64, at, notSince time.Time) (bool, error)
+	GetAutomationStats(userID int, since time.Time) (*AutomationStats, error)
+}
+
+const automationRuleColumns = `id, user_id, name, trigger, condition, action, enabled, last_fired_at, created_at, updated_at`
+
+func scanAutomationRule(row interface{ Scan(...any) error }) (*AutomationRule, error) {
+	rule := &AutomationRule{}
+	var condition, action []byte
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.Name, &rule.Trigger, &condition, &action, &rule.Enabled,
+		&rule.LastFiredAt, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if condition != nil {
+		err = json.Unmarshal(condition, &rule.Condition)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rule, json.Unmarshal(action, &rule.Action)
+}
+
+// * marshalRuleJSON --> condition stays SQL NULL when there is none
+func marshalRuleJSON(rule *AutomationRule) ([]byte, []byte, error) {
+	var condition []byte
+	if rule.Condition != nil {
+		raw, err := json.Marshal(rule.Condition)
+		if err != nil {
+			return nil, nil, err
+		}
+		condition = raw
+	}
+	action, err := json.Marshal(rule.Action)
+	return condition, action, err
+}
+
+func (s *PostgresAutomationStore) CreateRule(rule *AutomationRule) error {
+	condition, action, err := marshalRuleJSON(rule)
+	if err != nil {
+		return err
+	}
+	query := `
+  INSERT INTO automation_rules (user_id, name, trigger, condition, action, enabled)
+  VALUES ($1, $2, $3, $4, $5, $6)
+  RETURNING id, created_at, updated_at
+  `
+	return s.db.QueryRow(query, rule.UserID, rule.Name, rule.Trigger, condition, action, rule.Enabled).
+		Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+func (s *PostgresAutomationStore) GetRule(id int64) (*AutomationRule, error) {
+	rule, err := scanAutomationRule(s.db.QueryRow(`SELECT `+automationRuleColumns+` FROM automation_rules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+func (s *PostgresAutomationStore) listRules(query string, args ...any) ([]*AutomationRule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*AutomationRule{}
+	for rows.Next() {
+		rule, err := scanAutomationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, rule)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresAutomationStore) ListRules(userID int) ([]*AutomationRule, error) {
+	return s.listRules(`SELECT `+automationRuleColumns+` FROM automation_rules WHERE user_id = $1 ORDER BY id`, userID)
+}
+
+// ! ListEnabledRules --> what the engine evaluates when trigger is published for userID
+func (s *PostgresAutomationStore) ListEnabledRules(userID int, trigger string) ([]*AutomationRule, error) {
+	return s.listRules(`SELECT `+automationRuleColumns+` FROM automation_rules WHERE user_id = $1 AND trigger = $2 AND enabled ORDER BY id`, userID, trigger)
+}
+
+// ! UpdateRule --> changing a rule resets last_fired_at so the new condition gets a fresh window
+func (s *PostgresAutomationStore) UpdateRule(rule *AutomationRule) error {
+	condition, action, err := marshalRuleJSON(rule)
+	if err != nil {
+		return err
+	}
+	query := `
+  UPDATE automation_rules
+  SET name = $2, trigger = $3, condition = $4, action = $5, enabled = $6, last_fired_at = NULL, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1
+  RETURNING updated_at
+  `
+	rule.LastFiredAt = nil
+	return s.db.QueryRow(query, rule.ID, rule.Name, rule.Trigger, condition, action, rule.Enabled).Scan(&rule.UpdatedAt)
+}
+
+func (s *PostgresAutomationStore) DeleteRule(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM automation_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ! MarkRuleFired --> claims the firing, false when the rule already fired at or after notSince
+// ? a single conditional UPDATE so two events racing through the bus can't both fire the rule
+func (s *PostgresAutomationStore) MarkRuleFired(id int64, at, notSince time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+  UPDATE automation_rules
+  SET last_fired_at = $2
+  WHERE id = $1 AND (last_fired_at IS NULL OR last_fired_at < $3)
+  `, id, at, notSince)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// ! GetAutomationStats --> totals since the window start, flagged workouts don't count (same as goals)
+func (s *PostgresAutomationStore) GetAutomationStats(userID int, since time.Time) (*AutomationStats, error) {
+	stats := &AutomationStats{Since: since}
+	query := `
+  SELECT COUNT(*), COALESCE(SUM(duration_minutes), 0), COALESCE(SUM(calories_burned), 0)
+  FROM workouts
+  WHERE user_id = $1 AND NOT flagged AND created_at >= $2
+  `
+	err := s.db.QueryRow(query, userID, since).Scan(&stats.Workouts, &stats.Minutes, &stats.Calories)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
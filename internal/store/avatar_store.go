@@ -0,0 +1,57 @@
+package store
+
+import (
+	"database/sql"
+)
+
+// * holds the db connection for avatar keys
+type PostgresAvatarStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new avatar store instance
+func NewPostgresAvatarStore(db *sql.DB) *PostgresAvatarStore {
+	return &PostgresAvatarStore{db: db}
+}
+
+// ! AvatarStore interface --> which blob is a user's avatar, the key lives on the profile row
+type AvatarStore interface {
+	//* "" when the user has no avatar
+	GetAvatarKey(userID int) (string, error)
+	//* "" clears it, returns the key it replaced so the old blob can go
+	SetAvatarKey(userID int, key string) (previous string, err error)
+}
+
+func (s *PostgresAvatarStore) GetAvatarKey(userID int) (string, error) {
+	var key sql.NullString
+	err := s.db.QueryRow(`SELECT avatar_key FROM user_profiles WHERE user_id = $1`, userID).Scan(&key)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return key.String, err
+}
+
+// ! SetAvatarKey --> creates the profile row if needed, the row lock keeps two uploads from losing a previous key
+func (s *PostgresAvatarStore) SetAvatarKey(userID int, key string) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var previous sql.NullString
+	err = tx.QueryRow(`SELECT avatar_key FROM user_profiles WHERE user_id = $1 FOR UPDATE`, userID).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	query := `
+  INSERT INTO user_profiles (user_id, avatar_key)
+  VALUES ($1, NULLIF($2, ''))
+  ON CONFLICT (user_id) DO UPDATE SET avatar_key = EXCLUDED.avatar_key
+  `
+	_, err = tx.Exec(query, userID, key)
+	if err != nil {
+		return "", err
+	}
+	return previous.String, tx.Commit()
+}
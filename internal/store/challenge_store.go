@@ -0,0 +1,285 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ? - user-created, time-boxed competition, scored with the seasonal event metrics (EventMetric*)
+type Challenge struct {
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Metric       string     `json:"metric"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	Status       string     `json:"status"` // * EventStatus*, same lifecycle as seasonal events
+	Participants int        `json:"participants"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	CreatedBy    *int       `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// * holds the db connection for challenge operations
+type PostgresChallengeStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new challenge store instance
+func NewPostgresChallengeStore(db *sql.DB) *PostgresChallengeStore {
+	return &PostgresChallengeStore{db: db}
+}
+
+//! ChallengeStore interface --> contract for challenges, their participants and results
+type ChallengeStore interface {
+	CreateChallenge(*Challenge) error
+	GetChallenge(id int64) (*Challenge, error)
+	ListChallenges(userID int, limit int) ([]*Challenge, error)
+	JoinChallenge(id int64, userID int) error
+	LeaveChallenge(id int64, userID int) error
+	GetChallengeStandings(challenge *Challenge) ([]*EventStanding, error)
+	ListChallengesToClose(now time.Time) ([]*Challenge, error)
+	CloseChallenge(id int64) ([]*EventStanding, error)
+}
+
+const challengeColumns = `
+  c.id, c.title, c.description, c.metric, c.starts_at, c.ends_at,
+  CASE
+    WHEN c.closed_at IS NOT NULL THEN 'closed'
+    WHEN CURRENT_TIMESTAMP < c.starts_at THEN 'upcoming'
+    WHEN CURRENT_TIMESTAMP < c.ends_at THEN 'active'
+    ELSE 'ended'
+  END,
+  (SELECT COUNT(*) FROM challenge_participants p WHERE p.challenge_id = c.id)::int,
+  c.closed_at, c.created_by, c.created_at, c.updated_at
+`
+
+func scanChallenge(row interface{ Scan(...any) error }) (*Challenge, error) {
+	challenge := &Challenge{}
+	err := row.Scan(&challenge.ID, &challenge.Title, &challenge.Description, &challenge.Metric, &challenge.StartsAt,
+		&challenge.EndsAt, &challenge.Status, &challenge.Participants, &challenge.ClosedAt, &challenge.CreatedBy,
+		&challenge.CreatedAt, &challenge.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+//! CreateChallenge --> the creator takes part from the start, both rows in one transaction
+func (s *PostgresChallengeStore) CreateChallenge(challenge *Challenge) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`
+  INSERT INTO challenges (title, description, metric, starts_at, ends_at, created_by)
+  VALUES ($1, $2, $3, $4, $5, $6)
+  RETURNING id
+  `, challenge.Title, challenge.Description, challenge.Metric, challenge.StartsAt, challenge.EndsAt, challenge.CreatedBy).Scan(&id)
+	if err != nil {
+		return mapError(err)
+	}
+	if challenge.CreatedBy != nil {
+		_, err = tx.Exec(`INSERT INTO challenge_participants (challenge_id, user_id) VALUES ($1, $2)`, id, *challenge.CreatedBy)
+		if err != nil {
+			return mapError(err)
+		}
+	}
+
+	created, err := scanChallenge(tx.QueryRow(`SELECT`+challengeColumns+`FROM challenges c WHERE c.id = $1`, id))
+	if err != nil {
+		return err
+	}
+	*challenge = *created
+	return tx.Commit()
+}
+
+func (s *PostgresChallengeStore) GetChallenge(id int64) (*Challenge, error) {
+	challenge, err := scanChallenge(s.db.QueryRow(`SELECT`+challengeColumns+`FROM challenges c WHERE c.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+//! ListChallenges --> the ones userID takes part in, running and upcoming first, then the most recently finished
+func (s *PostgresChallengeStore) ListChallenges(userID int, limit int) ([]*Challenge, error) {
+	query := `SELECT` + challengeColumns + `
+  FROM challenges c
+  INNER JOIN challenge_participants me ON me.challenge_id = c.id AND me.user_id = $1
+  ORDER BY (c.ends_at < CURRENT_TIMESTAMP), c.starts_at DESC, c.id DESC
+  LIMIT $2
+  `
+	return s.queryChallenges(query, userID, limit)
+}
+
+func (s *PostgresChallengeStore) queryChallenges(query string, args ...any) ([]*Challenge, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	challenges := []*Challenge{}
+	for rows.Next() {
+		challenge, err := scanChallenge(rows)
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, challenge)
+	}
+	return challenges, rows.Err()
+}
+
+//! JoinChallenge --> joining twice is a no-op, ErrNotFound when the challenge is gone or already over
+func (s *PostgresChallengeStore) JoinChallenge(id int64, userID int) error {
+	query := `
+  INSERT INTO challenge_participants (challenge_id, user_id)
+  SELECT c.id, $2
+  FROM challenges c
+  WHERE c.id = $1 AND c.closed_at IS NULL AND c.ends_at > CURRENT_TIMESTAMP
+  ON CONFLICT (challenge_id, user_id) DO UPDATE SET joined_at = challenge_participants.joined_at
+  `
+	result, err := s.db.Exec(query, id, userID)
+	if err != nil {
+		return mapError(err)
+	}
+	return requireRow(result)
+}
+
+//! LeaveChallenge --> ErrNotFound when the user wasn't taking part or the challenge is already over, results stay as they were
+func (s *PostgresChallengeStore) LeaveChallenge(id int64, userID int) error {
+	query := `
+  DELETE FROM challenge_participants p
+  USING challenges c
+  WHERE p.challenge_id = $1 AND p.user_id = $2
+    AND c.id = p.challenge_id AND c.closed_at IS NULL AND c.ends_at > CURRENT_TIMESTAMP
+  `
+	result, err := s.db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+// * requireRow --> ErrNotFound unless the statement touched a row
+func requireRow(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! challengeStandingsQuery --> live ranking of every participant, workouts performed inside the window, flagged ones never count
+func challengeStandingsQuery(challenge *Challenge) (string, error) {
+	expr, ok := eventMetricExpr[challenge.Metric]
+	if !ok {
+		return "", fmt.Errorf("unknown challenge metric %q", challenge.Metric)
+	}
+	return `
+  WITH scores AS (
+    SELECT p.user_id, u.username, COALESCE(` + expr + `, 0)::float8 AS score
+    FROM challenge_participants p
+    INNER JOIN users u ON u.id = p.user_id
+    LEFT JOIN workouts w ON w.user_id = p.user_id AND NOT w.flagged
+      AND w.performed_at >= $2 AND w.performed_at < $3
+    WHERE p.challenge_id = $1
+    GROUP BY p.user_id, u.username
+  )
+  SELECT RANK() OVER (ORDER BY score DESC)::int AS rank, user_id, username, score
+  FROM scores
+  `, nil
+}
+
+//! GetChallengeStandings --> every participant, live while the challenge runs and frozen once it's closed
+func (s *PostgresChallengeStore) GetChallengeStandings(challenge *Challenge) ([]*EventStanding, error) {
+	if challenge.ClosedAt != nil {
+		rows, err := s.db.Query(`
+  SELECT r.rank, r.user_id, u.username, r.score
+  FROM challenge_results r
+  INNER JOIN users u ON u.id = r.user_id
+  WHERE r.challenge_id = $1
+  ORDER BY r.rank, r.user_id
+  `, challenge.ID)
+		if err != nil {
+			return nil, err
+		}
+		return scanStandings(rows)
+	}
+
+	query, err := challengeStandingsQuery(challenge)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(query+` ORDER BY rank, user_id`, challenge.ID, challenge.StartsAt, challenge.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	return scanStandings(rows)
+}
+
+//! ListChallengesToClose --> finished challenges the close job hasn't processed yet
+func (s *PostgresChallengeStore) ListChallengesToClose(now time.Time) ([]*Challenge, error) {
+	query := `SELECT` + challengeColumns + `
+  FROM challenges c
+  WHERE c.closed_at IS NULL AND c.ends_at <= $1
+  ORDER BY c.ends_at, c.id
+  `
+	return s.queryChallenges(query, now)
+}
+
+//! CloseChallenge --> freezes the final standings and marks the challenge closed in one transaction
+//? every participant gets a result row, returns nil if someone else closed it first
+func (s *PostgresChallengeStore) CloseChallenge(id int64) ([]*EventStanding, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	challenge := &Challenge{ID: int(id)}
+	err = tx.QueryRow(`
+  UPDATE challenges
+  SET closed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1 AND closed_at IS NULL
+  RETURNING metric, starts_at, ends_at
+  `, id).Scan(&challenge.Metric, &challenge.StartsAt, &challenge.EndsAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := challengeStandingsQuery(challenge)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`
+  INSERT INTO challenge_results (challenge_id, user_id, rank, score)
+  SELECT $1, ranked.user_id, ranked.rank, ranked.score
+  FROM (`+query+`) ranked
+  RETURNING rank, user_id, '', score
+  `, challenge.ID, challenge.StartsAt, challenge.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	results, err := scanStandings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, tx.Commit()
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - requests one client build made to one route on one day
+type ClientUsage struct {
+	Day           time.Time `json:"day,omitempty"`
+	ClientID      string    `json:"client_id"`
+	ClientVersion string    `json:"client_version"`
+	Method        string    `json:"method"`
+	Route         string    `json:"route"` // * chi route pattern, e.g. /workouts/{id}
+	Requests      int64     `json:"requests"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+}
+
+// ? - GET /admin/clients/usage filters, empty strings match everything
+type ClientUsageFilter struct {
+	Since    time.Time
+	Route    string
+	ClientID string
+	Limit    int
+}
+
+// * holds the db connection for client usage counters
+type PostgresClientUsageStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new client usage store instance
+func NewPostgresClientUsageStore(db *sql.DB) *PostgresClientUsageStore {
+	return &PostgresClientUsageStore{db: db}
+}
+
+//! ClientUsageStore interface --> per-day request counters written by clientusage.Recorder
+type ClientUsageStore interface {
+	AddClientUsage(usage []*ClientUsage) error
+	ListClientUsage(filter ClientUsageFilter) ([]*ClientUsage, error)
+}
+
+//! AddClientUsage --> adds to the day's counters, several instances can flush the same key
+func (s *PostgresClientUsageStore) AddClientUsage(usage []*ClientUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, u := range usage {
+		query := `
+    INSERT INTO client_usage (day, client_id, client_version, method, route, requests, last_seen_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    ON CONFLICT (day, client_id, client_version, method, route) DO UPDATE
+    SET requests = client_usage.requests + EXCLUDED.requests,
+        last_seen_at = GREATEST(client_usage.last_seen_at, EXCLUDED.last_seen_at)
+    `
+		_, err = tx.Exec(query, u.Day, u.ClientID, u.ClientVersion, u.Method, u.Route, u.Requests, u.LastSeenAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//! ListClientUsage --> totals since filter.Since, busiest first, days folded together
+func (s *PostgresClientUsageStore) ListClientUsage(filter ClientUsageFilter) ([]*ClientUsage, error) {
+	query := `
+  SELECT client_id, client_version, method, route, SUM(requests)::bigint, MAX(last_seen_at)
+  FROM client_usage
+  WHERE day >= $1::date
+    AND ($2 = '' OR route = $2)
+    AND ($3 = '' OR client_id = $3)
+  GROUP BY client_id, client_version, method, route
+  ORDER BY SUM(requests) DESC, route, client_id, client_version
+  LIMIT $4
+  `
+	rows, err := s.db.Query(query, filter.Since, filter.Route, filter.ClientID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*ClientUsage{}
+	for rows.Next() {
+		u := &ClientUsage{}
+		err = rows.Scan(&u.ClientID, &u.ClientVersion, &u.Method, &u.Route, &u.Requests, &u.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
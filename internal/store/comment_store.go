@@ -0,0 +1,179 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - one comment on a workout, Username is joined in for display
+// ? - comments of deleted accounts come back with UserID 0 and "[deleted]"
+type Comment struct {
+	ID        int       `json:"id"`
+	WorkoutID int       `json:"workout_id"`
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ? - comment + reaction totals shown next to a workout
+type SocialCounts struct {
+	Comments  int            `json:"comments"`
+	Reactions map[string]int `json:"reactions"` // * emoji --> count
+}
+
+// * holds the db connection for comment + reaction operations
+type PostgresCommentStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new comment store instance
+func NewPostgresCommentStore(db *sql.DB) *PostgresCommentStore {
+	return &PostgresCommentStore{db: db}
+}
+
+//! CommentStore interface --> contract for workout comments and emoji reactions
+type CommentStore interface {
+	CreateComment(*Comment) error
+	GetComment(id int64) (*Comment, error)
+	ListComments(workoutID int64, offset, limit int) ([]*Comment, int, error)
+	DeleteComment(id int64) error
+	AddReaction(workoutID, userID int64, emoji string) error
+	RemoveReaction(workoutID, userID int64, emoji string) error
+	GetCounts(workoutID int64) (*SocialCounts, error)
+}
+
+func (s *PostgresCommentStore) CreateComment(comment *Comment) error {
+	query := `
+  WITH inserted AS (
+    INSERT INTO workout_comments (workout_id, user_id, body)
+    VALUES ($1, $2, $3)
+    RETURNING id, user_id, created_at
+  )
+  SELECT i.id, u.username, i.created_at
+  FROM inserted i
+  INNER JOIN users u ON u.id = i.user_id
+  `
+	err := s.db.QueryRow(query, comment.WorkoutID, comment.UserID, comment.Body).Scan(&comment.ID, &comment.Username, &comment.CreatedAt)
+	return mapError(err) //* ErrForeignKey --> the workout was deleted in the meantime
+}
+
+func (s *PostgresCommentStore) GetComment(id int64) (*Comment, error) {
+	comment := &Comment{}
+	query := `
+  SELECT c.id, c.workout_id, COALESCE(c.user_id, 0), COALESCE(u.username, '[deleted]'), c.body, c.created_at
+  FROM workout_comments c
+  LEFT JOIN users u ON u.id = c.user_id
+  WHERE c.id = $1
+  `
+	err := s.db.QueryRow(query, id).Scan(&comment.ID, &comment.WorkoutID, &comment.UserID, &comment.Username, &comment.Body, &comment.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+//! ListComments --> oldest first so threads read top to bottom, plus the total for pagination
+func (s *PostgresCommentStore) ListComments(workoutID int64, offset, limit int) ([]*Comment, int, error) {
+	var total int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM workout_comments WHERE workout_id = $1`, workoutID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+  SELECT c.id, c.workout_id, COALESCE(c.user_id, 0), COALESCE(u.username, '[deleted]'), c.body, c.created_at
+  FROM workout_comments c
+  LEFT JOIN users u ON u.id = c.user_id
+  WHERE c.workout_id = $1
+  ORDER BY c.created_at, c.id
+  OFFSET $2 LIMIT $3
+  `
+	rows, err := s.db.Query(query, workoutID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments := []*Comment{}
+	for rows.Next() {
+		comment := &Comment{}
+		err = rows.Scan(&comment.ID, &comment.WorkoutID, &comment.UserID, &comment.Username, &comment.Body, &comment.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, total, rows.Err()
+}
+
+func (s *PostgresCommentStore) DeleteComment(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM workout_comments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! AddReaction --> one reaction per user per emoji, repeating it is a no-op
+func (s *PostgresCommentStore) AddReaction(workoutID, userID int64, emoji string) error {
+	query := `
+  INSERT INTO workout_reactions (workout_id, user_id, emoji)
+  VALUES ($1, $2, $3)
+  ON CONFLICT DO NOTHING
+  `
+	_, err := s.db.Exec(query, workoutID, userID, emoji)
+	return err
+}
+
+func (s *PostgresCommentStore) RemoveReaction(workoutID, userID int64, emoji string) error {
+	result, err := s.db.Exec(`DELETE FROM workout_reactions WHERE workout_id = $1 AND user_id = $2 AND emoji = $3`, workoutID, userID, emoji)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresCommentStore) GetCounts(workoutID int64) (*SocialCounts, error) {
+	counts := &SocialCounts{Reactions: map[string]int{}}
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM workout_comments WHERE workout_id = $1`, workoutID).Scan(&counts.Comments)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT emoji, COUNT(*) FROM workout_reactions WHERE workout_id = $1 GROUP BY emoji`, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var emoji string
+		var count int
+		err = rows.Scan(&emoji, &count)
+		if err != nil {
+			return nil, err
+		}
+		counts.Reactions[emoji] = count
+	}
+	return counts, rows.Err()
+}
@@ -18,10 +18,8 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
-//! Open --> establishes connection to PostgreSQL database
-//! Using port 5445 locally (not 5432) to avoid Windows port reservation conflicts
-//! In Docker, it uses environment variables and connects to port 5432
-func Open() (*sql.DB, error) {
+//! connString --> DSN built from DB_HOST / DB_PORT / DB_USER / DB_PASSWORD / DB_NAME
+func connString() string {
 	//* get database configuration from environment variables with fallbacks
 	host := getEnv("DB_HOST", "localhost")
 	port := getEnv("DB_PORT", "5445") //* 5445 for local Windows, 5432 in Docker
@@ -30,20 +28,39 @@ func Open() (*sql.DB, error) {
 	dbname := getEnv("DB_NAME", "postgres")
 
 	//* connection string with all database credentials
-	connStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		host, user, password, dbname, port)
+}
 
-	db, err := sql.Open("pgx", connStr)
+//! Open --> establishes connection to PostgreSQL database
+//! Using port 5445 locally (not 5432) to avoid Windows port reservation conflicts
+//! In Docker, it uses environment variables and connects to port 5432
+func Open() (*sql.DB, error) {
+	host := getEnv("DB_HOST", "localhost")
+	port := getEnv("DB_PORT", "5445")
+
+	db, err := sql.Open("pgx", connString())
 
 	//? if caught any error while opening connection
 	if err != nil {
 		return nil, fmt.Errorf("db : open %w", err)
 	}
+	PoolConfigFromEnv().Apply(db) //* pool limits from DB_MAX_OPEN_CONNS & co
 	fmt.Printf("Connected to the Database at %s:%s...\n", host, port)
 	return db, err //* return connection pool
 
 }
 
+//! OpenURL --> connects to a second database by DSN, used for the dual-write secondary
+func OpenURL(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("db : open %w", err)
+	}
+	PoolConfigFromEnv().Apply(db)
+	return db, nil
+}
+
 //! Migratefs --> runs database migrations from embedded filesystem
 //! Migrations are version control for database schema changes
 func Migratefs(db *sql.DB,migrationfs fs.FS,dir string) error {
@@ -72,4 +89,26 @@ func Migrate(db *sql.DB,dir string)error{
 	}
 	return nil
 
-}
\ No newline at end of file
+}
+//! MigrationStatus --> applied version in the db vs newest embedded migration, read only
+//? a missing goose table means nothing has been applied yet
+func MigrationStatus(db *sql.DB, migrationfs fs.FS, dir string) (current, latest int64, err error) {
+	goose.SetBaseFS(migrationfs)
+	defer goose.SetBaseFS(nil)
+
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, 0, fmt.Errorf("migrations : %w", err)
+	}
+	if last, err := migrations.Last(); err == nil {
+		latest = last.Version
+	}
+
+	var exists bool
+	err = db.QueryRow(`SELECT to_regclass('goose_db_version') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return 0, latest, err
+	}
+	err = db.QueryRow(`SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&current)
+	return current, latest, err
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - someone the weekly digest may go to, Timezone decides their Monday morning
+type DigestRecipient struct {
+	UserID   int
+	Username string
+	Email    string
+	Timezone string
+}
+
+// ? - an exercise whose heaviest set in the digest week beat everything logged before it
+type PersonalRecord struct {
+	ExerciseName string  `json:"exercise_name"`
+	WeightKG     float64 `json:"weight_kg"`
+	PreviousKG   float64 `json:"previous_kg"` // * the best before the week, a first-ever lift is no record
+}
+
+// ? - what a user did in one digest week, flagged workouts never count
+type DigestSummary struct {
+	Workouts int
+	Minutes  int
+	VolumeKG float64 // * sets * reps * weight, like AchievementStats
+	Records  []*PersonalRecord
+}
+
+// * holds the db connection for weekly digest operations
+type PostgresDigestStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new digest store instance
+func NewPostgresDigestStore(db *sql.DB) *PostgresDigestStore {
+	return &PostgresDigestStore{db: db}
+}
+
+//! DigestStore interface --> contract for who gets the weekly digest, what goes in it and which weeks went out
+type DigestStore interface {
+	//* users whose digest is due at now: it's Monday past sendAt where they live and last week's hasn't gone out
+	ListDigestRecipients(now time.Time, sendAt time.Duration) ([]*DigestRecipient, error)
+	GetDigestSummary(userID int, from, to time.Time) (*DigestSummary, error)
+	MarkDigestSent(userID int, week time.Time) (bool, error)
+	//* gives a claimed week back when its email couldn't be enqueued, the next run tries again
+	UnmarkDigestSent(userID int, week time.Time) error
+}
+
+//! ListDigestRecipients --> live, non-sandbox users with weekly_digest on (no preferences row means on) whose digest is due
+//? the Monday window and digest_deliveries are checked here, so a run only sees the few users it has to send to
+func (s *PostgresDigestStore) ListDigestRecipients(now time.Time, sendAt time.Duration) ([]*DigestRecipient, error) {
+	query := `
+  SELECT r.id, r.username, r.email, r.timezone
+  FROM (
+    SELECT u.id, u.username, u.email, COALESCE(p.timezone, 'UTC') AS timezone,
+           $1::timestamptz AT TIME ZONE COALESCE(p.timezone, 'UTC') AS local
+    FROM users u
+    LEFT JOIN user_profiles p ON p.user_id = u.id
+    LEFT JOIN notification_preferences np ON np.user_id = u.id
+    WHERE u.deleted_at IS NULL AND u.sandbox_expires_at IS NULL AND COALESCE(np.weekly_digest, TRUE)
+  ) r
+  WHERE EXTRACT(ISODOW FROM r.local) = 1
+    AND EXTRACT(EPOCH FROM r.local::time) >= $2
+    AND NOT EXISTS (SELECT 1 FROM digest_deliveries d WHERE d.user_id = r.id AND d.week = r.local::date - 7)
+  ORDER BY r.id
+  `
+	rows, err := s.db.Query(query, now, sendAt.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := []*DigestRecipient{}
+	for rows.Next() {
+		r := &DigestRecipient{}
+		err = rows.Scan(&r.UserID, &r.Username, &r.Email, &r.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+//! GetDigestSummary --> totals for workouts performed in [from, to) plus the personal records set in it
+func (s *PostgresDigestStore) GetDigestSummary(userID int, from, to time.Time) (*DigestSummary, error) {
+	summary := &DigestSummary{}
+	query := `
+  SELECT
+    COUNT(*),
+    COALESCE(SUM(w.duration_minutes), 0),
+    (SELECT COALESCE(SUM(e.sets * COALESCE(e.reps, 0) * COALESCE(e.weight, 0)), 0)::float8
+     FROM workout_entries e
+     INNER JOIN workouts ew ON ew.id = e.workout_id
+     WHERE ew.user_id = $1 AND NOT ew.flagged AND ew.performed_at >= $2 AND ew.performed_at < $3)
+  FROM workouts w
+  WHERE w.user_id = $1 AND NOT w.flagged AND w.performed_at >= $2 AND w.performed_at < $3
+  `
+	err := s.db.QueryRow(query, userID, from, to).Scan(&summary.Workouts, &summary.Minutes, &summary.VolumeKG)
+	if err != nil {
+		return nil, err
+	}
+
+	records := `
+  WITH best AS (
+    SELECT e.exercise_name,
+      MAX(e.weight) FILTER (WHERE w.performed_at >= $2)::float8 AS week,
+      MAX(e.weight) FILTER (WHERE w.performed_at < $2)::float8 AS before
+    FROM workout_entries e
+    INNER JOIN workouts w ON w.id = e.workout_id
+    WHERE w.user_id = $1 AND NOT w.flagged AND e.weight IS NOT NULL AND w.performed_at < $3
+    GROUP BY e.exercise_name
+  )
+  SELECT exercise_name, week, before
+  FROM best
+  WHERE week > before
+  ORDER BY exercise_name
+  `
+	rows, err := s.db.Query(records, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary.Records = []*PersonalRecord{}
+	for rows.Next() {
+		record := &PersonalRecord{}
+		err = rows.Scan(&record.ExerciseName, &record.WeightKG, &record.PreviousKG)
+		if err != nil {
+			return nil, err
+		}
+		summary.Records = append(summary.Records, record)
+	}
+	return summary, rows.Err()
+}
+
+//! MarkDigestSent --> claims a user's digest for week (its local Monday), false when another run already sent it
+func (s *PostgresDigestStore) MarkDigestSent(userID int, week time.Time) (bool, error) {
+	result, err := s.db.Exec(`INSERT INTO digest_deliveries (user_id, week) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, week)
+	if err != nil {
+		return false, mapError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (s *PostgresDigestStore) UnmarkDigestSent(userID int, week time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM digest_deliveries WHERE user_id = $1 AND week = $2`, userID, week)
+	return err
+}
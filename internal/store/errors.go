@@ -0,0 +1,83 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// ! store errors --> what every store answers instead of driver errors (sql.ErrNoRows, pgx, postgres + sqlite codes)
+// ? most getters still answer (nil, nil) for a missing row; ErrNotFound is for writes, lookups that need the row
+// ? and getters whose interface says so (GetWorkoutByID)
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")               //* a unique constraint rejected the write
+	ErrForeignKey = errors.New("referenced row missing") //* a foreign key rejected the write
+)
+
+// ! ConstraintError --> ErrConflict / ErrForeignKey plus the constraint that fired, errors.Is matches the sentinel
+type ConstraintError struct {
+	Kind       error
+	Constraint string
+	Err        error //* the driver's error
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Constraint == "" {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Constraint)
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// * postgres SQLSTATEs + sqlite extended result codes for the constraints above
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// ! mapError --> driver errors as the store errors, anything else comes back unchanged
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return &ConstraintError{Kind: ErrConflict, Constraint: pgErr.ConstraintName, Err: err}
+		case pgForeignKeyViolation:
+			return &ConstraintError{Kind: ErrForeignKey, Constraint: pgErr.ConstraintName, Err: err}
+		}
+	}
+
+	//? modernc.org/sqlite's *sqlite.Error, matched by method so the store doesn't import the driver
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqliteConstraintUnique, sqliteConstraintPrimaryKey:
+			return &ConstraintError{Kind: ErrConflict, Err: err}
+		case sqliteConstraintForeignKey:
+			return &ConstraintError{Kind: ErrForeignKey, Err: err}
+		}
+	}
+	return err
+}
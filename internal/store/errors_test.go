@@ -0,0 +1,37 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type sqliteCodeError int
+
+func (e sqliteCodeError) Error() string { return fmt.Sprintf("sqlite error %d", int(e)) }
+func (e sqliteCodeError) Code() int     { return int(e) }
+
+func TestMapError(t *testing.T) {
+	assert.NoError(t, mapError(nil))
+	assert.Equal(t, ErrNotFound, mapError(sql.ErrNoRows))
+	assert.Equal(t, ErrNotFound, mapError(fmt.Errorf("scan: %w", pgx.ErrNoRows)))
+	assert.False(t, errors.Is(mapError(sql.ErrNoRows), sql.ErrNoRows), "the driver error doesn't leak")
+
+	unique := &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_username_key"}
+	err := mapError(unique)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, unique)
+	assert.Equal(t, "conflict: users_username_key", err.Error())
+	assert.ErrorIs(t, mapError(&pgconn.PgError{Code: pgForeignKeyViolation}), ErrForeignKey)
+
+	assert.ErrorIs(t, mapError(sqliteCodeError(sqliteConstraintUnique)), ErrConflict)
+	assert.ErrorIs(t, mapError(sqliteCodeError(sqliteConstraintForeignKey)), ErrForeignKey)
+
+	other := errors.New("connection reset")
+	assert.Equal(t, other, mapError(other))
+}
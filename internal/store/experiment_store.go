@@ -0,0 +1,52 @@
+package store
+
+import (
+	"database/sql"
+)
+
+// ? - a user was shown Variant of Experiment
+type ExperimentExposure struct {
+	Experiment string
+	Variant    string
+}
+
+// * holds the db connection for experiment exposure logging
+type PostgresExperimentStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new experiment store instance
+func NewPostgresExperimentStore(db *sql.DB) *PostgresExperimentStore {
+	return &PostgresExperimentStore{db: db}
+}
+
+//! ExperimentStore interface --> exposure log read by the warehouse sync
+type ExperimentStore interface {
+	LogExposures(userID int, exposures []ExperimentExposure) error
+}
+
+//! LogExposures --> only the first exposure per variant is kept, analysis keys off that timestamp
+func (s *PostgresExperimentStore) LogExposures(userID int, exposures []ExperimentExposure) error {
+	if len(exposures) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, exposure := range exposures {
+		query := `
+    INSERT INTO experiment_exposures (user_id, experiment, variant)
+    VALUES ($1, $2, $3)
+    ON CONFLICT DO NOTHING
+    `
+		_, err = tx.Exec(query, userID, exposure.Experiment, exposure.Variant)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
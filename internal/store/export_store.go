@@ -0,0 +1,139 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! export kinds + lifecycle states
+const (
+	ExportKindOrg     = "org"
+	ExportKindAccount = "account" // * one user's own data
+
+	ExportStatusPending = "pending"
+	ExportStatusRunning = "running"
+	ExportStatusDone    = "done"
+	ExportStatusFailed  = "failed"
+)
+
+// ? - one requested export, produced asynchronously and downloaded later
+type ExportJob struct {
+	ID          int        `json:"id"`
+	Kind        string     `json:"kind"`
+	OrgID       *int       `json:"org_id,omitempty"`
+	RequestedBy int        `json:"requested_by"`
+	Format      string     `json:"format"`
+	Anonymize   bool       `json:"anonymize"`
+	Locale      string     `json:"locale,omitempty"` // * CSV number + date format, empty = machine readable
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"` // * server-side location, never exposed
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// * holds the db connection for export job bookkeeping
+type PostgresExportStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new export store instance
+func NewPostgresExportStore(db *sql.DB) *PostgresExportStore {
+	return &PostgresExportStore{db: db}
+}
+
+//! ExportStore interface --> contract for export job bookkeeping
+type ExportStore interface {
+	CreateExport(*ExportJob) error
+	GetExport(id int64) (*ExportJob, error)
+	UpdateExport(*ExportJob) error
+	ListUserExports(userID int, since time.Time) ([]*ExportJob, error)
+}
+
+func (s *PostgresExportStore) CreateExport(job *ExportJob) error {
+	job.Status = ExportStatusPending
+	query := `
+  INSERT INTO exports (kind, org_id, requested_by, format, anonymize, locale, range_from, range_to, status)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+  RETURNING id, created_at
+  `
+	return s.db.QueryRow(query, job.Kind, job.OrgID, job.RequestedBy, job.Format, job.Anonymize, job.Locale, job.From, job.To, job.Status).Scan(&job.ID, &job.CreatedAt)
+}
+
+func (s *PostgresExportStore) GetExport(id int64) (*ExportJob, error) {
+	job := &ExportJob{}
+	query := `
+  SELECT id, kind, org_id, requested_by, format, anonymize, locale, range_from, range_to, status,
+         COALESCE(file_path, ''), COALESCE(error, ''), created_at, completed_at
+  FROM exports
+  WHERE id = $1
+  `
+	err := s.db.QueryRow(query, id).Scan(
+		&job.ID,
+		&job.Kind,
+		&job.OrgID,
+		&job.RequestedBy,
+		&job.Format,
+		&job.Anonymize,
+		&job.Locale,
+		&job.From,
+		&job.To,
+		&job.Status,
+		&job.FilePath,
+		&job.Error,
+		&job.CreatedAt,
+		&job.CompletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil // ? - export doesn't exist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+//! UpdateExport --> persists status transitions, stamping completed_at on terminal states
+func (s *PostgresExportStore) UpdateExport(job *ExportJob) error {
+	if job.Status == ExportStatusDone || job.Status == ExportStatusFailed {
+		now := time.Now()
+		job.CompletedAt = &now
+	}
+	query := `
+  UPDATE exports
+  SET status = $1, file_path = NULLIF($2, ''), error = NULLIF($3, ''), completed_at = $4
+  WHERE id = $5
+  `
+	_, err := s.db.Exec(query, job.Status, job.FilePath, job.Error, job.CompletedAt, job.ID)
+	return err
+}
+
+//! ListUserExports --> account exports the user requested since `since`, newest first
+func (s *PostgresExportStore) ListUserExports(userID int, since time.Time) ([]*ExportJob, error) {
+	query := `
+  SELECT id, kind, org_id, requested_by, format, anonymize, locale, range_from, range_to, status,
+         COALESCE(file_path, ''), COALESCE(error, ''), created_at, completed_at
+  FROM exports
+  WHERE requested_by = $1 AND kind = $2 AND created_at >= $3
+  ORDER BY created_at DESC, id DESC
+  `
+	rows, err := s.db.Query(query, userID, ExportKindAccount, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*ExportJob{}
+	for rows.Next() {
+		job := &ExportJob{}
+		err = rows.Scan(&job.ID, &job.Kind, &job.OrgID, &job.RequestedBy, &job.Format, &job.Anonymize, &job.Locale, &job.From, &job.To,
+			&job.Status, &job.FilePath, &job.Error, &job.CreatedAt, &job.CompletedAt)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
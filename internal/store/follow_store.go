@@ -0,0 +1,187 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - one workout in someone's activity feed, entries are left out to keep the feed light
+type FeedItem struct {
+	WorkoutID       int       `json:"workout_id"`
+	UserID          int       `json:"user_id"`
+	Username        string    `json:"username"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	DurationMinutes int       `json:"duration_minutes"`
+	CaloriesBurned  int       `json:"calories_burned"`
+	Visibility      string    `json:"visibility"`
+	Verified        bool      `json:"verified"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ? - keyset position in the feed, the next page starts strictly before (At, ID)
+type FeedCursor struct {
+	At time.Time
+	ID int64
+}
+
+// * holds the db connection for follow + feed operations
+type PostgresFollowStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new follow store instance
+func NewPostgresFollowStore(db *sql.DB) *PostgresFollowStore {
+	return &PostgresFollowStore{db: db}
+}
+
+//! FollowStore interface --> contract for the social graph and activity feed
+type FollowStore interface {
+	Follow(followerID, followeeID int64) error
+	Unfollow(followerID, followeeID int64) error
+	IsFollowing(followerID, followeeID int64) (bool, error)
+	GetFeed(userID int64, before *FeedCursor, limit int) ([]*FeedItem, error)
+	FanOutWorkout(workoutID int64) ([]int, error)
+	BackfillFeed(followerID, followeeID int64) (int64, error)
+}
+
+//! Follow --> following someone twice is a no-op
+func (s *PostgresFollowStore) Follow(followerID, followeeID int64) error {
+	query := `
+  INSERT INTO follows (follower_id, followee_id)
+  VALUES ($1, $2)
+  ON CONFLICT DO NOTHING
+  `
+	_, err := s.db.Exec(query, followerID, followeeID)
+	return err
+}
+
+//! Unfollow --> ErrNotFound when the caller wasn't following, also clears the followee out of the caller's feed
+func (s *PostgresFollowStore) Unfollow(followerID, followeeID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	query := `
+  DELETE FROM feed_entries e
+  USING workouts w
+  WHERE e.workout_id = w.id AND e.user_id = $1 AND w.user_id = $2
+  `
+	_, err = tx.Exec(query, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresFollowStore) IsFollowing(followerID, followeeID int64) (bool, error) {
+	var following bool
+	query := `SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)`
+	err := s.db.QueryRow(query, followerID, followeeID).Scan(&following)
+	return following, err
+}
+
+//! GetFeed --> newest first workouts from followed users, private workouts never show up
+//? reads the fanned-out feed_entries; visibility is re-checked here since a workout can go private after fan-out
+//? keyset pagination on (created_at, id) so new workouts don't shift later pages
+func (s *PostgresFollowStore) GetFeed(userID int64, before *FeedCursor, limit int) ([]*FeedItem, error) {
+	query := `
+  SELECT w.id, w.user_id, u.username, w.title, COALESCE(w.description, ''), w.duration_minutes,
+         COALESCE(w.calories_burned, 0), w.visibility, w.verified, e.created_at
+  FROM feed_entries e
+  INNER JOIN workouts w ON w.id = e.workout_id
+  INNER JOIN users u ON u.id = w.user_id
+  WHERE e.user_id = $1
+    AND w.visibility IN ('followers', 'public')
+    AND ($2::timestamptz IS NULL OR (e.created_at, e.workout_id) < ($2, $3))
+  ORDER BY e.created_at DESC, e.workout_id DESC
+  LIMIT $4
+  `
+	var beforeAt *time.Time
+	var beforeID int64
+	if before != nil {
+		beforeAt = &before.At
+		beforeID = before.ID
+	}
+
+	rows, err := s.db.Query(query, userID, beforeAt, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*FeedItem{}
+	for rows.Next() {
+		item := &FeedItem{}
+		err = rows.Scan(&item.WorkoutID, &item.UserID, &item.Username, &item.Title, &item.Description, &item.DurationMinutes,
+			&item.CaloriesBurned, &item.Visibility, &item.Verified, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+//! FanOutWorkout --> copies a workout into every follower's feed, runs as a feed.fanout job
+//? private workouts are skipped, re-running after a visibility change is safe
+//? returns the followers that got a new entry
+func (s *PostgresFollowStore) FanOutWorkout(workoutID int64) ([]int, error) {
+	query := `
+  INSERT INTO feed_entries (user_id, workout_id, created_at)
+  SELECT f.follower_id, w.id, COALESCE(w.created_at, CURRENT_TIMESTAMP)
+  FROM workouts w
+  INNER JOIN follows f ON f.followee_id = w.user_id
+  WHERE w.id = $1 AND w.visibility IN ('followers', 'public')
+  ON CONFLICT DO NOTHING
+  RETURNING user_id
+  `
+	rows, err := s.db.Query(query, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	followers := []int{}
+	for rows.Next() {
+		var followerID int
+		err = rows.Scan(&followerID)
+		if err != nil {
+			return nil, err
+		}
+		followers = append(followers, followerID)
+	}
+	return followers, rows.Err()
+}
+
+//! BackfillFeed --> a new follow pulls the followee's existing workouts into the follower's feed
+func (s *PostgresFollowStore) BackfillFeed(followerID, followeeID int64) (int64, error) {
+	query := `
+  INSERT INTO feed_entries (user_id, workout_id, created_at)
+  SELECT f.follower_id, w.id, COALESCE(w.created_at, CURRENT_TIMESTAMP)
+  FROM follows f
+  INNER JOIN workouts w ON w.user_id = f.followee_id
+  WHERE f.follower_id = $1 AND f.followee_id = $2 AND w.visibility IN ('followers', 'public')
+  ON CONFLICT DO NOTHING
+  `
+	result, err := s.db.Exec(query, followerID, followeeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
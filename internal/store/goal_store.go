@@ -0,0 +1,154 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! goal types
+const (
+	GoalWeeklyWorkouts = "weekly_workouts" //* workouts logged in the current week
+	GoalTotalMinutes   = "total_minutes"   //* minutes trained since the goal was set
+	GoalWeightTarget   = "weight_target"   //* reach a body weight, start_value is the weight when the goal was set
+)
+
+// ? - a user goal, CurrentValue is computed on read from workouts / weights
+type Goal struct {
+	ID              int       `json:"id"`
+	UserID          int       `json:"user_id"`
+	Type            string    `json:"type"`
+	TargetValue     float64   `json:"target_value"`
+	StartValue      *float64  `json:"start_value"`
+	Deadline        *string   `json:"deadline"` // * YYYY-MM-DD, optional
+	CurrentValue    *float64  `json:"current_value"`
+	PercentComplete float64   `json:"percent_complete"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// * holds the db connection for goal operations
+type PostgresGoalStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new goal store instance
+func NewPostgresGoalStore(db *sql.DB) *PostgresGoalStore {
+	return &PostgresGoalStore{db: db}
+}
+
+//! GoalStore interface --> contract for goals and their progress inputs
+type GoalStore interface {
+	CreateGoal(*Goal) error
+	GetGoal(id int64) (*Goal, error)
+	ListGoals(userID int64) ([]*Goal, error)
+	UpdateGoal(*Goal) error
+	DeleteGoal(id int64) error
+}
+
+// * goalColumns --> shared select list, current_value joins against workouts / weights by goal type
+// ? flagged workouts don't count toward goals, same as stats; weeks and deadlines use the user's timezone
+const goalColumns = `
+  g.id, g.user_id, g.type, g.target_value::float8, g.start_value::float8, TO_CHAR(g.deadline, 'YYYY-MM-DD'),
+  CASE g.type
+    WHEN 'weekly_workouts' THEN (
+      SELECT COUNT(*)::float8 FROM workouts w
+      WHERE w.user_id = g.user_id AND NOT w.flagged
+        AND (w.performed_at AT TIME ZONE tz.name) >= DATE_TRUNC('week', CURRENT_TIMESTAMP AT TIME ZONE tz.name)
+    )
+    WHEN 'total_minutes' THEN (
+      SELECT COALESCE(SUM(w.duration_minutes), 0)::float8 FROM workouts w
+      WHERE w.user_id = g.user_id AND NOT w.flagged AND w.performed_at >= g.created_at
+        AND (g.deadline IS NULL OR (w.performed_at AT TIME ZONE tz.name)::date <= g.deadline)
+    )
+    WHEN 'weight_target' THEN (
+      SELECT uw.weight_kg::float8 FROM user_weights uw
+      WHERE uw.user_id = g.user_id
+      ORDER BY uw.measured_at DESC
+      LIMIT 1
+    )
+  END,
+  g.created_at, g.updated_at
+`
+
+// * goalFrom --> goals plus the owner's timezone as tz.name, UTC without a profile
+const goalFrom = `
+FROM goals g
+CROSS JOIN LATERAL (SELECT COALESCE((SELECT timezone FROM user_profiles WHERE user_id = g.user_id), 'UTC') AS name) tz
+`
+
+func scanGoal(row interface{ Scan(...any) error }) (*Goal, error) {
+	goal := &Goal{}
+	err := row.Scan(&goal.ID, &goal.UserID, &goal.Type, &goal.TargetValue, &goal.StartValue, &goal.Deadline,
+		&goal.CurrentValue, &goal.CreatedAt, &goal.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+func (s *PostgresGoalStore) CreateGoal(goal *Goal) error {
+	query := `
+  INSERT INTO goals (user_id, type, target_value, start_value, deadline)
+  VALUES ($1, $2, $3, $4, $5::date)
+  RETURNING id, created_at, updated_at
+  `
+	return s.db.QueryRow(query, goal.UserID, goal.Type, goal.TargetValue, goal.StartValue, goal.Deadline).Scan(&goal.ID, &goal.CreatedAt, &goal.UpdatedAt)
+}
+
+func (s *PostgresGoalStore) GetGoal(id int64) (*Goal, error) {
+	query := `SELECT` + goalColumns + goalFrom + `WHERE g.id = $1`
+	goal, err := scanGoal(s.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+func (s *PostgresGoalStore) ListGoals(userID int64) ([]*Goal, error) {
+	query := `SELECT` + goalColumns + goalFrom + `WHERE g.user_id = $1 ORDER BY g.created_at, g.id`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []*Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
+
+//! UpdateGoal --> type and start_value are fixed at creation, only target + deadline change
+func (s *PostgresGoalStore) UpdateGoal(goal *Goal) error {
+	query := `
+  UPDATE goals
+  SET target_value = $1, deadline = $2::date, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $3
+  RETURNING updated_at
+  `
+	return s.db.QueryRow(query, goal.TargetValue, goal.Deadline, goal.ID).Scan(&goal.UpdatedAt)
+}
+
+func (s *PostgresGoalStore) DeleteGoal(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM goals WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
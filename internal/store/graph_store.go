@@ -0,0 +1,147 @@
+package store
+
+import (
+	"database/sql"
+)
+
+// ? - totals over the workouts a viewer can see, flagged ones excluded
+type WorkoutStats struct {
+	Workouts int `json:"workouts"`
+	Minutes  int `json:"minutes"`
+	Calories int `json:"calories"`
+}
+
+// * holds the db connection for the batched GraphQL reads
+type PostgresGraphStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new graph store instance
+func NewPostgresGraphStore(db *sql.DB) *PostgresGraphStore {
+	return &PostgresGraphStore{db: db}
+}
+
+//! GraphStore interface --> one query per batch of ids, the GraphQL dataloaders sit on top of it
+type GraphStore interface {
+	GetUsersByIDs(ids []int64) (map[int64]*User, error)
+	GetEntriesByWorkoutIDs(ids []int64) (map[int64][]WorkoutEntry, error)
+	GetWorkoutStatsByUserIDs(viewerID int64, ids []int64) (map[int64]*WorkoutStats, error)
+	ListVisibleWorkouts(viewerID, ownerID int64, limit, offset int) ([]*Workout, error)
+}
+
+// ? - the visibility rule from the workout service as SQL, $1 is the viewer
+const visibleToViewer = `
+  (w.user_id = $1 OR w.visibility = 'public' OR (w.visibility = 'followers' AND EXISTS (
+    SELECT 1 FROM follows f WHERE f.follower_id = $1 AND f.followee_id = w.user_id
+  )))
+`
+
+//! GetUsersByIDs --> missing and deleted users are simply absent from the map
+func (s *PostgresGraphStore) GetUsersByIDs(ids []int64) (map[int64]*User, error) {
+	query := `
+  SELECT id, username, email, bio, is_admin, created_at, updated_at
+  FROM users
+  WHERE id = ANY($1) AND deleted_at IS NULL
+  `
+	rows, err := s.db.Query(query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := map[int64]*User{}
+	for rows.Next() {
+		user := &User{}
+		err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.Bio, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		users[int64(user.ID)] = user
+	}
+	return users, rows.Err()
+}
+
+//! GetEntriesByWorkoutIDs --> entries grouped by workout, each group in order_index order
+func (s *PostgresGraphStore) GetEntriesByWorkoutIDs(ids []int64) (map[int64][]WorkoutEntry, error) {
+	query := `
+  SELECT workout_id, id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index
+  FROM workout_entries
+  WHERE workout_id = ANY($1)
+  ORDER BY workout_id, order_index
+  `
+	rows, err := s.db.Query(query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := map[int64][]WorkoutEntry{}
+	for rows.Next() {
+		var workoutID int64
+		var entry WorkoutEntry
+		err = rows.Scan(&workoutID, &entry.ID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds,
+			&entry.Weight, &entry.Notes, &entry.OrderIndex)
+		if err != nil {
+			return nil, err
+		}
+		entries[workoutID] = append(entries[workoutID], entry)
+	}
+	return entries, rows.Err()
+}
+
+//! GetWorkoutStatsByUserIDs --> users without visible workouts are absent from the map
+func (s *PostgresGraphStore) GetWorkoutStatsByUserIDs(viewerID int64, ids []int64) (map[int64]*WorkoutStats, error) {
+	query := `
+  SELECT w.user_id, COUNT(*), COALESCE(SUM(w.duration_minutes), 0), COALESCE(SUM(w.calories_burned), 0)
+  FROM workouts w
+  WHERE w.user_id = ANY($2) AND NOT w.flagged AND ` + visibleToViewer + `
+  GROUP BY w.user_id
+  `
+	rows, err := s.db.Query(query, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := map[int64]*WorkoutStats{}
+	for rows.Next() {
+		var userID int64
+		stat := &WorkoutStats{}
+		err = rows.Scan(&userID, &stat.Workouts, &stat.Minutes, &stat.Calories)
+		if err != nil {
+			return nil, err
+		}
+		stats[userID] = stat
+	}
+	return stats, rows.Err()
+}
+
+//! ListVisibleWorkouts --> ownerID's workouts the viewer may see, newest first, entries left empty
+func (s *PostgresGraphStore) ListVisibleWorkouts(viewerID, ownerID int64, limit, offset int) ([]*Workout, error) {
+	query := `
+  SELECT w.id, w.user_id, w.title, w.description, w.duration_minutes, w.calories_burned, w.calories_estimated,
+    w.visibility, w.flagged, w.verified, w.created_at, w.performed_at
+  FROM workouts w
+  WHERE w.user_id = $2 AND ` + visibleToViewer + `
+  ORDER BY w.created_at DESC, w.id DESC
+  LIMIT $3 OFFSET $4
+  `
+	rows, err := s.db.Query(query, viewerID, ownerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := []*Workout{}
+	for rows.Next() {
+		workout := &Workout{}
+		err = rows.Scan(&workout.ID, &workout.UserID, &workout.Title, &workout.Description, &workout.DurationMinutes,
+			&workout.CaloriesBurned, &workout.CaloriesEstimated, &workout.Visibility, &workout.Flagged, &workout.Verified,
+			&workout.CreatedAt, &workout.PerformedAt)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, workout)
+	}
+	return workouts, rows.Err()
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/stretchr/testify/require"
+)
+
+// ! legacyTestDSN --> the docker-compose test_db service, used when neither TEST_DATABASE_URL nor docker is available
+const legacyTestDSN = "host=localhost user=postgres password=postgres dbname=postgres port=5500 sslmode=disable"
+
+// * testDSN --> where the postgres integration tests run, resolved once in TestMain
+var testDSN = legacyTestDSN
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// ! TestMain --> TEST_DATABASE_URL wins, otherwise a throwaway postgres container via dockertest
+// ? no docker daemon --> falls back to the compose test_db on 5500, tests that need it fail like before
+func TestMain(m *testing.M) {
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		testDSN = dsn
+		os.Exit(m.Run())
+	}
+
+	pool, resource, err := startPostgres()
+	if err != nil {
+		log.Printf("store tests: no throwaway postgres (%v), using %s", err, legacyTestDSN)
+		os.Exit(m.Run())
+	}
+	code := m.Run()
+	if err := pool.Purge(resource); err != nil {
+		log.Printf("store tests: removing postgres container: %v", err)
+	}
+	os.Exit(code)
+}
+
+// * startPostgres --> same image as the compose test_db, removed on exit and expired after 10 minutes if the run is killed
+func startPostgres() (*dockertest.Pool, *dockertest.Resource, error) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, nil, err
+	}
+	err = pool.Client.Ping()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "12.4-alpine",
+		Env:        []string{"POSTGRES_USER=postgres", "POSTGRES_PASSWORD=postgres", "POSTGRES_DB=postgres"},
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	resource.Expire(600)
+
+	dsn := fmt.Sprintf("host=localhost user=postgres password=postgres dbname=postgres port=%s sslmode=disable", resource.GetPort("5432/tcp"))
+	pool.MaxWait = time.Minute
+	err = pool.Retry(func() error {
+		db, err := sql.Open("pgx", dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.Ping()
+	})
+	if err != nil {
+		pool.Purge(resource)
+		return nil, nil, err
+	}
+	testDSN = dsn
+	return pool, resource, nil
+}
+
+// ! openTestDB --> migrated, empty database, closed when the test ends
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("pgx", testDSN)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	//* once per package run, every test after the first only truncates
+	migrateOnce.Do(func() { migrateErr = Migrate(db, "../../migrations/") })
+	if migrateErr != nil {
+		t.Fatalf("caught error while migrating db : %v", migrateErr)
+	}
+	truncateTables(t, db)
+	return db
+}
+
+// ! truncateTables --> every table but goose's bookkeeping, ids restart at 1
+func truncateTables(t *testing.T, db *sql.DB) {
+	rows, err := db.Query(`SELECT tablename FROM pg_tables WHERE schemaname = 'public' AND tablename <> 'goose_db_version'`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	tables := ""
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		if tables != "" {
+			tables += ", "
+		}
+		tables += `"` + name + `"`
+	}
+	require.NoError(t, rows.Err())
+	if tables == "" {
+		return
+	}
+	_, err = db.Exec(`TRUNCATE ` + tables + ` RESTART IDENTITY CASCADE`)
+	require.NoError(t, err)
+}
+
+// ! createTestUser --> fixture, the password is always "password"
+func createTestUser(t *testing.T, db *sql.DB, username string) *User {
+	user := &User{Username: username, Email: username + "@example.com"}
+	require.NoError(t, user.PasswordHash.Set("password"))
+	require.NoError(t, NewPostUserStore(db).CreateUser(user))
+	return user
+}
+
+// ! createTestWorkout --> fixture, one entry so the entry tables get exercised too
+func createTestWorkout(t *testing.T, db *sql.DB, userID int, title string) *Workout {
+	workout, err := NewPostgresWorkoutStore(db).CreateWorkout(&Workout{
+		UserID:          userID,
+		Title:           title,
+		DurationMinutes: 30,
+		Entries:         []WorkoutEntry{{ExerciseName: "squat", Sets: 3, Reps: intPointer(10), OrderIndex: 1}},
+	})
+	require.NoError(t, err)
+	return workout
+}
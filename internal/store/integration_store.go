@@ -0,0 +1,173 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! ProviderStrava --> the only third-party provider so far
+const ProviderStrava = "strava"
+
+// ? - a user's connected third-party account, tokens never leave the server
+type IntegrationConnection struct {
+	ID             int64      `json:"-"`
+	UserID         int        `json:"-"`
+	Provider       string     `json:"provider"`
+	ExternalUserID string     `json:"external_user_id"`
+	AccessToken    string     `json:"-"`
+	RefreshToken   string     `json:"-"`
+	TokenExpiresAt time.Time  `json:"-"`
+	Scope          string     `json:"scope"`
+	SyncedUntil    *time.Time `json:"synced_until"`
+	LastSyncedAt   *time.Time `json:"last_synced_at"`
+	LastSyncError  string     `json:"last_sync_error,omitempty"`
+	CreatedAt      time.Time  `json:"connected_at"`
+}
+
+// * holds the db connection for third-party integrations
+type PostgresIntegrationStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new integration store instance
+func NewPostgresIntegrationStore(db *sql.DB) *PostgresIntegrationStore {
+	return &PostgresIntegrationStore{db: db}
+}
+
+//! IntegrationStore interface --> connected accounts + their sync state
+type IntegrationStore interface {
+	UpsertConnection(*IntegrationConnection) error
+	GetConnection(userID int, provider string) (*IntegrationConnection, error)
+	GetConnectionByID(id int64) (*IntegrationConnection, error)
+	ListConnections(userID int) ([]*IntegrationConnection, error)
+	ListConnectionIDs(provider string) ([]int64, error)
+	DeleteConnection(userID int, provider string) error
+	UpdateConnectionTokens(id int64, accessToken, refreshToken string, expiresAt time.Time) error
+	RecordSync(id int64, syncedUntil *time.Time, syncErr string) error
+}
+
+const integrationColumns = `id, user_id, provider, external_user_id, access_token, refresh_token, token_expires_at, scope,
+         synced_until, last_synced_at, COALESCE(last_sync_error, ''), created_at`
+
+func scanIntegration(row interface{ Scan(...any) error }) (*IntegrationConnection, error) {
+	c := &IntegrationConnection{}
+	err := row.Scan(&c.ID, &c.UserID, &c.Provider, &c.ExternalUserID, &c.AccessToken, &c.RefreshToken, &c.TokenExpiresAt, &c.Scope,
+		&c.SyncedUntil, &c.LastSyncedAt, &c.LastSyncError, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//! UpsertConnection --> reconnecting keeps the sync cursor so nothing is imported twice
+func (s *PostgresIntegrationStore) UpsertConnection(c *IntegrationConnection) error {
+	query := `
+  INSERT INTO integration_connections (user_id, provider, external_user_id, access_token, refresh_token, token_expires_at, scope)
+  VALUES ($1, $2, $3, $4, $5, $6, $7)
+  ON CONFLICT (user_id, provider) DO UPDATE
+  SET external_user_id = EXCLUDED.external_user_id, access_token = EXCLUDED.access_token,
+      refresh_token = EXCLUDED.refresh_token, token_expires_at = EXCLUDED.token_expires_at,
+      scope = EXCLUDED.scope, last_sync_error = NULL, updated_at = CURRENT_TIMESTAMP
+  RETURNING ` + integrationColumns
+	saved, err := scanIntegration(s.db.QueryRow(query, c.UserID, c.Provider, c.ExternalUserID, c.AccessToken, c.RefreshToken, c.TokenExpiresAt, c.Scope))
+	if err != nil {
+		return err
+	}
+	*c = *saved
+	return nil
+}
+
+func (s *PostgresIntegrationStore) GetConnection(userID int, provider string) (*IntegrationConnection, error) {
+	query := `SELECT ` + integrationColumns + ` FROM integration_connections WHERE user_id = $1 AND provider = $2`
+	c, err := scanIntegration(s.db.QueryRow(query, userID, provider))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (s *PostgresIntegrationStore) GetConnectionByID(id int64) (*IntegrationConnection, error) {
+	query := `SELECT ` + integrationColumns + ` FROM integration_connections WHERE id = $1`
+	c, err := scanIntegration(s.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (s *PostgresIntegrationStore) ListConnections(userID int) ([]*IntegrationConnection, error) {
+	query := `SELECT ` + integrationColumns + ` FROM integration_connections WHERE user_id = $1 ORDER BY provider`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*IntegrationConnection{}
+	for rows.Next() {
+		c, err := scanIntegration(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+//! ListConnectionIDs --> every connection of a provider, for the periodic sync fan-out
+func (s *PostgresIntegrationStore) ListConnectionIDs(provider string) ([]int64, error) {
+	rows, err := s.db.Query(`SELECT id FROM integration_connections WHERE provider = $1 ORDER BY id`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *PostgresIntegrationStore) DeleteConnection(userID int, provider string) error {
+	result, err := s.db.Exec(`DELETE FROM integration_connections WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresIntegrationStore) UpdateConnectionTokens(id int64, accessToken, refreshToken string, expiresAt time.Time) error {
+	query := `
+  UPDATE integration_connections
+  SET access_token = $2, refresh_token = $3, token_expires_at = $4, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1
+  `
+	_, err := s.db.Exec(query, id, accessToken, refreshToken, expiresAt)
+	return err
+}
+
+//! RecordSync --> stamps a sync run, the cursor only ever moves forward
+func (s *PostgresIntegrationStore) RecordSync(id int64, syncedUntil *time.Time, syncErr string) error {
+	query := `
+  UPDATE integration_connections
+  SET synced_until = GREATEST(synced_until, $2), last_synced_at = CURRENT_TIMESTAMP,
+      last_sync_error = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1
+  `
+	_, err := s.db.Exec(query, id, syncedUntil, syncErr)
+	return err
+}
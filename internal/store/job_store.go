@@ -0,0 +1,144 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+//! job states
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// ? - one unit of background work, Payload is decoded by the handler registered for Type
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   *string         `json:"last_error"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// * holds the db connection for the job queue
+type PostgresJobStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new job store instance
+func NewPostgresJobStore(db *sql.DB) *PostgresJobStore {
+	return &PostgresJobStore{db: db}
+}
+
+//! JobStore interface --> contract for the DB-backed job queue
+type JobStore interface {
+	EnqueueJob(*Job) error
+	ClaimJob() (*Job, error)
+	CompleteJob(id int64) error
+	RetryJob(id int64, runAt time.Time, lastError string) error
+	FailJob(id int64, lastError string) error
+	RequeueStaleJobs(lockedBefore time.Time) (int64, error)
+}
+
+func (s *PostgresJobStore) EnqueueJob(job *Job) error {
+	if len(job.Payload) == 0 {
+		job.Payload = json.RawMessage(`{}`)
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = 5
+	}
+	query := `
+  INSERT INTO jobs (type, payload, max_attempts, run_at)
+  VALUES ($1, $2, $3, COALESCE($4, CURRENT_TIMESTAMP))
+  RETURNING id, status, run_at, created_at
+  `
+	var runAt *time.Time
+	if !job.RunAt.IsZero() {
+		runAt = &job.RunAt
+	}
+	return s.db.QueryRow(query, job.Type, []byte(job.Payload), job.MaxAttempts, runAt).
+		Scan(&job.ID, &job.Status, &job.RunAt, &job.CreatedAt)
+}
+
+//! ClaimJob --> locks the oldest due job for this worker, (nil, nil) when the queue is empty
+//? SKIP LOCKED lets any number of workers (and app instances) poll the same table without blocking each other
+func (s *PostgresJobStore) ClaimJob() (*Job, error) {
+	query := `
+  UPDATE jobs
+  SET status = 'running', attempts = attempts + 1, locked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+  WHERE id = (
+    SELECT id FROM jobs
+    WHERE status = 'queued' AND run_at <= CURRENT_TIMESTAMP
+    ORDER BY run_at, id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+  )
+  RETURNING id, type, payload, status, attempts, max_attempts, run_at, last_error, created_at
+  `
+	job := &Job{}
+	var payload []byte
+	err := s.db.QueryRow(query).Scan(&job.ID, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.RunAt, &job.LastError, &job.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	return job, nil
+}
+
+func (s *PostgresJobStore) CompleteJob(id int64) error {
+	query := `
+  UPDATE jobs
+  SET status = 'done', locked_at = NULL, last_error = NULL, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1
+  `
+	_, err := s.db.Exec(query, id)
+	return err
+}
+
+//! RetryJob --> back in the queue, not picked up again before runAt
+func (s *PostgresJobStore) RetryJob(id int64, runAt time.Time, lastError string) error {
+	query := `
+  UPDATE jobs
+  SET status = 'queued', run_at = $2, locked_at = NULL, last_error = $3, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1
+  `
+	_, err := s.db.Exec(query, id, runAt, lastError)
+	return err
+}
+
+//! FailJob --> out of attempts, the row stays around for inspection
+func (s *PostgresJobStore) FailJob(id int64, lastError string) error {
+	query := `
+  UPDATE jobs
+  SET status = 'failed', locked_at = NULL, last_error = $2, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1
+  `
+	_, err := s.db.Exec(query, id, lastError)
+	return err
+}
+
+//! RequeueStaleJobs --> jobs whose worker died mid-run (crash, deploy) go back in the queue
+func (s *PostgresJobStore) RequeueStaleJobs(lockedBefore time.Time) (int64, error) {
+	query := `
+  UPDATE jobs
+  SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'queued' END,
+      locked_at = NULL, last_error = 'worker lease expired', updated_at = CURRENT_TIMESTAMP
+  WHERE status = 'running' AND locked_at < $1
+  `
+	result, err := s.db.Exec(query, lockedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
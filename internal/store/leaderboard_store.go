@@ -0,0 +1,95 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! leaderboard metrics --> the {metric} in GET /leaderboards/{metric}
+const (
+	LeaderboardWeeklyMinutes  = "weekly_minutes"
+	LeaderboardWeeklyWorkouts = "weekly_workouts"
+	LeaderboardStreak         = "streak"
+)
+
+// * leaderboardColumns --> metric -> leaderboard_stats column, doubles as the whitelist for the ORDER BY
+var leaderboardColumns = map[string]string{
+	LeaderboardWeeklyMinutes:  "weekly_minutes",
+	LeaderboardWeeklyWorkouts: "weekly_workouts",
+	LeaderboardStreak:         "streak_days",
+}
+
+//! ValidLeaderboardMetric --> true for the metrics TopLeaderboard ranks by
+func ValidLeaderboardMetric(metric string) bool {
+	_, ok := leaderboardColumns[metric]
+	return ok
+}
+
+// ? - one row of a metric leaderboard, Value is minutes, workouts or streak days
+type LeaderboardEntry struct {
+	UserID      int       `json:"user_id"`
+	Username    string    `json:"username"`
+	Value       int       `json:"value"`
+	RefreshedAt time.Time `json:"refreshed_at"` // * when the cached numbers were computed
+}
+
+// ? - who a leaderboard ranks, FriendsOf = 0 means everyone
+type LeaderboardScope struct {
+	FriendsOf int // * the viewer plus the users they follow
+}
+
+// * holds the db connection for leaderboard operations
+type PostgresLeaderboardStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new leaderboard store instance
+func NewPostgresLeaderboardStore(db *sql.DB) *PostgresLeaderboardStore {
+	return &PostgresLeaderboardStore{db: db}
+}
+
+//! LeaderboardStore interface --> contract for the cached weekly + streak aggregates
+type LeaderboardStore interface {
+	RefreshLeaderboards() error
+	TopLeaderboard(metric string, scope LeaderboardScope, limit int) ([]*LeaderboardEntry, error)
+}
+
+//! RefreshLeaderboards --> recomputes leaderboard_stats, CONCURRENTLY so reads aren't blocked meanwhile
+func (s *PostgresLeaderboardStore) RefreshLeaderboards() error {
+	_, err := s.db.Exec(`REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_stats`)
+	return err
+}
+
+//! TopLeaderboard --> highest values first, ties broken by lower user id; zeros, opt-outs and deleted users are left off
+func (s *PostgresLeaderboardStore) TopLeaderboard(metric string, scope LeaderboardScope, limit int) ([]*LeaderboardEntry, error) {
+	column, ok := leaderboardColumns[metric]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	query := `
+  SELECT s.user_id, u.username, s.` + column + `, s.refreshed_at
+  FROM leaderboard_stats s
+  INNER JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL
+  WHERE s.` + column + ` > 0
+    AND NOT EXISTS (SELECT 1 FROM user_profiles p WHERE p.user_id = s.user_id AND p.leaderboard_opt_out)
+    AND ($1 = 0 OR s.user_id = $1 OR s.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1))
+  ORDER BY s.` + column + ` DESC, s.user_id
+  LIMIT $2
+  `
+	rows, err := s.db.Query(query, scope.FriendsOf, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*LeaderboardEntry{}
+	for rows.Next() {
+		entry := &LeaderboardEntry{}
+		err = rows.Scan(&entry.UserID, &entry.Username, &entry.Value, &entry.RefreshedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
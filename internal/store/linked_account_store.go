@@ -0,0 +1,127 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - a provider identity ("sign in with Google") linked to a local user
+type LinkedAccount struct {
+	ID          int64      `json:"id"`
+	UserID      int        `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	Email       string     `json:"email"`
+	SignedUp    bool       `json:"signed_up"` // * the account was created through this provider
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// * holds the db connection for linked accounts
+type PostgresLinkedAccountStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new linked account store instance
+func NewPostgresLinkedAccountStore(db *sql.DB) *PostgresLinkedAccountStore {
+	return &PostgresLinkedAccountStore{db: db}
+}
+
+// ! LinkedAccountStore interface
+type LinkedAccountStore interface {
+	GetLinkedAccount(provider, subject string) (*LinkedAccount, error)
+	ListLinkedAccounts(userID int) ([]*LinkedAccount, error)
+	CreateLinkedAccount(*LinkedAccount) error
+	TouchLinkedAccount(id int64) error
+	//* ErrNotFound when the provider isn't linked, SignedUp moves to a remaining account
+	DeleteLinkedAccount(userID int, provider string) error
+	//* 0 when no live user has that email (case-insensitive)
+	UserIDByEmail(email string) (int, error)
+}
+
+const linkedAccountColumns = `id, user_id, provider, subject, email, signed_up, created_at, last_login_at`
+
+func scanLinkedAccount(row interface{ Scan(...any) error }) (*LinkedAccount, error) {
+	a := &LinkedAccount{}
+	err := row.Scan(&a.ID, &a.UserID, &a.Provider, &a.Subject, &a.Email, &a.SignedUp, &a.CreatedAt, &a.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *PostgresLinkedAccountStore) GetLinkedAccount(provider, subject string) (*LinkedAccount, error) {
+	query := `SELECT ` + linkedAccountColumns + ` FROM linked_accounts WHERE provider = $1 AND subject = $2`
+	a, err := scanLinkedAccount(s.db.QueryRow(query, provider, subject))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+func (s *PostgresLinkedAccountStore) ListLinkedAccounts(userID int) ([]*LinkedAccount, error) {
+	rows, err := s.db.Query(`SELECT `+linkedAccountColumns+` FROM linked_accounts WHERE user_id = $1 ORDER BY provider`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*LinkedAccount{}
+	for rows.Next() {
+		a, err := scanLinkedAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresLinkedAccountStore) CreateLinkedAccount(a *LinkedAccount) error {
+	query := `
+  INSERT INTO linked_accounts (user_id, provider, subject, email, signed_up, last_login_at)
+  VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+  RETURNING id, created_at, last_login_at
+  `
+	err := s.db.QueryRow(query, a.UserID, a.Provider, a.Subject, a.Email, a.SignedUp).Scan(&a.ID, &a.CreatedAt, &a.LastLoginAt)
+	return mapError(err) //* ErrConflict --> identity or provider already linked
+}
+
+func (s *PostgresLinkedAccountStore) TouchLinkedAccount(id int64) error {
+	_, err := s.db.Exec(`UPDATE linked_accounts SET last_login_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	return err
+}
+
+func (s *PostgresLinkedAccountStore) DeleteLinkedAccount(userID int, provider string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var signedUp bool
+	err = tx.QueryRow(`DELETE FROM linked_accounts WHERE user_id = $1 AND provider = $2 RETURNING signed_up`, userID, provider).Scan(&signedUp)
+	if err != nil {
+		return mapError(err)
+	}
+	if signedUp {
+		query := `
+  UPDATE linked_accounts SET signed_up = TRUE
+  WHERE id = (SELECT id FROM linked_accounts WHERE user_id = $1 ORDER BY id LIMIT 1)
+  `
+		_, err = tx.Exec(query, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresLinkedAccountStore) UserIDByEmail(email string) (int, error) {
+	var id int
+	err := s.db.QueryRow(`SELECT id FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL`, email).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
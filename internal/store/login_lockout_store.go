@@ -0,0 +1,126 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ! lockout kinds --> failures are counted per username and per client IP
+const (
+	LockoutUsername = "username"
+	LockoutIP       = "ip"
+)
+
+// ? - failed login bookkeeping for one username or IP
+type LoginLockout struct {
+	Kind         string     `json:"kind"`
+	Key          string     `json:"key"`
+	Failures     int        `json:"failures"` // * since the last lockout
+	Lockouts     int        `json:"lockouts"` // * consecutive lockouts, drives the backoff
+	LockedUntil  *time.Time `json:"locked_until"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+}
+
+// ! Locked --> still inside its lockout at now
+func (l *LoginLockout) Locked(now time.Time) bool {
+	return l != nil && l.LockedUntil != nil && now.Before(*l.LockedUntil)
+}
+
+// * holds the db connection for login lockouts
+type PostgresLoginLockoutStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new login lockout store instance
+func NewPostgresLoginLockoutStore(db *sql.DB) *PostgresLoginLockoutStore {
+	return &PostgresLoginLockoutStore{db: db}
+}
+
+// ! LoginLockoutStore interface --> the service decides when to lock, the store only counts atomically
+type LoginLockoutStore interface {
+	GetLoginLockout(kind, key string) (*LoginLockout, error)
+	//* counts one failure, a row quiet for longer than window (last failure and lockout both) starts over
+	RecordLoginFailure(kind, key string, at time.Time, window time.Duration) (*LoginLockout, error)
+	//* locks until, failures back to 0 and lockouts + 1
+	LockLogin(kind, key string, until time.Time) error
+	ClearLoginLockout(kind, key string) error
+	ListLoginLockouts(now time.Time) ([]*LoginLockout, error)
+}
+
+const loginLockoutColumns = `kind, key, failures, lockouts, locked_until, last_failed_at`
+
+func scanLoginLockout(row interface{ Scan(...any) error }) (*LoginLockout, error) {
+	l := &LoginLockout{}
+	err := row.Scan(&l.Kind, &l.Key, &l.Failures, &l.Lockouts, &l.LockedUntil, &l.LastFailedAt)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (s *PostgresLoginLockoutStore) GetLoginLockout(kind, key string) (*LoginLockout, error) {
+	query := `SELECT ` + loginLockoutColumns + ` FROM login_lockouts WHERE kind = $1 AND key = $2`
+	l, err := scanLoginLockout(s.db.QueryRow(query, kind, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return l, err
+}
+
+func (s *PostgresLoginLockoutStore) RecordLoginFailure(kind, key string, at time.Time, window time.Duration) (*LoginLockout, error) {
+	query := `
+  INSERT INTO login_lockouts (kind, key, failures, last_failed_at)
+  VALUES ($1, $2, 1, $3)
+  ON CONFLICT (kind, key) DO UPDATE SET
+    failures = CASE WHEN GREATEST(login_lockouts.last_failed_at, login_lockouts.locked_until) < $4 THEN 1 ELSE login_lockouts.failures + 1 END,
+    lockouts = CASE WHEN GREATEST(login_lockouts.last_failed_at, login_lockouts.locked_until) < $4 THEN 0 ELSE login_lockouts.lockouts END,
+    last_failed_at = EXCLUDED.last_failed_at
+  RETURNING ` + loginLockoutColumns
+	return scanLoginLockout(s.db.QueryRow(query, kind, key, at, at.Add(-window)))
+}
+
+func (s *PostgresLoginLockoutStore) LockLogin(kind, key string, until time.Time) error {
+	query := `
+  UPDATE login_lockouts
+  SET locked_until = $3, failures = 0, lockouts = lockouts + 1
+  WHERE kind = $1 AND key = $2
+  `
+	_, err := s.db.Exec(query, kind, key, until)
+	return err
+}
+
+// ! ClearLoginLockout --> ErrNotFound when there was nothing to clear
+func (s *PostgresLoginLockoutStore) ClearLoginLockout(kind, key string) error {
+	result, err := s.db.Exec(`DELETE FROM login_lockouts WHERE kind = $1 AND key = $2`, kind, key)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ! ListLoginLockouts --> currently locked usernames + IPs, soonest unlock first
+func (s *PostgresLoginLockoutStore) ListLoginLockouts(now time.Time) ([]*LoginLockout, error) {
+	query := `SELECT ` + loginLockoutColumns + ` FROM login_lockouts WHERE locked_until > $1 ORDER BY locked_until, kind, key`
+	rows, err := s.db.Query(query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*LoginLockout{}
+	for rows.Next() {
+		l, err := scanLoginLockout(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,275 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// ! push platforms, also the devices.platform values
+const (
+	DevicePlatformFCM  = "fcm"  //* Android (and iOS apps that go through Firebase)
+	DevicePlatformAPNs = "apns" //* iOS, straight to Apple
+)
+
+// ! notification categories, one switch each in NotificationPreferences
+const (
+	NotifyComments     = "comments"     //* someone commented on your workout
+	NotifyFollowers    = "followers"    //* someone started following you
+	NotifyChallenges   = "challenges"   //* a challenge you took part in has results
+	NotifyReminders    = "reminders"    //* your scheduled workout reminders
+	NotifyAchievements = "achievements" //* you earned a badge
+)
+
+// ? - one install of the mobile app that can receive pushes
+type Device struct {
+	ID         int64     `json:"id"`
+	UserID     int       `json:"-"`
+	Platform   string    `json:"platform"`
+	Token      string    `json:"-"` // * never echoed back, the app already has it
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// ? - which categories a user wants to hear about and on which channels, a category goes out on every channel that is on
+type NotificationPreferences struct {
+	Comments     bool `json:"comments"`
+	Followers    bool `json:"followers"`
+	Challenges   bool `json:"challenges"`
+	Reminders    bool `json:"reminders"`
+	Achievements bool `json:"achievements"`
+	Email        bool `json:"email"` // * channels from here on
+	Push         bool `json:"push"`
+	InApp        bool `json:"in_app"`
+	WeeklyDigest bool `json:"weekly_digest"` // * the Monday summary email, separate from the email channel
+}
+
+// ! DefaultNotificationPreferences --> what a user without a preferences row gets, everything but the email channel
+func DefaultNotificationPreferences() *NotificationPreferences {
+	return &NotificationPreferences{Comments: true, Followers: true, Challenges: true, Reminders: true, Achievements: true, Push: true, InApp: true, WeeklyDigest: true}
+}
+
+// ! Enabled --> whether category is switched on, unknown categories never are
+func (p *NotificationPreferences) Enabled(category string) bool {
+	switch category {
+	case NotifyComments:
+		return p.Comments
+	case NotifyFollowers:
+		return p.Followers
+	case NotifyChallenges:
+		return p.Challenges
+	case NotifyReminders:
+		return p.Reminders
+	case NotifyAchievements:
+		return p.Achievements
+	}
+	return false
+}
+
+// ? - one entry of the in-app inbox
+type Notification struct {
+	ID        int64             `json:"id"`
+	UserID    int               `json:"-"`
+	Category  string            `json:"category"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data"` // * ids for deep links, e.g. workout_id
+	ReadAt    *time.Time        `json:"read_at"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// * holds the db connection for devices, notification preferences + the inbox
+type PostgresNotificationStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new notification store instance
+func NewPostgresNotificationStore(db *sql.DB) *PostgresNotificationStore {
+	return &PostgresNotificationStore{db: db}
+}
+
+//! NotificationStore interface --> push devices, the per-category + per-channel switches and the in-app inbox
+type NotificationStore interface {
+	RegisterDevice(*Device) error
+	ListDevices(userID int) ([]*Device, error)
+	DeleteDevice(id int64, userID int) error
+	DeleteDeviceToken(token string) error
+	GetNotificationPreferences(userID int) (*NotificationPreferences, error)
+	UpdateNotificationPreferences(userID int, prefs *NotificationPreferences) error
+	CreateNotification(*Notification) error
+	ListNotifications(userID int, unreadOnly bool, offset, limit int) ([]*Notification, int, error)
+	CountUnreadNotifications(userID int) (int, error)
+	MarkNotificationRead(id int64, userID int) error
+	MarkAllNotificationsRead(userID int) (int64, error)
+}
+
+//! RegisterDevice --> upsert on the token, a known token is moved to d.UserID and its last_seen_at bumped
+//? a phone handed to someone else (or a logout + login) keeps its token, the old owner must stop getting its pushes
+func (s *PostgresNotificationStore) RegisterDevice(d *Device) error {
+	query := `
+  INSERT INTO devices (user_id, platform, token, name)
+  VALUES ($1, $2, $3, $4)
+  ON CONFLICT (token) DO UPDATE
+  SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform, name = EXCLUDED.name, last_seen_at = CURRENT_TIMESTAMP
+  RETURNING id, created_at, last_seen_at
+  `
+	err := s.db.QueryRow(query, d.UserID, d.Platform, d.Token, d.Name).Scan(&d.ID, &d.CreatedAt, &d.LastSeenAt)
+	return mapError(err)
+}
+
+func (s *PostgresNotificationStore) ListDevices(userID int) ([]*Device, error) {
+	query := `
+  SELECT id, user_id, platform, token, name, created_at, last_seen_at
+  FROM devices
+  WHERE user_id = $1
+  ORDER BY id
+  `
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*Device{}
+	for rows.Next() {
+		d := &Device{}
+		err = rows.Scan(&d.ID, &d.UserID, &d.Platform, &d.Token, &d.Name, &d.CreatedAt, &d.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, rows.Err()
+}
+
+//! DeleteDevice --> ErrNotFound unless the device is userID's
+func (s *PostgresNotificationStore) DeleteDevice(id int64, userID int) error {
+	result, err := s.db.Exec(`DELETE FROM devices WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+//! DeleteDeviceToken --> drops a token the push service rejected, gone already is fine
+func (s *PostgresNotificationStore) DeleteDeviceToken(token string) error {
+	_, err := s.db.Exec(`DELETE FROM devices WHERE token = $1`, token)
+	return err
+}
+
+func (s *PostgresNotificationStore) GetNotificationPreferences(userID int) (*NotificationPreferences, error) {
+	query := `
+  SELECT comments, followers, challenges, reminders, achievements, email, push, in_app, weekly_digest
+  FROM notification_preferences
+  WHERE user_id = $1
+  `
+	prefs := &NotificationPreferences{}
+	err := s.db.QueryRow(query, userID).Scan(&prefs.Comments, &prefs.Followers, &prefs.Challenges, &prefs.Reminders,
+		&prefs.Achievements, &prefs.Email, &prefs.Push, &prefs.InApp, &prefs.WeeklyDigest)
+	if err == sql.ErrNoRows {
+		return DefaultNotificationPreferences(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (s *PostgresNotificationStore) UpdateNotificationPreferences(userID int, prefs *NotificationPreferences) error {
+	query := `
+  INSERT INTO notification_preferences (user_id, comments, followers, challenges, reminders, achievements, email, push, in_app, weekly_digest)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+  ON CONFLICT (user_id) DO UPDATE
+  SET comments = EXCLUDED.comments, followers = EXCLUDED.followers, challenges = EXCLUDED.challenges,
+      reminders = EXCLUDED.reminders, achievements = EXCLUDED.achievements, email = EXCLUDED.email,
+      push = EXCLUDED.push, in_app = EXCLUDED.in_app, weekly_digest = EXCLUDED.weekly_digest, updated_at = CURRENT_TIMESTAMP
+  `
+	_, err := s.db.Exec(query, userID, prefs.Comments, prefs.Followers, prefs.Challenges, prefs.Reminders,
+		prefs.Achievements, prefs.Email, prefs.Push, prefs.InApp, prefs.WeeklyDigest)
+	return mapError(err)
+}
+
+func (s *PostgresNotificationStore) CreateNotification(n *Notification) error {
+	if n.Data == nil {
+		n.Data = map[string]string{}
+	}
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return err
+	}
+	query := `
+  INSERT INTO notifications (user_id, category, title, body, data)
+  VALUES ($1, $2, $3, $4, $5)
+  RETURNING id, created_at
+  `
+	err = s.db.QueryRow(query, n.UserID, n.Category, n.Title, n.Body, data).Scan(&n.ID, &n.CreatedAt)
+	return mapError(err)
+}
+
+//! ListNotifications --> newest first, total counts every row the filter matches
+func (s *PostgresNotificationStore) ListNotifications(userID int, unreadOnly bool, offset, limit int) ([]*Notification, int, error) {
+	var total int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)`, userID, unreadOnly).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+  SELECT id, user_id, category, title, body, data, read_at, created_at
+  FROM notifications
+  WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+  ORDER BY id DESC
+  OFFSET $3 LIMIT $4
+  `
+	rows, err := s.db.Query(query, userID, unreadOnly, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+	for rows.Next() {
+		n := &Notification{}
+		var data []byte
+		err = rows.Scan(&n.ID, &n.UserID, &n.Category, &n.Title, &n.Body, &data, &n.ReadAt, &n.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		err = json.Unmarshal(data, &n.Data)
+		if err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, total, rows.Err()
+}
+
+func (s *PostgresNotificationStore) CountUnreadNotifications(userID int) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+//! MarkNotificationRead --> ErrNotFound unless the notification is userID's, reading it twice keeps the first read_at
+func (s *PostgresNotificationStore) MarkNotificationRead(id int64, userID int) error {
+	query := `
+  UPDATE notifications
+  SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+  WHERE id = $1 AND user_id = $2
+  `
+	result, err := s.db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+//! MarkAllNotificationsRead --> returns how many were unread
+func (s *PostgresNotificationStore) MarkAllNotificationsRead(userID int) (int64, error) {
+	result, err := s.db.Exec(`UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
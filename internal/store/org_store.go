@@ -0,0 +1,566 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"time"
+)
+
+//! org roles --> owners manage the org, coaches attest members' workouts, members just belong to it
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleCoach  = "coach"
+	OrgRoleMember = "member"
+)
+
+// ? - an organization (company / gym) that groups users together
+type Org struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ? - a single user's membership inside an org
+type OrgMember struct {
+	OrgID      int       `json:"org_id"`
+	User       *User     `json:"user"`
+	Role       string    `json:"role"`
+	Active     bool      `json:"active"`
+	ExternalID string    `json:"external_id"` // * id given by the identity provider (SCIM externalId)
+	ShareStats bool      `json:"share_stats"` // * member's privacy choice, false --> always anonymized in org exports
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// * holds the db connection for org operations
+type PostgresOrgStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new org store instance
+func NewPostgresOrgStore(db *sql.DB) *PostgresOrgStore {
+	return &PostgresOrgStore{db: db}
+}
+
+//! OrgStore interface --> contract for org and org-membership operations
+type OrgStore interface {
+	CreateOrg(org *Org, ownerID int) error
+	GetOrgByID(id int64) (*Org, error)
+	GetOrgBySCIMToken(plaintext string) (*Org, error)
+	SetSCIMTokenHash(orgID int64, hash []byte) error
+	GetMember(orgID int64, userID int) (*OrgMember, error)
+	ListMembers(orgID int64, username string, offset, limit int) ([]*OrgMember, int, error)
+	UpsertMember(member *OrgMember) error
+	ProvisionUser(member *OrgMember) error
+	IsProvisioned(orgID int64, userID int) (bool, error)
+	RemoveMember(orgID int64, userID int) error
+	CountActiveOwners(orgID int64) (int, error)
+	SetShareStats(orgID int64, userID int, share bool) error
+	SetMemberRole(orgID int64, userID int, role string) error
+	IsCoachFor(coachID, athleteID int) (bool, error)
+	GetMemberStats(orgID int64, from, to time.Time) ([]*MemberStats, error)
+	GetAttendance(orgID int64, from, to time.Time) ([]*AttendanceDay, error)
+	GetMemberWorkouts(orgID int64, from, to time.Time) ([]*MemberWorkout, error)
+	GetMemberEntries(orgID int64, from, to time.Time) ([]*MemberEntry, error)
+	GetMemberWeights(orgID int64, from, to time.Time) ([]*MemberWeight, error)
+}
+
+// ? - aggregated training numbers for one member over an export window
+type MemberStats struct {
+	UserID        int
+	Username      string
+	ShareStats    bool
+	Workouts      int
+	TotalMinutes  int
+	TotalCalories int
+	LastWorkoutAt *time.Time
+}
+
+// ? - raw workout row for analytics exports (description left out on purpose, it's free text)
+type MemberWorkout struct {
+	UserID            int
+	Username          string
+	ShareStats        bool
+	WorkoutID         int
+	Title             string
+	DurationMinutes   int
+	CaloriesBurned    int
+	CaloriesEstimated bool
+	CreatedAt         time.Time
+}
+
+// ? - raw workout entry row for analytics exports
+type MemberEntry struct {
+	UserID          int
+	Username        string
+	ShareStats      bool
+	WorkoutID       int
+	EntryID         int
+	ExerciseName    string
+	Sets            int
+	Reps            *int
+	DurationSeconds *int
+	Weight          *float64
+	OrderIndex      int
+}
+
+// ? - body weight measurement row for analytics exports
+type MemberWeight struct {
+	UserID     int
+	Username   string
+	ShareStats bool
+	WeightKG   float64
+	MeasuredAt time.Time
+}
+
+// ? - how many workouts a member logged on a given day
+type AttendanceDay struct {
+	UserID     int
+	Username   string
+	ShareStats bool
+	Day        time.Time
+	Workouts   int
+}
+
+//! CreateOrg --> inserts the org and makes the creator its owner in one transaction
+func (s *PostgresOrgStore) CreateOrg(org *Org, ownerID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // ? - rolls back if anything fails
+
+	query := `
+  INSERT INTO orgs (name)
+  VALUES ($1)
+  RETURNING id, created_at
+  `
+	err = tx.QueryRow(query, org.Name).Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO org_members (org_id, user_id, role) VALUES ($1, $2, $3)`, org.ID, ownerID, OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresOrgStore) GetOrgByID(id int64) (*Org, error) {
+	org := &Org{}
+	query := `
+  SELECT id, name, created_at
+  FROM orgs
+  WHERE id = $1
+  `
+	err := s.db.QueryRow(query, id).Scan(&org.ID, &org.Name, &org.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // ? - org doesn't exist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+//! GetOrgBySCIMToken --> resolves the org a SCIM bearer token was issued for
+func (s *PostgresOrgStore) GetOrgBySCIMToken(plaintext string) (*Org, error) {
+	tokenHash := sha256.Sum256([]byte(plaintext)) //* only the hash is stored, same as auth tokens
+	org := &Org{}
+	query := `
+  SELECT id, name, created_at
+  FROM orgs
+  WHERE scim_token_hash = $1
+  `
+	err := s.db.QueryRow(query, tokenHash[:]).Scan(&org.ID, &org.Name, &org.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+//! SetSCIMTokenHash --> replaces the org's SCIM token (old one stops working immediately)
+func (s *PostgresOrgStore) SetSCIMTokenHash(orgID int64, hash []byte) error {
+	result, err := s.db.Exec(`UPDATE orgs SET scim_token_hash = $1 WHERE id = $2`, hash, orgID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// * memberColumns --> shared select list so every member query scans the same way
+const memberColumns = `
+  m.org_id, m.role, m.active, COALESCE(m.external_id, ''), m.share_stats, m.created_at, m.updated_at,
+  u.id, u.username, u.email, u.bio, u.created_at, u.updated_at
+`
+
+func scanMember(row interface{ Scan(...any) error }) (*OrgMember, error) {
+	member := &OrgMember{User: &User{}}
+	err := row.Scan(
+		&member.OrgID,
+		&member.Role,
+		&member.Active,
+		&member.ExternalID,
+		&member.ShareStats,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+		&member.User.ID,
+		&member.User.Username,
+		&member.User.Email,
+		&member.User.Bio,
+		&member.User.CreatedAt,
+		&member.User.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *PostgresOrgStore) GetMember(orgID int64, userID int) (*OrgMember, error) {
+	query := `SELECT` + memberColumns + `
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  WHERE m.org_id = $1 AND m.user_id = $2
+  `
+	member, err := scanMember(s.db.QueryRow(query, orgID, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+//! ListMembers --> paginated member listing, optionally filtered by exact username
+//? returns the total count too so SCIM can report totalResults
+func (s *PostgresOrgStore) ListMembers(orgID int64, username string, offset, limit int) ([]*OrgMember, int, error) {
+	var total int
+	countQuery := `
+  SELECT COUNT(*)
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  WHERE m.org_id = $1 AND ($2 = '' OR u.username = $2)
+  `
+	err := s.db.QueryRow(countQuery, orgID, username).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT` + memberColumns + `
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  WHERE m.org_id = $1 AND ($2 = '' OR u.username = $2)
+  ORDER BY u.id
+  OFFSET $3 LIMIT $4
+  `
+	rows, err := s.db.Query(query, orgID, username, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	members := []*OrgMember{}
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		members = append(members, member)
+	}
+	return members, total, rows.Err()
+}
+
+//! UpsertMember --> adds the user to the org or updates the existing membership
+func (s *PostgresOrgStore) UpsertMember(member *OrgMember) error {
+	if member.Role == "" {
+		member.Role = OrgRoleMember
+	}
+	query := `
+  INSERT INTO org_members (org_id, user_id, role, active, external_id)
+  VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+  ON CONFLICT (org_id, user_id) DO UPDATE
+  SET role = EXCLUDED.role, active = EXCLUDED.active, external_id = EXCLUDED.external_id, updated_at = CURRENT_TIMESTAMP
+  RETURNING share_stats, created_at, updated_at
+  `
+	return s.db.QueryRow(query, member.OrgID, member.User.ID, member.Role, member.Active, member.ExternalID).Scan(&member.ShareStats, &member.CreatedAt, &member.UpdatedAt)
+}
+
+//! ProvisionUser --> creates member.User and its membership in one transaction, remembering which org created it
+//? SCIM may only ever link accounts its own org provisioned, see IsProvisioned
+func (s *PostgresOrgStore) ProvisionUser(member *OrgMember) error {
+	if member.Role == "" {
+		member.Role = OrgRoleMember
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	user := member.User
+	query := `
+  INSERT INTO users (username, email, password_hash, bio)
+  VALUES ($1, $2, $3, $4)
+  RETURNING id, created_at, updated_at
+  `
+	err = tx.QueryRow(query, user.Username, user.Email, user.PasswordHash.hash, user.Bio).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return mapError(err) //* ErrConflict --> username or email taken
+	}
+
+	_, err = tx.Exec(`INSERT INTO scim_provisioned_users (org_id, user_id) VALUES ($1, $2)`, member.OrgID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+  INSERT INTO org_members (org_id, user_id, role, active, external_id)
+  VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+  RETURNING share_stats, created_at, updated_at
+  `
+	err = tx.QueryRow(query, member.OrgID, user.ID, member.Role, member.Active, member.ExternalID).Scan(&member.ShareStats, &member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//! IsProvisioned --> true when orgID's SCIM created the account, even if it has been removed from the org since
+func (s *PostgresOrgStore) IsProvisioned(orgID int64, userID int) (bool, error) {
+	var provisioned bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM scim_provisioned_users WHERE org_id = $1 AND user_id = $2)`, orgID, userID).Scan(&provisioned)
+	return provisioned, err
+}
+
+func (s *PostgresOrgStore) RemoveMember(orgID int64, userID int) error {
+	result, err := s.db.Exec(`DELETE FROM org_members WHERE org_id = $1 AND user_id = $2`, orgID, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! CountActiveOwners --> owners whose membership is still active, SCIM won't take the org below one
+func (s *PostgresOrgStore) CountActiveOwners(orgID int64) (int, error) {
+	var owners int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM org_members WHERE org_id = $1 AND role = $2 AND active`, orgID, OrgRoleOwner).Scan(&owners)
+	return owners, err
+}
+
+//! SetShareStats --> member-controlled privacy toggle for org exports
+func (s *PostgresOrgStore) SetShareStats(orgID int64, userID int, share bool) error {
+	result, err := s.db.Exec(`UPDATE org_members SET share_stats = $1, updated_at = CURRENT_TIMESTAMP WHERE org_id = $2 AND user_id = $3`, share, orgID, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! SetMemberRole --> owner-managed role changes, ErrNotFound when the user isn't a member
+func (s *PostgresOrgStore) SetMemberRole(orgID int64, userID int, role string) error {
+	result, err := s.db.Exec(`UPDATE org_members SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE org_id = $2 AND user_id = $3`, role, orgID, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! IsCoachFor --> true when both users are active in a shared org where coachID is a coach or owner
+func (s *PostgresOrgStore) IsCoachFor(coachID, athleteID int) (bool, error) {
+	var coach bool
+	query := `
+  SELECT EXISTS (
+    SELECT 1
+    FROM org_members c
+    INNER JOIN org_members a ON a.org_id = c.org_id
+    WHERE c.user_id = $1 AND c.active AND c.role IN ('coach', 'owner')
+      AND a.user_id = $2 AND a.active
+  )
+  `
+	err := s.db.QueryRow(query, coachID, athleteID).Scan(&coach)
+	return coach, err
+}
+
+//! GetMemberStats --> one row per active member with workout totals inside [from, to)
+//? flagged (implausible) workouts are left out so they can't skew org stats
+func (s *PostgresOrgStore) GetMemberStats(orgID int64, from, to time.Time) ([]*MemberStats, error) {
+	query := `
+  SELECT u.id, u.username, m.share_stats,
+         COUNT(w.id), COALESCE(SUM(w.duration_minutes), 0), COALESCE(SUM(w.calories_burned), 0), MAX(w.created_at)
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  LEFT JOIN workouts w ON w.user_id = m.user_id AND w.created_at >= $2 AND w.created_at < $3 AND NOT w.flagged
+  WHERE m.org_id = $1 AND m.active
+  GROUP BY u.id, u.username, m.share_stats
+  ORDER BY u.id
+  `
+	rows, err := s.db.Query(query, orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*MemberStats{}
+	for rows.Next() {
+		row := &MemberStats{}
+		err = rows.Scan(&row.UserID, &row.Username, &row.ShareStats, &row.Workouts, &row.TotalMinutes, &row.TotalCalories, &row.LastWorkoutAt)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, row)
+	}
+	return stats, rows.Err()
+}
+
+//! GetAttendance --> per member per day workout counts inside [from, to), only days with activity
+func (s *PostgresOrgStore) GetAttendance(orgID int64, from, to time.Time) ([]*AttendanceDay, error) {
+	query := `
+  SELECT u.id, u.username, m.share_stats, DATE_TRUNC('day', w.created_at), COUNT(w.id)
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  INNER JOIN workouts w ON w.user_id = m.user_id
+  WHERE m.org_id = $1 AND m.active AND w.created_at >= $2 AND w.created_at < $3 AND NOT w.flagged
+  GROUP BY u.id, u.username, m.share_stats, DATE_TRUNC('day', w.created_at)
+  ORDER BY 4, u.id
+  `
+	rows, err := s.db.Query(query, orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []*AttendanceDay{}
+	for rows.Next() {
+		row := &AttendanceDay{}
+		err = rows.Scan(&row.UserID, &row.Username, &row.ShareStats, &row.Day, &row.Workouts)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, row)
+	}
+	return days, rows.Err()
+}
+
+//! GetMemberWorkouts --> every workout of active members inside [from, to)
+func (s *PostgresOrgStore) GetMemberWorkouts(orgID int64, from, to time.Time) ([]*MemberWorkout, error) {
+	query := `
+  SELECT u.id, u.username, m.share_stats, w.id, w.title, w.duration_minutes,
+         COALESCE(w.calories_burned, 0), w.calories_estimated, w.created_at
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  INNER JOIN workouts w ON w.user_id = m.user_id
+  WHERE m.org_id = $1 AND m.active AND w.created_at >= $2 AND w.created_at < $3
+  ORDER BY w.id
+  `
+	rows, err := s.db.Query(query, orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := []*MemberWorkout{}
+	for rows.Next() {
+		row := &MemberWorkout{}
+		err = rows.Scan(&row.UserID, &row.Username, &row.ShareStats, &row.WorkoutID, &row.Title, &row.DurationMinutes,
+			&row.CaloriesBurned, &row.CaloriesEstimated, &row.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, row)
+	}
+	return workouts, rows.Err()
+}
+
+//! GetMemberEntries --> entries belonging to the workouts GetMemberWorkouts returns
+func (s *PostgresOrgStore) GetMemberEntries(orgID int64, from, to time.Time) ([]*MemberEntry, error) {
+	query := `
+  SELECT u.id, u.username, m.share_stats, w.id, e.id, e.exercise_name, e.sets, e.reps,
+         e.duration_seconds, e.weight, e.order_index
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  INNER JOIN workouts w ON w.user_id = m.user_id
+  INNER JOIN workout_entries e ON e.workout_id = w.id
+  WHERE m.org_id = $1 AND m.active AND w.created_at >= $2 AND w.created_at < $3
+  ORDER BY w.id, e.order_index
+  `
+	rows, err := s.db.Query(query, orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*MemberEntry{}
+	for rows.Next() {
+		row := &MemberEntry{}
+		err = rows.Scan(&row.UserID, &row.Username, &row.ShareStats, &row.WorkoutID, &row.EntryID, &row.ExerciseName,
+			&row.Sets, &row.Reps, &row.DurationSeconds, &row.Weight, &row.OrderIndex)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, row)
+	}
+	return entries, rows.Err()
+}
+
+//! GetMemberWeights --> body weight history of active members inside [from, to)
+func (s *PostgresOrgStore) GetMemberWeights(orgID int64, from, to time.Time) ([]*MemberWeight, error) {
+	query := `
+  SELECT u.id, u.username, m.share_stats, uw.weight_kg, uw.measured_at
+  FROM org_members m
+  INNER JOIN users u ON u.id = m.user_id
+  INNER JOIN user_weights uw ON uw.user_id = m.user_id
+  WHERE m.org_id = $1 AND m.active AND uw.measured_at >= $2 AND uw.measured_at < $3
+  ORDER BY uw.measured_at
+  `
+	rows, err := s.db.Query(query, orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weights := []*MemberWeight{}
+	for rows.Next() {
+		row := &MemberWeight{}
+		err = rows.Scan(&row.UserID, &row.Username, &row.ShareStats, &row.WeightKG, &row.MeasuredAt)
+		if err != nil {
+			return nil, err
+		}
+		weights = append(weights, row)
+	}
+	return weights, rows.Err()
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestPostgresOrgStoreSCIM --> a SCIM token resolves only its own org, provisioning is remembered per org
+func TestPostgresOrgStoreSCIM(t *testing.T) {
+	db := openTestDB(t)
+	orgs := NewPostgresOrgStore(db)
+	ana := createTestUser(t, db, "ana")
+	ben := createTestUser(t, db, "ben")
+
+	acme := &Org{Name: "acme"}
+	globex := &Org{Name: "globex"}
+	require.NoError(t, orgs.CreateOrg(acme, ana.ID))
+	require.NoError(t, orgs.CreateOrg(globex, ben.ID))
+
+	// * tokens --> each org resolves from its own, a rotated one stops working
+	acmeHash := sha256.Sum256([]byte("acme-token"))
+	require.NoError(t, orgs.SetSCIMTokenHash(int64(acme.ID), acmeHash[:]))
+	org, err := orgs.GetOrgBySCIMToken("acme-token")
+	require.NoError(t, err)
+	require.NotNil(t, org)
+	assert.Equal(t, acme.ID, org.ID)
+
+	rotated := sha256.Sum256([]byte("acme-token-2"))
+	require.NoError(t, orgs.SetSCIMTokenHash(int64(acme.ID), rotated[:]))
+	org, err = orgs.GetOrgBySCIMToken("acme-token")
+	require.NoError(t, err)
+	assert.Nil(t, org)
+	org, err = orgs.GetOrgBySCIMToken("unknown")
+	require.NoError(t, err)
+	assert.Nil(t, org)
+
+	// * provisioning --> the user + membership of acme only, globex never provisioned it
+	member := &OrgMember{OrgID: acme.ID, User: &User{Username: "cleo", Email: "cleo@example.com"}, Active: true, ExternalID: "idp-1"}
+	require.NoError(t, member.User.PasswordHash.Set("password"))
+	require.NoError(t, orgs.ProvisionUser(member))
+	assert.NotZero(t, member.User.ID)
+
+	provisioned, err := orgs.IsProvisioned(int64(acme.ID), member.User.ID)
+	require.NoError(t, err)
+	assert.True(t, provisioned)
+	provisioned, err = orgs.IsProvisioned(int64(globex.ID), member.User.ID)
+	require.NoError(t, err)
+	assert.False(t, provisioned)
+	provisioned, err = orgs.IsProvisioned(int64(acme.ID), ben.ID)
+	require.NoError(t, err)
+	assert.False(t, provisioned, "an account signed up on its own was never provisioned")
+
+	got, err := orgs.GetMember(int64(acme.ID), member.User.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "idp-1", got.ExternalID)
+	got, err = orgs.GetMember(int64(globex.ID), member.User.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// * active owners --> cleo's plain membership doesn't count, a deactivated owner doesn't either
+	owners, err := orgs.CountActiveOwners(int64(acme.ID))
+	require.NoError(t, err)
+	assert.Equal(t, 1, owners)
+	require.NoError(t, orgs.UpsertMember(&OrgMember{OrgID: acme.ID, User: member.User, Role: OrgRoleOwner, Active: false}))
+	owners, err = orgs.CountActiveOwners(int64(acme.ID))
+	require.NoError(t, err)
+	assert.Equal(t, 1, owners)
+
+	// * removal keeps the provisioning record, so acme can link the account again
+	require.NoError(t, orgs.RemoveMember(int64(acme.ID), member.User.ID))
+	provisioned, err = orgs.IsProvisioned(int64(acme.ID), member.User.ID)
+	require.NoError(t, err)
+	assert.True(t, provisioned)
+
+	// * a taken email rolls the whole provisioning back
+	taken := &OrgMember{OrgID: globex.ID, User: &User{Username: "dora", Email: "ana@example.com"}, Active: true}
+	require.NoError(t, taken.User.PasswordHash.Set("password"))
+	assert.ErrorIs(t, orgs.ProvisionUser(taken), ErrConflict)
+	members, total, err := orgs.ListMembers(int64(globex.ID), "dora", 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+	assert.Zero(t, total)
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ? - a domain event saved with the write it describes, waiting for (or done with) the relay
+type OutboxEvent struct {
+	ID        int64
+	Type      string
+	UserID    int
+	WorkoutID int
+	Ref       string
+	CreatedAt time.Time
+}
+
+// * holds the db connection for the events outbox
+type PostgresOutboxStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new outbox store instance
+func NewPostgresOutboxStore(db *sql.DB) *PostgresOutboxStore {
+	return &PostgresOutboxStore{db: db}
+}
+
+// * SQLite --> same table, filled by the sqlite workout store
+type SQLiteOutboxStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteOutboxStore(db *sql.DB) *SQLiteOutboxStore {
+	return &SQLiteOutboxStore{db: db}
+}
+
+// ! OutboxStore interface --> the relay's side of events_outbox, the workout stores write the rows
+type OutboxStore interface {
+	// * RelayEvents --> up to limit pending events oldest first; publish answers how many of them, oldest first,
+	// * went through and only those are marked published, the rest stay pending for the next call
+	RelayEvents(limit int, publish func([]*OutboxEvent) (int, error)) (int, error)
+	PurgePublishedEvents(publishedBefore time.Time) (int64, error)
+}
+
+// * enqueueWorkoutEvent --> the outbox row for a workout write, inside its transaction; deletes enqueue before the DELETE
+// ? the owner is read from the row, so a write that matched nothing leaves nothing behind
+func enqueueWorkoutEvent(tx *sql.Tx, eventType string, workoutID int) error {
+	_, err := tx.Exec(`INSERT INTO events_outbox (event_type, user_id, workout_id) SELECT $1, user_id, id FROM workouts WHERE id = $2`,
+		eventType, workoutID)
+	return err
+}
+
+func enqueueWorkoutEventSQLite(tx *sql.Tx, eventType string, workoutID int) error {
+	_, err := tx.Exec(`INSERT INTO events_outbox (event_type, user_id, workout_id, created_at) SELECT ?, user_id, id, ? FROM workouts WHERE id = ?`,
+		eventType, time.Now().UTC(), workoutID)
+	return err
+}
+
+func enqueueWorkoutEventPgx(ctx context.Context, tx pgx.Tx, eventType string, workoutID int) error {
+	_, err := tx.Exec(ctx, `INSERT INTO events_outbox (event_type, user_id, workout_id) SELECT $1, user_id, id FROM workouts WHERE id = $2`,
+		eventType, workoutID)
+	return err
+}
+
+func scanOutboxEvents(rows *sql.Rows) ([]*OutboxEvent, error) {
+	defer rows.Close()
+
+	list := []*OutboxEvent{}
+	for rows.Next() {
+		event := &OutboxEvent{}
+		err := rows.Scan(&event.ID, &event.Type, &event.UserID, &event.WorkoutID, &event.Ref, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, event)
+	}
+	return list, rows.Err()
+}
+
+// ! RelayEvents --> SKIP LOCKED like ClaimJob, relays in several app instances each take their own rows
+func (s *PostgresOutboxStore) RelayEvents(limit int, publish func([]*OutboxEvent) (int, error)) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+  SELECT id, event_type, user_id, workout_id, ref, created_at
+  FROM events_outbox
+  WHERE published_at IS NULL
+  ORDER BY id
+  LIMIT $1
+  FOR UPDATE SKIP LOCKED
+  `, limit)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := scanOutboxEvents(rows)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	delivered, publishErr := publish(pending)
+	if delivered == 0 {
+		return 0, publishErr
+	}
+	ids := make([]int64, delivered)
+	for i, event := range pending[:delivered] {
+		ids[i] = event.ID
+	}
+	_, err = tx.Exec(`UPDATE events_outbox SET published_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return delivered, publishErr
+}
+
+func (s *PostgresOutboxStore) PurgePublishedEvents(publishedBefore time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM events_outbox WHERE published_at < $1`, publishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ! RelayEvents --> one writer on sqlite, the pending rows up to the last one read are exactly the page
+func (s *SQLiteOutboxStore) RelayEvents(limit int, publish func([]*OutboxEvent) (int, error)) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+  SELECT id, event_type, user_id, workout_id, ref, created_at
+  FROM events_outbox
+  WHERE published_at IS NULL
+  ORDER BY id
+  LIMIT ?
+  `, limit)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := scanOutboxEvents(rows)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	delivered, publishErr := publish(pending)
+	if delivered == 0 {
+		return 0, publishErr
+	}
+	_, err = tx.Exec(`UPDATE events_outbox SET published_at = ? WHERE published_at IS NULL AND id <= ?`,
+		time.Now().UTC(), pending[delivered-1].ID)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return delivered, publishErr
+}
+
+func (s *SQLiteOutboxStore) PurgePublishedEvents(publishedBefore time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM events_outbox WHERE published_at < ?`, publishedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
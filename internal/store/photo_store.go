@@ -0,0 +1,110 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - one uploaded photo of a workout, the bytes live in the blob store under Key / ThumbnailKey
+type WorkoutPhoto struct {
+	ID           int64     `json:"id"`
+	WorkoutID    int       `json:"workout_id"`
+	Key          string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url,omitempty"`           // * signed, filled in per response
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"` // * signed, filled in per response
+}
+
+// * holds the db connection for workout photos
+type PostgresPhotoStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new photo store instance
+func NewPostgresPhotoStore(db *sql.DB) *PostgresPhotoStore {
+	return &PostgresPhotoStore{db: db}
+}
+
+// ! PhotoStore interface --> photo metadata, deleting a workout cascades to its rows (not to the blobs)
+type PhotoStore interface {
+	CreatePhoto(*WorkoutPhoto) error
+	//* oldest first
+	ListPhotos(workoutID int64) ([]*WorkoutPhoto, error)
+	CountPhotos(workoutID int64) (int, error)
+	GetPhoto(id int64) (*WorkoutPhoto, error)
+	DeletePhoto(id int64) error
+}
+
+const photoColumns = `id, workout_id, object_key, thumbnail_key, content_type, size_bytes, width, height, created_at`
+
+func scanPhoto(row interface{ Scan(...any) error }) (*WorkoutPhoto, error) {
+	photo := &WorkoutPhoto{}
+	err := row.Scan(&photo.ID, &photo.WorkoutID, &photo.Key, &photo.ThumbnailKey, &photo.ContentType, &photo.SizeBytes,
+		&photo.Width, &photo.Height, &photo.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+func (s *PostgresPhotoStore) CreatePhoto(photo *WorkoutPhoto) error {
+	query := `
+  INSERT INTO workout_photos (workout_id, object_key, thumbnail_key, content_type, size_bytes, width, height)
+  VALUES ($1, $2, $3, $4, $5, $6, $7)
+  RETURNING id, created_at
+  `
+	return s.db.QueryRow(query, photo.WorkoutID, photo.Key, photo.ThumbnailKey, photo.ContentType, photo.SizeBytes,
+		photo.Width, photo.Height).Scan(&photo.ID, &photo.CreatedAt)
+}
+
+func (s *PostgresPhotoStore) ListPhotos(workoutID int64) ([]*WorkoutPhoto, error) {
+	rows, err := s.db.Query(`SELECT `+photoColumns+` FROM workout_photos WHERE workout_id = $1 ORDER BY id`, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []*WorkoutPhoto{}
+	for rows.Next() {
+		photo, err := scanPhoto(rows)
+		if err != nil {
+			return nil, err
+		}
+		photos = append(photos, photo)
+	}
+	return photos, rows.Err()
+}
+
+func (s *PostgresPhotoStore) CountPhotos(workoutID int64) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM workout_photos WHERE workout_id = $1`, workoutID).Scan(&count)
+	return count, err
+}
+
+func (s *PostgresPhotoStore) GetPhoto(id int64) (*WorkoutPhoto, error) {
+	photo, err := scanPhoto(s.db.QueryRow(`SELECT `+photoColumns+` FROM workout_photos WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return photo, err
+}
+
+func (s *PostgresPhotoStore) DeletePhoto(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM workout_photos WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! unit systems a user can prefer (values are always stored metric)
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+//! DefaultTimezone --> IANA zone used until the user picks one, day boundaries for stats + streaks follow it
+const DefaultTimezone = "UTC"
+
+// ? - body metrics + preferences attached to a user
+type Profile struct {
+	UserID            int       `json:"user_id"`
+	HeightCM          *float64  `json:"height_cm"`  // * pointer so it can be null
+	WeightKG          *float64  `json:"weight_kg"`  // * latest known weight, kept in sync with weight history
+	Birthdate         *string   `json:"birthdate"`  // * YYYY-MM-DD
+	Units             string    `json:"units"`
+	Timezone          string    `json:"timezone"` // * IANA name, e.g. Europe/Berlin
+	EventsOptIn       bool      `json:"events_opt_in"` // * auto-enrolled in seasonal events
+	LeaderboardOptOut bool      `json:"leaderboard_opt_out"` // * left off every leaderboard, the XP board included
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ? - one point in the user's weight history
+type WeightEntry struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"-"`
+	WeightKG   float64   `json:"weight_kg"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// * holds the db connection for profile operations
+type PostgresProfileStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new profile store instance
+func NewPostgresProfileStore(db *sql.DB) *PostgresProfileStore {
+	return &PostgresProfileStore{db: db}
+}
+
+//! ProfileStore interface --> contract for profile + weight history operations
+type ProfileStore interface {
+	GetProfile(userID int) (*Profile, error)
+	UpsertProfile(*Profile) error
+	AddWeight(*WeightEntry) error
+	ListWeights(userID int, from, to time.Time) ([]*WeightEntry, error)
+}
+
+//! GetProfile --> returns the stored profile or an empty metric profile if none saved yet
+func (s *PostgresProfileStore) GetProfile(userID int) (*Profile, error) {
+	profile := &Profile{UserID: userID, Units: UnitsMetric, Timezone: DefaultTimezone}
+	query := `
+  SELECT height_cm, weight_kg, TO_CHAR(birthdate, 'YYYY-MM-DD'), units, timezone, events_opt_in, leaderboard_opt_out, updated_at
+  FROM user_profiles
+  WHERE user_id = $1
+  `
+	err := s.db.QueryRow(query, userID).Scan(&profile.HeightCM, &profile.WeightKG, &profile.Birthdate, &profile.Units,
+		&profile.Timezone, &profile.EventsOptIn, &profile.LeaderboardOptOut, &profile.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return profile, nil // ? - nothing saved yet, defaults are fine
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (s *PostgresProfileStore) UpsertProfile(profile *Profile) error {
+	query := `
+  INSERT INTO user_profiles (user_id, height_cm, weight_kg, birthdate, units, events_opt_in, timezone, leaderboard_opt_out)
+  VALUES ($1, $2, $3, $4::date, $5, $6, COALESCE(NULLIF($7, ''), 'UTC'), $8)
+  ON CONFLICT (user_id) DO UPDATE
+  SET height_cm = EXCLUDED.height_cm, weight_kg = EXCLUDED.weight_kg, birthdate = EXCLUDED.birthdate,
+      units = EXCLUDED.units, events_opt_in = EXCLUDED.events_opt_in, timezone = EXCLUDED.timezone,
+      leaderboard_opt_out = EXCLUDED.leaderboard_opt_out, updated_at = CURRENT_TIMESTAMP
+  RETURNING timezone, updated_at
+  `
+	return s.db.QueryRow(query, profile.UserID, profile.HeightCM, profile.WeightKG, profile.Birthdate, profile.Units,
+		profile.EventsOptIn, profile.Timezone, profile.LeaderboardOptOut).Scan(&profile.Timezone, &profile.UpdatedAt)
+}
+
+//! Location --> the profile's zone, UTC when unset or unknown to this host's tzdata
+func (p *Profile) Location() *time.Location {
+	if p == nil || p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+//! AddWeight --> records a measurement and, if it's the newest one, makes it the profile's current weight
+func (s *PostgresProfileStore) AddWeight(entry *WeightEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // ? - rolls back if anything fails
+
+	query := `
+  INSERT INTO user_weights (user_id, weight_kg, measured_at)
+  VALUES ($1, $2, $3)
+  RETURNING id
+  `
+	err = tx.QueryRow(query, entry.UserID, entry.WeightKG, entry.MeasuredAt).Scan(&entry.ID)
+	if err != nil {
+		return err
+	}
+
+	//* back-filled older measurements must not overwrite the current weight
+	syncQuery := `
+  INSERT INTO user_profiles (user_id, weight_kg)
+  SELECT $1, $2
+  WHERE NOT EXISTS (SELECT 1 FROM user_weights WHERE user_id = $1 AND measured_at > $3)
+  ON CONFLICT (user_id) DO UPDATE
+  SET weight_kg = EXCLUDED.weight_kg, updated_at = CURRENT_TIMESTAMP
+  `
+	_, err = tx.Exec(syncQuery, entry.UserID, entry.WeightKG, entry.MeasuredAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//! ListWeights --> time series of measurements inside [from, to], oldest first for charting
+func (s *PostgresProfileStore) ListWeights(userID int, from, to time.Time) ([]*WeightEntry, error) {
+	query := `
+  SELECT id, user_id, weight_kg, measured_at
+  FROM user_weights
+  WHERE user_id = $1 AND measured_at >= $2 AND measured_at <= $3
+  ORDER BY measured_at
+  `
+	rows, err := s.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weights := []*WeightEntry{}
+	for rows.Next() {
+		entry := &WeightEntry{}
+		err = rows.Scan(&entry.ID, &entry.UserID, &entry.WeightKG, &entry.MeasuredAt)
+		if err != nil {
+			return nil, err
+		}
+		weights = append(weights, entry)
+	}
+	return weights, rows.Err()
+}
@@ -0,0 +1,350 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! program visibility --> private programs are only seen and followed by their author
+const (
+	ProgramPrivate = "private"
+	ProgramPublic  = "public"
+)
+
+// ? - a multi-week training plan, Weeks is only loaded by GetProgram
+type Program struct {
+	ID          int           `json:"id"`
+	UserID      int           `json:"user_id"` // * the author
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Visibility  string        `json:"visibility"`
+	Weeks       []ProgramWeek `json:"weeks,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// ? - one week of a program, Number is its 1-based position
+type ProgramWeek struct {
+	Number int          `json:"week"`
+	Notes  string       `json:"notes"`
+	Days   []ProgramDay `json:"days"`
+}
+
+// ? - one training day, Day is 1-7 counted from the day an enrollment starts
+type ProgramDay struct {
+	Day       int               `json:"day"`
+	Title     string            `json:"title"` // * optional, the templates' titles otherwise
+	Templates []ProgramTemplate `json:"templates"`
+}
+
+// ? - a workout the day repeats, Title + DurationMinutes are read from it
+type ProgramTemplate struct {
+	WorkoutID       int    `json:"workout_id"`
+	Title           string `json:"title"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+// ? - a user following a program, every day was planned as a schedule when they enrolled
+type ProgramEnrollment struct {
+	ID        int64     `json:"id"`
+	ProgramID int       `json:"program_id"`
+	UserID    int       `json:"user_id"`
+	StartsOn  string    `json:"starts_on"`   // * YYYY-MM-DD, day 1 of week 1
+	TimeOfDay string    `json:"time_of_day"` // * HH:MM in Timezone
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// * holds the db connection for program operations
+type PostgresProgramStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new program store instance
+func NewPostgresProgramStore(db *sql.DB) *PostgresProgramStore {
+	return &PostgresProgramStore{db: db}
+}
+
+//! ProgramStore interface --> contract for programs, their weeks/days/templates and enrollments
+type ProgramStore interface {
+	CreateProgram(*Program) error
+	GetProgram(id int64) (*Program, error)
+	ListPrograms(userID int) ([]*Program, error)
+	UpdateProgram(*Program) error
+	DeleteProgram(id int64) error
+	Enroll(e *ProgramEnrollment, schedules []*Schedule) error
+	GetEnrollment(programID int64, userID int) (*ProgramEnrollment, error)
+	Unenroll(programID int64, userID int) error
+}
+
+const programColumns = `p.id, p.user_id, p.title, p.description, p.visibility, p.created_at, p.updated_at`
+
+func scanProgram(row interface{ Scan(...any) error }) (*Program, error) {
+	program := &Program{}
+	err := row.Scan(&program.ID, &program.UserID, &program.Title, &program.Description, &program.Visibility,
+		&program.CreatedAt, &program.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+//! CreateProgram --> the program and its whole week tree in one transaction
+func (s *PostgresProgramStore) CreateProgram(program *Program) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+  INSERT INTO programs (user_id, title, description, visibility)
+  VALUES ($1, $2, $3, $4)
+  RETURNING id, created_at, updated_at
+  `
+	err = tx.QueryRow(query, program.UserID, program.Title, program.Description, program.Visibility).
+		Scan(&program.ID, &program.CreatedAt, &program.UpdatedAt)
+	if err != nil {
+		return mapError(err)
+	}
+	err = insertProgramWeeks(tx, program)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// * insertProgramWeeks --> weeks are numbered by position, Number is set on the way
+func insertProgramWeeks(tx *sql.Tx, program *Program) error {
+	for i := range program.Weeks {
+		week := &program.Weeks[i]
+		week.Number = i + 1
+		var weekID int64
+		err := tx.QueryRow(`INSERT INTO program_weeks (program_id, week_number, notes) VALUES ($1, $2, $3) RETURNING id`,
+			program.ID, week.Number, week.Notes).Scan(&weekID)
+		if err != nil {
+			return mapError(err)
+		}
+
+		for _, day := range week.Days {
+			var dayID int64
+			err = tx.QueryRow(`INSERT INTO program_days (week_id, day, title) VALUES ($1, $2, $3) RETURNING id`,
+				weekID, day.Day, day.Title).Scan(&dayID)
+			if err != nil {
+				return mapError(err)
+			}
+			for position, template := range day.Templates {
+				_, err = tx.Exec(`INSERT INTO program_day_templates (day_id, position, workout_id) VALUES ($1, $2, $3)`,
+					dayID, position, template.WorkoutID)
+				if err != nil {
+					return mapError(err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//! GetProgram --> the program with its weeks, days and templates, nil when it doesn't exist
+func (s *PostgresProgramStore) GetProgram(id int64) (*Program, error) {
+	program, err := scanProgram(s.db.QueryRow(`SELECT `+programColumns+` FROM programs p WHERE p.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+  SELECT pw.week_number, pw.notes, pd.day, pd.title, w.id, w.title, w.duration_minutes
+  FROM program_weeks pw
+  LEFT JOIN program_days pd ON pd.week_id = pw.id
+  LEFT JOIN program_day_templates t ON t.day_id = pd.id
+  LEFT JOIN workouts w ON w.id = t.workout_id
+  WHERE pw.program_id = $1
+  ORDER BY pw.week_number, pd.day, t.position
+  `
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	program.Weeks = []ProgramWeek{}
+	for rows.Next() {
+		var (
+			number, day, workoutID, duration sql.NullInt64
+			notes, dayTitle, workoutTitle    sql.NullString
+		)
+		err = rows.Scan(&number, &notes, &day, &dayTitle, &workoutID, &workoutTitle, &duration)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(program.Weeks) == 0 || program.Weeks[len(program.Weeks)-1].Number != int(number.Int64) {
+			program.Weeks = append(program.Weeks, ProgramWeek{Number: int(number.Int64), Notes: notes.String, Days: []ProgramDay{}})
+		}
+		week := &program.Weeks[len(program.Weeks)-1]
+		if !day.Valid {
+			continue
+		}
+		if len(week.Days) == 0 || week.Days[len(week.Days)-1].Day != int(day.Int64) {
+			week.Days = append(week.Days, ProgramDay{Day: int(day.Int64), Title: dayTitle.String, Templates: []ProgramTemplate{}})
+		}
+		if workoutID.Valid {
+			d := &week.Days[len(week.Days)-1]
+			d.Templates = append(d.Templates, ProgramTemplate{WorkoutID: int(workoutID.Int64), Title: workoutTitle.String, DurationMinutes: int(duration.Int64)})
+		}
+	}
+	return program, rows.Err()
+}
+
+//! ListPrograms --> programs userID wrote or is enrolled in, newest first, without their weeks
+func (s *PostgresProgramStore) ListPrograms(userID int) ([]*Program, error) {
+	query := `SELECT ` + programColumns + `
+  FROM programs p
+  WHERE p.user_id = $1 OR EXISTS (SELECT 1 FROM program_enrollments e WHERE e.program_id = p.id AND e.user_id = $1)
+  ORDER BY p.created_at DESC, p.id DESC
+  `
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	programs := []*Program{}
+	for rows.Next() {
+		program, err := scanProgram(rows)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, program)
+	}
+	return programs, rows.Err()
+}
+
+//! UpdateProgram --> replaces the whole week tree, existing enrollments keep the plan they were given
+func (s *PostgresProgramStore) UpdateProgram(program *Program) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+  UPDATE programs
+  SET title = $1, description = $2, visibility = $3, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $4
+  RETURNING updated_at
+  `
+	err = tx.QueryRow(query, program.Title, program.Description, program.Visibility, program.ID).Scan(&program.UpdatedAt)
+	if err != nil {
+		return mapError(err)
+	}
+	_, err = tx.Exec(`DELETE FROM program_weeks WHERE program_id = $1`, program.ID)
+	if err != nil {
+		return err
+	}
+	err = insertProgramWeeks(tx, program)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresProgramStore) DeleteProgram(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM programs WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! Enroll --> the enrollment and the schedules that plan its days in one transaction, ErrConflict when already enrolled
+func (s *PostgresProgramStore) Enroll(e *ProgramEnrollment, schedules []*Schedule) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+  INSERT INTO program_enrollments (program_id, user_id, starts_on, time_of_day, timezone)
+  VALUES ($1, $2, $3, $4, $5)
+  RETURNING id, created_at
+  `
+	err = tx.QueryRow(query, e.ProgramID, e.UserID, e.StartsOn, e.TimeOfDay, e.Timezone).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return mapError(err)
+	}
+
+	for _, schedule := range schedules {
+		schedule.ProgramEnrollment = &e.ID
+		query := `
+    INSERT INTO schedules (user_id, title, description, duration_minutes, starts_at, rrule, program_enrollment_id)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    RETURNING id, created_at, updated_at
+    `
+		err = tx.QueryRow(query, schedule.UserID, schedule.Title, schedule.Description, schedule.DurationMinutes,
+			schedule.StartsAt, schedule.RRule, e.ID).Scan(&schedule.ID, &schedule.CreatedAt, &schedule.UpdatedAt)
+		if err != nil {
+			return mapError(err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresProgramStore) GetEnrollment(programID int64, userID int) (*ProgramEnrollment, error) {
+	e := &ProgramEnrollment{}
+	query := `
+  SELECT id, program_id, user_id, TO_CHAR(starts_on, 'YYYY-MM-DD'), time_of_day, timezone, created_at
+  FROM program_enrollments
+  WHERE program_id = $1 AND user_id = $2
+  `
+	err := s.db.QueryRow(query, programID, userID).Scan(&e.ID, &e.ProgramID, &e.UserID, &e.StartsOn, &e.TimeOfDay, &e.Timezone, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+//! Unenroll --> drops the enrollment and every schedule it planned (their occurrences cascade), ErrNotFound when not enrolled
+func (s *PostgresProgramStore) Unenroll(programID int64, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//* schedules first, deleting the enrollment would only unlink them
+	_, err = tx.Exec(`
+  DELETE FROM schedules
+  WHERE program_enrollment_id = (SELECT id FROM program_enrollments WHERE program_id = $1 AND user_id = $2)
+  `, programID, userID)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM program_enrollments WHERE program_id = $1 AND user_id = $2`, programID, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+)
+
+//! ReadRouter --> sends reads to the replica while it answers pings, everything else stays on the primary
+//? writes never go through here, and neither do reads that guard a write (GetWorkoutOwner, the workout service's Primary store),
+//? replica lag would turn those into 404s or write an old copy back over newer changes
+type ReadRouter struct {
+	monitor   *DBMonitor
+	logger    *log.Logger
+	fallbacks atomic.Int64
+}
+
+//! NewReadRouter --> monitor pings the replica, Run has to be started for outages to be noticed
+func NewReadRouter(monitor *DBMonitor, logger *log.Logger) *ReadRouter {
+	return &ReadRouter{monitor: monitor, logger: logger}
+}
+
+//! ReplicaUp --> false while the replica is unreachable, reads go to the primary meanwhile
+func (r *ReadRouter) ReplicaUp() bool {
+	return r.monitor.Up()
+}
+
+//! Fallbacks --> replica reads that failed and were answered by the primary instead
+func (r *ReadRouter) Fallbacks() int64 {
+	return r.fallbacks.Load()
+}
+
+//! Run --> replica health loop, same backoff as the primary monitor
+func (r *ReadRouter) Run(ctx context.Context) {
+	r.monitor.Run(ctx)
+}
+
+//! routeRead --> replica first when it is up, the primary answers when it is down, the query fails or the row isn't there yet
+//? a replica miss may just be lag (GET right after the POST), only the primary's ErrNotFound is trusted; it isn't counted as a failure
+func routeRead[T any](r *ReadRouter, op string, replica, primary func() (T, error)) (T, error) {
+	if r.monitor.Up() {
+		result, err := replica()
+		if err == nil {
+			return result, nil
+		}
+		if errors.Is(err, ErrNotFound) {
+			return primary()
+		}
+		r.fallbacks.Add(1)
+		r.logger.Printf("ERROR: replica %s, retrying on primary: %v", op, err)
+	}
+	return primary()
+}
+
+//! RoutedWorkoutStore --> GetWorkoutByID from the replica, the embedded primary store does the rest
+type RoutedWorkoutStore struct {
+	WorkoutStore
+	replica WorkoutStore
+	router  *ReadRouter
+}
+
+func NewRoutedWorkoutStore(primary, replica WorkoutStore, router *ReadRouter) *RoutedWorkoutStore {
+	return &RoutedWorkoutStore{WorkoutStore: primary, replica: replica, router: router}
+}
+
+func (s *RoutedWorkoutStore) GetWorkoutByID(id int64) (*Workout, error) {
+	return routeRead(s.router, "getWorkoutByID",
+		func() (*Workout, error) { return s.replica.GetWorkoutByID(id) },
+		func() (*Workout, error) { return s.WorkoutStore.GetWorkoutByID(id) })
+}
+
+//! RoutedFollowStore --> the activity feed from the replica, follows + fan-out on the primary
+type RoutedFollowStore struct {
+	FollowStore
+	replica FollowStore
+	router  *ReadRouter
+}
+
+func NewRoutedFollowStore(primary, replica FollowStore, router *ReadRouter) *RoutedFollowStore {
+	return &RoutedFollowStore{FollowStore: primary, replica: replica, router: router}
+}
+
+func (s *RoutedFollowStore) GetFeed(userID int64, before *FeedCursor, limit int) ([]*FeedItem, error) {
+	return routeRead(s.router, "getFeed",
+		func() ([]*FeedItem, error) { return s.replica.GetFeed(userID, before, limit) },
+		func() ([]*FeedItem, error) { return s.FollowStore.GetFeed(userID, before, limit) })
+}
+
+//! RoutedXPStore --> XP totals + leaderboards from the replica, ledger writes on the primary
+type RoutedXPStore struct {
+	XPStore
+	replica XPStore
+	router  *ReadRouter
+}
+
+func NewRoutedXPStore(primary, replica XPStore, router *ReadRouter) *RoutedXPStore {
+	return &RoutedXPStore{XPStore: primary, replica: replica, router: router}
+}
+
+func (s *RoutedXPStore) GetXP(userID int, decay XPDecay) (*XPTotal, error) {
+	return routeRead(s.router, "getXP",
+		func() (*XPTotal, error) { return s.replica.GetXP(userID, decay) },
+		func() (*XPTotal, error) { return s.XPStore.GetXP(userID, decay) })
+}
+
+func (s *RoutedXPStore) TopXP(decay XPDecay, limit int) ([]*XPTotal, error) {
+	return routeRead(s.router, "topXP",
+		func() ([]*XPTotal, error) { return s.replica.TopXP(decay, limit) },
+		func() ([]*XPTotal, error) { return s.XPStore.TopXP(decay, limit) })
+}
+
+//! RoutedGraphStore --> GraphQL lists + stats, read only so every method is routed
+type RoutedGraphStore struct {
+	primary GraphStore
+	replica GraphStore
+	router  *ReadRouter
+}
+
+func NewRoutedGraphStore(primary, replica GraphStore, router *ReadRouter) *RoutedGraphStore {
+	return &RoutedGraphStore{primary: primary, replica: replica, router: router}
+}
+
+func (s *RoutedGraphStore) GetUsersByIDs(ids []int64) (map[int64]*User, error) {
+	return routeRead(s.router, "getUsersByIDs",
+		func() (map[int64]*User, error) { return s.replica.GetUsersByIDs(ids) },
+		func() (map[int64]*User, error) { return s.primary.GetUsersByIDs(ids) })
+}
+
+func (s *RoutedGraphStore) GetEntriesByWorkoutIDs(ids []int64) (map[int64][]WorkoutEntry, error) {
+	return routeRead(s.router, "getEntriesByWorkoutIDs",
+		func() (map[int64][]WorkoutEntry, error) { return s.replica.GetEntriesByWorkoutIDs(ids) },
+		func() (map[int64][]WorkoutEntry, error) { return s.primary.GetEntriesByWorkoutIDs(ids) })
+}
+
+func (s *RoutedGraphStore) GetWorkoutStatsByUserIDs(viewerID int64, ids []int64) (map[int64]*WorkoutStats, error) {
+	return routeRead(s.router, "getWorkoutStatsByUserIDs",
+		func() (map[int64]*WorkoutStats, error) { return s.replica.GetWorkoutStatsByUserIDs(viewerID, ids) },
+		func() (map[int64]*WorkoutStats, error) { return s.primary.GetWorkoutStatsByUserIDs(viewerID, ids) })
+}
+
+func (s *RoutedGraphStore) ListVisibleWorkouts(viewerID, ownerID int64, limit, offset int) ([]*Workout, error) {
+	return routeRead(s.router, "listVisibleWorkouts",
+		func() ([]*Workout, error) { return s.replica.ListVisibleWorkouts(viewerID, ownerID, limit, offset) },
+		func() ([]*Workout, error) { return s.primary.ListVisibleWorkouts(viewerID, ownerID, limit, offset) })
+}
+
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * fakeWorkoutStore --> answers GetWorkoutByID with a fixed title or error, counts calls
+type fakeWorkoutStore struct {
+	WorkoutStore
+	title string
+	err   error
+	calls int
+}
+
+func (f *fakeWorkoutStore) GetWorkoutByID(id int64) (*Workout, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &Workout{ID: int(id), Title: f.title}, nil
+}
+
+func TestReadRouter(t *testing.T) {
+	monitor := NewDBMonitor(nil, 0, log.New(io.Discard, "", 0))
+	router := NewReadRouter(monitor, log.New(io.Discard, "", 0))
+	primary := &fakeWorkoutStore{title: "primary"}
+	replica := &fakeWorkoutStore{title: "replica"}
+	workouts := NewRoutedWorkoutStore(primary, replica, router)
+
+	// ? - healthy replica answers reads
+	workout, err := workouts.GetWorkoutByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, "replica", workout.Title)
+	assert.Equal(t, 0, primary.calls)
+
+	// ? - a failing replica query is retried on the primary
+	replica.err = errors.New("connection reset")
+	workout, err = workouts.GetWorkoutByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", workout.Title)
+	assert.Equal(t, int64(1), router.Fallbacks())
+
+	// ? - a row the replica doesn't have yet comes from the primary, lag isn't a failure
+	replica.err = ErrNotFound
+	workout, err = workouts.GetWorkoutByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", workout.Title)
+	assert.Equal(t, int64(1), router.Fallbacks())
+
+	// ? - missing on both --> the primary's ErrNotFound
+	primary.err = ErrNotFound
+	_, err = workouts.GetWorkoutByID(1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	primary.err = nil
+
+	// ? - replica marked down --> straight to the primary
+	monitor.up.Store(false)
+	replica.calls = 0
+	_, err = workouts.GetWorkoutByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, replica.calls)
+	assert.Equal(t, int64(1), router.Fallbacks())
+}
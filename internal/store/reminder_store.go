@@ -0,0 +1,172 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ! which days a reminder fires on
+const (
+	ReminderPlannedDays = "planned" //* only local days with a scheduled occurrence
+	ReminderEveryDay    = "every"
+)
+
+// ? - "remind me at RemindAt on Days", evaluated in Timezone by the reminders job
+type Reminder struct {
+	ID           int64      `json:"id"`
+	UserID       int        `json:"-"`
+	Name         string     `json:"name"`
+	RemindAt     string     `json:"remind_at"` // * local HH:MM
+	Timezone     string     `json:"timezone"`  // * IANA name, e.g. Europe/Berlin
+	Days         string     `json:"days"`
+	Email        bool       `json:"email"`
+	Enabled      bool       `json:"enabled"`
+	SnoozedUntil *time.Time `json:"snoozed_until"`
+	LastSentOn   *time.Time `json:"-"` // * local date, midnight UTC
+	LastSentAt   *time.Time `json:"last_sent_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// * holds the db connection for reminders
+type PostgresReminderStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new reminder store instance
+func NewPostgresReminderStore(db *sql.DB) *PostgresReminderStore {
+	return &PostgresReminderStore{db: db}
+}
+
+// ! ReminderStore interface --> reminder rules + the delivery bookkeeping the job needs
+type ReminderStore interface {
+	CreateReminder(*Reminder) error
+	GetReminder(id int64) (*Reminder, error)
+	ListReminders(userID int) ([]*Reminder, error)
+	ListEnabledReminders() ([]*Reminder, error)
+	UpdateReminder(*Reminder) error
+	DeleteReminder(id int64) error
+	SnoozeReminder(id int64, until time.Time) error
+	MarkReminderSent(id int64, day, at time.Time) (bool, error)
+}
+
+const reminderColumns = `id, user_id, name, remind_at, timezone, days, email, enabled, snoozed_until, last_sent_on, last_sent_at, created_at, updated_at`
+
+func scanReminder(row interface{ Scan(...any) error }) (*Reminder, error) {
+	r := &Reminder{}
+	err := row.Scan(&r.ID, &r.UserID, &r.Name, &r.RemindAt, &r.Timezone, &r.Days, &r.Email, &r.Enabled,
+		&r.SnoozedUntil, &r.LastSentOn, &r.LastSentAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *PostgresReminderStore) CreateReminder(r *Reminder) error {
+	query := `
+  INSERT INTO reminders (user_id, name, remind_at, timezone, days, email, enabled)
+  VALUES ($1, $2, $3, $4, $5, $6, $7)
+  RETURNING id, created_at, updated_at
+  `
+	return s.db.QueryRow(query, r.UserID, r.Name, r.RemindAt, r.Timezone, r.Days, r.Email, r.Enabled).
+		Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+}
+
+func (s *PostgresReminderStore) GetReminder(id int64) (*Reminder, error) {
+	r, err := scanReminder(s.db.QueryRow(`SELECT `+reminderColumns+` FROM reminders WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func (s *PostgresReminderStore) listReminders(query string, args ...any) ([]*Reminder, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Reminder{}
+	for rows.Next() {
+		r, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresReminderStore) ListReminders(userID int) ([]*Reminder, error) {
+	return s.listReminders(`SELECT `+reminderColumns+` FROM reminders WHERE user_id = $1 ORDER BY id`, userID)
+}
+
+// ! ListEnabledReminders --> every live user's enabled reminders, the job works out which are due
+// ? deleted accounts are skipped for the grace period, their reminders are only disabled by DeleteAccount
+func (s *PostgresReminderStore) ListEnabledReminders() ([]*Reminder, error) {
+	return s.listReminders(`SELECT ` + reminderColumns + ` FROM reminders
+  WHERE enabled AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)
+  ORDER BY id`)
+}
+
+// ! UpdateReminder --> delivery bookkeeping and snooze are left alone, today's reminder isn't sent twice
+func (s *PostgresReminderStore) UpdateReminder(r *Reminder) error {
+	query := `
+  UPDATE reminders
+  SET name = $2, remind_at = $3, timezone = $4, days = $5, email = $6, enabled = $7, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1
+  RETURNING updated_at
+  `
+	return s.db.QueryRow(query, r.ID, r.Name, r.RemindAt, r.Timezone, r.Days, r.Email, r.Enabled).Scan(&r.UpdatedAt)
+}
+
+func (s *PostgresReminderStore) DeleteReminder(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM reminders WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresReminderStore) SnoozeReminder(id int64, until time.Time) error {
+	result, err := s.db.Exec(`UPDATE reminders SET snoozed_until = $2 WHERE id = $1`, id, until)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ! MarkReminderSent --> claims a delivery, false when another run already sent it
+// ? an expired snooze always claims (the snoozed reminder comes back), otherwise once per local day
+func (s *PostgresReminderStore) MarkReminderSent(id int64, day, at time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+  UPDATE reminders
+  SET last_sent_on = $2, last_sent_at = $3, snoozed_until = NULL
+  WHERE id = $1 AND (
+    (snoozed_until IS NOT NULL AND snoozed_until <= $3)
+    OR (snoozed_until IS NULL AND (last_sent_on IS NULL OR last_sent_on < $2))
+  )
+  `, id, day, at)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// * holds the db connection for sandbox accounts
+type PostgresSandboxStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new sandbox store instance
+func NewPostgresSandboxStore(db *sql.DB) *PostgresSandboxStore {
+	return &PostgresSandboxStore{db: db}
+}
+
+//! SandboxStore interface --> throwaway accounts that delete themselves after expiresAt
+type SandboxStore interface {
+	CreateSandboxUser(user *User, expiresAt time.Time) error
+	PurgeExpiredSandboxes(now time.Time) (int64, error)
+}
+
+func (s *PostgresSandboxStore) CreateSandboxUser(user *User, expiresAt time.Time) error {
+	query := `
+  INSERT INTO users (username, email, password_hash, bio, sandbox_expires_at)
+  VALUES ($1, $2, $3, $4, $5)
+  RETURNING id, created_at, updated_at
+  `
+	return s.db.QueryRow(query, user.Username, user.Email, user.PasswordHash.hash, user.Bio, expiresAt).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+}
+
+//! PurgeExpiredSandboxes --> hard delete, workouts, tokens + everything else cascade like PurgeDeletedUsers
+func (s *PostgresSandboxStore) PurgeExpiredSandboxes(now time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM users WHERE sandbox_expires_at IS NOT NULL AND sandbox_expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,273 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! occurrence states
+const (
+	OccurrenceScheduled = "scheduled"
+	OccurrenceSkipped   = "skipped"
+)
+
+// ? - a recurring workout plan, occurrences are materialized ahead of time from RRule
+type Schedule struct {
+	ID                int        `json:"id"`
+	UserID            int        `json:"user_id"`
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	DurationMinutes   int        `json:"duration_minutes"`
+	StartsAt          time.Time  `json:"starts_at"`
+	RRule             string     `json:"rrule"`
+	MaterializedUntil *time.Time `json:"materialized_until"`
+	ProgramEnrollment *int64     `json:"program_enrollment_id,omitempty"` // * set on the days a program enrollment planned
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// ? - one planned session, Title/DurationMinutes fall back to the schedule unless edited
+type Occurrence struct {
+	ID              int       `json:"id"`
+	ScheduleID      int       `json:"schedule_id"`
+	UserID          int       `json:"user_id"`
+	OriginalAt      time.Time `json:"original_at"` // * slot the rule generated, stays put when the occurrence moves
+	OccursAt        time.Time `json:"occurs_at"`
+	Title           string    `json:"title"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Status          string    `json:"status"`
+	Edited          bool      `json:"edited"`
+}
+
+// * holds the db connection for schedule operations
+type PostgresScheduleStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new schedule store instance
+func NewPostgresScheduleStore(db *sql.DB) *PostgresScheduleStore {
+	return &PostgresScheduleStore{db: db}
+}
+
+//! ScheduleStore interface --> contract for recurring schedules and their occurrences
+type ScheduleStore interface {
+	CreateSchedule(*Schedule) error
+	GetSchedule(id int64) (*Schedule, error)
+	ListSchedules(userID int) ([]*Schedule, error)
+	UpdateSchedule(*Schedule) error
+	DeleteSchedule(id int64) error
+	ListSchedulesToMaterialize(horizon time.Time, limit int) ([]*Schedule, error)
+	SaveOccurrences(schedule *Schedule, times []time.Time, until time.Time) error
+	ListOccurrences(userID int, from, to time.Time) ([]*Occurrence, error)
+	GetOccurrence(id int64) (*Occurrence, error)
+	UpdateOccurrence(*Occurrence) error
+}
+
+const scheduleColumns = `id, user_id, title, description, duration_minutes, starts_at, rrule, materialized_until, program_enrollment_id, created_at, updated_at`
+
+func scanSchedule(row interface{ Scan(...any) error }) (*Schedule, error) {
+	schedule := &Schedule{}
+	err := row.Scan(&schedule.ID, &schedule.UserID, &schedule.Title, &schedule.Description, &schedule.DurationMinutes,
+		&schedule.StartsAt, &schedule.RRule, &schedule.MaterializedUntil, &schedule.ProgramEnrollment, &schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *PostgresScheduleStore) CreateSchedule(schedule *Schedule) error {
+	query := `
+  INSERT INTO schedules (user_id, title, description, duration_minutes, starts_at, rrule)
+  VALUES ($1, $2, $3, $4, $5, $6)
+  RETURNING id, created_at, updated_at
+  `
+	return s.db.QueryRow(query, schedule.UserID, schedule.Title, schedule.Description, schedule.DurationMinutes,
+		schedule.StartsAt, schedule.RRule).Scan(&schedule.ID, &schedule.CreatedAt, &schedule.UpdatedAt)
+}
+
+func (s *PostgresScheduleStore) GetSchedule(id int64) (*Schedule, error) {
+	schedule, err := scanSchedule(s.db.QueryRow(`SELECT `+scheduleColumns+` FROM schedules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *PostgresScheduleStore) ListSchedules(userID int) ([]*Schedule, error) {
+	return s.querySchedules(`SELECT `+scheduleColumns+` FROM schedules WHERE user_id = $1 ORDER BY starts_at, id`, userID)
+}
+
+func (s *PostgresScheduleStore) querySchedules(query string, args ...any) ([]*Schedule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+//! UpdateSchedule --> editing the whole series drops every future occurrence (including one-off edits)
+//? the materializer regenerates them from the new rule on its next pass
+func (s *PostgresScheduleStore) UpdateSchedule(schedule *Schedule) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+  UPDATE schedules
+  SET title = $1, description = $2, duration_minutes = $3, starts_at = $4, rrule = $5,
+      materialized_until = NULL, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $6
+  RETURNING updated_at
+  `
+	err = tx.QueryRow(query, schedule.Title, schedule.Description, schedule.DurationMinutes, schedule.StartsAt,
+		schedule.RRule, schedule.ID).Scan(&schedule.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	schedule.MaterializedUntil = nil
+
+	_, err = tx.Exec(`DELETE FROM schedule_occurrences WHERE schedule_id = $1 AND original_at >= CURRENT_TIMESTAMP`, schedule.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresScheduleStore) DeleteSchedule(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM schedules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! ListSchedulesToMaterialize --> schedules whose occurrences don't reach the horizon yet
+func (s *PostgresScheduleStore) ListSchedulesToMaterialize(horizon time.Time, limit int) ([]*Schedule, error) {
+	query := `SELECT ` + scheduleColumns + `
+  FROM schedules
+  WHERE materialized_until IS NULL OR materialized_until < $1
+  ORDER BY materialized_until NULLS FIRST, id
+  LIMIT $2
+  `
+	return s.querySchedules(query, horizon, limit)
+}
+
+//! SaveOccurrences --> inserts generated slots (existing ones are left alone) and moves the high-water mark
+func (s *PostgresScheduleStore) SaveOccurrences(schedule *Schedule, times []time.Time, until time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, t := range times {
+		query := `
+    INSERT INTO schedule_occurrences (schedule_id, user_id, original_at, occurs_at)
+    VALUES ($1, $2, $3, $3)
+    ON CONFLICT (schedule_id, original_at) DO NOTHING
+    `
+		_, err = tx.Exec(query, schedule.ID, schedule.UserID, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE schedules SET materialized_until = $1 WHERE id = $2`, until, schedule.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// * occurrenceColumns --> overrides fall back to the schedule's values
+const occurrenceColumns = `
+  o.id, o.schedule_id, o.user_id, o.original_at, o.occurs_at,
+  COALESCE(o.title, s.title), COALESCE(o.duration_minutes, s.duration_minutes), o.status, o.edited
+`
+
+func scanOccurrence(row interface{ Scan(...any) error }) (*Occurrence, error) {
+	o := &Occurrence{}
+	err := row.Scan(&o.ID, &o.ScheduleID, &o.UserID, &o.OriginalAt, &o.OccursAt, &o.Title, &o.DurationMinutes, &o.Status, &o.Edited)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (s *PostgresScheduleStore) ListOccurrences(userID int, from, to time.Time) ([]*Occurrence, error) {
+	query := `SELECT` + occurrenceColumns + `
+  FROM schedule_occurrences o
+  INNER JOIN schedules s ON s.id = o.schedule_id
+  WHERE o.user_id = $1 AND o.occurs_at >= $2 AND o.occurs_at < $3
+  ORDER BY o.occurs_at, o.id
+  `
+	rows, err := s.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occurrences := []*Occurrence{}
+	for rows.Next() {
+		o, err := scanOccurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, o)
+	}
+	return occurrences, rows.Err()
+}
+
+func (s *PostgresScheduleStore) GetOccurrence(id int64) (*Occurrence, error) {
+	query := `SELECT` + occurrenceColumns + `
+  FROM schedule_occurrences o
+  INNER JOIN schedules s ON s.id = o.schedule_id
+  WHERE o.id = $1
+  `
+	o, err := scanOccurrence(s.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+//! UpdateOccurrence --> single-occurrence edit or skip, marked edited so it's recognisable as an exception
+func (s *PostgresScheduleStore) UpdateOccurrence(o *Occurrence) error {
+	query := `
+  UPDATE schedule_occurrences
+  SET occurs_at = $1, title = $2, duration_minutes = $3, status = $4, edited = TRUE
+  WHERE id = $5
+  `
+	_, err := s.db.Exec(query, o.OccursAt, o.Title, o.DurationMinutes, o.Status, o.ID)
+	if err != nil {
+		return err
+	}
+	o.Edited = true
+	return nil
+}
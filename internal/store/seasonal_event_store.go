@@ -0,0 +1,362 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+//! what a seasonal event ranks participants by
+const (
+	EventMetricWorkouts        = "workouts"
+	EventMetricDurationMinutes = "duration_minutes"
+	EventMetricCalories        = "calories"
+	EventMetricVolumeKG        = "volume_kg" // * sets * reps * weight across all entries
+)
+
+//! event lifecycle, derived from the window and closed_at
+const (
+	EventStatusUpcoming = "upcoming"
+	EventStatusActive   = "active"
+	EventStatusEnded    = "ended" // * past ends_at, waiting for the close job
+	EventStatusClosed   = "closed"
+)
+
+// * eventMetricExpr --> per-metric aggregate over the participant's workouts (w), whitelisted so it's safe to splice in
+var eventMetricExpr = map[string]string{
+	EventMetricWorkouts:        `COUNT(w.id)`,
+	EventMetricDurationMinutes: `SUM(w.duration_minutes)`,
+	EventMetricCalories:        `SUM(COALESCE(w.calories_burned, 0))`,
+	EventMetricVolumeKG: `SUM((SELECT COALESCE(SUM(e.sets * COALESCE(e.reps, 0) * COALESCE(e.weight, 0)), 0)
+                               FROM workout_entries e WHERE e.workout_id = w.id))`,
+}
+
+//! ValidEventMetric --> true for metrics GetStandings knows how to score
+func ValidEventMetric(metric string) bool {
+	_, ok := eventMetricExpr[metric]
+	return ok
+}
+
+// ? - admin-defined, time-boxed challenge (e.g. "January Volume Challenge")
+type SeasonalEvent struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Metric      string     `json:"metric"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      time.Time  `json:"ends_at"`
+	BadgeColor  string     `json:"badge_color"`
+	Status      string     `json:"status"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	CreatedBy   *int       `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ? - one row of an event's standings, frozen into seasonal_event_results at close
+type EventStanding struct {
+	Rank     int     `json:"rank"`
+	UserID   int     `json:"user_id"`
+	Username string  `json:"username"`
+	Score    float64 `json:"score"`
+}
+
+// * holds the db connection for seasonal event operations
+type PostgresSeasonalEventStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new seasonal event store instance
+func NewPostgresSeasonalEventStore(db *sql.DB) *PostgresSeasonalEventStore {
+	return &PostgresSeasonalEventStore{db: db}
+}
+
+//! SeasonalEventStore interface --> contract for seasonal events, enrollment and standings
+type SeasonalEventStore interface {
+	CreateSeasonalEvent(*SeasonalEvent) error
+	GetSeasonalEvent(id int64) (*SeasonalEvent, error)
+	ListSeasonalEvents(limit int) ([]*SeasonalEvent, error)
+	UpdateSeasonalEvent(*SeasonalEvent) error
+	DeleteSeasonalEvent(id int64) error
+	EnrollOptedInUsers() (int64, error)
+	GetStandings(event *SeasonalEvent, limit int) ([]*EventStanding, error)
+	GetStanding(event *SeasonalEvent, userID int) (*EventStanding, error)
+	ListEventsToClose(now time.Time) ([]*SeasonalEvent, error)
+	CloseSeasonalEvent(id int64) ([]*EventStanding, error)
+}
+
+const seasonalEventColumns = `
+  id, name, description, metric, starts_at, ends_at, badge_color,
+  CASE
+    WHEN closed_at IS NOT NULL THEN 'closed'
+    WHEN CURRENT_TIMESTAMP < starts_at THEN 'upcoming'
+    WHEN CURRENT_TIMESTAMP < ends_at THEN 'active'
+    ELSE 'ended'
+  END,
+  closed_at, created_by, created_at, updated_at
+`
+
+func scanSeasonalEvent(row interface{ Scan(...any) error }) (*SeasonalEvent, error) {
+	event := &SeasonalEvent{}
+	err := row.Scan(&event.ID, &event.Name, &event.Description, &event.Metric, &event.StartsAt, &event.EndsAt,
+		&event.BadgeColor, &event.Status, &event.ClosedAt, &event.CreatedBy, &event.CreatedAt, &event.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *PostgresSeasonalEventStore) CreateSeasonalEvent(event *SeasonalEvent) error {
+	query := `
+  INSERT INTO seasonal_events (name, description, metric, starts_at, ends_at, badge_color, created_by)
+  VALUES ($1, $2, $3, $4, $5, $6, $7)
+  RETURNING` + seasonalEventColumns
+	created, err := scanSeasonalEvent(s.db.QueryRow(query, event.Name, event.Description, event.Metric, event.StartsAt,
+		event.EndsAt, event.BadgeColor, event.CreatedBy))
+	if err != nil {
+		return err
+	}
+	*event = *created
+	return nil
+}
+
+func (s *PostgresSeasonalEventStore) GetSeasonalEvent(id int64) (*SeasonalEvent, error) {
+	event, err := scanSeasonalEvent(s.db.QueryRow(`SELECT`+seasonalEventColumns+`FROM seasonal_events WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+//! ListSeasonalEvents --> running and upcoming first, then the most recently finished
+func (s *PostgresSeasonalEventStore) ListSeasonalEvents(limit int) ([]*SeasonalEvent, error) {
+	query := `SELECT` + seasonalEventColumns + `
+  FROM seasonal_events
+  ORDER BY (ends_at < CURRENT_TIMESTAMP), starts_at DESC, id DESC
+  LIMIT $1
+  `
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*SeasonalEvent{}
+	for rows.Next() {
+		event, err := scanSeasonalEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+//! UpdateSeasonalEvent --> closed events are final, ErrNotFound when the event is gone or closed
+func (s *PostgresSeasonalEventStore) UpdateSeasonalEvent(event *SeasonalEvent) error {
+	query := `
+  UPDATE seasonal_events
+  SET name = $1, description = $2, metric = $3, starts_at = $4, ends_at = $5, badge_color = $6,
+      updated_at = CURRENT_TIMESTAMP
+  WHERE id = $7 AND closed_at IS NULL
+  RETURNING` + seasonalEventColumns
+	updated, err := scanSeasonalEvent(s.db.QueryRow(query, event.Name, event.Description, event.Metric, event.StartsAt,
+		event.EndsAt, event.BadgeColor, event.ID))
+	if err != nil {
+		return mapError(err)
+	}
+	*event = *updated
+	return nil
+}
+
+func (s *PostgresSeasonalEventStore) DeleteSeasonalEvent(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM seasonal_events WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//! EnrollOptedInUsers --> every opted-in user joins every event that hasn't ended yet, safe to re-run
+func (s *PostgresSeasonalEventStore) EnrollOptedInUsers() (int64, error) {
+	query := `
+  INSERT INTO seasonal_event_participants (event_id, user_id)
+  SELECT e.id, p.user_id
+  FROM seasonal_events e
+  CROSS JOIN user_profiles p
+  WHERE p.events_opt_in AND e.closed_at IS NULL AND e.ends_at > CURRENT_TIMESTAMP
+  ON CONFLICT DO NOTHING
+  `
+	result, err := s.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+//! standingsQuery --> live ranking of every participant by the event's metric, flagged workouts never count
+func standingsQuery(event *SeasonalEvent) (string, error) {
+	expr, ok := eventMetricExpr[event.Metric]
+	if !ok {
+		return "", fmt.Errorf("unknown event metric %q", event.Metric)
+	}
+	return `
+  WITH scores AS (
+    SELECT p.user_id, u.username, COALESCE(` + expr + `, 0)::float8 AS score
+    FROM seasonal_event_participants p
+    INNER JOIN users u ON u.id = p.user_id
+    LEFT JOIN workouts w ON w.user_id = p.user_id AND NOT w.flagged
+      AND w.created_at >= $2 AND w.created_at < $3
+    WHERE p.event_id = $1
+    GROUP BY p.user_id, u.username
+  )
+  SELECT RANK() OVER (ORDER BY score DESC)::int AS rank, user_id, username, score
+  FROM scores
+  `, nil
+}
+
+// * frozenStandingsQuery --> results written at close
+const frozenStandingsQuery = `
+  SELECT r.rank, r.user_id, u.username, r.score
+  FROM seasonal_event_results r
+  INNER JOIN users u ON u.id = r.user_id
+  WHERE r.event_id = $1
+`
+
+func scanStandings(rows *sql.Rows) ([]*EventStanding, error) {
+	defer rows.Close()
+
+	standings := []*EventStanding{}
+	for rows.Next() {
+		standing := &EventStanding{}
+		err := rows.Scan(&standing.Rank, &standing.UserID, &standing.Username, &standing.Score)
+		if err != nil {
+			return nil, err
+		}
+		standings = append(standings, standing)
+	}
+	return standings, rows.Err()
+}
+
+//! GetStandings --> top participants, live while the event runs and frozen once it's closed
+func (s *PostgresSeasonalEventStore) GetStandings(event *SeasonalEvent, limit int) ([]*EventStanding, error) {
+	if event.ClosedAt != nil {
+		rows, err := s.db.Query(frozenStandingsQuery+` ORDER BY r.rank, r.user_id LIMIT $2`, event.ID, limit)
+		if err != nil {
+			return nil, err
+		}
+		return scanStandings(rows)
+	}
+
+	query, err := standingsQuery(event)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(query+` ORDER BY rank, user_id LIMIT $4`, event.ID, event.StartsAt, event.EndsAt, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanStandings(rows)
+}
+
+//! GetStanding --> one user's row, (nil, nil) when they aren't taking part (or earned nothing in a closed event)
+func (s *PostgresSeasonalEventStore) GetStanding(event *SeasonalEvent, userID int) (*EventStanding, error) {
+	var rows *sql.Rows
+	var err error
+	if event.ClosedAt != nil {
+		rows, err = s.db.Query(frozenStandingsQuery+` AND r.user_id = $2`, event.ID, userID)
+	} else {
+		query, qErr := standingsQuery(event)
+		if qErr != nil {
+			return nil, qErr
+		}
+		rows, err = s.db.Query(`SELECT * FROM (`+query+`) ranked WHERE user_id = $4`, event.ID, event.StartsAt, event.EndsAt, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	standings, err := scanStandings(rows)
+	if err != nil || len(standings) == 0 {
+		return nil, err
+	}
+	return standings[0], nil
+}
+
+//! ListEventsToClose --> finished events the close job hasn't processed yet
+func (s *PostgresSeasonalEventStore) ListEventsToClose(now time.Time) ([]*SeasonalEvent, error) {
+	query := `SELECT` + seasonalEventColumns + `
+  FROM seasonal_events
+  WHERE closed_at IS NULL AND ends_at <= $1
+  ORDER BY ends_at, id
+  `
+	rows, err := s.db.Query(query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*SeasonalEvent{}
+	for rows.Next() {
+		event, err := scanSeasonalEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+//! CloseSeasonalEvent --> freezes the final standings and marks the event closed in one transaction
+//? only participants who scored get a result row (and with it a badge), returns nil if someone else closed it first
+func (s *PostgresSeasonalEventStore) CloseSeasonalEvent(id int64) ([]*EventStanding, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	event, err := scanSeasonalEvent(tx.QueryRow(`
+  UPDATE seasonal_events
+  SET closed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1 AND closed_at IS NULL
+  RETURNING`+seasonalEventColumns, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := standingsQuery(event)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`
+  INSERT INTO seasonal_event_results (event_id, user_id, rank, score)
+  SELECT $1, ranked.user_id, ranked.rank, ranked.score
+  FROM (`+query+`) ranked
+  WHERE ranked.score > 0
+  RETURNING rank, user_id, '', score
+  `, event.ID, event.StartsAt, event.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	results, err := scanStandings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, tx.Commit()
+}
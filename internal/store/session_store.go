@@ -0,0 +1,93 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fem/internal/tokens"
+	"time"
+)
+
+// ! SessionTouchInterval --> a session's last use is written at most this often (unless the IP changes)
+const SessionTouchInterval = time.Minute
+
+// ? - one auth token, as the user sees it in their session list
+type Session struct {
+	ID         int64      `json:"id"`
+	Hash       []byte     `json:"-"`
+	Current    bool       `json:"current"` // * the token this request came in with
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	Expiry     time.Time  `json:"expiry"`
+}
+
+// * holds the db connection for sessions
+type PostgresSessionStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new session store instance
+func NewPostgresSessionStore(db *sql.DB) *PostgresSessionStore {
+	return &PostgresSessionStore{db: db}
+}
+
+// ! SessionStore interface --> auth tokens with their metadata, logout-everywhere stays TokenStore.DeleteAllTokensForUser
+type SessionStore interface {
+	//* unexpired auth tokens of userID, most recently used first
+	ListSessions(userID int, now time.Time) ([]*Session, error)
+	//* records where token was used from, skipped within SessionTouchInterval of the last write from the same IP
+	TouchSession(token, ip, userAgent string, at time.Time) error
+	//* ErrNotFound when userID has no such session
+	DeleteSession(userID int, id int64) error
+}
+
+func (s *PostgresSessionStore) ListSessions(userID int, now time.Time) ([]*Session, error) {
+	query := `
+  SELECT id, hash, created_at, last_used_at, ip, user_agent, expiry
+  FROM tokens
+  WHERE user_id = $1 AND scope = $2 AND expiry > $3
+  ORDER BY COALESCE(last_used_at, created_at) DESC, id DESC`
+	rows, err := s.db.Query(query, userID, tokens.ScopeAuth, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		session := &Session{}
+		err = rows.Scan(&session.ID, &session.Hash, &session.CreatedAt, &session.LastUsedAt, &session.IP, &session.UserAgent, &session.Expiry)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *PostgresSessionStore) TouchSession(token, ip, userAgent string, at time.Time) error {
+	hash := sha256.Sum256([]byte(token))
+	query := `
+  UPDATE tokens SET last_used_at = $3, ip = $4, user_agent = $5
+  WHERE hash = $1 AND scope = $2
+    AND (last_used_at IS NULL OR last_used_at < $6 OR ip <> $4)`
+	_, err := s.db.Exec(query, hash[:], tokens.ScopeAuth, at, ip, userAgent, at.Add(-SessionTouchInterval))
+	return err
+}
+
+func (s *PostgresSessionStore) DeleteSession(userID int, id int64) error {
+	query := `DELETE FROM tokens WHERE user_id = $1 AND id = $2 AND scope = $3`
+	result, err := s.db.Exec(query, userID, id, tokens.ScopeAuth)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
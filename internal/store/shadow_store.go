@@ -0,0 +1,79 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// ? - one mirrored request whose secondary response didn't match
+type ShadowDiff struct {
+	ID            int64     `json:"id"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	PrimaryStatus int       `json:"primary_status"`
+	ShadowStatus  *int      `json:"shadow_status"` // * nil when the secondary couldn't be reached
+	Differences   []string  `json:"differences"`
+	Error         *string   `json:"error"`
+	PrimaryMS     int       `json:"primary_ms"`
+	ShadowMS      int       `json:"shadow_ms"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// * holds the db connection for shadow traffic results
+type PostgresShadowStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new shadow store instance
+func NewPostgresShadowStore(db *sql.DB) *PostgresShadowStore {
+	return &PostgresShadowStore{db: db}
+}
+
+//! ShadowStore interface --> mismatches found by the traffic mirror
+type ShadowStore interface {
+	RecordShadowDiff(diff *ShadowDiff) error
+	ListShadowDiffs(limit int) ([]*ShadowDiff, error)
+}
+
+func (s *PostgresShadowStore) RecordShadowDiff(diff *ShadowDiff) error {
+	query := `
+  INSERT INTO shadow_diffs (method, path, primary_status, shadow_status, differences, error, primary_ms, shadow_ms)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+  RETURNING id, created_at
+  `
+	return s.db.QueryRow(query, diff.Method, diff.Path, diff.PrimaryStatus, diff.ShadowStatus, diff.Differences, diff.Error,
+		diff.PrimaryMS, diff.ShadowMS).Scan(&diff.ID, &diff.CreatedAt)
+}
+
+//! ListShadowDiffs --> newest first
+func (s *PostgresShadowStore) ListShadowDiffs(limit int) ([]*ShadowDiff, error) {
+	query := `
+  SELECT id, method, path, primary_status, shadow_status, array_to_json(differences), error, primary_ms, shadow_ms, created_at
+  FROM shadow_diffs
+  ORDER BY created_at DESC, id DESC
+  LIMIT $1
+  `
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	diffs := []*ShadowDiff{}
+	for rows.Next() {
+		diff := &ShadowDiff{}
+		var differences []byte
+		err = rows.Scan(&diff.ID, &diff.Method, &diff.Path, &diff.PrimaryStatus, &diff.ShadowStatus, &differences, &diff.Error,
+			&diff.PrimaryMS, &diff.ShadowMS, &diff.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(differences, &diff.Differences)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, rows.Err()
+}
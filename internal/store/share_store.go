@@ -0,0 +1,70 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"time"
+)
+
+// ? - public read-only link to one workout
+type WorkoutShare struct {
+	ID        int       `json:"id"`
+	WorkoutID int       `json:"workout_id"`
+	TokenHash []byte    `json:"-"` // * plaintext only ever goes back to the owner once
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// * holds the db connection for share link operations
+type PostgresShareStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new share store instance
+func NewPostgresShareStore(db *sql.DB) *PostgresShareStore {
+	return &PostgresShareStore{db: db}
+}
+
+//! ShareStore interface --> contract for public workout share links
+type ShareStore interface {
+	CreateShare(*WorkoutShare) error
+	GetSharedWorkoutID(plaintext string) (int64, error)
+	RevokeShares(workoutID int64) (int64, error)
+}
+
+func (s *PostgresShareStore) CreateShare(share *WorkoutShare) error {
+	query := `
+  INSERT INTO workout_shares (workout_id, token_hash, expires_at)
+  VALUES ($1, $2, $3)
+  RETURNING id, created_at
+  `
+	return s.db.QueryRow(query, share.WorkoutID, share.TokenHash, share.ExpiresAt).Scan(&share.ID, &share.CreatedAt)
+}
+
+//! GetSharedWorkoutID --> workout behind a share token, 0 when unknown, expired or revoked
+func (s *PostgresShareStore) GetSharedWorkoutID(plaintext string) (int64, error) {
+	tokenHash := sha256.Sum256([]byte(plaintext))
+	var workoutID int64
+	query := `
+  SELECT workout_id
+  FROM workout_shares
+  WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > $2
+  `
+	err := s.db.QueryRow(query, tokenHash[:], time.Now()).Scan(&workoutID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return workoutID, nil
+}
+
+//! RevokeShares --> kills every active link of a workout, returns how many were revoked
+func (s *PostgresShareStore) RevokeShares(workoutID int64) (int64, error) {
+	result, err := s.db.Exec(`UPDATE workout_shares SET revoked_at = CURRENT_TIMESTAMP WHERE workout_id = $1 AND revoked_at IS NULL`, workoutID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
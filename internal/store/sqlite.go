@@ -0,0 +1,63 @@
+package store
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed sqlite_schema.sql
+var sqliteSchema string
+
+//! OpenSQLite --> single-file database for demos + local development, no postgres needed
+//? only users, tokens and workouts live here, the other stores need postgres (DB_DRIVER=postgres)
+func OpenSQLite(path string) (*sql.DB, error) {
+	//* foreign keys are off by default in sqlite, the cascades below rely on them
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite : open %w", err)
+	}
+	//* one writer at a time anyway, a single connection avoids SQLITE_BUSY between our own connections
+	db.SetMaxOpenConns(1)
+
+	//! schema bootstrap --> CREATE ... IF NOT EXISTS, safe on every start
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite : schema %w", err)
+	}
+	err = addSQLiteColumns(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite : schema %w", err)
+	}
+	return db, nil
+}
+
+//! sqliteAddedColumns --> columns added to sqlite_schema.sql after release, CREATE IF NOT EXISTS skips them on older files
+var sqliteAddedColumns = []struct{ table, column, definition string }{
+	{"workout_entries", "distance", "REAL"},
+	{"workouts", "performed_at", "TIMESTAMP"},
+}
+
+//! addSQLiteColumns --> ALTER TABLE for every added column the file doesn't have yet
+func addSQLiteColumns(db *sql.DB) error {
+	for _, c := range sqliteAddedColumns {
+		var exists int
+		err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+-- single-file schema for DB_DRIVER=sqlite, mirrors what the postgres migrations build for these tables
+-- timestamps are written by the stores in UTC, token expiry is unix seconds so comparisons stay numeric
+
+CREATE TABLE IF NOT EXISTS users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  username TEXT UNIQUE NOT NULL,
+  email TEXT UNIQUE NOT NULL,
+  password_hash BLOB NOT NULL,
+  bio TEXT NOT NULL DEFAULT '',
+  is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+  deleted_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS workouts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  duration_minutes INTEGER NOT NULL,
+  calories_burned INTEGER NOT NULL DEFAULT 0,
+  calories_estimated BOOLEAN NOT NULL DEFAULT FALSE,
+  visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'followers', 'public')),
+  flagged BOOLEAN NOT NULL DEFAULT FALSE,
+  verified BOOLEAN NOT NULL DEFAULT FALSE,
+  external_source TEXT,
+  external_id TEXT,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  performed_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_workouts_user_created ON workouts (user_id, created_at DESC, id DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workouts_external ON workouts (user_id, external_source, external_id) WHERE external_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS workout_entries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_id INTEGER NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+  exercise_name TEXT NOT NULL,
+  sets INTEGER NOT NULL,
+  reps INTEGER,
+  duration_seconds INTEGER,
+  weight REAL,
+  distance REAL,
+  notes TEXT NOT NULL DEFAULT '',
+  order_index INTEGER NOT NULL,
+  CONSTRAINT valid_workout_entry CHECK (
+    (reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+    (reps IS NULL OR duration_seconds IS NULL)
+  )
+);
+CREATE INDEX IF NOT EXISTS idx_workout_entries_workout ON workout_entries (workout_id, order_index);
+
+CREATE TABLE IF NOT EXISTS tokens (
+  hash BLOB PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  expiry INTEGER NOT NULL,
+  scope TEXT NOT NULL
+);
+
+-- GET /sync change log, see migrations/00044_workout_changes.sql; sqlite has one writer, so seq order is commit order
+CREATE TABLE IF NOT EXISTS workout_changes (
+  seq INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  deleted BOOLEAN NOT NULL DEFAULT FALSE,
+  changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workout_changes_workout ON workout_changes (workout_id);
+CREATE INDEX IF NOT EXISTS idx_workout_changes_user_seq ON workout_changes (user_id, seq);
+
+CREATE TRIGGER IF NOT EXISTS workouts_record_insert AFTER INSERT ON workouts BEGIN
+  DELETE FROM workout_changes WHERE workout_id = NEW.id;
+  INSERT INTO workout_changes (workout_id, user_id) VALUES (NEW.id, NEW.user_id);
+END;
+CREATE TRIGGER IF NOT EXISTS workouts_record_update AFTER UPDATE ON workouts BEGIN
+  DELETE FROM workout_changes WHERE workout_id = NEW.id;
+  INSERT INTO workout_changes (workout_id, user_id) VALUES (NEW.id, NEW.user_id);
+END;
+CREATE TRIGGER IF NOT EXISTS workouts_record_delete AFTER DELETE ON workouts BEGIN
+  DELETE FROM workout_changes WHERE workout_id = OLD.id;
+  INSERT INTO workout_changes (workout_id, user_id, deleted) VALUES (OLD.id, OLD.user_id, TRUE);
+END;
+
+-- workouts from files created before the log existed
+INSERT INTO workout_changes (workout_id, user_id)
+SELECT id, user_id FROM workouts WHERE id NOT IN (SELECT workout_id FROM workout_changes) ORDER BY id;
+
+-- workout events waiting for the outbox relay, see migrations/00045_events_outbox.sql
+CREATE TABLE IF NOT EXISTS events_outbox (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  event_type TEXT NOT NULL,
+  user_id INTEGER NOT NULL,
+  workout_id INTEGER NOT NULL DEFAULT 0,
+  ref TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+  published_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_events_outbox_pending ON events_outbox (id) WHERE published_at IS NULL;
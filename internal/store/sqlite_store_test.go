@@ -0,0 +1,261 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fem/internal/events"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! setupSQLiteDB --> fresh file database per test, no postgres needed
+func setupSQLiteDB(t *testing.T) *sql.DB {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "fittrack.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteStores(t *testing.T) {
+	db := setupSQLiteDB(t)
+	users := NewSQLiteUserStore(db)
+	tokenStore := NewSQLiteTokenStore(db)
+	workouts := NewSQLiteWorkoutStore(db)
+
+	// * user + token round trip
+	user := &User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("correct horse"))
+	require.NoError(t, users.CreateUser(user))
+	assert.NotZero(t, user.ID)
+
+	found, err := users.GetUserByUsername("ana")
+	require.NoError(t, err)
+	ok, err := found.PasswordHash.Matches("correct horse")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	token, err := tokenStore.CreateNewToken(user.ID, time.Hour, "authentication")
+	require.NoError(t, err)
+	authed, err := users.GetUserToken("authentication", token.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, authed)
+	assert.Equal(t, user.ID, authed.ID)
+
+	expired, err := tokenStore.CreateNewToken(user.ID, -time.Minute, "authentication")
+	require.NoError(t, err)
+	authed, err = users.GetUserToken("authentication", expired.Plaintext)
+	require.NoError(t, err)
+	assert.Nil(t, authed)
+	purged, err := tokenStore.DeleteExpiredTokens()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	// * workout create / read / update / delete
+	workout := &Workout{
+		UserID:          user.ID,
+		Title:           "push day",
+		DurationMinutes: 60,
+		Entries: []WorkoutEntry{
+			{ExerciseName: "bench press", Sets: 4, Reps: intPointer(10), Weight: floatPointer(80.5), OrderIndex: 1},
+			{ExerciseName: "plank", Sets: 3, DurationSeconds: intPointer(60), OrderIndex: 2},
+		},
+	}
+	created, err := workouts.CreateWorkout(workout)
+	require.NoError(t, err)
+	assert.Equal(t, VisibilityPrivate, created.Visibility)
+
+	retrieved, err := workouts.GetWorkoutByID(int64(created.ID))
+	require.NoError(t, err)
+	assert.Equal(t, created.Entries, retrieved.Entries)
+	assert.WithinDuration(t, created.CreatedAt, retrieved.CreatedAt, time.Second)
+
+	retrieved.Entries = retrieved.Entries[1:]
+	require.NoError(t, workouts.UpdateWorkout(retrieved))
+	updated, err := workouts.GetWorkoutByID(int64(created.ID))
+	require.NoError(t, err)
+	assert.Len(t, updated.Entries, 1)
+	_, err = workouts.GetWorkoutByID(int64(created.ID) + 1000)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// ? - the entry CHECK constraint holds here too, a bad import row only drops itself
+	rowErrs, err := workouts.ImportWorkouts([]*Workout{
+		{UserID: user.ID, Title: "bad", DurationMinutes: 10, Entries: []WorkoutEntry{{ExerciseName: "nothing", Sets: 1, OrderIndex: 1}}},
+		{UserID: user.ID, Title: "good", DurationMinutes: 10},
+	})
+	require.NoError(t, err)
+	assert.Error(t, rowErrs[0])
+	assert.NoError(t, rowErrs[1])
+
+	inserted, err := workouts.CreateExternalWorkout(&Workout{UserID: user.ID, Title: "ride", DurationMinutes: 30}, ExternalRef{Source: "strava", ID: "1"})
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	inserted, err = workouts.CreateExternalWorkout(&Workout{UserID: user.ID, Title: "ride", DurationMinutes: 30}, ExternalRef{Source: "strava", ID: "1"})
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	require.NoError(t, workouts.DeleteWorkout(int64(created.ID)))
+	assert.ErrorIs(t, workouts.DeleteWorkout(int64(created.ID)), ErrNotFound)
+
+	// * soft delete hides the user and signs them out
+	_, err = users.DeleteAccount(int64(user.ID))
+	require.NoError(t, err)
+	authed, err = users.GetUserToken("authentication", token.Plaintext)
+	require.NoError(t, err)
+	assert.Nil(t, authed)
+}
+
+func TestSQLiteBatchWorkouts(t *testing.T) {
+	db := setupSQLiteDB(t)
+	users := NewSQLiteUserStore(db)
+	workouts := NewSQLiteWorkoutStore(db)
+	user := &User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("correct horse"))
+	require.NoError(t, users.CreateUser(user))
+	existing, err := workouts.CreateWorkout(&Workout{UserID: user.ID, Title: "run", DurationMinutes: 30})
+	require.NoError(t, err)
+
+	batch := func(atomic bool) ([]WorkoutOp, []error) {
+		existing.Title = "tempo run"
+		ops := []WorkoutOp{
+			{Op: WorkoutOpCreate, Workout: &Workout{UserID: user.ID, Title: "legs", DurationMinutes: 50}},
+			{Op: WorkoutOpUpdate, Workout: existing},
+			{Op: WorkoutOpDelete, Workout: &Workout{ID: existing.ID + 1000}},
+		}
+		opErrs, err := workouts.BatchWorkouts(ops, atomic)
+		require.NoError(t, err)
+		return ops, opErrs
+	}
+
+	// * atomic: the missing delete rolls back the create + update
+	ops, opErrs := batch(true)
+	assert.ErrorIs(t, opErrs[0], ErrBatchAborted)
+	assert.ErrorIs(t, opErrs[1], ErrBatchAborted)
+	assert.ErrorIs(t, opErrs[2], ErrNotFound)
+	assert.Zero(t, ops[0].Workout.ID)
+	stored, err := workouts.GetWorkoutByID(int64(existing.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "run", stored.Title)
+
+	// * one by one: only the delete fails
+	ops, opErrs = batch(false)
+	assert.NoError(t, opErrs[0])
+	assert.NoError(t, opErrs[1])
+	assert.ErrorIs(t, opErrs[2], ErrNotFound)
+	_, err = workouts.GetWorkoutByID(int64(ops[0].Workout.ID))
+	assert.NoError(t, err)
+	stored, err = workouts.GetWorkoutByID(int64(existing.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "tempo run", stored.Title)
+
+	opErrs, err = workouts.BatchWorkouts([]WorkoutOp{{Op: WorkoutOpDelete, Workout: existing}}, true)
+	require.NoError(t, err)
+	assert.NoError(t, opErrs[0])
+	_, err = workouts.GetWorkoutByID(int64(existing.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLiteSyncChanges(t *testing.T) {
+	db := setupSQLiteDB(t)
+	users := NewSQLiteUserStore(db)
+	workouts := NewSQLiteWorkoutStore(db)
+	changes := NewSQLiteSyncStore(db)
+	user := &User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("correct horse"))
+	require.NoError(t, users.CreateUser(user))
+
+	kept, err := workouts.CreateWorkout(&Workout{UserID: user.ID, Title: "run", DurationMinutes: 30,
+		Entries: []WorkoutEntry{{ExerciseName: "jog", Sets: 1, DurationSeconds: intPointer(1800), OrderIndex: 1}}})
+	require.NoError(t, err)
+	dropped, err := workouts.CreateWorkout(&Workout{UserID: user.ID, Title: "swim", DurationMinutes: 40})
+	require.NoError(t, err)
+
+	page, err := changes.ListChanges(user.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, int64(kept.ID), page[0].WorkoutID)
+	require.NotNil(t, page[0].Workout)
+	assert.Equal(t, "run", page[0].Workout.Title)
+	assert.Len(t, page[0].Workout.Entries, 1)
+	cursor := page[1].Seq
+
+	// * an update moves the workout past the cursor, a delete leaves a tombstone
+	kept.Title = "tempo run"
+	require.NoError(t, workouts.UpdateWorkout(kept))
+	require.NoError(t, workouts.DeleteWorkout(int64(dropped.ID)))
+	page, err = changes.ListChanges(user.ID, cursor, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "tempo run", page[0].Workout.Title)
+	assert.Equal(t, int64(dropped.ID), page[1].WorkoutID)
+	assert.True(t, page[1].Deleted)
+	assert.Nil(t, page[1].Workout)
+	assert.Greater(t, page[0].Seq, cursor)
+
+	page, err = changes.ListChanges(user.ID, page[1].Seq, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+	page, err = changes.ListChanges(user.ID+1, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page, "only the user's own workouts")
+}
+
+func TestSQLiteOutbox(t *testing.T) {
+	db := setupSQLiteDB(t)
+	users := NewSQLiteUserStore(db)
+	workouts := NewSQLiteWorkoutStore(db)
+	outbox := NewSQLiteOutboxStore(db)
+	user := &User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("correct horse"))
+	require.NoError(t, users.CreateUser(user))
+
+	workout, err := workouts.CreateWorkout(&Workout{UserID: user.ID, Title: "run", DurationMinutes: 30})
+	require.NoError(t, err)
+	workout.Title = "tempo run"
+	require.NoError(t, workouts.UpdateWorkout(workout))
+	require.NoError(t, workouts.DeleteWorkout(int64(workout.ID)))
+	assert.ErrorIs(t, workouts.DeleteWorkout(int64(workout.ID)), ErrNotFound)
+	_, err = workouts.ImportWorkouts([]*Workout{{UserID: user.ID, Title: "old ride", DurationMinutes: 60}})
+	require.NoError(t, err)
+
+	// * a failed publish leaves the events pending
+	_, err = outbox.RelayEvents(10, func([]*OutboxEvent) (int, error) { return 0, errors.New("bus down") })
+	require.Error(t, err)
+
+	var relayed []*OutboxEvent
+	count, err := outbox.RelayEvents(2, func(pending []*OutboxEvent) (int, error) {
+		relayed = append(relayed, pending[0])
+		return 1, errors.New("second subscriber failed")
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, count, "only what went through is marked")
+	count, err = outbox.RelayEvents(1, func(pending []*OutboxEvent) (int, error) {
+		relayed = append(relayed, pending...)
+		return len(pending), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = outbox.RelayEvents(2, func(pending []*OutboxEvent) (int, error) {
+		relayed = append(relayed, pending...)
+		return len(pending), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "the missing delete and the import leave nothing behind")
+
+	require.Len(t, relayed, 3)
+	for i, eventType := range []string{events.WorkoutCreated, events.WorkoutUpdated, events.WorkoutDeleted} {
+		assert.Equal(t, eventType, relayed[i].Type)
+		assert.Equal(t, user.ID, relayed[i].UserID)
+		assert.Equal(t, workout.ID, relayed[i].WorkoutID)
+	}
+
+	count, err = outbox.RelayEvents(10, func([]*OutboxEvent) (int, error) { return 0, errors.New("nothing to publish") })
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	purged, err := outbox.PurgePublishedEvents(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
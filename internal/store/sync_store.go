@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ? - one workout in a user's change log, as it is now or as a tombstone once it's deleted
+type SyncChange struct {
+	Seq       int64
+	WorkoutID int64
+	Deleted   bool
+	ChangedAt time.Time
+	Workout   *Workout //* with entries (and tags where they exist), nil for tombstones
+}
+
+// * holds the db connection for the workout_changes log
+type PostgresSyncStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new sync store instance
+func NewPostgresSyncStore(db *sql.DB) *PostgresSyncStore {
+	return &PostgresSyncStore{db: db}
+}
+
+// * SQLite --> the same log, kept by the triggers in sqlite_schema.sql
+type SQLiteSyncStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteSyncStore(db *sql.DB) *SQLiteSyncStore {
+	return &SQLiteSyncStore{db: db}
+}
+
+// ! SyncStore interface --> the workout_changes log, written by triggers on workouts (+ workout_tags on postgres)
+type SyncStore interface {
+	//* userID's workouts changed after since, oldest change first; a workout shows up once, with its latest change
+	ListChanges(userID int, since int64, limit int) ([]*SyncChange, error)
+}
+
+const syncWorkoutColumns = `w.id, w.user_id, w.title, COALESCE(w.description, ''), w.duration_minutes, COALESCE(w.calories_burned, 0),
+    w.calories_estimated, w.visibility, w.flagged, w.verified, w.created_at, w.performed_at`
+
+const syncEntryColumns = `e.workout_id, e.id, e.exercise_name, e.sets, e.reps, e.duration_seconds, e.weight, e.distance, e.notes, e.order_index`
+
+// * syncQueries --> one dialect's page of the log + the workouts and entries behind it, page is repeated as a subquery
+type syncQueries struct {
+	page     string
+	workouts string
+	entries  string
+	tags     bool //* workouts selects a JSON array of tags last
+}
+
+var postgresSyncQueries = newSyncQueries(
+	`SELECT seq, workout_id, deleted, changed_at FROM workout_changes WHERE user_id = $1 AND seq > $2 ORDER BY seq LIMIT $3`,
+	`, COALESCE((SELECT json_agg(t.tag ORDER BY t.tag) FROM workout_tags t WHERE t.workout_id = w.id), '[]')`)
+
+var sqliteSyncQueries = newSyncQueries(
+	`SELECT seq, workout_id, deleted, changed_at FROM workout_changes WHERE user_id = ? AND seq > ? ORDER BY seq LIMIT ?`, "")
+
+func newSyncQueries(page, tagsColumn string) syncQueries {
+	return syncQueries{
+		page:     page,
+		workouts: fmt.Sprintf(`SELECT %s%s FROM (%s) c INNER JOIN workouts w ON w.id = c.workout_id`, syncWorkoutColumns, tagsColumn, page),
+		entries: fmt.Sprintf(`SELECT %s FROM (%s) c INNER JOIN workout_entries e ON e.workout_id = c.workout_id
+    ORDER BY e.workout_id, e.order_index`, syncEntryColumns, page),
+		tags: tagsColumn != "",
+	}
+}
+
+func (s *PostgresSyncStore) ListChanges(userID int, since int64, limit int) ([]*SyncChange, error) {
+	//! one snapshot for the three reads, a change committed in between must not split a page
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return listSyncChanges(tx, postgresSyncQueries, userID, since, limit)
+}
+
+func (s *SQLiteSyncStore) ListChanges(userID int, since int64, limit int) ([]*SyncChange, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return listSyncChanges(tx, sqliteSyncQueries, userID, since, limit)
+}
+
+// * listSyncChanges --> the page, then its live workouts and their entries, in tx
+func listSyncChanges(tx *sql.Tx, q syncQueries, userID int, since int64, limit int) ([]*SyncChange, error) {
+	rows, err := tx.Query(q.page, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*SyncChange{}
+	for rows.Next() {
+		change := &SyncChange{}
+		err = rows.Scan(&change.Seq, &change.WorkoutID, &change.Deleted, &change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	workouts, err := scanSyncWorkouts(tx, q, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if !change.Deleted {
+			change.Workout = workouts[change.WorkoutID]
+		}
+	}
+	return changes, nil
+}
+
+// * scanSyncWorkouts --> the page's workouts by id, entries attached
+func scanSyncWorkouts(tx *sql.Tx, q syncQueries, userID int, since int64, limit int) (map[int64]*Workout, error) {
+	rows, err := tx.Query(q.workouts, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := map[int64]*Workout{}
+	for rows.Next() {
+		workout := &Workout{Entries: []WorkoutEntry{}}
+		var performedAt sql.NullTime //* NULL on sqlite rows written before the column was added
+		dest := []any{&workout.ID, &workout.UserID, &workout.Title, &workout.Description, &workout.DurationMinutes,
+			&workout.CaloriesBurned, &workout.CaloriesEstimated, &workout.Visibility, &workout.Flagged, &workout.Verified,
+			&workout.CreatedAt, &performedAt}
+		var tags []byte
+		if q.tags {
+			dest = append(dest, &tags)
+		}
+		err = rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+		workout.PerformedAt = workout.CreatedAt
+		if performedAt.Valid {
+			workout.PerformedAt = performedAt.Time
+		}
+		if q.tags {
+			err = json.Unmarshal(tags, &workout.Tags)
+			if err != nil {
+				return nil, err
+			}
+		}
+		workouts[int64(workout.ID)] = workout
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, err := tx.Query(q.entries, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer entries.Close()
+	for entries.Next() {
+		var workoutID int64
+		var entry WorkoutEntry
+		err = entries.Scan(&workoutID, &entry.ID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds,
+			&entry.Weight, &entry.Distance, &entry.Notes, &entry.OrderIndex)
+		if err != nil {
+			return nil, err
+		}
+		if workout, ok := workouts[workoutID]; ok {
+			workout.Entries = append(workout.Entries, entry)
+		}
+	}
+	return workouts, entries.Err()
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// ? - one of the user's tags with how many of their workouts carry it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// * holds the db connection for workout tags
+type PostgresTagStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new tag store instance
+func NewPostgresTagStore(db *sql.DB) *PostgresTagStore {
+	return &PostgresTagStore{db: db}
+}
+
+// ! TagStore interface --> workout_tags rows, tags arrive already normalized (lowercase, trimmed, deduped)
+type TagStore interface {
+	//* sorted by tag
+	ListWorkoutTags(workoutID int64) ([]string, error)
+	//* replaces every tag of the workout
+	SetWorkoutTags(workoutID int64, tags []string) error
+	//* no-op when the workout already has it
+	AddWorkoutTag(workoutID int64, tag string) error
+	//* ErrNotFound when the workout doesn't have it
+	RemoveWorkoutTag(workoutID int64, tag string) error
+	//* userID's tags starting with prefix, most used first
+	ListTagCounts(userID int, prefix string, limit int) ([]*TagCount, error)
+	//* userID's workouts (all of them for an empty tag) newest performed first, Tags set, entries left empty
+	ListWorkoutsByTag(userID int, tag string, limit, offset int) ([]*Workout, error)
+}
+
+func (s *PostgresTagStore) ListWorkoutTags(workoutID int64) ([]string, error) {
+	rows, err := s.db.Query(`SELECT tag FROM workout_tags WHERE workout_id = $1 ORDER BY tag`, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		err = rows.Scan(&tag)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *PostgresTagStore) SetWorkoutTags(workoutID int64, tags []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // ? - rolls back if anything fails
+
+	err = replaceWorkoutTags(tx, workoutID, tags)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// * replaceWorkoutTags --> the statements of SetWorkoutTags inside the caller's transaction
+func replaceWorkoutTags(tx *sql.Tx, workoutID int64, tags []string) error {
+	_, err := tx.Exec(`DELETE FROM workout_tags WHERE workout_id = $1`, workoutID)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO workout_tags (workout_id, tag) SELECT $1, UNNEST($2::text[])`, workoutID, tags)
+	return err
+}
+
+func (s *PostgresTagStore) AddWorkoutTag(workoutID int64, tag string) error {
+	_, err := s.db.Exec(`INSERT INTO workout_tags (workout_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING`, workoutID, tag)
+	return err
+}
+
+func (s *PostgresTagStore) RemoveWorkoutTag(workoutID int64, tag string) error {
+	result, err := s.db.Exec(`DELETE FROM workout_tags WHERE workout_id = $1 AND tag = $2`, workoutID, tag)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresTagStore) ListTagCounts(userID int, prefix string, limit int) ([]*TagCount, error) {
+	//? prefix is matched literally, LIKE wildcards in it are escaped
+	query := `
+  SELECT t.tag, COUNT(*)
+  FROM workout_tags t
+  INNER JOIN workouts w ON w.id = t.workout_id
+  WHERE w.user_id = $1 AND t.tag LIKE REPLACE(REPLACE(REPLACE($2, '\', '\\'), '%', '\%'), '_', '\_') || '%'
+  GROUP BY t.tag
+  ORDER BY COUNT(*) DESC, t.tag
+  LIMIT $3
+  `
+	rows, err := s.db.Query(query, userID, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*TagCount{}
+	for rows.Next() {
+		count := &TagCount{}
+		err = rows.Scan(&count.Tag, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+func (s *PostgresTagStore) ListWorkoutsByTag(userID int, tag string, limit, offset int) ([]*Workout, error) {
+	//* tags come back as a JSON array, database/sql can't scan TEXT[] directly
+	query := `
+  SELECT w.id, w.user_id, w.title, COALESCE(w.description, ''), w.duration_minutes, COALESCE(w.calories_burned, 0),
+    w.calories_estimated, w.visibility, w.flagged, w.verified, w.created_at, w.performed_at,
+    COALESCE((SELECT json_agg(t.tag ORDER BY t.tag) FROM workout_tags t WHERE t.workout_id = w.id), '[]')
+  FROM workouts w
+  WHERE w.user_id = $1 AND ($2 = '' OR EXISTS (SELECT 1 FROM workout_tags t WHERE t.workout_id = w.id AND t.tag = $2))
+  ORDER BY w.performed_at DESC, w.id DESC
+  LIMIT $3 OFFSET $4
+  `
+	rows, err := s.db.Query(query, userID, tag, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := []*Workout{}
+	for rows.Next() {
+		workout := &Workout{Entries: []WorkoutEntry{}}
+		var tags []byte
+		err = rows.Scan(&workout.ID, &workout.UserID, &workout.Title, &workout.Description, &workout.DurationMinutes,
+			&workout.CaloriesBurned, &workout.CaloriesEstimated, &workout.Visibility, &workout.Flagged, &workout.Verified,
+			&workout.CreatedAt, &workout.PerformedAt, &tags)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(tags, &workout.Tags)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, workout)
+	}
+	return workouts, rows.Err()
+}
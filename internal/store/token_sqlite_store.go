@@ -0,0 +1,51 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fem/internal/tokens"
+	"time"
+)
+
+//! SQLiteTokenStore --> TokenStore on the single-file sqlite database (DB_DRIVER=sqlite)
+type SQLiteTokenStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteTokenStore(db *sql.DB) *SQLiteTokenStore {
+	return &SQLiteTokenStore{db: db}
+}
+
+func (t *SQLiteTokenStore) CreateNewToken(userID int, ttl time.Duration, scope string) (*tokens.Token, error) {
+	token, err := tokens.GenerateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+	err = t.Insert(token)
+	return token, err
+}
+
+//! Insert --> hash only, expiry stored as unix seconds
+func (t *SQLiteTokenStore) Insert(token *tokens.Token) error {
+	_, err := t.db.Exec(`INSERT INTO tokens (hash, user_id, expiry, scope) VALUES (?, ?, ?, ?)`, token.Hash, token.UserID, token.Expiry.Unix(), token.Scope)
+	return err
+}
+
+func (t *SQLiteTokenStore) DeleteAllTokensForUser(userID int, scope string) error {
+	_, err := t.db.Exec(`DELETE FROM tokens WHERE user_id = ? AND scope = ?`, userID, scope)
+	return err
+}
+
+func (t *SQLiteTokenStore) DeleteToken(scope string, tokenPlainText string) error {
+	tokenHash := sha256.Sum256([]byte(tokenPlainText))
+	_, err := t.db.Exec(`DELETE FROM tokens WHERE hash = ? AND scope = ?`, tokenHash[:], scope)
+	return err
+}
+
+func (t *SQLiteTokenStore) DeleteExpiredTokens() (int64, error) {
+	result, err := t.db.Exec(`DELETE FROM tokens WHERE expiry < ?`, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"crypto/sha256"
 	"database/sql"
 	"fem/internal/tokens"
 	"time"
@@ -24,6 +25,8 @@ type TokenStore interface {
 	Insert(token *tokens.Token) error //* saves token to database
 	CreateNewToken(userID int,ttl time.Duration,scope string) (*tokens.Token, error) //* generates and saves new token
 	DeleteAllTokensForUser(userID int,scope string) error //* cleanup old tokens for user
+	DeleteToken(scope string,tokenPlainText string) error //* revokes a single token (logout)
+	DeleteExpiredTokens() (int64,error) //* purges tokens past their expiry
 }
 
 //! CreateNewToken --> generates random token and saves it to database
@@ -63,3 +66,29 @@ func (t *PostgresTokenStore) DeleteAllTokensForUser(userId int,scope string) err
 	return err
 }
 
+//! DeleteToken --> removes one token by its hash, deleting an unknown token is not an error
+func (t *PostgresTokenStore) DeleteToken(scope string,tokenPlainText string) error {
+	tokenHash := sha256.Sum256([]byte(tokenPlainText))
+	query := `
+		delete from tokens
+		where hash=$1 and scope=$2
+	`
+
+	_,err := t.db.Exec(query,tokenHash[:],scope)
+	return err
+}
+
+//! DeleteExpiredTokens --> removes every expired token, run periodically by the background worker
+//? expired tokens are already rejected on lookup, this just keeps the table small
+func (t *PostgresTokenStore) DeleteExpiredTokens() (int64,error) {
+	query := `
+		delete from tokens
+		where expiry < now()
+	`
+
+	result,err := t.db.Exec(query)
+	if err != nil {
+		return 0,err
+	}
+	return result.RowsAffected()
+}
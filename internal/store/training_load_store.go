@@ -0,0 +1,78 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - one day of training in the user's timezone, labelled as UTC midnight, Volume is sets x reps x weight over weighted entries
+type DailyLoad struct {
+	Day      time.Time `json:"day"`
+	Sessions int       `json:"sessions"`
+	Minutes  int       `json:"minutes"`
+	Volume   float64   `json:"volume"`
+}
+
+// * holds the db connection for training load reads
+type PostgresTrainingLoadStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new training load store instance
+func NewPostgresTrainingLoadStore(db *sql.DB) *PostgresTrainingLoadStore {
+	return &PostgresTrainingLoadStore{db: db}
+}
+
+// ! TrainingLoadStore interface --> daily load for the ACWR + the alerts already sent
+type TrainingLoadStore interface {
+	ListDailyLoad(userID int, since time.Time) ([]*DailyLoad, error)
+	MarkTrainingLoadAlert(userID int, day time.Time, band string, ratio float64) (bool, error)
+}
+
+// ! ListDailyLoad --> days with at least one workout since the since day, oldest first, flagged workouts don't count
+// ? days are the user's local dates of performed_at, since is a day label like DailyLoad.Day
+func (s *PostgresTrainingLoadStore) ListDailyLoad(userID int, since time.Time) ([]*DailyLoad, error) {
+	query := `
+  SELECT (w.performed_at AT TIME ZONE tz.name)::date AS day, COUNT(*), SUM(w.duration_minutes), COALESCE(SUM(v.volume), 0)
+  FROM workouts w
+  CROSS JOIN (SELECT COALESCE((SELECT timezone FROM user_profiles WHERE user_id = $1), 'UTC') AS name) tz
+  LEFT JOIN (
+    SELECT workout_id, SUM(sets * COALESCE(reps, 0) * COALESCE(weight, 0)) AS volume
+    FROM workout_entries
+    GROUP BY workout_id
+  ) v ON v.workout_id = w.id
+  WHERE w.user_id = $1 AND NOT w.flagged AND (w.performed_at AT TIME ZONE tz.name)::date >= ($2::timestamptz AT TIME ZONE 'UTC')::date
+  GROUP BY day
+  ORDER BY day
+  `
+	rows, err := s.db.Query(query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*DailyLoad{}
+	for rows.Next() {
+		d := &DailyLoad{}
+		err = rows.Scan(&d.Day, &d.Sessions, &d.Minutes, &d.Volume)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// ! MarkTrainingLoadAlert --> claims the alert, false when this band was already sent that day
+func (s *PostgresTrainingLoadStore) MarkTrainingLoadAlert(userID int, day time.Time, band string, ratio float64) (bool, error) {
+	result, err := s.db.Exec(`
+  INSERT INTO training_load_alerts (user_id, day, band, ratio)
+  VALUES ($1, $2, $3, $4)
+  ON CONFLICT DO NOTHING
+  `, userID, day, band, ratio)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
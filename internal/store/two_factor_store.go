@@ -0,0 +1,161 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - a user's TOTP setup, Enabled once the first code was confirmed
+type TwoFactor struct {
+	UserID          int        `json:"-"`
+	Secret          string     `json:"-"`
+	LastStep        int64      `json:"-"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	BackupCodesLeft int        `json:"backup_codes_left"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ! Enabled --> logins need a second factor
+func (t *TwoFactor) Enabled() bool {
+	return t != nil && t.ConfirmedAt != nil
+}
+
+// * holds the db connection for two-factor settings
+type PostgresTwoFactorStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new two-factor store instance
+func NewPostgresTwoFactorStore(db *sql.DB) *PostgresTwoFactorStore {
+	return &PostgresTwoFactorStore{db: db}
+}
+
+// ! TwoFactorStore interface --> secrets + backup codes, the service does the TOTP math
+type TwoFactorStore interface {
+	GetTwoFactor(userID int) (*TwoFactor, error)
+	//* starts (or restarts) an unconfirmed setup, replaces any earlier pending secret
+	SaveTwoFactorSecret(userID int, secret string) error
+	//* enables 2FA with step as the last used code, backup codes replace any earlier ones
+	ConfirmTwoFactor(userID int, step int64, backupHashes [][]byte) error
+	//* false when step isn't newer than the last accepted one (a replayed code)
+	MarkTwoFactorStep(userID int, step int64) (bool, error)
+	//* false when the code is unknown or already used
+	UseBackupCode(userID int, hash []byte) (bool, error)
+	//* ErrNotFound when 2FA was never set up
+	DeleteTwoFactor(userID int) error
+}
+
+func (s *PostgresTwoFactorStore) GetTwoFactor(userID int) (*TwoFactor, error) {
+	query := `
+  SELECT t.user_id, t.secret, t.last_step, t.confirmed_at, t.created_at,
+    (SELECT COUNT(*) FROM two_factor_backup_codes c WHERE c.user_id = t.user_id AND c.used_at IS NULL)
+  FROM two_factor t
+  WHERE t.user_id = $1
+  `
+	t := &TwoFactor{}
+	err := s.db.QueryRow(query, userID).Scan(&t.UserID, &t.Secret, &t.LastStep, &t.ConfirmedAt, &t.CreatedAt, &t.BackupCodesLeft)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *PostgresTwoFactorStore) SaveTwoFactorSecret(userID int, secret string) error {
+	query := `
+  INSERT INTO two_factor (user_id, secret)
+  VALUES ($1, $2)
+  ON CONFLICT (user_id) DO UPDATE
+  SET secret = EXCLUDED.secret, last_step = 0, confirmed_at = NULL, created_at = CURRENT_TIMESTAMP
+  `
+	_, err := s.db.Exec(query, userID, secret)
+	return err
+}
+
+func (s *PostgresTwoFactorStore) ConfirmTwoFactor(userID int, step int64, backupHashes [][]byte) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE two_factor SET confirmed_at = CURRENT_TIMESTAMP, last_step = $2 WHERE user_id = $1`, userID, step)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	_, err = tx.Exec(`DELETE FROM two_factor_backup_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	for _, hash := range backupHashes {
+		_, err = tx.Exec(`INSERT INTO two_factor_backup_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresTwoFactorStore) MarkTwoFactorStep(userID int, step int64) (bool, error) {
+	result, err := s.db.Exec(`UPDATE two_factor SET last_step = $2 WHERE user_id = $1 AND last_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (s *PostgresTwoFactorStore) UseBackupCode(userID int, hash []byte) (bool, error) {
+	query := `
+  UPDATE two_factor_backup_codes
+  SET used_at = CURRENT_TIMESTAMP
+  WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+  `
+	result, err := s.db.Exec(query, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (s *PostgresTwoFactorStore) DeleteTwoFactor(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM two_factor_backup_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM two_factor WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"time"
+)
+
+//! SQLiteUserStore --> UserStore on the single-file sqlite database (DB_DRIVER=sqlite)
+type SQLiteUserStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserStore(db *sql.DB) *SQLiteUserStore {
+	return &SQLiteUserStore{db: db}
+}
+
+func (s *SQLiteUserStore) CreateUser(user *User) error {
+	now := time.Now().UTC()
+	query := `
+  INSERT INTO users (username, email, password_hash, bio, created_at, updated_at)
+  VALUES (?, ?, ?, ?, ?, ?)
+  RETURNING id
+  `
+	err := s.db.QueryRow(query, user.Username, user.Email, user.PasswordHash.hash, user.Bio, now, now).Scan(&user.ID)
+	if err != nil {
+		return mapError(err)
+	}
+	user.CreatedAt, user.UpdatedAt = now, now
+	return nil
+}
+
+//! scanUser --> users row in the column order every lookup below selects
+func (s *SQLiteUserStore) scanUser(row *sql.Row) (*User, error) {
+	user := &User{PasswordHash: password{}}
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash.hash, &user.Bio, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *SQLiteUserStore) GetUserByUsername(username string) (*User, error) {
+	return s.scanUser(s.db.QueryRow(`
+  SELECT id, username, email, password_hash, bio, is_admin, created_at, updated_at
+  FROM users
+  WHERE username = ? AND deleted_at IS NULL
+  `, username))
+}
+
+func (s *SQLiteUserStore) GetUserByID(id int64) (*User, error) {
+	return s.scanUser(s.db.QueryRow(`
+  SELECT id, username, email, password_hash, bio, is_admin, created_at, updated_at
+  FROM users
+  WHERE id = ? AND deleted_at IS NULL
+  `, id))
+}
+
+func (s *SQLiteUserStore) UpdateUser(user *User) error {
+	now := time.Now().UTC()
+	result, err := s.db.Exec(`
+  UPDATE users
+  SET username = ?, email = ?, bio = ?, updated_at = ?
+  WHERE id = ?
+  `, user.Username, user.Email, user.Bio, now, user.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	user.UpdatedAt = now
+	return nil
+}
+
+func (s *SQLiteUserStore) UpdatePassword(user *User) error {
+	now := time.Now().UTC()
+	result, err := s.db.Exec(`
+  UPDATE users
+  SET password_hash = ?, updated_at = ?
+  WHERE id = ? AND deleted_at IS NULL
+  `, user.PasswordHash.hash, now, user.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	user.UpdatedAt = now
+	return nil
+}
+
+//! GetUserToken --> user behind an unexpired token, expiry is unix seconds in sqlite
+func (s *SQLiteUserStore) GetUserToken(scope string, tokenPlainText string) (*User, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlainText))
+	return s.scanUser(s.db.QueryRow(`
+  SELECT u.id, u.username, u.email, u.password_hash, u.bio, u.is_admin, u.created_at, u.updated_at
+  FROM users u
+  INNER JOIN tokens t ON t.user_id = u.id
+  WHERE t.hash = ? AND t.scope = ? AND t.expiry > ? AND u.deleted_at IS NULL
+  `, tokenHash[:], scope, time.Now().Unix()))
+}
+
+//! DeleteAccount --> soft delete + token revocation, workouts go private until the purge
+//? the social tables the postgres store cleans up don't exist in sqlite mode
+func (s *SQLiteUserStore) DeleteAccount(userID int64) (time.Time, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec(`UPDATE users SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`, now, now, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if rowsAffected == 0 {
+		return time.Time{}, ErrNotFound //* already deleted
+	}
+
+	for _, query := range []string{
+		`DELETE FROM tokens WHERE user_id = ?`,
+		`UPDATE workouts SET visibility = 'private' WHERE user_id = ?`,
+	} {
+		_, err = tx.Exec(query, userID)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return now, tx.Commit()
+}
+
+func (s *SQLiteUserStore) PurgeDeletedUsers(deletedBefore time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?`, deletedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -40,12 +40,22 @@ func (p *password) Matches (plainTextPass string) (bool,error) {
 	return true,nil
 }
 
+//* Hash / SetHash --> raw bcrypt hash, lets a cache carry a user without another db round trip
+func (p *password) Hash() []byte {
+	return p.hash
+}
+
+func (p *password) SetHash(hash []byte) {
+	p.hash = hash
+}
+
 type User struct { // LOGGED IN USER
 	ID           int       `json:"id"`
 	Username     string    `json:"username"`
 	Email        string    `json:"email"`
 	PasswordHash password  `json:"-"`
 	Bio          string    `json:"bio"`
+	IsAdmin      bool      `json:"is_admin"` // * granted directly in the db, never through the API
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -72,8 +82,12 @@ func NewPostUserStore(db *sql.DB) *PostgresUserStore {
 type UserStore interface {
 	CreateUser(*User) error
 	GetUserByUsername(username string) (*User,error)
+	GetUserByID(id int64) (*User,error)
 	UpdateUser(*User) error
+	UpdatePassword(*User) error
 	GetUserToken(scope string,tokenPlainText string) (*User, error)
+	DeleteAccount(userID int64) (time.Time,error)
+	PurgeDeletedUsers(deletedBefore time.Time) (int64,error)
  }
 
 //! CREATEUSER METHOD -  directly access type PUsrStore
@@ -86,7 +100,7 @@ func ( s *PostgresUserStore) CreateUser(user *User) error {
 
 	err := s.db.QueryRow(query, user.Username, user.Email, user.PasswordHash.hash, user.Bio).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
-		return err
+		return mapError(err) //* ErrConflict --> username or email taken
 	}
 
 	return nil
@@ -98,9 +112,9 @@ func (s *PostgresUserStore) GetUserByUsername(username string) (*User, error) {
 	}
 
 	query := `
-  SELECT id, username, email, password_hash, bio, created_at, updated_at
+  SELECT id, username, email, password_hash, bio, is_admin, created_at, updated_at
   FROM users
-  WHERE username = $1
+  WHERE username = $1 AND deleted_at IS NULL
   `
 
 	err := s.db.QueryRow(query, username).Scan(
@@ -109,6 +123,41 @@ func (s *PostgresUserStore) GetUserByUsername(username string) (*User, error) {
 		&user.Email,
 		&user.PasswordHash.hash,
 		&user.Bio,
+		&user.IsAdmin,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+//! GetUserByID --> same lookup as GetUserByUsername but keyed on primary key
+func (s *PostgresUserStore) GetUserByID(id int64) (*User, error) {
+	user := &User{
+		PasswordHash: password{},
+	}
+
+	query := `
+  SELECT id, username, email, password_hash, bio, is_admin, created_at, updated_at
+  FROM users
+  WHERE id = $1 AND deleted_at IS NULL
+  `
+
+	err := s.db.QueryRow(query, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash.hash,
+		&user.Bio,
+		&user.IsAdmin,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -134,7 +183,7 @@ func (s *PostgresUserStore) UpdateUser(user *User) error {
 
 	result, err := s.db.Exec(query, user.Username, user.Email, user.Bio, user.ID)
 	if err != nil {
-		return err
+		return mapError(err) //* ErrConflict --> the new username or email is taken
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -143,24 +192,45 @@ func (s *PostgresUserStore) UpdateUser(user *User) error {
 	}
 
 	if rowsAffected == 0 {
-		return sql.ErrNoRows
+		return ErrNotFound
 	}
 
 	return nil
 }
 
+//! UpdatePassword --> saves user.PasswordHash, Set it first
+func (s *PostgresUserStore) UpdatePassword(user *User) error {
+	result, err := s.db.Exec(`
+  UPDATE users
+  SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $2 AND deleted_at IS NULL
+  `, user.PasswordHash.hash, user.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // !auth tokenzation
 func (s *PostgresUserStore) GetUserToken(scope string,plaintextpassword string) (*User,error) {
 	tokenHash := sha256.Sum256([]byte(plaintextpassword)) //* get hashed pass using sha256 salt
 
 	query := `
-	 Select u.id, u.username, u.email,u.password_hash, u.bio, u.created_at, u.updated_at 
+	 Select u.id, u.username, u.email,u.password_hash, u.bio, u.is_admin, u.created_at, u.updated_at 
 	 from users u
 	 INNER JOIN tokens t
 	 ON
 	 t.user_id = u.id
 	 WHERE
-	 t.hash=$1 AND t.scope=$2 AND t.expiry > $3
+	 t.hash=$1 AND t.scope=$2 AND t.expiry > $3 AND u.deleted_at IS NULL
 	`
 // intializing instance of User struct but with these fields only
 	user := &User{
@@ -174,6 +244,7 @@ func (s *PostgresUserStore) GetUserToken(scope string,plaintextpassword string)
 		&user.Email,
 		&user.PasswordHash.hash,
 		&user.Bio,
+		&user.IsAdmin,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -187,4 +258,56 @@ func (s *PostgresUserStore) GetUserToken(scope string,plaintextpassword string)
 	}
 
 	return user,nil
-}
\ No newline at end of file
+}
+
+//! DeleteAccount --> soft delete, the account is unusable right away but the row + workouts stay until the purge
+//? one transaction: tokens revoked, comments anonymized, social links dropped, workouts hidden
+func (s *PostgresUserStore) DeleteAccount(userID int64) (time.Time,error) {
+	tx,err := s.db.Begin()
+	if err != nil {
+		return time.Time{},err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	err = tx.QueryRow(`
+  UPDATE users
+  SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+  WHERE id = $1 AND deleted_at IS NULL
+  RETURNING deleted_at
+  `,userID).Scan(&deletedAt)
+	if err != nil {
+		return time.Time{},mapError(err) //* ErrNotFound --> already deleted
+	}
+
+	cleanup := []string{
+		`DELETE FROM tokens WHERE user_id = $1`,
+		`UPDATE workout_comments SET user_id = NULL WHERE user_id = $1`,
+		`DELETE FROM workout_reactions WHERE user_id = $1`,
+		`DELETE FROM follows WHERE follower_id = $1 OR followee_id = $1`,
+		`DELETE FROM feed_entries WHERE user_id = $1 OR workout_id IN (SELECT id FROM workouts WHERE user_id = $1)`,
+		`UPDATE workout_shares SET revoked_at = CURRENT_TIMESTAMP WHERE revoked_at IS NULL AND workout_id IN (SELECT id FROM workouts WHERE user_id = $1)`,
+		`UPDATE workouts SET visibility = 'private' WHERE user_id = $1`,
+		`DELETE FROM integration_connections WHERE user_id = $1`,
+		`DELETE FROM webhooks WHERE user_id = $1`,
+		`DELETE FROM devices WHERE user_id = $1`,
+		`UPDATE reminders SET enabled = FALSE, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND enabled`,
+	}
+	for _,query := range cleanup {
+		_,err = tx.Exec(query,userID)
+		if err != nil {
+			return time.Time{},err
+		}
+	}
+
+	return deletedAt,tx.Commit()
+}
+
+//! PurgeDeletedUsers --> hard delete once the grace period is over, workouts + everything else cascade
+func (s *PostgresUserStore) PurgeDeletedUsers(deletedBefore time.Time) (int64,error) {
+	result,err := s.db.Exec(`DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1`,deletedBefore)
+	if err != nil {
+		return 0,err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestPostgresUserStore --> accounts, tokens, soft delete + purge against real postgres
+func TestPostgresUserStore(t *testing.T) {
+	db := openTestDB(t)
+	users := NewPostUserStore(db)
+	tokenStore := NewPostgresTokenStore(db)
+
+	ana := createTestUser(t, db, "ana")
+	assert.Error(t, users.CreateUser(&User{Username: "ana", Email: "other@example.com"}))
+
+	token, err := tokenStore.CreateNewToken(ana.ID, time.Hour, "authentication")
+	require.NoError(t, err)
+	authed, err := users.GetUserToken("authentication", token.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, authed)
+	assert.Equal(t, ana.ID, authed.ID)
+
+	// * soft delete signs out and stops pushes + reminders, the purge hard deletes and cascades
+	workout := createTestWorkout(t, db, ana.ID, "push day")
+	notifications := NewPostgresNotificationStore(db)
+	reminders := NewPostgresReminderStore(db)
+	require.NoError(t, notifications.RegisterDevice(&Device{UserID: ana.ID, Platform: DevicePlatformFCM, Token: "android-1"}))
+	reminder := &Reminder{UserID: ana.ID, Name: "Train", RemindAt: "18:00", Timezone: "UTC", Days: ReminderEveryDay, Enabled: true}
+	require.NoError(t, reminders.CreateReminder(reminder))
+	deletedAt, err := users.DeleteAccount(int64(ana.ID))
+	require.NoError(t, err)
+	authed, err = users.GetUserToken("authentication", token.Plaintext)
+	require.NoError(t, err)
+	assert.Nil(t, authed)
+	devices, err := notifications.ListDevices(ana.ID)
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+	stored, err := reminders.GetReminder(reminder.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Enabled)
+	require.NoError(t, reminders.UpdateReminder(reminder)) //* re-enabled by a request still in flight
+	enabled, err := reminders.ListEnabledReminders()
+	require.NoError(t, err)
+	assert.Empty(t, enabled, "a deleted account's reminders never come due")
+
+	purged, err := users.PurgeDeletedUsers(deletedAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	gone, err := NewPostgresWorkoutStore(db).GetWorkoutByID(int64(workout.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, gone)
+}
+
+// ! TestPostgresSandboxAndUsageStores --> sandbox expiry + per-user request counters
+func TestPostgresSandboxAndUsageStores(t *testing.T) {
+	db := openTestDB(t)
+	sandboxes := NewPostgresSandboxStore(db)
+	usage := NewPostgresUserUsageStore(db)
+
+	user := &User{Username: "sandbox-test", Email: "sandbox-test@example.com"}
+	require.NoError(t, user.PasswordHash.Set("password"))
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, sandboxes.CreateSandboxUser(user, expiresAt))
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	require.NoError(t, usage.AddUserRequests([]*UserRequests{{UserID: user.ID, Day: day, Requests: 3}}))
+	require.NoError(t, usage.AddUserRequests([]*UserRequests{{UserID: user.ID, Day: day, Requests: 2}, {UserID: 999, Day: day, Requests: 1}}))
+	counts, err := usage.ListUserRequests(user.ID, day)
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	assert.Equal(t, int64(5), counts[0].Requests)
+
+	purged, err := sandboxes.PurgeExpiredSandboxes(time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = sandboxes.PurgeExpiredSandboxes(expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	gone, err := NewPostUserStore(db).GetUserByID(int64(user.ID))
+	require.NoError(t, err)
+	assert.Nil(t, gone)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ? - authenticated requests one user made on one day
+type UserRequests struct {
+	UserID   int       `json:"-"`
+	Day      time.Time `json:"day"`
+	Requests int64     `json:"requests"`
+}
+
+// * holds the db connection for per-user request counters
+type PostgresUserUsageStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new user usage store instance
+func NewPostgresUserUsageStore(db *sql.DB) *PostgresUserUsageStore {
+	return &PostgresUserUsageStore{db: db}
+}
+
+//! UserUsageStore interface --> per-user daily request counters written by clientusage.Recorder
+type UserUsageStore interface {
+	AddUserRequests(counts []*UserRequests) error
+	ListUserRequests(userID int, since time.Time) ([]*UserRequests, error)
+}
+
+//! AddUserRequests --> adds to the day's counter, several instances can flush the same user
+func (s *PostgresUserUsageStore) AddUserRequests(counts []*UserRequests) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range counts {
+		//* the account may be gone by the time its counts are flushed
+		query := `
+    INSERT INTO user_request_counts (user_id, day, requests)
+    SELECT $1, $2, $3 WHERE EXISTS (SELECT 1 FROM users WHERE id = $1)
+    ON CONFLICT (user_id, day) DO UPDATE
+    SET requests = user_request_counts.requests + EXCLUDED.requests
+    `
+		_, err = tx.Exec(query, c.UserID, c.Day, c.Requests)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//! ListUserRequests --> one row per day since `since` that saw requests, oldest first
+func (s *PostgresUserUsageStore) ListUserRequests(userID int, since time.Time) ([]*UserRequests, error) {
+	query := `
+  SELECT day, requests
+  FROM user_request_counts
+  WHERE user_id = $1 AND day >= $2::date
+  ORDER BY day
+  `
+	rows, err := s.db.Query(query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*UserRequests{}
+	for rows.Next() {
+		c := &UserRequests{UserID: userID}
+		err = rows.Scan(&c.Day, &c.Requests)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
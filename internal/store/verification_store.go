@@ -0,0 +1,90 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! verification states
+const (
+	VerificationPending  = "pending"
+	VerificationVerified = "verified"
+	VerificationRejected = "rejected"
+)
+
+// ? - anti-cheat evidence for one workout, Status is derived by the verification pipeline
+type WorkoutVerification struct {
+	WorkoutID    int        `json:"workout_id"`
+	Status       string     `json:"status"`
+	VideoURL     *string    `json:"video_url"`
+	AttestedBy   *int       `json:"attested_by"` // * coach who reviewed the workout
+	Approved     *bool      `json:"approved"`    // * nil until a coach has reviewed it
+	Note         string     `json:"note"`
+	AttestedAt   *time.Time `json:"attested_at"`
+	AnomalyScore float64    `json:"anomaly_score"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// * holds the db connection for verification operations
+type PostgresVerificationStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new verification store instance
+func NewPostgresVerificationStore(db *sql.DB) *PostgresVerificationStore {
+	return &PostgresVerificationStore{db: db}
+}
+
+//! VerificationStore interface --> contract for leaderboard verification evidence
+type VerificationStore interface {
+	GetVerification(workoutID int64) (*WorkoutVerification, error)
+	SaveVerification(*WorkoutVerification) error
+}
+
+func (s *PostgresVerificationStore) GetVerification(workoutID int64) (*WorkoutVerification, error) {
+	v := &WorkoutVerification{}
+	query := `
+  SELECT workout_id, status, video_url, attested_by, approved, note, attested_at, anomaly_score::float8, updated_at
+  FROM workout_verifications
+  WHERE workout_id = $1
+  `
+	err := s.db.QueryRow(query, workoutID).Scan(&v.WorkoutID, &v.Status, &v.VideoURL, &v.AttestedBy, &v.Approved, &v.Note,
+		&v.AttestedAt, &v.AnomalyScore, &v.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+//! SaveVerification --> upserts the evidence and mirrors the outcome onto workouts.verified in one transaction
+func (s *PostgresVerificationStore) SaveVerification(v *WorkoutVerification) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+  INSERT INTO workout_verifications (workout_id, status, video_url, attested_by, approved, note, attested_at, anomaly_score)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+  ON CONFLICT (workout_id) DO UPDATE
+  SET status = EXCLUDED.status, video_url = EXCLUDED.video_url, attested_by = EXCLUDED.attested_by,
+      approved = EXCLUDED.approved, note = EXCLUDED.note, attested_at = EXCLUDED.attested_at,
+      anomaly_score = EXCLUDED.anomaly_score, updated_at = CURRENT_TIMESTAMP
+  RETURNING updated_at
+  `
+	err = tx.QueryRow(query, v.WorkoutID, v.Status, v.VideoURL, v.AttestedBy, v.Approved, v.Note, v.AttestedAt, v.AnomalyScore).Scan(&v.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE workouts SET verified = $1 WHERE id = $2`, v.Status == VerificationVerified, v.WorkoutID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+//! backoff limits for WaitForDB + DBMonitor
+const (
+	minBackoff  = 250 * time.Millisecond
+	maxBackoff  = 5 * time.Second
+	pingTimeout = 2 * time.Second
+)
+
+func ping(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+//! WaitForDB --> pings until postgres answers, for startups where the db container comes up after the app
+//? backs off 250ms, 500ms ... capped at 5s, gives up once maxWait has passed (DB_WAIT_TIMEOUT)
+func WaitForDB(ctx context.Context, db *sql.DB, maxWait time.Duration, logger *log.Logger) error {
+	deadline := time.Now().Add(maxWait)
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx, db)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("db : not reachable after %s : %w", maxWait, err)
+		}
+		logger.Printf("waiting for database (attempt %d, retry in %s): %v", attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+//! DBMonitor --> pings on an interval so an outage is noticed (and logged) before requests start failing
+//? database/sql already replaces broken connections on its own, the monitor only tracks + reports the state
+type DBMonitor struct {
+	db       *sql.DB
+	interval time.Duration
+	logger   *log.Logger
+	up       atomic.Bool
+}
+
+//! NewDBMonitor --> starts out up, WaitForDB has just succeeded when this gets built
+func NewDBMonitor(db *sql.DB, interval time.Duration, logger *log.Logger) *DBMonitor {
+	m := &DBMonitor{db: db, interval: interval, logger: logger}
+	m.up.Store(true)
+	return m
+}
+
+//! Up --> result of the latest ping
+func (m *DBMonitor) Up() bool {
+	return m.up.Load()
+}
+
+//! Run --> pings every interval while up, backs off the same way WaitForDB does while down
+func (m *DBMonitor) Run(ctx context.Context) {
+	wait := m.interval
+	downSince := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		err := ping(ctx, m.db)
+		switch {
+		case err != nil && m.up.Load():
+			m.up.Store(false)
+			downSince = time.Now()
+			m.logger.Printf("ERROR: database connection lost: %v", err)
+			wait = minBackoff
+		case err != nil:
+			wait = min(wait*2, maxBackoff)
+		case !m.up.Load():
+			m.up.Store(true)
+			m.logger.Printf("database connection restored after %s", time.Since(downSince).Round(time.Second))
+			wait = m.interval
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForDBGivesUp(t *testing.T) {
+	//* nothing listens on port 1, every ping fails fast
+	db, err := sql.Open("pgx", "host=127.0.0.1 port=1 user=postgres password=postgres dbname=postgres sslmode=disable connect_timeout=1")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	start := time.Now()
+	err = WaitForDB(context.Background(), db, time.Second, log.New(io.Discard, "", 0))
+	assert.ErrorContains(t, err, "not reachable after 1s")
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
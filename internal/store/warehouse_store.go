@@ -0,0 +1,294 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ? - position of a sync stream in warehouse_changes, rows are read strictly after (Tx, Seq)
+type SyncCursor struct {
+	Tx  int64 //* writing transaction, see readChanges
+	Seq int64
+}
+
+// ? - where a change sits in the log; a deleted row carries only its id
+type ChangeMeta struct {
+	Cursor  SyncCursor
+	Deleted bool
+}
+
+// ? - workout row as it looked at UpdatedAt
+type WorkoutChange struct {
+	ChangeMeta
+	ID                int64
+	UserID            int64
+	Title             string
+	DurationMinutes   int
+	CaloriesBurned    int
+	CaloriesEstimated bool
+	Flagged           bool
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// ? - workout entry row, WorkoutUpdatedAt tells which workout version it belongs to
+//? entries are rewritten on every workout update, the old ones arrive as deletes
+type EntryChange struct {
+	ChangeMeta
+	ID               int64
+	WorkoutID        int64
+	ExerciseName     string
+	Sets             int
+	Reps             *int
+	DurationSeconds  *int
+	Weight           *float64
+	OrderIndex       int
+	CreatedAt        time.Time
+	WorkoutUpdatedAt time.Time
+}
+
+// ? - body weight row, measurements are append-only until the user is purged
+type WeightChange struct {
+	ChangeMeta
+	ID         int64
+	UserID     int64
+	WeightKG   float64
+	MeasuredAt time.Time
+	CreatedAt  time.Time
+}
+
+// ? - first time a user saw an experiment variant, append-only until the user is purged
+type ExposureChange struct {
+	ChangeMeta
+	ID         int64
+	UserID     int64
+	Experiment string
+	Variant    string
+	ExposedAt  time.Time
+}
+
+// * holds the db connection for warehouse sync reads + cursor bookkeeping
+type PostgresWarehouseStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new warehouse store instance
+func NewPostgresWarehouseStore(db *sql.DB) *PostgresWarehouseStore {
+	return &PostgresWarehouseStore{db: db}
+}
+
+//! WarehouseStore interface --> change feeds for the warehouse connector, read from the warehouse_changes log
+type WarehouseStore interface {
+	GetSyncCursor(name string) (SyncCursor, error)
+	SaveSyncCursor(name string, cursor SyncCursor) error
+	//* oldest change first; a row shows up once with its latest change, Deleted when it's gone
+	ListWorkoutChanges(after SyncCursor, limit int) ([]*WorkoutChange, error)
+	ListEntryChanges(after SyncCursor, limit int) ([]*EntryChange, error)
+	ListWeightChanges(after SyncCursor, limit int) ([]*WeightChange, error)
+	ListExposureChanges(after SyncCursor, limit int) ([]*ExposureChange, error)
+}
+
+//! GetSyncCursor --> zero cursor (sync everything) when the stream never ran
+func (s *PostgresWarehouseStore) GetSyncCursor(name string) (SyncCursor, error) {
+	var cursor SyncCursor
+	err := s.db.QueryRow(`SELECT cursor_tx, cursor_seq FROM warehouse_sync_cursors WHERE name = $1`, name).Scan(&cursor.Tx, &cursor.Seq)
+	if err == sql.ErrNoRows {
+		return SyncCursor{}, nil
+	}
+	return cursor, err
+}
+
+func (s *PostgresWarehouseStore) SaveSyncCursor(name string, cursor SyncCursor) error {
+	query := `
+  INSERT INTO warehouse_sync_cursors (name, cursor_tx, cursor_seq)
+  VALUES ($1, $2, $3)
+  ON CONFLICT (name) DO UPDATE
+  SET cursor_tx = EXCLUDED.cursor_tx, cursor_seq = EXCLUDED.cursor_seq, updated_at = CURRENT_TIMESTAMP
+  `
+	_, err := s.db.Exec(query, name, cursor.Tx, cursor.Seq)
+	return err
+}
+
+//! warehouseChangePage --> one table's page of the log, repeated as a subquery by the row reads
+//? only transactions older than every running one: they are all finished, so nothing can still commit behind the cursor
+const warehouseChangePage = `
+  SELECT tx, seq, row_id, deleted FROM warehouse_changes
+  WHERE table_name = $1 AND (tx, seq) > ($2, $3) AND tx < txid_snapshot_xmin(txid_current_snapshot())
+  ORDER BY tx, seq
+  LIMIT $4`
+
+// * pageChange --> one warehouse_changes row
+type pageChange struct {
+	ChangeMeta
+	rowID int64
+}
+
+// * readChanges --> the page, then the live rows behind it through scan, in one snapshot
+//? rowsQuery gets the page as its %s, a change whose row is gone by now reads as deleted
+func (s *PostgresWarehouseStore) readChanges(table string, after SyncCursor, limit int, rowsQuery string, scan func(*sql.Rows) (int64, error)) ([]pageChange, error) {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(warehouseChangePage, table, after.Tx, after.Seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := []pageChange{}
+	for rows.Next() {
+		var change pageChange
+		err = rows.Scan(&change.Cursor.Tx, &change.Cursor.Seq, &change.rowID, &change.Deleted)
+		if err != nil {
+			return nil, err
+		}
+		page = append(page, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	live, err := tx.Query(fmt.Sprintf(rowsQuery, warehouseChangePage), table, after.Tx, after.Seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer live.Close()
+
+	found := map[int64]bool{}
+	for live.Next() {
+		id, err := scan(live)
+		if err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	if err = live.Err(); err != nil {
+		return nil, err
+	}
+	for i := range page {
+		page[i].Deleted = page[i].Deleted || !found[page[i].rowID]
+	}
+	return page, nil
+}
+
+func (s *PostgresWarehouseStore) ListWorkoutChanges(after SyncCursor, limit int) ([]*WorkoutChange, error) {
+	query := `
+  SELECT w.id, w.user_id, w.title, w.duration_minutes, COALESCE(w.calories_burned, 0), w.calories_estimated, w.flagged,
+         w.created_at, w.updated_at
+  FROM (%s) c
+  INNER JOIN workouts w ON w.id = c.row_id`
+	live := map[int64]*WorkoutChange{}
+	page, err := s.readChanges("workouts", after, limit, query, func(rows *sql.Rows) (int64, error) {
+		change := &WorkoutChange{}
+		err := rows.Scan(&change.ID, &change.UserID, &change.Title, &change.DurationMinutes, &change.CaloriesBurned,
+			&change.CaloriesEstimated, &change.Flagged, &change.CreatedAt, &change.UpdatedAt)
+		live[change.ID] = change
+		return change.ID, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*WorkoutChange, 0, len(page))
+	for _, c := range page {
+		change := &WorkoutChange{ID: c.rowID}
+		if !c.Deleted {
+			change = live[c.rowID]
+		}
+		change.ChangeMeta = c.ChangeMeta
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (s *PostgresWarehouseStore) ListEntryChanges(after SyncCursor, limit int) ([]*EntryChange, error) {
+	query := `
+  SELECT e.id, e.workout_id, e.exercise_name, e.sets, e.reps, e.duration_seconds, e.weight, e.order_index,
+         e.created_at, w.updated_at
+  FROM (%s) c
+  INNER JOIN workout_entries e ON e.id = c.row_id
+  INNER JOIN workouts w ON w.id = e.workout_id`
+	live := map[int64]*EntryChange{}
+	page, err := s.readChanges("workout_entries", after, limit, query, func(rows *sql.Rows) (int64, error) {
+		change := &EntryChange{}
+		err := rows.Scan(&change.ID, &change.WorkoutID, &change.ExerciseName, &change.Sets, &change.Reps, &change.DurationSeconds,
+			&change.Weight, &change.OrderIndex, &change.CreatedAt, &change.WorkoutUpdatedAt)
+		live[change.ID] = change
+		return change.ID, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*EntryChange, 0, len(page))
+	for _, c := range page {
+		change := &EntryChange{ID: c.rowID}
+		if !c.Deleted {
+			change = live[c.rowID]
+		}
+		change.ChangeMeta = c.ChangeMeta
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (s *PostgresWarehouseStore) ListWeightChanges(after SyncCursor, limit int) ([]*WeightChange, error) {
+	query := `
+  SELECT u.id, u.user_id, u.weight_kg, u.measured_at, u.created_at
+  FROM (%s) c
+  INNER JOIN user_weights u ON u.id = c.row_id`
+	live := map[int64]*WeightChange{}
+	page, err := s.readChanges("user_weights", after, limit, query, func(rows *sql.Rows) (int64, error) {
+		change := &WeightChange{}
+		err := rows.Scan(&change.ID, &change.UserID, &change.WeightKG, &change.MeasuredAt, &change.CreatedAt)
+		live[change.ID] = change
+		return change.ID, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*WeightChange, 0, len(page))
+	for _, c := range page {
+		change := &WeightChange{ID: c.rowID}
+		if !c.Deleted {
+			change = live[c.rowID]
+		}
+		change.ChangeMeta = c.ChangeMeta
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (s *PostgresWarehouseStore) ListExposureChanges(after SyncCursor, limit int) ([]*ExposureChange, error) {
+	query := `
+  SELECT x.id, x.user_id, x.experiment, x.variant, x.exposed_at
+  FROM (%s) c
+  INNER JOIN experiment_exposures x ON x.id = c.row_id`
+	live := map[int64]*ExposureChange{}
+	page, err := s.readChanges("experiment_exposures", after, limit, query, func(rows *sql.Rows) (int64, error) {
+		change := &ExposureChange{}
+		err := rows.Scan(&change.ID, &change.UserID, &change.Experiment, &change.Variant, &change.ExposedAt)
+		live[change.ID] = change
+		return change.ID, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*ExposureChange, 0, len(page))
+	for _, c := range page {
+		change := &ExposureChange{ID: c.rowID}
+		if !c.Deleted {
+			change = live[c.rowID]
+		}
+		change.ChangeMeta = c.ChangeMeta
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestPostgresWarehouseStore --> changes wait for older running transactions, deletes come back as tombstones
+func TestPostgresWarehouseStore(t *testing.T) {
+	db := openTestDB(t)
+	warehouse := NewPostgresWarehouseStore(db)
+	profiles := NewPostgresProfileStore(db)
+	ana := createTestUser(t, db, "ana")
+
+	//* a long transaction writes first and commits last
+	slow, err := db.Begin()
+	require.NoError(t, err)
+	defer slow.Rollback()
+	var slowID int64
+	require.NoError(t, slow.QueryRow(`INSERT INTO user_weights (user_id, weight_kg) VALUES ($1, 81) RETURNING id`, ana.ID).Scan(&slowID))
+
+	fast := &WeightEntry{UserID: ana.ID, WeightKG: 80, MeasuredAt: time.Now()}
+	require.NoError(t, profiles.AddWeight(fast))
+
+	changes, err := warehouse.ListWeightChanges(SyncCursor{}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "the fast commit waits until the slow transaction is done")
+
+	require.NoError(t, slow.Commit())
+	changes, err = warehouse.ListWeightChanges(SyncCursor{}, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, slowID, changes[0].ID)
+	assert.Equal(t, int64(fast.ID), changes[1].ID)
+	require.NoError(t, warehouse.SaveSyncCursor("user_weights", changes[1].Cursor))
+
+	cursor, err := warehouse.GetSyncCursor("user_weights")
+	require.NoError(t, err)
+	assert.Equal(t, changes[1].Cursor, cursor)
+	changes, err = warehouse.ListWeightChanges(cursor, 10)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	workout := createTestWorkout(t, db, ana.ID, "run")
+	require.NoError(t, NewPostgresWorkoutStore(db).DeleteWorkout(int64(workout.ID)))
+	workoutChanges, err := warehouse.ListWorkoutChanges(SyncCursor{}, 10)
+	require.NoError(t, err)
+	require.Len(t, workoutChanges, 1, "a row shows up once, with its latest change")
+	assert.Equal(t, int64(workout.ID), workoutChanges[0].ID)
+	assert.True(t, workoutChanges[0].Deleted)
+	entryChanges, err := warehouse.ListEntryChanges(SyncCursor{}, 10)
+	require.NoError(t, err)
+	require.Len(t, entryChanges, 1)
+	assert.True(t, entryChanges[0].Deleted, "the cascade deletes the entries too")
+}
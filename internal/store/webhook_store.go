@@ -0,0 +1,202 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+//! webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed" //* out of retries
+)
+
+// ? - a user's subscription, Secret only goes out in the create response
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int       `json:"-"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ? - one event sent (or being sent) to one webhook
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+// * holds the db connection for webhooks + their delivery log
+type PostgresWebhookStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new webhook store instance
+func NewPostgresWebhookStore(db *sql.DB) *PostgresWebhookStore {
+	return &PostgresWebhookStore{db: db}
+}
+
+//! WebhookStore interface --> subscriptions + delivery log
+type WebhookStore interface {
+	CreateWebhook(*Webhook) error
+	GetWebhook(id int64) (*Webhook, error)
+	ListWebhooks(userID int) ([]*Webhook, error)
+	ListWebhooksForEvent(userID int, event string) ([]*Webhook, error)
+	DeleteWebhook(id int64) error
+	CreateDelivery(*WebhookDelivery) error
+	GetDelivery(id int64) (*WebhookDelivery, error)
+	ListDeliveries(webhookID int64, limit int) ([]*WebhookDelivery, error)
+	RecordDeliveryAttempt(id int64, status string, responseStatus *int, lastError string) error
+}
+
+//* events comes back as a JSON array, database/sql can't scan TEXT[] directly
+const webhookColumns = `id, user_id, url, secret, array_to_json(events), created_at`
+
+func scanWebhook(row interface{ Scan(...any) error }) (*Webhook, error) {
+	w := &Webhook{}
+	var events []byte
+	err := row.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &events, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return w, json.Unmarshal(events, &w.Events)
+}
+
+func (s *PostgresWebhookStore) CreateWebhook(w *Webhook) error {
+	query := `
+  INSERT INTO webhooks (user_id, url, secret, events)
+  VALUES ($1, $2, $3, $4)
+  RETURNING id, created_at
+  `
+	return s.db.QueryRow(query, w.UserID, w.URL, w.Secret, w.Events).Scan(&w.ID, &w.CreatedAt)
+}
+
+func (s *PostgresWebhookStore) GetWebhook(id int64) (*Webhook, error) {
+	w, err := scanWebhook(s.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return w, err
+}
+
+func (s *PostgresWebhookStore) listWebhooks(query string, args ...any) ([]*Webhook, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+func (s *PostgresWebhookStore) ListWebhooks(userID int) ([]*Webhook, error) {
+	return s.listWebhooks(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 ORDER BY id`, userID)
+}
+
+//! ListWebhooksForEvent --> the user's webhooks subscribed to event
+func (s *PostgresWebhookStore) ListWebhooksForEvent(userID int, event string) ([]*Webhook, error) {
+	return s.listWebhooks(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 AND $2 = ANY(events) ORDER BY id`, userID, event)
+}
+
+func (s *PostgresWebhookStore) DeleteWebhook(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresWebhookStore) CreateDelivery(d *WebhookDelivery) error {
+	query := `
+  INSERT INTO webhook_deliveries (webhook_id, event, payload)
+  VALUES ($1, $2, $3)
+  RETURNING id, status, attempts, created_at
+  `
+	return s.db.QueryRow(query, d.WebhookID, d.Event, []byte(d.Payload)).Scan(&d.ID, &d.Status, &d.Attempts, &d.CreatedAt)
+}
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, response_status, COALESCE(last_error, ''), created_at, delivered_at`
+
+func scanDelivery(row interface{ Scan(...any) error }) (*WebhookDelivery, error) {
+	d := &WebhookDelivery{}
+	var payload []byte
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return d, nil
+}
+
+func (s *PostgresWebhookStore) GetDelivery(id int64) (*WebhookDelivery, error) {
+	d, err := scanDelivery(s.db.QueryRow(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+//! ListDeliveries --> newest first
+func (s *PostgresWebhookStore) ListDeliveries(webhookID int64, limit int) ([]*WebhookDelivery, error) {
+	query := `
+  SELECT ` + deliveryColumns + `
+  FROM webhook_deliveries
+  WHERE webhook_id = $1
+  ORDER BY created_at DESC, id DESC
+  LIMIT $2
+  `
+	rows, err := s.db.Query(query, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+//! RecordDeliveryAttempt --> counts the attempt, delivered_at is set on success
+func (s *PostgresWebhookStore) RecordDeliveryAttempt(id int64, status string, responseStatus *int, lastError string) error {
+	query := `
+  UPDATE webhook_deliveries
+  SET status = $2, attempts = attempts + 1, response_status = $3, last_error = NULLIF($4, ''),
+      delivered_at = CASE WHEN $2 = 'succeeded' THEN CURRENT_TIMESTAMP ELSE delivered_at END
+  WHERE id = $1
+  `
+	_, err := s.db.Exec(query, id, status, responseStatus, lastError)
+	return err
+}
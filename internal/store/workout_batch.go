@@ -0,0 +1,286 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fem/internal/events"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ! WorkoutOp kinds --> what one step of BatchWorkouts does with its Workout
+const (
+	WorkoutOpCreate = "create" //* Workout.ID is set on success
+	WorkoutOpUpdate = "update" //* like UpdateWorkout, ownership is the caller's business
+	WorkoutOpDelete = "delete" //* only Workout.ID is read, ErrNotFound when it's gone
+)
+
+// ! WorkoutOp --> one step of a BatchWorkouts call
+type WorkoutOp struct {
+	Op      string
+	Workout *Workout
+	SetTags bool //* create / update: Workout.Tags replace the workout's tags in the same transaction
+}
+
+// ! ErrBatchAborted --> an atomic batch rolled back because of another op, this one wasn't applied (or was undone)
+var ErrBatchAborted = errors.New("store: batch rolled back, another operation failed")
+
+// ! BatchWorkouts contract --> ops run in order in one transaction with a savepoint each, like ImportWorkouts;
+// ? opErrs[i] is nil when ops[i] went through. atomic --> the first failure rolls everything back, that op keeps
+// ? its error and every other one gets ErrBatchAborted; the error return is for the transaction itself
+
+// * abortBatch --> opErrs once failed sank an atomic batch, created workouts lose the id they never kept
+func abortBatch(ops []WorkoutOp, opErrs []error, failed int) []error {
+	for i, op := range ops {
+		if i != failed {
+			opErrs[i] = ErrBatchAborted
+		}
+		if op.Op == WorkoutOpCreate {
+			op.Workout.ID = 0
+		}
+	}
+	return opErrs
+}
+
+// * deletedRow --> ErrNotFound when a DELETE matched nothing
+func deletedRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func unknownOp(op string) error {
+	return fmt.Errorf("store: unknown workout op %q", op)
+}
+
+// * workoutOpEvent --> the event a create or update leaves in the outbox, deletes write theirs before the row goes
+func workoutOpEvent(op string) string {
+	if op == WorkoutOpCreate {
+		return events.WorkoutCreated
+	}
+	return events.WorkoutUpdated
+}
+
+// * applyWorkoutOp --> the op + its events_outbox row, the single-workout writes go through here too
+func applyWorkoutOp(tx *sql.Tx, op WorkoutOp) error {
+	var err error
+	switch op.Op {
+	case WorkoutOpCreate:
+		err = insertWorkout(tx, op.Workout, nil)
+	case WorkoutOpUpdate:
+		err = updateWorkout(tx, op.Workout)
+	case WorkoutOpDelete:
+		err = enqueueWorkoutEvent(tx, events.WorkoutDeleted, op.Workout.ID)
+		if err != nil {
+			return err
+		}
+		return deletedRow(tx.Exec(`DELETE FROM workouts WHERE id = $1`, op.Workout.ID))
+	default:
+		return unknownOp(op.Op)
+	}
+	if err == nil && op.SetTags {
+		err = replaceWorkoutTags(tx, int64(op.Workout.ID), op.Workout.Tags)
+	}
+	if err != nil {
+		return err
+	}
+	return enqueueWorkoutEvent(tx, workoutOpEvent(op.Op), op.Workout.ID)
+}
+
+// ! BatchWorkouts --> see the contract above
+func (pg *PostgresWorkoutStore) BatchWorkouts(ops []WorkoutOp, atomic bool) ([]error, error) {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	opErrs := make([]error, len(ops))
+	for i, op := range ops {
+		_, err = tx.Exec(`SAVEPOINT batch_op`)
+		if err != nil {
+			return nil, err
+		}
+
+		opErrs[i] = mapError(applyWorkoutOp(tx, op))
+		switch {
+		case opErrs[i] != nil && atomic:
+			return abortBatch(ops, opErrs, i), nil //* the deferred Rollback undoes the rest
+		case opErrs[i] != nil:
+			if op.Op == WorkoutOpCreate {
+				op.Workout.ID = 0
+			}
+			_, err = tx.Exec(`ROLLBACK TO SAVEPOINT batch_op`)
+		default:
+			_, err = tx.Exec(`RELEASE SAVEPOINT batch_op`)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return opErrs, nil
+}
+
+// * errNoTagsSQLite --> the sqlite schema has no workout_tags table, the service turns tags off there
+var errNoTagsSQLite = errors.New("store: workout tags need postgres")
+
+func applyWorkoutOpSQLite(tx *sql.Tx, op WorkoutOp) error {
+	var err error
+	switch op.Op {
+	case WorkoutOpCreate:
+		err = insertWorkoutSQLite(tx, op.Workout, nil)
+	case WorkoutOpUpdate:
+		err = updateWorkoutSQLite(tx, op.Workout)
+	case WorkoutOpDelete:
+		err = enqueueWorkoutEventSQLite(tx, events.WorkoutDeleted, op.Workout.ID)
+		if err != nil {
+			return err
+		}
+		return deletedRow(tx.Exec(`DELETE FROM workouts WHERE id = ?`, op.Workout.ID))
+	default:
+		return unknownOp(op.Op)
+	}
+	if err == nil && op.SetTags {
+		err = errNoTagsSQLite
+	}
+	if err != nil {
+		return err
+	}
+	return enqueueWorkoutEventSQLite(tx, workoutOpEvent(op.Op), op.Workout.ID)
+}
+
+// ! BatchWorkouts --> the postgres contract, sqlite keeps a savepoint open after ROLLBACK TO so it's released too
+func (s *SQLiteWorkoutStore) BatchWorkouts(ops []WorkoutOp, atomic bool) ([]error, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	opErrs := make([]error, len(ops))
+	for i, op := range ops {
+		_, err = tx.Exec(`SAVEPOINT batch_op`)
+		if err != nil {
+			return nil, err
+		}
+
+		opErrs[i] = mapError(applyWorkoutOpSQLite(tx, op))
+		switch {
+		case opErrs[i] != nil && atomic:
+			return abortBatch(ops, opErrs, i), nil
+		case opErrs[i] != nil:
+			if op.Op == WorkoutOpCreate {
+				op.Workout.ID = 0
+			}
+			_, err = tx.Exec(`ROLLBACK TO SAVEPOINT batch_op`)
+			if err == nil {
+				_, err = tx.Exec(`RELEASE SAVEPOINT batch_op`)
+			}
+		default:
+			_, err = tx.Exec(`RELEASE SAVEPOINT batch_op`)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return opErrs, nil
+}
+
+// * replaceWorkoutTagsPgx --> replaceWorkoutTags on a pgx transaction
+func replaceWorkoutTagsPgx(ctx context.Context, tx pgx.Tx, workoutID int64, tags []string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM workout_tags WHERE workout_id = $1`, workoutID)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO workout_tags (workout_id, tag) SELECT $1, UNNEST($2::text[])`, workoutID, tags)
+	return err
+}
+
+func applyWorkoutOpPgx(ctx context.Context, tx pgx.Tx, op WorkoutOp) error {
+	var err error
+	switch op.Op {
+	case WorkoutOpCreate:
+		err = insertWorkoutPgx(ctx, tx, op.Workout, nil)
+	case WorkoutOpUpdate:
+		err = updateWorkoutPgx(ctx, tx, op.Workout)
+	case WorkoutOpDelete:
+		err = enqueueWorkoutEventPgx(ctx, tx, events.WorkoutDeleted, op.Workout.ID)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `DELETE FROM workouts WHERE id = $1`, op.Workout.ID)
+		if err == nil && tag.RowsAffected() == 0 {
+			err = ErrNotFound
+		}
+		return err
+	default:
+		return unknownOp(op.Op)
+	}
+	if err == nil && op.SetTags {
+		err = replaceWorkoutTagsPgx(ctx, tx, int64(op.Workout.ID), op.Workout.Tags)
+	}
+	if err != nil {
+		return err
+	}
+	return enqueueWorkoutEventPgx(ctx, tx, workoutOpEvent(op.Op), op.Workout.ID)
+}
+
+// ! BatchWorkouts --> the postgres contract on the pool
+func (pg *PgxWorkoutStore) BatchWorkouts(ops []WorkoutOp, atomic bool) ([]error, error) {
+	ctx := context.Background()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	opErrs := make([]error, len(ops))
+	for i, op := range ops {
+		_, err = tx.Exec(ctx, `SAVEPOINT batch_op`)
+		if err != nil {
+			return nil, err
+		}
+
+		opErrs[i] = mapError(applyWorkoutOpPgx(ctx, tx, op))
+		switch {
+		case opErrs[i] != nil && atomic:
+			return abortBatch(ops, opErrs, i), nil
+		case opErrs[i] != nil:
+			if op.Op == WorkoutOpCreate {
+				op.Workout.ID = 0
+			}
+			_, err = tx.Exec(ctx, `ROLLBACK TO SAVEPOINT batch_op`)
+		default:
+			_, err = tx.Exec(ctx, `RELEASE SAVEPOINT batch_op`)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return opErrs, nil
+}
@@ -0,0 +1,284 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fem/internal/events"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+//! OpenPool --> native pgx pool on the same DB_* settings as Open, sized by DB_MAX_OPEN_CONNS / DB_CONN_MAX_*
+func OpenPool(ctx context.Context) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(connString())
+	if err != nil {
+		return nil, fmt.Errorf("db : pool config %w", err)
+	}
+	limits := PoolConfigFromEnv()
+	if limits.MaxOpenConns > 0 {
+		config.MaxConns = int32(limits.MaxOpenConns)
+	}
+	config.MaxConnLifetime = limits.ConnMaxLifetime
+	config.MaxConnIdleTime = limits.ConnMaxIdleTime
+
+	pool, err := pgxpool.ConnectConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("db : pool connect %w", err)
+	}
+	return pool, nil
+}
+
+//! PgxWorkoutStore --> WorkoutStore on pgxpool, selected with WORKOUT_STORE_DRIVER=pgxpool
+//? same SQL + semantics as PostgresWorkoutStore, but entries go out as one batch and reads pipeline both queries
+//? errors go through mapError like everywhere else, so the service layer doesn't care which driver is behind it
+type PgxWorkoutStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxWorkoutStore(pool *pgxpool.Pool) *PgxWorkoutStore {
+	return &PgxWorkoutStore{pool: pool}
+}
+
+const insertEntryQuery = `
+  INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, distance, notes, order_index)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+  RETURNING id
+  `
+
+//! queueEntries --> one INSERT per entry, sent to the server in a single round trip
+func queueEntries(batch *pgx.Batch, workout *Workout) {
+	for _, entry := range workout.Entries {
+		batch.Queue(insertEntryQuery, workout.ID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Distance, entry.Notes, entry.OrderIndex)
+	}
+}
+
+//! readEntryIDs --> results come back in queue order, skip is how many non-entry statements were queued first
+func readEntryIDs(results pgx.BatchResults, workout *Workout, skip int) error {
+	defer results.Close()
+	for i := 0; i < skip; i++ {
+		_, err := results.Exec()
+		if err != nil {
+			return err
+		}
+	}
+	for i := range workout.Entries {
+		err := results.QueryRow().Scan(&workout.Entries[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+	return results.Close()
+}
+
+//! insertWorkoutPgx --> insertWorkout for a pgx transaction, the entries batch needs the new workout id first
+func insertWorkoutPgx(ctx context.Context, tx pgx.Tx, workout *Workout, ref *ExternalRef) error {
+	query := `
+  INSERT INTO workouts (user_id, title, description, duration_minutes, calories_burned, calories_estimated, visibility, flagged, created_at, external_source, external_id, performed_at)
+  VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'private'), $8, COALESCE($9, CURRENT_TIMESTAMP), $10, $11, COALESCE($12, $9, CURRENT_TIMESTAMP))
+  ON CONFLICT (user_id, external_source, external_id) WHERE external_id IS NOT NULL DO NOTHING
+  RETURNING id, visibility, flagged, verified, created_at, performed_at
+  `
+	var createdAt, performedAt *time.Time
+	if !workout.CreatedAt.IsZero() {
+		createdAt = &workout.CreatedAt
+	}
+	if !workout.PerformedAt.IsZero() {
+		performedAt = &workout.PerformedAt
+	}
+	var source, externalID *string
+	if ref != nil {
+		source, externalID = &ref.Source, &ref.ID
+	}
+
+	err := tx.QueryRow(ctx, query, workout.UserID, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, workout.CaloriesEstimated, workout.Visibility, workout.Flagged, createdAt, source, externalID, performedAt).Scan(&workout.ID, &workout.Visibility, &workout.Flagged, &workout.Verified, &workout.CreatedAt, &workout.PerformedAt)
+	if err != nil {
+		return mapError(err)
+	}
+	if len(workout.Entries) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	queueEntries(batch, workout)
+	return readEntryIDs(tx.SendBatch(ctx, batch), workout, 0)
+}
+
+func (pg *PgxWorkoutStore) CreateWorkout(workout *Workout) (*Workout, error) {
+	ctx := context.Background()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = applyWorkoutOpPgx(ctx, tx, WorkoutOp{Op: WorkoutOpCreate, Workout: workout})
+	if err != nil {
+		return nil, err
+	}
+	return workout, tx.Commit(ctx)
+}
+
+//! ImportWorkouts --> same savepoint-per-row contract as PostgresWorkoutStore.ImportWorkouts
+func (pg *PgxWorkoutStore) ImportWorkouts(workouts []*Workout) ([]error, error) {
+	ctx := context.Background()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rowErrs := make([]error, len(workouts))
+	for i, workout := range workouts {
+		_, err = tx.Exec(ctx, `SAVEPOINT import_row`)
+		if err != nil {
+			return nil, err
+		}
+
+		rowErrs[i] = insertWorkoutPgx(ctx, tx, workout, nil)
+		if rowErrs[i] != nil {
+			workout.ID = 0
+			_, err = tx.Exec(ctx, `ROLLBACK TO SAVEPOINT import_row`)
+		} else {
+			_, err = tx.Exec(ctx, `RELEASE SAVEPOINT import_row`)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rowErrs, nil
+}
+
+func (pg *PgxWorkoutStore) CreateExternalWorkout(workout *Workout, ref ExternalRef) (bool, error) {
+	ctx := context.Background()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = insertWorkoutPgx(ctx, tx, workout, &ref)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err == nil {
+		err = enqueueWorkoutEventPgx(ctx, tx, events.WorkoutCreated, workout.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+//! GetWorkoutByID --> workout + entries pipelined in one round trip
+func (pg *PgxWorkoutStore) GetWorkoutByID(id int64) (*Workout, error) {
+	ctx := context.Background()
+	batch := &pgx.Batch{}
+	batch.Queue(`
+  SELECT id, user_id, title, description, duration_minutes, calories_burned, calories_estimated, visibility, flagged, verified, created_at, performed_at
+  FROM workouts
+  WHERE id = $1
+  `, id)
+	batch.Queue(`
+  SELECT id, exercise_name, sets, reps, duration_seconds, weight, distance, notes, order_index
+  FROM workout_entries
+  WHERE workout_id = $1
+  ORDER BY order_index
+  `, id)
+
+	results := pg.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	workout := &Workout{}
+	err := results.QueryRow().Scan(&workout.ID, &workout.UserID, &workout.Title, &workout.Description, &workout.DurationMinutes, &workout.CaloriesBurned, &workout.CaloriesEstimated, &workout.Visibility, &workout.Flagged, &workout.Verified, &workout.CreatedAt, &workout.PerformedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry WorkoutEntry
+		err = rows.Scan(&entry.ID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds, &entry.Weight, &entry.Distance, &entry.Notes, &entry.OrderIndex)
+		if err != nil {
+			return nil, err
+		}
+		workout.Entries = append(workout.Entries, entry)
+	}
+	return workout, rows.Err()
+}
+
+//! UpdateWorkout --> every statement, entries included, goes out in a single batch
+func (pg *PgxWorkoutStore) UpdateWorkout(workout *Workout) error {
+	ctx := context.Background()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = applyWorkoutOpPgx(ctx, tx, WorkoutOp{Op: WorkoutOpUpdate, Workout: workout})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func updateWorkoutPgx(ctx context.Context, tx pgx.Tx, workout *Workout) error {
+	batch := &pgx.Batch{}
+	batch.Queue(`
+  UPDATE workouts
+  SET title = $1, description = $2, duration_minutes = $3, calories_burned = $4, calories_estimated = $5,
+      visibility = $6, flagged = $7, verified = FALSE, updated_at = CURRENT_TIMESTAMP, performed_at = COALESCE($9, performed_at)
+  WHERE id = $8
+  `, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, workout.CaloriesEstimated, workout.Visibility, workout.Flagged, workout.ID, workout.PerformedAtOrNil())
+	//! an edited workout is no longer what the coach attested --> send it back through verification
+	batch.Queue(`
+  UPDATE workout_verifications
+  SET status = 'pending', attested_by = NULL, approved = NULL, attested_at = NULL, updated_at = CURRENT_TIMESTAMP
+  WHERE workout_id = $1
+  `, workout.ID)
+	batch.Queue(`DELETE FROM workout_entries WHERE workout_id = $1`, workout.ID)
+	queueEntries(batch, workout)
+
+	err := readEntryIDs(tx.SendBatch(ctx, batch), workout, 3)
+	if err != nil {
+		return err
+	}
+	workout.Verified = false
+	return nil
+}
+
+func (pg *PgxWorkoutStore) DeleteWorkout(id int64) error {
+	ctx := context.Background()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = applyWorkoutOpPgx(ctx, tx, WorkoutOp{Op: WorkoutOpDelete, Workout: &Workout{ID: int(id)}})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (pg *PgxWorkoutStore) GetWorkoutOwner(workoutID int64) (int, error) {
+	var userID int
+	err := pg.pool.QueryRow(context.Background(), `SELECT user_id FROM workouts WHERE id = $1`, workoutID).Scan(&userID)
+	return userID, mapError(err)
+}
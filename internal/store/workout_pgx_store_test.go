@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestPgxWorkoutStore --> create / read / update / delete through the batched pgxpool store
+func TestPgxWorkoutStore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	pool, err := pgxpool.Connect(context.Background(), testDSN)
+	require.NoError(t, err)
+	defer pool.Close()
+	store := NewPgxWorkoutStore(pool)
+
+	workout := &Workout{
+		UserID:          1,
+		Title:           "leg day",
+		DurationMinutes: 45,
+		Entries: []WorkoutEntry{
+			{ExerciseName: "squat", Sets: 5, Reps: intPointer(5), Weight: floatPointer(100.5), OrderIndex: 1},
+			{ExerciseName: "wall sit", Sets: 3, DurationSeconds: intPointer(60), OrderIndex: 2},
+		},
+	}
+	created, err := store.CreateWorkout(workout)
+	require.NoError(t, err)
+	assert.NotZero(t, created.Entries[0].ID)
+	assert.NotZero(t, created.Entries[1].ID)
+
+	retrieved, err := store.GetWorkoutByID(int64(created.ID))
+	require.NoError(t, err)
+	assert.Equal(t, created.Entries, retrieved.Entries)
+
+	retrieved.Title = "heavy leg day"
+	retrieved.Entries = retrieved.Entries[:1]
+	require.NoError(t, store.UpdateWorkout(retrieved))
+	updated, err := store.GetWorkoutByID(int64(created.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "heavy leg day", updated.Title)
+	assert.Len(t, updated.Entries, 1)
+
+	owner, err := store.GetWorkoutOwner(int64(created.ID))
+	require.NoError(t, err)
+	assert.Equal(t, 1, owner)
+
+	require.NoError(t, store.DeleteWorkout(int64(created.ID)))
+	assert.ErrorIs(t, store.DeleteWorkout(int64(created.ID)), ErrNotFound)
+	missing, err := store.GetWorkoutByID(int64(created.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, missing)
+}
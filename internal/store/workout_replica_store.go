@@ -0,0 +1,50 @@
+package store
+
+//! ReplicaWorkoutStore --> what a dual-write secondary has to support, ids always come from the primary
+//? DeleteWorkout queues workout.deleted in the secondary's events_outbox too, empty that table at cutover
+type ReplicaWorkoutStore interface {
+	UpsertWorkout(workout *Workout) error
+	DeleteWorkout(id int64) error
+	GetWorkoutByID(id int64) (*Workout, error)
+}
+
+//! UpsertWorkout --> writes the workout under its primary id, entries are replaced wholesale
+//? explicit ids don't advance the workouts id sequence, setval it before this database takes writes on its own
+func (pg *PostgresWorkoutStore) UpsertWorkout(workout *Workout) error {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+  INSERT INTO workouts (id, user_id, title, description, duration_minutes, calories_burned, calories_estimated, visibility, flagged, verified, created_at, performed_at)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12, $11))
+  ON CONFLICT (id) DO UPDATE
+  SET user_id = EXCLUDED.user_id, title = EXCLUDED.title, description = EXCLUDED.description,
+      duration_minutes = EXCLUDED.duration_minutes, calories_burned = EXCLUDED.calories_burned,
+      calories_estimated = EXCLUDED.calories_estimated, visibility = EXCLUDED.visibility, flagged = EXCLUDED.flagged,
+      verified = EXCLUDED.verified, performed_at = EXCLUDED.performed_at, updated_at = CURRENT_TIMESTAMP
+  `
+	_, err = tx.Exec(query, workout.ID, workout.UserID, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned,
+		workout.CaloriesEstimated, workout.Visibility, workout.Flagged, workout.Verified, workout.CreatedAt, workout.PerformedAtOrNil())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM workout_entries WHERE workout_id = $1`, workout.ID)
+	if err != nil {
+		return err
+	}
+	for _, entry := range workout.Entries {
+		query := `
+    INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, distance, notes, order_index)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+		_, err = tx.Exec(query, workout.ID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Distance, entry.Notes, entry.OrderIndex)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,259 @@
+package store
+
+import (
+	"database/sql"
+	"fem/internal/events"
+	"strings"
+	"time"
+)
+
+//! SQLiteWorkoutStore --> WorkoutStore on the single-file sqlite database (DB_DRIVER=sqlite)
+type SQLiteWorkoutStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteWorkoutStore(db *sql.DB) *SQLiteWorkoutStore {
+	return &SQLiteWorkoutStore{db: db}
+}
+
+func (s *SQLiteWorkoutStore) CreateWorkout(workout *Workout) (*Workout, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = applyWorkoutOpSQLite(tx, WorkoutOp{Op: WorkoutOpCreate, Workout: workout})
+	if err != nil {
+		return nil, err
+	}
+	return workout, tx.Commit()
+}
+
+//! insertWorkoutSQLite --> same contract as insertWorkout: zero CreatedAt means now, a synced duplicate answers sql.ErrNoRows
+func insertWorkoutSQLite(tx *sql.Tx, workout *Workout, ref *ExternalRef) error {
+	query := `
+  INSERT INTO workouts (user_id, title, description, duration_minutes, calories_burned, calories_estimated, visibility, flagged, created_at, updated_at, external_source, external_id, performed_at)
+  VALUES (?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), 'private'), ?, ?, ?, ?, ?, ?)
+  ON CONFLICT (user_id, external_source, external_id) WHERE external_id IS NOT NULL DO NOTHING
+  RETURNING id, visibility, flagged, verified
+  `
+	now := time.Now().UTC()
+	createdAt := now
+	if !workout.CreatedAt.IsZero() {
+		createdAt = workout.CreatedAt.UTC()
+	}
+	performedAt := createdAt
+	if !workout.PerformedAt.IsZero() {
+		performedAt = workout.PerformedAt.UTC()
+	}
+	var source, externalID *string
+	if ref != nil {
+		source, externalID = &ref.Source, &ref.ID
+	}
+
+	err := tx.QueryRow(query, workout.UserID, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, workout.CaloriesEstimated, workout.Visibility, workout.Flagged, createdAt, now, source, externalID, performedAt).Scan(&workout.ID, &workout.Visibility, &workout.Flagged, &workout.Verified)
+	if err != nil {
+		return err
+	}
+	workout.CreatedAt, workout.PerformedAt = createdAt, performedAt
+	return insertEntriesSQLite(tx, workout)
+}
+
+//! insertEntriesSQLite --> one multi-row INSERT, sqlite allows 32766 params so no chunking below that
+func insertEntriesSQLite(tx *sql.Tx, workout *Workout) error {
+	for start := 0; start < len(workout.Entries); start += entryInsertChunk {
+		chunk := workout.Entries[start:min(start+entryInsertChunk, len(workout.Entries))]
+
+		var query strings.Builder
+		query.WriteString("INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, distance, notes, order_index) VALUES ")
+		args := make([]any, 0, len(chunk)*9)
+		for i, entry := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+			args = append(args, workout.ID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Distance, entry.Notes, entry.OrderIndex)
+		}
+		query.WriteString(" RETURNING id")
+
+		rows, err := tx.Query(query.String(), args...)
+		if err != nil {
+			return err
+		}
+		i := 0
+		for rows.Next() {
+			err = rows.Scan(&chunk[i].ID)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			i++
+		}
+		rows.Close()
+		err = rows.Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//! ImportWorkouts --> one transaction, a savepoint per workout, like the postgres store
+func (s *SQLiteWorkoutStore) ImportWorkouts(workouts []*Workout) ([]error, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rowErrs := make([]error, len(workouts))
+	for i, workout := range workouts {
+		_, err = tx.Exec(`SAVEPOINT import_row`)
+		if err != nil {
+			return nil, err
+		}
+
+		rowErrs[i] = insertWorkoutSQLite(tx, workout, nil)
+		if rowErrs[i] != nil {
+			workout.ID = 0
+			_, err = tx.Exec(`ROLLBACK TO SAVEPOINT import_row`)
+			if err == nil {
+				_, err = tx.Exec(`RELEASE SAVEPOINT import_row`) //* sqlite keeps the savepoint open after ROLLBACK TO
+			}
+		} else {
+			_, err = tx.Exec(`RELEASE SAVEPOINT import_row`)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return rowErrs, nil
+}
+
+func (s *SQLiteWorkoutStore) CreateExternalWorkout(workout *Workout, ref ExternalRef) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	err = insertWorkoutSQLite(tx, workout, &ref)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err == nil {
+		err = enqueueWorkoutEventSQLite(tx, events.WorkoutCreated, workout.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *SQLiteWorkoutStore) GetWorkoutByID(id int64) (*Workout, error) {
+	workout := &Workout{}
+	query := `
+  SELECT id, user_id, title, description, duration_minutes, calories_burned, calories_estimated, visibility, flagged, verified, created_at, performed_at
+  FROM workouts
+  WHERE id = ?
+  `
+	var performedAt sql.NullTime //* NULL on rows written before the column was added
+	err := s.db.QueryRow(query, id).Scan(&workout.ID, &workout.UserID, &workout.Title, &workout.Description, &workout.DurationMinutes, &workout.CaloriesBurned, &workout.CaloriesEstimated, &workout.Visibility, &workout.Flagged, &workout.Verified, &workout.CreatedAt, &performedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	workout.PerformedAt = workout.CreatedAt
+	if performedAt.Valid {
+		workout.PerformedAt = performedAt.Time
+	}
+
+	entryQuery := `
+  SELECT id, exercise_name, sets, reps, duration_seconds, weight, distance, notes, order_index
+  FROM workout_entries
+  WHERE workout_id = ?
+  ORDER BY order_index
+  `
+	rows, err := s.db.Query(entryQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry WorkoutEntry
+		err = rows.Scan(&entry.ID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds, &entry.Weight, &entry.Distance, &entry.Notes, &entry.OrderIndex)
+		if err != nil {
+			return nil, err
+		}
+		workout.Entries = append(workout.Entries, entry)
+	}
+	return workout, rows.Err()
+}
+
+//! UpdateWorkout --> workout row + entries replaced in one transaction, verification resets with the edit
+func (s *SQLiteWorkoutStore) UpdateWorkout(workout *Workout) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = applyWorkoutOpSQLite(tx, WorkoutOp{Op: WorkoutOpUpdate, Workout: workout})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func updateWorkoutSQLite(tx *sql.Tx, workout *Workout) error {
+	query := `
+  UPDATE workouts
+  SET title = ?, description = ?, duration_minutes = ?, calories_burned = ?, calories_estimated = ?,
+      visibility = ?, flagged = ?, verified = FALSE, updated_at = ?, performed_at = COALESCE(?, performed_at, created_at)
+  WHERE id = ?
+  `
+	_, err := tx.Exec(query, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, workout.CaloriesEstimated, workout.Visibility, workout.Flagged, time.Now().UTC(), workout.PerformedAtOrNil(), workout.ID)
+	if err != nil {
+		return err
+	}
+	workout.Verified = false
+
+	_, err = tx.Exec("DELETE FROM workout_entries WHERE workout_id = ?", workout.ID)
+	if err != nil {
+		return err
+	}
+	return insertEntriesSQLite(tx, workout)
+}
+
+//! DeleteWorkout --> entries go with it through ON DELETE CASCADE
+func (s *SQLiteWorkoutStore) DeleteWorkout(id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = applyWorkoutOpSQLite(tx, WorkoutOp{Op: WorkoutOpDelete, Workout: &Workout{ID: int(id)}})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteWorkoutStore) GetWorkoutOwner(workoutID int64) (int, error) {
+	var userID int
+	err := s.db.QueryRow(`SELECT user_id FROM workouts WHERE id = ?`, workoutID).Scan(&userID)
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return userID, nil
+}
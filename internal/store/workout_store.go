@@ -1,16 +1,44 @@
 package store
 
-import "database/sql"
+import (
+	"database/sql"
+	"fem/internal/events"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//! workout visibility levels --> who besides the owner may see a workout
+const (
+	VisibilityPrivate   = "private"
+	VisibilityFollowers = "followers"
+	VisibilityPublic    = "public"
+)
 
 // ? - main workout data structure
 type Workout struct {
-	ID              int            `json:"id"`
-	UserID          int            `json:"user_id"`
-	Title           string         `json:"title"`
-	Description     string         `json:"description"`
-	DurationMinutes int            `json:"duration_minutes"`
-	CaloriesBurned  int            `json:"calories_burned"`
-	Entries         []WorkoutEntry `json:"entries"` // ! nested entries for each exercise
+	ID                int            `json:"id"`
+	UserID            int            `json:"user_id"`
+	Title             string         `json:"title"`
+	Description       string         `json:"description"`
+	DurationMinutes   int            `json:"duration_minutes"`
+	CaloriesBurned    int            `json:"calories_burned"`
+	CaloriesEstimated bool           `json:"calories_estimated"` // * true when calories_burned came from the MET estimator
+	Visibility        string         `json:"visibility"`         // * private | followers | public
+	Flagged           bool           `json:"flagged"`            // * saved despite anomaly warnings, kept out of stats
+	Verified          bool           `json:"verified"`           // * passed the verification pipeline, leaderboard eligible
+	CreatedAt         time.Time      `json:"created_at"`
+	PerformedAt       time.Time      `json:"performed_at"`       // * when it was done, defaults to created_at, stats + streaks use it
+	Tags              []string       `json:"tags,omitempty"`     // * from workout_tags, only set by routes that load them
+	Entries           []WorkoutEntry `json:"entries"` // ! nested entries for each exercise
+}
+
+// ? - PerformedAtOrNil --> nil keeps the stored performed_at on update
+func (w *Workout) PerformedAtOrNil() *time.Time {
+	if w.PerformedAt.IsZero() {
+		return nil
+	}
+	return &w.PerformedAt
 }
 
 // ? - individual exercise within a workout
@@ -20,11 +48,18 @@ type WorkoutEntry struct {
 	Sets            int      `json:"sets"`
 	Reps            *int     `json:"reps"` // * pointer so it can be null
 	DurationSeconds *int     `json:"duration_seconds"` // * pointer so it can be null
-	Weight          *float64 `json:"weight"` // * pointer so it can be null
+	Weight          *float64 `json:"weight"` // * kg, pointer so it can be null
+	Distance        *float64 `json:"distance"` // * meters, pointer so it can be null
 	Notes           string   `json:"notes"`
 	OrderIndex      int      `json:"order_index"`
 }
 
+// ? - where a synced workout came from, e.g. {strava, 1234567}
+type ExternalRef struct {
+	Source string
+	ID     string
+}
+
 // * holds the db connection for workout operations
 type PostgresWorkoutStore struct {
 	db *sql.DB
@@ -39,10 +74,13 @@ func NewPostgresWorkoutStore(db *sql.DB) *PostgresWorkoutStore {
 //! collection of methods
 type WorkoutStore interface {
 	CreateWorkout(*Workout) (*Workout, error)
-	GetWorkoutByID(id int64) (*Workout, error)
+	ImportWorkouts(workouts []*Workout) ([]error, error)
+	CreateExternalWorkout(workout *Workout, ref ExternalRef) (bool, error)
+	GetWorkoutByID(id int64) (*Workout, error) //* ErrNotFound --> no such workout
 	UpdateWorkout(*Workout)  error
 	DeleteWorkout(id int64)  error
 	GetWorkoutOwner(id int64) (int,error)
+	BatchWorkouts(ops []WorkoutOp, atomic bool) ([]error, error) //* see workout_batch.go
 }
 
 func (pg *PostgresWorkoutStore) CreateWorkout(workout *Workout) (*Workout, error) {
@@ -54,52 +92,163 @@ func (pg *PostgresWorkoutStore) CreateWorkout(workout *Workout) (*Workout, error
 	}
 	defer tx.Rollback() // ? - rolls back if anything fails
 
+	// ? - the workout.created outbox row goes in the same transaction, see outbox_store.go
+	err = applyWorkoutOp(tx, WorkoutOp{Op: WorkoutOpCreate, Workout: workout})
+	if err != nil {
+		return nil, err
+	}
+
+	// ! commit the transaction - makes everything permanent
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return workout, nil
+}
+
+//! insertWorkout --> workout + entries inside the caller's transaction
+//? a zero CreatedAt means "now", imports pass the original date
+//? with a ref, an already synced external workout is skipped and sql.ErrNoRows comes back
+func insertWorkout(tx *sql.Tx, workout *Workout, ref *ExternalRef) error {
 	// * inserting main workout data first
-	query :=
-		`
-  INSERT INTO workouts (user_id, title, description, duration_minutes, calories_burned)
-  VALUES ($1, $2, $3, $4, $5)
-  RETURNING id 
+	query := `
+  INSERT INTO workouts (user_id, title, description, duration_minutes, calories_burned, calories_estimated, visibility, flagged, created_at, external_source, external_id, performed_at)
+  VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'private'), $8, COALESCE($9, CURRENT_TIMESTAMP), $10, $11, COALESCE($12, $9, CURRENT_TIMESTAMP))
+  ON CONFLICT (user_id, external_source, external_id) WHERE external_id IS NOT NULL DO NOTHING
+  RETURNING id, visibility, flagged, verified, created_at, performed_at
   `
+	var createdAt, performedAt *time.Time
+	if !workout.CreatedAt.IsZero() {
+		createdAt = &workout.CreatedAt
+	}
+	if !workout.PerformedAt.IsZero() {
+		performedAt = &workout.PerformedAt
+	}
+	var source, externalID *string
+	if ref != nil {
+		source, externalID = &ref.Source, &ref.ID
+	}
 
-	err = tx.QueryRow(query, workout.UserID, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned).Scan(&workout.ID)
+	err := tx.QueryRow(query, workout.UserID, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, workout.CaloriesEstimated, workout.Visibility, workout.Flagged, createdAt, source, externalID, performedAt).Scan(&workout.ID, &workout.Visibility, &workout.Flagged, &workout.Verified, &workout.CreatedAt, &workout.PerformedAt)
+	if err != nil {
+		return err
+	}
+
+	return insertEntries(tx, workout)
+}
+
+//? 9 params per entry, postgres caps a statement at 65535
+const entryInsertChunk = 1000
+
+//! insertEntries --> one multi-row INSERT per chunk instead of a round trip per entry
+//? RETURNING hands the ids back in VALUES order, so they line up with workout.Entries
+func insertEntries(tx *sql.Tx, workout *Workout) error {
+	for start := 0; start < len(workout.Entries); start += entryInsertChunk {
+		chunk := workout.Entries[start:min(start+entryInsertChunk, len(workout.Entries))]
+
+		var query strings.Builder
+		query.WriteString("INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, distance, notes, order_index) VALUES ")
+		args := make([]any, 0, len(chunk)*9)
+		for i, entry := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			n := len(args)
+			fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9)
+			args = append(args, workout.ID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Distance, entry.Notes, entry.OrderIndex)
+		}
+		query.WriteString(" RETURNING id")
+
+		rows, err := tx.Query(query.String(), args...)
+		if err != nil {
+			return err
+		}
+		i := 0
+		for rows.Next() {
+			err = rows.Scan(&chunk[i].ID)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			i++
+		}
+		rows.Close()
+		err = rows.Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//! ImportWorkouts --> one transaction for the whole batch, a savepoint per workout
+//? a bad row only rolls back itself, rowErrs[i] is nil when workouts[i] was saved
+func (pg *PostgresWorkoutStore) ImportWorkouts(workouts []*Workout) ([]error, error) {
+	tx, err := pg.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	// ? - now looping through each exercise entry and saving them
-	for i := range workout.Entries {
-		query := `
-    INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index)
-    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-    RETURNING id
-    `
-		err = tx.QueryRow(query, workout.ID, workout.Entries[i].ExerciseName, workout.Entries[i].Sets, workout.Entries[i].Reps, workout.Entries[i].DurationSeconds, workout.Entries[i].Weight, workout.Entries[i].Notes, workout.Entries[i].OrderIndex).Scan(&workout.Entries[i].ID)
+	rowErrs := make([]error, len(workouts))
+	for i, workout := range workouts {
+		_, err = tx.Exec(`SAVEPOINT import_row`)
+		if err != nil {
+			return nil, err
+		}
+
+		rowErrs[i] = insertWorkout(tx, workout, nil)
+		if rowErrs[i] != nil {
+			workout.ID = 0
+			_, err = tx.Exec(`ROLLBACK TO SAVEPOINT import_row`)
+		} else {
+			_, err = tx.Exec(`RELEASE SAVEPOINT import_row`)
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	// ! commit the transaction - makes everything permanent
 	err = tx.Commit()
 	if err != nil {
 		return nil, err
 	}
+	return rowErrs, nil
+}
 
-	return workout, nil
+//! CreateExternalWorkout --> false when the same external activity was synced before
+func (pg *PostgresWorkoutStore) CreateExternalWorkout(workout *Workout, ref ExternalRef) (bool, error) {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	err = insertWorkout(tx, workout, &ref)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err == nil {
+		err = enqueueWorkoutEvent(tx, events.WorkoutCreated, workout.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
 func (pg *PostgresWorkoutStore) GetWorkoutByID(id int64) (*Workout, error) {
 	workout := &Workout{}
 	// * fetching main workout info by id
 	query := `
-  SELECT id, title, description, duration_minutes, calories_burned
+  SELECT id, user_id, title, description, duration_minutes, calories_burned, calories_estimated, visibility, flagged, verified, created_at, performed_at
   FROM workouts
   WHERE id = $1
   `
-	err := pg.db.QueryRow(query, id).Scan(&workout.ID, &workout.Title, &workout.Description, &workout.DurationMinutes, &workout.CaloriesBurned)
+	err := pg.db.QueryRow(query, id).Scan(&workout.ID, &workout.UserID, &workout.Title, &workout.Description, &workout.DurationMinutes, &workout.CaloriesBurned, &workout.CaloriesEstimated, &workout.Visibility, &workout.Flagged, &workout.Verified, &workout.CreatedAt, &workout.PerformedAt)
 	if err == sql.ErrNoRows {
-		return nil, nil // ? - workout doesn't exist
+		return nil, ErrNotFound // ? - workout doesn't exist
 	}
 
 	if err != nil {
@@ -108,7 +257,7 @@ func (pg *PostgresWorkoutStore) GetWorkoutByID(id int64) (*Workout, error) {
 
 	// ? - now grabbing all exercise entries for this workout
 	entryQuery := `
-  SELECT id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index
+  SELECT id, exercise_name, sets, reps, duration_seconds, weight, distance, notes, order_index
   FROM workout_entries
   WHERE workout_id = $1
   ORDER BY order_index
@@ -130,6 +279,7 @@ func (pg *PostgresWorkoutStore) GetWorkoutByID(id int64) (*Workout, error) {
 			&entry.Reps,
 			&entry.DurationSeconds,
 			&entry.Weight,
+			&entry.Distance,
 			&entry.Notes,
 			&entry.OrderIndex,
 		)
@@ -150,65 +300,66 @@ func (pg *PostgresWorkoutStore) UpdateWorkout(workout *Workout) error {
 	}
 	defer tx.Rollback() // ? - safety net if update fails
 
+	err = applyWorkoutOp(tx, WorkoutOp{Op: WorkoutOpUpdate, Workout: workout})
+	if err != nil {
+		return err
+	}
+
+	// ! commit to save all changes
+	return tx.Commit()
+}
+
+//* updateWorkout --> the statements of UpdateWorkout inside the caller's transaction
+func updateWorkout(tx *sql.Tx, workout *Workout) error {
 	// * updating main workout info
 	query := `
   UPDATE workouts
-  SET title = $1, description = $2, duration_minutes = $3, calories_burned = $4
-  WHERE id = $5
+  SET title = $1, description = $2, duration_minutes = $3, calories_burned = $4, calories_estimated = $5,
+      visibility = $6, flagged = $7, verified = FALSE, updated_at = CURRENT_TIMESTAMP, performed_at = COALESCE($9, performed_at)
+  WHERE id = $8
   `
 
-	_, err = tx.Exec(query, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, workout.ID)
+	_, err := tx.Exec(query, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, workout.CaloriesEstimated, workout.Visibility, workout.Flagged, workout.ID, workout.PerformedAtOrNil())
 	if err != nil {
 		return err
 	}
+	workout.Verified = false
 
-	// ? - wiping old entries first
-	_, err = tx.Exec("DELETE FROM workout_entries WHERE workout_id = $1", workout.ID)
+	// ! an edited workout is no longer what the coach attested --> send it back through verification
+	_, err = tx.Exec(`
+  UPDATE workout_verifications
+  SET status = 'pending', attested_by = NULL, approved = NULL, attested_at = NULL, updated_at = CURRENT_TIMESTAMP
+  WHERE workout_id = $1
+  `, workout.ID)
 	if err != nil {
 		return err
 	}
 
-	// ? - inserting fresh entries
-	for _, entry := range workout.Entries {
-		query := `
-    INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index)
-    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-    `
-		_, err = tx.Exec(query, workout.ID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Notes, entry.OrderIndex)
-		if err != nil {
-			return err
-		}
+	// ? - wiping old entries first
+	_, err = tx.Exec("DELETE FROM workout_entries WHERE workout_id = $1", workout.ID)
+	if err != nil {
+		return err
 	}
 
-	// ! commit to save all changes
-	return tx.Commit()
+	// ? - inserting fresh entries, batched like on create
+	return insertEntries(tx, workout)
 }
 
 //! DeleteWorkout --> removes workout and its entries (CASCADE handles entries)
+//? a transaction now, the workout.deleted outbox row has to go with the delete
 func (pg *PostgresWorkoutStore) DeleteWorkout(id int64) error {
-	//* query for delete
-	query := `
-	DELETE FROM workouts
-	where id=$1
-	`
-
-	result,err := pg.db.Exec(query,id)
+	tx, err := pg.db.Begin()
 	if err!= nil {
 		return err
 	}
-	//* check if any rows were actually deleted
-	rowsAffected,err := result.RowsAffected()
-		if err!= nil {
-		return err
-	}
+	defer tx.Rollback()
 
-	//? if 0 rows affected, workout didn't exist
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+	//? ErrNotFound when 0 rows affected, workout didn't exist
+	err = applyWorkoutOp(tx, WorkoutOp{Op: WorkoutOpDelete, Workout: &Workout{ID: int(id)}})
+	if err!= nil {
+		return err
 	}
-
-	return nil
-
+	return tx.Commit()
 }
 
 
@@ -226,7 +377,7 @@ func (pg *PostgresWorkoutStore) GetWorkoutOwner(workoutID int64) (int,error) {
 		//* fetch owner's user ID
 		err := pg.db.QueryRow(query,workoutID).Scan(&userID)
 		if err != nil {
-			return 0,err
+			return 0,mapError(err) //* ErrNotFound for a missing workout
 		}
 		return userID,nil
 }	
\ No newline at end of file
@@ -2,33 +2,18 @@ package store
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 
-	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 // ! setupTestDB --> prepares fresh test database for each test
+// ? - harness_test.go picks the database, migrates it and wipes every table, user 1 owns the test workouts
 func setupTestDB(t *testing.T) *sql.DB {
-	// * connecting to test db on port 5500 (separate from dev db on 5445)
-	db,err := sql.Open("pgx","host=localhost user=postgres password=postgres dbname=postgres port=5500 sslmode=disable")
-	if err!= nil {
-		t.Fatalf("opening test db : %v",err)
-	}
-
-	// ? - running migrations to create tables in test db
-	err = Migrate(db,"../../migrations/")
-	if err != nil {
-		t.Fatalf("caught error while migrating db : %v",err)
-	}
-
-	// ! wiping all data so each test starts with clean slate
-	_,err = db.Exec(`TRUNCATE workouts,workout_entries CASCADE`)
-	if err!= nil {
-		t.Fatalf("caught error while truncating db : %v",err)
-	}
-
+	db := openTestDB(t)
+	createTestUser(t,db,"workout_owner")
 	return db
 }
 
@@ -96,6 +81,16 @@ func TestCreateWorkout(t *testing.T) {
 		},
 		wantErr: false,  // * name says "invalid" but should still work - tests flexible entry types
 	 },
+	 {  // ! TEST CASE 3: lots of entries --> all go in through the batched insert
+		name: "workout with dozens of sets",
+		workout: &Workout{
+			UserID: 1,
+			Title: "volume day",
+			DurationMinutes: 120,
+			Entries: manyEntries(40),
+		},
+		wantErr: false,
+	 },
 	}
 
 	// ! looping through each test case
@@ -137,6 +132,15 @@ func TestCreateWorkout(t *testing.T) {
 
 // ! HELPER FUNCTIONS for converting values to pointers
 
+// * manyEntries --> n numbered entries, for the batch insert case
+func manyEntries(n int) []WorkoutEntry {
+	entries := make([]WorkoutEntry,n)
+	for i := range entries {
+		entries[i] = WorkoutEntry{ExerciseName: fmt.Sprintf("set %d",i+1), Sets: 1, Reps: intPointer(i+1), OrderIndex: i+1}
+	}
+	return entries
+}
+
 // * intPointer --> some fields like reps/duration are optional pointers
 func intPointer( i int) *int {
 	return &i // ? --> returns address of the passed "i"
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+//! xp sources --> what a ledger row was awarded for
+const (
+	XPSourceWorkout     = "workout"
+	XPSourceAchievement = "achievement"
+)
+
+// ? - inactivity decay, Percent of XP is lost per full week without a workout after Grace
+type XPDecay struct {
+	Percent float64
+	Grace   time.Duration
+}
+
+// ? - a user's XP before and after decay
+type XPTotal struct {
+	UserID       int        `json:"user_id"`
+	Username     string     `json:"username"`
+	Earned       int        `json:"earned"`
+	XP           int        `json:"xp"` // * after decay, this is what levels use
+	LastActiveAt *time.Time `json:"last_active_at"`
+}
+
+// * holds the db connection for XP ledger operations
+type PostgresXPStore struct {
+	db *sql.DB
+}
+
+// ? - constructor that creates new xp store instance
+func NewPostgresXPStore(db *sql.DB) *PostgresXPStore {
+	return &PostgresXPStore{db: db}
+}
+
+//! XPStore interface --> contract for the XP ledger
+type XPStore interface {
+	UpsertXP(userID int, source, ref string, points int) error
+	UpsertWorkoutXP(workoutID int, base, perMinute int) error
+	DeleteXP(userID int, source, ref string) error
+	GetXP(userID int, decay XPDecay) (*XPTotal, error)
+	TopXP(decay XPDecay, limit int) ([]*XPTotal, error)
+}
+
+//! UpsertXP --> one ledger row per (user, source, ref), re-awarding replaces the points
+func (s *PostgresXPStore) UpsertXP(userID int, source, ref string, points int) error {
+	query := `
+  INSERT INTO xp_events (user_id, source, source_ref, points)
+  VALUES ($1, $2, $3, $4)
+  ON CONFLICT (user_id, source, source_ref) DO UPDATE
+  SET points = EXCLUDED.points, updated_at = CURRENT_TIMESTAMP
+  `
+	_, err := s.db.Exec(query, userID, source, ref, points)
+	return err
+}
+
+//! UpsertWorkoutXP --> points straight from the workout row, flagged workouts earn nothing
+func (s *PostgresXPStore) UpsertWorkoutXP(workoutID int, base, perMinute int) error {
+	query := `
+  INSERT INTO xp_events (user_id, source, source_ref, points)
+  SELECT user_id, 'workout', id::text, CASE WHEN flagged THEN 0 ELSE $2 + duration_minutes * $3 END
+  FROM workouts
+  WHERE id = $1
+  ON CONFLICT (user_id, source, source_ref) DO UPDATE
+  SET points = EXCLUDED.points, updated_at = CURRENT_TIMESTAMP
+  `
+	_, err := s.db.Exec(query, workoutID, base, perMinute)
+	return err
+}
+
+func (s *PostgresXPStore) DeleteXP(userID int, source, ref string) error {
+	_, err := s.db.Exec(`DELETE FROM xp_events WHERE user_id = $1 AND source = $2 AND source_ref = $3`, userID, source, ref)
+	return err
+}
+
+// * xpTotalsQuery --> per-user totals with decay applied, $1 = percent per week, $2 = grace in seconds
+// ? users without workouts never decay, there is no activity to measure from
+const xpTotalsQuery = `
+  WITH totals AS (
+    SELECT x.user_id, SUM(x.points) AS earned,
+           (SELECT MAX(w.created_at) FROM workouts w WHERE w.user_id = x.user_id) AS last_active
+    FROM xp_events x
+    GROUP BY x.user_id
+  )
+  SELECT t.user_id, u.username, t.earned::int,
+         FLOOR(t.earned * POWER(1 - $1::float8 / 100,
+           GREATEST(0, FLOOR((EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - COALESCE(t.last_active, CURRENT_TIMESTAMP))) - $2) / 604800))
+         ))::int AS xp,
+         t.last_active
+  FROM totals t
+  INNER JOIN users u ON u.id = t.user_id AND u.deleted_at IS NULL
+`
+
+//! GetXP --> zero totals for users who never earned anything
+func (s *PostgresXPStore) GetXP(userID int, decay XPDecay) (*XPTotal, error) {
+	total := &XPTotal{}
+	query := xpTotalsQuery + `WHERE t.user_id = $3`
+	err := s.db.QueryRow(query, decay.Percent, decay.Grace.Seconds(), userID).Scan(&total.UserID, &total.Username, &total.Earned, &total.XP, &total.LastActiveAt)
+	if err == sql.ErrNoRows {
+		return &XPTotal{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return total, nil
+}
+
+//! TopXP --> XP leaderboard, ties broken by who got there first (lower user id), opted-out users are left off
+func (s *PostgresXPStore) TopXP(decay XPDecay, limit int) ([]*XPTotal, error) {
+	query := xpTotalsQuery + `
+  WHERE NOT EXISTS (SELECT 1 FROM user_profiles p WHERE p.user_id = t.user_id AND p.leaderboard_opt_out)
+  ORDER BY xp DESC, t.user_id LIMIT $3`
+	rows, err := s.db.Query(query, decay.Percent, decay.Grace.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []*XPTotal{}
+	for rows.Next() {
+		total := &XPTotal{}
+		err = rows.Scan(&total.UserID, &total.Username, &total.Earned, &total.XP, &total.LastActiveAt)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,156 @@
+// Package tlsconfig lets the API terminate HTTPS itself: certificate files, or Let's Encrypt certificates
+// obtained and renewed through autocert, plus the port 80 listener that answers ACME challenges and redirects.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"errors"
+	"fem/internal/utils"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ! reloadCheck --> how often the certificate files are stat'ed, a renewed pair is picked up without a restart
+const reloadCheck = time.Minute
+
+// ! Config --> how the API serves HTTPS, either CertFile + KeyFile or autocert Domains
+type Config struct {
+	CertFile     string
+	KeyFile      string
+	Domains      []string //* autocert: the only hosts certificates are requested for
+	CacheDir     string   //* autocert: account key + certificates, keep it across restarts (rate limits)
+	Email        string   //* autocert: Let's Encrypt expiry + problem notices
+	RedirectAddr string   //* plain HTTP listener redirecting to HTTPS, "" for none
+}
+
+// ! FromEnv --> nil without TLS_CERT_FILE / TLS_AUTOCERT_DOMAINS, the API stays plain HTTP
+func FromEnv() (*Config, error) {
+	c := &Config{
+		CertFile: os.Getenv("TLS_CERT_FILE"),
+		KeyFile:  os.Getenv("TLS_KEY_FILE"),
+		CacheDir: utils.GetEnv("TLS_AUTOCERT_DIR", "autocert-cache"),
+		Email:    os.Getenv("TLS_AUTOCERT_EMAIL"),
+	}
+	for _, domain := range strings.Split(os.Getenv("TLS_AUTOCERT_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			c.Domains = append(c.Domains, domain)
+		}
+	}
+
+	switch {
+	case len(c.Domains) > 0 && (c.CertFile != "" || c.KeyFile != ""):
+		return nil, errors.New("tls: set either TLS_CERT_FILE + TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	case len(c.Domains) > 0:
+		c.RedirectAddr = utils.GetEnv("TLS_REDIRECT_ADDR", ":80") //* HTTP-01 challenges arrive on port 80
+	case c.CertFile != "" || c.KeyFile != "":
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("tls: TLS_CERT_FILE and TLS_KEY_FILE go together")
+		}
+		c.RedirectAddr = os.Getenv("TLS_REDIRECT_ADDR")
+	default:
+		return nil, nil
+	}
+	return c, nil
+}
+
+// ! Setup --> sets server.TLSConfig, returns the redirect server to run next to it (nil when RedirectAddr is "")
+// ? httpsPort is where the redirect points, "" or "443" leaves it out of the URL
+func (c *Config) Setup(server *http.Server, httpsPort string, logger *log.Logger) (*http.Server, error) {
+	redirect := Redirect(httpsPort)
+	if len(c.Domains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(c.Domains...),
+			Cache:      autocert.DirCache(c.CacheDir),
+			Email:      c.Email,
+		}
+		server.TLSConfig = manager.TLSConfig() //* TLS-ALPN-01 challenges on the HTTPS port itself
+		redirect = manager.HTTPHandler(redirect)
+	} else {
+		certs := &reloader{certFile: c.CertFile, keyFile: c.KeyFile, logger: logger}
+		err := certs.load()
+		if err != nil {
+			return nil, err
+		}
+		server.TLSConfig = &tls.Config{GetCertificate: certs.GetCertificate}
+	}
+	server.TLSConfig.MinVersion = tls.VersionTLS12
+
+	if c.RedirectAddr == "" {
+		return nil, nil
+	}
+	return &http.Server{
+		Addr:              c.RedirectAddr,
+		Handler:           redirect,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       time.Minute,
+	}, nil
+}
+
+// ! Redirect --> 308 to the same URL over HTTPS, method + body are kept so API clients don't silently turn POSTs into GETs
+func Redirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "missing Host header", http.StatusBadRequest)
+			return
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// * reloader --> serves the pair from disk, re-read when the certificate file changes (certbot renewals)
+type reloader struct {
+	certFile string
+	keyFile  string
+	logger   *log.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func (r *reloader) load() error {
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.modTime = &cert, info.ModTime()
+	return nil
+}
+
+// ! GetCertificate --> tls.Config hook, a broken renewal keeps the previous certificate in service
+func (r *reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.Sub(r.checked) >= reloadCheck {
+		r.checked = now
+		info, err := os.Stat(r.certFile)
+		if err == nil && !info.ModTime().Equal(r.modTime) {
+			err = r.load()
+		}
+		if err != nil {
+			r.logger.Printf("ERROR: tls: reloading %s: %v (still serving the previous certificate)", r.certFile, err)
+		}
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,79 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirect(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://api.example.com/v1/workouts?units=metric", nil)
+	w := httptest.NewRecorder()
+	Redirect("").ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "https://api.example.com/v1/workouts?units=metric", w.Header().Get("Location"))
+
+	req = httptest.NewRequest(http.MethodGet, "http://localhost:8080/health", nil)
+	w = httptest.NewRecorder()
+	Redirect("8443").ServeHTTP(w, req)
+	assert.Equal(t, "https://localhost:8443/health", w.Header().Get("Location"))
+}
+
+func TestFromEnv(t *testing.T) {
+	config, err := FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, config) //* nothing set --> plain HTTP
+
+	t.Setenv("TLS_AUTOCERT_DOMAINS", "api.example.com, www.example.com")
+	config, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com", "www.example.com"}, config.Domains)
+	assert.Equal(t, ":80", config.RedirectAddr)
+
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+	_, err = FromEnv()
+	assert.Error(t, err) //* both modes at once
+
+	t.Setenv("TLS_AUTOCERT_DOMAINS", "")
+	_, err = FromEnv()
+	assert.Error(t, err) //* cert without key
+}
+
+func TestSetupLoadsCertificateFiles(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), DNSNames: []string{"localhost"}, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	server := &http.Server{}
+	redirect, err := (&Config{CertFile: certFile, KeyFile: keyFile}).Setup(server, "", nil)
+	require.NoError(t, err)
+	assert.Nil(t, redirect) //* no TLS_REDIRECT_ADDR --> no plain listener
+	assert.Equal(t, uint16(tls.VersionTLS12), server.TLSConfig.MinVersion)
+	cert, err := server.TLSConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "localhost"})
+	require.NoError(t, err)
+	assert.Equal(t, der, cert.Certificate[0])
+
+	_, err = (&Config{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: keyFile}).Setup(&http.Server{}, "", nil)
+	assert.Error(t, err) //* fails at startup, not on the first handshake
+}
@@ -8,8 +8,14 @@ import (
 )
 
 //! ScopeAuth --> token type identifier for authentication tokens
+//! ScopeSCIM --> org-level token used by identity providers for SCIM provisioning
+//! ScopeShare --> read-only public link to a single workout
+//! ScopeTwoFactor --> short-lived login challenge, traded for an auth token together with a TOTP or backup code
 const (
-	ScopeAuth = "authentication"
+	ScopeAuth      = "authentication"
+	ScopeSCIM      = "scim"
+	ScopeShare     = "share"
+	ScopeTwoFactor = "two-factor"
 )
 
 //! Token struct --> represents authentication token with both plaintext and hashed versions
@@ -0,0 +1,35 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ! TestRFC6238 --> SHA1 test vectors from the RFC, truncated to 6 digits
+func TestRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"} {
+		code, err := CodeAt(secret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, unix)
+	}
+
+	now := time.Unix(1111111109, 0)
+	step, ok := Verify(secret, "081804", now.Add(Period))
+	assert.True(t, ok, "one step of drift is accepted")
+	assert.Equal(t, Step(now), step)
+	_, ok = Verify(secret, "081804", now.Add(3*Period))
+	assert.False(t, ok)
+}
+
+func TestBackupCodes(t *testing.T) {
+	codes, err := NewBackupCodes(3)
+	require.NoError(t, err)
+	require.Len(t, codes, 3)
+	assert.Len(t, codes[0], 9)
+	assert.Equal(t, HashBackupCode(codes[0]), HashBackupCode(" "+codes[0][:4]+codes[0][5:]+" "))
+}
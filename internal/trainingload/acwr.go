@@ -0,0 +1,157 @@
+// ! package trainingload --> acute:chronic workload ratio (ACWR) from daily session load
+// ? rolling averages: acute = last 7 days, chronic = last 28 days as a weekly average, so both are "load per week"
+package trainingload
+
+import (
+	"fem/internal/store"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ! what a session's load is measured in
+const (
+	MetricDuration = "duration" //* minutes
+	MetricVolume   = "volume"   //* sets x reps x weight
+)
+
+// ! risk bands, Thresholds decides where one ends and the next starts
+const (
+	BandInsufficient = "insufficient_data" //* the chronic window isn't covered yet
+	BandLow          = "undertraining"
+	BandOptimal      = "optimal"
+	BandCaution      = "caution"
+	BandHigh         = "high_risk"
+)
+
+// ! window sizes in days
+const (
+	AcuteDays   = 7
+	ChronicDays = 28
+)
+
+// ! Thresholds --> ratio below Low is undertraining, above Caution is caution, above High is high risk
+type Thresholds struct {
+	Low     float64 `json:"low"`
+	Caution float64 `json:"caution"`
+	High    float64 `json:"high"`
+}
+
+// * DefaultThresholds --> the commonly cited 0.8-1.3 "sweet spot", 1.5+ as the danger zone
+var DefaultThresholds = Thresholds{Low: 0.8, Caution: 1.3, High: 1.5}
+
+// ! ParseThresholds --> "0.8,1.3,1.5" style, falls back to the defaults when invalid or not increasing
+func ParseThresholds(raw string) Thresholds {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 3 {
+		return DefaultThresholds
+	}
+	values := [3]float64{}
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || value <= 0 || i > 0 && value <= values[i-1] {
+			return DefaultThresholds
+		}
+		values[i] = value
+	}
+	return Thresholds{Low: values[0], Caution: values[1], High: values[2]}
+}
+
+// ! Band --> the risk band a ratio falls into
+func (t Thresholds) Band(ratio float64) string {
+	switch {
+	case ratio > t.High:
+		return BandHigh
+	case ratio > t.Caution:
+		return BandCaution
+	case ratio < t.Low:
+		return BandLow
+	}
+	return BandOptimal
+}
+
+// ! Point --> the workload as of one day, Ratio is nil while the band is insufficient_data
+type Point struct {
+	Date    time.Time `json:"date"`
+	Acute   float64   `json:"acute"`
+	Chronic float64   `json:"chronic"`
+	Ratio   *float64  `json:"ratio"`
+	Band    string    `json:"band"`
+}
+
+// ! Report --> GET /stats/training-load, Current is today, Weekly ends on today
+type Report struct {
+	Metric     string     `json:"metric"`
+	Thresholds Thresholds `json:"thresholds"`
+	Current    Point      `json:"current"`
+	Weekly     []Point    `json:"weekly"`
+}
+
+// ! Since --> how far back daily load has to go for a report with weeks of history
+func Since(asOf time.Time, weeks int) time.Time {
+	return Day(asOf).AddDate(0, 0, -(ChronicDays - 1 + 7*max(weeks-1, 0)))
+}
+
+// ! Day --> the UTC day at holds
+func Day(at time.Time) time.Time {
+	return LocalDay(at, time.UTC)
+}
+
+// ! LocalDay --> the day at falls on in loc, labelled as UTC midnight like store.DailyLoad.Day
+// ? pass it as asOf so "today" is the user's today, not the server's
+func LocalDay(at time.Time, loc *time.Location) time.Time {
+	at = at.In(loc)
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ! Compute --> today's ratio plus one point per week going back weeks-1 weeks
+// ? daily must reach back to Since(asOf, weeks), anything older is ignored
+func Compute(daily []*store.DailyLoad, metric string, asOf time.Time, weeks int, thresholds Thresholds) *Report {
+	loads := map[time.Time]float64{}
+	for _, d := range daily {
+		switch metric {
+		case MetricVolume:
+			loads[Day(d.Day)] += d.Volume
+		default:
+			loads[Day(d.Day)] += float64(d.Minutes)
+		}
+	}
+
+	today := Day(asOf)
+	report := &Report{Metric: metric, Thresholds: thresholds, Current: At(loads, today, thresholds), Weekly: []Point{}}
+	for w := max(weeks, 1) - 1; w >= 0; w-- {
+		report.Weekly = append(report.Weekly, At(loads, today.AddDate(0, 0, -7*w), thresholds))
+	}
+	return report
+}
+
+// ! At --> the point for day from per-day loads
+// ? no load in the oldest week of the chronic window means there's no baseline yet, the ratio would just be noise
+func At(loads map[time.Time]float64, day time.Time, thresholds Thresholds) Point {
+	var acute, chronic, baseline float64
+	for i := 0; i < ChronicDays; i++ {
+		load := loads[day.AddDate(0, 0, -i)]
+		chronic += load
+		if i < AcuteDays {
+			acute += load
+		}
+		if i >= ChronicDays-7 {
+			baseline += load
+		}
+	}
+	chronic /= ChronicDays / 7
+
+	point := Point{Date: day, Acute: round(acute), Chronic: round(chronic), Band: BandInsufficient}
+	if baseline == 0 || chronic == 0 {
+		return point
+	}
+	ratio := round(acute / chronic)
+	point.Ratio = &ratio
+	point.Band = thresholds.Band(ratio)
+	return point
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,97 @@
+package trainingload
+
+import (
+	"fem/internal/events"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * steady --> minutes every day for days days ending on end
+func steady(end time.Time, days, minutes int) []*store.DailyLoad {
+	daily := []*store.DailyLoad{}
+	for i := days - 1; i >= 0; i-- {
+		daily = append(daily, &store.DailyLoad{Day: Day(end).AddDate(0, 0, -i), Sessions: 1, Minutes: minutes})
+	}
+	return daily
+}
+
+func TestCompute(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	report := Compute(steady(now, 60, 30), MetricDuration, now, 4, DefaultThresholds)
+	require.NotNil(t, report.Current.Ratio)
+	assert.Equal(t, 1.0, *report.Current.Ratio)
+	assert.Equal(t, 210.0, report.Current.Acute)
+	assert.Equal(t, BandOptimal, report.Current.Band)
+	require.Len(t, report.Weekly, 4)
+	assert.Equal(t, Day(now), report.Weekly[3].Date)
+
+	//* doubling the last week: acute 420, chronic (3*210 + 420)/4 = 262.5
+	spike := steady(now, 60, 30)
+	for _, d := range spike[len(spike)-7:] {
+		d.Minutes = 60
+	}
+	report = Compute(spike, MetricDuration, now, 1, DefaultThresholds)
+	assert.Equal(t, 1.6, *report.Current.Ratio)
+	assert.Equal(t, BandHigh, report.Current.Band)
+
+	report = Compute(steady(now, 10, 30), MetricDuration, now, 1, DefaultThresholds)
+	assert.Nil(t, report.Current.Ratio)
+	assert.Equal(t, BandInsufficient, report.Current.Band)
+}
+
+func TestParseThresholds(t *testing.T) {
+	assert.Equal(t, Thresholds{Low: 0.7, Caution: 1.2, High: 1.4}, ParseThresholds("0.7, 1.2, 1.4"))
+	assert.Equal(t, DefaultThresholds, ParseThresholds(""))
+	assert.Equal(t, DefaultThresholds, ParseThresholds("1.5,1.3,0.8"))
+}
+
+// ! TestMonitorAlertsOnce --> a spike publishes one TrainingLoadHigh per day and band
+func TestMonitorAlertsOnce(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	db := memstore.New()
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, user.PasswordHash.Set("password"))
+	require.NoError(t, memstore.NewUserStore(db).CreateUser(user))
+
+	now := time.Now().UTC()
+	workouts := memstore.NewWorkoutStore(db)
+	for i := 35; i >= 0; i-- {
+		minutes := 30
+		if i < 7 {
+			minutes = 90
+		}
+		_, err := workouts.CreateWorkout(&store.Workout{UserID: user.ID, Title: "Run", DurationMinutes: minutes, CreatedAt: now.AddDate(0, 0, -i)})
+		require.NoError(t, err)
+	}
+
+	bus := events.NewBus(logger)
+	var mu sync.Mutex
+	alerts := []events.Event{}
+	bus.Subscribe(func(e events.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		alerts = append(alerts, e)
+		return nil
+	}, events.TrainingLoadHigh)
+
+	monitor := NewMonitor(memstore.NewTrainingLoadStore(db), memstore.NewProfileStore(db), MetricDuration, DefaultThresholds, logger)
+	monitor.Subscribe(bus)
+	for range 2 {
+		point, err := monitor.Check(user.ID, now)
+		require.NoError(t, err)
+		assert.Equal(t, BandHigh, point.Band)
+	}
+	bus.Wait()
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, BandHigh, alerts[0].Ref)
+}
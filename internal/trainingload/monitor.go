@@ -0,0 +1,63 @@
+package trainingload
+
+import (
+	"fem/internal/events"
+	"fem/internal/store"
+	"log"
+	"time"
+)
+
+// ! Monitor --> recomputes today's ratio after every logged workout, alerts once per day and band
+type Monitor struct {
+	Store      store.TrainingLoadStore
+	Profiles   store.ProfileStore //* the user's timezone decides which day "today" is
+	Metric     string
+	Thresholds Thresholds
+	Logger     *log.Logger
+	Bus        *events.Bus //* set by Subscribe, alerts are published back as TrainingLoadHigh
+}
+
+// ! NewMonitor --> constructor for the training load monitor
+func NewMonitor(loadStore store.TrainingLoadStore, profiles store.ProfileStore, metric string, thresholds Thresholds, logger *log.Logger) *Monitor {
+	return &Monitor{Store: loadStore, Profiles: profiles, Metric: metric, Thresholds: thresholds, Logger: logger}
+}
+
+// ! Subscribe --> new and edited workouts change the acute load
+func (m *Monitor) Subscribe(bus *events.Bus) {
+	m.Bus = bus
+	bus.Subscribe(m.HandleEvent, events.WorkoutCreated, events.WorkoutUpdated)
+}
+
+// ! HandleEvent --> events.Handler, checks the event's user as of the event
+func (m *Monitor) HandleEvent(event events.Event) error {
+	_, err := m.Check(event.UserID, event.At)
+	return err
+}
+
+// ! Check --> today's point, publishes TrainingLoadHigh when it crossed the caution or high threshold
+// ? caution and high_risk are claimed separately, so climbing from one into the other alerts again
+func (m *Monitor) Check(userID int, at time.Time) (*Point, error) {
+	profile, err := m.Profiles.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	asOf := LocalDay(at, profile.Location())
+	daily, err := m.Store.ListDailyLoad(userID, Since(asOf, 1))
+	if err != nil {
+		return nil, err
+	}
+	point := Compute(daily, m.Metric, asOf, 1, m.Thresholds).Current
+	if point.Band != BandCaution && point.Band != BandHigh {
+		return &point, nil
+	}
+
+	claimed, err := m.Store.MarkTrainingLoadAlert(userID, point.Date, point.Band, *point.Ratio)
+	if err != nil || !claimed {
+		return &point, err
+	}
+	m.Logger.Printf("training load: user %d at %.2f (%s)", userID, *point.Ratio, point.Band)
+	if m.Bus != nil {
+		m.Bus.Publish(events.Event{Type: events.TrainingLoadHigh, UserID: userID, Ref: point.Band, At: at})
+	}
+	return &point, nil
+}
@@ -0,0 +1,133 @@
+// ! package units --> metric/imperial conversion at the API edge, storage always stays kg + meters
+// ? metric answers in the stored units, imperial in pounds + miles; the gRPC + GraphQL APIs stay canonical
+package units
+
+import (
+	"context"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+)
+
+const (
+	Metric   = store.UnitsMetric
+	Imperial = store.UnitsImperial
+
+	KilogramsPerPound = 0.45359237
+	MetersPerMile     = 1609.344
+)
+
+// ! Valid --> one of the two systems
+func Valid(system string) bool {
+	return system == Metric || system == Imperial
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+// * scaled --> new pointer so the caller's entry (maybe cached) isn't touched
+func scaled(v *float64, factor float64, places int) *float64 {
+	if v == nil {
+		return nil
+	}
+	out := round(*v*factor, places)
+	return &out
+}
+
+// ! Entries --> copy of canonical entries in system
+func Entries(entries []store.WorkoutEntry, system string) []store.WorkoutEntry {
+	if system != Imperial || entries == nil {
+		return entries
+	}
+	out := make([]store.WorkoutEntry, len(entries))
+	for i, e := range entries {
+		e.Weight = scaled(e.Weight, 1/KilogramsPerPound, 2)
+		e.Distance = scaled(e.Distance, 1/MetersPerMile, 3)
+		out[i] = e
+	}
+	return out
+}
+
+// ! Canonical --> copy of entries sent in system, back in kg + meters
+func Canonical(entries []store.WorkoutEntry, system string) []store.WorkoutEntry {
+	if system != Imperial || entries == nil {
+		return entries
+	}
+	out := make([]store.WorkoutEntry, len(entries))
+	for i, e := range entries {
+		e.Weight = scaled(e.Weight, KilogramsPerPound, 2)
+		e.Distance = scaled(e.Distance, MetersPerMile, 2)
+		out[i] = e
+	}
+	return out
+}
+
+// ! Workout --> copy of w with its entries in system, nil stays nil
+func Workout(w *store.Workout, system string) *store.Workout {
+	if w == nil || system != Imperial {
+		return w
+	}
+	converted := *w
+	converted.Entries = Entries(w.Entries, system)
+	return &converted
+}
+
+type contextKey struct{}
+
+// * preference --> the profile is read the first time a handler asks, most routes never do
+type preference struct {
+	once   sync.Once
+	load   func() string
+	system string
+}
+
+// ! Resolver --> user pipeline stage that makes the caller's profile preference available to FromRequest
+type Resolver struct {
+	Profiles store.ProfileStore
+	Logger   *log.Logger
+}
+
+// ! NewResolver --> constructor for the units resolver
+func NewResolver(profileStore store.ProfileStore, logger *log.Logger) *Resolver {
+	return &Resolver{Profiles: profileStore, Logger: logger}
+}
+
+// ! Middleware --> runs after authenticate, anonymous callers get metric
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user := middleware.GetUser(req)
+		pref := &preference{load: func() string {
+			if user.IsAnonymousUser() {
+				return Metric
+			}
+			profile, err := r.Profiles.GetProfile(user.ID)
+			if err != nil {
+				r.Logger.Printf("ERROR: units getProfile: %v", err) //* answering in metric beats failing the request
+				return Metric
+			}
+			if profile == nil || !Valid(profile.Units) {
+				return Metric
+			}
+			return profile.Units
+		}}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKey{}, pref)))
+	})
+}
+
+// ! FromRequest --> ?units=metric|imperial wins, then the caller's profile, then metric
+func FromRequest(req *http.Request) string {
+	if system := req.URL.Query().Get("units"); Valid(system) {
+		return system
+	}
+	pref, ok := req.Context().Value(contextKey{}).(*preference)
+	if !ok {
+		return Metric
+	}
+	pref.once.Do(func() { pref.system = pref.load() })
+	return pref.system
+}
@@ -0,0 +1,57 @@
+package units
+
+import (
+	"fem/internal/memstore"
+	"fem/internal/middleware"
+	"fem/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+// ! TestRoundTrip --> imperial in, canonical stored, the same imperial values back out
+func TestRoundTrip(t *testing.T) {
+	sent := []store.WorkoutEntry{{ExerciseName: "Run", Distance: floatPtr(3.1)}, {ExerciseName: "Squat", Weight: floatPtr(225)}}
+	stored := Canonical(sent, Imperial)
+	assert.InDelta(t, 4988.97, *stored[0].Distance, 0.01)
+	assert.InDelta(t, 102.06, *stored[1].Weight, 0.001)
+	assert.Equal(t, 225.0, *sent[1].Weight, "input left alone")
+
+	shown := Workout(&store.Workout{Entries: stored}, Imperial)
+	assert.Equal(t, 3.1, *shown.Entries[0].Distance)
+	assert.Equal(t, 225.0, *shown.Entries[1].Weight, "2 decimal kg survive the round trip")
+	assert.InDelta(t, 102.06, *stored[1].Weight, 0.001, "stored workout left alone")
+
+	assert.Same(t, &sent[0], &Canonical(sent, Metric)[0], "metric is the stored system")
+}
+
+// ! TestFromRequest --> ?units= beats the profile, the profile beats metric
+func TestFromRequest(t *testing.T) {
+	db := memstore.New()
+	users := memstore.NewUserStore(db)
+	ana := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, ana.PasswordHash.Set("password"))
+	require.NoError(t, users.CreateUser(ana))
+	profiles := memstore.NewProfileStore(db)
+	require.NoError(t, profiles.UpsertProfile(&store.Profile{UserID: ana.ID, Units: Imperial}))
+
+	resolver := NewResolver(profiles, log.New(io.Discard, "", 0))
+	system := func(user *store.User, target string) string {
+		var got string
+		handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = FromRequest(r) }))
+		handler.ServeHTTP(httptest.NewRecorder(), middleware.SetUser(httptest.NewRequest(http.MethodGet, target, nil), user))
+		return got
+	}
+
+	assert.Equal(t, Imperial, system(ana, "/workouts/1"))
+	assert.Equal(t, Metric, system(ana, "/workouts/1?units=metric"))
+	assert.Equal(t, Metric, system(store.AnonymousUser, "/shared/x"))
+	assert.Equal(t, Imperial, system(store.AnonymousUser, "/shared/x?units=imperial"))
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ! Fields --> sparse fieldset from ?fields=title,duration_minutes,entries.exercise_name
+// ? a nil entry keeps the whole value, a nested one keeps only its own fields; "id" always stays (JSON:API)
+type Fields map[string]Fields
+
+// ! ParseFields --> ?fields= of r checked against sample's json tags, nil when the client wants everything
+// ? sample is the type being shaped, e.g. store.Workout{}; unknown names are an error so typos don't pass silently
+func ParseFields(r *http.Request, sample any) (Fields, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("fields"))
+	if raw == "" {
+		return nil, nil
+	}
+	fields := Fields{}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node := fields
+		parts := strings.Split(path, ".")
+		for i, name := range parts {
+			child, seen := node[name]
+			if i == len(parts)-1 {
+				node[name] = nil //* the whole value, wins over sub-fields asked for elsewhere
+				break
+			}
+			if seen && child == nil {
+				break //* already selected whole
+			}
+			if child == nil {
+				child = Fields{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	err := fields.check(reflect.TypeOf(sample), "")
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (f Fields) check(t reflect.Type, prefix string) error {
+	t = elemType(t)
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("fields: %s has no sub-fields", strings.TrimSuffix(prefix, "."))
+	}
+	known := jsonFields(t)
+	for name, sub := range f {
+		fieldType, ok := known[name]
+		if !ok {
+			names := make([]string, 0, len(known))
+			for name := range known {
+				names = append(names, prefix+name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("fields: unknown field %q, want one of %s", prefix+name, strings.Join(names, ", "))
+		}
+		if sub != nil {
+			err := sub.check(fieldType, prefix+name+".")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// * elemType --> the struct behind pointers, slices and arrays
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// * jsonFields --> json name --> field type, the way encoding/json names them (embedded structs promoted, "-" skipped)
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && elemType(field.Type).Kind() == reflect.Struct {
+			for promoted, fieldType := range jsonFields(elemType(field.Type)) {
+				out[promoted] = fieldType
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		out[name] = field.Type
+	}
+	return out
+}
+
+// ! Select --> v as JSON-shaped maps + slices holding only the selected fields, v itself when f is nil
+func (f Fields) Select(v any) (any, error) {
+	if f == nil {
+		return v, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() //* ids stay integers, WriteJson can still encode them
+	var shaped any
+	err = dec.Decode(&shaped)
+	if err != nil {
+		return nil, err
+	}
+	return f.prune(shaped), nil
+}
+
+func (f Fields) prune(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, field := range value {
+			sub, keep := f[key]
+			switch {
+			case !keep && key != "id":
+				delete(value, key)
+			case sub != nil:
+				value[key] = sub.prune(field)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = f.prune(item)
+		}
+	}
+	return v
+}
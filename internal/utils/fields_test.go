@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fieldsEntry struct {
+	Name string `json:"exercise_name"`
+	Sets int    `json:"sets"`
+}
+
+type fieldsWorkout struct {
+	ID       int           `json:"id"`
+	Title    string        `json:"title"`
+	Duration int           `json:"duration_minutes"`
+	Tags     []string      `json:"tags,omitempty"`
+	Secret   string        `json:"-"`
+	Entries  []fieldsEntry `json:"entries"`
+}
+
+func parseFields(t *testing.T, query string) (Fields, error) {
+	t.Helper()
+	return ParseFields(httptest.NewRequest(http.MethodGet, "/workouts?fields="+query, nil), fieldsWorkout{})
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields(t, "")
+	require.NoError(t, err)
+	assert.Nil(t, fields, "no ?fields= selects everything")
+
+	fields, err = parseFields(t, "title,%20tags,entries.sets,entries.exercise_name")
+	require.NoError(t, err)
+	assert.Equal(t, Fields{"title": nil, "tags": nil, "entries": {"sets": nil, "exercise_name": nil}}, fields, "omitempty fields are still known")
+
+	fields, err = parseFields(t, "entries.sets,entries")
+	require.NoError(t, err)
+	assert.Equal(t, Fields{"entries": nil}, fields, "the whole value wins")
+
+	_, err = parseFields(t, "title,calories")
+	assert.ErrorContains(t, err, `unknown field "calories"`)
+	_, err = parseFields(t, "entries.weight")
+	assert.ErrorContains(t, err, `unknown field "entries.weight"`)
+	_, err = parseFields(t, "title.length")
+	assert.ErrorContains(t, err, "title has no sub-fields")
+	_, err = parseFields(t, "Secret")
+	assert.Error(t, err, `json:"-" fields don't exist for clients`)
+}
+
+func TestFieldsSelect(t *testing.T) {
+	workouts := []*fieldsWorkout{{ID: 9, Title: "run", Duration: 30, Entries: []fieldsEntry{{Name: "sprint", Sets: 6}}}}
+
+	shaped, err := Fields{"title": nil, "entries": {"sets": nil}}.Select(workouts)
+	require.NoError(t, err)
+	raw, err := json.Marshal(shaped)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id":9,"title":"run","entries":[{"sets":6}]}]`, string(raw))
+
+	var none Fields
+	same, err := none.Select(workouts)
+	require.NoError(t, err)
+	assert.Equal(t, workouts, same)
+}
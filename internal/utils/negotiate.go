@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fem/internal/msgpack"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ! Encoder --> writes one response body; v is the JSON response decoded into maps, slices, json.Number + strings
+// ? encoders see exactly what JSON clients get (encoded ids, ?fields=, links + meta), only the wire format differs
+type Encoder func(w io.Writer, v any) error
+
+// ! MediaJSON --> what WriteJson answers with when nothing else was negotiated
+const MediaJSON = "application/json"
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		"application/xml":     encodeXML,
+		"application/msgpack": msgpack.Encode,
+	}
+	aliases = map[string]string{
+		"text/xml":              "application/xml",
+		"application/x-msgpack": "application/msgpack", //* the pre-registration name most msgpack libraries send
+	}
+)
+
+// ! RegisterEncoder --> responses for clients that Accept mediaType go through enc, call it before serving
+func RegisterEncoder(mediaType string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[strings.ToLower(mediaType)] = enc
+}
+
+// ! Negotiate --> root middleware: picks the response encoding from the Accept header
+// ? JSON whenever it ties or nothing registered matches, a client with a strange Accept still gets an answer
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		if mediaType, enc := negotiate(r.Header.Get("Accept")); enc != nil {
+			w = negotiatedWriter{ResponseWriter: w, mediaType: mediaType, enc: enc}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// * acceptRange --> one entry of an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// * negotiate --> the preferred registered media type + its encoder, nil for JSON
+func negotiate(accept string) (string, Encoder) {
+	if accept == "" {
+		return "", nil
+	}
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if alias, ok := aliases[mediaType]; ok {
+			mediaType = alias
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err == nil {
+					q = parsed
+				}
+			}
+		}
+		if mediaType != "" && q > 0 {
+			ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	for _, rng := range ranges {
+		if rng.mediaType == MediaJSON || strings.HasSuffix(rng.mediaType, "/*") {
+			return "", nil //* wildcards are JSON, an explicit format has to be asked for
+		}
+		if enc, ok := encoders[rng.mediaType]; ok {
+			return rng.mediaType, enc
+		}
+	}
+	return "", nil
+}
+
+// * negotiatedWriter --> marks the response like prettyWriter, WriteJson re-encodes through enc
+type negotiatedWriter struct {
+	http.ResponseWriter
+	mediaType string
+	enc       Encoder
+}
+
+func (w negotiatedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// * reencode --> the JSON in raw through enc
+func reencode(out io.Writer, raw []byte, enc Encoder) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() //* integers stay integers, msgpack sends them as ints
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return err
+	}
+	return enc(out, v)
+}
+
+// ! encodeXML --> <response> with one element per key; array items are <item>, keys that aren't XML names <entry key="...">
+// ? null is an empty element with nil="true", so it can be told apart from ""
+func encodeXML(w io.Writer, v any) error {
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	err := xmlElement(enc, "response", v)
+	if err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func xmlElement(enc *xml.Encoder, name string, v any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !validXMLName(name) {
+		start = xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+	}
+	if v == nil {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "nil"}, Value: "true"})
+	}
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	switch value := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			err = xmlElement(enc, key, value[key])
+			if err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range value {
+			err = xmlElement(enc, "item", item)
+			if err != nil {
+				return err
+			}
+		}
+	case string:
+		err = enc.EncodeToken(xml.CharData(value))
+	case json.Number:
+		err = enc.EncodeToken(xml.CharData(value.String()))
+	case bool:
+		err = enc.EncodeToken(xml.CharData(strconv.FormatBool(value)))
+	}
+	if err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// * validXMLName --> json keys are snake_case in this API, anything else (user supplied map keys) goes in an attribute
+func validXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, c := range name {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || !(c == '-' || c == '.' || (c >= '0' && c <= '9'))) {
+			return false
+		}
+	}
+	return true
+}
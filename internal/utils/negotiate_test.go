@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	serve := func(accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/workouts/42?pretty=1", nil)
+		req.Header.Set("Accept", accept)
+		PrettyJSON(Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, WriteJson(w, http.StatusOK, Envelope{"workout": map[string]any{
+				"id": 42, "title": "<run>", "tags": []string{"legs"}, "notes": nil, "Weird Key": true,
+			}}))
+		}))).ServeHTTP(w, req)
+		return w
+	}
+
+	for _, accept := range []string{"", "*/*", "application/json", "text/html, */*;q=0.8", "application/xml;q=0.5, application/json"} {
+		w := serve(accept)
+		assert.Equal(t, MediaJSON, w.Header().Get("Content-Type"), accept)
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	}
+
+	w := serve("text/html, application/xml;q=0.9")
+	assert.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><workout><entry key="Weird Key">true</entry><id>42</id><notes nil="true"></notes>`+
+		`<tags><item>legs</item></tags><title>&lt;run&gt;</title></workout></response>`, w.Body.String())
+
+	w = serve("application/x-msgpack")
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	assert.Equal(t, []byte{0x81, 0xa7, 'w', 'o', 'r', 'k', 'o', 'u', 't', 0x85}, w.Body.Bytes()[:10])
+
+	RegisterEncoder("text/plain", func(w io.Writer, v any) error {
+		_, err := io.WriteString(w, "workout")
+		return err
+	})
+	defer func() {
+		encodersMu.Lock()
+		delete(encoders, "text/plain")
+		encodersMu.Unlock()
+	}()
+	w = serve("text/plain")
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "workout", w.Body.String())
+}
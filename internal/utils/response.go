@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// ! Response metadata --> every WriteJson object carries "links" + "meta" next to its payload keys
+// ? the payload stays where clients already read it ("workout", "workouts", "error" ...), links + meta are additive
+//
+//	{"workouts": [...], "links": {"self": "/v1/workouts?limit=20", "next": "/v1/workouts?limit=20&offset=20"},
+//	 "meta": {"request_id": "4f1c...", "pagination": {"offset": 0, "limit": 20}}}
+
+// ! Links --> HATEOAS links, relative to the API host; next/prev only on paginated lists
+type Links struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// ! Meta --> request_id matches the X-Request-ID header + the access log line
+type Meta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// ! Pagination --> offset pages fill Offset (+ Total when counted), cursor pages NextCursor
+type Pagination struct {
+	Limit      int     `json:"limit"`
+	Offset     *int    `json:"offset,omitempty"`
+	Total      *int    `json:"total,omitempty"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
+
+// ! Paginate --> links + meta for an offset page of count items, total nil when the list isn't counted
+// ? without a total a full page is assumed to have a successor, like the handlers always did
+func (e Envelope) Paginate(r *http.Request, offset, limit, count int, total *int) Envelope {
+	links := Links{Self: r.URL.RequestURI()}
+	more := count == limit
+	if total != nil {
+		more = offset+count < *total
+	}
+	if more {
+		links.Next = withQuery(r.URL, map[string]string{"offset": strconv.Itoa(offset + count), "limit": strconv.Itoa(limit)})
+	}
+	if offset > 0 {
+		links.Prev = withQuery(r.URL, map[string]string{"offset": strconv.Itoa(max(offset-limit, 0)), "limit": strconv.Itoa(limit)})
+	}
+	e["links"] = links
+	e["meta"] = Meta{Pagination: &Pagination{Limit: limit, Offset: &offset, Total: total}}
+	return e
+}
+
+// ! PaginateCursor --> links + meta for a cursor page, next is the value param takes for the next page ("" on the last one)
+func (e Envelope) PaginateCursor(r *http.Request, param, next string, limit int) Envelope {
+	links := Links{Self: r.URL.RequestURI()}
+	pagination := &Pagination{Limit: limit}
+	if next != "" {
+		links.Next = withQuery(r.URL, map[string]string{param: next})
+		pagination.NextCursor = &next
+	}
+	e["links"] = links
+	e["meta"] = Meta{Pagination: pagination}
+	return e
+}
+
+// * withQuery --> u's path + query with set overriding single parameters
+func withQuery(u *url.URL, set map[string]string) string {
+	query := u.Query()
+	for key, value := range set {
+		query.Set(key, value)
+	}
+	next := *u
+	next.RawQuery = query.Encode()
+	return next.RequestURI()
+}
+
+// * withResponseMeta --> a copy of data with links.self + meta.request_id filled in, handler-set values win
+func withResponseMeta(data Envelope, info *requestInfo) Envelope {
+	out := make(Envelope, len(data)+2)
+	maps.Copy(out, data)
+
+	links, ok := out["links"].(Links)
+	if !ok && out["links"] != nil {
+		return data //* a payload that happens to use the key, leave it alone
+	}
+	if links.Self == "" {
+		links.Self = info.self
+	}
+	meta, ok := out["meta"].(Meta)
+	if !ok && out["meta"] != nil {
+		return data
+	}
+	meta.RequestID = info.requestID
+	out["links"], out["meta"] = links, meta
+	return out
+}
+
+// ! HeaderRequestID --> echoed on every response, taken from the caller (a proxy, a client retry) when well formed
+const HeaderRequestID = "X-Request-ID"
+
+// * validRequestID --> what we accept from outside, it ends up in logs + bodies
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// ! RequestIDFrom --> "" outside of a request (jobs, tests)
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// * requestInfo --> what WriteJson needs from the request it answers, it only gets the ResponseWriter
+type requestInfo struct {
+	http.ResponseWriter
+	requestID string
+	self      string
+}
+
+func (w *requestInfo) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ! RequestID --> root middleware: X-Request-ID in (or a new one) + out, on the context, and links + meta for WriteJson
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+		next.ServeHTTP(&requestInfo{ResponseWriter: w, requestID: id, self: r.URL.RequestURI()}, r)
+	})
+}
+
+func newRequestID() string {
+	raw := make([]byte, 16)
+	rand.Read(raw) //* never fails, see crypto/rand
+	return hex.EncodeToString(raw)
+}
+
+// * findWriter --> the first T along w's Unwrap chain, middlewares wrap the response in between
+func findWriter[T http.ResponseWriter](w http.ResponseWriter) (T, bool) {
+	for {
+		if found, ok := w.(T); ok {
+			return found, true
+		}
+		inner, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		w = inner.Unwrap()
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(PrettyJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+		WriteJson(w, http.StatusOK, Envelope{"workout": map[string]any{"title": "legs"}})
+	})))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/workouts/3?pretty=1", nil))
+	id := rec.Header().Get(HeaderRequestID)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, seen)
+
+	var body struct {
+		Workout map[string]any `json:"workout"`
+		Links   Links          `json:"links"`
+		Meta    Meta           `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "legs", body.Workout["title"])
+	assert.Equal(t, "/v1/workouts/3?pretty=1", body.Links.Self)
+	assert.Equal(t, id, body.Meta.RequestID)
+
+	//* a well formed ID from the caller is kept, anything else replaced
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "edge-7f3a.1")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "edge-7f3a.1", rec.Header().Get(HeaderRequestID))
+
+	req.Header.Set(HeaderRequestID, "bad id\nwith newline")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Len(t, rec.Header().Get(HeaderRequestID), 32)
+
+	//* without the middleware nothing is added
+	rec = httptest.NewRecorder()
+	WriteJson(rec, http.StatusOK, Envelope{"ok": true})
+	assert.JSONEq(t, `{"ok":true}`, rec.Body.String())
+}
+
+func TestPaginate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/workouts?tag=legs&offset=20&limit=10", nil)
+	rec := httptest.NewRecorder()
+	RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJson(w, http.StatusOK, Envelope{"workouts": []int{1, 2}}.Paginate(r, 20, 10, 10, nil))
+	})).ServeHTTP(rec, req)
+
+	var body struct {
+		Links Links `json:"links"`
+		Meta  Meta  `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "/v1/workouts?tag=legs&offset=20&limit=10", body.Links.Self)
+	assert.Equal(t, "/v1/workouts?limit=10&offset=30&tag=legs", body.Links.Next)
+	assert.Equal(t, "/v1/workouts?limit=10&offset=10&tag=legs", body.Links.Prev)
+	assert.Equal(t, rec.Header().Get(HeaderRequestID), body.Meta.RequestID, "the handler's meta keeps the request ID")
+	require.NotNil(t, body.Meta.Pagination)
+	assert.Equal(t, 10, body.Meta.Pagination.Limit)
+	assert.Equal(t, 20, *body.Meta.Pagination.Offset)
+
+	total := 25
+	links := Envelope{}.Paginate(req, 20, 10, 5, &total)["links"].(Links)
+	assert.Empty(t, links.Next, "the last counted page")
+
+	first := httptest.NewRequest(http.MethodGet, "/v1/feed", nil)
+	page := Envelope{}.PaginateCursor(first, "cursor", "abc", 20)
+	assert.Equal(t, "/v1/feed?cursor=abc", page["links"].(Links).Next)
+	assert.Equal(t, "abc", *page["meta"].(Meta).Pagination.NextCursor)
+	page = Envelope{}.PaginateCursor(first, "cursor", "", 20)
+	assert.Empty(t, page["links"].(Links).Next)
+	assert.Empty(t, page["links"].(Links).Prev)
+}
@@ -1,10 +1,15 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fem/internal/hashid"
 	"net/http"
+	"os"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -13,36 +18,206 @@ import (
 //! Envelope --> wrapper for JSON responses, allows flexible key-value pairs
 type Envelope map[string]interface{}
 
+//! ID obfuscation --> configured once at startup via SetIDCodec, nil codec means plain numeric ids
+var (
+	idCodec *hashid.Codec
+	idAcceptNumeric = true
+)
+
+//! SetIDCodec --> every WriteJson response gets encoded ids from now on
+//! acceptNumeric is the compatibility mode, URLs still take the old numeric ids next to the encoded ones
+func SetIDCodec(codec *hashid.Codec,acceptNumeric bool) {
+	idCodec = codec
+	idAcceptNumeric = acceptNumeric
+}
+
+//! FormatID --> id as clients see it, for URLs built server side (download links, badges)
+func FormatID(id int64) string {
+	if idCodec == nil {
+		return strconv.FormatInt(id,10)
+	}
+	return idCodec.Encode(id)
+}
+
+//! prettyJSON --> every response indented, SetPrettyJSON turns it on for APP_ENV=development
+var prettyJSON bool
+
+//! SetPrettyJSON --> indent all WriteJson responses, not just the ?pretty=1 ones
+func SetPrettyJSON(pretty bool) {
+	prettyJSON = pretty
+}
+
+//! bufferPool --> response buffers are reused, list endpoints otherwise allocate a fresh one per request
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+//* maxPooledBuffer --> one huge export shouldn't keep its buffer alive forever
+const maxPooledBuffer = 64 << 10
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
 //! WriteJson --> standardized JSON response writer used across all handlers
+//? compact by default, indented for ?pretty=1 (PrettyJSON middleware) or in dev mode
+//? behind the RequestID middleware the object also gets links.self + meta.request_id, see response.go
+//? behind Negotiate the same object goes out as XML / MessagePack when the client Accepts it, see negotiate.go
 func WriteJson(w http.ResponseWriter, status int, data Envelope) error {
-	//* using MarshalIndent for pretty formatted JSON output (easier to read in browser/postman)
-	json,err := json.MarshalIndent(data,""," ")
-	
+	if info,ok := findWriter[*requestInfo](w); ok {
+		data = withResponseMeta(data,info)
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	negotiated,reencoded := findWriter[negotiatedWriter](w)
+	_,pretty := findWriter[prettyWriter](w)
+	err := encodeResponse(buf,data,(prettyJSON || pretty) && !reencoded)
 	if err != nil {
-		return err
+		return err //* nothing written yet, the handler can still answer with an error
+	}
+
+	mediaType := MediaJSON
+	if reencoded {
+		out := getBuffer()
+		defer putBuffer(out)
+		err = reencode(out,buf.Bytes(),negotiated.enc)
+		if err != nil {
+			return err
+		}
+		mediaType,buf = negotiated.mediaType,out
 	}
 
-	json = append(json, '\n') //* adding newline at end for cleaner terminal output
-	w.Header().Set("Content-type","application/json") //* setting response content type
+	w.Header().Set("Content-type",mediaType) //* setting response content type
 	w.WriteHeader(status) //* HTTP status code (200, 400, 500, etc.)
-	w.Write(json) //* writing JSON to response
+	w.Write(buf.Bytes()) //* writing JSON to response
 	return nil
 }
 
+//! encodeResponse --> data as JSON + newline into buf, "id" / "*_id" fields swapped for their encoded form when a codec is set
+func encodeResponse(buf *bytes.Buffer,data Envelope,pretty bool) error {
+	if idCodec == nil {
+		enc := json.NewEncoder(buf) //* streams into buf, no intermediate []byte like Marshal
+		if pretty {
+			enc.SetIndent(""," ")
+		}
+		return enc.Encode(data) //* Encode adds the trailing newline
+	}
+
+	raw,err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	raw,err = idCodec.RewriteJSON(raw)
+	if err != nil {
+		return err
+	}
+	if pretty {
+		err = json.Indent(buf,raw,""," ")
+	} else {
+		_,err = buf.Write(raw)
+	}
+	buf.WriteByte('\n') //* adding newline at end for cleaner terminal output
+	return err
+}
+
+//! PrettyJSON --> root middleware, ?pretty=1 (or true) indents the JSON this request gets back
+func PrettyJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pretty := r.URL.Query().Get("pretty")
+		if pretty == "1" || pretty == "true" {
+			w = prettyWriter{ResponseWriter: w}
+		}
+		next.ServeHTTP(w,r)
+	})
+}
+
+//* prettyWriter --> marks the response, later middlewares may wrap it again so WriteJson unwraps
+type prettyWriter struct {
+	http.ResponseWriter
+}
+
+func (w prettyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+
 //! ReadIDParam --> extracts and validates ID from URL path parameter
 //! Used by GET/PUT/DELETE endpoints like /workouts/{id}
 func ReadIDParam(r *http.Request) (int64,error) {
-	idParam := chi.URLParam(r,"id") //* reads {id} slug from URL path
+	return ReadInt64Param(r,"id")
+}
+
+//! ReadInt64Param --> same as ReadIDParam for any named slug (e.g. {exportID})
+func ReadInt64Param(r *http.Request,name string) (int64,error) {
+	idParam := chi.URLParam(r,name) //* reads {name} slug from URL path
 	
 	//? if no id was passed or empty string
 	if idParam == "" {
 		return 0, errors.New("Invalid id parameter")
 	}
+	return ParseID(idParam)
+}
+
+//! ParseID --> encoded id when obfuscation is on, plain base 10 when it's off or in compatibility mode
+func ParseID(raw string) (int64,error) {
+	if idCodec != nil {
+		id,err := idCodec.Decode(raw)
+		if err == nil {
+			return id,nil
+		}
+		if !idAcceptNumeric {
+			return 0, errors.New("Invalid id parameter type")
+		}
+	}
+
 	//* convert string to int64 (base 10, 64-bit)
-	id,err := strconv.ParseInt(idParam,10,64)
+	id,err := strconv.ParseInt(raw,10,64)
 	if err!= nil {
 		return 0, errors.New("Invalid id parameter type")
 	}
 	
 	return id,err
-}
\ No newline at end of file
+}
+
+//! ParseTimeParam --> parses query params like ?from= / ?to=
+//! accepts either a plain date (2006-01-02) or a full RFC3339 timestamp
+func ParseTimeParam(raw string) (time.Time,error) {
+	t,err := time.Parse(time.DateOnly,raw)
+	if err == nil {
+		return t,nil
+	}
+	return time.Parse(time.RFC3339,raw)
+}
+
+//! GetEnv --> reads an environment variable with a fallback (same idea as store's getEnv)
+func GetEnv(key,fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+//! GetEnvInt --> integer env var, fallback when missing or not a number
+func GetEnvInt(key string,fallback int) int {
+	value,err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+//! GetEnvDuration --> duration env var like "30s" / "5m", fallback when missing or invalid
+func GetEnvDuration(key string,fallback time.Duration) time.Duration {
+	value,err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
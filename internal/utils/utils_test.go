@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fem/internal/hashid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * wrapped --> stands in for the status recording writers other middlewares put around the response
+type wrapped struct {
+	http.ResponseWriter
+}
+
+func (w wrapped) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestWriteJson(t *testing.T) {
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler := PrettyJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, WriteJson(wrapped{w}, http.StatusCreated, Envelope{"workout": map[string]any{"id": 42, "title": "<run>"}}))
+		}))
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := serve("/workouts")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"workout":{"id":42,"title":"\u003crun\u003e"}}`+"\n", w.Body.String())
+
+	assert.Equal(t, "{\n \"workout\": {\n  \"id\": 42,\n  \"title\": \"\\u003crun\\u003e\"\n }\n}\n", serve("/workouts?pretty=1").Body.String())
+
+	SetPrettyJSON(true)
+	assert.Contains(t, serve("/workouts").Body.String(), "\n  \"id\": 42,")
+	SetPrettyJSON(false)
+
+	codec, err := hashid.New("test-salt")
+	require.NoError(t, err)
+	SetIDCodec(codec, false)
+	defer SetIDCodec(nil, true)
+	assert.Equal(t, `{"workout":{"id":"`+codec.Encode(42)+`","title":"\u003crun\u003e"}}`+"\n", serve("/workouts").Body.String())
+	assert.Contains(t, serve("/workouts?pretty=true").Body.String(), "\n  \"id\": \""+codec.Encode(42)+"\",")
+}
+
+// * benchmarkWorkout --> the shape of GET /workouts/{id}, the most requested response
+func benchmarkWorkout() Envelope {
+	entries := make([]map[string]any, 10)
+	for i := range entries {
+		entries[i] = map[string]any{"id": 1000 + i, "exercise_name": "bench press", "sets": 4, "reps": 10, "weight": 80.5, "order_index": i + 1}
+	}
+	return Envelope{"workout": map[string]any{
+		"id": 42, "user_id": 7, "title": "push day", "description": "chest + triceps",
+		"duration_minutes": 60, "calories_burned": 420, "created_at": time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		"entries": entries,
+	}}
+}
+
+// * discardWriter --> keeps the recorder's own allocations out of the numbers
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func BenchmarkWriteJson(b *testing.B) {
+	envelope := benchmarkWorkout()
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	for b.Loop() {
+		WriteJson(w, http.StatusOK, envelope)
+	}
+}
+
+func BenchmarkWriteJsonPretty(b *testing.B) {
+	envelope := benchmarkWorkout()
+	w := prettyWriter{&discardWriter{header: http.Header{}}}
+	b.ReportAllocs()
+	for b.Loop() {
+		WriteJson(w, http.StatusOK, envelope)
+	}
+}
+
+// ? encoded ids walk the whole document a second time
+func BenchmarkWriteJsonEncodedIDs(b *testing.B) {
+	codec, err := hashid.New("benchmark-salt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	SetIDCodec(codec, false)
+	defer SetIDCodec(nil, true)
+
+	envelope := benchmarkWorkout()
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	for b.Loop() {
+		WriteJson(w, http.StatusOK, envelope)
+	}
+}
@@ -0,0 +1,42 @@
+package verification
+
+import "fem/internal/store"
+
+// ! Requirements --> what a workout needs before it counts on leaderboards
+type Requirements struct {
+	RequireVideo       bool
+	RequireAttestation bool
+	MaxAnomalyScore    float64 //* anomaly.Detector.Score must stay below this
+}
+
+// ! DefaultRequirements --> video proof, a coach sign-off and values well inside the anomaly limits
+func DefaultRequirements() Requirements {
+	return Requirements{
+		RequireVideo:       true,
+		RequireAttestation: true,
+		MaxAnomalyScore:    0.8,
+	}
+}
+
+// ! Evaluate --> derives the verification status and lists what is still missing
+// ? a coach rejection or an implausible workout is final until the workout is edited
+func Evaluate(v *store.WorkoutVerification, flagged bool, req Requirements) (string, []string) {
+	if v.Approved != nil && !*v.Approved {
+		return store.VerificationRejected, []string{"rejected by coach"}
+	}
+	if flagged || v.AnomalyScore >= req.MaxAnomalyScore {
+		return store.VerificationRejected, []string{"anomaly score too high"}
+	}
+
+	missing := []string{}
+	if req.RequireVideo && (v.VideoURL == nil || *v.VideoURL == "") {
+		missing = append(missing, "video attachment")
+	}
+	if req.RequireAttestation && v.Approved == nil {
+		missing = append(missing, "coach attestation")
+	}
+	if len(missing) > 0 {
+		return store.VerificationPending, missing
+	}
+	return store.VerificationVerified, missing
+}
@@ -0,0 +1,31 @@
+package verification
+
+import (
+	"fem/internal/store"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ! TestEvaluate --> each piece of evidence moves the workout along the pipeline
+func TestEvaluate(t *testing.T) {
+	req := DefaultRequirements()
+	video := "https://example.com/lift.mp4"
+	approved, rejected := true, false
+
+	status, missing := Evaluate(&store.WorkoutVerification{AnomalyScore: 0.2}, false, req)
+	assert.Equal(t, store.VerificationPending, status)
+	assert.Equal(t, []string{"video attachment", "coach attestation"}, missing)
+
+	status, _ = Evaluate(&store.WorkoutVerification{VideoURL: &video, Approved: &approved, AnomalyScore: 0.2}, false, req)
+	assert.Equal(t, store.VerificationVerified, status)
+
+	status, _ = Evaluate(&store.WorkoutVerification{VideoURL: &video, Approved: &rejected, AnomalyScore: 0.2}, false, req)
+	assert.Equal(t, store.VerificationRejected, status)
+
+	status, _ = Evaluate(&store.WorkoutVerification{VideoURL: &video, Approved: &approved, AnomalyScore: 0.9}, false, req)
+	assert.Equal(t, store.VerificationRejected, status)
+
+	status, _ = Evaluate(&store.WorkoutVerification{VideoURL: &video, Approved: &approved}, true, req)
+	assert.Equal(t, store.VerificationRejected, status)
+}
@@ -0,0 +1,132 @@
+package warehouse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fem/internal/export"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+//! Sink --> destination warehouse, ClickHouse today (BigQuery etc. can implement the same two calls)
+type Sink interface {
+	EnsureTable(stream *Stream) error
+	Insert(stream *Stream, rows []Row) error
+}
+
+//! ClickHouseSink --> talks to ClickHouse over its HTTP interface, no driver needed
+type ClickHouseSink struct {
+	BaseURL  string //* e.g. http://clickhouse:8123
+	Database string
+	User     string
+	Password string
+	client   *http.Client
+}
+
+//! NewClickHouseSink --> constructor with a bounded HTTP timeout
+func NewClickHouseSink(baseURL, database, user, password string) *ClickHouseSink {
+	return &ClickHouseSink{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Database: database,
+		User:     user,
+		Password: password,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+//! clickHouseType --> column type in the warehouse, everything outside the key is Nullable
+func clickHouseType(column export.Column, nullable bool) string {
+	var typ string
+	switch column.Type {
+	case export.TypeInt:
+		typ = "Int64"
+	case export.TypeFloat:
+		typ = "Float64"
+	case export.TypeBool:
+		typ = "Bool"
+	case export.TypeTime:
+		typ = "DateTime64(3, 'UTC')"
+	default:
+		typ = "String"
+	}
+	if nullable {
+		return "Nullable(" + typ + ")"
+	}
+	return typ
+}
+
+//! exec --> runs one statement, the body is either the query itself or data for a query in the URL
+func (c *ClickHouseSink) exec(query string, body io.Reader) error {
+	params := url.Values{}
+	params.Set("database", c.Database)
+	params.Set("date_time_input_format", "best_effort") //* accept RFC3339 timestamps from encoding/json
+	if body == nil {
+		body = strings.NewReader(query)
+	} else {
+		params.Set("query", query)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL+"/?"+params.Encode(), body)
+	if err != nil {
+		return err
+	}
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("clickhouse : %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("clickhouse : status %d : %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+//! EnsureTable --> creates the table and adds any columns the stream gained since (schema evolution)
+//? ReplacingMergeTree keeps the newest version per key, so re-syncing the same row is harmless
+func (c *ClickHouseSink) EnsureTable(stream *Stream) error {
+	definitions := make([]string, 0, len(stream.Columns))
+	for _, column := range stream.Columns {
+		notNull := slices.Contains(stream.Key, column.Name) || column.Name == stream.Version
+		definitions = append(definitions, fmt.Sprintf("`%s` %s", column.Name, clickHouseType(column, !notNull)))
+	}
+
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (%s) ENGINE = ReplacingMergeTree(`%s`) ORDER BY (%s)",
+		stream.Table, strings.Join(definitions, ", "), stream.Version, strings.Join(stream.Key, ", "))
+	err := c.exec(create, nil)
+	if err != nil {
+		return err
+	}
+
+	for i, column := range stream.Columns {
+		alter := fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN IF NOT EXISTS %s", stream.Table, definitions[i])
+		err = c.exec(alter, nil)
+		if err != nil {
+			return fmt.Errorf("adding column %s : %w", column.Name, err)
+		}
+	}
+	return nil
+}
+
+//! Insert --> one INSERT per batch using JSONEachRow (newline delimited JSON)
+func (c *ClickHouseSink) Insert(stream *Stream, rows []Row) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, row := range rows {
+		err := encoder.Encode(row.Values)
+		if err != nil {
+			return err
+		}
+	}
+	return c.exec(fmt.Sprintf("INSERT INTO `%s` FORMAT JSONEachRow", stream.Table), &body)
+}
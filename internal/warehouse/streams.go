@@ -0,0 +1,184 @@
+package warehouse
+
+import (
+	"fem/internal/export"
+	"fem/internal/store"
+)
+
+// ? - one synced row plus the cursor position it came from
+type Row struct {
+	Cursor store.SyncCursor
+	Values map[string]any
+}
+
+//* changeColumns --> on every stream: deleted rows arrive as tombstones, change_seq orders a row's versions
+var changeColumns = []export.Column{
+	{Name: "deleted", Type: export.TypeBool},
+	{Name: "change_seq", Type: export.TypeInt},
+}
+
+//* newRow --> values plus the change columns, a deleted row keeps only its id
+func newRow(change store.ChangeMeta, id int64, values map[string]any) Row {
+	if change.Deleted {
+		values = map[string]any{"id": id}
+	}
+	values["deleted"] = change.Deleted
+	values["change_seq"] = change.Cursor.Seq
+	return Row{Cursor: change.Cursor, Values: values}
+}
+
+//! Stream --> a change feed from the warehouse_changes log into one warehouse table
+//? columns reuse export.Column so the warehouse and file exports share one schema vocabulary
+type Stream struct {
+	Name    string          //* cursor name in warehouse_sync_cursors
+	Table   string          //* destination table
+	Columns []export.Column //* adding a column here evolves the warehouse table on next sync
+	Key     []string        //* sort / dedup key
+	Version string          //* newer versions replace older rows with the same key, change_seq on the default streams
+	Fetch   func(after store.SyncCursor, limit int) ([]Row, error)
+}
+
+//! DefaultStreams --> workouts, entries, body weights and experiment exposures
+//? analysts query with FINAL and skip deleted rows, ReplacingMergeTree keeps the newest change per id
+func DefaultStreams(warehouseStore store.WarehouseStore) []*Stream {
+	return []*Stream{
+		{
+			Name:  "workouts",
+			Table: "workouts",
+			Columns: append([]export.Column{
+				{Name: "id", Type: export.TypeInt},
+				{Name: "user_id", Type: export.TypeInt},
+				{Name: "title", Type: export.TypeString},
+				{Name: "duration_minutes", Type: export.TypeInt},
+				{Name: "calories_burned", Type: export.TypeInt},
+				{Name: "calories_estimated", Type: export.TypeBool},
+				{Name: "flagged", Type: export.TypeBool},
+				{Name: "created_at", Type: export.TypeTime},
+				{Name: "updated_at", Type: export.TypeTime},
+			}, changeColumns...),
+			Key:     []string{"id"},
+			Version: "change_seq",
+			Fetch: func(after store.SyncCursor, limit int) ([]Row, error) {
+				changes, err := warehouseStore.ListWorkoutChanges(after, limit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]Row, 0, len(changes))
+				for _, c := range changes {
+					rows = append(rows, newRow(c.ChangeMeta, c.ID, map[string]any{
+						"id":                 c.ID,
+						"user_id":            c.UserID,
+						"title":              c.Title,
+						"duration_minutes":   c.DurationMinutes,
+						"calories_burned":    c.CaloriesBurned,
+						"calories_estimated": c.CaloriesEstimated,
+						"flagged":            c.Flagged,
+						"created_at":         c.CreatedAt,
+						"updated_at":         c.UpdatedAt,
+					}))
+				}
+				return rows, nil
+			},
+		},
+		{
+			Name:  "workout_entries",
+			Table: "workout_entries",
+			Columns: append([]export.Column{
+				{Name: "id", Type: export.TypeInt},
+				{Name: "workout_id", Type: export.TypeInt},
+				{Name: "exercise_name", Type: export.TypeString},
+				{Name: "sets", Type: export.TypeInt},
+				{Name: "reps", Type: export.TypeInt},
+				{Name: "duration_seconds", Type: export.TypeInt},
+				{Name: "weight", Type: export.TypeFloat},
+				{Name: "order_index", Type: export.TypeInt},
+				{Name: "created_at", Type: export.TypeTime},
+				{Name: "workout_updated_at", Type: export.TypeTime},
+			}, changeColumns...),
+			Key:     []string{"id"},
+			Version: "change_seq",
+			Fetch: func(after store.SyncCursor, limit int) ([]Row, error) {
+				changes, err := warehouseStore.ListEntryChanges(after, limit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]Row, 0, len(changes))
+				for _, c := range changes {
+					rows = append(rows, newRow(c.ChangeMeta, c.ID, map[string]any{
+						"id":                 c.ID,
+						"workout_id":         c.WorkoutID,
+						"exercise_name":      c.ExerciseName,
+						"sets":               c.Sets,
+						"reps":               c.Reps,
+						"duration_seconds":   c.DurationSeconds,
+						"weight":             c.Weight,
+						"order_index":        c.OrderIndex,
+						"created_at":         c.CreatedAt,
+						"workout_updated_at": c.WorkoutUpdatedAt,
+					}))
+				}
+				return rows, nil
+			},
+		},
+		{
+			Name:  "user_weights",
+			Table: "user_weights",
+			Columns: append([]export.Column{
+				{Name: "id", Type: export.TypeInt},
+				{Name: "user_id", Type: export.TypeInt},
+				{Name: "weight_kg", Type: export.TypeFloat},
+				{Name: "measured_at", Type: export.TypeTime},
+				{Name: "created_at", Type: export.TypeTime},
+			}, changeColumns...),
+			Key:     []string{"id"},
+			Version: "change_seq",
+			Fetch: func(after store.SyncCursor, limit int) ([]Row, error) {
+				changes, err := warehouseStore.ListWeightChanges(after, limit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]Row, 0, len(changes))
+				for _, c := range changes {
+					rows = append(rows, newRow(c.ChangeMeta, c.ID, map[string]any{
+						"id":          c.ID,
+						"user_id":     c.UserID,
+						"weight_kg":   c.WeightKG,
+						"measured_at": c.MeasuredAt,
+						"created_at":  c.CreatedAt,
+					}))
+				}
+				return rows, nil
+			},
+		},
+		{
+			Name:  "experiment_exposures",
+			Table: "experiment_exposures",
+			Columns: append([]export.Column{
+				{Name: "id", Type: export.TypeInt},
+				{Name: "user_id", Type: export.TypeInt},
+				{Name: "experiment", Type: export.TypeString},
+				{Name: "variant", Type: export.TypeString},
+				{Name: "exposed_at", Type: export.TypeTime},
+			}, changeColumns...),
+			Key:     []string{"id"},
+			Version: "change_seq",
+			Fetch: func(after store.SyncCursor, limit int) ([]Row, error) {
+				changes, err := warehouseStore.ListExposureChanges(after, limit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]Row, 0, len(changes))
+				for _, c := range changes {
+					rows = append(rows, newRow(c.ChangeMeta, c.ID, map[string]any{
+						"id":         c.ID,
+						"user_id":    c.UserID,
+						"experiment": c.Experiment,
+						"variant":    c.Variant,
+						"exposed_at": c.ExposedAt,
+					}))
+				}
+				return rows, nil
+			},
+		},
+	}
+}
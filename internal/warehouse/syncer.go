@@ -0,0 +1,104 @@
+package warehouse
+
+import (
+	"context"
+	"fem/internal/store"
+	"log"
+	"time"
+)
+
+//! Syncer --> periodically copies new/changed rows from Postgres into the warehouse
+//! each stream keeps its own cursor, so a failed batch is simply retried on the next tick
+//? rows come from the warehouse_changes log by (tx, seq) cursor, deletes arrive as tombstone rows
+//? and a transaction committing late is only read once it's done, so nothing lands behind the cursor
+type Syncer struct {
+	Sink      Sink
+	Store     store.WarehouseStore
+	Streams   []*Stream
+	BatchSize int
+	Interval  time.Duration
+	Logger    *log.Logger
+
+	ensured map[string]bool //* tables already created/evolved in this process
+}
+
+//! NewSyncer --> constructor with the default streams
+func NewSyncer(sink Sink, warehouseStore store.WarehouseStore, batchSize int, interval time.Duration, logger *log.Logger) *Syncer {
+	return &Syncer{
+		Sink:      sink,
+		Store:     warehouseStore,
+		Streams:   DefaultStreams(warehouseStore),
+		BatchSize: batchSize,
+		Interval:  interval,
+		Logger:    logger,
+		ensured:   map[string]bool{},
+	}
+}
+
+//! Run --> sync loop, returns when ctx is cancelled
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		for _, stream := range s.Streams {
+			synced, err := s.SyncStream(stream)
+			if err != nil {
+				s.Logger.Printf("ERROR: warehouse sync %s: %v", stream.Name, err)
+				continue
+			}
+			if synced > 0 {
+				s.Logger.Printf("warehouse sync %s: %d rows", stream.Name, synced)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//! SyncStream --> drains a stream batch by batch, saving the cursor after every successful insert
+func (s *Syncer) SyncStream(stream *Stream) (int, error) {
+	if !s.ensured[stream.Table] {
+		err := s.Sink.EnsureTable(stream)
+		if err != nil {
+			return 0, err
+		}
+		s.ensured[stream.Table] = true
+	}
+
+	cursor, err := s.Store.GetSyncCursor(stream.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	synced := 0
+	for {
+		rows, err := stream.Fetch(cursor, s.BatchSize)
+		if err != nil {
+			return synced, err
+		}
+		if len(rows) == 0 {
+			return synced, nil
+		}
+
+		err = s.Sink.Insert(stream, rows)
+		if err != nil {
+			return synced, err
+		}
+
+		cursor = rows[len(rows)-1].Cursor
+		err = s.Store.SaveSyncCursor(stream.Name, cursor)
+		if err != nil {
+			return synced, err
+		}
+
+		synced += len(rows)
+		if len(rows) < s.BatchSize {
+			return synced, nil
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package warehouse
+
+import (
+	"errors"
+	"fem/internal/memstore"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// * recordingSink --> keeps every inserted row per table, fails inserts while err is set
+type recordingSink struct {
+	rows map[string][]map[string]any
+	err  error
+}
+
+func (s *recordingSink) EnsureTable(stream *Stream) error {
+	return nil
+}
+
+func (s *recordingSink) Insert(stream *Stream, rows []Row) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, row := range rows {
+		s.rows[stream.Table] = append(s.rows[stream.Table], row.Values)
+	}
+	return nil
+}
+
+func newTestSyncer(t *testing.T) (*Syncer, *recordingSink, *memstore.DB) {
+	db := memstore.New()
+	sink := &recordingSink{rows: map[string][]map[string]any{}}
+	return NewSyncer(sink, memstore.NewWarehouseStore(db), 2, time.Minute, log.New(io.Discard, "", 0)), sink, db
+}
+
+func syncAll(t *testing.T, syncer *Syncer) {
+	for _, stream := range syncer.Streams {
+		_, err := syncer.SyncStream(stream)
+		require.NoError(t, err, stream.Name)
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// ! TestSyncPropagatesUpdatesAndDeletes --> every change reaches the sink once, deletes as tombstones
+func TestSyncPropagatesUpdatesAndDeletes(t *testing.T) {
+	syncer, sink, db := newTestSyncer(t)
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, memstore.NewUserStore(db).CreateUser(user))
+	workouts := memstore.NewWorkoutStore(db)
+	workout, err := workouts.CreateWorkout(&store.Workout{UserID: user.ID, Title: "push", DurationMinutes: 45, Entries: []store.WorkoutEntry{
+		{ExerciseName: "bench press", Sets: 3, Reps: intPtr(10), OrderIndex: 1},
+		{ExerciseName: "dips", Sets: 3, Reps: intPtr(12), OrderIndex: 2},
+		{ExerciseName: "plank", Sets: 1, DurationSeconds: intPtr(60), OrderIndex: 3},
+	}})
+	require.NoError(t, err)
+	oldEntries := workout.Entries
+	require.NoError(t, memstore.NewProfileStore(db).AddWeight(&store.WeightEntry{UserID: user.ID, WeightKG: 80, MeasuredAt: time.Now()}))
+
+	syncAll(t, syncer)
+	require.Len(t, sink.rows["workouts"], 1)
+	assert.Equal(t, "push", sink.rows["workouts"][0]["title"])
+	assert.Equal(t, false, sink.rows["workouts"][0]["deleted"])
+	assert.Len(t, sink.rows["workout_entries"], 3, "more rows than BatchSize take several batches")
+	assert.Len(t, sink.rows["user_weights"], 1)
+
+	workout.Title = "push (heavy)"
+	workout.Entries = []store.WorkoutEntry{{ExerciseName: "bench press", Sets: 5, Reps: intPtr(5), OrderIndex: 1}}
+	require.NoError(t, workouts.UpdateWorkout(workout))
+	syncAll(t, syncer)
+
+	require.Len(t, sink.rows["workouts"], 2, "only the changed workout is sent again")
+	assert.Equal(t, "push (heavy)", sink.rows["workouts"][1]["title"])
+	entries := sink.rows["workout_entries"][3:]
+	require.Len(t, entries, 4, "three replaced entries and the new one")
+	tombstones := map[int64]bool{}
+	for _, row := range entries {
+		if row["deleted"] == true {
+			tombstones[row["id"].(int64)] = true
+			assert.NotContains(t, row, "exercise_name", "a tombstone carries its key only")
+		}
+	}
+	for _, e := range oldEntries {
+		assert.True(t, tombstones[int64(e.ID)], "entry %d", e.ID)
+	}
+
+	require.NoError(t, workouts.DeleteWorkout(int64(workout.ID)))
+	syncAll(t, syncer)
+	last := sink.rows["workouts"][len(sink.rows["workouts"])-1]
+	assert.Equal(t, int64(workout.ID), last["id"])
+	assert.Equal(t, true, last["deleted"])
+	assert.Greater(t, last["change_seq"], sink.rows["workouts"][1]["change_seq"], "the tombstone replaces the older versions")
+
+	before := len(sink.rows["workouts"]) + len(sink.rows["workout_entries"]) + len(sink.rows["user_weights"])
+	syncAll(t, syncer)
+	assert.Equal(t, before, len(sink.rows["workouts"])+len(sink.rows["workout_entries"])+len(sink.rows["user_weights"]), "nothing changed, nothing sent")
+}
+
+// ! TestSyncRetriesFailedInsert --> a batch the sink refused leaves the cursor where it was
+func TestSyncRetriesFailedInsert(t *testing.T) {
+	syncer, sink, db := newTestSyncer(t)
+	user := &store.User{Username: "ana", Email: "ana@example.com"}
+	require.NoError(t, memstore.NewUserStore(db).CreateUser(user))
+	_, err := memstore.NewWorkoutStore(db).CreateWorkout(&store.Workout{UserID: user.ID, Title: "run", DurationMinutes: 30})
+	require.NoError(t, err)
+
+	stream := syncer.Streams[0]
+	sink.err = errors.New("clickhouse down")
+	synced, err := syncer.SyncStream(stream)
+	require.Error(t, err)
+	assert.Zero(t, synced)
+	cursor, err := syncer.Store.GetSyncCursor(stream.Name)
+	require.NoError(t, err)
+	assert.Zero(t, cursor)
+
+	sink.err = nil
+	synced, err = syncer.SyncStream(stream)
+	require.NoError(t, err)
+	assert.Equal(t, 1, synced)
+	assert.Equal(t, "run", sink.rows["workouts"][0]["title"])
+}
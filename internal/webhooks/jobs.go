@@ -0,0 +1,144 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fem/internal/events"
+	"fem/internal/store"
+	"fem/internal/worker"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ! background job types for webhook delivery
+const (
+	JobDispatch = "webhooks.dispatch" //* one event --> a delivery per subscribed webhook
+	JobDeliver  = "webhooks.deliver"  //* one POST, retried with the pool's backoff
+)
+
+// ! DispatchPayload --> webhooks.dispatch, built from the bus event
+type DispatchPayload struct {
+	Event     string    `json:"event"`
+	UserID    int       `json:"user_id"`
+	WorkoutID int       `json:"workout_id"`
+	At        time.Time `json:"at"`
+}
+
+// ! DeliverPayload --> webhooks.deliver
+type DeliverPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// ! Body --> JSON document POSTed to the receiver
+type Body struct {
+	Event      string         `json:"event"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+// ! Dispatcher --> turns workout events into signed deliveries
+type Dispatcher struct {
+	Store    store.WebhookStore
+	Workouts store.WorkoutStore //* created/updated payloads carry the workout
+	Jobs     worker.Enqueuer
+	Client   *http.Client
+	Logger   *log.Logger
+}
+
+// ! Subscribe --> events are handed to the worker right away, lookups + HTTP happen there
+func (d *Dispatcher) Subscribe(bus *events.Bus) {
+	bus.Subscribe(func(e events.Event) error {
+		return d.Jobs.Enqueue(JobDispatch, DispatchPayload{Event: e.Type, UserID: e.UserID, WorkoutID: e.WorkoutID, At: e.At})
+	}, Events...)
+}
+
+// ! DispatchJob --> payload is a DispatchPayload
+func DispatchJob(d *Dispatcher) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p DispatchPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+
+		hooks, err := d.Store.ListWebhooksForEvent(p.UserID, p.Event)
+		if err != nil || len(hooks) == 0 {
+			return err
+		}
+
+		body := Body{Event: p.Event, OccurredAt: p.At, Data: map[string]any{"workout_id": p.WorkoutID}}
+		if p.Event != events.WorkoutDeleted {
+			workout, err := d.Workouts.GetWorkoutByID(int64(p.WorkoutID))
+			if errors.Is(err, store.ErrNotFound) {
+				return nil //* deleted before we got to it, the delete event follows
+			}
+			if err != nil {
+				return err
+			}
+			body.Data["workout"] = workout
+		}
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		for _, hook := range hooks {
+			delivery := &store.WebhookDelivery{WebhookID: hook.ID, Event: p.Event, Payload: raw}
+			err = d.Store.CreateDelivery(delivery)
+			if err != nil {
+				return err
+			}
+			err = d.Jobs.Enqueue(JobDeliver, DeliverPayload{DeliveryID: delivery.ID})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ! DeliverJob --> payload is a DeliverPayload, every attempt lands in the delivery log
+// ? a failed attempt returns the error so the pool retries, the last one marks the delivery failed
+func DeliverJob(d *Dispatcher) worker.HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p DeliverPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+
+		delivery, err := d.Store.GetDelivery(p.DeliveryID)
+		if err != nil {
+			return err
+		}
+		if delivery == nil || delivery.Status != store.DeliveryPending {
+			return nil //* webhook deleted, or a retry of an attempt that was already recorded
+		}
+		hook, err := d.Store.GetWebhook(delivery.WebhookID)
+		if err != nil || hook == nil {
+			return err
+		}
+
+		status, sendErr := post(ctx, d.Client, hook.URL, hook.Secret, delivery.Event, delivery.ID, delivery.Payload)
+		var responseStatus *int
+		if status != 0 {
+			responseStatus = &status
+		}
+
+		outcome, lastError := store.DeliverySucceeded, ""
+		if sendErr != nil {
+			lastError = sendErr.Error()
+			outcome = store.DeliveryPending
+			if info, ok := worker.Info(ctx); !ok || info.LastAttempt() {
+				outcome = store.DeliveryFailed
+			}
+		}
+		err = d.Store.RecordDeliveryAttempt(delivery.ID, outcome, responseStatus, lastError)
+		if err != nil {
+			d.Logger.Printf("ERROR: webhooks record delivery %d: %v", delivery.ID, err)
+		}
+		return sendErr
+	}
+}
@@ -0,0 +1,145 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fem/internal/events"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ! headers sent with every delivery
+const (
+	HeaderSignature = "X-Webhook-Signature" //* t=<unix>,v1=<hex hmac-sha256 of "<t>.<body>">
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery" //* delivery id, the same across retries so receivers can dedupe
+)
+
+// ! Events --> event types a webhook can subscribe to
+var Events = []string{events.WorkoutCreated, events.WorkoutUpdated, events.WorkoutDeleted}
+
+// ! MaxPerUser --> webhooks one user can register
+const MaxPerUser = 10
+
+// ! NewSecret --> random signing key, handed to the user once on create
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ! Sign --> value of the signature header, the timestamp is signed too so old payloads can't be replayed
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ! ValidateEvents --> at least one event, all of them known, duplicates dropped
+func ValidateEvents(list []string) ([]string, error) {
+	if len(list) == 0 {
+		return nil, errors.New("events must not be empty")
+	}
+	out := []string{}
+	for _, e := range list {
+		if !slices.Contains(Events, e) {
+			return nil, fmt.Errorf("unknown event %q", e)
+		}
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// ! ValidateURL --> absolute https URL (http only when allowHTTP), no credentials, no literal private address
+// ? hostnames are checked again at dial time, see NewClient
+func ValidateURL(raw string, allowHTTP bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return errors.New("url must be an absolute URL")
+	}
+	if u.Scheme != "https" && !(allowHTTP && u.Scheme == "http") {
+		return errors.New("url must use https")
+	}
+	if u.User != nil {
+		return errors.New("url must not contain credentials")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !allowHTTP && blocked(ip) {
+		return errors.New("url must not point at a private address")
+	}
+	return nil
+}
+
+// ! blocked --> addresses a user-supplied URL must never reach
+func blocked(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast()
+}
+
+// ! NewClient --> HTTP client for deliveries, refuses to connect to private addresses unless allowPrivate
+// ? the check runs on the resolved IP so DNS pointing at internal hosts is caught too
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blocked(ip) {
+				return fmt.Errorf("webhooks: refusing to connect to %s", host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse //* a redirect is a failed delivery, not a way around ValidateURL
+		},
+	}
+}
+
+// ! post --> one delivery attempt, returns the response status (0 when there was none)
+func post(ctx context.Context, client *http.Client, target, secret, event string, deliveryID int64, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fittrack-webhooks/1")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(deliveryID, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
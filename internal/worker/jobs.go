@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/events"
+	"fem/internal/mailer"
+	"fem/internal/store"
+	"log"
+	"time"
+)
+
+// ! built-in job types
+const (
+	JobTokenCleanup       = "tokens.cleanup"
+	JobSendEmail          = "email.send"
+	JobFeedFanout         = "feed.fanout"
+	JobFeedBackfill       = "feed.backfill"
+	JobAccountPurge       = "accounts.purge"
+	JobSandboxPurge       = "sandbox.purge"
+	JobLeaderboardRefresh = "leaderboards.refresh"
+)
+
+// ! FeedFanoutPayload --> feed.fanout, copy one workout into its owner's followers' feeds
+type FeedFanoutPayload struct {
+	WorkoutID int64 `json:"workout_id"`
+}
+
+// ! FeedBackfillPayload --> feed.backfill, fill a new follower's feed with the followee's past workouts
+type FeedBackfillPayload struct {
+	FollowerID int64 `json:"follower_id"`
+	FolloweeID int64 `json:"followee_id"`
+}
+
+// ! TokenCleanup --> deletes expired tokens
+func TokenCleanup(tokenStore store.TokenStore, logger *log.Logger) HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		count, err := tokenStore.DeleteExpiredTokens()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logger.Printf("worker: deleted %d expired tokens", count)
+		}
+		return nil
+	}
+}
+
+// ! AccountPurge --> hard deletes accounts soft-deleted more than grace ago
+func AccountPurge(userStore store.UserStore, grace time.Duration, logger *log.Logger) HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		count, err := userStore.PurgeDeletedUsers(time.Now().Add(-grace))
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logger.Printf("worker: purged %d deleted accounts", count)
+		}
+		return nil
+	}
+}
+
+// ! SandboxPurge --> hard deletes sandbox accounts past their expiry
+func SandboxPurge(sandboxStore store.SandboxStore, logger *log.Logger) HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		count, err := sandboxStore.PurgeExpiredSandboxes(time.Now())
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logger.Printf("worker: purged %d expired sandbox accounts", count)
+		}
+		return nil
+	}
+}
+
+// ! LeaderboardRefresh --> recomputes the cached weekly + streak numbers behind GET /leaderboards/{metric}
+func LeaderboardRefresh(leaderboardStore store.LeaderboardStore) HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		return leaderboardStore.RefreshLeaderboards()
+	}
+}
+
+// ! SendEmail --> payload is a mailer.Message
+func SendEmail(m mailer.Mailer) HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var msg mailer.Message
+		err := json.Unmarshal(payload, &msg)
+		if err != nil {
+			return err
+		}
+		return m.Send(ctx, msg)
+	}
+}
+
+// ! FeedFanout --> payload is a FeedFanoutPayload, each follower with a new entry gets a FeedEntryAdded event
+func FeedFanout(followStore store.FollowStore, bus *events.Bus) HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p FeedFanoutPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+		followers, err := followStore.FanOutWorkout(p.WorkoutID)
+		if err != nil {
+			return err
+		}
+		for _, followerID := range followers {
+			bus.Publish(events.Event{Type: events.FeedEntryAdded, UserID: followerID, WorkoutID: int(p.WorkoutID)})
+		}
+		return nil
+	}
+}
+
+// ! FeedBackfill --> payload is a FeedBackfillPayload
+func FeedBackfill(followStore store.FollowStore) HandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p FeedBackfillPayload
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return err
+		}
+		_, err = followStore.BackfillFeed(p.FollowerID, p.FolloweeID)
+		return err
+	}
+}
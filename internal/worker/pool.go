@@ -0,0 +1,254 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fem/internal/store"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ! HandlerFunc --> runs one job, a returned error schedules a retry with backoff
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// ! Enqueuer --> what request handlers need to push work off the request path
+type Enqueuer interface {
+	Enqueue(jobType string, payload any) error
+}
+
+// ! Backoff --> exponential retry delay, Base after the first failure, doubling up to Max
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// ! Delay --> wait before the next try, attempt is how many runs have already failed
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := b.Base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= b.Max {
+			return b.Max
+		}
+	}
+	return min(delay, b.Max)
+}
+
+type periodicJob struct {
+	jobType  string
+	interval time.Duration
+}
+
+// ! Pool --> fixed number of workers polling the jobs table
+type Pool struct {
+	Store        store.JobStore
+	Concurrency  int
+	PollInterval time.Duration
+	Lease        time.Duration //* a running job older than this is assumed orphaned and requeued
+	Backoff      Backoff
+	Logger       *log.Logger
+
+	handlers map[string]HandlerFunc
+	periodic []periodicJob
+	running  atomic.Bool
+}
+
+// ! NewPool --> constructor with default lease + backoff
+func NewPool(jobStore store.JobStore, concurrency int, pollInterval time.Duration, logger *log.Logger) *Pool {
+	return &Pool{
+		Store:        jobStore,
+		Concurrency:  max(concurrency, 1),
+		PollInterval: pollInterval,
+		Lease:        10 * time.Minute,
+		Backoff:      Backoff{Base: 10 * time.Second, Max: time.Hour},
+		Logger:       logger,
+		handlers:     map[string]HandlerFunc{},
+	}
+}
+
+// ! Register --> handler for a job type, call before Run
+func (p *Pool) Register(jobType string, handler HandlerFunc) {
+	p.handlers[jobType] = handler
+}
+
+// ! Every --> enqueues an empty-payload job of this type on a fixed interval while the pool runs
+func (p *Pool) Every(jobType string, interval time.Duration) {
+	p.periodic = append(p.periodic, periodicJob{jobType: jobType, interval: interval})
+}
+
+// ! Enqueue --> payload is stored as JSON and handed back to the handler as-is
+func (p *Pool) Enqueue(jobType string, payload any) error {
+	return p.EnqueueAt(jobType, payload, time.Time{})
+}
+
+// ! EnqueueAt --> same as Enqueue but not run before runAt (zero means now)
+func (p *Pool) EnqueueAt(jobType string, payload any, runAt time.Time) error {
+	if _, ok := p.handlers[jobType]; !ok {
+		return fmt.Errorf("worker: no handler registered for job type %q", jobType)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return p.Store.EnqueueJob(&store.Job{Type: jobType, Payload: raw, RunAt: runAt})
+}
+
+// ! Running --> true between Run starting and every worker having returned
+func (p *Pool) Running() bool {
+	return p.running.Load()
+}
+
+// ! Run --> starts the workers, periodic jobs and lease reaper, blocks until ctx is cancelled
+// ? a cancelled ctx lets each worker finish (or abort, if its handler honours ctx) the job it holds
+func (p *Pool) Run(ctx context.Context) {
+	p.running.Store(true)
+	defer p.running.Store(false)
+	var wg sync.WaitGroup
+
+	for i := 0; i < p.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	for _, job := range p.periodic {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.tick(ctx, job)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.reap(ctx)
+	}()
+
+	wg.Wait()
+}
+
+// ! work --> drains due jobs, sleeps PollInterval whenever the queue is empty
+func (p *Pool) work(ctx context.Context) {
+	for {
+		job, err := p.Store.ClaimJob()
+		if err != nil {
+			p.Logger.Printf("ERROR: worker claim: %v", err)
+		}
+		if job != nil {
+			p.process(ctx, job)
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.PollInterval):
+		}
+	}
+}
+
+func (p *Pool) tick(ctx context.Context, job periodicJob) {
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.Enqueue(job.jobType, struct{}{})
+			if err != nil {
+				p.Logger.Printf("ERROR: worker enqueue %s: %v", job.jobType, err)
+			}
+		}
+	}
+}
+
+// ! reap --> requeues jobs whose worker went away mid-run
+func (p *Pool) reap(ctx context.Context) {
+	ticker := time.NewTicker(p.Lease / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := p.Store.RequeueStaleJobs(time.Now().Add(-p.Lease))
+			if err != nil {
+				p.Logger.Printf("ERROR: worker reap: %v", err)
+			} else if count > 0 {
+				p.Logger.Printf("worker: requeued %d stale jobs", count)
+			}
+		}
+	}
+}
+
+// ! process --> runs the handler and records the outcome (done, retry later, or failed for good)
+func (p *Pool) process(ctx context.Context, job *store.Job) {
+	err := p.run(ctx, job)
+	if err == nil {
+		err = p.Store.CompleteJob(job.ID)
+		if err != nil {
+			p.Logger.Printf("ERROR: worker complete job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		p.Logger.Printf("ERROR: job %d (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		err = p.Store.FailJob(job.ID, err.Error())
+	} else {
+		p.Logger.Printf("job %d (%s) attempt %d failed, retrying: %v", job.ID, job.Type, job.Attempts, err)
+		err = p.Store.RetryJob(job.ID, time.Now().Add(p.Backoff.Delay(job.Attempts)), err.Error())
+	}
+	if err != nil {
+		p.Logger.Printf("ERROR: worker record job %d: %v", job.ID, err)
+	}
+}
+
+// ! JobInfo --> which attempt a handler is running, see Info
+type JobInfo struct {
+	ID          int64
+	Attempt     int
+	MaxAttempts int
+}
+
+// ! LastAttempt --> a failure now is final, no more retries
+func (i JobInfo) LastAttempt() bool {
+	return i.Attempt >= i.MaxAttempts
+}
+
+type jobInfoKey struct{}
+
+// ! Info --> the running job's attempt counters, false outside a worker
+func Info(ctx context.Context) (JobInfo, bool) {
+	info, ok := ctx.Value(jobInfoKey{}).(JobInfo)
+	return info, ok
+}
+
+// ! run --> a panicking handler counts as a failed attempt instead of killing the worker
+func (p *Pool) run(ctx context.Context, job *store.Job) (err error) {
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler registered for job type %q", job.Type)
+	}
+	ctx = context.WithValue(ctx, jobInfoKey{}, JobInfo{ID: job.ID, Attempt: job.Attempts, MaxAttempts: job.MaxAttempts})
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
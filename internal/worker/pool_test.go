@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fem/internal/store"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryJobStore --> records what the pool did with each job
+type memoryJobStore struct {
+	completed []int64
+	retried   map[int64]time.Time
+	failed    map[int64]string
+}
+
+func newMemoryJobStore() *memoryJobStore {
+	return &memoryJobStore{retried: map[int64]time.Time{}, failed: map[int64]string{}}
+}
+
+func (s *memoryJobStore) EnqueueJob(job *store.Job) error { return nil }
+func (s *memoryJobStore) ClaimJob() (*store.Job, error)   { return nil, nil }
+func (s *memoryJobStore) CompleteJob(id int64) error {
+	s.completed = append(s.completed, id)
+	return nil
+}
+func (s *memoryJobStore) RetryJob(id int64, runAt time.Time, lastError string) error {
+	s.retried[id] = runAt
+	return nil
+}
+func (s *memoryJobStore) FailJob(id int64, lastError string) error {
+	s.failed[id] = lastError
+	return nil
+}
+func (s *memoryJobStore) RequeueStaleJobs(lockedBefore time.Time) (int64, error) { return 0, nil }
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: 10 * time.Second, Max: time.Minute}
+
+	assert.Equal(t, 10*time.Second, b.Delay(0))
+	assert.Equal(t, 10*time.Second, b.Delay(1))
+	assert.Equal(t, 20*time.Second, b.Delay(2))
+	assert.Equal(t, 40*time.Second, b.Delay(3))
+	assert.Equal(t, time.Minute, b.Delay(4))
+	assert.Equal(t, time.Minute, b.Delay(100))
+}
+
+func TestPoolProcess(t *testing.T) {
+	jobStore := newMemoryJobStore()
+	pool := NewPool(jobStore, 1, time.Second, log.New(io.Discard, "", 0))
+	pool.Register("ok", func(ctx context.Context, payload json.RawMessage) error { return nil })
+	pool.Register("boom", func(ctx context.Context, payload json.RawMessage) error { return errors.New("boom") })
+	pool.Register("panic", func(ctx context.Context, payload json.RawMessage) error { panic("oops") })
+
+	ctx := context.Background()
+	pool.process(ctx, &store.Job{ID: 1, Type: "ok", Attempts: 1, MaxAttempts: 5})
+	pool.process(ctx, &store.Job{ID: 2, Type: "boom", Attempts: 1, MaxAttempts: 5})
+	pool.process(ctx, &store.Job{ID: 3, Type: "boom", Attempts: 5, MaxAttempts: 5})
+	pool.process(ctx, &store.Job{ID: 4, Type: "panic", Attempts: 5, MaxAttempts: 5})
+	pool.process(ctx, &store.Job{ID: 5, Type: "unknown", Attempts: 5, MaxAttempts: 5})
+
+	assert.Equal(t, []int64{1}, jobStore.completed)
+	require.Contains(t, jobStore.retried, int64(2))
+	assert.WithinDuration(t, time.Now().Add(pool.Backoff.Base), jobStore.retried[2], time.Second)
+	assert.Equal(t, "boom", jobStore.failed[3])
+	assert.Equal(t, "panic: oops", jobStore.failed[4])
+	assert.Contains(t, jobStore.failed[5], "no handler")
+}
+
+func TestEnqueueRejectsUnknownType(t *testing.T) {
+	pool := NewPool(newMemoryJobStore(), 1, time.Second, log.New(io.Discard, "", 0))
+
+	err := pool.Enqueue("nope", nil)
+	assert.Error(t, err)
+}
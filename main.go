@@ -2,58 +2,431 @@ package main
 
 // imports
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fem/internal/app"
+	"fem/internal/config"
+	"fem/internal/devseed"
+	"fem/internal/httpserver"
+	"fem/internal/listener"
+	"fem/internal/loadtest"
+	"fem/internal/memstore"
+	"fem/internal/selftest"
+	"fem/internal/store"
+	"fem/internal/tlsconfig"
+	"fem/migrations"
 	"fem/internal/routes"
+	"fem/internal/utils"
 	"flag"
 	"fmt"
+	"io/fs"
+	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
 // Main function where go application spins up
 func main() {
 
+	//! subcommands --> `fittrack seed` fills the configured database with development data and exits
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		os.Exit(runSeed(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		os.Exit(runLoadTest(os.Args[2:]))
+	}
+
+	//! -config --> the file has to be read before the flags below pick their env defaults
+	configFile := configPath(os.Args[1:])
+	var fileConfig *config.Config
+	if configFile != "" {
+		var err error
+		fileConfig,err = config.Load(configFile)
+		if err != nil {
+			log.Fatalf("ERROR: %v",err)
+		}
+		err = fileConfig.ApplyEnv()
+		if err != nil {
+			log.Fatalf("ERROR: %v",err)
+		}
+	}
+
 	// fallback port if not specified
 	var port int
-	flag.IntVar(&port,"port",8080,"GO BACKEND SERVER!")
+	flag.IntVar(&port,"port",utils.GetEnvInt("PORT",8080),"GO BACKEND SERVER! (env PORT)")
+	var grpcPort int
+	flag.IntVar(&grpcPort,"grpc-port",utils.GetEnvInt("GRPC_PORT",9090),"gRPC API for internal services, 0 disables it (env GRPC_PORT)")
+	var listen string
+	flag.StringVar(&listen,"listen",utils.GetEnv("LISTEN",""),"unix:/path.sock, systemd[:name] (socket activation) or host:port, overrides -port (env LISTEN)")
+	var socketMode string
+	flag.StringVar(&socketMode,"socket-mode",utils.GetEnv("SOCKET_MODE","0660"),"permissions of a unix socket from -listen, octal (env SOCKET_MODE)")
+	var adminPort int
+	flag.IntVar(&adminPort,"admin-port",utils.GetEnvInt("ADMIN_PORT",0),"admin UI + API, /metrics and /debug/* on their own port, 0 keeps them on -port (env ADMIN_PORT)")
+	var adminHost string
+	flag.StringVar(&adminHost,"admin-host",utils.GetEnv("ADMIN_HOST",""),"interface the admin port binds to, e.g. 127.0.0.1 (env ADMIN_HOST)")
+	var debugAddr string
+	flag.StringVar(&debugAddr,"debug-addr",utils.GetEnv("DEBUG_ADDR",""),"pprof + runtime stats without auth on a loopback address, e.g. 127.0.0.1:6060, empty disables it (env DEBUG_ADDR)")
+	var selfTest bool
+	flag.BoolVar(&selfTest,"selftest",false,"check config, database, migrations, export storage, mailer + cache, print a JSON report and exit (1 on failure)")
+	var demo bool
+	flag.BoolVar(&demo,"demo",os.Getenv("DEMO") == "true","in-memory stores seeded with sample users + workouts, no postgres needed, nothing is persisted (env DEMO=true)")
+	flag.String("config",configFile,"YAML config file, env vars win over it, log_level/cors/public_api reload on SIGHUP (env CONFIG_FILE)")
 	flag.Parse() // execute it
 
-	app,err := app.NewApplication() //! returns Logger's output
+	//! -selftest --> CI/CD smoke check before traffic is routed, exits before anything starts serving
+	if selfTest {
+		report := runSelfTest()
+		if !report.OK {
+			os.Exit(1)
+		}
+		return
+	}
+
+	//! -demo --> frontend work without any external service, every store lives in memory
+	if demo {
+		os.Setenv("DB_DRIVER","memory")
+	}
+
+	app,err := app.NewBuilder().WithConfig(fileConfig).Build() //! returns Logger's output, the config file's log level + CORS + limits already applied
 
 	//  if caught any error intiting app
 	if err !=nil {
 		panic(err) // exits the app 
 	}
 
-	// closing db connection
-	defer app.DB.Close() //!defer the execution to the very end of the application
+	if demo {
+		usernames,err := memstore.Seed(app.MemDB)
+		if err != nil {
+			panic(err)
+		}
+		app.Logger.Printf("demo mode : log in as %v with password %q, demo is an admin\n",usernames,memstore.DemoPassword)
+	}
+
+	//! SIGHUP --> re-reads the config file and applies log level, CORS origins + public API limits, a bad file keeps the old ones
+	reloadSignal := make(chan os.Signal,1)
+	signal.Notify(reloadSignal,syscall.SIGHUP)
+	go func() {
+		for range reloadSignal {
+			if configFile == "" {
+				//* no file, PUBLIC_API_FILE is still worth re-reading
+				err := app.Reload(&config.Config{})
+				if err != nil {
+					app.Logger.Printf("ERROR: reload : %v\n",err)
+				}
+				continue
+			}
+			reloaded,err := config.Load(configFile)
+			if err == nil {
+				err = app.Reload(reloaded)
+			}
+			if err != nil {
+				app.Logger.Printf("ERROR: reload : %v, keeping the previous settings\n",err)
+				continue
+			}
+			app.Logger.Println("reload : ports, listen address + env settings only change on restart")
+		}
+	}()
 
 	// ? - otherwise successfully imported function and executed
 	fmt.Println("app is running!")
+	app.Logger.Printf("instance : %+v\n",app.Lifecycle.Instance)
+
+	//* SIGTERM (kubectl rollout, docker stop) or ctrl+c starts the graceful shutdown below
+	shutdownSignal,stopSignals := signal.NotifyContext(context.Background(),syscall.SIGTERM,syscall.SIGINT)
+	defer stopSignals()
+
+	//! background loops (workers, schedules, usage flushes, db pings) --> components of the app, stopped after the servers
+	err = app.Start(context.Background())
+	if err != nil {
+		app.Logger.Fatalf("ERROR: %v",err)
+	}
+
+	//! custom middleware goes in here, e.g. corporate SSO in front of token auth:
+	//! app.UserPipeline.Before("authenticate","corp_sso",corpSSO) --> stage names are the Stage* consts in internal/app
+	//! plugin hooks register the same way, e.g. app.Hooks.OnWorkoutCreated.Register("crm",0,hooks.Continue,syncToCRM)
+	app.Logger.Printf("middleware : %v | user routes : %v\n",app.Pipeline.Names(),app.UserPipeline.Names())
 
 	//! server management
 
 	// ? - handles request on this path
 	r := routes.SetupRoutes(app)	// needs to pass logger as it points to application struct
-	// creating instance of a server
-	server := &http.Server{
-		Addr: fmt.Sprintf(":%d",port),
-		IdleTimeout: time.Minute,
-		Handler: r, //! now parent handler is set for all route req --> handled through chi routes
-		ReadTimeout: 10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+	//* timeouts, header limit, keep-alives + h2c come from HTTP_* env vars, see internal/httpserver
+	serverConfig := httpserver.ConfigFromEnv()
+	connStats := httpserver.NewConnStats() //* the API port, a separate ADMIN_PORT keeps its scrapes out of the counts
+	connStats.Register(app.Metrics)
+	//* separate admin port --> operators firewall it, the public port stops answering admin + debug paths
+	var adminServer *http.Server
+	if adminPort != 0 {
+		r = routes.SetupPublicRoutes(app)
+		adminServer = httpserver.New(net.JoinHostPort(adminHost,strconv.Itoa(adminPort)),routes.SetupAdminRoutes(app),serverConfig,nil)
+	}
+	//* loopback debug listener --> profiles over an ssh tunnel / kubectl port-forward without an admin token
+	var debugServer *http.Server
+	var debugListener net.Listener
+	if debugAddr != "" {
+		debugListener,err = listener.Loopback(debugAddr)
+		if err != nil {
+			app.Logger.Fatalf("ERROR: DEBUG_ADDR: %v",err)
+		}
+		debugConfig := serverConfig
+		debugConfig.WriteTimeout = 0 //* a 30s CPU profile must not hit HTTP_WRITE_TIMEOUT
+		debugServer = httpserver.New(debugAddr,routes.SetupDebugRoutes(app),debugConfig,nil)
 	}
+	// creating instance of a server
+	server := httpserver.New(fmt.Sprintf(":%d",port),r,serverConfig,connStats) //! now parent handler is set for all route req --> handled through chi routes
 
-	app.Logger.Printf("App is running on port : %d\n",port)
+	//* gRPC API on its own port --> same services + stores as the HTTP handlers
+	if grpcPort != 0 {
+		listener,err := net.Listen("tcp",fmt.Sprintf(":%d",grpcPort))
+		if err != nil {
+			app.Logger.Fatalf("ERROR: %v",err)
+		}
+		go func() {
+			err := app.GRPCServer.Serve(listener)
+			if err != nil {
+				app.Logger.Printf("ERROR: grpc server: %v",err)
+			}
+		}()
+		app.Logger.Printf("gRPC API is running on port : %d\n",grpcPort)
+	}
 
+	//* -listen --> unix socket behind a reverse proxy, or the socket systemd keeps open across restarts
+	if listen == "" {
+		listen = fmt.Sprintf(":%d",port)
+	}
+	mode,err := strconv.ParseUint(socketMode,8,32)
+	if err != nil {
+		app.Logger.Fatalf("ERROR: invalid -socket-mode %q: %v",socketMode,err)
+	}
+	httpListener,err := listener.Listen(listen,fs.FileMode(mode))
+	if err != nil {
+		app.Logger.Fatalf("ERROR: %v",err)
+	}
 
+	//! HTTPS --> TLS_CERT_FILE + TLS_KEY_FILE, or TLS_AUTOCERT_DOMAINS for Let's Encrypt; the admin port stays plain HTTP
+	tlsConfig,err := tlsconfig.FromEnv()
+	if err != nil {
+		app.Logger.Fatalf("ERROR: %v",err)
+	}
+	var redirectServer *http.Server
+	if tlsConfig != nil {
+		httpsPort := ""
+		if addr,ok := httpListener.Addr().(*net.TCPAddr); ok {
+			httpsPort = strconv.Itoa(addr.Port)
+		}
+		redirectServer,err = tlsConfig.Setup(server,httpsPort,app.Logger)
+		if err != nil {
+			app.Logger.Fatalf("ERROR: %v",err)
+		}
+		app.Logger.Printf("App is running on : %s (HTTPS)\n",httpListener.Addr())
+	} else {
+		app.Logger.Printf("App is running on : %s\n",httpListener.Addr())
+	}
 
 	// * server listens for any incoming request
-	err = server.ListenAndServe() // returns error if failed to listen for a sever
-   // if caught error listening for a server
-	if err !=nil {
-		app.Logger.Fatal(err)
-	} 
+	go func() {
+		var err error
+		if tlsConfig != nil {
+			err = server.ServeTLS(httpListener,"","") //* certificates come from server.TLSConfig
+		} else {
+			err = server.Serve(httpListener) // returns error if failed to serve
+		}
+		// if caught error listening for a server
+		if err != nil && !errors.Is(err,http.ErrServerClosed) {
+			app.Logger.Fatalf("ERROR: %v",err)
+		}
+	}()
+	if redirectServer != nil {
+		app.Logger.Printf("HTTP -> HTTPS redirect is running on : %s\n",redirectServer.Addr)
+		go func() {
+			err := redirectServer.ListenAndServe()
+			if err != nil && !errors.Is(err,http.ErrServerClosed) {
+				app.Logger.Fatalf("ERROR: %v",err)
+			}
+		}()
+	}
+	if adminServer != nil {
+		app.Logger.Printf("Admin endpoints are running on : %s\n",adminServer.Addr)
+		go func() {
+			err := adminServer.ListenAndServe()
+			if err != nil && !errors.Is(err,http.ErrServerClosed) {
+				app.Logger.Fatalf("ERROR: %v",err)
+			}
+		}()
+	}
+
+	if debugServer != nil {
+		app.Logger.Printf("Debug endpoints are running on : %s\n",debugListener.Addr())
+		go func() {
+			err := debugServer.Serve(debugListener)
+			if err != nil && !errors.Is(err,http.ErrServerClosed) {
+				app.Logger.Fatalf("ERROR: %v",err)
+			}
+		}()
+	}
 
+	//! graceful shutdown --> in-flight workout writes finish, new requests go to other pods
+	<-shutdownSignal.Done()
+	stopSignals() //* a second signal kills the process right away
+	app.Logger.Printf("shutdown : draining for %s\n",app.Lifecycle.DrainDelay)
+	app.Lifecycle.Drain() //* /ready answers 503, the endpoints controller takes this pod out of rotation
+	time.Sleep(app.Lifecycle.DrainDelay)
+
+	ctx,cancel := context.WithTimeout(context.Background(),app.Lifecycle.Timeout)
+	defer cancel()
+	err = server.Shutdown(ctx) //* stops accepting, waits for in-flight requests
+	if err != nil {
+		app.Logger.Printf("ERROR: http shutdown: %v",err)
+	}
+	if redirectServer != nil {
+		err = redirectServer.Shutdown(ctx)
+		if err != nil {
+			app.Logger.Printf("ERROR: redirect http shutdown: %v",err)
+		}
+	}
+	if adminServer != nil {
+		err = adminServer.Shutdown(ctx)
+		if err != nil {
+			app.Logger.Printf("ERROR: admin http shutdown: %v",err)
+		}
+	}
+	if debugServer != nil {
+		debugServer.Close() //* nothing to drain, a running profile is just cut off
+	}
+	if grpcPort != 0 {
+		stopped := make(chan struct{})
+		go func() {
+			app.GRPCServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			app.GRPCServer.Stop()
+		}
+	}
+
+	//* workers finish the job they hold, then the loops exit and the database closes
+	err = app.Stop(ctx)
+	if err != nil {
+		app.Logger.Printf("ERROR: shutdown : %v, exiting anyway",err)
+	}
+	app.Logger.Printf("shutdown : complete\n")
+
+}
+
+//! runSelfTest --> the JSON report is the only thing on stdout, store logging goes to stderr meanwhile
+func runSelfTest() selftest.Report {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	report := selftest.Run(context.Background(),selftest.DefaultChecks(migrations.FS),utils.GetEnvDuration("SELFTEST_TIMEOUT",10*time.Second))
+	os.Stdout = stdout
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("","  ")
+	encoder.Encode(report)
+	return report
+}
+
+//! runSeed --> fittrack seed [-users N] [-days D] [-seed S] [-prefix P], postgres only, migrates first like the server does
+func runSeed(args []string) int {
+	flags := flag.NewFlagSet("seed",flag.ExitOnError)
+	users := flags.Int("users",25,"accounts to create")
+	days := flags.Int("days",365,"days of workout history per account")
+	seed := flags.Int64("seed",1,"random seed, the same seed produces the same data")
+	prefix := flags.String("prefix","seed","username prefix, accounts are <prefix>_001 ...")
+	flags.Parse(args)
+
+	logger := log.New(os.Stderr,"",log.Ldate | log.Ltime)
+	if driver := utils.GetEnv("DB_DRIVER","postgres"); driver != "postgres" {
+		logger.Printf("ERROR: seed needs DB_DRIVER=postgres, got %q",driver)
+		return 1
+	}
+	db,err := store.Open()
+	if err != nil {
+		logger.Printf("ERROR: %v",err)
+		return 1
+	}
+	defer db.Close()
+	err = store.WaitForDB(context.Background(),db,utils.GetEnvDuration("DB_WAIT_TIMEOUT",time.Minute),logger)
+	if err == nil {
+		err = store.Migratefs(db,migrations.FS,".")
+	}
+	if err != nil {
+		logger.Printf("ERROR: %v",err)
+		return 1
+	}
+
+	ctx,stop := signal.NotifyContext(context.Background(),syscall.SIGTERM,syscall.SIGINT)
+	defer stop()
+	result,err := devseed.Run(ctx,devseed.Stores{
+		Users: store.NewPostUserStore(db),
+		Workouts: store.NewPostgresWorkoutStore(db),
+		Follows: store.NewPostgresFollowStore(db),
+	},devseed.Options{Users: *users,Days: *days,Seed: *seed,Prefix: *prefix},logger)
+	if err != nil {
+		logger.Printf("ERROR: seed: %v",err)
+		return 1
+	}
+	logger.Printf("seed : %d users, %d workouts, %d follows created, password %q\n",result.Users,result.Workouts,result.Follows,devseed.Password)
+	return 0
 }
 
+//! runLoadTest --> fittrack loadtest [-format k6|vegeta] [-url U] ..., prints a script for the hot paths this build serves
+//? the router is built on in-memory stores, nothing is started and no database is needed
+func runLoadTest(args []string) int {
+	flags := flag.NewFlagSet("loadtest",flag.ExitOnError)
+	format := flags.String("format","k6","k6 (JavaScript for k6 run) or vegeta (JSON targets for vegeta attack -format=json)")
+	baseURL := flags.String("url","http://localhost:8080","API base URL the script targets")
+	username := flags.String("user","loadtest","account k6 signs in as, registered in setup when missing")
+	password := flags.String("password","load-test-Passw0rd!","password of -user")
+	token := flags.String("token","","bearer token for the vegeta targets")
+	workoutID := flags.String("workout","","workout id for /workouts/{id} in the vegeta targets, empty skips those routes")
+	flags.Parse(args)
+
+	logger := log.New(os.Stderr,"",log.Ldate | log.Ltime)
+	os.Setenv("DB_DRIVER","memory")
+	stdout := os.Stdout
+	os.Stdout = os.Stderr //* the script is the only thing on stdout, like the selftest report
+	app,err := app.NewBuilder().WithLogOutput(os.Stderr).Build()
+	os.Stdout = stdout
+	if err != nil {
+		logger.Printf("ERROR: %v",err)
+		return 1
+	}
+	script,err := loadtest.Generate(*format,routes.SetupRoutes(app),loadtest.Options{
+		BaseURL: *baseURL,
+		Username: *username,
+		Password: *password,
+		Token: *token,
+		WorkoutID: *workoutID,
+	})
+	if err != nil {
+		logger.Printf("ERROR: %v",err)
+		return 1
+	}
+	fmt.Print(script)
+	return 0
+}
+
+//? configPath --> -config / --config from the command line, CONFIG_FILE otherwise
+func configPath(args []string) string {
+	for i,arg := range args {
+		for _,prefix := range []string{"-config","--config"} {
+			if arg == prefix && i+1 < len(args) {
+				return args[i+1]
+			}
+			if strings.HasPrefix(arg,prefix+"=") {
+				return strings.TrimPrefix(arg,prefix+"=")
+			}
+		}
+	}
+	return os.Getenv("CONFIG_FILE")
+}
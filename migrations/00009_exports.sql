@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE org_members
+ADD COLUMN IF NOT EXISTS share_stats BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE IF NOT EXISTS exports (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,
+  org_id BIGINT REFERENCES orgs(id) ON DELETE CASCADE,
+  requested_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  format TEXT NOT NULL DEFAULT 'csv',
+  anonymize BOOLEAN NOT NULL DEFAULT FALSE,
+  range_from TIMESTAMP WITH TIME ZONE NOT NULL,
+  range_to TIMESTAMP WITH TIME ZONE NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  file_path TEXT,
+  error TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  completed_at TIMESTAMP WITH TIME ZONE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE exports;
+ALTER TABLE org_members DROP COLUMN IF EXISTS share_stats;
+-- +goose StatementEnd
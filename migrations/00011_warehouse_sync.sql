@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS warehouse_sync_cursors (
+  name TEXT PRIMARY KEY,
+  cursor_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  cursor_id BIGINT NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_workouts_updated_at_id ON workouts (updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_workout_entries_created_at_id ON workout_entries (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workout_entries_created_at_id;
+DROP INDEX IF EXISTS idx_workouts_updated_at_id;
+DROP TABLE warehouse_sync_cursors;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_verifications (
+  workout_id BIGINT PRIMARY KEY REFERENCES workouts(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'verified', 'rejected')),
+  video_url TEXT,
+  attested_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  approved BOOLEAN,
+  note TEXT NOT NULL DEFAULT '',
+  attested_at TIMESTAMP WITH TIME ZONE,
+  anomaly_score NUMERIC(6, 3) NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE workouts
+ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workouts DROP COLUMN IF EXISTS verified;
+DROP TABLE workout_verifications;
+-- +goose StatementEnd
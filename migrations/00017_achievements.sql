@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_achievements (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  achievement TEXT NOT NULL,
+  earned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, achievement)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_achievements;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS schedules (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  duration_minutes INTEGER NOT NULL,
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  rrule TEXT NOT NULL,
+  materialized_until TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_user ON schedules (user_id);
+
+CREATE TABLE IF NOT EXISTS schedule_occurrences (
+  id BIGSERIAL PRIMARY KEY,
+  schedule_id BIGINT NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  original_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  occurs_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  title VARCHAR(255),
+  duration_minutes INTEGER,
+  status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'skipped')),
+  edited BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (schedule_id, original_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_occurrences_user ON schedule_occurrences (user_id, occurs_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE schedule_occurrences;
+DROP TABLE schedules;
+-- +goose StatementEnd
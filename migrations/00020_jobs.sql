@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS jobs (
+  id BIGSERIAL PRIMARY KEY,
+  type TEXT NOT NULL,
+  payload JSONB NOT NULL DEFAULT '{}',
+  status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL DEFAULT 5,
+  run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  locked_at TIMESTAMP WITH TIME ZONE,
+  last_error TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queued ON jobs (run_at, id) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs (locked_at) WHERE status = 'running';
+
+-- push-model feed: feed.fanout jobs copy each workout into its followers' feeds
+CREATE TABLE IF NOT EXISTS feed_entries (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  workout_id BIGINT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (user_id, workout_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_feed_entries_user_created ON feed_entries (user_id, created_at DESC, workout_id DESC);
+
+INSERT INTO feed_entries (user_id, workout_id, created_at)
+SELECT f.follower_id, w.id, COALESCE(w.created_at, CURRENT_TIMESTAMP)
+FROM follows f
+INNER JOIN workouts w ON w.user_id = f.followee_id
+WHERE w.visibility IN ('followers', 'public')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE feed_entries;
+DROP TABLE jobs;
+-- +goose StatementEnd
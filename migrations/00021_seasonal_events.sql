@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- admins are granted by hand: UPDATE users SET is_admin = TRUE WHERE username = '...'
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS events_opt_in BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS seasonal_events (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  metric TEXT NOT NULL CHECK (metric IN ('workouts', 'duration_minutes', 'calories', 'volume_kg')),
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  badge_color VARCHAR(7) NOT NULL DEFAULT '#9c27b0',
+  closed_at TIMESTAMP WITH TIME ZONE,
+  created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT seasonal_event_window CHECK (ends_at > starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS seasonal_event_participants (
+  event_id BIGINT NOT NULL REFERENCES seasonal_events(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  enrolled_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (event_id, user_id)
+);
+
+-- final standings frozen when the event closes, every row is an earned badge
+CREATE TABLE IF NOT EXISTS seasonal_event_results (
+  event_id BIGINT NOT NULL REFERENCES seasonal_events(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  rank INTEGER NOT NULL,
+  score DOUBLE PRECISION NOT NULL,
+  PRIMARY KEY (event_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE seasonal_event_results;
+DROP TABLE seasonal_event_participants;
+DROP TABLE seasonal_events;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS events_opt_in;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- first exposure per user and variant, synced to the warehouse as the experiment_exposures stream
+CREATE TABLE IF NOT EXISTS experiment_exposures (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  experiment TEXT NOT NULL,
+  variant TEXT NOT NULL,
+  exposed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, experiment, variant)
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiment_exposures_exposed_at_id ON experiment_exposures (exposed_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE experiment_exposures;
+-- +goose StatementEnd
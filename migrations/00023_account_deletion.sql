@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- soft-deleted accounts keep their row (and workouts) until the purge job hard-deletes them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- comments outlive their author, anonymized
+ALTER TABLE workout_comments ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE workout_comments DROP CONSTRAINT IF EXISTS workout_comments_user_id_fkey;
+ALTER TABLE workout_comments ADD CONSTRAINT workout_comments_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM workout_comments WHERE user_id IS NULL;
+ALTER TABLE workout_comments DROP CONSTRAINT IF EXISTS workout_comments_user_id_fkey;
+ALTER TABLE workout_comments ADD CONSTRAINT workout_comments_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE workout_comments ALTER COLUMN user_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
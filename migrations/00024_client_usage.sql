@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- request counts per day, client and route pattern, flushed from memory by each API instance
+CREATE TABLE IF NOT EXISTS client_usage (
+  day DATE NOT NULL,
+  client_id TEXT NOT NULL,
+  client_version TEXT NOT NULL,
+  method TEXT NOT NULL,
+  route TEXT NOT NULL,
+  requests BIGINT NOT NULL DEFAULT 0,
+  last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (day, client_id, client_version, method, route)
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_usage_route ON client_usage (route, day);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE client_usage;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- one row per connected third-party account, tokens are refreshed in place by the sync job
+CREATE TABLE IF NOT EXISTS integration_connections (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  external_user_id TEXT NOT NULL,
+  access_token TEXT NOT NULL,
+  refresh_token TEXT NOT NULL,
+  token_expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  scope TEXT NOT NULL DEFAULT '',
+  synced_until TIMESTAMP WITH TIME ZONE, -- start time of the newest activity pulled so far
+  last_synced_at TIMESTAMP WITH TIME ZONE,
+  last_sync_error TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, provider)
+);
+
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS external_source TEXT;
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS external_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workouts_external ON workouts (user_id, external_source, external_id) WHERE external_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workouts_external;
+ALTER TABLE workouts DROP COLUMN IF EXISTS external_id;
+ALTER TABLE workouts DROP COLUMN IF EXISTS external_source;
+DROP TABLE integration_connections;
+-- +goose StatementEnd
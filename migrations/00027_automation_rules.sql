@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user-defined trigger + condition + action, evaluated when the trigger event is published
+CREATE TABLE IF NOT EXISTS automation_rules (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  trigger TEXT NOT NULL,
+  condition JSONB, -- NULL fires on every trigger event
+  action JSONB NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  last_fired_at TIMESTAMP WITH TIME ZONE, -- a rule fires at most once per condition window
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_automation_rules_user_trigger ON automation_rules (user_id, trigger) WHERE enabled;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE automation_rules;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- mirrored GET requests whose secondary response didn't match the primary one
+CREATE TABLE IF NOT EXISTS shadow_diffs (
+  id BIGSERIAL PRIMARY KEY,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL, -- path + query as the client sent it
+  primary_status INTEGER NOT NULL,
+  shadow_status INTEGER, -- NULL when the secondary couldn't be reached
+  differences TEXT[] NOT NULL, -- JSON paths that differ, capped
+  error TEXT,
+  primary_ms INTEGER NOT NULL,
+  shadow_ms INTEGER NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_shadow_diffs_created ON shadow_diffs (created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE shadow_diffs;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- locale the CSV cells are formatted for, '' keeps the machine-readable format
+ALTER TABLE exports ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE exports DROP COLUMN locale;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- throwaway demo accounts, the sandbox purge job hard-deletes them once this has passed
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sandbox_expires_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_users_sandbox_expires_at ON users (sandbox_expires_at) WHERE sandbox_expires_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_sandbox_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS sandbox_expires_at;
+-- +goose StatementEnd
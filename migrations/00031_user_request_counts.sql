@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- authenticated requests per user and day, flushed from memory by each API instance, shown on GET /users/me/usage
+CREATE TABLE IF NOT EXISTS user_request_counts (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  day DATE NOT NULL,
+  requests BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (user_id, day)
+);
+
+-- the per-user export quota counts account exports by requester
+CREATE INDEX IF NOT EXISTS idx_exports_requested_by ON exports (requested_by, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_exports_requested_by;
+DROP TABLE user_request_counts;
+-- +goose StatementEnd
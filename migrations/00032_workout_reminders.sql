@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- per-user reminder rules, the reminders job evaluates them in the rule's timezone
+CREATE TABLE IF NOT EXISTS reminders (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  remind_at TEXT NOT NULL, -- local wall clock, HH:MM
+  timezone TEXT NOT NULL DEFAULT 'UTC', -- IANA name
+  days TEXT NOT NULL DEFAULT 'planned', -- planned = only days with a scheduled occurrence, every = daily
+  email BOOLEAN NOT NULL DEFAULT FALSE, -- also email, the live event stream always gets it
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  snoozed_until TIMESTAMP WITH TIME ZONE, -- muted until then, fires again once it passes
+  last_sent_on DATE, -- local date of the last delivery, one regular delivery per day
+  last_sent_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_user_id ON reminders (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reminders;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- acute:chronic workload alerts already sent, one per user, day and band
+CREATE TABLE IF NOT EXISTS training_load_alerts (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  day DATE NOT NULL,
+  band TEXT NOT NULL,
+  ratio DOUBLE PRECISION NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, day, band)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE training_load_alerts;
+-- +goose StatementEnd
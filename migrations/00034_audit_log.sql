@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- security-relevant events (logins, password changes, token revocations, admin actions)
+-- actor_id / target_id carry no foreign key on purpose, the trail outlives purged accounts
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  action TEXT NOT NULL,
+  actor_id BIGINT, -- who did it, NULL for failed logins + anonymous callers
+  target_id BIGINT, -- the user it was done to, when that's someone else
+  ip TEXT NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  metadata JSONB,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_action_id ON audit_log (action, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log (actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target_id ON audit_log (target_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- failed login bookkeeping, one row per username and per client IP
+-- username rows exist for unknown names too, so a lockout doesn't reveal which accounts exist
+CREATE TABLE IF NOT EXISTS login_lockouts (
+  kind TEXT NOT NULL, -- username | ip
+  key TEXT NOT NULL,
+  failures INTEGER NOT NULL DEFAULT 0, -- since the last lockout
+  lockouts INTEGER NOT NULL DEFAULT 0, -- consecutive lockouts, drives the backoff
+  locked_until TIMESTAMP WITH TIME ZONE,
+  last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (kind, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_lockouts_locked_until ON login_lockouts (locked_until) WHERE locked_until IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE login_lockouts;
+-- +goose StatementEnd
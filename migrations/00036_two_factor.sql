@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- TOTP two-factor authentication, a row without confirmed_at is a setup that hasn't been confirmed yet
+CREATE TABLE IF NOT EXISTS two_factor (
+  user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret TEXT NOT NULL, -- base32 TOTP secret
+  last_step BIGINT NOT NULL DEFAULT 0, -- last accepted time step, a code is never accepted twice
+  confirmed_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- single-use recovery codes, sha256 hashed like tokens
+CREATE TABLE IF NOT EXISTS two_factor_backup_codes (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash BYTEA NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE,
+  PRIMARY KEY (user_id, code_hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE two_factor_backup_codes;
+DROP TABLE two_factor;
+-- +goose StatementEnd
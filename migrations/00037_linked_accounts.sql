@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- "sign in with" identities, one per provider account, a user may link several providers
+CREATE TABLE IF NOT EXISTS linked_accounts (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL, -- google | github
+  subject TEXT NOT NULL, -- the provider's user id
+  email TEXT NOT NULL DEFAULT '',
+  signed_up BOOLEAN NOT NULL DEFAULT FALSE, -- the account was created through it, its password is unknown to the user
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  last_login_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (provider, subject),
+  UNIQUE (user_id, provider)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE linked_accounts;
+-- +goose StatementEnd
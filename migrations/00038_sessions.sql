@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- every auth token is a session: an id to revoke it by, plus where it was last used from
+-- last_used_at / ip / user_agent are written by the auth middleware, at most once a minute per token
+ALTER TABLE tokens
+  ADD COLUMN IF NOT EXISTS id BIGSERIAL,
+  ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tokens_id ON tokens (id);
+CREATE INDEX IF NOT EXISTS idx_tokens_user_scope ON tokens (user_id, scope);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tokens_user_scope;
+DROP INDEX IF EXISTS idx_tokens_id;
+ALTER TABLE tokens
+  DROP COLUMN IF EXISTS user_agent,
+  DROP COLUMN IF EXISTS ip,
+  DROP COLUMN IF EXISTS last_used_at,
+  DROP COLUMN IF EXISTS created_at,
+  DROP COLUMN IF EXISTS id;
+-- +goose StatementEnd
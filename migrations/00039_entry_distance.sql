@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- distances are stored in meters (like weights in kg), the API converts for imperial users
+ALTER TABLE workout_entries ADD COLUMN IF NOT EXISTS distance DECIMAL(10, 2) CHECK (distance >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workout_entries DROP COLUMN IF EXISTS distance;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- IANA zone name, stats and streaks cut days at the user's local midnight
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- when the workout was done, created_at is when it was logged
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS performed_at TIMESTAMP WITH TIME ZONE;
+UPDATE workouts SET performed_at = created_at WHERE performed_at IS NULL;
+ALTER TABLE workouts ALTER COLUMN performed_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE workouts ALTER COLUMN performed_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_workouts_user_performed ON workouts (user_id, performed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workouts_user_performed;
+ALTER TABLE workouts DROP COLUMN IF EXISTS performed_at;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- free-form labels on workouts, stored normalized (lowercase, trimmed)
+CREATE TABLE IF NOT EXISTS workout_tags (
+  workout_id BIGINT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+  tag VARCHAR(32) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (workout_id, tag)
+);
+
+-- ?tag= filtering + autocomplete go from tag to workouts
+CREATE INDEX IF NOT EXISTS idx_workout_tags_tag ON workout_tags (tag, workout_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_tags;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- metadata only, the image + its thumbnail live in the blob store (BLOB_STORE)
+CREATE TABLE IF NOT EXISTS workout_photos (
+  id BIGSERIAL PRIMARY KEY,
+  workout_id BIGINT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+  object_key TEXT NOT NULL,
+  thumbnail_key TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_photos_workout ON workout_photos (workout_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_photos;
+-- +goose StatementEnd
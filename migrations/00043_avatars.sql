@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- blob store key of the current avatar (BLOB_STORE), NULL until one is uploaded
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS avatar_key TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS avatar_key;
+-- +goose StatementEnd
@@ -0,0 +1,72 @@
+-- +goose Up
+-- +goose StatementBegin
+-- change log behind GET /sync: one row per workout, moved to a fresh seq on every change, deleted workouts keep theirs as a tombstone
+-- no FK on user_id (like audit_log): purging a user deletes their workouts, the tombstones are written after the user row is gone
+CREATE TABLE IF NOT EXISTS workout_changes (
+  seq BIGSERIAL PRIMARY KEY,
+  workout_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  deleted BOOLEAN NOT NULL DEFAULT FALSE,
+  changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workout_changes_workout ON workout_changes (workout_id);
+CREATE INDEX IF NOT EXISTS idx_workout_changes_user_seq ON workout_changes (user_id, seq);
+
+-- the per-user advisory lock is held until commit, so a user's changes commit in seq order
+-- and a client that read up to seq N can't miss a lower seq committed later
+CREATE OR REPLACE FUNCTION record_workout_change(changed_workout BIGINT, changed_user BIGINT, is_deleted BOOLEAN) RETURNS VOID AS $$
+BEGIN
+  PERFORM pg_advisory_xact_lock(hashtext('workout_changes'), changed_user::INTEGER);
+  DELETE FROM workout_changes WHERE workout_id = changed_workout;
+  INSERT INTO workout_changes (workout_id, user_id, deleted) VALUES (changed_workout, changed_user, is_deleted);
+END;
+$$ LANGUAGE plpgsql;
+
+-- entries are always rewritten together with their workout row, so the workouts trigger covers them
+CREATE OR REPLACE FUNCTION workouts_changed() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    PERFORM record_workout_change(OLD.id, OLD.user_id, TRUE);
+  ELSE
+    PERFORM record_workout_change(NEW.id, NEW.user_id, FALSE);
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- tag edits don't touch the workout row; the cascade from a deleted workout finds no workout and keeps the tombstone
+CREATE OR REPLACE FUNCTION workout_tags_changed() RETURNS TRIGGER AS $$
+DECLARE
+  owner BIGINT;
+BEGIN
+  SELECT user_id INTO owner FROM workouts WHERE id = COALESCE(NEW.workout_id, OLD.workout_id);
+  IF FOUND THEN
+    PERFORM record_workout_change(COALESCE(NEW.workout_id, OLD.workout_id), owner, FALSE);
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER workouts_record_change
+AFTER INSERT OR UPDATE OR DELETE ON workouts
+FOR EACH ROW EXECUTE FUNCTION workouts_changed();
+
+CREATE TRIGGER workout_tags_record_change
+AFTER INSERT OR DELETE ON workout_tags
+FOR EACH ROW EXECUTE FUNCTION workout_tags_changed();
+
+-- workouts from before the log existed, the first sync picks them up
+INSERT INTO workout_changes (workout_id, user_id, changed_at)
+SELECT id, user_id, updated_at FROM workouts ORDER BY updated_at, id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS workout_tags_record_change ON workout_tags;
+DROP TRIGGER IF EXISTS workouts_record_change ON workouts;
+DROP FUNCTION IF EXISTS workout_tags_changed();
+DROP FUNCTION IF EXISTS workouts_changed();
+DROP FUNCTION IF EXISTS record_workout_change(BIGINT, BIGINT, BOOLEAN);
+DROP TABLE IF EXISTS workout_changes;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- domain events written in the transaction of the workout write they describe, the outbox relay publishes them afterwards
+-- no FK on user_id: a purge must not drop events that were never published
+CREATE TABLE IF NOT EXISTS events_outbox (
+  id BIGSERIAL PRIMARY KEY,
+  event_type VARCHAR(64) NOT NULL,
+  user_id BIGINT NOT NULL,
+  workout_id BIGINT NOT NULL DEFAULT 0,
+  ref TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  published_at TIMESTAMP WITH TIME ZONE
+);
+
+-- the relay only ever reads the pending rows, retention only the published ones
+CREATE INDEX IF NOT EXISTS idx_events_outbox_pending ON events_outbox (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_events_outbox_published ON events_outbox (published_at) WHERE published_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS events_outbox;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+-- hides the user from every leaderboard, the XP board included
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- per-user numbers behind GET /leaderboards/{metric}, refreshed by the leaderboards.refresh job
+-- weeks and days are the user's local ones, streak_days is the run of training days ending today or yesterday
+-- flagged workouts never count; opt-outs and deleted users are filtered at read time so they take effect at once
+CREATE MATERIALIZED VIEW IF NOT EXISTS leaderboard_stats AS
+WITH tz AS (
+  SELECT u.id AS user_id, COALESCE(p.timezone, 'UTC') AS name
+  FROM users u
+  LEFT JOIN user_profiles p ON p.user_id = u.id
+  WHERE u.deleted_at IS NULL
+),
+week AS (
+  SELECT tz.user_id, COALESCE(SUM(w.duration_minutes), 0)::int AS minutes, COUNT(w.id)::int AS workouts
+  FROM tz
+  LEFT JOIN workouts w ON w.user_id = tz.user_id AND NOT w.flagged
+    AND (w.performed_at AT TIME ZONE tz.name) >= DATE_TRUNC('week', CURRENT_TIMESTAMP AT TIME ZONE tz.name)
+  GROUP BY tz.user_id
+),
+days AS (
+  SELECT DISTINCT w.user_id, (w.performed_at AT TIME ZONE tz.name)::date AS day
+  FROM workouts w
+  INNER JOIN tz ON tz.user_id = w.user_id
+  WHERE NOT w.flagged AND w.performed_at <= CURRENT_TIMESTAMP
+),
+runs AS (
+  SELECT user_id, MAX(day) AS last_day, COUNT(*)::int AS length
+  FROM (SELECT user_id, day, day - (ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY day))::int AS grp FROM days) d
+  GROUP BY user_id, grp
+)
+SELECT week.user_id, week.minutes AS weekly_minutes, week.workouts AS weekly_workouts,
+       COALESCE(MAX(runs.length) FILTER (WHERE runs.last_day >= (CURRENT_TIMESTAMP AT TIME ZONE tz.name)::date - 1), 0) AS streak_days,
+       CURRENT_TIMESTAMP AS refreshed_at
+FROM week
+INNER JOIN tz ON tz.user_id = week.user_id
+LEFT JOIN runs ON runs.user_id = week.user_id
+GROUP BY week.user_id, week.minutes, week.workouts, tz.name;
+
+-- REFRESH ... CONCURRENTLY needs a unique index, reads keep working while the job runs
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leaderboard_stats_user ON leaderboard_stats (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP MATERIALIZED VIEW IF EXISTS leaderboard_stats;
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS leaderboard_opt_out;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user-created, time-boxed competitions between the users who join; metrics are the seasonal event ones
+CREATE TABLE IF NOT EXISTS challenges (
+  id BIGSERIAL PRIMARY KEY,
+  title VARCHAR(100) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  metric TEXT NOT NULL CHECK (metric IN ('workouts', 'duration_minutes', 'calories', 'volume_kg')),
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  closed_at TIMESTAMP WITH TIME ZONE,
+  created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT challenge_window CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenges_open ON challenges (ends_at) WHERE closed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS challenge_participants (
+  challenge_id BIGINT NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (challenge_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenge_participants_user ON challenge_participants (user_id);
+
+-- final standings frozen when the challenge closes, every participant gets a row, rank 1 with a score wins
+CREATE TABLE IF NOT EXISTS challenge_results (
+  challenge_id BIGINT NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  rank INTEGER NOT NULL,
+  score DOUBLE PRECISION NOT NULL,
+  PRIMARY KEY (challenge_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE challenge_results;
+DROP TABLE challenge_participants;
+DROP TABLE challenges;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- push tokens of the user's phones; a token belongs to one install, registering it again moves it to the new user
+CREATE TABLE IF NOT EXISTS devices (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  platform TEXT NOT NULL CHECK (platform IN ('fcm', 'apns')),
+  token TEXT NOT NULL UNIQUE,
+  name VARCHAR(100) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user ON devices (user_id);
+
+-- per-category push switches, no row means everything is on
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  comments BOOLEAN NOT NULL DEFAULT TRUE,
+  followers BOOLEAN NOT NULL DEFAULT TRUE,
+  challenges BOOLEAN NOT NULL DEFAULT TRUE,
+  reminders BOOLEAN NOT NULL DEFAULT TRUE,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_preferences;
+DROP TABLE devices;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- in-app inbox, one row per notification the user chose to get in the app
+CREATE TABLE IF NOT EXISTS notifications (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  category TEXT NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL DEFAULT '',
+  data JSONB NOT NULL DEFAULT '{}',
+  read_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+
+-- achievements become a category, and every category now goes out on the channels switched on here
+ALTER TABLE notification_preferences
+  ADD COLUMN IF NOT EXISTS achievements BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD COLUMN IF NOT EXISTS email BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS push BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD COLUMN IF NOT EXISTS in_app BOOLEAN NOT NULL DEFAULT TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notification_preferences
+  DROP COLUMN IF EXISTS in_app,
+  DROP COLUMN IF EXISTS push,
+  DROP COLUMN IF EXISTS email,
+  DROP COLUMN IF EXISTS achievements;
+DROP TABLE notifications;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the weekly summary email is on unless the user switches it off
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS weekly_digest BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- one row per digest sent, week is the local Monday the summarized week started on
+CREATE TABLE IF NOT EXISTS digest_deliveries (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  week DATE NOT NULL,
+  sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, week)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE digest_deliveries;
+ALTER TABLE notification_preferences DROP COLUMN IF EXISTS weekly_digest;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- multi-week training programs: program -> weeks -> days -> template workouts to repeat on that day
+CREATE TABLE IF NOT EXISTS programs (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title VARCHAR(100) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'public')),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_programs_user ON programs (user_id);
+
+CREATE TABLE IF NOT EXISTS program_weeks (
+  id BIGSERIAL PRIMARY KEY,
+  program_id BIGINT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+  week_number INTEGER NOT NULL CHECK (week_number >= 1),
+  notes TEXT NOT NULL DEFAULT '',
+  UNIQUE (program_id, week_number)
+);
+
+-- day is 1-7 within the week, counted from the day an enrollment starts rather than Monday
+CREATE TABLE IF NOT EXISTS program_days (
+  id BIGSERIAL PRIMARY KEY,
+  week_id BIGINT NOT NULL REFERENCES program_weeks(id) ON DELETE CASCADE,
+  day INTEGER NOT NULL CHECK (day BETWEEN 1 AND 7),
+  title VARCHAR(255) NOT NULL DEFAULT '',
+  UNIQUE (week_id, day)
+);
+
+-- a deleted template workout just drops out of the day
+CREATE TABLE IF NOT EXISTS program_day_templates (
+  day_id BIGINT NOT NULL REFERENCES program_days(id) ON DELETE CASCADE,
+  position INTEGER NOT NULL,
+  workout_id BIGINT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+  PRIMARY KEY (day_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS program_enrollments (
+  id BIGSERIAL PRIMARY KEY,
+  program_id BIGINT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  starts_on DATE NOT NULL,
+  time_of_day TEXT NOT NULL, -- local wall clock of every planned session, HH:MM
+  timezone TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (program_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_enrollments_user ON program_enrollments (user_id);
+
+-- every program day an enrollment planned is a one-off schedule; a deleted program leaves them as plain schedules
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS program_enrollment_id BIGINT REFERENCES program_enrollments(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_schedules_program_enrollment ON schedules (program_enrollment_id) WHERE program_enrollment_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_schedules_program_enrollment;
+ALTER TABLE schedules DROP COLUMN IF EXISTS program_enrollment_id;
+DROP TABLE program_enrollments;
+DROP TABLE program_day_templates;
+DROP TABLE program_days;
+DROP TABLE program_weeks;
+DROP TABLE programs;
+-- +goose StatementEnd
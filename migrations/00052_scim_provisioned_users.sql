@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- accounts an org's SCIM created, only those can be linked again by the same org after being removed
+CREATE TABLE IF NOT EXISTS scim_provisioned_users (
+  org_id BIGINT NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (org_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE scim_provisioned_users;
+-- +goose StatementEnd
@@ -0,0 +1,77 @@
+-- +goose Up
+-- +goose StatementBegin
+-- change log behind the warehouse sync: one row per synced row, moved to a fresh seq on every change, deleted rows keep theirs as a tombstone
+-- tx is the writing transaction; the syncer only reads changes of transactions older than every running one,
+-- so a long transaction committing late can't land behind the cursor the way (updated_at, id) positions did
+CREATE TABLE IF NOT EXISTS warehouse_changes (
+  seq BIGSERIAL PRIMARY KEY,
+  tx BIGINT NOT NULL DEFAULT txid_current(),
+  table_name TEXT NOT NULL,
+  row_id BIGINT NOT NULL,
+  deleted BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouse_changes_row ON warehouse_changes (table_name, row_id);
+CREATE INDEX IF NOT EXISTS idx_warehouse_changes_cursor ON warehouse_changes (table_name, tx, seq);
+
+-- a row's changes are serialized by its row lock, so a later change of the same row always gets a higher seq
+CREATE OR REPLACE FUNCTION warehouse_row_changed() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    DELETE FROM warehouse_changes WHERE table_name = TG_TABLE_NAME AND row_id = OLD.id;
+    INSERT INTO warehouse_changes (table_name, row_id, deleted) VALUES (TG_TABLE_NAME, OLD.id, TRUE);
+  ELSE
+    DELETE FROM warehouse_changes WHERE table_name = TG_TABLE_NAME AND row_id = NEW.id;
+    INSERT INTO warehouse_changes (table_name, row_id) VALUES (TG_TABLE_NAME, NEW.id);
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER workouts_warehouse_change
+AFTER INSERT OR UPDATE OR DELETE ON workouts
+FOR EACH ROW EXECUTE FUNCTION warehouse_row_changed();
+
+CREATE TRIGGER workout_entries_warehouse_change
+AFTER INSERT OR UPDATE OR DELETE ON workout_entries
+FOR EACH ROW EXECUTE FUNCTION warehouse_row_changed();
+
+CREATE TRIGGER user_weights_warehouse_change
+AFTER INSERT OR UPDATE OR DELETE ON user_weights
+FOR EACH ROW EXECUTE FUNCTION warehouse_row_changed();
+
+CREATE TRIGGER experiment_exposures_warehouse_change
+AFTER INSERT OR UPDATE OR DELETE ON experiment_exposures
+FOR EACH ROW EXECUTE FUNCTION warehouse_row_changed();
+
+-- rows from before the log existed, the first sync picks them up
+INSERT INTO warehouse_changes (table_name, row_id) SELECT 'workouts', id FROM workouts ORDER BY id;
+INSERT INTO warehouse_changes (table_name, row_id) SELECT 'workout_entries', id FROM workout_entries ORDER BY id;
+INSERT INTO warehouse_changes (table_name, row_id) SELECT 'user_weights', id FROM user_weights ORDER BY id;
+INSERT INTO warehouse_changes (table_name, row_id) SELECT 'experiment_exposures', id FROM experiment_exposures ORDER BY id;
+
+-- the old cursors were (timestamp, id) positions in the tables themselves, every stream starts over on the log
+-- warehouse tables created before this keep the row timestamp as their ReplacingMergeTree version, drop them so they are recreated on change_seq
+DELETE FROM warehouse_sync_cursors;
+ALTER TABLE warehouse_sync_cursors
+  DROP COLUMN cursor_at,
+  DROP COLUMN cursor_id,
+  ADD COLUMN cursor_tx BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN cursor_seq BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM warehouse_sync_cursors;
+ALTER TABLE warehouse_sync_cursors
+  DROP COLUMN cursor_tx,
+  DROP COLUMN cursor_seq,
+  ADD COLUMN cursor_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ADD COLUMN cursor_id BIGINT NOT NULL DEFAULT 0;
+DROP TRIGGER IF EXISTS experiment_exposures_warehouse_change ON experiment_exposures;
+DROP TRIGGER IF EXISTS user_weights_warehouse_change ON user_weights;
+DROP TRIGGER IF EXISTS workout_entries_warehouse_change ON workout_entries;
+DROP TRIGGER IF EXISTS workouts_warehouse_change ON workouts;
+DROP FUNCTION IF EXISTS warehouse_row_changed();
+DROP TABLE IF EXISTS warehouse_changes;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+package web
+
+import (
+	"embed"
+)
+
+// Dist --> the compiled frontend, copy the build output into web/dist before `go build`
+//
+//go:embed all:dist
+var Dist embed.FS